@@ -70,8 +70,15 @@ func provideCleanup(
 	schedulerSnapshot *service.SchedulerSnapshotService,
 	tokenRefresh *service.TokenRefreshService,
 	accountExpiry *service.AccountExpiryService,
+	accountProbe *service.AccountProbeService,
+	proxyMonitor *service.ProxyMonitorService,
+	proxyImport *service.ProxyImportService,
 	subscriptionExpiry *service.SubscriptionExpiryService,
+	userNotification *service.UserNotificationService,
 	usageCleanup *service.UsageCleanupService,
+	usageExport *service.UsageExportService,
+	usagePartition *service.UsagePartitionService,
+	usageWrite *service.UsageWriteService,
 	pricing *service.PricingService,
 	emailQueue *service.EmailQueueService,
 	billingCache *service.BillingCacheService,
@@ -131,6 +138,24 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"UsageExportService", func() error {
+				if usageExport != nil {
+					usageExport.Stop()
+				}
+				return nil
+			}},
+			{"UsagePartitionService", func() error {
+				if usagePartition != nil {
+					usagePartition.Stop()
+				}
+				return nil
+			}},
+			{"UsageWriteService", func() error {
+				if usageWrite != nil {
+					usageWrite.Stop()
+				}
+				return nil
+			}},
 			{"TokenRefreshService", func() error {
 				tokenRefresh.Stop()
 				return nil
@@ -139,10 +164,26 @@ func provideCleanup(
 				accountExpiry.Stop()
 				return nil
 			}},
+			{"AccountProbeService", func() error {
+				accountProbe.Stop()
+				return nil
+			}},
+			{"ProxyMonitorService", func() error {
+				proxyMonitor.Stop()
+				return nil
+			}},
+			{"ProxyImportService", func() error {
+				proxyImport.Stop()
+				return nil
+			}},
 			{"SubscriptionExpiryService", func() error {
 				subscriptionExpiry.Stop()
 				return nil
 			}},
+			{"UserNotificationService", func() error {
+				userNotification.Stop()
+				return nil
+			}},
 			{"PricingService", func() error {
 				pricing.Stop()
 				return nil
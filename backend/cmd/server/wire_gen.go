@@ -56,40 +56,50 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	promoCodeRepository := repository.NewPromoCodeRepository(client)
 	billingCache := repository.NewBillingCache(redisClient)
 	userSubscriptionRepository := repository.NewUserSubscriptionRepository(client)
-	billingCacheService := service.NewBillingCacheService(billingCache, userRepository, userSubscriptionRepository, configConfig)
+	usageLogRepository := repository.NewUsageLogRepository(client, db)
+	organizationRepository := repository.NewOrganizationRepository(db)
+	referralRepository := repository.NewReferralRepository(db)
 	apiKeyRepository := repository.NewAPIKeyRepository(client)
 	groupRepository := repository.NewGroupRepository(client, db)
 	userGroupRateRepository := repository.NewUserGroupRateRepository(db)
 	apiKeyCache := repository.NewAPIKeyCache(redisClient)
-	apiKeyService := service.NewAPIKeyService(apiKeyRepository, userRepository, groupRepository, userSubscriptionRepository, userGroupRateRepository, apiKeyCache, configConfig)
+	apiKeyService := service.ProvideAPIKeyService(apiKeyRepository, userRepository, groupRepository, userSubscriptionRepository, userGroupRateRepository, apiKeyCache, organizationRepository, configConfig)
 	apiKeyAuthCacheInvalidator := service.ProvideAPIKeyAuthCacheInvalidator(apiKeyService)
+	referralService := service.NewReferralService(referralRepository, settingRepository, billingCache, apiKeyAuthCacheInvalidator)
+	billingCacheService := service.ProvideBillingCacheService(billingCache, userRepository, userSubscriptionRepository, usageLogRepository, organizationRepository, referralService, configConfig)
 	promoService := service.NewPromoService(promoCodeRepository, userRepository, billingCacheService, client, apiKeyAuthCacheInvalidator)
-	authService := service.NewAuthService(userRepository, redeemCodeRepository, refreshTokenCache, configConfig, settingService, emailService, turnstileService, emailQueueService, promoService)
+	userSessionRepository := repository.NewUserSessionRepository(db)
+	passkeyRepository := repository.NewPasskeyRepository(db)
+	passkeyCache := repository.NewPasskeyCache(redisClient)
+	passkeyService := service.NewPasskeyService(passkeyRepository, passkeyCache, settingRepository, userRepository)
+	loginEventRepository := repository.NewLoginEventRepository(db)
+	loginSecurityCache := repository.NewLoginSecurityCache(redisClient)
+	loginSecurityService := service.NewLoginSecurityService(loginEventRepository, loginSecurityCache, settingRepository)
+	authService := service.ProvideAuthService(userRepository, redeemCodeRepository, refreshTokenCache, configConfig, settingService, emailService, turnstileService, emailQueueService, promoService, userSessionRepository, passkeyService, loginSecurityService)
 	userService := service.NewUserService(userRepository, apiKeyAuthCacheInvalidator)
 	subscriptionService := service.NewSubscriptionService(groupRepository, userSubscriptionRepository, billingCacheService)
 	redeemCache := repository.NewRedeemCache(redisClient)
-	redeemService := service.NewRedeemService(redeemCodeRepository, userRepository, subscriptionService, redeemCache, billingCacheService, client, apiKeyAuthCacheInvalidator)
+	redeemService := service.ProvideRedeemService(redeemCodeRepository, userRepository, subscriptionService, redeemCache, billingCacheService, client, apiKeyAuthCacheInvalidator, referralService)
 	secretEncryptor, err := repository.NewAESEncryptor(configConfig)
 	if err != nil {
 		return nil, err
 	}
 	totpCache := repository.NewTotpCache(redisClient)
 	totpService := service.NewTotpService(userRepository, secretEncryptor, totpCache, settingService, emailService, emailQueueService)
-	authHandler := handler.NewAuthHandler(configConfig, authService, userService, settingService, promoService, redeemService, totpService)
+	authHandler := handler.NewAuthHandler(configConfig, authService, userService, settingService, promoService, redeemService, totpService, passkeyService, loginSecurityService, referralService)
 	userHandler := handler.NewUserHandler(userService)
-	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
-	usageLogRepository := repository.NewUsageLogRepository(client, db)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, billingCacheService)
 	usageService := service.NewUsageService(usageLogRepository, userRepository, client, apiKeyAuthCacheInvalidator)
-	usageHandler := handler.NewUsageHandler(usageService, apiKeyService)
+	dashboardAggregationRepository := repository.NewDashboardAggregationRepository(db)
+	dashboardStatsCache := repository.NewDashboardCache(redisClient, configConfig)
+	dashboardService := service.NewDashboardService(usageLogRepository, dashboardAggregationRepository, dashboardStatsCache, configConfig)
+	usageHandler := handler.NewUsageHandler(usageService, apiKeyService, dashboardService)
 	redeemHandler := handler.NewRedeemHandler(redeemService)
 	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
 	announcementRepository := repository.NewAnnouncementRepository(client)
 	announcementReadRepository := repository.NewAnnouncementReadRepository(client)
 	announcementService := service.NewAnnouncementService(announcementRepository, announcementReadRepository, userRepository, userSubscriptionRepository)
 	announcementHandler := handler.NewAnnouncementHandler(announcementService)
-	dashboardAggregationRepository := repository.NewDashboardAggregationRepository(db)
-	dashboardStatsCache := repository.NewDashboardCache(redisClient, configConfig)
-	dashboardService := service.NewDashboardService(usageLogRepository, dashboardAggregationRepository, dashboardStatsCache, configConfig)
 	timingWheelService, err := service.ProvideTimingWheelService()
 	if err != nil {
 		return nil, err
@@ -101,9 +111,10 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	proxyRepository := repository.NewProxyRepository(client, db)
 	proxyExitInfoProber := repository.NewProxyExitInfoProber(configConfig)
 	proxyLatencyCache := repository.NewProxyLatencyCache(redisClient)
-	adminService := service.NewAdminService(userRepository, groupRepository, accountRepository, proxyRepository, apiKeyRepository, redeemCodeRepository, userGroupRateRepository, billingCacheService, proxyExitInfoProber, proxyLatencyCache, apiKeyAuthCacheInvalidator)
+	adminService := service.NewAdminService(userRepository, groupRepository, accountRepository, proxyRepository, apiKeyRepository, redeemCodeRepository, userGroupRateRepository, billingCacheService, proxyExitInfoProber, proxyLatencyCache, apiKeyAuthCacheInvalidator, referralService)
 	concurrencyCache := repository.ProvideConcurrencyCache(redisClient, configConfig)
-	concurrencyService := service.ProvideConcurrencyService(concurrencyCache, accountRepository, configConfig)
+	fairShareCache := repository.ProvideFairShareCache(redisClient, configConfig)
+	concurrencyService := service.ProvideConcurrencyService(concurrencyCache, fairShareCache, accountRepository, configConfig)
 	adminUserHandler := admin.NewUserHandler(adminService, concurrencyService)
 	groupHandler := admin.NewGroupHandler(adminService)
 	claudeOAuthClient := repository.NewClaudeOAuthClient()
@@ -120,7 +131,9 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	geminiTokenCache := repository.NewGeminiTokenCache(redisClient)
 	compositeTokenCacheInvalidator := service.NewCompositeTokenCacheInvalidator(geminiTokenCache)
 	rateLimitService := service.ProvideRateLimitService(accountRepository, usageLogRepository, configConfig, geminiQuotaService, tempUnschedCache, timeoutCounterCache, settingService, compositeTokenCacheInvalidator)
-	httpUpstream := repository.NewHTTPUpstream(configConfig)
+	proxyPoolRepository := repository.NewProxyPoolRepository(db)
+	proxyPoolService := service.NewProxyPoolService(proxyPoolRepository, proxyRepository, proxyLatencyCache)
+	httpUpstream := repository.ProvideHTTPUpstream(configConfig, proxyPoolService)
 	claudeUsageFetcher := repository.NewClaudeUsageFetcher(httpUpstream)
 	antigravityQuotaFetcher := service.NewAntigravityQuotaFetcher(proxyRepository)
 	usageCache := service.NewUsageCache()
@@ -155,9 +168,13 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	deferredService := service.ProvideDeferredService(accountRepository, timingWheelService)
 	claudeTokenProvider := service.NewClaudeTokenProvider(accountRepository, geminiTokenCache, oAuthService)
 	digestSessionStore := service.NewDigestSessionStore()
-	gatewayService := service.NewGatewayService(accountRepository, groupRepository, usageLogRepository, userRepository, userSubscriptionRepository, userGroupRateRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, identityService, httpUpstream, deferredService, claudeTokenProvider, sessionLimitCache, digestSessionStore)
+	accountLatencyCache := repository.NewAccountLatencyCache(redisClient)
+	usageWriteQueue := repository.NewUsageWriteQueue(redisClient)
+	usageLogBatchWriter := repository.NewUsageLogBatchWriter(client, db)
+	usageWriteService := service.ProvideUsageWriteService(client, usageWriteQueue, usageLogBatchWriter, userRepository, userSubscriptionRepository, billingCacheService, apiKeyService, configConfig)
+	gatewayService := service.ProvideGatewayService(accountRepository, groupRepository, usageLogRepository, userRepository, userSubscriptionRepository, userGroupRateRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, identityService, httpUpstream, deferredService, claudeTokenProvider, sessionLimitCache, digestSessionStore, accountLatencyCache, usageWriteService)
 	openAITokenProvider := service.NewOpenAITokenProvider(accountRepository, geminiTokenCache, openAIOAuthService)
-	openAIGatewayService := service.NewOpenAIGatewayService(accountRepository, usageLogRepository, userRepository, userSubscriptionRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, httpUpstream, deferredService, openAITokenProvider)
+	openAIGatewayService := service.ProvideOpenAIGatewayService(accountRepository, usageLogRepository, userRepository, userSubscriptionRepository, gatewayCache, configConfig, schedulerSnapshotService, concurrencyService, billingService, rateLimitService, billingCacheService, httpUpstream, deferredService, openAITokenProvider, usageWriteService, accountLatencyCache)
 	geminiMessagesCompatService := service.NewGeminiMessagesCompatService(accountRepository, groupRepository, gatewayCache, schedulerSnapshotService, geminiTokenProvider, rateLimitService, httpUpstream, antigravityGatewayService, configConfig)
 	opsService := service.NewOpsService(opsRepository, settingRepository, configConfig, accountRepository, userRepository, concurrencyService, gatewayService, openAIGatewayService, geminiMessagesCompatService, antigravityGatewayService)
 	settingHandler := admin.NewSettingHandler(settingService, emailService, turnstileService, opsService)
@@ -179,26 +196,87 @@ func initializeApplication(buildInfo handler.BuildInfo) (*Application, error) {
 	errorPassthroughCache := repository.NewErrorPassthroughCache(redisClient)
 	errorPassthroughService := service.NewErrorPassthroughService(errorPassthroughRepository, errorPassthroughCache)
 	errorPassthroughHandler := admin.NewErrorPassthroughHandler(errorPassthroughService)
-	adminHandlers := handler.ProvideAdminHandlers(dashboardHandler, adminUserHandler, groupHandler, accountHandler, adminAnnouncementHandler, oAuthHandler, openAIOAuthHandler, geminiOAuthHandler, antigravityOAuthHandler, proxyHandler, adminRedeemHandler, promoHandler, settingHandler, opsHandler, systemHandler, adminSubscriptionHandler, adminUsageHandler, userAttributeHandler, errorPassthroughHandler)
+	accountProbeRepository := repository.NewAccountProbeRepository(db)
+	accountProbeService := service.ProvideAccountProbeService(accountProbeRepository, accountRepository, settingRepository, accountTestService, tempUnschedCache, opsRepository, db, redisClient, configConfig)
+	accountProbeHandler := admin.NewAccountProbeHandler(accountProbeService)
+	schedulingHandler := admin.NewSchedulingHandler(gatewayService)
+	proxyPoolHandler := admin.NewProxyPoolHandler(proxyPoolService)
+	proxyHealthRepository := repository.NewProxyHealthRepository(db)
+	proxyMonitorService := service.ProvideProxyMonitorService(proxyHealthRepository, proxyRepository, settingRepository, proxyExitInfoProber, proxyLatencyCache, opsRepository, db, redisClient, configConfig)
+	proxyMonitorHandler := admin.NewProxyMonitorHandler(proxyMonitorService)
+	proxySubscriptionRepository := repository.NewProxySubscriptionRepository(db)
+	proxySubscriptionFetcher := repository.NewProxySubscriptionFetcher(configConfig)
+	proxyImportService := service.ProvideProxyImportService(proxyRepository, proxySubscriptionRepository, proxySubscriptionFetcher, db, redisClient, configConfig)
+	proxyImportHandler := admin.NewProxyImportHandler(proxyImportService)
+	adminRoleRepository := repository.NewAdminRoleRepository(db)
+	adminRoleService := service.NewAdminRoleService(adminRoleRepository, userRepository)
+	adminRoleHandler := admin.NewAdminRoleHandler(adminRoleService)
+	adminAPIKeyRepository := repository.NewAdminAPIKeyRepository(db)
+	adminAPIKeyService := service.NewAdminAPIKeyService(adminAPIKeyRepository, userRepository, adminRoleService)
+	adminAPIKeyHandler := admin.NewAdminAPIKeyHandler(adminAPIKeyService)
+	adminAuditLogRepository := repository.NewAdminAuditLogRepository(db)
+	adminAuditService := service.NewAdminAuditService(adminAuditLogRepository, adminService, settingService, promoService, opsService, errorPassthroughService, adminAPIKeyService)
+	adminAuditHandler := admin.NewAdminAuditHandler(adminAuditService)
+	userOAuthIdentityRepository := repository.NewUserOAuthIdentityRepository(db)
+	oAuthProviderService := service.NewOAuthProviderService(settingRepository, userOAuthIdentityRepository, userRepository, groupRepository, subscriptionService, authService)
+	oAuthProviderHandler := admin.NewOAuthProviderHandler(oAuthProviderService)
+	passkeySettingsHandler := admin.NewPasskeySettingsHandler(passkeyService)
+	loginSecurityHandler := admin.NewLoginSecurityHandler(loginSecurityService, authService)
+	organizationService := service.NewOrganizationService(organizationRepository, userRepository, usageLogRepository, apiKeyService, billingCacheService)
+	organizationHandler := admin.NewOrganizationHandler(organizationService)
+	referralHandler := admin.NewReferralHandler(referralService)
+	statementRepository := repository.NewStatementRepository(db)
+	statementService := service.NewStatementService(statementRepository, dashboardAggregationRepository, organizationService, emailQueueService, settingService)
+	statementHandler := admin.NewStatementHandler(statementService)
+	usageExportRepository := repository.NewUsageExportRepository(db)
+	usageExportService := service.ProvideUsageExportService(usageExportRepository, timingWheelService, configConfig)
+	usageExportHandler := admin.NewUsageExportHandler(usageExportService)
+	usagePartitionRepository := repository.NewUsagePartitionRepository(db)
+	usageLogArchiveStore, err := repository.NewUsageLogArchiveStore(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	usagePartitionService := service.ProvideUsagePartitionService(usagePartitionRepository, usageLogArchiveStore, timingWheelService, configConfig)
+	usagePartitionHandler := admin.NewUsagePartitionHandler(usagePartitionService)
+	usageWriteHandler := admin.NewUsageWriteHandler(usageWriteService)
+	adminHandlers := handler.ProvideAdminHandlers(dashboardHandler, adminUserHandler, groupHandler, accountHandler, adminAnnouncementHandler, oAuthHandler, openAIOAuthHandler, geminiOAuthHandler, antigravityOAuthHandler, proxyHandler, adminRedeemHandler, promoHandler, settingHandler, opsHandler, systemHandler, adminSubscriptionHandler, adminUsageHandler, userAttributeHandler, errorPassthroughHandler, accountProbeHandler, schedulingHandler, proxyPoolHandler, proxyMonitorHandler, proxyImportHandler, adminRoleHandler, adminAPIKeyHandler, adminAuditHandler, oAuthProviderHandler, passkeySettingsHandler, loginSecurityHandler, organizationHandler, referralHandler, statementHandler, usageExportHandler, usagePartitionHandler, usageWriteHandler)
 	gatewayHandler := handler.NewGatewayHandler(gatewayService, geminiMessagesCompatService, antigravityGatewayService, userService, concurrencyService, billingCacheService, usageService, apiKeyService, errorPassthroughService, configConfig)
 	openAIGatewayHandler := handler.NewOpenAIGatewayHandler(openAIGatewayService, concurrencyService, billingCacheService, apiKeyService, errorPassthroughService, configConfig)
 	handlerSettingHandler := handler.ProvideSettingHandler(settingService, buildInfo)
 	totpHandler := handler.NewTotpHandler(totpService)
-	handlers := handler.ProvideHandlers(authHandler, userHandler, apiKeyHandler, usageHandler, redeemHandler, subscriptionHandler, announcementHandler, adminHandlers, gatewayHandler, openAIGatewayHandler, handlerSettingHandler, totpHandler)
+	handlerOAuthHandler := handler.NewOAuthHandler(oAuthProviderService, loginSecurityService)
+	passkeyHandler := handler.NewPasskeyHandler(passkeyService, totpService, userService)
+	handlerOrganizationHandler := handler.NewOrganizationHandler(organizationService)
+	handlerReferralHandler := handler.NewReferralHandler(referralService)
+	handlerStatementHandler := handler.NewStatementHandler(statementService)
+	userNotificationRepository := repository.NewUserNotificationRepository(db)
+	userNotificationWebhookSender, err := repository.NewUserNotificationWebhookSender(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	userNotificationService := service.ProvideUserNotificationService(userNotificationRepository, userRepository, apiKeyRepository, userSubscriptionRepository, emailQueueService, settingService, userNotificationWebhookSender, db, redisClient, configConfig)
+	userNotificationHandler := handler.NewUserNotificationHandler(userNotificationService)
+	handlerUsageExportHandler := handler.NewUsageExportHandler(usageExportService, apiKeyService)
+	handlers := handler.ProvideHandlers(authHandler, userHandler, apiKeyHandler, usageHandler, redeemHandler, subscriptionHandler, announcementHandler, adminHandlers, gatewayHandler, openAIGatewayHandler, handlerSettingHandler, totpHandler, handlerOAuthHandler, passkeyHandler, handlerOrganizationHandler, handlerReferralHandler, handlerStatementHandler, userNotificationHandler, handlerUsageExportHandler)
 	jwtAuthMiddleware := middleware.NewJWTAuthMiddleware(authService, userService)
-	adminAuthMiddleware := middleware.NewAdminAuthMiddleware(authService, userService, settingService)
+	adminAuthMiddleware := middleware.NewAdminAuthMiddleware(authService, userService, settingService, adminRoleService, adminAPIKeyService, passkeyService)
+	adminAuditMiddleware := middleware.NewAdminAuditMiddleware(adminAuditService)
 	apiKeyAuthMiddleware := middleware.NewAPIKeyAuthMiddleware(apiKeyService, subscriptionService, configConfig)
-	engine := server.ProvideRouter(configConfig, handlers, jwtAuthMiddleware, adminAuthMiddleware, apiKeyAuthMiddleware, apiKeyService, subscriptionService, opsService, settingService, redisClient)
+	idempotencyCache := repository.NewIdempotencyCache(redisClient)
+	idempotencyUsageLookup := repository.NewIdempotencyUsageLookup(client, db)
+	idempotencyService := service.NewIdempotencyService(idempotencyCache, idempotencyUsageLookup, usageWriteService, configConfig)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(idempotencyService)
+	engine := server.ProvideRouter(configConfig, handlers, jwtAuthMiddleware, adminAuthMiddleware, adminAuditMiddleware, apiKeyAuthMiddleware, idempotencyMiddleware, apiKeyService, subscriptionService, opsService, settingService, redisClient)
 	httpServer := server.ProvideHTTPServer(configConfig, engine)
-	opsMetricsCollector := service.ProvideOpsMetricsCollector(opsRepository, settingRepository, accountRepository, concurrencyService, db, redisClient, configConfig)
+	opsMetricsCollector := service.ProvideOpsMetricsCollector(opsRepository, settingRepository, accountRepository, concurrencyService, db, redisClient, configConfig, usageWriteService)
 	opsAggregationService := service.ProvideOpsAggregationService(opsRepository, settingRepository, db, redisClient, configConfig)
-	opsAlertEvaluatorService := service.ProvideOpsAlertEvaluatorService(opsService, opsRepository, emailService, redisClient, configConfig)
+	opsAlertEvaluatorService := service.ProvideOpsAlertEvaluatorService(opsService, opsRepository, emailService, proxyHealthRepository, redisClient, configConfig)
 	opsCleanupService := service.ProvideOpsCleanupService(opsRepository, db, redisClient, configConfig)
 	opsScheduledReportService := service.ProvideOpsScheduledReportService(opsService, userService, emailService, redisClient, configConfig)
 	tokenRefreshService := service.ProvideTokenRefreshService(accountRepository, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService, compositeTokenCacheInvalidator, schedulerCache, configConfig)
 	accountExpiryService := service.ProvideAccountExpiryService(accountRepository)
 	subscriptionExpiryService := service.ProvideSubscriptionExpiryService(userSubscriptionRepository)
-	v := provideCleanup(client, redisClient, opsMetricsCollector, opsAggregationService, opsAlertEvaluatorService, opsCleanupService, opsScheduledReportService, schedulerSnapshotService, tokenRefreshService, accountExpiryService, subscriptionExpiryService, usageCleanupService, pricingService, emailQueueService, billingCacheService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService)
+	v := provideCleanup(client, redisClient, opsMetricsCollector, opsAggregationService, opsAlertEvaluatorService, opsCleanupService, opsScheduledReportService, schedulerSnapshotService, tokenRefreshService, accountExpiryService, accountProbeService, proxyMonitorService, proxyImportService, subscriptionExpiryService, userNotificationService, usageCleanupService, usageExportService, usagePartitionService, usageWriteService, pricingService, emailQueueService, billingCacheService, oAuthService, openAIOAuthService, geminiOAuthService, antigravityOAuthService)
 	application := &Application{
 		Server:  httpServer,
 		Cleanup: v,
@@ -231,8 +309,15 @@ func provideCleanup(
 	schedulerSnapshot *service.SchedulerSnapshotService,
 	tokenRefresh *service.TokenRefreshService,
 	accountExpiry *service.AccountExpiryService,
+	accountProbe *service.AccountProbeService,
+	proxyMonitor *service.ProxyMonitorService,
+	proxyImport *service.ProxyImportService,
 	subscriptionExpiry *service.SubscriptionExpiryService,
+	userNotification *service.UserNotificationService,
 	usageCleanup *service.UsageCleanupService,
+	usageExport *service.UsageExportService,
+	usagePartition *service.UsagePartitionService,
+	usageWrite *service.UsageWriteService,
 	pricing *service.PricingService,
 	emailQueue *service.EmailQueueService,
 	billingCache *service.BillingCacheService,
@@ -291,6 +376,24 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"UsageExportService", func() error {
+				if usageExport != nil {
+					usageExport.Stop()
+				}
+				return nil
+			}},
+			{"UsagePartitionService", func() error {
+				if usagePartition != nil {
+					usagePartition.Stop()
+				}
+				return nil
+			}},
+			{"UsageWriteService", func() error {
+				if usageWrite != nil {
+					usageWrite.Stop()
+				}
+				return nil
+			}},
 			{"TokenRefreshService", func() error {
 				tokenRefresh.Stop()
 				return nil
@@ -299,10 +402,26 @@ func provideCleanup(
 				accountExpiry.Stop()
 				return nil
 			}},
+			{"AccountProbeService", func() error {
+				accountProbe.Stop()
+				return nil
+			}},
+			{"ProxyMonitorService", func() error {
+				proxyMonitor.Stop()
+				return nil
+			}},
+			{"ProxyImportService", func() error {
+				proxyImport.Stop()
+				return nil
+			}},
 			{"SubscriptionExpiryService", func() error {
 				subscriptionExpiry.Stop()
 				return nil
 			}},
+			{"UserNotificationService", func() error {
+				userNotification.Stop()
+				return nil
+			}},
 			{"PricingService", func() error {
 				pricing.Stop()
 				return nil
@@ -35,6 +35,8 @@ const (
 	FieldExtra = "extra"
 	// FieldProxyID holds the string denoting the proxy_id field in the database.
 	FieldProxyID = "proxy_id"
+	// FieldProxyPoolID holds the string denoting the proxy_pool_id field in the database.
+	FieldProxyPoolID = "proxy_pool_id"
 	// FieldConcurrency holds the string denoting the concurrency field in the database.
 	FieldConcurrency = "concurrency"
 	// FieldPriority holds the string denoting the priority field in the database.
@@ -116,6 +118,7 @@ var Columns = []string{
 	FieldCredentials,
 	FieldExtra,
 	FieldProxyID,
+	FieldProxyPoolID,
 	FieldConcurrency,
 	FieldPriority,
 	FieldRateMultiplier,
@@ -239,6 +242,11 @@ func ByProxyID(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldProxyID, opts...).ToFunc()
 }
 
+// ByProxyPoolID orders the results by the proxy_pool_id field.
+func ByProxyPoolID(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldProxyPoolID, opts...).ToFunc()
+}
+
 // ByConcurrency orders the results by the concurrency field.
 func ByConcurrency(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldConcurrency, opts...).ToFunc()
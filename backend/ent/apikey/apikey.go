@@ -41,6 +41,24 @@ const (
 	FieldQuotaUsed = "quota_used"
 	// FieldExpiresAt holds the string denoting the expires_at field in the database.
 	FieldExpiresAt = "expires_at"
+	// FieldDailyLimitUsd holds the string denoting the daily_limit_usd field in the database.
+	FieldDailyLimitUsd = "daily_limit_usd"
+	// FieldWeeklyLimitUsd holds the string denoting the weekly_limit_usd field in the database.
+	FieldWeeklyLimitUsd = "weekly_limit_usd"
+	// FieldMonthlyLimitUsd holds the string denoting the monthly_limit_usd field in the database.
+	FieldMonthlyLimitUsd = "monthly_limit_usd"
+	// FieldPreviousKey holds the string denoting the previous_key field in the database.
+	FieldPreviousKey = "previous_key"
+	// FieldPreviousKeyExpiresAt holds the string denoting the previous_key_expires_at field in the database.
+	FieldPreviousKeyExpiresAt = "previous_key_expires_at"
+	// FieldKeyGeneration holds the string denoting the key_generation field in the database.
+	FieldKeyGeneration = "key_generation"
+	// FieldRotatedAt holds the string denoting the rotated_at field in the database.
+	FieldRotatedAt = "rotated_at"
+	// FieldAllowedTagKeys holds the string denoting the allowed_tag_keys field in the database.
+	FieldAllowedTagKeys = "allowed_tag_keys"
+	// FieldOrganizationID holds the string denoting the organization_id field in the database.
+	FieldOrganizationID = "organization_id"
 	// EdgeUser holds the string denoting the user edge name in mutations.
 	EdgeUser = "user"
 	// EdgeGroup holds the string denoting the group edge name in mutations.
@@ -88,6 +106,15 @@ var Columns = []string{
 	FieldQuota,
 	FieldQuotaUsed,
 	FieldExpiresAt,
+	FieldDailyLimitUsd,
+	FieldWeeklyLimitUsd,
+	FieldMonthlyLimitUsd,
+	FieldPreviousKey,
+	FieldPreviousKeyExpiresAt,
+	FieldKeyGeneration,
+	FieldRotatedAt,
+	FieldAllowedTagKeys,
+	FieldOrganizationID,
 }
 
 // ValidColumn reports if the column name is valid (part of the table columns).
@@ -126,6 +153,10 @@ var (
 	DefaultQuota float64
 	// DefaultQuotaUsed holds the default value on creation for the "quota_used" field.
 	DefaultQuotaUsed float64
+	// PreviousKeyValidator is a validator for the "previous_key" field. It is called by the builders before save.
+	PreviousKeyValidator func(string) error
+	// DefaultKeyGeneration holds the default value on creation for the "key_generation" field.
+	DefaultKeyGeneration int
 )
 
 // OrderOption defines the ordering options for the APIKey queries.
@@ -191,6 +222,46 @@ func ByExpiresAt(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldExpiresAt, opts...).ToFunc()
 }
 
+// ByDailyLimitUsd orders the results by the daily_limit_usd field.
+func ByDailyLimitUsd(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldDailyLimitUsd, opts...).ToFunc()
+}
+
+// ByWeeklyLimitUsd orders the results by the weekly_limit_usd field.
+func ByWeeklyLimitUsd(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldWeeklyLimitUsd, opts...).ToFunc()
+}
+
+// ByMonthlyLimitUsd orders the results by the monthly_limit_usd field.
+func ByMonthlyLimitUsd(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldMonthlyLimitUsd, opts...).ToFunc()
+}
+
+// ByPreviousKey orders the results by the previous_key field.
+func ByPreviousKey(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldPreviousKey, opts...).ToFunc()
+}
+
+// ByPreviousKeyExpiresAt orders the results by the previous_key_expires_at field.
+func ByPreviousKeyExpiresAt(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldPreviousKeyExpiresAt, opts...).ToFunc()
+}
+
+// ByKeyGeneration orders the results by the key_generation field.
+func ByKeyGeneration(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldKeyGeneration, opts...).ToFunc()
+}
+
+// ByRotatedAt orders the results by the rotated_at field.
+func ByRotatedAt(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldRotatedAt, opts...).ToFunc()
+}
+
+// ByOrganizationID orders the results by the organization_id field.
+func ByOrganizationID(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldOrganizationID, opts...).ToFunc()
+}
+
 // ByUserField orders the results by user field.
 func ByUserField(field string, opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
@@ -110,6 +110,46 @@ func ExpiresAt(v time.Time) predicate.APIKey {
 	return predicate.APIKey(sql.FieldEQ(FieldExpiresAt, v))
 }
 
+// DailyLimitUsd applies equality check predicate on the "daily_limit_usd" field. It's identical to DailyLimitUsdEQ.
+func DailyLimitUsd(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldDailyLimitUsd, v))
+}
+
+// WeeklyLimitUsd applies equality check predicate on the "weekly_limit_usd" field. It's identical to WeeklyLimitUsdEQ.
+func WeeklyLimitUsd(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldWeeklyLimitUsd, v))
+}
+
+// MonthlyLimitUsd applies equality check predicate on the "monthly_limit_usd" field. It's identical to MonthlyLimitUsdEQ.
+func MonthlyLimitUsd(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldMonthlyLimitUsd, v))
+}
+
+// PreviousKey applies equality check predicate on the "previous_key" field. It's identical to PreviousKeyEQ.
+func PreviousKey(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldPreviousKey, v))
+}
+
+// PreviousKeyExpiresAt applies equality check predicate on the "previous_key_expires_at" field. It's identical to PreviousKeyExpiresAtEQ.
+func PreviousKeyExpiresAt(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldPreviousKeyExpiresAt, v))
+}
+
+// KeyGeneration applies equality check predicate on the "key_generation" field. It's identical to KeyGenerationEQ.
+func KeyGeneration(v int) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldKeyGeneration, v))
+}
+
+// RotatedAt applies equality check predicate on the "rotated_at" field. It's identical to RotatedAtEQ.
+func RotatedAt(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldRotatedAt, v))
+}
+
+// OrganizationID applies equality check predicate on the "organization_id" field. It's identical to OrganizationIDEQ.
+func OrganizationID(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldOrganizationID, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.APIKey {
 	return predicate.APIKey(sql.FieldEQ(FieldCreatedAt, v))
@@ -635,6 +675,431 @@ func ExpiresAtNotNil() predicate.APIKey {
 	return predicate.APIKey(sql.FieldNotNull(FieldExpiresAt))
 }
 
+// DailyLimitUsdEQ applies the EQ predicate on the "daily_limit_usd" field.
+func DailyLimitUsdEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdNEQ applies the NEQ predicate on the "daily_limit_usd" field.
+func DailyLimitUsdNEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdIn applies the In predicate on the "daily_limit_usd" field.
+func DailyLimitUsdIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldDailyLimitUsd, vs...))
+}
+
+// DailyLimitUsdNotIn applies the NotIn predicate on the "daily_limit_usd" field.
+func DailyLimitUsdNotIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldDailyLimitUsd, vs...))
+}
+
+// DailyLimitUsdGT applies the GT predicate on the "daily_limit_usd" field.
+func DailyLimitUsdGT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdGTE applies the GTE predicate on the "daily_limit_usd" field.
+func DailyLimitUsdGTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdLT applies the LT predicate on the "daily_limit_usd" field.
+func DailyLimitUsdLT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdLTE applies the LTE predicate on the "daily_limit_usd" field.
+func DailyLimitUsdLTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdIsNil applies the IsNil predicate on the "daily_limit_usd" field.
+func DailyLimitUsdIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldDailyLimitUsd))
+}
+
+// DailyLimitUsdNotNil applies the NotNil predicate on the "daily_limit_usd" field.
+func DailyLimitUsdNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldDailyLimitUsd))
+}
+
+// WeeklyLimitUsdEQ applies the EQ predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldWeeklyLimitUsd, v))
+}
+
+// WeeklyLimitUsdNEQ applies the NEQ predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdNEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldWeeklyLimitUsd, v))
+}
+
+// WeeklyLimitUsdIn applies the In predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldWeeklyLimitUsd, vs...))
+}
+
+// WeeklyLimitUsdNotIn applies the NotIn predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdNotIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldWeeklyLimitUsd, vs...))
+}
+
+// WeeklyLimitUsdGT applies the GT predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdGT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldWeeklyLimitUsd, v))
+}
+
+// WeeklyLimitUsdGTE applies the GTE predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdGTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldWeeklyLimitUsd, v))
+}
+
+// WeeklyLimitUsdLT applies the LT predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdLT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldWeeklyLimitUsd, v))
+}
+
+// WeeklyLimitUsdLTE applies the LTE predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdLTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldWeeklyLimitUsd, v))
+}
+
+// WeeklyLimitUsdIsNil applies the IsNil predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldWeeklyLimitUsd))
+}
+
+// WeeklyLimitUsdNotNil applies the NotNil predicate on the "weekly_limit_usd" field.
+func WeeklyLimitUsdNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldWeeklyLimitUsd))
+}
+
+// MonthlyLimitUsdEQ applies the EQ predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdNEQ applies the NEQ predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdNEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdIn applies the In predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldMonthlyLimitUsd, vs...))
+}
+
+// MonthlyLimitUsdNotIn applies the NotIn predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdNotIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldMonthlyLimitUsd, vs...))
+}
+
+// MonthlyLimitUsdGT applies the GT predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdGT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdGTE applies the GTE predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdGTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdLT applies the LT predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdLT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdLTE applies the LTE predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdLTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdIsNil applies the IsNil predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldMonthlyLimitUsd))
+}
+
+// MonthlyLimitUsdNotNil applies the NotNil predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldMonthlyLimitUsd))
+}
+
+// PreviousKeyEQ applies the EQ predicate on the "previous_key" field.
+func PreviousKeyEQ(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldPreviousKey, v))
+}
+
+// PreviousKeyNEQ applies the NEQ predicate on the "previous_key" field.
+func PreviousKeyNEQ(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldPreviousKey, v))
+}
+
+// PreviousKeyIn applies the In predicate on the "previous_key" field.
+func PreviousKeyIn(vs ...string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldPreviousKey, vs...))
+}
+
+// PreviousKeyNotIn applies the NotIn predicate on the "previous_key" field.
+func PreviousKeyNotIn(vs ...string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldPreviousKey, vs...))
+}
+
+// PreviousKeyGT applies the GT predicate on the "previous_key" field.
+func PreviousKeyGT(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldPreviousKey, v))
+}
+
+// PreviousKeyGTE applies the GTE predicate on the "previous_key" field.
+func PreviousKeyGTE(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldPreviousKey, v))
+}
+
+// PreviousKeyLT applies the LT predicate on the "previous_key" field.
+func PreviousKeyLT(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldPreviousKey, v))
+}
+
+// PreviousKeyLTE applies the LTE predicate on the "previous_key" field.
+func PreviousKeyLTE(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldPreviousKey, v))
+}
+
+// PreviousKeyContains applies the Contains predicate on the "previous_key" field.
+func PreviousKeyContains(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldContains(FieldPreviousKey, v))
+}
+
+// PreviousKeyHasPrefix applies the HasPrefix predicate on the "previous_key" field.
+func PreviousKeyHasPrefix(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldHasPrefix(FieldPreviousKey, v))
+}
+
+// PreviousKeyHasSuffix applies the HasSuffix predicate on the "previous_key" field.
+func PreviousKeyHasSuffix(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldHasSuffix(FieldPreviousKey, v))
+}
+
+// PreviousKeyIsNil applies the IsNil predicate on the "previous_key" field.
+func PreviousKeyIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldPreviousKey))
+}
+
+// PreviousKeyNotNil applies the NotNil predicate on the "previous_key" field.
+func PreviousKeyNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldPreviousKey))
+}
+
+// PreviousKeyEqualFold applies the EqualFold predicate on the "previous_key" field.
+func PreviousKeyEqualFold(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEqualFold(FieldPreviousKey, v))
+}
+
+// PreviousKeyContainsFold applies the ContainsFold predicate on the "previous_key" field.
+func PreviousKeyContainsFold(v string) predicate.APIKey {
+	return predicate.APIKey(sql.FieldContainsFold(FieldPreviousKey, v))
+}
+
+// PreviousKeyExpiresAtEQ applies the EQ predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtEQ(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldPreviousKeyExpiresAt, v))
+}
+
+// PreviousKeyExpiresAtNEQ applies the NEQ predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtNEQ(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldPreviousKeyExpiresAt, v))
+}
+
+// PreviousKeyExpiresAtIn applies the In predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtIn(vs ...time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldPreviousKeyExpiresAt, vs...))
+}
+
+// PreviousKeyExpiresAtNotIn applies the NotIn predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtNotIn(vs ...time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldPreviousKeyExpiresAt, vs...))
+}
+
+// PreviousKeyExpiresAtGT applies the GT predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtGT(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldPreviousKeyExpiresAt, v))
+}
+
+// PreviousKeyExpiresAtGTE applies the GTE predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtGTE(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldPreviousKeyExpiresAt, v))
+}
+
+// PreviousKeyExpiresAtLT applies the LT predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtLT(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldPreviousKeyExpiresAt, v))
+}
+
+// PreviousKeyExpiresAtLTE applies the LTE predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtLTE(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldPreviousKeyExpiresAt, v))
+}
+
+// PreviousKeyExpiresAtIsNil applies the IsNil predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldPreviousKeyExpiresAt))
+}
+
+// PreviousKeyExpiresAtNotNil applies the NotNil predicate on the "previous_key_expires_at" field.
+func PreviousKeyExpiresAtNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldPreviousKeyExpiresAt))
+}
+
+// KeyGenerationEQ applies the EQ predicate on the "key_generation" field.
+func KeyGenerationEQ(v int) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldKeyGeneration, v))
+}
+
+// KeyGenerationNEQ applies the NEQ predicate on the "key_generation" field.
+func KeyGenerationNEQ(v int) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldKeyGeneration, v))
+}
+
+// KeyGenerationIn applies the In predicate on the "key_generation" field.
+func KeyGenerationIn(vs ...int) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldKeyGeneration, vs...))
+}
+
+// KeyGenerationNotIn applies the NotIn predicate on the "key_generation" field.
+func KeyGenerationNotIn(vs ...int) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldKeyGeneration, vs...))
+}
+
+// KeyGenerationGT applies the GT predicate on the "key_generation" field.
+func KeyGenerationGT(v int) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldKeyGeneration, v))
+}
+
+// KeyGenerationGTE applies the GTE predicate on the "key_generation" field.
+func KeyGenerationGTE(v int) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldKeyGeneration, v))
+}
+
+// KeyGenerationLT applies the LT predicate on the "key_generation" field.
+func KeyGenerationLT(v int) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldKeyGeneration, v))
+}
+
+// KeyGenerationLTE applies the LTE predicate on the "key_generation" field.
+func KeyGenerationLTE(v int) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldKeyGeneration, v))
+}
+
+// RotatedAtEQ applies the EQ predicate on the "rotated_at" field.
+func RotatedAtEQ(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldRotatedAt, v))
+}
+
+// RotatedAtNEQ applies the NEQ predicate on the "rotated_at" field.
+func RotatedAtNEQ(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldRotatedAt, v))
+}
+
+// RotatedAtIn applies the In predicate on the "rotated_at" field.
+func RotatedAtIn(vs ...time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldRotatedAt, vs...))
+}
+
+// RotatedAtNotIn applies the NotIn predicate on the "rotated_at" field.
+func RotatedAtNotIn(vs ...time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldRotatedAt, vs...))
+}
+
+// RotatedAtGT applies the GT predicate on the "rotated_at" field.
+func RotatedAtGT(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldRotatedAt, v))
+}
+
+// RotatedAtGTE applies the GTE predicate on the "rotated_at" field.
+func RotatedAtGTE(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldRotatedAt, v))
+}
+
+// RotatedAtLT applies the LT predicate on the "rotated_at" field.
+func RotatedAtLT(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldRotatedAt, v))
+}
+
+// RotatedAtLTE applies the LTE predicate on the "rotated_at" field.
+func RotatedAtLTE(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldRotatedAt, v))
+}
+
+// RotatedAtIsNil applies the IsNil predicate on the "rotated_at" field.
+func RotatedAtIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldRotatedAt))
+}
+
+// RotatedAtNotNil applies the NotNil predicate on the "rotated_at" field.
+func RotatedAtNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldRotatedAt))
+}
+
+// AllowedTagKeysIsNil applies the IsNil predicate on the "allowed_tag_keys" field.
+func AllowedTagKeysIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldAllowedTagKeys))
+}
+
+// AllowedTagKeysNotNil applies the NotNil predicate on the "allowed_tag_keys" field.
+func AllowedTagKeysNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldAllowedTagKeys))
+}
+
+// OrganizationIDEQ applies the EQ predicate on the "organization_id" field.
+func OrganizationIDEQ(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldOrganizationID, v))
+}
+
+// OrganizationIDNEQ applies the NEQ predicate on the "organization_id" field.
+func OrganizationIDNEQ(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldOrganizationID, v))
+}
+
+// OrganizationIDIn applies the In predicate on the "organization_id" field.
+func OrganizationIDIn(vs ...int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldOrganizationID, vs...))
+}
+
+// OrganizationIDNotIn applies the NotIn predicate on the "organization_id" field.
+func OrganizationIDNotIn(vs ...int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldOrganizationID, vs...))
+}
+
+// OrganizationIDGT applies the GT predicate on the "organization_id" field.
+func OrganizationIDGT(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldOrganizationID, v))
+}
+
+// OrganizationIDGTE applies the GTE predicate on the "organization_id" field.
+func OrganizationIDGTE(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldOrganizationID, v))
+}
+
+// OrganizationIDLT applies the LT predicate on the "organization_id" field.
+func OrganizationIDLT(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldOrganizationID, v))
+}
+
+// OrganizationIDLTE applies the LTE predicate on the "organization_id" field.
+func OrganizationIDLTE(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldOrganizationID, v))
+}
+
+// OrganizationIDIsNil applies the IsNil predicate on the "organization_id" field.
+func OrganizationIDIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldOrganizationID))
+}
+
+// OrganizationIDNotNil applies the NotNil predicate on the "organization_id" field.
+func OrganizationIDNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldOrganizationID))
+}
+
 // HasUser applies the HasEdge predicate on the "user" edge.
 func HasUser() predicate.APIKey {
 	return predicate.APIKey(func(s *sql.Selector) {
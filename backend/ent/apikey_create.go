@@ -167,6 +167,124 @@ func (_c *APIKeyCreate) SetNillableExpiresAt(v *time.Time) *APIKeyCreate {
 	return _c
 }
 
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (_c *APIKeyCreate) SetDailyLimitUsd(v float64) *APIKeyCreate {
+	_c.mutation.SetDailyLimitUsd(v)
+	return _c
+}
+
+// SetNillableDailyLimitUsd sets the "daily_limit_usd" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableDailyLimitUsd(v *float64) *APIKeyCreate {
+	if v != nil {
+		_c.SetDailyLimitUsd(*v)
+	}
+	return _c
+}
+
+// SetWeeklyLimitUsd sets the "weekly_limit_usd" field.
+func (_c *APIKeyCreate) SetWeeklyLimitUsd(v float64) *APIKeyCreate {
+	_c.mutation.SetWeeklyLimitUsd(v)
+	return _c
+}
+
+// SetNillableWeeklyLimitUsd sets the "weekly_limit_usd" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableWeeklyLimitUsd(v *float64) *APIKeyCreate {
+	if v != nil {
+		_c.SetWeeklyLimitUsd(*v)
+	}
+	return _c
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (_c *APIKeyCreate) SetMonthlyLimitUsd(v float64) *APIKeyCreate {
+	_c.mutation.SetMonthlyLimitUsd(v)
+	return _c
+}
+
+// SetNillableMonthlyLimitUsd sets the "monthly_limit_usd" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableMonthlyLimitUsd(v *float64) *APIKeyCreate {
+	if v != nil {
+		_c.SetMonthlyLimitUsd(*v)
+	}
+	return _c
+}
+
+// SetPreviousKey sets the "previous_key" field.
+func (_c *APIKeyCreate) SetPreviousKey(v string) *APIKeyCreate {
+	_c.mutation.SetPreviousKey(v)
+	return _c
+}
+
+// SetNillablePreviousKey sets the "previous_key" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillablePreviousKey(v *string) *APIKeyCreate {
+	if v != nil {
+		_c.SetPreviousKey(*v)
+	}
+	return _c
+}
+
+// SetPreviousKeyExpiresAt sets the "previous_key_expires_at" field.
+func (_c *APIKeyCreate) SetPreviousKeyExpiresAt(v time.Time) *APIKeyCreate {
+	_c.mutation.SetPreviousKeyExpiresAt(v)
+	return _c
+}
+
+// SetNillablePreviousKeyExpiresAt sets the "previous_key_expires_at" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillablePreviousKeyExpiresAt(v *time.Time) *APIKeyCreate {
+	if v != nil {
+		_c.SetPreviousKeyExpiresAt(*v)
+	}
+	return _c
+}
+
+// SetKeyGeneration sets the "key_generation" field.
+func (_c *APIKeyCreate) SetKeyGeneration(v int) *APIKeyCreate {
+	_c.mutation.SetKeyGeneration(v)
+	return _c
+}
+
+// SetNillableKeyGeneration sets the "key_generation" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableKeyGeneration(v *int) *APIKeyCreate {
+	if v != nil {
+		_c.SetKeyGeneration(*v)
+	}
+	return _c
+}
+
+// SetRotatedAt sets the "rotated_at" field.
+func (_c *APIKeyCreate) SetRotatedAt(v time.Time) *APIKeyCreate {
+	_c.mutation.SetRotatedAt(v)
+	return _c
+}
+
+// SetNillableRotatedAt sets the "rotated_at" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableRotatedAt(v *time.Time) *APIKeyCreate {
+	if v != nil {
+		_c.SetRotatedAt(*v)
+	}
+	return _c
+}
+
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (_c *APIKeyCreate) SetAllowedTagKeys(v []string) *APIKeyCreate {
+	_c.mutation.SetAllowedTagKeys(v)
+	return _c
+}
+
+// SetOrganizationID sets the "organization_id" field.
+func (_c *APIKeyCreate) SetOrganizationID(v int64) *APIKeyCreate {
+	_c.mutation.SetOrganizationID(v)
+	return _c
+}
+
+// SetNillableOrganizationID sets the "organization_id" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableOrganizationID(v *int64) *APIKeyCreate {
+	if v != nil {
+		_c.SetOrganizationID(*v)
+	}
+	return _c
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_c *APIKeyCreate) SetUser(v *User) *APIKeyCreate {
 	return _c.SetUserID(v.ID)
@@ -255,6 +373,10 @@ func (_c *APIKeyCreate) defaults() error {
 		v := apikey.DefaultQuotaUsed
 		_c.mutation.SetQuotaUsed(v)
 	}
+	if _, ok := _c.mutation.KeyGeneration(); !ok {
+		v := apikey.DefaultKeyGeneration
+		_c.mutation.SetKeyGeneration(v)
+	}
 	return nil
 }
 
@@ -299,6 +421,14 @@ func (_c *APIKeyCreate) check() error {
 	if _, ok := _c.mutation.QuotaUsed(); !ok {
 		return &ValidationError{Name: "quota_used", err: errors.New(`ent: missing required field "APIKey.quota_used"`)}
 	}
+	if v, ok := _c.mutation.PreviousKey(); ok {
+		if err := apikey.PreviousKeyValidator(v); err != nil {
+			return &ValidationError{Name: "previous_key", err: fmt.Errorf(`ent: validator failed for field "APIKey.previous_key": %w`, err)}
+		}
+	}
+	if _, ok := _c.mutation.KeyGeneration(); !ok {
+		return &ValidationError{Name: "key_generation", err: errors.New(`ent: missing required field "APIKey.key_generation"`)}
+	}
 	if len(_c.mutation.UserIDs()) == 0 {
 		return &ValidationError{Name: "user", err: errors.New(`ent: missing required edge "APIKey.user"`)}
 	}
@@ -373,6 +503,42 @@ func (_c *APIKeyCreate) createSpec() (*APIKey, *sqlgraph.CreateSpec) {
 		_spec.SetField(apikey.FieldExpiresAt, field.TypeTime, value)
 		_node.ExpiresAt = &value
 	}
+	if value, ok := _c.mutation.DailyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+		_node.DailyLimitUsd = &value
+	}
+	if value, ok := _c.mutation.WeeklyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldWeeklyLimitUsd, field.TypeFloat64, value)
+		_node.WeeklyLimitUsd = &value
+	}
+	if value, ok := _c.mutation.MonthlyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+		_node.MonthlyLimitUsd = &value
+	}
+	if value, ok := _c.mutation.PreviousKey(); ok {
+		_spec.SetField(apikey.FieldPreviousKey, field.TypeString, value)
+		_node.PreviousKey = &value
+	}
+	if value, ok := _c.mutation.PreviousKeyExpiresAt(); ok {
+		_spec.SetField(apikey.FieldPreviousKeyExpiresAt, field.TypeTime, value)
+		_node.PreviousKeyExpiresAt = &value
+	}
+	if value, ok := _c.mutation.KeyGeneration(); ok {
+		_spec.SetField(apikey.FieldKeyGeneration, field.TypeInt, value)
+		_node.KeyGeneration = value
+	}
+	if value, ok := _c.mutation.RotatedAt(); ok {
+		_spec.SetField(apikey.FieldRotatedAt, field.TypeTime, value)
+		_node.RotatedAt = &value
+	}
+	if value, ok := _c.mutation.AllowedTagKeys(); ok {
+		_spec.SetField(apikey.FieldAllowedTagKeys, field.TypeJSON, value)
+		_node.AllowedTagKeys = value
+	}
+	if value, ok := _c.mutation.OrganizationID(); ok {
+		_spec.SetField(apikey.FieldOrganizationID, field.TypeInt64, value)
+		_node.OrganizationID = &value
+	}
 	if nodes := _c.mutation.UserIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -661,6 +827,192 @@ func (u *APIKeyUpsert) ClearExpiresAt() *APIKeyUpsert {
 	return u
 }
 
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (u *APIKeyUpsert) SetDailyLimitUsd(v float64) *APIKeyUpsert {
+	u.Set(apikey.FieldDailyLimitUsd, v)
+	return u
+}
+
+// UpdateDailyLimitUsd sets the "daily_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateDailyLimitUsd() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldDailyLimitUsd)
+	return u
+}
+
+// AddDailyLimitUsd adds v to the "daily_limit_usd" field.
+func (u *APIKeyUpsert) AddDailyLimitUsd(v float64) *APIKeyUpsert {
+	u.Add(apikey.FieldDailyLimitUsd, v)
+	return u
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (u *APIKeyUpsert) ClearDailyLimitUsd() *APIKeyUpsert {
+	u.SetNull(apikey.FieldDailyLimitUsd)
+	return u
+}
+
+// SetWeeklyLimitUsd sets the "weekly_limit_usd" field.
+func (u *APIKeyUpsert) SetWeeklyLimitUsd(v float64) *APIKeyUpsert {
+	u.Set(apikey.FieldWeeklyLimitUsd, v)
+	return u
+}
+
+// UpdateWeeklyLimitUsd sets the "weekly_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateWeeklyLimitUsd() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldWeeklyLimitUsd)
+	return u
+}
+
+// AddWeeklyLimitUsd adds v to the "weekly_limit_usd" field.
+func (u *APIKeyUpsert) AddWeeklyLimitUsd(v float64) *APIKeyUpsert {
+	u.Add(apikey.FieldWeeklyLimitUsd, v)
+	return u
+}
+
+// ClearWeeklyLimitUsd clears the value of the "weekly_limit_usd" field.
+func (u *APIKeyUpsert) ClearWeeklyLimitUsd() *APIKeyUpsert {
+	u.SetNull(apikey.FieldWeeklyLimitUsd)
+	return u
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (u *APIKeyUpsert) SetMonthlyLimitUsd(v float64) *APIKeyUpsert {
+	u.Set(apikey.FieldMonthlyLimitUsd, v)
+	return u
+}
+
+// UpdateMonthlyLimitUsd sets the "monthly_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateMonthlyLimitUsd() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldMonthlyLimitUsd)
+	return u
+}
+
+// AddMonthlyLimitUsd adds v to the "monthly_limit_usd" field.
+func (u *APIKeyUpsert) AddMonthlyLimitUsd(v float64) *APIKeyUpsert {
+	u.Add(apikey.FieldMonthlyLimitUsd, v)
+	return u
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (u *APIKeyUpsert) ClearMonthlyLimitUsd() *APIKeyUpsert {
+	u.SetNull(apikey.FieldMonthlyLimitUsd)
+	return u
+}
+
+// SetPreviousKey sets the "previous_key" field.
+func (u *APIKeyUpsert) SetPreviousKey(v string) *APIKeyUpsert {
+	u.Set(apikey.FieldPreviousKey, v)
+	return u
+}
+
+// UpdatePreviousKey sets the "previous_key" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdatePreviousKey() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldPreviousKey)
+	return u
+}
+
+// ClearPreviousKey clears the value of the "previous_key" field.
+func (u *APIKeyUpsert) ClearPreviousKey() *APIKeyUpsert {
+	u.SetNull(apikey.FieldPreviousKey)
+	return u
+}
+
+// SetPreviousKeyExpiresAt sets the "previous_key_expires_at" field.
+func (u *APIKeyUpsert) SetPreviousKeyExpiresAt(v time.Time) *APIKeyUpsert {
+	u.Set(apikey.FieldPreviousKeyExpiresAt, v)
+	return u
+}
+
+// UpdatePreviousKeyExpiresAt sets the "previous_key_expires_at" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdatePreviousKeyExpiresAt() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldPreviousKeyExpiresAt)
+	return u
+}
+
+// ClearPreviousKeyExpiresAt clears the value of the "previous_key_expires_at" field.
+func (u *APIKeyUpsert) ClearPreviousKeyExpiresAt() *APIKeyUpsert {
+	u.SetNull(apikey.FieldPreviousKeyExpiresAt)
+	return u
+}
+
+// SetKeyGeneration sets the "key_generation" field.
+func (u *APIKeyUpsert) SetKeyGeneration(v int) *APIKeyUpsert {
+	u.Set(apikey.FieldKeyGeneration, v)
+	return u
+}
+
+// UpdateKeyGeneration sets the "key_generation" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateKeyGeneration() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldKeyGeneration)
+	return u
+}
+
+// AddKeyGeneration adds v to the "key_generation" field.
+func (u *APIKeyUpsert) AddKeyGeneration(v int) *APIKeyUpsert {
+	u.Add(apikey.FieldKeyGeneration, v)
+	return u
+}
+
+// SetRotatedAt sets the "rotated_at" field.
+func (u *APIKeyUpsert) SetRotatedAt(v time.Time) *APIKeyUpsert {
+	u.Set(apikey.FieldRotatedAt, v)
+	return u
+}
+
+// UpdateRotatedAt sets the "rotated_at" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateRotatedAt() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldRotatedAt)
+	return u
+}
+
+// ClearRotatedAt clears the value of the "rotated_at" field.
+func (u *APIKeyUpsert) ClearRotatedAt() *APIKeyUpsert {
+	u.SetNull(apikey.FieldRotatedAt)
+	return u
+}
+
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (u *APIKeyUpsert) SetAllowedTagKeys(v []string) *APIKeyUpsert {
+	u.Set(apikey.FieldAllowedTagKeys, v)
+	return u
+}
+
+// UpdateAllowedTagKeys sets the "allowed_tag_keys" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateAllowedTagKeys() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldAllowedTagKeys)
+	return u
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (u *APIKeyUpsert) ClearAllowedTagKeys() *APIKeyUpsert {
+	u.SetNull(apikey.FieldAllowedTagKeys)
+	return u
+}
+
+// SetOrganizationID sets the "organization_id" field.
+func (u *APIKeyUpsert) SetOrganizationID(v int64) *APIKeyUpsert {
+	u.Set(apikey.FieldOrganizationID, v)
+	return u
+}
+
+// UpdateOrganizationID sets the "organization_id" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateOrganizationID() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldOrganizationID)
+	return u
+}
+
+// AddOrganizationID adds v to the "organization_id" field.
+func (u *APIKeyUpsert) AddOrganizationID(v int64) *APIKeyUpsert {
+	u.Add(apikey.FieldOrganizationID, v)
+	return u
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (u *APIKeyUpsert) ClearOrganizationID() *APIKeyUpsert {
+	u.SetNull(apikey.FieldOrganizationID)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -923,6 +1275,223 @@ func (u *APIKeyUpsertOne) ClearExpiresAt() *APIKeyUpsertOne {
 	})
 }
 
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (u *APIKeyUpsertOne) SetDailyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetDailyLimitUsd(v)
+	})
+}
+
+// AddDailyLimitUsd adds v to the "daily_limit_usd" field.
+func (u *APIKeyUpsertOne) AddDailyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddDailyLimitUsd(v)
+	})
+}
+
+// UpdateDailyLimitUsd sets the "daily_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateDailyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateDailyLimitUsd()
+	})
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (u *APIKeyUpsertOne) ClearDailyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearDailyLimitUsd()
+	})
+}
+
+// SetWeeklyLimitUsd sets the "weekly_limit_usd" field.
+func (u *APIKeyUpsertOne) SetWeeklyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetWeeklyLimitUsd(v)
+	})
+}
+
+// AddWeeklyLimitUsd adds v to the "weekly_limit_usd" field.
+func (u *APIKeyUpsertOne) AddWeeklyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddWeeklyLimitUsd(v)
+	})
+}
+
+// UpdateWeeklyLimitUsd sets the "weekly_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateWeeklyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateWeeklyLimitUsd()
+	})
+}
+
+// ClearWeeklyLimitUsd clears the value of the "weekly_limit_usd" field.
+func (u *APIKeyUpsertOne) ClearWeeklyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearWeeklyLimitUsd()
+	})
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (u *APIKeyUpsertOne) SetMonthlyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetMonthlyLimitUsd(v)
+	})
+}
+
+// AddMonthlyLimitUsd adds v to the "monthly_limit_usd" field.
+func (u *APIKeyUpsertOne) AddMonthlyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddMonthlyLimitUsd(v)
+	})
+}
+
+// UpdateMonthlyLimitUsd sets the "monthly_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateMonthlyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateMonthlyLimitUsd()
+	})
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (u *APIKeyUpsertOne) ClearMonthlyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearMonthlyLimitUsd()
+	})
+}
+
+// SetPreviousKey sets the "previous_key" field.
+func (u *APIKeyUpsertOne) SetPreviousKey(v string) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetPreviousKey(v)
+	})
+}
+
+// UpdatePreviousKey sets the "previous_key" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdatePreviousKey() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdatePreviousKey()
+	})
+}
+
+// ClearPreviousKey clears the value of the "previous_key" field.
+func (u *APIKeyUpsertOne) ClearPreviousKey() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearPreviousKey()
+	})
+}
+
+// SetPreviousKeyExpiresAt sets the "previous_key_expires_at" field.
+func (u *APIKeyUpsertOne) SetPreviousKeyExpiresAt(v time.Time) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetPreviousKeyExpiresAt(v)
+	})
+}
+
+// UpdatePreviousKeyExpiresAt sets the "previous_key_expires_at" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdatePreviousKeyExpiresAt() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdatePreviousKeyExpiresAt()
+	})
+}
+
+// ClearPreviousKeyExpiresAt clears the value of the "previous_key_expires_at" field.
+func (u *APIKeyUpsertOne) ClearPreviousKeyExpiresAt() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearPreviousKeyExpiresAt()
+	})
+}
+
+// SetKeyGeneration sets the "key_generation" field.
+func (u *APIKeyUpsertOne) SetKeyGeneration(v int) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetKeyGeneration(v)
+	})
+}
+
+// AddKeyGeneration adds v to the "key_generation" field.
+func (u *APIKeyUpsertOne) AddKeyGeneration(v int) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddKeyGeneration(v)
+	})
+}
+
+// UpdateKeyGeneration sets the "key_generation" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateKeyGeneration() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateKeyGeneration()
+	})
+}
+
+// SetRotatedAt sets the "rotated_at" field.
+func (u *APIKeyUpsertOne) SetRotatedAt(v time.Time) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetRotatedAt(v)
+	})
+}
+
+// UpdateRotatedAt sets the "rotated_at" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateRotatedAt() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateRotatedAt()
+	})
+}
+
+// ClearRotatedAt clears the value of the "rotated_at" field.
+func (u *APIKeyUpsertOne) ClearRotatedAt() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearRotatedAt()
+	})
+}
+
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (u *APIKeyUpsertOne) SetAllowedTagKeys(v []string) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetAllowedTagKeys(v)
+	})
+}
+
+// UpdateAllowedTagKeys sets the "allowed_tag_keys" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateAllowedTagKeys() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateAllowedTagKeys()
+	})
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (u *APIKeyUpsertOne) ClearAllowedTagKeys() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearAllowedTagKeys()
+	})
+}
+
+// SetOrganizationID sets the "organization_id" field.
+func (u *APIKeyUpsertOne) SetOrganizationID(v int64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetOrganizationID(v)
+	})
+}
+
+// AddOrganizationID adds v to the "organization_id" field.
+func (u *APIKeyUpsertOne) AddOrganizationID(v int64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddOrganizationID(v)
+	})
+}
+
+// UpdateOrganizationID sets the "organization_id" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateOrganizationID() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateOrganizationID()
+	})
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (u *APIKeyUpsertOne) ClearOrganizationID() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearOrganizationID()
+	})
+}
+
 // Exec executes the query.
 func (u *APIKeyUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -1351,6 +1920,223 @@ func (u *APIKeyUpsertBulk) ClearExpiresAt() *APIKeyUpsertBulk {
 	})
 }
 
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (u *APIKeyUpsertBulk) SetDailyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetDailyLimitUsd(v)
+	})
+}
+
+// AddDailyLimitUsd adds v to the "daily_limit_usd" field.
+func (u *APIKeyUpsertBulk) AddDailyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddDailyLimitUsd(v)
+	})
+}
+
+// UpdateDailyLimitUsd sets the "daily_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateDailyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateDailyLimitUsd()
+	})
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (u *APIKeyUpsertBulk) ClearDailyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearDailyLimitUsd()
+	})
+}
+
+// SetWeeklyLimitUsd sets the "weekly_limit_usd" field.
+func (u *APIKeyUpsertBulk) SetWeeklyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetWeeklyLimitUsd(v)
+	})
+}
+
+// AddWeeklyLimitUsd adds v to the "weekly_limit_usd" field.
+func (u *APIKeyUpsertBulk) AddWeeklyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddWeeklyLimitUsd(v)
+	})
+}
+
+// UpdateWeeklyLimitUsd sets the "weekly_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateWeeklyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateWeeklyLimitUsd()
+	})
+}
+
+// ClearWeeklyLimitUsd clears the value of the "weekly_limit_usd" field.
+func (u *APIKeyUpsertBulk) ClearWeeklyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearWeeklyLimitUsd()
+	})
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (u *APIKeyUpsertBulk) SetMonthlyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetMonthlyLimitUsd(v)
+	})
+}
+
+// AddMonthlyLimitUsd adds v to the "monthly_limit_usd" field.
+func (u *APIKeyUpsertBulk) AddMonthlyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddMonthlyLimitUsd(v)
+	})
+}
+
+// UpdateMonthlyLimitUsd sets the "monthly_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateMonthlyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateMonthlyLimitUsd()
+	})
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (u *APIKeyUpsertBulk) ClearMonthlyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearMonthlyLimitUsd()
+	})
+}
+
+// SetPreviousKey sets the "previous_key" field.
+func (u *APIKeyUpsertBulk) SetPreviousKey(v string) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetPreviousKey(v)
+	})
+}
+
+// UpdatePreviousKey sets the "previous_key" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdatePreviousKey() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdatePreviousKey()
+	})
+}
+
+// ClearPreviousKey clears the value of the "previous_key" field.
+func (u *APIKeyUpsertBulk) ClearPreviousKey() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearPreviousKey()
+	})
+}
+
+// SetPreviousKeyExpiresAt sets the "previous_key_expires_at" field.
+func (u *APIKeyUpsertBulk) SetPreviousKeyExpiresAt(v time.Time) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetPreviousKeyExpiresAt(v)
+	})
+}
+
+// UpdatePreviousKeyExpiresAt sets the "previous_key_expires_at" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdatePreviousKeyExpiresAt() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdatePreviousKeyExpiresAt()
+	})
+}
+
+// ClearPreviousKeyExpiresAt clears the value of the "previous_key_expires_at" field.
+func (u *APIKeyUpsertBulk) ClearPreviousKeyExpiresAt() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearPreviousKeyExpiresAt()
+	})
+}
+
+// SetKeyGeneration sets the "key_generation" field.
+func (u *APIKeyUpsertBulk) SetKeyGeneration(v int) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetKeyGeneration(v)
+	})
+}
+
+// AddKeyGeneration adds v to the "key_generation" field.
+func (u *APIKeyUpsertBulk) AddKeyGeneration(v int) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddKeyGeneration(v)
+	})
+}
+
+// UpdateKeyGeneration sets the "key_generation" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateKeyGeneration() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateKeyGeneration()
+	})
+}
+
+// SetRotatedAt sets the "rotated_at" field.
+func (u *APIKeyUpsertBulk) SetRotatedAt(v time.Time) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetRotatedAt(v)
+	})
+}
+
+// UpdateRotatedAt sets the "rotated_at" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateRotatedAt() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateRotatedAt()
+	})
+}
+
+// ClearRotatedAt clears the value of the "rotated_at" field.
+func (u *APIKeyUpsertBulk) ClearRotatedAt() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearRotatedAt()
+	})
+}
+
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (u *APIKeyUpsertBulk) SetAllowedTagKeys(v []string) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetAllowedTagKeys(v)
+	})
+}
+
+// UpdateAllowedTagKeys sets the "allowed_tag_keys" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateAllowedTagKeys() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateAllowedTagKeys()
+	})
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (u *APIKeyUpsertBulk) ClearAllowedTagKeys() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearAllowedTagKeys()
+	})
+}
+
+// SetOrganizationID sets the "organization_id" field.
+func (u *APIKeyUpsertBulk) SetOrganizationID(v int64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetOrganizationID(v)
+	})
+}
+
+// AddOrganizationID adds v to the "organization_id" field.
+func (u *APIKeyUpsertBulk) AddOrganizationID(v int64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddOrganizationID(v)
+	})
+}
+
+// UpdateOrganizationID sets the "organization_id" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateOrganizationID() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateOrganizationID()
+	})
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (u *APIKeyUpsertBulk) ClearOrganizationID() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearOrganizationID()
+	})
+}
+
 // Exec executes the query.
 func (u *APIKeyUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
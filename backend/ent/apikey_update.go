@@ -232,6 +232,213 @@ func (_u *APIKeyUpdate) ClearExpiresAt() *APIKeyUpdate {
 	return _u
 }
 
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (_u *APIKeyUpdate) SetDailyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.ResetDailyLimitUsd()
+	_u.mutation.SetDailyLimitUsd(v)
+	return _u
+}
+
+// SetNillableDailyLimitUsd sets the "daily_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableDailyLimitUsd(v *float64) *APIKeyUpdate {
+	if v != nil {
+		_u.SetDailyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddDailyLimitUsd adds value to the "daily_limit_usd" field.
+func (_u *APIKeyUpdate) AddDailyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.AddDailyLimitUsd(v)
+	return _u
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (_u *APIKeyUpdate) ClearDailyLimitUsd() *APIKeyUpdate {
+	_u.mutation.ClearDailyLimitUsd()
+	return _u
+}
+
+// SetWeeklyLimitUsd sets the "weekly_limit_usd" field.
+func (_u *APIKeyUpdate) SetWeeklyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.ResetWeeklyLimitUsd()
+	_u.mutation.SetWeeklyLimitUsd(v)
+	return _u
+}
+
+// SetNillableWeeklyLimitUsd sets the "weekly_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableWeeklyLimitUsd(v *float64) *APIKeyUpdate {
+	if v != nil {
+		_u.SetWeeklyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddWeeklyLimitUsd adds value to the "weekly_limit_usd" field.
+func (_u *APIKeyUpdate) AddWeeklyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.AddWeeklyLimitUsd(v)
+	return _u
+}
+
+// ClearWeeklyLimitUsd clears the value of the "weekly_limit_usd" field.
+func (_u *APIKeyUpdate) ClearWeeklyLimitUsd() *APIKeyUpdate {
+	_u.mutation.ClearWeeklyLimitUsd()
+	return _u
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (_u *APIKeyUpdate) SetMonthlyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.ResetMonthlyLimitUsd()
+	_u.mutation.SetMonthlyLimitUsd(v)
+	return _u
+}
+
+// SetNillableMonthlyLimitUsd sets the "monthly_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableMonthlyLimitUsd(v *float64) *APIKeyUpdate {
+	if v != nil {
+		_u.SetMonthlyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddMonthlyLimitUsd adds value to the "monthly_limit_usd" field.
+func (_u *APIKeyUpdate) AddMonthlyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.AddMonthlyLimitUsd(v)
+	return _u
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (_u *APIKeyUpdate) ClearMonthlyLimitUsd() *APIKeyUpdate {
+	_u.mutation.ClearMonthlyLimitUsd()
+	return _u
+}
+
+// SetPreviousKey sets the "previous_key" field.
+func (_u *APIKeyUpdate) SetPreviousKey(v string) *APIKeyUpdate {
+	_u.mutation.SetPreviousKey(v)
+	return _u
+}
+
+// SetNillablePreviousKey sets the "previous_key" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillablePreviousKey(v *string) *APIKeyUpdate {
+	if v != nil {
+		_u.SetPreviousKey(*v)
+	}
+	return _u
+}
+
+// ClearPreviousKey clears the value of the "previous_key" field.
+func (_u *APIKeyUpdate) ClearPreviousKey() *APIKeyUpdate {
+	_u.mutation.ClearPreviousKey()
+	return _u
+}
+
+// SetPreviousKeyExpiresAt sets the "previous_key_expires_at" field.
+func (_u *APIKeyUpdate) SetPreviousKeyExpiresAt(v time.Time) *APIKeyUpdate {
+	_u.mutation.SetPreviousKeyExpiresAt(v)
+	return _u
+}
+
+// SetNillablePreviousKeyExpiresAt sets the "previous_key_expires_at" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillablePreviousKeyExpiresAt(v *time.Time) *APIKeyUpdate {
+	if v != nil {
+		_u.SetPreviousKeyExpiresAt(*v)
+	}
+	return _u
+}
+
+// ClearPreviousKeyExpiresAt clears the value of the "previous_key_expires_at" field.
+func (_u *APIKeyUpdate) ClearPreviousKeyExpiresAt() *APIKeyUpdate {
+	_u.mutation.ClearPreviousKeyExpiresAt()
+	return _u
+}
+
+// SetKeyGeneration sets the "key_generation" field.
+func (_u *APIKeyUpdate) SetKeyGeneration(v int) *APIKeyUpdate {
+	_u.mutation.ResetKeyGeneration()
+	_u.mutation.SetKeyGeneration(v)
+	return _u
+}
+
+// SetNillableKeyGeneration sets the "key_generation" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableKeyGeneration(v *int) *APIKeyUpdate {
+	if v != nil {
+		_u.SetKeyGeneration(*v)
+	}
+	return _u
+}
+
+// AddKeyGeneration adds value to the "key_generation" field.
+func (_u *APIKeyUpdate) AddKeyGeneration(v int) *APIKeyUpdate {
+	_u.mutation.AddKeyGeneration(v)
+	return _u
+}
+
+// SetRotatedAt sets the "rotated_at" field.
+func (_u *APIKeyUpdate) SetRotatedAt(v time.Time) *APIKeyUpdate {
+	_u.mutation.SetRotatedAt(v)
+	return _u
+}
+
+// SetNillableRotatedAt sets the "rotated_at" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableRotatedAt(v *time.Time) *APIKeyUpdate {
+	if v != nil {
+		_u.SetRotatedAt(*v)
+	}
+	return _u
+}
+
+// ClearRotatedAt clears the value of the "rotated_at" field.
+func (_u *APIKeyUpdate) ClearRotatedAt() *APIKeyUpdate {
+	_u.mutation.ClearRotatedAt()
+	return _u
+}
+
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (_u *APIKeyUpdate) SetAllowedTagKeys(v []string) *APIKeyUpdate {
+	_u.mutation.SetAllowedTagKeys(v)
+	return _u
+}
+
+// AppendAllowedTagKeys appends value to the "allowed_tag_keys" field.
+func (_u *APIKeyUpdate) AppendAllowedTagKeys(v []string) *APIKeyUpdate {
+	_u.mutation.AppendAllowedTagKeys(v)
+	return _u
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (_u *APIKeyUpdate) ClearAllowedTagKeys() *APIKeyUpdate {
+	_u.mutation.ClearAllowedTagKeys()
+	return _u
+}
+
+// SetOrganizationID sets the "organization_id" field.
+func (_u *APIKeyUpdate) SetOrganizationID(v int64) *APIKeyUpdate {
+	_u.mutation.ResetOrganizationID()
+	_u.mutation.SetOrganizationID(v)
+	return _u
+}
+
+// SetNillableOrganizationID sets the "organization_id" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableOrganizationID(v *int64) *APIKeyUpdate {
+	if v != nil {
+		_u.SetOrganizationID(*v)
+	}
+	return _u
+}
+
+// AddOrganizationID adds value to the "organization_id" field.
+func (_u *APIKeyUpdate) AddOrganizationID(v int64) *APIKeyUpdate {
+	_u.mutation.AddOrganizationID(v)
+	return _u
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (_u *APIKeyUpdate) ClearOrganizationID() *APIKeyUpdate {
+	_u.mutation.ClearOrganizationID()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *APIKeyUpdate) SetUser(v *User) *APIKeyUpdate {
 	return _u.SetUserID(v.ID)
@@ -354,6 +561,11 @@ func (_u *APIKeyUpdate) check() error {
 			return &ValidationError{Name: "status", err: fmt.Errorf(`ent: validator failed for field "APIKey.status": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.PreviousKey(); ok {
+		if err := apikey.PreviousKeyValidator(v); err != nil {
+			return &ValidationError{Name: "previous_key", err: fmt.Errorf(`ent: validator failed for field "APIKey.previous_key": %w`, err)}
+		}
+	}
 	if _u.mutation.UserCleared() && len(_u.mutation.UserIDs()) > 0 {
 		return errors.New(`ent: clearing a required unique edge "APIKey.user"`)
 	}
@@ -430,6 +642,77 @@ func (_u *APIKeyUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.ExpiresAtCleared() {
 		_spec.ClearField(apikey.FieldExpiresAt, field.TypeTime)
 	}
+	if value, ok := _u.mutation.DailyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedDailyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.DailyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldDailyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.WeeklyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldWeeklyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedWeeklyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldWeeklyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.WeeklyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldWeeklyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.MonthlyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedMonthlyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.MonthlyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.PreviousKey(); ok {
+		_spec.SetField(apikey.FieldPreviousKey, field.TypeString, value)
+	}
+	if _u.mutation.PreviousKeyCleared() {
+		_spec.ClearField(apikey.FieldPreviousKey, field.TypeString)
+	}
+	if value, ok := _u.mutation.PreviousKeyExpiresAt(); ok {
+		_spec.SetField(apikey.FieldPreviousKeyExpiresAt, field.TypeTime, value)
+	}
+	if _u.mutation.PreviousKeyExpiresAtCleared() {
+		_spec.ClearField(apikey.FieldPreviousKeyExpiresAt, field.TypeTime)
+	}
+	if value, ok := _u.mutation.KeyGeneration(); ok {
+		_spec.SetField(apikey.FieldKeyGeneration, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedKeyGeneration(); ok {
+		_spec.AddField(apikey.FieldKeyGeneration, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.RotatedAt(); ok {
+		_spec.SetField(apikey.FieldRotatedAt, field.TypeTime, value)
+	}
+	if _u.mutation.RotatedAtCleared() {
+		_spec.ClearField(apikey.FieldRotatedAt, field.TypeTime)
+	}
+	if value, ok := _u.mutation.AllowedTagKeys(); ok {
+		_spec.SetField(apikey.FieldAllowedTagKeys, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedTagKeys(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldAllowedTagKeys, value)
+		})
+	}
+	if _u.mutation.AllowedTagKeysCleared() {
+		_spec.ClearField(apikey.FieldAllowedTagKeys, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.OrganizationID(); ok {
+		_spec.SetField(apikey.FieldOrganizationID, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedOrganizationID(); ok {
+		_spec.AddField(apikey.FieldOrganizationID, field.TypeInt64, value)
+	}
+	if _u.mutation.OrganizationIDCleared() {
+		_spec.ClearField(apikey.FieldOrganizationID, field.TypeInt64)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -753,6 +1036,213 @@ func (_u *APIKeyUpdateOne) ClearExpiresAt() *APIKeyUpdateOne {
 	return _u
 }
 
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (_u *APIKeyUpdateOne) SetDailyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.ResetDailyLimitUsd()
+	_u.mutation.SetDailyLimitUsd(v)
+	return _u
+}
+
+// SetNillableDailyLimitUsd sets the "daily_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableDailyLimitUsd(v *float64) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetDailyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddDailyLimitUsd adds value to the "daily_limit_usd" field.
+func (_u *APIKeyUpdateOne) AddDailyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.AddDailyLimitUsd(v)
+	return _u
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (_u *APIKeyUpdateOne) ClearDailyLimitUsd() *APIKeyUpdateOne {
+	_u.mutation.ClearDailyLimitUsd()
+	return _u
+}
+
+// SetWeeklyLimitUsd sets the "weekly_limit_usd" field.
+func (_u *APIKeyUpdateOne) SetWeeklyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.ResetWeeklyLimitUsd()
+	_u.mutation.SetWeeklyLimitUsd(v)
+	return _u
+}
+
+// SetNillableWeeklyLimitUsd sets the "weekly_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableWeeklyLimitUsd(v *float64) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetWeeklyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddWeeklyLimitUsd adds value to the "weekly_limit_usd" field.
+func (_u *APIKeyUpdateOne) AddWeeklyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.AddWeeklyLimitUsd(v)
+	return _u
+}
+
+// ClearWeeklyLimitUsd clears the value of the "weekly_limit_usd" field.
+func (_u *APIKeyUpdateOne) ClearWeeklyLimitUsd() *APIKeyUpdateOne {
+	_u.mutation.ClearWeeklyLimitUsd()
+	return _u
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (_u *APIKeyUpdateOne) SetMonthlyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.ResetMonthlyLimitUsd()
+	_u.mutation.SetMonthlyLimitUsd(v)
+	return _u
+}
+
+// SetNillableMonthlyLimitUsd sets the "monthly_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableMonthlyLimitUsd(v *float64) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetMonthlyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddMonthlyLimitUsd adds value to the "monthly_limit_usd" field.
+func (_u *APIKeyUpdateOne) AddMonthlyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.AddMonthlyLimitUsd(v)
+	return _u
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (_u *APIKeyUpdateOne) ClearMonthlyLimitUsd() *APIKeyUpdateOne {
+	_u.mutation.ClearMonthlyLimitUsd()
+	return _u
+}
+
+// SetPreviousKey sets the "previous_key" field.
+func (_u *APIKeyUpdateOne) SetPreviousKey(v string) *APIKeyUpdateOne {
+	_u.mutation.SetPreviousKey(v)
+	return _u
+}
+
+// SetNillablePreviousKey sets the "previous_key" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillablePreviousKey(v *string) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetPreviousKey(*v)
+	}
+	return _u
+}
+
+// ClearPreviousKey clears the value of the "previous_key" field.
+func (_u *APIKeyUpdateOne) ClearPreviousKey() *APIKeyUpdateOne {
+	_u.mutation.ClearPreviousKey()
+	return _u
+}
+
+// SetPreviousKeyExpiresAt sets the "previous_key_expires_at" field.
+func (_u *APIKeyUpdateOne) SetPreviousKeyExpiresAt(v time.Time) *APIKeyUpdateOne {
+	_u.mutation.SetPreviousKeyExpiresAt(v)
+	return _u
+}
+
+// SetNillablePreviousKeyExpiresAt sets the "previous_key_expires_at" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillablePreviousKeyExpiresAt(v *time.Time) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetPreviousKeyExpiresAt(*v)
+	}
+	return _u
+}
+
+// ClearPreviousKeyExpiresAt clears the value of the "previous_key_expires_at" field.
+func (_u *APIKeyUpdateOne) ClearPreviousKeyExpiresAt() *APIKeyUpdateOne {
+	_u.mutation.ClearPreviousKeyExpiresAt()
+	return _u
+}
+
+// SetKeyGeneration sets the "key_generation" field.
+func (_u *APIKeyUpdateOne) SetKeyGeneration(v int) *APIKeyUpdateOne {
+	_u.mutation.ResetKeyGeneration()
+	_u.mutation.SetKeyGeneration(v)
+	return _u
+}
+
+// SetNillableKeyGeneration sets the "key_generation" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableKeyGeneration(v *int) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetKeyGeneration(*v)
+	}
+	return _u
+}
+
+// AddKeyGeneration adds value to the "key_generation" field.
+func (_u *APIKeyUpdateOne) AddKeyGeneration(v int) *APIKeyUpdateOne {
+	_u.mutation.AddKeyGeneration(v)
+	return _u
+}
+
+// SetRotatedAt sets the "rotated_at" field.
+func (_u *APIKeyUpdateOne) SetRotatedAt(v time.Time) *APIKeyUpdateOne {
+	_u.mutation.SetRotatedAt(v)
+	return _u
+}
+
+// SetNillableRotatedAt sets the "rotated_at" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableRotatedAt(v *time.Time) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetRotatedAt(*v)
+	}
+	return _u
+}
+
+// ClearRotatedAt clears the value of the "rotated_at" field.
+func (_u *APIKeyUpdateOne) ClearRotatedAt() *APIKeyUpdateOne {
+	_u.mutation.ClearRotatedAt()
+	return _u
+}
+
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (_u *APIKeyUpdateOne) SetAllowedTagKeys(v []string) *APIKeyUpdateOne {
+	_u.mutation.SetAllowedTagKeys(v)
+	return _u
+}
+
+// AppendAllowedTagKeys appends value to the "allowed_tag_keys" field.
+func (_u *APIKeyUpdateOne) AppendAllowedTagKeys(v []string) *APIKeyUpdateOne {
+	_u.mutation.AppendAllowedTagKeys(v)
+	return _u
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (_u *APIKeyUpdateOne) ClearAllowedTagKeys() *APIKeyUpdateOne {
+	_u.mutation.ClearAllowedTagKeys()
+	return _u
+}
+
+// SetOrganizationID sets the "organization_id" field.
+func (_u *APIKeyUpdateOne) SetOrganizationID(v int64) *APIKeyUpdateOne {
+	_u.mutation.ResetOrganizationID()
+	_u.mutation.SetOrganizationID(v)
+	return _u
+}
+
+// SetNillableOrganizationID sets the "organization_id" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableOrganizationID(v *int64) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetOrganizationID(*v)
+	}
+	return _u
+}
+
+// AddOrganizationID adds value to the "organization_id" field.
+func (_u *APIKeyUpdateOne) AddOrganizationID(v int64) *APIKeyUpdateOne {
+	_u.mutation.AddOrganizationID(v)
+	return _u
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (_u *APIKeyUpdateOne) ClearOrganizationID() *APIKeyUpdateOne {
+	_u.mutation.ClearOrganizationID()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *APIKeyUpdateOne) SetUser(v *User) *APIKeyUpdateOne {
 	return _u.SetUserID(v.ID)
@@ -888,6 +1378,11 @@ func (_u *APIKeyUpdateOne) check() error {
 			return &ValidationError{Name: "status", err: fmt.Errorf(`ent: validator failed for field "APIKey.status": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.PreviousKey(); ok {
+		if err := apikey.PreviousKeyValidator(v); err != nil {
+			return &ValidationError{Name: "previous_key", err: fmt.Errorf(`ent: validator failed for field "APIKey.previous_key": %w`, err)}
+		}
+	}
 	if _u.mutation.UserCleared() && len(_u.mutation.UserIDs()) > 0 {
 		return errors.New(`ent: clearing a required unique edge "APIKey.user"`)
 	}
@@ -981,6 +1476,77 @@ func (_u *APIKeyUpdateOne) sqlSave(ctx context.Context) (_node *APIKey, err erro
 	if _u.mutation.ExpiresAtCleared() {
 		_spec.ClearField(apikey.FieldExpiresAt, field.TypeTime)
 	}
+	if value, ok := _u.mutation.DailyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedDailyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.DailyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldDailyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.WeeklyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldWeeklyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedWeeklyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldWeeklyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.WeeklyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldWeeklyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.MonthlyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedMonthlyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.MonthlyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.PreviousKey(); ok {
+		_spec.SetField(apikey.FieldPreviousKey, field.TypeString, value)
+	}
+	if _u.mutation.PreviousKeyCleared() {
+		_spec.ClearField(apikey.FieldPreviousKey, field.TypeString)
+	}
+	if value, ok := _u.mutation.PreviousKeyExpiresAt(); ok {
+		_spec.SetField(apikey.FieldPreviousKeyExpiresAt, field.TypeTime, value)
+	}
+	if _u.mutation.PreviousKeyExpiresAtCleared() {
+		_spec.ClearField(apikey.FieldPreviousKeyExpiresAt, field.TypeTime)
+	}
+	if value, ok := _u.mutation.KeyGeneration(); ok {
+		_spec.SetField(apikey.FieldKeyGeneration, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedKeyGeneration(); ok {
+		_spec.AddField(apikey.FieldKeyGeneration, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.RotatedAt(); ok {
+		_spec.SetField(apikey.FieldRotatedAt, field.TypeTime, value)
+	}
+	if _u.mutation.RotatedAtCleared() {
+		_spec.ClearField(apikey.FieldRotatedAt, field.TypeTime)
+	}
+	if value, ok := _u.mutation.AllowedTagKeys(); ok {
+		_spec.SetField(apikey.FieldAllowedTagKeys, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedTagKeys(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldAllowedTagKeys, value)
+		})
+	}
+	if _u.mutation.AllowedTagKeysCleared() {
+		_spec.ClearField(apikey.FieldAllowedTagKeys, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.OrganizationID(); ok {
+		_spec.SetField(apikey.FieldOrganizationID, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedOrganizationID(); ok {
+		_spec.AddField(apikey.FieldOrganizationID, field.TypeInt64, value)
+	}
+	if _u.mutation.OrganizationIDCleared() {
+		_spec.ClearField(apikey.FieldOrganizationID, field.TypeInt64)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
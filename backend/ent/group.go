@@ -68,6 +68,14 @@ type Group struct {
 	SupportedModelScopes []string `json:"supported_model_scopes,omitempty"`
 	// 分组显示排序，数值越小越靠前
 	SortOrder int `json:"sort_order,omitempty"`
+	// 账号选择策略：default(优先级→负载→LRU), weighted(按权重随机), latency(按 EWMA 延迟/错误评分)
+	SelectionStrategy string `json:"selection_strategy,omitempty"`
+	// 是否在分组内按用户 max-min 公平分配账号并发
+	FairShareEnabled bool `json:"fair_share_enabled,omitempty"`
+	// 模型降级链：模型模式 -> 按顺序尝试的降级模型列表
+	ModelFallback map[string][]string `json:"model_fallback,omitempty"`
+	// 是否启用模型降级链
+	ModelFallbackEnabled bool `json:"model_fallback_enabled,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the GroupQuery when eager-loading is set.
 	Edges        GroupEdges `json:"edges"`
@@ -174,15 +182,15 @@ func (*Group) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
-		case group.FieldModelRouting, group.FieldSupportedModelScopes:
+		case group.FieldModelRouting, group.FieldSupportedModelScopes, group.FieldModelFallback:
 			values[i] = new([]byte)
-		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled, group.FieldMcpXMLInject:
+		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled, group.FieldMcpXMLInject, group.FieldFairShareEnabled, group.FieldModelFallbackEnabled:
 			values[i] = new(sql.NullBool)
 		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k:
 			values[i] = new(sql.NullFloat64)
 		case group.FieldID, group.FieldDefaultValidityDays, group.FieldFallbackGroupID, group.FieldFallbackGroupIDOnInvalidRequest, group.FieldSortOrder:
 			values[i] = new(sql.NullInt64)
-		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType:
+		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType, group.FieldSelectionStrategy:
 			values[i] = new(sql.NullString)
 		case group.FieldCreatedAt, group.FieldUpdatedAt, group.FieldDeletedAt:
 			values[i] = new(sql.NullTime)
@@ -371,6 +379,32 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.SortOrder = int(value.Int64)
 			}
+		case group.FieldSelectionStrategy:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field selection_strategy", values[i])
+			} else if value.Valid {
+				_m.SelectionStrategy = value.String
+			}
+		case group.FieldFairShareEnabled:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field fair_share_enabled", values[i])
+			} else if value.Valid {
+				_m.FairShareEnabled = value.Bool
+			}
+		case group.FieldModelFallback:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field model_fallback", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ModelFallback); err != nil {
+					return fmt.Errorf("unmarshal field model_fallback: %w", err)
+				}
+			}
+		case group.FieldModelFallbackEnabled:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field model_fallback_enabled", values[i])
+			} else if value.Valid {
+				_m.ModelFallbackEnabled = value.Bool
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -541,6 +575,18 @@ func (_m *Group) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("sort_order=")
 	builder.WriteString(fmt.Sprintf("%v", _m.SortOrder))
+	builder.WriteString(", ")
+	builder.WriteString("selection_strategy=")
+	builder.WriteString(_m.SelectionStrategy)
+	builder.WriteString(", ")
+	builder.WriteString("fair_share_enabled=")
+	builder.WriteString(fmt.Sprintf("%v", _m.FairShareEnabled))
+	builder.WriteString(", ")
+	builder.WriteString("model_fallback=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ModelFallback))
+	builder.WriteString(", ")
+	builder.WriteString("model_fallback_enabled=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ModelFallbackEnabled))
 	builder.WriteByte(')')
 	return builder.String()
 }
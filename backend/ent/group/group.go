@@ -65,6 +65,14 @@ const (
 	FieldSupportedModelScopes = "supported_model_scopes"
 	// FieldSortOrder holds the string denoting the sort_order field in the database.
 	FieldSortOrder = "sort_order"
+	// FieldSelectionStrategy holds the string denoting the selection_strategy field in the database.
+	FieldSelectionStrategy = "selection_strategy"
+	// FieldFairShareEnabled holds the string denoting the fair_share_enabled field in the database.
+	FieldFairShareEnabled = "fair_share_enabled"
+	// FieldModelFallback holds the string denoting the model_fallback field in the database.
+	FieldModelFallback = "model_fallback"
+	// FieldModelFallbackEnabled holds the string denoting the model_fallback_enabled field in the database.
+	FieldModelFallbackEnabled = "model_fallback_enabled"
 	// EdgeAPIKeys holds the string denoting the api_keys edge name in mutations.
 	EdgeAPIKeys = "api_keys"
 	// EdgeRedeemCodes holds the string denoting the redeem_codes edge name in mutations.
@@ -165,6 +173,10 @@ var Columns = []string{
 	FieldMcpXMLInject,
 	FieldSupportedModelScopes,
 	FieldSortOrder,
+	FieldSelectionStrategy,
+	FieldFairShareEnabled,
+	FieldModelFallback,
+	FieldModelFallbackEnabled,
 }
 
 var (
@@ -230,6 +242,14 @@ var (
 	DefaultSupportedModelScopes []string
 	// DefaultSortOrder holds the default value on creation for the "sort_order" field.
 	DefaultSortOrder int
+	// DefaultSelectionStrategy holds the default value on creation for the "selection_strategy" field.
+	DefaultSelectionStrategy string
+	// SelectionStrategyValidator is a validator for the "selection_strategy" field. It is called by the builders before save.
+	SelectionStrategyValidator func(string) error
+	// DefaultFairShareEnabled holds the default value on creation for the "fair_share_enabled" field.
+	DefaultFairShareEnabled bool
+	// DefaultModelFallbackEnabled holds the default value on creation for the "model_fallback_enabled" field.
+	DefaultModelFallbackEnabled bool
 )
 
 // OrderOption defines the ordering options for the Group queries.
@@ -355,6 +375,21 @@ func BySortOrder(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldSortOrder, opts...).ToFunc()
 }
 
+// BySelectionStrategy orders the results by the selection_strategy field.
+func BySelectionStrategy(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldSelectionStrategy, opts...).ToFunc()
+}
+
+// ByFairShareEnabled orders the results by the fair_share_enabled field.
+func ByFairShareEnabled(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldFairShareEnabled, opts...).ToFunc()
+}
+
+// ByModelFallbackEnabled orders the results by the model_fallback_enabled field.
+func ByModelFallbackEnabled(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldModelFallbackEnabled, opts...).ToFunc()
+}
+
 // ByAPIKeysCount orders the results by api_keys count.
 func ByAPIKeysCount(opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
@@ -170,6 +170,21 @@ func SortOrder(v int) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldSortOrder, v))
 }
 
+// SelectionStrategy applies equality check predicate on the "selection_strategy" field. It's identical to SelectionStrategyEQ.
+func SelectionStrategy(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldSelectionStrategy, v))
+}
+
+// FairShareEnabled applies equality check predicate on the "fair_share_enabled" field. It's identical to FairShareEnabledEQ.
+func FairShareEnabled(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldFairShareEnabled, v))
+}
+
+// ModelFallbackEnabled applies equality check predicate on the "model_fallback_enabled" field. It's identical to ModelFallbackEnabledEQ.
+func ModelFallbackEnabled(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldModelFallbackEnabled, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldCreatedAt, v))
@@ -1205,6 +1220,101 @@ func SortOrderLTE(v int) predicate.Group {
 	return predicate.Group(sql.FieldLTE(FieldSortOrder, v))
 }
 
+// SelectionStrategyEQ applies the EQ predicate on the "selection_strategy" field.
+func SelectionStrategyEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyNEQ applies the NEQ predicate on the "selection_strategy" field.
+func SelectionStrategyNEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyIn applies the In predicate on the "selection_strategy" field.
+func SelectionStrategyIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldSelectionStrategy, vs...))
+}
+
+// SelectionStrategyNotIn applies the NotIn predicate on the "selection_strategy" field.
+func SelectionStrategyNotIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldSelectionStrategy, vs...))
+}
+
+// SelectionStrategyGT applies the GT predicate on the "selection_strategy" field.
+func SelectionStrategyGT(v string) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyGTE applies the GTE predicate on the "selection_strategy" field.
+func SelectionStrategyGTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyLT applies the LT predicate on the "selection_strategy" field.
+func SelectionStrategyLT(v string) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyLTE applies the LTE predicate on the "selection_strategy" field.
+func SelectionStrategyLTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyContains applies the Contains predicate on the "selection_strategy" field.
+func SelectionStrategyContains(v string) predicate.Group {
+	return predicate.Group(sql.FieldContains(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyHasPrefix applies the HasPrefix predicate on the "selection_strategy" field.
+func SelectionStrategyHasPrefix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasPrefix(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyHasSuffix applies the HasSuffix predicate on the "selection_strategy" field.
+func SelectionStrategyHasSuffix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasSuffix(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyEqualFold applies the EqualFold predicate on the "selection_strategy" field.
+func SelectionStrategyEqualFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldEqualFold(FieldSelectionStrategy, v))
+}
+
+// SelectionStrategyContainsFold applies the ContainsFold predicate on the "selection_strategy" field.
+func SelectionStrategyContainsFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldContainsFold(FieldSelectionStrategy, v))
+}
+
+// FairShareEnabledEQ applies the EQ predicate on the "fair_share_enabled" field.
+func FairShareEnabledEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldFairShareEnabled, v))
+}
+
+// FairShareEnabledNEQ applies the NEQ predicate on the "fair_share_enabled" field.
+func FairShareEnabledNEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldFairShareEnabled, v))
+}
+
+// ModelFallbackIsNil applies the IsNil predicate on the "model_fallback" field.
+func ModelFallbackIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldModelFallback))
+}
+
+// ModelFallbackNotNil applies the NotNil predicate on the "model_fallback" field.
+func ModelFallbackNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldModelFallback))
+}
+
+// ModelFallbackEnabledEQ applies the EQ predicate on the "model_fallback_enabled" field.
+func ModelFallbackEnabledEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldModelFallbackEnabled, v))
+}
+
+// ModelFallbackEnabledNEQ applies the NEQ predicate on the "model_fallback_enabled" field.
+func ModelFallbackEnabledNEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldModelFallbackEnabled, v))
+}
+
 // HasAPIKeys applies the HasEdge predicate on the "api_keys" edge.
 func HasAPIKeys() predicate.Group {
 	return predicate.Group(func(s *sql.Selector) {
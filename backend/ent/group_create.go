@@ -354,6 +354,54 @@ func (_c *GroupCreate) SetNillableSortOrder(v *int) *GroupCreate {
 	return _c
 }
 
+// SetSelectionStrategy sets the "selection_strategy" field.
+func (_c *GroupCreate) SetSelectionStrategy(v string) *GroupCreate {
+	_c.mutation.SetSelectionStrategy(v)
+	return _c
+}
+
+// SetNillableSelectionStrategy sets the "selection_strategy" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableSelectionStrategy(v *string) *GroupCreate {
+	if v != nil {
+		_c.SetSelectionStrategy(*v)
+	}
+	return _c
+}
+
+// SetFairShareEnabled sets the "fair_share_enabled" field.
+func (_c *GroupCreate) SetFairShareEnabled(v bool) *GroupCreate {
+	_c.mutation.SetFairShareEnabled(v)
+	return _c
+}
+
+// SetNillableFairShareEnabled sets the "fair_share_enabled" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableFairShareEnabled(v *bool) *GroupCreate {
+	if v != nil {
+		_c.SetFairShareEnabled(*v)
+	}
+	return _c
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (_c *GroupCreate) SetModelFallback(v map[string][]string) *GroupCreate {
+	_c.mutation.SetModelFallback(v)
+	return _c
+}
+
+// SetModelFallbackEnabled sets the "model_fallback_enabled" field.
+func (_c *GroupCreate) SetModelFallbackEnabled(v bool) *GroupCreate {
+	_c.mutation.SetModelFallbackEnabled(v)
+	return _c
+}
+
+// SetNillableModelFallbackEnabled sets the "model_fallback_enabled" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableModelFallbackEnabled(v *bool) *GroupCreate {
+	if v != nil {
+		_c.SetModelFallbackEnabled(*v)
+	}
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *GroupCreate) AddAPIKeyIDs(ids ...int64) *GroupCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -539,6 +587,18 @@ func (_c *GroupCreate) defaults() error {
 		v := group.DefaultSortOrder
 		_c.mutation.SetSortOrder(v)
 	}
+	if _, ok := _c.mutation.SelectionStrategy(); !ok {
+		v := group.DefaultSelectionStrategy
+		_c.mutation.SetSelectionStrategy(v)
+	}
+	if _, ok := _c.mutation.FairShareEnabled(); !ok {
+		v := group.DefaultFairShareEnabled
+		_c.mutation.SetFairShareEnabled(v)
+	}
+	if _, ok := _c.mutation.ModelFallbackEnabled(); !ok {
+		v := group.DefaultModelFallbackEnabled
+		_c.mutation.SetModelFallbackEnabled(v)
+	}
 	return nil
 }
 
@@ -606,6 +666,20 @@ func (_c *GroupCreate) check() error {
 	if _, ok := _c.mutation.SortOrder(); !ok {
 		return &ValidationError{Name: "sort_order", err: errors.New(`ent: missing required field "Group.sort_order"`)}
 	}
+	if _, ok := _c.mutation.SelectionStrategy(); !ok {
+		return &ValidationError{Name: "selection_strategy", err: errors.New(`ent: missing required field "Group.selection_strategy"`)}
+	}
+	if v, ok := _c.mutation.SelectionStrategy(); ok {
+		if err := group.SelectionStrategyValidator(v); err != nil {
+			return &ValidationError{Name: "selection_strategy", err: fmt.Errorf(`ent: validator failed for field "Group.selection_strategy": %w`, err)}
+		}
+	}
+	if _, ok := _c.mutation.FairShareEnabled(); !ok {
+		return &ValidationError{Name: "fair_share_enabled", err: errors.New(`ent: missing required field "Group.fair_share_enabled"`)}
+	}
+	if _, ok := _c.mutation.ModelFallbackEnabled(); !ok {
+		return &ValidationError{Name: "model_fallback_enabled", err: errors.New(`ent: missing required field "Group.model_fallback_enabled"`)}
+	}
 	return nil
 }
 
@@ -733,6 +807,22 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldSortOrder, field.TypeInt, value)
 		_node.SortOrder = value
 	}
+	if value, ok := _c.mutation.SelectionStrategy(); ok {
+		_spec.SetField(group.FieldSelectionStrategy, field.TypeString, value)
+		_node.SelectionStrategy = value
+	}
+	if value, ok := _c.mutation.FairShareEnabled(); ok {
+		_spec.SetField(group.FieldFairShareEnabled, field.TypeBool, value)
+		_node.FairShareEnabled = value
+	}
+	if value, ok := _c.mutation.ModelFallback(); ok {
+		_spec.SetField(group.FieldModelFallback, field.TypeJSON, value)
+		_node.ModelFallback = value
+	}
+	if value, ok := _c.mutation.ModelFallbackEnabled(); ok {
+		_spec.SetField(group.FieldModelFallbackEnabled, field.TypeBool, value)
+		_node.ModelFallbackEnabled = value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1309,6 +1399,60 @@ func (u *GroupUpsert) AddSortOrder(v int) *GroupUpsert {
 	return u
 }
 
+// SetSelectionStrategy sets the "selection_strategy" field.
+func (u *GroupUpsert) SetSelectionStrategy(v string) *GroupUpsert {
+	u.Set(group.FieldSelectionStrategy, v)
+	return u
+}
+
+// UpdateSelectionStrategy sets the "selection_strategy" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateSelectionStrategy() *GroupUpsert {
+	u.SetExcluded(group.FieldSelectionStrategy)
+	return u
+}
+
+// SetFairShareEnabled sets the "fair_share_enabled" field.
+func (u *GroupUpsert) SetFairShareEnabled(v bool) *GroupUpsert {
+	u.Set(group.FieldFairShareEnabled, v)
+	return u
+}
+
+// UpdateFairShareEnabled sets the "fair_share_enabled" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateFairShareEnabled() *GroupUpsert {
+	u.SetExcluded(group.FieldFairShareEnabled)
+	return u
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (u *GroupUpsert) SetModelFallback(v map[string][]string) *GroupUpsert {
+	u.Set(group.FieldModelFallback, v)
+	return u
+}
+
+// UpdateModelFallback sets the "model_fallback" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateModelFallback() *GroupUpsert {
+	u.SetExcluded(group.FieldModelFallback)
+	return u
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (u *GroupUpsert) ClearModelFallback() *GroupUpsert {
+	u.SetNull(group.FieldModelFallback)
+	return u
+}
+
+// SetModelFallbackEnabled sets the "model_fallback_enabled" field.
+func (u *GroupUpsert) SetModelFallbackEnabled(v bool) *GroupUpsert {
+	u.Set(group.FieldModelFallbackEnabled, v)
+	return u
+}
+
+// UpdateModelFallbackEnabled sets the "model_fallback_enabled" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateModelFallbackEnabled() *GroupUpsert {
+	u.SetExcluded(group.FieldModelFallbackEnabled)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1844,6 +1988,69 @@ func (u *GroupUpsertOne) UpdateSortOrder() *GroupUpsertOne {
 	})
 }
 
+// SetSelectionStrategy sets the "selection_strategy" field.
+func (u *GroupUpsertOne) SetSelectionStrategy(v string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSelectionStrategy(v)
+	})
+}
+
+// UpdateSelectionStrategy sets the "selection_strategy" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateSelectionStrategy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSelectionStrategy()
+	})
+}
+
+// SetFairShareEnabled sets the "fair_share_enabled" field.
+func (u *GroupUpsertOne) SetFairShareEnabled(v bool) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetFairShareEnabled(v)
+	})
+}
+
+// UpdateFairShareEnabled sets the "fair_share_enabled" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateFairShareEnabled() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateFairShareEnabled()
+	})
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (u *GroupUpsertOne) SetModelFallback(v map[string][]string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelFallback(v)
+	})
+}
+
+// UpdateModelFallback sets the "model_fallback" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateModelFallback() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelFallback()
+	})
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (u *GroupUpsertOne) ClearModelFallback() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelFallback()
+	})
+}
+
+// SetModelFallbackEnabled sets the "model_fallback_enabled" field.
+func (u *GroupUpsertOne) SetModelFallbackEnabled(v bool) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelFallbackEnabled(v)
+	})
+}
+
+// UpdateModelFallbackEnabled sets the "model_fallback_enabled" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateModelFallbackEnabled() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelFallbackEnabled()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -2545,6 +2752,69 @@ func (u *GroupUpsertBulk) UpdateSortOrder() *GroupUpsertBulk {
 	})
 }
 
+// SetSelectionStrategy sets the "selection_strategy" field.
+func (u *GroupUpsertBulk) SetSelectionStrategy(v string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSelectionStrategy(v)
+	})
+}
+
+// UpdateSelectionStrategy sets the "selection_strategy" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateSelectionStrategy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSelectionStrategy()
+	})
+}
+
+// SetFairShareEnabled sets the "fair_share_enabled" field.
+func (u *GroupUpsertBulk) SetFairShareEnabled(v bool) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetFairShareEnabled(v)
+	})
+}
+
+// UpdateFairShareEnabled sets the "fair_share_enabled" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateFairShareEnabled() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateFairShareEnabled()
+	})
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (u *GroupUpsertBulk) SetModelFallback(v map[string][]string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelFallback(v)
+	})
+}
+
+// UpdateModelFallback sets the "model_fallback" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateModelFallback() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelFallback()
+	})
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (u *GroupUpsertBulk) ClearModelFallback() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelFallback()
+	})
+}
+
+// SetModelFallbackEnabled sets the "model_fallback_enabled" field.
+func (u *GroupUpsertBulk) SetModelFallbackEnabled(v bool) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelFallbackEnabled(v)
+	})
+}
+
+// UpdateModelFallbackEnabled sets the "model_fallback_enabled" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateModelFallbackEnabled() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelFallbackEnabled()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -496,6 +496,60 @@ func (_u *GroupUpdate) AddSortOrder(v int) *GroupUpdate {
 	return _u
 }
 
+// SetSelectionStrategy sets the "selection_strategy" field.
+func (_u *GroupUpdate) SetSelectionStrategy(v string) *GroupUpdate {
+	_u.mutation.SetSelectionStrategy(v)
+	return _u
+}
+
+// SetNillableSelectionStrategy sets the "selection_strategy" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableSelectionStrategy(v *string) *GroupUpdate {
+	if v != nil {
+		_u.SetSelectionStrategy(*v)
+	}
+	return _u
+}
+
+// SetFairShareEnabled sets the "fair_share_enabled" field.
+func (_u *GroupUpdate) SetFairShareEnabled(v bool) *GroupUpdate {
+	_u.mutation.SetFairShareEnabled(v)
+	return _u
+}
+
+// SetNillableFairShareEnabled sets the "fair_share_enabled" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableFairShareEnabled(v *bool) *GroupUpdate {
+	if v != nil {
+		_u.SetFairShareEnabled(*v)
+	}
+	return _u
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (_u *GroupUpdate) SetModelFallback(v map[string][]string) *GroupUpdate {
+	_u.mutation.SetModelFallback(v)
+	return _u
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (_u *GroupUpdate) ClearModelFallback() *GroupUpdate {
+	_u.mutation.ClearModelFallback()
+	return _u
+}
+
+// SetModelFallbackEnabled sets the "model_fallback_enabled" field.
+func (_u *GroupUpdate) SetModelFallbackEnabled(v bool) *GroupUpdate {
+	_u.mutation.SetModelFallbackEnabled(v)
+	return _u
+}
+
+// SetNillableModelFallbackEnabled sets the "model_fallback_enabled" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableModelFallbackEnabled(v *bool) *GroupUpdate {
+	if v != nil {
+		_u.SetModelFallbackEnabled(*v)
+	}
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdate) AddAPIKeyIDs(ids ...int64) *GroupUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -781,6 +835,11 @@ func (_u *GroupUpdate) check() error {
 			return &ValidationError{Name: "subscription_type", err: fmt.Errorf(`ent: validator failed for field "Group.subscription_type": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.SelectionStrategy(); ok {
+		if err := group.SelectionStrategyValidator(v); err != nil {
+			return &ValidationError{Name: "selection_strategy", err: fmt.Errorf(`ent: validator failed for field "Group.selection_strategy": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -939,6 +998,21 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.AddedSortOrder(); ok {
 		_spec.AddField(group.FieldSortOrder, field.TypeInt, value)
 	}
+	if value, ok := _u.mutation.SelectionStrategy(); ok {
+		_spec.SetField(group.FieldSelectionStrategy, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.FairShareEnabled(); ok {
+		_spec.SetField(group.FieldFairShareEnabled, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.ModelFallback(); ok {
+		_spec.SetField(group.FieldModelFallback, field.TypeJSON, value)
+	}
+	if _u.mutation.ModelFallbackCleared() {
+		_spec.ClearField(group.FieldModelFallback, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.ModelFallbackEnabled(); ok {
+		_spec.SetField(group.FieldModelFallbackEnabled, field.TypeBool, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1714,6 +1788,60 @@ func (_u *GroupUpdateOne) AddSortOrder(v int) *GroupUpdateOne {
 	return _u
 }
 
+// SetSelectionStrategy sets the "selection_strategy" field.
+func (_u *GroupUpdateOne) SetSelectionStrategy(v string) *GroupUpdateOne {
+	_u.mutation.SetSelectionStrategy(v)
+	return _u
+}
+
+// SetNillableSelectionStrategy sets the "selection_strategy" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableSelectionStrategy(v *string) *GroupUpdateOne {
+	if v != nil {
+		_u.SetSelectionStrategy(*v)
+	}
+	return _u
+}
+
+// SetFairShareEnabled sets the "fair_share_enabled" field.
+func (_u *GroupUpdateOne) SetFairShareEnabled(v bool) *GroupUpdateOne {
+	_u.mutation.SetFairShareEnabled(v)
+	return _u
+}
+
+// SetNillableFairShareEnabled sets the "fair_share_enabled" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableFairShareEnabled(v *bool) *GroupUpdateOne {
+	if v != nil {
+		_u.SetFairShareEnabled(*v)
+	}
+	return _u
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (_u *GroupUpdateOne) SetModelFallback(v map[string][]string) *GroupUpdateOne {
+	_u.mutation.SetModelFallback(v)
+	return _u
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (_u *GroupUpdateOne) ClearModelFallback() *GroupUpdateOne {
+	_u.mutation.ClearModelFallback()
+	return _u
+}
+
+// SetModelFallbackEnabled sets the "model_fallback_enabled" field.
+func (_u *GroupUpdateOne) SetModelFallbackEnabled(v bool) *GroupUpdateOne {
+	_u.mutation.SetModelFallbackEnabled(v)
+	return _u
+}
+
+// SetNillableModelFallbackEnabled sets the "model_fallback_enabled" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableModelFallbackEnabled(v *bool) *GroupUpdateOne {
+	if v != nil {
+		_u.SetModelFallbackEnabled(*v)
+	}
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdateOne) AddAPIKeyIDs(ids ...int64) *GroupUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -2012,6 +2140,11 @@ func (_u *GroupUpdateOne) check() error {
 			return &ValidationError{Name: "subscription_type", err: fmt.Errorf(`ent: validator failed for field "Group.subscription_type": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.SelectionStrategy(); ok {
+		if err := group.SelectionStrategyValidator(v); err != nil {
+			return &ValidationError{Name: "selection_strategy", err: fmt.Errorf(`ent: validator failed for field "Group.selection_strategy": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -2187,6 +2320,21 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.AddedSortOrder(); ok {
 		_spec.AddField(group.FieldSortOrder, field.TypeInt, value)
 	}
+	if value, ok := _u.mutation.SelectionStrategy(); ok {
+		_spec.SetField(group.FieldSelectionStrategy, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.FairShareEnabled(); ok {
+		_spec.SetField(group.FieldFairShareEnabled, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.ModelFallback(); ok {
+		_spec.SetField(group.FieldModelFallback, field.TypeJSON, value)
+	}
+	if _u.mutation.ModelFallbackCleared() {
+		_spec.ClearField(group.FieldModelFallback, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.ModelFallbackEnabled(); ok {
+		_spec.SetField(group.FieldModelFallbackEnabled, field.TypeBool, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -68,35 +68,50 @@ const (
 // APIKeyMutation represents an operation that mutates the APIKey nodes in the graph.
 type APIKeyMutation struct {
 	config
-	op                 Op
-	typ                string
-	id                 *int64
-	created_at         *time.Time
-	updated_at         *time.Time
-	deleted_at         *time.Time
-	key                *string
-	name               *string
-	status             *string
-	ip_whitelist       *[]string
-	appendip_whitelist []string
-	ip_blacklist       *[]string
-	appendip_blacklist []string
-	quota              *float64
-	addquota           *float64
-	quota_used         *float64
-	addquota_used      *float64
-	expires_at         *time.Time
-	clearedFields      map[string]struct{}
-	user               *int64
-	cleareduser        bool
-	group              *int64
-	clearedgroup       bool
-	usage_logs         map[int64]struct{}
-	removedusage_logs  map[int64]struct{}
-	clearedusage_logs  bool
-	done               bool
-	oldValue           func(context.Context) (*APIKey, error)
-	predicates         []predicate.APIKey
+	op                      Op
+	typ                     string
+	id                      *int64
+	created_at              *time.Time
+	updated_at              *time.Time
+	deleted_at              *time.Time
+	key                     *string
+	name                    *string
+	status                  *string
+	ip_whitelist            *[]string
+	appendip_whitelist      []string
+	ip_blacklist            *[]string
+	appendip_blacklist      []string
+	quota                   *float64
+	addquota                *float64
+	quota_used              *float64
+	addquota_used           *float64
+	expires_at              *time.Time
+	daily_limit_usd         *float64
+	adddaily_limit_usd      *float64
+	weekly_limit_usd        *float64
+	addweekly_limit_usd     *float64
+	monthly_limit_usd       *float64
+	addmonthly_limit_usd    *float64
+	previous_key            *string
+	previous_key_expires_at *time.Time
+	key_generation          *int
+	addkey_generation       *int
+	rotated_at              *time.Time
+	allowed_tag_keys        *[]string
+	appendallowed_tag_keys  []string
+	organization_id         *int64
+	addorganization_id      *int64
+	clearedFields           map[string]struct{}
+	user                    *int64
+	cleareduser             bool
+	group                   *int64
+	clearedgroup            bool
+	usage_logs              map[int64]struct{}
+	removedusage_logs       map[int64]struct{}
+	clearedusage_logs       bool
+	done                    bool
+	oldValue                func(context.Context) (*APIKey, error)
+	predicates              []predicate.APIKey
 }
 
 var _ ent.Mutation = (*APIKeyMutation)(nil)
@@ -802,6 +817,554 @@ func (m *APIKeyMutation) ResetExpiresAt() {
 	delete(m.clearedFields, apikey.FieldExpiresAt)
 }
 
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (m *APIKeyMutation) SetDailyLimitUsd(f float64) {
+	m.daily_limit_usd = &f
+	m.adddaily_limit_usd = nil
+}
+
+// DailyLimitUsd returns the value of the "daily_limit_usd" field in the mutation.
+func (m *APIKeyMutation) DailyLimitUsd() (r float64, exists bool) {
+	v := m.daily_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDailyLimitUsd returns the old "daily_limit_usd" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldDailyLimitUsd(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDailyLimitUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDailyLimitUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDailyLimitUsd: %w", err)
+	}
+	return oldValue.DailyLimitUsd, nil
+}
+
+// AddDailyLimitUsd adds f to the "daily_limit_usd" field.
+func (m *APIKeyMutation) AddDailyLimitUsd(f float64) {
+	if m.adddaily_limit_usd != nil {
+		*m.adddaily_limit_usd += f
+	} else {
+		m.adddaily_limit_usd = &f
+	}
+}
+
+// AddedDailyLimitUsd returns the value that was added to the "daily_limit_usd" field in this mutation.
+func (m *APIKeyMutation) AddedDailyLimitUsd() (r float64, exists bool) {
+	v := m.adddaily_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (m *APIKeyMutation) ClearDailyLimitUsd() {
+	m.daily_limit_usd = nil
+	m.adddaily_limit_usd = nil
+	m.clearedFields[apikey.FieldDailyLimitUsd] = struct{}{}
+}
+
+// DailyLimitUsdCleared returns if the "daily_limit_usd" field was cleared in this mutation.
+func (m *APIKeyMutation) DailyLimitUsdCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldDailyLimitUsd]
+	return ok
+}
+
+// ResetDailyLimitUsd resets all changes to the "daily_limit_usd" field.
+func (m *APIKeyMutation) ResetDailyLimitUsd() {
+	m.daily_limit_usd = nil
+	m.adddaily_limit_usd = nil
+	delete(m.clearedFields, apikey.FieldDailyLimitUsd)
+}
+
+// SetWeeklyLimitUsd sets the "weekly_limit_usd" field.
+func (m *APIKeyMutation) SetWeeklyLimitUsd(f float64) {
+	m.weekly_limit_usd = &f
+	m.addweekly_limit_usd = nil
+}
+
+// WeeklyLimitUsd returns the value of the "weekly_limit_usd" field in the mutation.
+func (m *APIKeyMutation) WeeklyLimitUsd() (r float64, exists bool) {
+	v := m.weekly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldWeeklyLimitUsd returns the old "weekly_limit_usd" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldWeeklyLimitUsd(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldWeeklyLimitUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldWeeklyLimitUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldWeeklyLimitUsd: %w", err)
+	}
+	return oldValue.WeeklyLimitUsd, nil
+}
+
+// AddWeeklyLimitUsd adds f to the "weekly_limit_usd" field.
+func (m *APIKeyMutation) AddWeeklyLimitUsd(f float64) {
+	if m.addweekly_limit_usd != nil {
+		*m.addweekly_limit_usd += f
+	} else {
+		m.addweekly_limit_usd = &f
+	}
+}
+
+// AddedWeeklyLimitUsd returns the value that was added to the "weekly_limit_usd" field in this mutation.
+func (m *APIKeyMutation) AddedWeeklyLimitUsd() (r float64, exists bool) {
+	v := m.addweekly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearWeeklyLimitUsd clears the value of the "weekly_limit_usd" field.
+func (m *APIKeyMutation) ClearWeeklyLimitUsd() {
+	m.weekly_limit_usd = nil
+	m.addweekly_limit_usd = nil
+	m.clearedFields[apikey.FieldWeeklyLimitUsd] = struct{}{}
+}
+
+// WeeklyLimitUsdCleared returns if the "weekly_limit_usd" field was cleared in this mutation.
+func (m *APIKeyMutation) WeeklyLimitUsdCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldWeeklyLimitUsd]
+	return ok
+}
+
+// ResetWeeklyLimitUsd resets all changes to the "weekly_limit_usd" field.
+func (m *APIKeyMutation) ResetWeeklyLimitUsd() {
+	m.weekly_limit_usd = nil
+	m.addweekly_limit_usd = nil
+	delete(m.clearedFields, apikey.FieldWeeklyLimitUsd)
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (m *APIKeyMutation) SetMonthlyLimitUsd(f float64) {
+	m.monthly_limit_usd = &f
+	m.addmonthly_limit_usd = nil
+}
+
+// MonthlyLimitUsd returns the value of the "monthly_limit_usd" field in the mutation.
+func (m *APIKeyMutation) MonthlyLimitUsd() (r float64, exists bool) {
+	v := m.monthly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldMonthlyLimitUsd returns the old "monthly_limit_usd" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldMonthlyLimitUsd(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldMonthlyLimitUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldMonthlyLimitUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldMonthlyLimitUsd: %w", err)
+	}
+	return oldValue.MonthlyLimitUsd, nil
+}
+
+// AddMonthlyLimitUsd adds f to the "monthly_limit_usd" field.
+func (m *APIKeyMutation) AddMonthlyLimitUsd(f float64) {
+	if m.addmonthly_limit_usd != nil {
+		*m.addmonthly_limit_usd += f
+	} else {
+		m.addmonthly_limit_usd = &f
+	}
+}
+
+// AddedMonthlyLimitUsd returns the value that was added to the "monthly_limit_usd" field in this mutation.
+func (m *APIKeyMutation) AddedMonthlyLimitUsd() (r float64, exists bool) {
+	v := m.addmonthly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (m *APIKeyMutation) ClearMonthlyLimitUsd() {
+	m.monthly_limit_usd = nil
+	m.addmonthly_limit_usd = nil
+	m.clearedFields[apikey.FieldMonthlyLimitUsd] = struct{}{}
+}
+
+// MonthlyLimitUsdCleared returns if the "monthly_limit_usd" field was cleared in this mutation.
+func (m *APIKeyMutation) MonthlyLimitUsdCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldMonthlyLimitUsd]
+	return ok
+}
+
+// ResetMonthlyLimitUsd resets all changes to the "monthly_limit_usd" field.
+func (m *APIKeyMutation) ResetMonthlyLimitUsd() {
+	m.monthly_limit_usd = nil
+	m.addmonthly_limit_usd = nil
+	delete(m.clearedFields, apikey.FieldMonthlyLimitUsd)
+}
+
+// SetPreviousKey sets the "previous_key" field.
+func (m *APIKeyMutation) SetPreviousKey(s string) {
+	m.previous_key = &s
+}
+
+// PreviousKey returns the value of the "previous_key" field in the mutation.
+func (m *APIKeyMutation) PreviousKey() (r string, exists bool) {
+	v := m.previous_key
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldPreviousKey returns the old "previous_key" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldPreviousKey(ctx context.Context) (v *string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldPreviousKey is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldPreviousKey requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldPreviousKey: %w", err)
+	}
+	return oldValue.PreviousKey, nil
+}
+
+// ClearPreviousKey clears the value of the "previous_key" field.
+func (m *APIKeyMutation) ClearPreviousKey() {
+	m.previous_key = nil
+	m.clearedFields[apikey.FieldPreviousKey] = struct{}{}
+}
+
+// PreviousKeyCleared returns if the "previous_key" field was cleared in this mutation.
+func (m *APIKeyMutation) PreviousKeyCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldPreviousKey]
+	return ok
+}
+
+// ResetPreviousKey resets all changes to the "previous_key" field.
+func (m *APIKeyMutation) ResetPreviousKey() {
+	m.previous_key = nil
+	delete(m.clearedFields, apikey.FieldPreviousKey)
+}
+
+// SetPreviousKeyExpiresAt sets the "previous_key_expires_at" field.
+func (m *APIKeyMutation) SetPreviousKeyExpiresAt(t time.Time) {
+	m.previous_key_expires_at = &t
+}
+
+// PreviousKeyExpiresAt returns the value of the "previous_key_expires_at" field in the mutation.
+func (m *APIKeyMutation) PreviousKeyExpiresAt() (r time.Time, exists bool) {
+	v := m.previous_key_expires_at
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldPreviousKeyExpiresAt returns the old "previous_key_expires_at" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldPreviousKeyExpiresAt(ctx context.Context) (v *time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldPreviousKeyExpiresAt is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldPreviousKeyExpiresAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldPreviousKeyExpiresAt: %w", err)
+	}
+	return oldValue.PreviousKeyExpiresAt, nil
+}
+
+// ClearPreviousKeyExpiresAt clears the value of the "previous_key_expires_at" field.
+func (m *APIKeyMutation) ClearPreviousKeyExpiresAt() {
+	m.previous_key_expires_at = nil
+	m.clearedFields[apikey.FieldPreviousKeyExpiresAt] = struct{}{}
+}
+
+// PreviousKeyExpiresAtCleared returns if the "previous_key_expires_at" field was cleared in this mutation.
+func (m *APIKeyMutation) PreviousKeyExpiresAtCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldPreviousKeyExpiresAt]
+	return ok
+}
+
+// ResetPreviousKeyExpiresAt resets all changes to the "previous_key_expires_at" field.
+func (m *APIKeyMutation) ResetPreviousKeyExpiresAt() {
+	m.previous_key_expires_at = nil
+	delete(m.clearedFields, apikey.FieldPreviousKeyExpiresAt)
+}
+
+// SetKeyGeneration sets the "key_generation" field.
+func (m *APIKeyMutation) SetKeyGeneration(i int) {
+	m.key_generation = &i
+	m.addkey_generation = nil
+}
+
+// KeyGeneration returns the value of the "key_generation" field in the mutation.
+func (m *APIKeyMutation) KeyGeneration() (r int, exists bool) {
+	v := m.key_generation
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldKeyGeneration returns the old "key_generation" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldKeyGeneration(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldKeyGeneration is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldKeyGeneration requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldKeyGeneration: %w", err)
+	}
+	return oldValue.KeyGeneration, nil
+}
+
+// AddKeyGeneration adds i to the "key_generation" field.
+func (m *APIKeyMutation) AddKeyGeneration(i int) {
+	if m.addkey_generation != nil {
+		*m.addkey_generation += i
+	} else {
+		m.addkey_generation = &i
+	}
+}
+
+// AddedKeyGeneration returns the value that was added to the "key_generation" field in this mutation.
+func (m *APIKeyMutation) AddedKeyGeneration() (r int, exists bool) {
+	v := m.addkey_generation
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetKeyGeneration resets all changes to the "key_generation" field.
+func (m *APIKeyMutation) ResetKeyGeneration() {
+	m.key_generation = nil
+	m.addkey_generation = nil
+}
+
+// SetRotatedAt sets the "rotated_at" field.
+func (m *APIKeyMutation) SetRotatedAt(t time.Time) {
+	m.rotated_at = &t
+}
+
+// RotatedAt returns the value of the "rotated_at" field in the mutation.
+func (m *APIKeyMutation) RotatedAt() (r time.Time, exists bool) {
+	v := m.rotated_at
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldRotatedAt returns the old "rotated_at" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldRotatedAt(ctx context.Context) (v *time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRotatedAt is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRotatedAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRotatedAt: %w", err)
+	}
+	return oldValue.RotatedAt, nil
+}
+
+// ClearRotatedAt clears the value of the "rotated_at" field.
+func (m *APIKeyMutation) ClearRotatedAt() {
+	m.rotated_at = nil
+	m.clearedFields[apikey.FieldRotatedAt] = struct{}{}
+}
+
+// RotatedAtCleared returns if the "rotated_at" field was cleared in this mutation.
+func (m *APIKeyMutation) RotatedAtCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldRotatedAt]
+	return ok
+}
+
+// ResetRotatedAt resets all changes to the "rotated_at" field.
+func (m *APIKeyMutation) ResetRotatedAt() {
+	m.rotated_at = nil
+	delete(m.clearedFields, apikey.FieldRotatedAt)
+}
+
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (m *APIKeyMutation) SetAllowedTagKeys(s []string) {
+	m.allowed_tag_keys = &s
+	m.appendallowed_tag_keys = nil
+}
+
+// AllowedTagKeys returns the value of the "allowed_tag_keys" field in the mutation.
+func (m *APIKeyMutation) AllowedTagKeys() (r []string, exists bool) {
+	v := m.allowed_tag_keys
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAllowedTagKeys returns the old "allowed_tag_keys" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldAllowedTagKeys(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAllowedTagKeys is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAllowedTagKeys requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAllowedTagKeys: %w", err)
+	}
+	return oldValue.AllowedTagKeys, nil
+}
+
+// AppendAllowedTagKeys adds s to the "allowed_tag_keys" field.
+func (m *APIKeyMutation) AppendAllowedTagKeys(s []string) {
+	m.appendallowed_tag_keys = append(m.appendallowed_tag_keys, s...)
+}
+
+// AppendedAllowedTagKeys returns the list of values that were appended to the "allowed_tag_keys" field in this mutation.
+func (m *APIKeyMutation) AppendedAllowedTagKeys() ([]string, bool) {
+	if len(m.appendallowed_tag_keys) == 0 {
+		return nil, false
+	}
+	return m.appendallowed_tag_keys, true
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (m *APIKeyMutation) ClearAllowedTagKeys() {
+	m.allowed_tag_keys = nil
+	m.appendallowed_tag_keys = nil
+	m.clearedFields[apikey.FieldAllowedTagKeys] = struct{}{}
+}
+
+// AllowedTagKeysCleared returns if the "allowed_tag_keys" field was cleared in this mutation.
+func (m *APIKeyMutation) AllowedTagKeysCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldAllowedTagKeys]
+	return ok
+}
+
+// ResetAllowedTagKeys resets all changes to the "allowed_tag_keys" field.
+func (m *APIKeyMutation) ResetAllowedTagKeys() {
+	m.allowed_tag_keys = nil
+	m.appendallowed_tag_keys = nil
+	delete(m.clearedFields, apikey.FieldAllowedTagKeys)
+}
+
+// SetOrganizationID sets the "organization_id" field.
+func (m *APIKeyMutation) SetOrganizationID(i int64) {
+	m.organization_id = &i
+	m.addorganization_id = nil
+}
+
+// OrganizationID returns the value of the "organization_id" field in the mutation.
+func (m *APIKeyMutation) OrganizationID() (r int64, exists bool) {
+	v := m.organization_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldOrganizationID returns the old "organization_id" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldOrganizationID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldOrganizationID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldOrganizationID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldOrganizationID: %w", err)
+	}
+	return oldValue.OrganizationID, nil
+}
+
+// AddOrganizationID adds i to the "organization_id" field.
+func (m *APIKeyMutation) AddOrganizationID(i int64) {
+	if m.addorganization_id != nil {
+		*m.addorganization_id += i
+	} else {
+		m.addorganization_id = &i
+	}
+}
+
+// AddedOrganizationID returns the value that was added to the "organization_id" field in this mutation.
+func (m *APIKeyMutation) AddedOrganizationID() (r int64, exists bool) {
+	v := m.addorganization_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearOrganizationID clears the value of the "organization_id" field.
+func (m *APIKeyMutation) ClearOrganizationID() {
+	m.organization_id = nil
+	m.addorganization_id = nil
+	m.clearedFields[apikey.FieldOrganizationID] = struct{}{}
+}
+
+// OrganizationIDCleared returns if the "organization_id" field was cleared in this mutation.
+func (m *APIKeyMutation) OrganizationIDCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldOrganizationID]
+	return ok
+}
+
+// ResetOrganizationID resets all changes to the "organization_id" field.
+func (m *APIKeyMutation) ResetOrganizationID() {
+	m.organization_id = nil
+	m.addorganization_id = nil
+	delete(m.clearedFields, apikey.FieldOrganizationID)
+}
+
 // ClearUser clears the "user" edge to the User entity.
 func (m *APIKeyMutation) ClearUser() {
 	m.cleareduser = true
@@ -944,7 +1507,7 @@ func (m *APIKeyMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *APIKeyMutation) Fields() []string {
-	fields := make([]string, 0, 13)
+	fields := make([]string, 0, 22)
 	if m.created_at != nil {
 		fields = append(fields, apikey.FieldCreatedAt)
 	}
@@ -984,6 +1547,33 @@ func (m *APIKeyMutation) Fields() []string {
 	if m.expires_at != nil {
 		fields = append(fields, apikey.FieldExpiresAt)
 	}
+	if m.daily_limit_usd != nil {
+		fields = append(fields, apikey.FieldDailyLimitUsd)
+	}
+	if m.weekly_limit_usd != nil {
+		fields = append(fields, apikey.FieldWeeklyLimitUsd)
+	}
+	if m.monthly_limit_usd != nil {
+		fields = append(fields, apikey.FieldMonthlyLimitUsd)
+	}
+	if m.previous_key != nil {
+		fields = append(fields, apikey.FieldPreviousKey)
+	}
+	if m.previous_key_expires_at != nil {
+		fields = append(fields, apikey.FieldPreviousKeyExpiresAt)
+	}
+	if m.key_generation != nil {
+		fields = append(fields, apikey.FieldKeyGeneration)
+	}
+	if m.rotated_at != nil {
+		fields = append(fields, apikey.FieldRotatedAt)
+	}
+	if m.allowed_tag_keys != nil {
+		fields = append(fields, apikey.FieldAllowedTagKeys)
+	}
+	if m.organization_id != nil {
+		fields = append(fields, apikey.FieldOrganizationID)
+	}
 	return fields
 }
 
@@ -1018,6 +1608,24 @@ func (m *APIKeyMutation) Field(name string) (ent.Value, bool) {
 		return m.QuotaUsed()
 	case apikey.FieldExpiresAt:
 		return m.ExpiresAt()
+	case apikey.FieldDailyLimitUsd:
+		return m.DailyLimitUsd()
+	case apikey.FieldWeeklyLimitUsd:
+		return m.WeeklyLimitUsd()
+	case apikey.FieldMonthlyLimitUsd:
+		return m.MonthlyLimitUsd()
+	case apikey.FieldPreviousKey:
+		return m.PreviousKey()
+	case apikey.FieldPreviousKeyExpiresAt:
+		return m.PreviousKeyExpiresAt()
+	case apikey.FieldKeyGeneration:
+		return m.KeyGeneration()
+	case apikey.FieldRotatedAt:
+		return m.RotatedAt()
+	case apikey.FieldAllowedTagKeys:
+		return m.AllowedTagKeys()
+	case apikey.FieldOrganizationID:
+		return m.OrganizationID()
 	}
 	return nil, false
 }
@@ -1053,6 +1661,24 @@ func (m *APIKeyMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldQuotaUsed(ctx)
 	case apikey.FieldExpiresAt:
 		return m.OldExpiresAt(ctx)
+	case apikey.FieldDailyLimitUsd:
+		return m.OldDailyLimitUsd(ctx)
+	case apikey.FieldWeeklyLimitUsd:
+		return m.OldWeeklyLimitUsd(ctx)
+	case apikey.FieldMonthlyLimitUsd:
+		return m.OldMonthlyLimitUsd(ctx)
+	case apikey.FieldPreviousKey:
+		return m.OldPreviousKey(ctx)
+	case apikey.FieldPreviousKeyExpiresAt:
+		return m.OldPreviousKeyExpiresAt(ctx)
+	case apikey.FieldKeyGeneration:
+		return m.OldKeyGeneration(ctx)
+	case apikey.FieldRotatedAt:
+		return m.OldRotatedAt(ctx)
+	case apikey.FieldAllowedTagKeys:
+		return m.OldAllowedTagKeys(ctx)
+	case apikey.FieldOrganizationID:
+		return m.OldOrganizationID(ctx)
 	}
 	return nil, fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -1153,6 +1779,69 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetExpiresAt(v)
 		return nil
+	case apikey.FieldDailyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetDailyLimitUsd(v)
+		return nil
+	case apikey.FieldWeeklyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetWeeklyLimitUsd(v)
+		return nil
+	case apikey.FieldMonthlyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetMonthlyLimitUsd(v)
+		return nil
+	case apikey.FieldPreviousKey:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetPreviousKey(v)
+		return nil
+	case apikey.FieldPreviousKeyExpiresAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetPreviousKeyExpiresAt(v)
+		return nil
+	case apikey.FieldKeyGeneration:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetKeyGeneration(v)
+		return nil
+	case apikey.FieldRotatedAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRotatedAt(v)
+		return nil
+	case apikey.FieldAllowedTagKeys:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAllowedTagKeys(v)
+		return nil
+	case apikey.FieldOrganizationID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetOrganizationID(v)
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -1167,6 +1856,21 @@ func (m *APIKeyMutation) AddedFields() []string {
 	if m.addquota_used != nil {
 		fields = append(fields, apikey.FieldQuotaUsed)
 	}
+	if m.adddaily_limit_usd != nil {
+		fields = append(fields, apikey.FieldDailyLimitUsd)
+	}
+	if m.addweekly_limit_usd != nil {
+		fields = append(fields, apikey.FieldWeeklyLimitUsd)
+	}
+	if m.addmonthly_limit_usd != nil {
+		fields = append(fields, apikey.FieldMonthlyLimitUsd)
+	}
+	if m.addkey_generation != nil {
+		fields = append(fields, apikey.FieldKeyGeneration)
+	}
+	if m.addorganization_id != nil {
+		fields = append(fields, apikey.FieldOrganizationID)
+	}
 	return fields
 }
 
@@ -1179,6 +1883,16 @@ func (m *APIKeyMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedQuota()
 	case apikey.FieldQuotaUsed:
 		return m.AddedQuotaUsed()
+	case apikey.FieldDailyLimitUsd:
+		return m.AddedDailyLimitUsd()
+	case apikey.FieldWeeklyLimitUsd:
+		return m.AddedWeeklyLimitUsd()
+	case apikey.FieldMonthlyLimitUsd:
+		return m.AddedMonthlyLimitUsd()
+	case apikey.FieldKeyGeneration:
+		return m.AddedKeyGeneration()
+	case apikey.FieldOrganizationID:
+		return m.AddedOrganizationID()
 	}
 	return nil, false
 }
@@ -1202,6 +1916,41 @@ func (m *APIKeyMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddQuotaUsed(v)
 		return nil
+	case apikey.FieldDailyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddDailyLimitUsd(v)
+		return nil
+	case apikey.FieldWeeklyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddWeeklyLimitUsd(v)
+		return nil
+	case apikey.FieldMonthlyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddMonthlyLimitUsd(v)
+		return nil
+	case apikey.FieldKeyGeneration:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddKeyGeneration(v)
+		return nil
+	case apikey.FieldOrganizationID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddOrganizationID(v)
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey numeric field %s", name)
 }
@@ -1225,6 +1974,30 @@ func (m *APIKeyMutation) ClearedFields() []string {
 	if m.FieldCleared(apikey.FieldExpiresAt) {
 		fields = append(fields, apikey.FieldExpiresAt)
 	}
+	if m.FieldCleared(apikey.FieldDailyLimitUsd) {
+		fields = append(fields, apikey.FieldDailyLimitUsd)
+	}
+	if m.FieldCleared(apikey.FieldWeeklyLimitUsd) {
+		fields = append(fields, apikey.FieldWeeklyLimitUsd)
+	}
+	if m.FieldCleared(apikey.FieldMonthlyLimitUsd) {
+		fields = append(fields, apikey.FieldMonthlyLimitUsd)
+	}
+	if m.FieldCleared(apikey.FieldPreviousKey) {
+		fields = append(fields, apikey.FieldPreviousKey)
+	}
+	if m.FieldCleared(apikey.FieldPreviousKeyExpiresAt) {
+		fields = append(fields, apikey.FieldPreviousKeyExpiresAt)
+	}
+	if m.FieldCleared(apikey.FieldRotatedAt) {
+		fields = append(fields, apikey.FieldRotatedAt)
+	}
+	if m.FieldCleared(apikey.FieldAllowedTagKeys) {
+		fields = append(fields, apikey.FieldAllowedTagKeys)
+	}
+	if m.FieldCleared(apikey.FieldOrganizationID) {
+		fields = append(fields, apikey.FieldOrganizationID)
+	}
 	return fields
 }
 
@@ -1245,14 +2018,38 @@ func (m *APIKeyMutation) ClearField(name string) error {
 	case apikey.FieldGroupID:
 		m.ClearGroupID()
 		return nil
-	case apikey.FieldIPWhitelist:
-		m.ClearIPWhitelist()
+	case apikey.FieldIPWhitelist:
+		m.ClearIPWhitelist()
+		return nil
+	case apikey.FieldIPBlacklist:
+		m.ClearIPBlacklist()
+		return nil
+	case apikey.FieldExpiresAt:
+		m.ClearExpiresAt()
+		return nil
+	case apikey.FieldDailyLimitUsd:
+		m.ClearDailyLimitUsd()
+		return nil
+	case apikey.FieldWeeklyLimitUsd:
+		m.ClearWeeklyLimitUsd()
+		return nil
+	case apikey.FieldMonthlyLimitUsd:
+		m.ClearMonthlyLimitUsd()
+		return nil
+	case apikey.FieldPreviousKey:
+		m.ClearPreviousKey()
+		return nil
+	case apikey.FieldPreviousKeyExpiresAt:
+		m.ClearPreviousKeyExpiresAt()
+		return nil
+	case apikey.FieldRotatedAt:
+		m.ClearRotatedAt()
 		return nil
-	case apikey.FieldIPBlacklist:
-		m.ClearIPBlacklist()
+	case apikey.FieldAllowedTagKeys:
+		m.ClearAllowedTagKeys()
 		return nil
-	case apikey.FieldExpiresAt:
-		m.ClearExpiresAt()
+	case apikey.FieldOrganizationID:
+		m.ClearOrganizationID()
 		return nil
 	}
 	return fmt.Errorf("unknown APIKey nullable field %s", name)
@@ -1301,6 +2098,33 @@ func (m *APIKeyMutation) ResetField(name string) error {
 	case apikey.FieldExpiresAt:
 		m.ResetExpiresAt()
 		return nil
+	case apikey.FieldDailyLimitUsd:
+		m.ResetDailyLimitUsd()
+		return nil
+	case apikey.FieldWeeklyLimitUsd:
+		m.ResetWeeklyLimitUsd()
+		return nil
+	case apikey.FieldMonthlyLimitUsd:
+		m.ResetMonthlyLimitUsd()
+		return nil
+	case apikey.FieldPreviousKey:
+		m.ResetPreviousKey()
+		return nil
+	case apikey.FieldPreviousKeyExpiresAt:
+		m.ResetPreviousKeyExpiresAt()
+		return nil
+	case apikey.FieldKeyGeneration:
+		m.ResetKeyGeneration()
+		return nil
+	case apikey.FieldRotatedAt:
+		m.ResetRotatedAt()
+		return nil
+	case apikey.FieldAllowedTagKeys:
+		m.ResetAllowedTagKeys()
+		return nil
+	case apikey.FieldOrganizationID:
+		m.ResetOrganizationID()
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -1440,6 +2264,8 @@ type AccountMutation struct {
 	_type                 *string
 	credentials           *map[string]interface{}
 	extra                 *map[string]interface{}
+	proxy_pool_id         *int64
+	addproxy_pool_id      *int64
 	concurrency           *int
 	addconcurrency        *int
 	priority              *int
@@ -1969,6 +2795,76 @@ func (m *AccountMutation) ResetProxyID() {
 	delete(m.clearedFields, account.FieldProxyID)
 }
 
+// SetProxyPoolID sets the "proxy_pool_id" field.
+func (m *AccountMutation) SetProxyPoolID(i int64) {
+	m.proxy_pool_id = &i
+	m.addproxy_pool_id = nil
+}
+
+// ProxyPoolID returns the value of the "proxy_pool_id" field in the mutation.
+func (m *AccountMutation) ProxyPoolID() (r int64, exists bool) {
+	v := m.proxy_pool_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldProxyPoolID returns the old "proxy_pool_id" field's value of the Account entity.
+// If the Account object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *AccountMutation) OldProxyPoolID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldProxyPoolID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldProxyPoolID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldProxyPoolID: %w", err)
+	}
+	return oldValue.ProxyPoolID, nil
+}
+
+// AddProxyPoolID adds i to the "proxy_pool_id" field.
+func (m *AccountMutation) AddProxyPoolID(i int64) {
+	if m.addproxy_pool_id != nil {
+		*m.addproxy_pool_id += i
+	} else {
+		m.addproxy_pool_id = &i
+	}
+}
+
+// AddedProxyPoolID returns the value that was added to the "proxy_pool_id" field in this mutation.
+func (m *AccountMutation) AddedProxyPoolID() (r int64, exists bool) {
+	v := m.addproxy_pool_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearProxyPoolID clears the value of the "proxy_pool_id" field.
+func (m *AccountMutation) ClearProxyPoolID() {
+	m.proxy_pool_id = nil
+	m.addproxy_pool_id = nil
+	m.clearedFields[account.FieldProxyPoolID] = struct{}{}
+}
+
+// ProxyPoolIDCleared returns if the "proxy_pool_id" field was cleared in this mutation.
+func (m *AccountMutation) ProxyPoolIDCleared() bool {
+	_, ok := m.clearedFields[account.FieldProxyPoolID]
+	return ok
+}
+
+// ResetProxyPoolID resets all changes to the "proxy_pool_id" field.
+func (m *AccountMutation) ResetProxyPoolID() {
+	m.proxy_pool_id = nil
+	m.addproxy_pool_id = nil
+	delete(m.clearedFields, account.FieldProxyPoolID)
+}
+
 // SetConcurrency sets the "concurrency" field.
 func (m *AccountMutation) SetConcurrency(i int) {
 	m.concurrency = &i
@@ -2855,7 +3751,7 @@ func (m *AccountMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *AccountMutation) Fields() []string {
-	fields := make([]string, 0, 25)
+	fields := make([]string, 0, 26)
 	if m.created_at != nil {
 		fields = append(fields, account.FieldCreatedAt)
 	}
@@ -2886,6 +3782,9 @@ func (m *AccountMutation) Fields() []string {
 	if m.proxy != nil {
 		fields = append(fields, account.FieldProxyID)
 	}
+	if m.proxy_pool_id != nil {
+		fields = append(fields, account.FieldProxyPoolID)
+	}
 	if m.concurrency != nil {
 		fields = append(fields, account.FieldConcurrency)
 	}
@@ -2959,6 +3858,8 @@ func (m *AccountMutation) Field(name string) (ent.Value, bool) {
 		return m.Extra()
 	case account.FieldProxyID:
 		return m.ProxyID()
+	case account.FieldProxyPoolID:
+		return m.ProxyPoolID()
 	case account.FieldConcurrency:
 		return m.Concurrency()
 	case account.FieldPriority:
@@ -3018,6 +3919,8 @@ func (m *AccountMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldExtra(ctx)
 	case account.FieldProxyID:
 		return m.OldProxyID(ctx)
+	case account.FieldProxyPoolID:
+		return m.OldProxyPoolID(ctx)
 	case account.FieldConcurrency:
 		return m.OldConcurrency(ctx)
 	case account.FieldPriority:
@@ -3127,6 +4030,13 @@ func (m *AccountMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetProxyID(v)
 		return nil
+	case account.FieldProxyPoolID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetProxyPoolID(v)
+		return nil
 	case account.FieldConcurrency:
 		v, ok := value.(int)
 		if !ok {
@@ -3240,6 +4150,9 @@ func (m *AccountMutation) SetField(name string, value ent.Value) error {
 // this mutation.
 func (m *AccountMutation) AddedFields() []string {
 	var fields []string
+	if m.addproxy_pool_id != nil {
+		fields = append(fields, account.FieldProxyPoolID)
+	}
 	if m.addconcurrency != nil {
 		fields = append(fields, account.FieldConcurrency)
 	}
@@ -3257,6 +4170,8 @@ func (m *AccountMutation) AddedFields() []string {
 // was not set, or was not defined in the schema.
 func (m *AccountMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
+	case account.FieldProxyPoolID:
+		return m.AddedProxyPoolID()
 	case account.FieldConcurrency:
 		return m.AddedConcurrency()
 	case account.FieldPriority:
@@ -3272,6 +4187,13 @@ func (m *AccountMutation) AddedField(name string) (ent.Value, bool) {
 // type.
 func (m *AccountMutation) AddField(name string, value ent.Value) error {
 	switch name {
+	case account.FieldProxyPoolID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddProxyPoolID(v)
+		return nil
 	case account.FieldConcurrency:
 		v, ok := value.(int)
 		if !ok {
@@ -3310,6 +4232,9 @@ func (m *AccountMutation) ClearedFields() []string {
 	if m.FieldCleared(account.FieldProxyID) {
 		fields = append(fields, account.FieldProxyID)
 	}
+	if m.FieldCleared(account.FieldProxyPoolID) {
+		fields = append(fields, account.FieldProxyPoolID)
+	}
 	if m.FieldCleared(account.FieldErrorMessage) {
 		fields = append(fields, account.FieldErrorMessage)
 	}
@@ -3360,6 +4285,9 @@ func (m *AccountMutation) ClearField(name string) error {
 	case account.FieldProxyID:
 		m.ClearProxyID()
 		return nil
+	case account.FieldProxyPoolID:
+		m.ClearProxyPoolID()
+		return nil
 	case account.FieldErrorMessage:
 		m.ClearErrorMessage()
 		return nil
@@ -3425,6 +4353,9 @@ func (m *AccountMutation) ResetField(name string) error {
 	case account.FieldProxyID:
 		m.ResetProxyID()
 		return nil
+	case account.FieldProxyPoolID:
+		m.ResetProxyPoolID()
+		return nil
 	case account.FieldConcurrency:
 		m.ResetConcurrency()
 		return nil
@@ -7115,6 +8046,10 @@ type GroupMutation struct {
 	appendsupported_model_scopes            []string
 	sort_order                              *int
 	addsort_order                           *int
+	selection_strategy                      *string
+	fair_share_enabled                      *bool
+	model_fallback                          *map[string][]string
+	model_fallback_enabled                  *bool
 	clearedFields                           map[string]struct{}
 	api_keys                                map[int64]struct{}
 	removedapi_keys                         map[int64]struct{}
@@ -8523,6 +9458,163 @@ func (m *GroupMutation) ResetSortOrder() {
 	m.addsort_order = nil
 }
 
+// SetSelectionStrategy sets the "selection_strategy" field.
+func (m *GroupMutation) SetSelectionStrategy(s string) {
+	m.selection_strategy = &s
+}
+
+// SelectionStrategy returns the value of the "selection_strategy" field in the mutation.
+func (m *GroupMutation) SelectionStrategy() (r string, exists bool) {
+	v := m.selection_strategy
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldSelectionStrategy returns the old "selection_strategy" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldSelectionStrategy(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldSelectionStrategy is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldSelectionStrategy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldSelectionStrategy: %w", err)
+	}
+	return oldValue.SelectionStrategy, nil
+}
+
+// ResetSelectionStrategy resets all changes to the "selection_strategy" field.
+func (m *GroupMutation) ResetSelectionStrategy() {
+	m.selection_strategy = nil
+}
+
+// SetFairShareEnabled sets the "fair_share_enabled" field.
+func (m *GroupMutation) SetFairShareEnabled(b bool) {
+	m.fair_share_enabled = &b
+}
+
+// FairShareEnabled returns the value of the "fair_share_enabled" field in the mutation.
+func (m *GroupMutation) FairShareEnabled() (r bool, exists bool) {
+	v := m.fair_share_enabled
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldFairShareEnabled returns the old "fair_share_enabled" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldFairShareEnabled(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldFairShareEnabled is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldFairShareEnabled requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldFairShareEnabled: %w", err)
+	}
+	return oldValue.FairShareEnabled, nil
+}
+
+// ResetFairShareEnabled resets all changes to the "fair_share_enabled" field.
+func (m *GroupMutation) ResetFairShareEnabled() {
+	m.fair_share_enabled = nil
+}
+
+// SetModelFallback sets the "model_fallback" field.
+func (m *GroupMutation) SetModelFallback(value map[string][]string) {
+	m.model_fallback = &value
+}
+
+// ModelFallback returns the value of the "model_fallback" field in the mutation.
+func (m *GroupMutation) ModelFallback() (r map[string][]string, exists bool) {
+	v := m.model_fallback
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldModelFallback returns the old "model_fallback" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldModelFallback(ctx context.Context) (v map[string][]string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldModelFallback is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldModelFallback requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldModelFallback: %w", err)
+	}
+	return oldValue.ModelFallback, nil
+}
+
+// ClearModelFallback clears the value of the "model_fallback" field.
+func (m *GroupMutation) ClearModelFallback() {
+	m.model_fallback = nil
+	m.clearedFields[group.FieldModelFallback] = struct{}{}
+}
+
+// ModelFallbackCleared returns if the "model_fallback" field was cleared in this mutation.
+func (m *GroupMutation) ModelFallbackCleared() bool {
+	_, ok := m.clearedFields[group.FieldModelFallback]
+	return ok
+}
+
+// ResetModelFallback resets all changes to the "model_fallback" field.
+func (m *GroupMutation) ResetModelFallback() {
+	m.model_fallback = nil
+	delete(m.clearedFields, group.FieldModelFallback)
+}
+
+// SetModelFallbackEnabled sets the "model_fallback_enabled" field.
+func (m *GroupMutation) SetModelFallbackEnabled(b bool) {
+	m.model_fallback_enabled = &b
+}
+
+// ModelFallbackEnabled returns the value of the "model_fallback_enabled" field in the mutation.
+func (m *GroupMutation) ModelFallbackEnabled() (r bool, exists bool) {
+	v := m.model_fallback_enabled
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldModelFallbackEnabled returns the old "model_fallback_enabled" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldModelFallbackEnabled(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldModelFallbackEnabled is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldModelFallbackEnabled requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldModelFallbackEnabled: %w", err)
+	}
+	return oldValue.ModelFallbackEnabled, nil
+}
+
+// ResetModelFallbackEnabled resets all changes to the "model_fallback_enabled" field.
+func (m *GroupMutation) ResetModelFallbackEnabled() {
+	m.model_fallback_enabled = nil
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
 func (m *GroupMutation) AddAPIKeyIDs(ids ...int64) {
 	if m.api_keys == nil {
@@ -8881,7 +9973,7 @@ func (m *GroupMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *GroupMutation) Fields() []string {
-	fields := make([]string, 0, 25)
+	fields := make([]string, 0, 29)
 	if m.created_at != nil {
 		fields = append(fields, group.FieldCreatedAt)
 	}
@@ -8957,6 +10049,18 @@ func (m *GroupMutation) Fields() []string {
 	if m.sort_order != nil {
 		fields = append(fields, group.FieldSortOrder)
 	}
+	if m.selection_strategy != nil {
+		fields = append(fields, group.FieldSelectionStrategy)
+	}
+	if m.fair_share_enabled != nil {
+		fields = append(fields, group.FieldFairShareEnabled)
+	}
+	if m.model_fallback != nil {
+		fields = append(fields, group.FieldModelFallback)
+	}
+	if m.model_fallback_enabled != nil {
+		fields = append(fields, group.FieldModelFallbackEnabled)
+	}
 	return fields
 }
 
@@ -9015,6 +10119,14 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.SupportedModelScopes()
 	case group.FieldSortOrder:
 		return m.SortOrder()
+	case group.FieldSelectionStrategy:
+		return m.SelectionStrategy()
+	case group.FieldFairShareEnabled:
+		return m.FairShareEnabled()
+	case group.FieldModelFallback:
+		return m.ModelFallback()
+	case group.FieldModelFallbackEnabled:
+		return m.ModelFallbackEnabled()
 	}
 	return nil, false
 }
@@ -9074,6 +10186,14 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldSupportedModelScopes(ctx)
 	case group.FieldSortOrder:
 		return m.OldSortOrder(ctx)
+	case group.FieldSelectionStrategy:
+		return m.OldSelectionStrategy(ctx)
+	case group.FieldFairShareEnabled:
+		return m.OldFairShareEnabled(ctx)
+	case group.FieldModelFallback:
+		return m.OldModelFallback(ctx)
+	case group.FieldModelFallbackEnabled:
+		return m.OldModelFallbackEnabled(ctx)
 	}
 	return nil, fmt.Errorf("unknown Group field %s", name)
 }
@@ -9258,6 +10378,34 @@ func (m *GroupMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetSortOrder(v)
 		return nil
+	case group.FieldSelectionStrategy:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetSelectionStrategy(v)
+		return nil
+	case group.FieldFairShareEnabled:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetFairShareEnabled(v)
+		return nil
+	case group.FieldModelFallback:
+		v, ok := value.(map[string][]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelFallback(v)
+		return nil
+	case group.FieldModelFallbackEnabled:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelFallbackEnabled(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -9456,6 +10604,9 @@ func (m *GroupMutation) ClearedFields() []string {
 	if m.FieldCleared(group.FieldModelRouting) {
 		fields = append(fields, group.FieldModelRouting)
 	}
+	if m.FieldCleared(group.FieldModelFallback) {
+		fields = append(fields, group.FieldModelFallback)
+	}
 	return fields
 }
 
@@ -9503,6 +10654,9 @@ func (m *GroupMutation) ClearField(name string) error {
 	case group.FieldModelRouting:
 		m.ClearModelRouting()
 		return nil
+	case group.FieldModelFallback:
+		m.ClearModelFallback()
+		return nil
 	}
 	return fmt.Errorf("unknown Group nullable field %s", name)
 }
@@ -9586,6 +10740,18 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldSortOrder:
 		m.ResetSortOrder()
 		return nil
+	case group.FieldSelectionStrategy:
+		m.ResetSelectionStrategy()
+		return nil
+	case group.FieldFairShareEnabled:
+		m.ResetFairShareEnabled()
+		return nil
+	case group.FieldModelFallback:
+		m.ResetModelFallback()
+		return nil
+	case group.FieldModelFallbackEnabled:
+		m.ResetModelFallbackEnabled()
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -17910,6 +19076,7 @@ type UserMutation struct {
 	email                         *string
 	password_hash                 *string
 	role                          *string
+	admin_role                    *string
 	balance                       *float64
 	addbalance                    *float64
 	concurrency                   *int
@@ -17920,6 +19087,8 @@ type UserMutation struct {
 	totp_secret_encrypted         *string
 	totp_enabled                  *bool
 	totp_enabled_at               *time.Time
+	allowed_tag_keys              *[]string
+	appendallowed_tag_keys        []string
 	clearedFields                 map[string]struct{}
 	api_keys                      map[int64]struct{}
 	removedapi_keys               map[int64]struct{}
@@ -18280,6 +19449,42 @@ func (m *UserMutation) ResetRole() {
 	m.role = nil
 }
 
+// SetAdminRole sets the "admin_role" field.
+func (m *UserMutation) SetAdminRole(s string) {
+	m.admin_role = &s
+}
+
+// AdminRole returns the value of the "admin_role" field in the mutation.
+func (m *UserMutation) AdminRole() (r string, exists bool) {
+	v := m.admin_role
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAdminRole returns the old "admin_role" field's value of the User entity.
+// If the User object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserMutation) OldAdminRole(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAdminRole is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAdminRole requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAdminRole: %w", err)
+	}
+	return oldValue.AdminRole, nil
+}
+
+// ResetAdminRole resets all changes to the "admin_role" field.
+func (m *UserMutation) ResetAdminRole() {
+	m.admin_role = nil
+}
+
 // SetBalance sets the "balance" field.
 func (m *UserMutation) SetBalance(f float64) {
 	m.balance = &f
@@ -18634,6 +19839,71 @@ func (m *UserMutation) ResetTotpEnabledAt() {
 	delete(m.clearedFields, user.FieldTotpEnabledAt)
 }
 
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (m *UserMutation) SetAllowedTagKeys(s []string) {
+	m.allowed_tag_keys = &s
+	m.appendallowed_tag_keys = nil
+}
+
+// AllowedTagKeys returns the value of the "allowed_tag_keys" field in the mutation.
+func (m *UserMutation) AllowedTagKeys() (r []string, exists bool) {
+	v := m.allowed_tag_keys
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAllowedTagKeys returns the old "allowed_tag_keys" field's value of the User entity.
+// If the User object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserMutation) OldAllowedTagKeys(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAllowedTagKeys is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAllowedTagKeys requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAllowedTagKeys: %w", err)
+	}
+	return oldValue.AllowedTagKeys, nil
+}
+
+// AppendAllowedTagKeys adds s to the "allowed_tag_keys" field.
+func (m *UserMutation) AppendAllowedTagKeys(s []string) {
+	m.appendallowed_tag_keys = append(m.appendallowed_tag_keys, s...)
+}
+
+// AppendedAllowedTagKeys returns the list of values that were appended to the "allowed_tag_keys" field in this mutation.
+func (m *UserMutation) AppendedAllowedTagKeys() ([]string, bool) {
+	if len(m.appendallowed_tag_keys) == 0 {
+		return nil, false
+	}
+	return m.appendallowed_tag_keys, true
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (m *UserMutation) ClearAllowedTagKeys() {
+	m.allowed_tag_keys = nil
+	m.appendallowed_tag_keys = nil
+	m.clearedFields[user.FieldAllowedTagKeys] = struct{}{}
+}
+
+// AllowedTagKeysCleared returns if the "allowed_tag_keys" field was cleared in this mutation.
+func (m *UserMutation) AllowedTagKeysCleared() bool {
+	_, ok := m.clearedFields[user.FieldAllowedTagKeys]
+	return ok
+}
+
+// ResetAllowedTagKeys resets all changes to the "allowed_tag_keys" field.
+func (m *UserMutation) ResetAllowedTagKeys() {
+	m.allowed_tag_keys = nil
+	m.appendallowed_tag_keys = nil
+	delete(m.clearedFields, user.FieldAllowedTagKeys)
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
 func (m *UserMutation) AddAPIKeyIDs(ids ...int64) {
 	if m.api_keys == nil {
@@ -19154,7 +20424,7 @@ func (m *UserMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *UserMutation) Fields() []string {
-	fields := make([]string, 0, 14)
+	fields := make([]string, 0, 16)
 	if m.created_at != nil {
 		fields = append(fields, user.FieldCreatedAt)
 	}
@@ -19173,6 +20443,9 @@ func (m *UserMutation) Fields() []string {
 	if m.role != nil {
 		fields = append(fields, user.FieldRole)
 	}
+	if m.admin_role != nil {
+		fields = append(fields, user.FieldAdminRole)
+	}
 	if m.balance != nil {
 		fields = append(fields, user.FieldBalance)
 	}
@@ -19197,6 +20470,9 @@ func (m *UserMutation) Fields() []string {
 	if m.totp_enabled_at != nil {
 		fields = append(fields, user.FieldTotpEnabledAt)
 	}
+	if m.allowed_tag_keys != nil {
+		fields = append(fields, user.FieldAllowedTagKeys)
+	}
 	return fields
 }
 
@@ -19217,6 +20493,8 @@ func (m *UserMutation) Field(name string) (ent.Value, bool) {
 		return m.PasswordHash()
 	case user.FieldRole:
 		return m.Role()
+	case user.FieldAdminRole:
+		return m.AdminRole()
 	case user.FieldBalance:
 		return m.Balance()
 	case user.FieldConcurrency:
@@ -19233,6 +20511,8 @@ func (m *UserMutation) Field(name string) (ent.Value, bool) {
 		return m.TotpEnabled()
 	case user.FieldTotpEnabledAt:
 		return m.TotpEnabledAt()
+	case user.FieldAllowedTagKeys:
+		return m.AllowedTagKeys()
 	}
 	return nil, false
 }
@@ -19254,6 +20534,8 @@ func (m *UserMutation) OldField(ctx context.Context, name string) (ent.Value, er
 		return m.OldPasswordHash(ctx)
 	case user.FieldRole:
 		return m.OldRole(ctx)
+	case user.FieldAdminRole:
+		return m.OldAdminRole(ctx)
 	case user.FieldBalance:
 		return m.OldBalance(ctx)
 	case user.FieldConcurrency:
@@ -19270,6 +20552,8 @@ func (m *UserMutation) OldField(ctx context.Context, name string) (ent.Value, er
 		return m.OldTotpEnabled(ctx)
 	case user.FieldTotpEnabledAt:
 		return m.OldTotpEnabledAt(ctx)
+	case user.FieldAllowedTagKeys:
+		return m.OldAllowedTagKeys(ctx)
 	}
 	return nil, fmt.Errorf("unknown User field %s", name)
 }
@@ -19321,6 +20605,13 @@ func (m *UserMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetRole(v)
 		return nil
+	case user.FieldAdminRole:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAdminRole(v)
+		return nil
 	case user.FieldBalance:
 		v, ok := value.(float64)
 		if !ok {
@@ -19377,6 +20668,13 @@ func (m *UserMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetTotpEnabledAt(v)
 		return nil
+	case user.FieldAllowedTagKeys:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAllowedTagKeys(v)
+		return nil
 	}
 	return fmt.Errorf("unknown User field %s", name)
 }
@@ -19443,6 +20741,9 @@ func (m *UserMutation) ClearedFields() []string {
 	if m.FieldCleared(user.FieldTotpEnabledAt) {
 		fields = append(fields, user.FieldTotpEnabledAt)
 	}
+	if m.FieldCleared(user.FieldAllowedTagKeys) {
+		fields = append(fields, user.FieldAllowedTagKeys)
+	}
 	return fields
 }
 
@@ -19466,6 +20767,9 @@ func (m *UserMutation) ClearField(name string) error {
 	case user.FieldTotpEnabledAt:
 		m.ClearTotpEnabledAt()
 		return nil
+	case user.FieldAllowedTagKeys:
+		m.ClearAllowedTagKeys()
+		return nil
 	}
 	return fmt.Errorf("unknown User nullable field %s", name)
 }
@@ -19492,6 +20796,9 @@ func (m *UserMutation) ResetField(name string) error {
 	case user.FieldRole:
 		m.ResetRole()
 		return nil
+	case user.FieldAdminRole:
+		m.ResetAdminRole()
+		return nil
 	case user.FieldBalance:
 		m.ResetBalance()
 		return nil
@@ -19516,6 +20823,9 @@ func (m *UserMutation) ResetField(name string) error {
 	case user.FieldTotpEnabledAt:
 		m.ResetTotpEnabledAt()
 		return nil
+	case user.FieldAllowedTagKeys:
+		m.ResetAllowedTagKeys()
+		return nil
 	}
 	return fmt.Errorf("unknown User field %s", name)
 }
@@ -92,6 +92,12 @@ func (Account) Fields() []ent.Field {
 			Optional().
 			Nillable(),
 
+		// proxy_pool_id: 关联的代理池 ID（可选，added by migration 059）
+		// 设置后优先于 proxy_id，由代理池按策略选择代理并在代理失效时自动切换
+		field.Int64("proxy_pool_id").
+			Optional().
+			Nillable(),
+
 		// concurrency: 账户最大并发请求数
 		// 用于限制同一时间对该账户发起的请求数量
 		field.Int("concurrency").
@@ -70,6 +70,54 @@ func (APIKey) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			Comment("Expiration time for this API key (null = never expires)"),
+
+		// ========== Rolling spend limits ==========
+		// 按配置时区的自然日/周/月重置（nil = 不限制）
+		field.Float("daily_limit_usd").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}).
+			Comment("Daily spend limit in USD (null = unlimited)"),
+		field.Float("weekly_limit_usd").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}).
+			Comment("Weekly spend limit in USD (null = unlimited)"),
+		field.Float("monthly_limit_usd").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}).
+			Comment("Monthly spend limit in USD (null = unlimited)"),
+
+		// ========== Rotation fields ==========
+		// 轮换后旧密钥在宽限期内仍可使用，到期后自动失效
+		field.String("previous_key").
+			MaxLen(128).
+			Optional().
+			Nillable().
+			Comment("Previous secret kept valid during the rotation grace period"),
+		field.Time("previous_key_expires_at").
+			Optional().
+			Nillable().
+			Comment("Expiration time of the previous secret"),
+		field.Int("key_generation").
+			Default(1).
+			Comment("Secret generation, incremented on every rotation"),
+		field.Time("rotated_at").
+			Optional().
+			Nillable().
+			Comment("Time of the last rotation"),
+
+		// ========== Cost attribution tags ==========
+		field.JSON("allowed_tag_keys", []string{}).
+			Optional().
+			Comment("Tag keys accepted on requests made with this key; empty falls back to the user's list"),
+
+		// ========== Organization ==========
+		field.Int64("organization_id").
+			Optional().
+			Nillable().
+			Comment("Owning organization; requests are billed to the organization pool when set"),
 	}
 }
 
@@ -98,5 +146,7 @@ func (APIKey) Indexes() []ent.Index {
 		// Index for quota queries
 		index.Fields("quota", "quota_used"),
 		index.Fields("expires_at"),
+		index.Fields("previous_key"),
+		index.Fields("organization_id"),
 	}
 }
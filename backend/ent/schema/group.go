@@ -126,6 +126,26 @@ func (Group) Fields() []ent.Field {
 		field.Int("sort_order").
 			Default(0).
 			Comment("分组显示排序，数值越小越靠前"),
+
+		// 账号选择策略 (added by migration 056)
+		field.String("selection_strategy").
+			MaxLen(20).
+			Default("default").
+			Comment("账号选择策略：default(优先级→负载→LRU), weighted(按权重随机), latency(按 EWMA 延迟/错误评分)"),
+
+		// 用户公平分配开关 (added by migration 057)
+		field.Bool("fair_share_enabled").
+			Default(false).
+			Comment("是否在分组内按用户 max-min 公平分配账号并发"),
+
+		// 模型降级链 (added by migration 058)
+		field.JSON("model_fallback", map[string][]string{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("模型降级链：模型模式 -> 按顺序尝试的降级模型列表"),
+		field.Bool("model_fallback_enabled").
+			Default(false).
+			Comment("是否启用模型降级链"),
 	}
 }
 
@@ -44,6 +44,10 @@ func (User) Fields() []ent.Field {
 		field.String("role").
 			MaxLen(20).
 			Default(domain.RoleUser),
+		// 管理员角色（仅 role=admin 时生效）：内置角色或自定义角色名，空表示超级管理员
+		field.String("admin_role").
+			MaxLen(50).
+			Default(""),
 		field.Float("balance").
 			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}).
 			Default(0),
@@ -72,6 +76,10 @@ func (User) Fields() []ent.Field {
 		field.Time("totp_enabled_at").
 			Optional().
 			Nillable(),
+
+		// 成本归属标签：请求可携带的标签键白名单（API Key 未单独配置时使用）
+		field.JSON("allowed_tag_keys", []string{}).
+			Optional(),
 	}
 }
 
@@ -27,6 +27,8 @@ const (
 	FieldPasswordHash = "password_hash"
 	// FieldRole holds the string denoting the role field in the database.
 	FieldRole = "role"
+	// FieldAdminRole holds the string denoting the admin_role field in the database.
+	FieldAdminRole = "admin_role"
 	// FieldBalance holds the string denoting the balance field in the database.
 	FieldBalance = "balance"
 	// FieldConcurrency holds the string denoting the concurrency field in the database.
@@ -43,6 +45,8 @@ const (
 	FieldTotpEnabled = "totp_enabled"
 	// FieldTotpEnabledAt holds the string denoting the totp_enabled_at field in the database.
 	FieldTotpEnabledAt = "totp_enabled_at"
+	// FieldAllowedTagKeys holds the string denoting the allowed_tag_keys field in the database.
+	FieldAllowedTagKeys = "allowed_tag_keys"
 	// EdgeAPIKeys holds the string denoting the api_keys edge name in mutations.
 	EdgeAPIKeys = "api_keys"
 	// EdgeRedeemCodes holds the string denoting the redeem_codes edge name in mutations.
@@ -144,6 +148,7 @@ var Columns = []string{
 	FieldEmail,
 	FieldPasswordHash,
 	FieldRole,
+	FieldAdminRole,
 	FieldBalance,
 	FieldConcurrency,
 	FieldStatus,
@@ -152,6 +157,7 @@ var Columns = []string{
 	FieldTotpSecretEncrypted,
 	FieldTotpEnabled,
 	FieldTotpEnabledAt,
+	FieldAllowedTagKeys,
 }
 
 var (
@@ -192,6 +198,10 @@ var (
 	DefaultRole string
 	// RoleValidator is a validator for the "role" field. It is called by the builders before save.
 	RoleValidator func(string) error
+	// DefaultAdminRole holds the default value on creation for the "admin_role" field.
+	DefaultAdminRole string
+	// AdminRoleValidator is a validator for the "admin_role" field. It is called by the builders before save.
+	AdminRoleValidator func(string) error
 	// DefaultBalance holds the default value on creation for the "balance" field.
 	DefaultBalance float64
 	// DefaultConcurrency holds the default value on creation for the "concurrency" field.
@@ -248,6 +258,11 @@ func ByRole(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldRole, opts...).ToFunc()
 }
 
+// ByAdminRole orders the results by the admin_role field.
+func ByAdminRole(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldAdminRole, opts...).ToFunc()
+}
+
 // ByBalance orders the results by the balance field.
 func ByBalance(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldBalance, opts...).ToFunc()
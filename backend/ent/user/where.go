@@ -85,6 +85,11 @@ func Role(v string) predicate.User {
 	return predicate.User(sql.FieldEQ(FieldRole, v))
 }
 
+// AdminRole applies equality check predicate on the "admin_role" field. It's identical to AdminRoleEQ.
+func AdminRole(v string) predicate.User {
+	return predicate.User(sql.FieldEQ(FieldAdminRole, v))
+}
+
 // Balance applies equality check predicate on the "balance" field. It's identical to BalanceEQ.
 func Balance(v float64) predicate.User {
 	return predicate.User(sql.FieldEQ(FieldBalance, v))
@@ -450,6 +455,71 @@ func RoleContainsFold(v string) predicate.User {
 	return predicate.User(sql.FieldContainsFold(FieldRole, v))
 }
 
+// AdminRoleEQ applies the EQ predicate on the "admin_role" field.
+func AdminRoleEQ(v string) predicate.User {
+	return predicate.User(sql.FieldEQ(FieldAdminRole, v))
+}
+
+// AdminRoleNEQ applies the NEQ predicate on the "admin_role" field.
+func AdminRoleNEQ(v string) predicate.User {
+	return predicate.User(sql.FieldNEQ(FieldAdminRole, v))
+}
+
+// AdminRoleIn applies the In predicate on the "admin_role" field.
+func AdminRoleIn(vs ...string) predicate.User {
+	return predicate.User(sql.FieldIn(FieldAdminRole, vs...))
+}
+
+// AdminRoleNotIn applies the NotIn predicate on the "admin_role" field.
+func AdminRoleNotIn(vs ...string) predicate.User {
+	return predicate.User(sql.FieldNotIn(FieldAdminRole, vs...))
+}
+
+// AdminRoleGT applies the GT predicate on the "admin_role" field.
+func AdminRoleGT(v string) predicate.User {
+	return predicate.User(sql.FieldGT(FieldAdminRole, v))
+}
+
+// AdminRoleGTE applies the GTE predicate on the "admin_role" field.
+func AdminRoleGTE(v string) predicate.User {
+	return predicate.User(sql.FieldGTE(FieldAdminRole, v))
+}
+
+// AdminRoleLT applies the LT predicate on the "admin_role" field.
+func AdminRoleLT(v string) predicate.User {
+	return predicate.User(sql.FieldLT(FieldAdminRole, v))
+}
+
+// AdminRoleLTE applies the LTE predicate on the "admin_role" field.
+func AdminRoleLTE(v string) predicate.User {
+	return predicate.User(sql.FieldLTE(FieldAdminRole, v))
+}
+
+// AdminRoleContains applies the Contains predicate on the "admin_role" field.
+func AdminRoleContains(v string) predicate.User {
+	return predicate.User(sql.FieldContains(FieldAdminRole, v))
+}
+
+// AdminRoleHasPrefix applies the HasPrefix predicate on the "admin_role" field.
+func AdminRoleHasPrefix(v string) predicate.User {
+	return predicate.User(sql.FieldHasPrefix(FieldAdminRole, v))
+}
+
+// AdminRoleHasSuffix applies the HasSuffix predicate on the "admin_role" field.
+func AdminRoleHasSuffix(v string) predicate.User {
+	return predicate.User(sql.FieldHasSuffix(FieldAdminRole, v))
+}
+
+// AdminRoleEqualFold applies the EqualFold predicate on the "admin_role" field.
+func AdminRoleEqualFold(v string) predicate.User {
+	return predicate.User(sql.FieldEqualFold(FieldAdminRole, v))
+}
+
+// AdminRoleContainsFold applies the ContainsFold predicate on the "admin_role" field.
+func AdminRoleContainsFold(v string) predicate.User {
+	return predicate.User(sql.FieldContainsFold(FieldAdminRole, v))
+}
+
 // BalanceEQ applies the EQ predicate on the "balance" field.
 func BalanceEQ(v float64) predicate.User {
 	return predicate.User(sql.FieldEQ(FieldBalance, v))
@@ -860,6 +930,16 @@ func TotpEnabledAtNotNil() predicate.User {
 	return predicate.User(sql.FieldNotNull(FieldTotpEnabledAt))
 }
 
+// AllowedTagKeysIsNil applies the IsNil predicate on the "allowed_tag_keys" field.
+func AllowedTagKeysIsNil() predicate.User {
+	return predicate.User(sql.FieldIsNull(FieldAllowedTagKeys))
+}
+
+// AllowedTagKeysNotNil applies the NotNil predicate on the "allowed_tag_keys" field.
+func AllowedTagKeysNotNil() predicate.User {
+	return predicate.User(sql.FieldNotNull(FieldAllowedTagKeys))
+}
+
 // HasAPIKeys applies the HasEdge predicate on the "api_keys" edge.
 func HasAPIKeys() predicate.User {
 	return predicate.User(func(s *sql.Selector) {
@@ -98,6 +98,20 @@ func (_c *UserCreate) SetNillableRole(v *string) *UserCreate {
 	return _c
 }
 
+// SetAdminRole sets the "admin_role" field.
+func (_c *UserCreate) SetAdminRole(v string) *UserCreate {
+	_c.mutation.SetAdminRole(v)
+	return _c
+}
+
+// SetNillableAdminRole sets the "admin_role" field if the given value is not nil.
+func (_c *UserCreate) SetNillableAdminRole(v *string) *UserCreate {
+	if v != nil {
+		_c.SetAdminRole(*v)
+	}
+	return _c
+}
+
 // SetBalance sets the "balance" field.
 func (_c *UserCreate) SetBalance(v float64) *UserCreate {
 	_c.mutation.SetBalance(v)
@@ -210,6 +224,12 @@ func (_c *UserCreate) SetNillableTotpEnabledAt(v *time.Time) *UserCreate {
 	return _c
 }
 
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (_c *UserCreate) SetAllowedTagKeys(v []string) *UserCreate {
+	_c.mutation.SetAllowedTagKeys(v)
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *UserCreate) AddAPIKeyIDs(ids ...int64) *UserCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -400,6 +420,10 @@ func (_c *UserCreate) defaults() error {
 		v := user.DefaultRole
 		_c.mutation.SetRole(v)
 	}
+	if _, ok := _c.mutation.AdminRole(); !ok {
+		v := user.DefaultAdminRole
+		_c.mutation.SetAdminRole(v)
+	}
 	if _, ok := _c.mutation.Balance(); !ok {
 		v := user.DefaultBalance
 		_c.mutation.SetBalance(v)
@@ -459,6 +483,14 @@ func (_c *UserCreate) check() error {
 			return &ValidationError{Name: "role", err: fmt.Errorf(`ent: validator failed for field "User.role": %w`, err)}
 		}
 	}
+	if _, ok := _c.mutation.AdminRole(); !ok {
+		return &ValidationError{Name: "admin_role", err: errors.New(`ent: missing required field "User.admin_role"`)}
+	}
+	if v, ok := _c.mutation.AdminRole(); ok {
+		if err := user.AdminRoleValidator(v); err != nil {
+			return &ValidationError{Name: "admin_role", err: fmt.Errorf(`ent: validator failed for field "User.admin_role": %w`, err)}
+		}
+	}
 	if _, ok := _c.mutation.Balance(); !ok {
 		return &ValidationError{Name: "balance", err: errors.New(`ent: missing required field "User.balance"`)}
 	}
@@ -538,6 +570,10 @@ func (_c *UserCreate) createSpec() (*User, *sqlgraph.CreateSpec) {
 		_spec.SetField(user.FieldRole, field.TypeString, value)
 		_node.Role = value
 	}
+	if value, ok := _c.mutation.AdminRole(); ok {
+		_spec.SetField(user.FieldAdminRole, field.TypeString, value)
+		_node.AdminRole = value
+	}
 	if value, ok := _c.mutation.Balance(); ok {
 		_spec.SetField(user.FieldBalance, field.TypeFloat64, value)
 		_node.Balance = value
@@ -570,6 +606,10 @@ func (_c *UserCreate) createSpec() (*User, *sqlgraph.CreateSpec) {
 		_spec.SetField(user.FieldTotpEnabledAt, field.TypeTime, value)
 		_node.TotpEnabledAt = &value
 	}
+	if value, ok := _c.mutation.AllowedTagKeys(); ok {
+		_spec.SetField(user.FieldAllowedTagKeys, field.TypeJSON, value)
+		_node.AllowedTagKeys = value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -836,6 +876,18 @@ func (u *UserUpsert) UpdateRole() *UserUpsert {
 	return u
 }
 
+// SetAdminRole sets the "admin_role" field.
+func (u *UserUpsert) SetAdminRole(v string) *UserUpsert {
+	u.Set(user.FieldAdminRole, v)
+	return u
+}
+
+// UpdateAdminRole sets the "admin_role" field to the value that was provided on create.
+func (u *UserUpsert) UpdateAdminRole() *UserUpsert {
+	u.SetExcluded(user.FieldAdminRole)
+	return u
+}
+
 // SetBalance sets the "balance" field.
 func (u *UserUpsert) SetBalance(v float64) *UserUpsert {
 	u.Set(user.FieldBalance, v)
@@ -956,6 +1008,24 @@ func (u *UserUpsert) ClearTotpEnabledAt() *UserUpsert {
 	return u
 }
 
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (u *UserUpsert) SetAllowedTagKeys(v []string) *UserUpsert {
+	u.Set(user.FieldAllowedTagKeys, v)
+	return u
+}
+
+// UpdateAllowedTagKeys sets the "allowed_tag_keys" field to the value that was provided on create.
+func (u *UserUpsert) UpdateAllowedTagKeys() *UserUpsert {
+	u.SetExcluded(user.FieldAllowedTagKeys)
+	return u
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (u *UserUpsert) ClearAllowedTagKeys() *UserUpsert {
+	u.SetNull(user.FieldAllowedTagKeys)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1078,6 +1148,20 @@ func (u *UserUpsertOne) UpdateRole() *UserUpsertOne {
 	})
 }
 
+// SetAdminRole sets the "admin_role" field.
+func (u *UserUpsertOne) SetAdminRole(v string) *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.SetAdminRole(v)
+	})
+}
+
+// UpdateAdminRole sets the "admin_role" field to the value that was provided on create.
+func (u *UserUpsertOne) UpdateAdminRole() *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateAdminRole()
+	})
+}
+
 // SetBalance sets the "balance" field.
 func (u *UserUpsertOne) SetBalance(v float64) *UserUpsertOne {
 	return u.Update(func(s *UserUpsert) {
@@ -1218,6 +1302,27 @@ func (u *UserUpsertOne) ClearTotpEnabledAt() *UserUpsertOne {
 	})
 }
 
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (u *UserUpsertOne) SetAllowedTagKeys(v []string) *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.SetAllowedTagKeys(v)
+	})
+}
+
+// UpdateAllowedTagKeys sets the "allowed_tag_keys" field to the value that was provided on create.
+func (u *UserUpsertOne) UpdateAllowedTagKeys() *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateAllowedTagKeys()
+	})
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (u *UserUpsertOne) ClearAllowedTagKeys() *UserUpsertOne {
+	return u.Update(func(s *UserUpsert) {
+		s.ClearAllowedTagKeys()
+	})
+}
+
 // Exec executes the query.
 func (u *UserUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -1506,6 +1611,20 @@ func (u *UserUpsertBulk) UpdateRole() *UserUpsertBulk {
 	})
 }
 
+// SetAdminRole sets the "admin_role" field.
+func (u *UserUpsertBulk) SetAdminRole(v string) *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.SetAdminRole(v)
+	})
+}
+
+// UpdateAdminRole sets the "admin_role" field to the value that was provided on create.
+func (u *UserUpsertBulk) UpdateAdminRole() *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateAdminRole()
+	})
+}
+
 // SetBalance sets the "balance" field.
 func (u *UserUpsertBulk) SetBalance(v float64) *UserUpsertBulk {
 	return u.Update(func(s *UserUpsert) {
@@ -1646,6 +1765,27 @@ func (u *UserUpsertBulk) ClearTotpEnabledAt() *UserUpsertBulk {
 	})
 }
 
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (u *UserUpsertBulk) SetAllowedTagKeys(v []string) *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.SetAllowedTagKeys(v)
+	})
+}
+
+// UpdateAllowedTagKeys sets the "allowed_tag_keys" field to the value that was provided on create.
+func (u *UserUpsertBulk) UpdateAllowedTagKeys() *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.UpdateAllowedTagKeys()
+	})
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (u *UserUpsertBulk) ClearAllowedTagKeys() *UserUpsertBulk {
+	return u.Update(func(s *UserUpsert) {
+		s.ClearAllowedTagKeys()
+	})
+}
+
 // Exec executes the query.
 func (u *UserUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
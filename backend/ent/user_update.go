@@ -10,6 +10,7 @@ import (
 
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/dialect/sql/sqljson"
 	"entgo.io/ent/schema/field"
 	"github.com/Wei-Shaw/sub2api/ent/announcementread"
 	"github.com/Wei-Shaw/sub2api/ent/apikey"
@@ -104,6 +105,20 @@ func (_u *UserUpdate) SetNillableRole(v *string) *UserUpdate {
 	return _u
 }
 
+// SetAdminRole sets the "admin_role" field.
+func (_u *UserUpdate) SetAdminRole(v string) *UserUpdate {
+	_u.mutation.SetAdminRole(v)
+	return _u
+}
+
+// SetNillableAdminRole sets the "admin_role" field if the given value is not nil.
+func (_u *UserUpdate) SetNillableAdminRole(v *string) *UserUpdate {
+	if v != nil {
+		_u.SetAdminRole(*v)
+	}
+	return _u
+}
+
 // SetBalance sets the "balance" field.
 func (_u *UserUpdate) SetBalance(v float64) *UserUpdate {
 	_u.mutation.ResetBalance()
@@ -242,6 +257,24 @@ func (_u *UserUpdate) ClearTotpEnabledAt() *UserUpdate {
 	return _u
 }
 
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (_u *UserUpdate) SetAllowedTagKeys(v []string) *UserUpdate {
+	_u.mutation.SetAllowedTagKeys(v)
+	return _u
+}
+
+// AppendAllowedTagKeys appends value to the "allowed_tag_keys" field.
+func (_u *UserUpdate) AppendAllowedTagKeys(v []string) *UserUpdate {
+	_u.mutation.AppendAllowedTagKeys(v)
+	return _u
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (_u *UserUpdate) ClearAllowedTagKeys() *UserUpdate {
+	_u.mutation.ClearAllowedTagKeys()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *UserUpdate) AddAPIKeyIDs(ids ...int64) *UserUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -630,6 +663,11 @@ func (_u *UserUpdate) check() error {
 			return &ValidationError{Name: "role", err: fmt.Errorf(`ent: validator failed for field "User.role": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.AdminRole(); ok {
+		if err := user.AdminRoleValidator(v); err != nil {
+			return &ValidationError{Name: "admin_role", err: fmt.Errorf(`ent: validator failed for field "User.admin_role": %w`, err)}
+		}
+	}
 	if v, ok := _u.mutation.Status(); ok {
 		if err := user.StatusValidator(v); err != nil {
 			return &ValidationError{Name: "status", err: fmt.Errorf(`ent: validator failed for field "User.status": %w`, err)}
@@ -673,6 +711,9 @@ func (_u *UserUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.Role(); ok {
 		_spec.SetField(user.FieldRole, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.AdminRole(); ok {
+		_spec.SetField(user.FieldAdminRole, field.TypeString, value)
+	}
 	if value, ok := _u.mutation.Balance(); ok {
 		_spec.SetField(user.FieldBalance, field.TypeFloat64, value)
 	}
@@ -709,6 +750,17 @@ func (_u *UserUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.TotpEnabledAtCleared() {
 		_spec.ClearField(user.FieldTotpEnabledAt, field.TypeTime)
 	}
+	if value, ok := _u.mutation.AllowedTagKeys(); ok {
+		_spec.SetField(user.FieldAllowedTagKeys, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedTagKeys(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, user.FieldAllowedTagKeys, value)
+		})
+	}
+	if _u.mutation.AllowedTagKeysCleared() {
+		_spec.ClearField(user.FieldAllowedTagKeys, field.TypeJSON)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1214,6 +1266,20 @@ func (_u *UserUpdateOne) SetNillableRole(v *string) *UserUpdateOne {
 	return _u
 }
 
+// SetAdminRole sets the "admin_role" field.
+func (_u *UserUpdateOne) SetAdminRole(v string) *UserUpdateOne {
+	_u.mutation.SetAdminRole(v)
+	return _u
+}
+
+// SetNillableAdminRole sets the "admin_role" field if the given value is not nil.
+func (_u *UserUpdateOne) SetNillableAdminRole(v *string) *UserUpdateOne {
+	if v != nil {
+		_u.SetAdminRole(*v)
+	}
+	return _u
+}
+
 // SetBalance sets the "balance" field.
 func (_u *UserUpdateOne) SetBalance(v float64) *UserUpdateOne {
 	_u.mutation.ResetBalance()
@@ -1352,6 +1418,24 @@ func (_u *UserUpdateOne) ClearTotpEnabledAt() *UserUpdateOne {
 	return _u
 }
 
+// SetAllowedTagKeys sets the "allowed_tag_keys" field.
+func (_u *UserUpdateOne) SetAllowedTagKeys(v []string) *UserUpdateOne {
+	_u.mutation.SetAllowedTagKeys(v)
+	return _u
+}
+
+// AppendAllowedTagKeys appends value to the "allowed_tag_keys" field.
+func (_u *UserUpdateOne) AppendAllowedTagKeys(v []string) *UserUpdateOne {
+	_u.mutation.AppendAllowedTagKeys(v)
+	return _u
+}
+
+// ClearAllowedTagKeys clears the value of the "allowed_tag_keys" field.
+func (_u *UserUpdateOne) ClearAllowedTagKeys() *UserUpdateOne {
+	_u.mutation.ClearAllowedTagKeys()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *UserUpdateOne) AddAPIKeyIDs(ids ...int64) *UserUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -1753,6 +1837,11 @@ func (_u *UserUpdateOne) check() error {
 			return &ValidationError{Name: "role", err: fmt.Errorf(`ent: validator failed for field "User.role": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.AdminRole(); ok {
+		if err := user.AdminRoleValidator(v); err != nil {
+			return &ValidationError{Name: "admin_role", err: fmt.Errorf(`ent: validator failed for field "User.admin_role": %w`, err)}
+		}
+	}
 	if v, ok := _u.mutation.Status(); ok {
 		if err := user.StatusValidator(v); err != nil {
 			return &ValidationError{Name: "status", err: fmt.Errorf(`ent: validator failed for field "User.status": %w`, err)}
@@ -1813,6 +1902,9 @@ func (_u *UserUpdateOne) sqlSave(ctx context.Context) (_node *User, err error) {
 	if value, ok := _u.mutation.Role(); ok {
 		_spec.SetField(user.FieldRole, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.AdminRole(); ok {
+		_spec.SetField(user.FieldAdminRole, field.TypeString, value)
+	}
 	if value, ok := _u.mutation.Balance(); ok {
 		_spec.SetField(user.FieldBalance, field.TypeFloat64, value)
 	}
@@ -1849,6 +1941,17 @@ func (_u *UserUpdateOne) sqlSave(ctx context.Context) (_node *User, err error) {
 	if _u.mutation.TotpEnabledAtCleared() {
 		_spec.ClearField(user.FieldTotpEnabledAt, field.TypeTime)
 	}
+	if value, ok := _u.mutation.AllowedTagKeys(); ok {
+		_spec.SetField(user.FieldAllowedTagKeys, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedTagKeys(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, user.FieldAllowedTagKeys, value)
+		})
+	}
+	if _u.mutation.AllowedTagKeysCleared() {
+		_spec.ClearField(user.FieldAllowedTagKeys, field.TypeJSON)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -38,31 +38,34 @@ const (
 )
 
 type Config struct {
-	Server       ServerConfig               `mapstructure:"server"`
-	CORS         CORSConfig                 `mapstructure:"cors"`
-	Security     SecurityConfig             `mapstructure:"security"`
-	Billing      BillingConfig              `mapstructure:"billing"`
-	Turnstile    TurnstileConfig            `mapstructure:"turnstile"`
-	Database     DatabaseConfig             `mapstructure:"database"`
-	Redis        RedisConfig                `mapstructure:"redis"`
-	Ops          OpsConfig                  `mapstructure:"ops"`
-	JWT          JWTConfig                  `mapstructure:"jwt"`
-	Totp         TotpConfig                 `mapstructure:"totp"`
-	LinuxDo      LinuxDoConnectConfig       `mapstructure:"linuxdo_connect"`
-	Default      DefaultConfig              `mapstructure:"default"`
-	RateLimit    RateLimitConfig            `mapstructure:"rate_limit"`
-	Pricing      PricingConfig              `mapstructure:"pricing"`
-	Gateway      GatewayConfig              `mapstructure:"gateway"`
-	APIKeyAuth   APIKeyAuthCacheConfig      `mapstructure:"api_key_auth_cache"`
-	Dashboard    DashboardCacheConfig       `mapstructure:"dashboard_cache"`
-	DashboardAgg DashboardAggregationConfig `mapstructure:"dashboard_aggregation"`
-	UsageCleanup UsageCleanupConfig         `mapstructure:"usage_cleanup"`
-	Concurrency  ConcurrencyConfig          `mapstructure:"concurrency"`
-	TokenRefresh TokenRefreshConfig         `mapstructure:"token_refresh"`
-	RunMode      string                     `mapstructure:"run_mode" yaml:"run_mode"`
-	Timezone     string                     `mapstructure:"timezone"` // e.g. "Asia/Shanghai", "UTC"
-	Gemini       GeminiConfig               `mapstructure:"gemini"`
-	Update       UpdateConfig               `mapstructure:"update"`
+	Server         ServerConfig               `mapstructure:"server"`
+	CORS           CORSConfig                 `mapstructure:"cors"`
+	Security       SecurityConfig             `mapstructure:"security"`
+	Billing        BillingConfig              `mapstructure:"billing"`
+	Turnstile      TurnstileConfig            `mapstructure:"turnstile"`
+	Database       DatabaseConfig             `mapstructure:"database"`
+	Redis          RedisConfig                `mapstructure:"redis"`
+	Ops            OpsConfig                  `mapstructure:"ops"`
+	JWT            JWTConfig                  `mapstructure:"jwt"`
+	Totp           TotpConfig                 `mapstructure:"totp"`
+	LinuxDo        LinuxDoConnectConfig       `mapstructure:"linuxdo_connect"`
+	Default        DefaultConfig              `mapstructure:"default"`
+	RateLimit      RateLimitConfig            `mapstructure:"rate_limit"`
+	Pricing        PricingConfig              `mapstructure:"pricing"`
+	Gateway        GatewayConfig              `mapstructure:"gateway"`
+	APIKeyAuth     APIKeyAuthCacheConfig      `mapstructure:"api_key_auth_cache"`
+	Dashboard      DashboardCacheConfig       `mapstructure:"dashboard_cache"`
+	DashboardAgg   DashboardAggregationConfig `mapstructure:"dashboard_aggregation"`
+	UsageCleanup   UsageCleanupConfig         `mapstructure:"usage_cleanup"`
+	UsageExport    UsageExportConfig          `mapstructure:"usage_export"`
+	UsagePartition UsagePartitionConfig       `mapstructure:"usage_partition"`
+	UsageWrite     UsageWriteConfig           `mapstructure:"usage_write"`
+	Concurrency    ConcurrencyConfig          `mapstructure:"concurrency"`
+	TokenRefresh   TokenRefreshConfig         `mapstructure:"token_refresh"`
+	RunMode        string                     `mapstructure:"run_mode" yaml:"run_mode"`
+	Timezone       string                     `mapstructure:"timezone"` // e.g. "Asia/Shanghai", "UTC"
+	Gemini         GeminiConfig               `mapstructure:"gemini"`
+	Update         UpdateConfig               `mapstructure:"update"`
 }
 
 type GeminiConfig struct {
@@ -284,6 +287,24 @@ type GatewayConfig struct {
 
 	// TLSFingerprint: TLS指纹伪装配置
 	TLSFingerprint TLSFingerprintConfig `mapstructure:"tls_fingerprint"`
+
+	// Idempotency: Idempotency-Key 幂等请求配置
+	Idempotency GatewayIdempotencyConfig `mapstructure:"idempotency"`
+}
+
+// GatewayIdempotencyConfig Idempotency-Key 幂等请求配置
+// 客户端超时重试时携带相同的 Idempotency-Key，网关不会再次调用上游，也不会重复计费。
+type GatewayIdempotencyConfig struct {
+	// Enabled: 是否识别 Idempotency-Key 请求头
+	Enabled bool `mapstructure:"enabled"`
+	// TTLSeconds: 已完成请求的结果保留时长（秒），期间重复请求直接返回保存的响应
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// LockTTLSeconds: 进行中请求的占位过期时间（秒），防止实例崩溃后 Key 永久不可用
+	LockTTLSeconds int `mapstructure:"lock_ttl_seconds"`
+	// WaitTimeoutSeconds: 非流式重复请求等待进行中请求完成的最长时间（秒）
+	WaitTimeoutSeconds int `mapstructure:"wait_timeout_seconds"`
+	// MaxResponseBytes: 可保存以供重放的最大响应体大小，超过时重复请求返回冲突错误
+	MaxResponseBytes int `mapstructure:"max_response_bytes"`
 }
 
 // TLSFingerprintConfig TLS指纹伪装配置
@@ -465,6 +486,10 @@ type OpsCleanupConfig struct {
 	ErrorLogRetentionDays      int `mapstructure:"error_log_retention_days"`
 	MinuteMetricsRetentionDays int `mapstructure:"minute_metrics_retention_days"`
 	HourlyMetricsRetentionDays int `mapstructure:"hourly_metrics_retention_days"`
+	// AuditLogRetentionDays 管理操作审计日志保留天数（admin_audit_logs）
+	AuditLogRetentionDays int `mapstructure:"audit_log_retention_days"`
+	// LoginEventRetentionDays 登录事件保留天数（login_events），过期会话随清理任务一并删除
+	LoginEventRetentionDays int `mapstructure:"login_event_retention_days"`
 }
 
 type OpsAggregationConfig struct {
@@ -578,6 +603,105 @@ type UsageCleanupConfig struct {
 	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
 }
 
+// UsageExportConfig 使用记录批量导出任务配置
+type UsageExportConfig struct {
+	// Enabled: 是否启用导出任务执行器
+	Enabled bool `mapstructure:"enabled"`
+	// Dir: 导出文件存放目录（多实例部署时需为共享目录，否则下载请求需落到执行任务的实例）
+	Dir string `mapstructure:"dir"`
+	// MaxRangeDays: 单次导出允许的最大时间跨度（天）
+	MaxRangeDays int `mapstructure:"max_range_days"`
+	// BatchSize: 游标单次读取行数
+	BatchSize int `mapstructure:"batch_size"`
+	// WorkerIntervalSeconds: 后台任务轮询间隔（秒）
+	WorkerIntervalSeconds int `mapstructure:"worker_interval_seconds"`
+	// TaskTimeoutSeconds: 单次任务最大执行时长（秒）
+	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
+	// LinkTTLHours: 下载链接有效期（小时），过期后文件被删除
+	LinkTTLHours int `mapstructure:"link_ttl_hours"`
+	// MaxActiveTasksPerUser: 每个用户同时排队/执行中的导出任务上限
+	MaxActiveTasksPerUser int `mapstructure:"max_active_tasks_per_user"`
+}
+
+// UsagePartitionConfig usage_logs 月分区管理配置
+type UsagePartitionConfig struct {
+	// Enabled: 是否启用分区维护作业（预建分区、执行在线转换/归档/回灌任务）
+	Enabled bool `mapstructure:"enabled"`
+	// WorkerIntervalSeconds: 维护作业轮询间隔（秒）
+	WorkerIntervalSeconds int `mapstructure:"worker_interval_seconds"`
+	// TaskTimeoutSeconds: 单次任务最长执行时长（秒），在线转换超时后让出并在下一轮继续
+	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
+	// AheadMonths: 提前创建的未来月分区数量
+	AheadMonths int `mapstructure:"ahead_months"`
+	// BatchSize: 在线转换单批复制行数，同时用于归档导出/回灌的批量大小
+	BatchSize int `mapstructure:"batch_size"`
+	// Archive: 冷数据归档
+	Archive UsagePartitionArchiveConfig `mapstructure:"archive"`
+}
+
+// UsagePartitionArchiveConfig 过期分区归档配置
+// 启用后 usage_logs 的保留由归档作业负责，dashboard_aggregation.retention.usage_logs_days 不再删除使用记录
+type UsagePartitionArchiveConfig struct {
+	// Enabled: 是否归档并分离过期分区
+	Enabled bool `mapstructure:"enabled"`
+	// RetentionMonths: 在线保留的完整月数（不含当月），更早的分区归档后分离删除
+	RetentionMonths int `mapstructure:"retention_months"`
+	// Storage: 归档存储类型 local / s3
+	Storage string `mapstructure:"storage"`
+	// Dir: 本地归档目录；使用 s3 时作为上传前的临时目录
+	Dir string `mapstructure:"dir"`
+	// RestoreKeepDays: 回灌后的分区在线保留天数，到期后再次分离（无需重新归档）
+	RestoreKeepDays int `mapstructure:"restore_keep_days"`
+	// S3: S3 兼容对象存储配置（Storage=s3 时生效）
+	S3 UsagePartitionS3Config `mapstructure:"s3"`
+}
+
+// UsagePartitionS3Config S3 兼容对象存储配置
+type UsagePartitionS3Config struct {
+	Endpoint        string `mapstructure:"endpoint"`
+	Region          string `mapstructure:"region"`
+	Bucket          string `mapstructure:"bucket"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	// Prefix: 对象键前缀，如 "sub2api/usage-archives/"
+	Prefix string `mapstructure:"prefix"`
+	// ForcePathStyle: 使用 path-style 地址（MinIO 等自建服务通常需要开启）
+	ForcePathStyle bool `mapstructure:"force_path_style"`
+}
+
+const (
+	UsageWriteModeSync        = "sync"
+	UsageWriteModeRedisStream = "redis_stream"
+)
+
+// UsageWriteConfig 使用记录写入配置
+type UsageWriteConfig struct {
+	// Mode: 写入模式
+	// - sync: 请求结束时同步写入 usage_logs 并扣费（默认）
+	// - redis_stream: 先写入 Redis Stream，由后台 worker 批量落库并扣费；Redis 不可用时回退为同步写入
+	Mode string `mapstructure:"mode"`
+	// Workers: 每个实例的批量落库 worker 数量
+	Workers int `mapstructure:"workers"`
+	// BatchSize: 单次多行 INSERT 的最大记录数（受 PostgreSQL 参数上限约束，最大 1000）
+	BatchSize int `mapstructure:"batch_size"`
+	// FlushIntervalMs: 未攒满一批时的最长等待时间（毫秒）
+	FlushIntervalMs int `mapstructure:"flush_interval_ms"`
+	// ClaimIdleSeconds: 已投递但超过该时长未确认的消息会被其他 worker 接管（实例崩溃恢复）
+	ClaimIdleSeconds int `mapstructure:"claim_idle_seconds"`
+	// MaxRetries: 单条消息最大投递次数，超过后转入死信队列等待人工处理
+	MaxRetries int `mapstructure:"max_retries"`
+}
+
+// Async 是否启用异步批量写入使用记录
+func (c UsageWriteConfig) Async() bool {
+	return c.Mode == UsageWriteModeRedisStream
+}
+
+// UsageLogsRetentionManaged usage_logs 的保留是否由分区归档作业接管
+func (c *Config) UsageLogsRetentionManaged() bool {
+	return c != nil && c.UsagePartition.Enabled && c.UsagePartition.Archive.Enabled
+}
+
 func NormalizeRunMode(value string) string {
 	normalized := strings.ToLower(strings.TrimSpace(value))
 	switch normalized {
@@ -802,6 +926,8 @@ func setDefaults() {
 	viper.SetDefault("ops.cleanup.error_log_retention_days", 30)
 	viper.SetDefault("ops.cleanup.minute_metrics_retention_days", 30)
 	viper.SetDefault("ops.cleanup.hourly_metrics_retention_days", 30)
+	viper.SetDefault("ops.cleanup.audit_log_retention_days", 180)
+	viper.SetDefault("ops.cleanup.login_event_retention_days", 90)
 	viper.SetDefault("ops.aggregation.enabled", true)
 	viper.SetDefault("ops.metrics_collector_cache.enabled", true)
 	// TTL should be slightly larger than collection interval (1m) to maximize cross-replica cache hits.
@@ -874,6 +1000,37 @@ func setDefaults() {
 	viper.SetDefault("usage_cleanup.worker_interval_seconds", 10)
 	viper.SetDefault("usage_cleanup.task_timeout_seconds", 1800)
 
+	// Usage export task
+	viper.SetDefault("usage_export.enabled", true)
+	viper.SetDefault("usage_export.dir", "./data/exports")
+	viper.SetDefault("usage_export.max_range_days", 92)
+	viper.SetDefault("usage_export.batch_size", 2000)
+	viper.SetDefault("usage_export.worker_interval_seconds", 10)
+	viper.SetDefault("usage_export.task_timeout_seconds", 3600)
+	viper.SetDefault("usage_export.link_ttl_hours", 24)
+	viper.SetDefault("usage_export.max_active_tasks_per_user", 2)
+
+	// Usage partition management
+	viper.SetDefault("usage_partition.enabled", true)
+	viper.SetDefault("usage_partition.worker_interval_seconds", 600)
+	viper.SetDefault("usage_partition.task_timeout_seconds", 3600)
+	viper.SetDefault("usage_partition.ahead_months", 3)
+	viper.SetDefault("usage_partition.batch_size", 20000)
+	viper.SetDefault("usage_partition.archive.enabled", false)
+	viper.SetDefault("usage_partition.archive.retention_months", 12)
+	viper.SetDefault("usage_partition.archive.storage", "local")
+	viper.SetDefault("usage_partition.archive.dir", "./data/usage-archives")
+	viper.SetDefault("usage_partition.archive.restore_keep_days", 7)
+	viper.SetDefault("usage_partition.archive.s3.region", "us-east-1")
+
+	// Usage write
+	viper.SetDefault("usage_write.mode", UsageWriteModeSync)
+	viper.SetDefault("usage_write.workers", 2)
+	viper.SetDefault("usage_write.batch_size", 500)
+	viper.SetDefault("usage_write.flush_interval_ms", 200)
+	viper.SetDefault("usage_write.claim_idle_seconds", 60)
+	viper.SetDefault("usage_write.max_retries", 10)
+
 	// Gateway
 	viper.SetDefault("gateway.response_header_timeout", 600) // 600秒(10分钟)等待上游响应头，LLM高负载时可能排队较久
 	viper.SetDefault("gateway.log_upstream_error_body", true)
@@ -915,6 +1072,11 @@ func setDefaults() {
 	viper.SetDefault("gateway.scheduling.full_rebuild_interval_seconds", 300)
 	// TLS指纹伪装配置（默认关闭，需要账号级别单独启用）
 	viper.SetDefault("gateway.tls_fingerprint.enabled", true)
+	viper.SetDefault("gateway.idempotency.enabled", true)
+	viper.SetDefault("gateway.idempotency.ttl_seconds", 86400)
+	viper.SetDefault("gateway.idempotency.lock_ttl_seconds", 900)
+	viper.SetDefault("gateway.idempotency.wait_timeout_seconds", 60)
+	viper.SetDefault("gateway.idempotency.max_response_bytes", 1024*1024)
 	viper.SetDefault("concurrency.ping_interval", 10)
 
 	// TokenRefresh
@@ -1160,6 +1322,85 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("usage_cleanup.task_timeout_seconds must be non-negative")
 		}
 	}
+	if c.UsageExport.Enabled {
+		if strings.TrimSpace(c.UsageExport.Dir) == "" {
+			return fmt.Errorf("usage_export.dir is required")
+		}
+		if c.UsageExport.MaxRangeDays <= 0 {
+			return fmt.Errorf("usage_export.max_range_days must be positive")
+		}
+		if c.UsageExport.BatchSize <= 0 {
+			return fmt.Errorf("usage_export.batch_size must be positive")
+		}
+		if c.UsageExport.WorkerIntervalSeconds <= 0 {
+			return fmt.Errorf("usage_export.worker_interval_seconds must be positive")
+		}
+		if c.UsageExport.TaskTimeoutSeconds <= 0 {
+			return fmt.Errorf("usage_export.task_timeout_seconds must be positive")
+		}
+		if c.UsageExport.LinkTTLHours <= 0 {
+			return fmt.Errorf("usage_export.link_ttl_hours must be positive")
+		}
+		if c.UsageExport.MaxActiveTasksPerUser <= 0 {
+			return fmt.Errorf("usage_export.max_active_tasks_per_user must be positive")
+		}
+	}
+	if c.UsagePartition.Enabled {
+		if c.UsagePartition.WorkerIntervalSeconds <= 0 {
+			return fmt.Errorf("usage_partition.worker_interval_seconds must be positive")
+		}
+		if c.UsagePartition.TaskTimeoutSeconds <= 0 {
+			return fmt.Errorf("usage_partition.task_timeout_seconds must be positive")
+		}
+		if c.UsagePartition.AheadMonths < 1 {
+			return fmt.Errorf("usage_partition.ahead_months must be at least 1")
+		}
+		if c.UsagePartition.BatchSize <= 0 {
+			return fmt.Errorf("usage_partition.batch_size must be positive")
+		}
+	}
+	if c.UsagePartition.Archive.Enabled {
+		archive := c.UsagePartition.Archive
+		if archive.RetentionMonths < 1 {
+			return fmt.Errorf("usage_partition.archive.retention_months must be at least 1")
+		}
+		if strings.TrimSpace(archive.Dir) == "" {
+			return fmt.Errorf("usage_partition.archive.dir is required")
+		}
+		if archive.RestoreKeepDays <= 0 {
+			return fmt.Errorf("usage_partition.archive.restore_keep_days must be positive")
+		}
+		switch archive.Storage {
+		case "local":
+		case "s3":
+			if strings.TrimSpace(archive.S3.Endpoint) == "" || strings.TrimSpace(archive.S3.Bucket) == "" || strings.TrimSpace(archive.S3.Region) == "" {
+				return fmt.Errorf("usage_partition.archive.s3 endpoint, bucket and region are required")
+			}
+		default:
+			return fmt.Errorf("usage_partition.archive.storage must be one of: local, s3")
+		}
+	}
+	switch c.UsageWrite.Mode {
+	case UsageWriteModeSync:
+	case UsageWriteModeRedisStream:
+		if c.UsageWrite.Workers <= 0 {
+			return fmt.Errorf("usage_write.workers must be positive")
+		}
+		if c.UsageWrite.BatchSize <= 0 || c.UsageWrite.BatchSize > 1000 {
+			return fmt.Errorf("usage_write.batch_size must be between 1 and 1000")
+		}
+		if c.UsageWrite.FlushIntervalMs <= 0 {
+			return fmt.Errorf("usage_write.flush_interval_ms must be positive")
+		}
+		if c.UsageWrite.ClaimIdleSeconds <= 0 {
+			return fmt.Errorf("usage_write.claim_idle_seconds must be positive")
+		}
+		if c.UsageWrite.MaxRetries <= 0 {
+			return fmt.Errorf("usage_write.max_retries must be positive")
+		}
+	default:
+		return fmt.Errorf("usage_write.mode must be one of: sync, redis_stream")
+	}
 	if c.Gateway.MaxBodySize <= 0 {
 		return fmt.Errorf("gateway.max_body_size must be positive")
 	}
@@ -1259,6 +1500,23 @@ func (c *Config) Validate() error {
 		c.Gateway.Scheduling.OutboxLagRebuildSeconds < c.Gateway.Scheduling.OutboxLagWarnSeconds {
 		return fmt.Errorf("gateway.scheduling.outbox_lag_rebuild_seconds must be >= outbox_lag_warn_seconds")
 	}
+	if c.Gateway.Idempotency.Enabled {
+		if c.Gateway.Idempotency.TTLSeconds <= 0 {
+			return fmt.Errorf("gateway.idempotency.ttl_seconds must be positive")
+		}
+		if c.Gateway.Idempotency.LockTTLSeconds <= 0 {
+			return fmt.Errorf("gateway.idempotency.lock_ttl_seconds must be positive")
+		}
+		if c.Gateway.Idempotency.LockTTLSeconds > c.Gateway.Idempotency.TTLSeconds {
+			return fmt.Errorf("gateway.idempotency.lock_ttl_seconds must be <= ttl_seconds")
+		}
+		if c.Gateway.Idempotency.WaitTimeoutSeconds < 0 {
+			return fmt.Errorf("gateway.idempotency.wait_timeout_seconds must be non-negative")
+		}
+		if c.Gateway.Idempotency.MaxResponseBytes <= 0 {
+			return fmt.Errorf("gateway.idempotency.max_response_bytes must be positive")
+		}
+	}
 	if c.Ops.MetricsCollectorCache.TTL < 0 {
 		return fmt.Errorf("ops.metrics_collector_cache.ttl must be non-negative")
 	}
@@ -1271,6 +1529,12 @@ func (c *Config) Validate() error {
 	if c.Ops.Cleanup.HourlyMetricsRetentionDays < 0 {
 		return fmt.Errorf("ops.cleanup.hourly_metrics_retention_days must be non-negative")
 	}
+	if c.Ops.Cleanup.AuditLogRetentionDays < 0 {
+		return fmt.Errorf("ops.cleanup.audit_log_retention_days must be non-negative")
+	}
+	if c.Ops.Cleanup.LoginEventRetentionDays < 0 {
+		return fmt.Errorf("ops.cleanup.login_event_retention_days must be non-negative")
+	}
 	if c.Ops.Cleanup.Enabled && strings.TrimSpace(c.Ops.Cleanup.Schedule) == "" {
 		return fmt.Errorf("ops.cleanup.schedule is required when ops.cleanup.enabled=true")
 	}
@@ -50,6 +50,7 @@ const (
 const (
 	AdjustmentTypeAdminBalance     = "admin_balance"     // 管理员调整余额
 	AdjustmentTypeAdminConcurrency = "admin_concurrency" // 管理员调整并发数
+	AdjustmentTypeReferralReward   = "referral_reward"   // 推荐返佣入账
 )
 
 // Group subscription type constants
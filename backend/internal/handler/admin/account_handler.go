@@ -15,12 +15,22 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/sync/errgroup"
 )
 
+// accountDTO 转换账号 DTO；当前管理员缺少 accounts:credentials 权限时脱敏凭证字段
+func accountDTO(c *gin.Context, a *service.Account) *dto.Account {
+	out := dto.AccountFromService(a)
+	if !middleware.HasAdminPermission(c, service.AdminPermAccountsCredentials) {
+		dto.RedactAccountCredentials(out)
+	}
+	return out
+}
+
 // OAuthHandler handles OAuth-related operations for accounts
 type OAuthHandler struct {
 	oauthService *service.OAuthService
@@ -89,6 +99,7 @@ type CreateAccountRequest struct {
 	Credentials             map[string]any `json:"credentials" binding:"required"`
 	Extra                   map[string]any `json:"extra"`
 	ProxyID                 *int64         `json:"proxy_id"`
+	ProxyPoolID             *int64         `json:"proxy_pool_id"`
 	Concurrency             int            `json:"concurrency"`
 	Priority                int            `json:"priority"`
 	RateMultiplier          *float64       `json:"rate_multiplier"`
@@ -107,6 +118,7 @@ type UpdateAccountRequest struct {
 	Credentials             map[string]any `json:"credentials"`
 	Extra                   map[string]any `json:"extra"`
 	ProxyID                 *int64         `json:"proxy_id"`
+	ProxyPoolID             *int64         `json:"proxy_pool_id"`
 	Concurrency             *int           `json:"concurrency"`
 	Priority                *int           `json:"priority"`
 	RateMultiplier          *float64       `json:"rate_multiplier"`
@@ -122,6 +134,7 @@ type BulkUpdateAccountsRequest struct {
 	AccountIDs              []int64        `json:"account_ids" binding:"required,min=1"`
 	Name                    string         `json:"name"`
 	ProxyID                 *int64         `json:"proxy_id"`
+	ProxyPoolID             *int64         `json:"proxy_pool_id"`
 	Concurrency             *int           `json:"concurrency"`
 	Priority                *int           `json:"priority"`
 	RateMultiplier          *float64       `json:"rate_multiplier"`
@@ -248,7 +261,7 @@ func (h *AccountHandler) List(c *gin.Context) {
 	for i := range accounts {
 		acc := &accounts[i]
 		item := AccountWithConcurrency{
-			Account:            dto.AccountFromService(acc),
+			Account:            accountDTO(c, acc),
 			CurrentConcurrency: concurrencyCounts[acc.ID],
 		}
 
@@ -287,7 +300,7 @@ func (h *AccountHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, dto.AccountFromService(account))
+	response.Success(c, accountDTO(c, account))
 }
 
 // CheckMixedChannel handles checking mixed channel risk for account-group binding.
@@ -358,6 +371,7 @@ func (h *AccountHandler) Create(c *gin.Context) {
 		Credentials:           req.Credentials,
 		Extra:                 req.Extra,
 		ProxyID:               req.ProxyID,
+		ProxyPoolID:           req.ProxyPoolID,
 		Concurrency:           req.Concurrency,
 		Priority:              req.Priority,
 		RateMultiplier:        req.RateMultiplier,
@@ -382,7 +396,7 @@ func (h *AccountHandler) Create(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, dto.AccountFromService(account))
+	response.Success(c, accountDTO(c, account))
 }
 
 // Update handles updating an account
@@ -403,6 +417,11 @@ func (h *AccountHandler) Update(c *gin.Context) {
 		response.BadRequest(c, "rate_multiplier must be >= 0")
 		return
 	}
+	// 无凭证权限的管理员看到的是脱敏后的凭证，不允许回写
+	if len(req.Credentials) > 0 && !middleware.HasAdminPermission(c, service.AdminPermAccountsCredentials) {
+		response.Forbidden(c, "Missing admin permission: "+service.AdminPermAccountsCredentials)
+		return
+	}
 
 	// 确定是否跳过混合渠道检查
 	skipCheck := req.ConfirmMixedChannelRisk != nil && *req.ConfirmMixedChannelRisk
@@ -414,6 +433,7 @@ func (h *AccountHandler) Update(c *gin.Context) {
 		Credentials:           req.Credentials,
 		Extra:                 req.Extra,
 		ProxyID:               req.ProxyID,
+		ProxyPoolID:           req.ProxyPoolID,
 		Concurrency:           req.Concurrency, // 指针类型，nil 表示未提供
 		Priority:              req.Priority,    // 指针类型，nil 表示未提供
 		RateMultiplier:        req.RateMultiplier,
@@ -439,7 +459,7 @@ func (h *AccountHandler) Update(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, dto.AccountFromService(account))
+	response.Success(c, accountDTO(c, account))
 }
 
 // Delete handles deleting an account
@@ -697,7 +717,7 @@ func (h *AccountHandler) Refresh(c *gin.Context) {
 		}
 	}
 
-	response.Success(c, dto.AccountFromService(updatedAccount))
+	response.Success(c, accountDTO(c, updatedAccount))
 }
 
 // GetStats handles getting account statistics
@@ -755,7 +775,7 @@ func (h *AccountHandler) ClearError(c *gin.Context) {
 		}
 	}
 
-	response.Success(c, dto.AccountFromService(account))
+	response.Success(c, accountDTO(c, account))
 }
 
 // BatchCreate handles batch creating accounts
@@ -795,6 +815,7 @@ func (h *AccountHandler) BatchCreate(c *gin.Context) {
 			Credentials:           item.Credentials,
 			Extra:                 item.Extra,
 			ProxyID:               item.ProxyID,
+			ProxyPoolID:           item.ProxyPoolID,
 			Concurrency:           item.Concurrency,
 			Priority:              item.Priority,
 			RateMultiplier:        item.RateMultiplier,
@@ -927,12 +948,18 @@ func (h *AccountHandler) BulkUpdate(c *gin.Context) {
 		response.BadRequest(c, "rate_multiplier must be >= 0")
 		return
 	}
+	// 无凭证权限的管理员看到的是脱敏后的凭证，不允许回写
+	if len(req.Credentials) > 0 && !middleware.HasAdminPermission(c, service.AdminPermAccountsCredentials) {
+		response.Forbidden(c, "Missing admin permission: "+service.AdminPermAccountsCredentials)
+		return
+	}
 
 	// 确定是否跳过混合渠道检查
 	skipCheck := req.ConfirmMixedChannelRisk != nil && *req.ConfirmMixedChannelRisk
 
 	hasUpdates := req.Name != "" ||
 		req.ProxyID != nil ||
+		req.ProxyPoolID != nil ||
 		req.Concurrency != nil ||
 		req.Priority != nil ||
 		req.RateMultiplier != nil ||
@@ -951,6 +978,7 @@ func (h *AccountHandler) BulkUpdate(c *gin.Context) {
 		AccountIDs:            req.AccountIDs,
 		Name:                  req.Name,
 		ProxyID:               req.ProxyID,
+		ProxyPoolID:           req.ProxyPoolID,
 		Concurrency:           req.Concurrency,
 		Priority:              req.Priority,
 		RateMultiplier:        req.RateMultiplier,
@@ -1236,7 +1264,7 @@ func (h *AccountHandler) SetSchedulable(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, dto.AccountFromService(account))
+	response.Success(c, accountDTO(c, account))
 }
 
 // GetAvailableModels handles getting available models for an account
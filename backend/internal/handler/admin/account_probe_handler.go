@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountProbeHandler handles account health probe settings and history
+type AccountProbeHandler struct {
+	probeService *service.AccountProbeService
+}
+
+// NewAccountProbeHandler creates a new account probe handler
+func NewAccountProbeHandler(probeService *service.AccountProbeService) *AccountProbeHandler {
+	return &AccountProbeHandler{probeService: probeService}
+}
+
+// GetSettings 获取账号健康探测配置
+// GET /api/v1/admin/accounts/probe-settings
+func (h *AccountProbeHandler) GetSettings(c *gin.Context) {
+	settings, err := h.probeService.GetSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, settings)
+}
+
+// UpdateSettings 更新账号健康探测配置
+// PUT /api/v1/admin/accounts/probe-settings
+func (h *AccountProbeHandler) UpdateSettings(c *gin.Context) {
+	var req service.AccountProbeSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	updated, err := h.probeService.UpdateSettings(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, updated)
+}
+
+// ListResults 获取账号探测历史与当前状态
+// GET /api/v1/admin/accounts/:id/probe-results
+func (h *AccountProbeHandler) ListResults(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	results, err := h.probeService.ListResults(c.Request.Context(), accountID, limit)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	state, err := h.probeService.GetState(c.Request.Context(), accountID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"state":   state,
+		"results": results,
+	})
+}
+
+// ProbeAccountRequest represents the request body for probing an account
+type ProbeAccountRequest struct {
+	Model string `json:"model"`
+}
+
+// Probe 立即探测指定账号并记录结果
+// POST /api/v1/admin/accounts/:id/probe
+func (h *AccountProbeHandler) Probe(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	var req ProbeAccountRequest
+	// Allow empty body, model is optional
+	_ = c.ShouldBindJSON(&req)
+
+	result, err := h.probeService.ProbeNow(c.Request.Context(), accountID, req.Model)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
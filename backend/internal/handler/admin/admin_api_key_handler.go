@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAPIKeyHandler handles named, scoped admin API keys
+type AdminAPIKeyHandler struct {
+	keyService *service.AdminAPIKeyService
+}
+
+// NewAdminAPIKeyHandler creates a new admin API key handler
+func NewAdminAPIKeyHandler(keyService *service.AdminAPIKeyService) *AdminAPIKeyHandler {
+	return &AdminAPIKeyHandler{keyService: keyService}
+}
+
+// CreateAdminAPIKeyRequest represents create admin API key request
+type CreateAdminAPIKeyRequest struct {
+	Name        string     `json:"name" binding:"required,max=100"`
+	Role        string     `json:"role"`
+	Permissions []string   `json:"permissions"`
+	IPWhitelist []string   `json:"ip_whitelist"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// UpdateAdminAPIKeyRequest represents update admin API key request
+type UpdateAdminAPIKeyRequest struct {
+	Name           *string    `json:"name" binding:"omitempty,max=100"`
+	Role           *string    `json:"role"`
+	Permissions    []string   `json:"permissions"`
+	IPWhitelist    []string   `json:"ip_whitelist"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	ClearExpiresAt bool       `json:"clear_expires_at"`
+}
+
+// CreateAdminAPIKeyResponse 创建结果，key 明文仅返回这一次
+type CreateAdminAPIKeyResponse struct {
+	*service.AdminAPIKey
+	Key string `json:"key"`
+}
+
+// List 列出管理员 API Key
+// GET /api/v1/admin/admin-api-keys
+func (h *AdminAPIKeyHandler) List(c *gin.Context) {
+	keys, err := h.keyService.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, keys)
+}
+
+// GetByID 获取管理员 API Key
+// GET /api/v1/admin/admin-api-keys/:id
+func (h *AdminAPIKeyHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid key ID")
+		return
+	}
+	key, err := h.keyService.Get(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, key)
+}
+
+// Create 创建管理员 API Key，授权范围不得超出当前管理员的权限
+// POST /api/v1/admin/admin-api-keys
+func (h *AdminAPIKeyHandler) Create(c *gin.Context) {
+	var req CreateAdminAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	perms, _ := middleware.GetAdminPermissionsFromContext(c)
+
+	key, rawKey, err := h.keyService.Create(c.Request.Context(), subject.UserID, perms, &service.CreateAdminAPIKeyInput{
+		Name:        req.Name,
+		Role:        req.Role,
+		Permissions: req.Permissions,
+		IPWhitelist: req.IPWhitelist,
+		ExpiresAt:   req.ExpiresAt,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, CreateAdminAPIKeyResponse{AdminAPIKey: key, Key: rawKey})
+}
+
+// Update 更新管理员 API Key
+// PUT /api/v1/admin/admin-api-keys/:id
+func (h *AdminAPIKeyHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid key ID")
+		return
+	}
+	var req UpdateAdminAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	perms, _ := middleware.GetAdminPermissionsFromContext(c)
+
+	key, err := h.keyService.Update(c.Request.Context(), id, perms, &service.UpdateAdminAPIKeyInput{
+		Name:           req.Name,
+		Role:           req.Role,
+		Permissions:    req.Permissions,
+		IPWhitelist:    req.IPWhitelist,
+		ExpiresAt:      req.ExpiresAt,
+		ClearExpiresAt: req.ClearExpiresAt,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, key)
+}
+
+// Revoke 吊销管理员 API Key，不影响其他 Key
+// POST /api/v1/admin/admin-api-keys/:id/revoke
+func (h *AdminAPIKeyHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid key ID")
+		return
+	}
+	key, err := h.keyService.Revoke(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, key)
+}
+
+// Delete 删除管理员 API Key
+// DELETE /api/v1/admin/admin-api-keys/:id
+func (h *AdminAPIKeyHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid key ID")
+		return
+	}
+	if err := h.keyService.Delete(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Admin API key deleted successfully"})
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuditHandler handles admin audit log queries
+type AdminAuditHandler struct {
+	auditService *service.AdminAuditService
+}
+
+// NewAdminAuditHandler creates a new admin audit log handler
+func NewAdminAuditHandler(auditService *service.AdminAuditService) *AdminAuditHandler {
+	return &AdminAuditHandler{auditService: auditService}
+}
+
+// List 查询审计日志
+// GET /api/v1/admin/audit-logs
+// 过滤参数：actor_user_id、api_key_id（0 表示旧版全局 Key）、resource、action、target_id、
+// start_time / end_time（RFC3339）
+func (h *AdminAuditHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	filter := service.AdminAuditLogFilter{
+		Resource: strings.TrimSpace(c.Query("resource")),
+		Action:   strings.TrimSpace(c.Query("action")),
+		TargetID: strings.TrimSpace(c.Query("target_id")),
+	}
+	if v := strings.TrimSpace(c.Query("actor_user_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid actor_user_id")
+			return
+		}
+		filter.ActorUserID = id
+	}
+	if v := strings.TrimSpace(c.Query("api_key_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			response.BadRequest(c, "Invalid api_key_id")
+			return
+		}
+		filter.APIKeyID = &id
+	}
+	for _, item := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"start_time", &filter.StartTime},
+		{"end_time", &filter.EndTime},
+	} {
+		v := strings.TrimSpace(c.Query(item.name))
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			response.BadRequest(c, "Invalid "+item.name+", use RFC3339 format")
+			return
+		}
+		*item.dest = &t
+	}
+
+	logs, result, err := h.auditService.List(c.Request.Context(), filter, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, logs, result.Total, page, pageSize)
+}
+
+// GetByID 获取单条审计日志
+// GET /api/v1/admin/audit-logs/:id
+func (h *AdminAuditHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid audit log ID")
+		return
+	}
+	entry, err := h.auditService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, entry)
+}
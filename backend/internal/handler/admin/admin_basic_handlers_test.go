@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
@@ -15,6 +17,11 @@ func setupAdminRouter() (*gin.Engine, *stubAdminService) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	adminSvc := newStubAdminService()
+	// 模拟 adminAuth：超级管理员拥有全部权限
+	router.Use(func(c *gin.Context) {
+		c.Set(string(middleware.ContextKeyAdminPermissions), service.NewAdminPermissionSet(service.AllAdminPermissions()...))
+		c.Next()
+	})
 
 	userHandler := NewUserHandler(adminSvc, nil)
 	groupHandler := NewGroupHandler(adminSvc)
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func withAdminPermissions(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(string(middleware.ContextKeyAdminPermissions), service.NewAdminPermissionSet(perms...))
+		c.Next()
+	}
+}
+
+func TestAccountDTORedactsCredentialsWithoutPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	account := &service.Account{
+		ID: 1,
+		Credentials: map[string]any{
+			"access_token":  "at-secret",
+			"refresh_token": "rt-secret",
+			"api_key":       "sk-secret",
+			"base_url":      "https://api.example.com",
+		},
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	withAdminPermissions(service.AdminPermAccountsRead)(c)
+	out := accountDTO(c, account)
+	require.Equal(t, "***", out.Credentials["access_token"])
+	require.Equal(t, "***", out.Credentials["refresh_token"])
+	require.Equal(t, "***", out.Credentials["api_key"])
+	require.Equal(t, "https://api.example.com", out.Credentials["base_url"])
+	require.Equal(t, "at-secret", account.Credentials["access_token"], "不得修改 service 层对象")
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	withAdminPermissions(service.AdminPermAccountsRead, service.AdminPermAccountsCredentials)(c)
+	out = accountDTO(c, account)
+	require.Equal(t, "at-secret", out.Credentials["access_token"])
+}
+
+func TestUserHandlerUpdateFieldPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	adminSvc := newStubAdminService()
+	adminSvc.users = append(adminSvc.users, service.User{ID: 9, Email: "admin@example.com", Role: service.RoleAdmin, Status: service.StatusActive})
+	userHandler := NewUserHandler(adminSvc, nil)
+
+	router := gin.New()
+	router.Use(withAdminPermissions(service.AdminPermUsersRead, service.AdminPermUsersWrite))
+	router.PUT("/api/v1/admin/users/:id", userHandler.Update)
+
+	cases := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"plain update", "/api/v1/admin/users/1", `{"username":"bob"}`, http.StatusOK},
+		{"balance requires users:balance", "/api/v1/admin/users/1", `{"balance":100}`, http.StatusForbidden},
+		{"group rates require groups:write", "/api/v1/admin/users/1", `{"group_rates":{"2":0.5}}`, http.StatusForbidden},
+		{"editing admins requires roles:manage", "/api/v1/admin/users/9", `{"password":"newpass123"}`, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, tc.path, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(rec, req)
+			require.Equal(t, tc.want, rec.Code, rec.Body.String())
+		})
+	}
+}
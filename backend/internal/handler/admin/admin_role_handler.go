@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRoleHandler handles admin roles, permissions and role assignment
+type AdminRoleHandler struct {
+	roleService *service.AdminRoleService
+}
+
+// NewAdminRoleHandler creates a new admin role handler
+func NewAdminRoleHandler(roleService *service.AdminRoleService) *AdminRoleHandler {
+	return &AdminRoleHandler{roleService: roleService}
+}
+
+// CreateAdminRoleRequest represents create custom admin role request
+type CreateAdminRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateAdminRoleRequest represents update custom admin role request
+type UpdateAdminRoleRequest struct {
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// AssignAdminRoleRequest represents assign admin role request
+type AssignAdminRoleRequest struct {
+	AdminRole string `json:"admin_role" binding:"required"`
+}
+
+// GetMyPermissions 获取当前管理员的权限列表（前端据此隐藏无权限的菜单）
+// GET /api/v1/admin/me/permissions
+func (h *AdminRoleHandler) GetMyPermissions(c *gin.Context) {
+	perms, _ := middleware.GetAdminPermissionsFromContext(c)
+	response.Success(c, gin.H{"permissions": perms.List()})
+}
+
+// ListPermissions 列出全部权限点
+// GET /api/v1/admin/roles/permissions
+func (h *AdminRoleHandler) ListPermissions(c *gin.Context) {
+	response.Success(c, service.AdminPermissionCatalog())
+}
+
+// List 列出内置与自定义角色
+// GET /api/v1/admin/roles
+func (h *AdminRoleHandler) List(c *gin.Context) {
+	roles, err := h.roleService.ListRoles(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, roles)
+}
+
+// Create 创建自定义角色
+// POST /api/v1/admin/roles
+func (h *AdminRoleHandler) Create(c *gin.Context) {
+	var req CreateAdminRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	role, err := h.roleService.CreateRole(c.Request.Context(), &service.CreateAdminRoleInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, role)
+}
+
+// Update 更新自定义角色
+// PUT /api/v1/admin/roles/:id
+func (h *AdminRoleHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID")
+		return
+	}
+	var req UpdateAdminRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	role, err := h.roleService.UpdateRole(c.Request.Context(), id, &service.UpdateAdminRoleInput{
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, role)
+}
+
+// Delete 删除自定义角色
+// DELETE /api/v1/admin/roles/:id
+func (h *AdminRoleHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID")
+		return
+	}
+	if err := h.roleService.DeleteRole(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Role deleted successfully"})
+}
+
+// AssignRole 为管理员分配角色
+// PUT /api/v1/admin/users/:id/admin-role
+func (h *AdminRoleHandler) AssignRole(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+	var req AssignAdminRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	user, err := h.roleService.AssignRole(c.Request.Context(), subject.UserID, userID, req.AdminRole)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserFromServiceAdmin(user))
+}
@@ -3,10 +3,12 @@ package admin
 import (
 	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -308,6 +310,49 @@ func (h *DashboardHandler) GetModelStats(c *gin.Context) {
 	})
 }
 
+// GetTagUsage handles getting usage breakdown by cost attribution tag
+// GET /api/v1/admin/dashboard/tags
+// Query params: start_date, end_date (YYYY-MM-DD), tag_key (required), tag_value, user_id, api_key_id
+func (h *DashboardHandler) GetTagUsage(c *gin.Context) {
+	startTime, endTime := parseTimeRange(c)
+
+	filters := usagestats.TagUsageFilters{
+		TagKey:    c.Query("tag_key"),
+		TagValue:  c.Query("tag_value"),
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		id, err := strconv.ParseInt(userIDStr, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filters.UserID = id
+	}
+	if apiKeyIDStr := c.Query("api_key_id"); apiKeyIDStr != "" {
+		id, err := strconv.ParseInt(apiKeyIDStr, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid api_key_id")
+			return
+		}
+		filters.APIKeyID = id
+	}
+
+	stats, err := h.dashboardService.GetTagUsageStats(c.Request.Context(), filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"tag_key":    strings.ToLower(strings.TrimSpace(filters.TagKey)),
+		"tags":       stats,
+		"start_date": startTime.Format("2006-01-02"),
+		"end_date":   endTime.Add(-24 * time.Hour).Format("2006-01-02"),
+	})
+}
+
 // GetAPIKeyUsageTrend handles getting API key usage trend data
 // GET /api/v1/admin/dashboard/api-keys-trend
 // Query params: start_date, end_date (YYYY-MM-DD), granularity (day/hour), limit (default 5)
@@ -47,6 +47,13 @@ type CreateGroupRequest struct {
 	MCPXMLInject        *bool              `json:"mcp_xml_inject"`
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes []string `json:"supported_model_scopes"`
+	// 账号选择策略：default/weighted/latency
+	SelectionStrategy string `json:"selection_strategy" binding:"omitempty,oneof=default weighted latency"`
+	// 分组内用户公平分配
+	FairShareEnabled bool `json:"fair_share_enabled"`
+	// 模型降级链：模型模式 -> 按顺序尝试的降级模型
+	ModelFallback        map[string][]string `json:"model_fallback"`
+	ModelFallbackEnabled bool                `json:"model_fallback_enabled"`
 	// 从指定分组复制账号（创建后自动绑定）
 	CopyAccountsFromGroupIDs []int64 `json:"copy_accounts_from_group_ids"`
 }
@@ -76,6 +83,13 @@ type UpdateGroupRequest struct {
 	MCPXMLInject        *bool              `json:"mcp_xml_inject"`
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes *[]string `json:"supported_model_scopes"`
+	// 账号选择策略：default/weighted/latency
+	SelectionStrategy *string `json:"selection_strategy" binding:"omitempty,oneof=default weighted latency"`
+	// 分组内用户公平分配
+	FairShareEnabled *bool `json:"fair_share_enabled"`
+	// 模型降级链：模型模式 -> 按顺序尝试的降级模型
+	ModelFallback        map[string][]string `json:"model_fallback"`
+	ModelFallbackEnabled *bool               `json:"model_fallback_enabled"`
 	// 从指定分组复制账号（同步操作：先清空当前分组的账号绑定，再绑定源分组的账号）
 	CopyAccountsFromGroupIDs []int64 `json:"copy_accounts_from_group_ids"`
 }
@@ -186,6 +200,10 @@ func (h *GroupHandler) Create(c *gin.Context) {
 		ModelRoutingEnabled:             req.ModelRoutingEnabled,
 		MCPXMLInject:                    req.MCPXMLInject,
 		SupportedModelScopes:            req.SupportedModelScopes,
+		SelectionStrategy:               req.SelectionStrategy,
+		FairShareEnabled:                req.FairShareEnabled,
+		ModelFallback:                   req.ModelFallback,
+		ModelFallbackEnabled:            req.ModelFallbackEnabled,
 		CopyAccountsFromGroupIDs:        req.CopyAccountsFromGroupIDs,
 	})
 	if err != nil {
@@ -232,6 +250,10 @@ func (h *GroupHandler) Update(c *gin.Context) {
 		ModelRoutingEnabled:             req.ModelRoutingEnabled,
 		MCPXMLInject:                    req.MCPXMLInject,
 		SupportedModelScopes:            req.SupportedModelScopes,
+		SelectionStrategy:               req.SelectionStrategy,
+		FairShareEnabled:                req.FairShareEnabled,
+		ModelFallback:                   req.ModelFallback,
+		ModelFallbackEnabled:            req.ModelFallbackEnabled,
 		CopyAccountsFromGroupIDs:        req.CopyAccountsFromGroupIDs,
 	})
 	if err != nil {
@@ -0,0 +1,176 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginSecurityHandler handles login events, lockout settings and user sessions
+type LoginSecurityHandler struct {
+	loginSecurity *service.LoginSecurityService
+	authService   *service.AuthService
+}
+
+// NewLoginSecurityHandler creates a new login security handler
+func NewLoginSecurityHandler(loginSecurity *service.LoginSecurityService, authService *service.AuthService) *LoginSecurityHandler {
+	return &LoginSecurityHandler{loginSecurity: loginSecurity, authService: authService}
+}
+
+// GetSettings 获取登录锁定配置
+// GET /api/v1/admin/settings/login-security
+func (h *LoginSecurityHandler) GetSettings(c *gin.Context) {
+	settings, err := h.loginSecurity.GetSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, settings)
+}
+
+// UpdateSettings 更新登录锁定配置
+// PUT /api/v1/admin/settings/login-security
+func (h *LoginSecurityHandler) UpdateSettings(c *gin.Context) {
+	var req service.LoginSecuritySettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	settings, err := h.loginSecurity.UpdateSettings(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, settings)
+}
+
+// ListEvents 查询登录事件
+// GET /api/v1/admin/login-events
+// 过滤参数：user_id、email、ip、success（true/false）、start_time / end_time（RFC3339）
+func (h *LoginSecurityHandler) ListEvents(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	filter := service.LoginEventFilter{
+		Email: strings.TrimSpace(c.Query("email")),
+		IP:    strings.TrimSpace(c.Query("ip")),
+	}
+	if v := strings.TrimSpace(c.Query("user_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filter.UserID = id
+	}
+	if v := strings.TrimSpace(c.Query("success")); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid success")
+			return
+		}
+		filter.Success = &success
+	}
+	for _, item := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"start_time", &filter.StartTime},
+		{"end_time", &filter.EndTime},
+	} {
+		v := strings.TrimSpace(c.Query(item.name))
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			response.BadRequest(c, "Invalid "+item.name+", use RFC3339 format")
+			return
+		}
+		*item.dest = &t
+	}
+
+	events, result, err := h.loginSecurity.ListEvents(c.Request.Context(), filter, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, events, result.Total, page, pageSize)
+}
+
+// UnlockRequest 解除登录锁定请求
+type UnlockRequest struct {
+	Email string `json:"email"`
+	IP    string `json:"ip"`
+}
+
+// Unlock 解除账号或 IP 的登录锁定
+// POST /api/v1/admin/login-lockouts/unlock
+func (h *LoginSecurityHandler) Unlock(c *gin.Context) {
+	var req UnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if err := h.loginSecurity.Unlock(c.Request.Context(), req.Email, req.IP); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Unlocked"})
+}
+
+// ListUserSessions 列出用户的活跃会话
+// GET /api/v1/admin/users/:id/sessions
+func (h *LoginSecurityHandler) ListUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+	sessions, err := h.authService.ListUserSessions(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, sessions)
+}
+
+// RevokeUserSession 撤销用户的指定会话
+// DELETE /api/v1/admin/users/:id/sessions/:session_id
+func (h *LoginSecurityHandler) RevokeUserSession(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid session ID")
+		return
+	}
+	if err := h.authService.RevokeUserSession(c.Request.Context(), userID, sessionID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Session revoked"})
+}
+
+// RevokeAllUserSessions 撤销用户的全部会话
+// DELETE /api/v1/admin/users/:id/sessions
+func (h *LoginSecurityHandler) RevokeAllUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+	if err := h.authService.RevokeAllUserSessions(c.Request.Context(), userID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "All sessions revoked"})
+}
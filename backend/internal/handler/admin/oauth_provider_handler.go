@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthProviderHandler handles generic OIDC/OAuth2 login provider configuration
+type OAuthProviderHandler struct {
+	oauthProviderService *service.OAuthProviderService
+}
+
+// NewOAuthProviderHandler creates a new OAuth provider handler
+func NewOAuthProviderHandler(oauthProviderService *service.OAuthProviderService) *OAuthProviderHandler {
+	return &OAuthProviderHandler{oauthProviderService: oauthProviderService}
+}
+
+// OAuthProviderResponse 提供商配置（client_secret 不回显）
+type OAuthProviderResponse struct {
+	service.OAuthProviderConfig
+	ClientSecretConfigured bool `json:"client_secret_configured"`
+}
+
+func toOAuthProviderResponse(p service.OAuthProviderConfig) OAuthProviderResponse {
+	configured := p.ClientSecret != ""
+	p.ClientSecret = ""
+	return OAuthProviderResponse{OAuthProviderConfig: p, ClientSecretConfigured: configured}
+}
+
+// List 获取第三方登录提供商列表
+// GET /api/v1/admin/settings/oauth-providers
+func (h *OAuthProviderHandler) List(c *gin.Context) {
+	providers, err := h.oauthProviderService.ListProviders(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]OAuthProviderResponse, 0, len(providers))
+	for _, p := range providers {
+		out = append(out, toOAuthProviderResponse(p))
+	}
+	response.Success(c, out)
+}
+
+// Upsert 新增或更新第三方登录提供商（client_secret 留空表示保留原值）
+// PUT /api/v1/admin/settings/oauth-providers/:name
+func (h *OAuthProviderHandler) Upsert(c *gin.Context) {
+	var req service.OAuthProviderConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	req.Name = c.Param("name")
+
+	provider, err := h.oauthProviderService.UpsertProvider(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, toOAuthProviderResponse(*provider))
+}
+
+// Delete 删除第三方登录提供商
+// DELETE /api/v1/admin/settings/oauth-providers/:name
+func (h *OAuthProviderHandler) Delete(c *gin.Context) {
+	if err := h.oauthProviderService.DeleteProvider(c.Request.Context(), c.Param("name")); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "OAuth provider deleted successfully"})
+}
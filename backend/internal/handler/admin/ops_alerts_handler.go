@@ -23,6 +23,10 @@ var validOpsAlertMetricTypes = []string{
 	"cpu_usage_percent",
 	"memory_usage_percent",
 	"concurrency_queue_depth",
+	"usage_write_backlog",
+	"usage_write_dead_letters",
+	"proxy_down_count",
+	"proxy_exit_ip_changed",
 }
 
 var validOpsAlertMetricTypeSet = func() map[string]struct{} {
@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler handles admin organization management
+type OrganizationHandler struct {
+	organizationService *service.OrganizationService
+}
+
+// NewOrganizationHandler creates a new admin organization handler
+func NewOrganizationHandler(organizationService *service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{organizationService: organizationService}
+}
+
+// UpdateOrganizationStatusRequest represents organization status update request
+type UpdateOrganizationStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active disabled"`
+}
+
+// OrganizationDetail is the admin view of an organization with its members
+type OrganizationDetail struct {
+	*service.Organization
+	Members []service.OrganizationMember `json:"members"`
+}
+
+// List handles listing organizations with pagination
+// GET /api/v1/admin/organizations
+// Query params:
+//   - search: search in organization name
+func (h *OrganizationHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	search := strings.TrimSpace(c.Query("search"))
+	if len(search) > 100 {
+		search = search[:100]
+	}
+
+	orgs, result, err := h.organizationService.AdminList(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize}, search)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, orgs, result.Total, page, pageSize)
+}
+
+// Get handles getting organization detail with members
+// GET /api/v1/admin/organizations/:id
+func (h *OrganizationHandler) Get(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid organization ID")
+		return
+	}
+
+	org, members, err := h.organizationService.AdminGet(c.Request.Context(), orgID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, OrganizationDetail{Organization: org, Members: members})
+}
+
+// UpdateBalance handles adjusting organization pooled balance
+// POST /api/v1/admin/organizations/:id/balance
+func (h *OrganizationHandler) UpdateBalance(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid organization ID")
+		return
+	}
+
+	var req UpdateBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	org, err := h.organizationService.AdminUpdateBalance(c.Request.Context(), orgID, req.Balance, req.Operation)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, org)
+}
+
+// UpdateStatus handles enabling or disabling an organization
+// PUT /api/v1/admin/organizations/:id/status
+func (h *OrganizationHandler) UpdateStatus(c *gin.Context) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid organization ID")
+		return
+	}
+
+	var req UpdateOrganizationStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	org, err := h.organizationService.AdminUpdateStatus(c.Request.Context(), orgID, req.Status)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, org)
+}
@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// PasskeySettingsHandler handles WebAuthn passkey settings
+type PasskeySettingsHandler struct {
+	passkeyService *service.PasskeyService
+}
+
+// NewPasskeySettingsHandler creates a new passkey settings handler
+func NewPasskeySettingsHandler(passkeyService *service.PasskeyService) *PasskeySettingsHandler {
+	return &PasskeySettingsHandler{passkeyService: passkeyService}
+}
+
+// GetSettings 获取通行密钥配置
+// GET /api/v1/admin/settings/passkeys
+func (h *PasskeySettingsHandler) GetSettings(c *gin.Context) {
+	settings, err := h.passkeyService.GetSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, settings)
+}
+
+// UpdateSettings 更新通行密钥配置
+// PUT /api/v1/admin/settings/passkeys
+func (h *PasskeySettingsHandler) UpdateSettings(c *gin.Context) {
+	var req service.PasskeySettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	updated, err := h.passkeyService.UpdateSettings(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, updated)
+}
@@ -0,0 +1,131 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ProxyImportHandler handles bulk proxy import and proxy subscriptions
+type ProxyImportHandler struct {
+	importService *service.ProxyImportService
+}
+
+// NewProxyImportHandler creates a new proxy import handler
+func NewProxyImportHandler(importService *service.ProxyImportService) *ProxyImportHandler {
+	return &ProxyImportHandler{importService: importService}
+}
+
+// Import 从 Clash YAML / URI 列表 / CSV 批量导入代理（dry_run=true 时仅预览）
+// POST /api/v1/admin/proxies/import
+func (h *ProxyImportHandler) Import(c *gin.Context) {
+	var req service.ProxyImportInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	result, err := h.importService.Import(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// ListSubscriptions 获取代理订阅列表
+// GET /api/v1/admin/proxy-subscriptions
+func (h *ProxyImportHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.importService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, subs)
+}
+
+// GetSubscription 获取代理订阅详情
+// GET /api/v1/admin/proxy-subscriptions/:id
+func (h *ProxyImportHandler) GetSubscription(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+	sub, err := h.importService.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, sub)
+}
+
+// CreateSubscription 创建代理订阅
+// POST /api/v1/admin/proxy-subscriptions
+func (h *ProxyImportHandler) CreateSubscription(c *gin.Context) {
+	var req service.CreateProxySubscriptionInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	sub, err := h.importService.CreateSubscription(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, sub)
+}
+
+// UpdateSubscription 更新代理订阅
+// PUT /api/v1/admin/proxy-subscriptions/:id
+func (h *ProxyImportHandler) UpdateSubscription(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+	var req service.UpdateProxySubscriptionInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	sub, err := h.importService.UpdateSubscription(c.Request.Context(), id, &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, sub)
+}
+
+// DeleteSubscription 删除代理订阅（已导入的代理保留）
+// DELETE /api/v1/admin/proxy-subscriptions/:id
+func (h *ProxyImportHandler) DeleteSubscription(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+	if err := h.importService.DeleteSubscription(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Proxy subscription deleted successfully"})
+}
+
+// SyncSubscription 立即同步代理订阅（?dry_run=true 时仅预览变更）
+// POST /api/v1/admin/proxy-subscriptions/:id/sync
+func (h *ProxyImportHandler) SyncSubscription(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	result, err := h.importService.SyncSubscription(c.Request.Context(), id, dryRun)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
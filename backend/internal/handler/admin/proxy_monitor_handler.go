@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ProxyMonitorHandler handles proxy health monitor settings and history
+type ProxyMonitorHandler struct {
+	monitorService *service.ProxyMonitorService
+}
+
+// NewProxyMonitorHandler creates a new proxy monitor handler
+func NewProxyMonitorHandler(monitorService *service.ProxyMonitorService) *ProxyMonitorHandler {
+	return &ProxyMonitorHandler{monitorService: monitorService}
+}
+
+// GetSettings 获取代理健康监控配置
+// GET /api/v1/admin/proxies/monitor-settings
+func (h *ProxyMonitorHandler) GetSettings(c *gin.Context) {
+	settings, err := h.monitorService.GetSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, settings)
+}
+
+// UpdateSettings 更新代理健康监控配置
+// PUT /api/v1/admin/proxies/monitor-settings
+func (h *ProxyMonitorHandler) UpdateSettings(c *gin.Context) {
+	var req service.ProxyMonitorSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	updated, err := h.monitorService.UpdateSettings(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, updated)
+}
+
+// ListStates 获取所有代理的健康状态
+// GET /api/v1/admin/proxies/health
+func (h *ProxyMonitorHandler) ListStates(c *gin.Context) {
+	states, err := h.monitorService.ListStates(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, states)
+}
+
+// GetReport 获取代理巡检历史、当前状态与受影响账号
+// GET /api/v1/admin/proxies/:id/health
+func (h *ProxyMonitorHandler) GetReport(c *gin.Context) {
+	proxyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid proxy ID")
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	report, err := h.monitorService.GetReport(c.Request.Context(), proxyID, limit)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, report)
+}
@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ProxyPoolHandler handles proxy pool management
+type ProxyPoolHandler struct {
+	proxyPoolService *service.ProxyPoolService
+}
+
+// NewProxyPoolHandler creates a new proxy pool handler
+func NewProxyPoolHandler(proxyPoolService *service.ProxyPoolService) *ProxyPoolHandler {
+	return &ProxyPoolHandler{proxyPoolService: proxyPoolService}
+}
+
+// List 获取代理池列表（含成员健康状态）
+// GET /api/v1/admin/proxy-pools
+func (h *ProxyPoolHandler) List(c *gin.Context) {
+	pools, err := h.proxyPoolService.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, pools)
+}
+
+// GetByID 获取代理池详情
+// GET /api/v1/admin/proxy-pools/:id
+func (h *ProxyPoolHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid proxy pool ID")
+		return
+	}
+	pool, err := h.proxyPoolService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, pool)
+}
+
+// Create 创建代理池
+// POST /api/v1/admin/proxy-pools
+func (h *ProxyPoolHandler) Create(c *gin.Context) {
+	var req service.CreateProxyPoolInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	pool, err := h.proxyPoolService.Create(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, pool)
+}
+
+// Update 更新代理池
+// PUT /api/v1/admin/proxy-pools/:id
+func (h *ProxyPoolHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid proxy pool ID")
+		return
+	}
+	var req service.UpdateProxyPoolInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	pool, err := h.proxyPoolService.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, pool)
+}
+
+// Delete 删除代理池
+// DELETE /api/v1/admin/proxy-pools/:id
+func (h *ProxyPoolHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid proxy pool ID")
+		return
+	}
+	if err := h.proxyPoolService.Delete(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Proxy pool deleted successfully"})
+}
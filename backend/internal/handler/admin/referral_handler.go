@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReferralHandler handles referral settings and commission review
+type ReferralHandler struct {
+	referralService *service.ReferralService
+}
+
+// NewReferralHandler creates a new admin referral handler
+func NewReferralHandler(referralService *service.ReferralService) *ReferralHandler {
+	return &ReferralHandler{referralService: referralService}
+}
+
+// ReviewReferralCommissionRequest represents approve / reject request
+type ReviewReferralCommissionRequest struct {
+	Notes string `json:"notes"`
+}
+
+// GetSettings 获取推荐计划配置
+// GET /api/v1/admin/settings/referral
+func (h *ReferralHandler) GetSettings(c *gin.Context) {
+	settings, err := h.referralService.GetSettings(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, settings)
+}
+
+// UpdateSettings 更新推荐计划配置
+// PUT /api/v1/admin/settings/referral
+func (h *ReferralHandler) UpdateSettings(c *gin.Context) {
+	var req service.ReferralSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	settings, err := h.referralService.UpdateSettings(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, settings)
+}
+
+// ListCommissions 查询返佣台账
+// GET /api/v1/admin/referral-commissions
+// 过滤参数：referrer_user_id、referee_user_id、type（first_topup/spend）、status（pending/approved/rejected）
+func (h *ReferralHandler) ListCommissions(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	filter := service.ReferralCommissionFilter{
+		Type:   strings.TrimSpace(c.Query("type")),
+		Status: strings.TrimSpace(c.Query("status")),
+	}
+	if v := strings.TrimSpace(c.Query("referrer_user_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid referrer_user_id")
+			return
+		}
+		filter.ReferrerUserID = id
+	}
+	if v := strings.TrimSpace(c.Query("referee_user_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid referee_user_id")
+			return
+		}
+		filter.RefereeUserID = id
+	}
+
+	items, result, err := h.referralService.AdminListCommissions(c.Request.Context(), filter, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, items, result.Total, page, pageSize)
+}
+
+// ApproveCommission 审核通过并入账到推荐人余额
+// POST /api/v1/admin/referral-commissions/:id/approve
+func (h *ReferralHandler) ApproveCommission(c *gin.Context) {
+	h.review(c, true)
+}
+
+// RejectCommission 驳回返佣
+// POST /api/v1/admin/referral-commissions/:id/reject
+func (h *ReferralHandler) RejectCommission(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *ReferralHandler) review(c *gin.Context, approve bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid commission ID")
+		return
+	}
+	var req ReviewReferralCommissionRequest
+	// 备注可选，允许空请求体
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+	}
+
+	var reviewerID int64
+	if subject, ok := middleware.GetAuthSubjectFromContext(c); ok {
+		reviewerID = subject.UserID
+	}
+
+	var commission *service.ReferralCommission
+	if approve {
+		commission, err = h.referralService.ApproveCommission(c.Request.Context(), id, reviewerID, req.Notes)
+	} else {
+		commission, err = h.referralService.RejectCommission(c.Request.Context(), id, reviewerID, req.Notes)
+	}
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, commission)
+}
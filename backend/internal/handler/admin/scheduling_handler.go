@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SchedulingHandler handles account scheduling diagnostics
+type SchedulingHandler struct {
+	gatewayService *service.GatewayService
+}
+
+// NewSchedulingHandler creates a new scheduling handler
+func NewSchedulingHandler(gatewayService *service.GatewayService) *SchedulingHandler {
+	return &SchedulingHandler{gatewayService: gatewayService}
+}
+
+// ExplainSchedulingRequest 调度解释请求
+type ExplainSchedulingRequest struct {
+	GroupID          *int64  `json:"group_id"`
+	Platform         string  `json:"platform"`
+	Model            string  `json:"model"`
+	SessionHash      string  `json:"session_hash"`
+	ExcludedIDs      []int64 `json:"excluded_account_ids"`
+	ClaudeCodeClient bool    `json:"claude_code_client"`
+	// RequestBody 可选：真实请求体，用于推导会话 hash（与网关 GenerateSessionHash 一致）
+	RequestBody json.RawMessage `json:"request_body"`
+	// 以下字段仅在提供 request_body 时参与会话 hash 计算
+	APIKeyID  int64  `json:"api_key_id"`
+	ClientIP  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+// Explain 以 dry-run 方式执行账号调度，返回每个候选账号被淘汰的阶段与最终排序
+// POST /api/v1/admin/scheduling/explain
+func (h *SchedulingHandler) Explain(c *gin.Context) {
+	var req ExplainSchedulingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	explainReq := &service.ScheduleExplainRequest{
+		GroupID:          req.GroupID,
+		Platform:         strings.TrimSpace(req.Platform),
+		Model:            strings.TrimSpace(req.Model),
+		SessionHash:      strings.TrimSpace(req.SessionHash),
+		ExcludedIDs:      req.ExcludedIDs,
+		ClaudeCodeClient: req.ClaudeCodeClient,
+	}
+	if len(req.RequestBody) > 0 && string(req.RequestBody) != "null" {
+		explainReq.RequestBody = req.RequestBody
+		if req.APIKeyID > 0 || req.ClientIP != "" || req.UserAgent != "" {
+			explainReq.SessionContext = &service.SessionContext{
+				ClientIP:  req.ClientIP,
+				UserAgent: req.UserAgent,
+				APIKeyID:  req.APIKeyID,
+			}
+		}
+	}
+
+	result, err := h.gatewayService.ExplainAccountSelection(c.Request.Context(), explainReq)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatementHandler handles monthly statements for admins
+type StatementHandler struct {
+	statementService *service.StatementService
+}
+
+// NewStatementHandler creates a new admin statement handler
+func NewStatementHandler(statementService *service.StatementService) *StatementHandler {
+	return &StatementHandler{statementService: statementService}
+}
+
+// GenerateStatementRequest represents generate statement request
+type GenerateStatementRequest struct {
+	SubjectType string `json:"subject_type" binding:"required,oneof=user organization"`
+	SubjectID   int64  `json:"subject_id" binding:"required,gt=0"`
+	Period      string `json:"period" binding:"required"`
+}
+
+// EmailStatementsRequest represents bulk email request
+type EmailStatementsRequest struct {
+	SubjectType string  `json:"subject_type" binding:"required,oneof=user organization"`
+	Period      string  `json:"period" binding:"required"`
+	SubjectIDs  []int64 `json:"subject_ids"`
+}
+
+// List 查询对账单
+// GET /api/v1/admin/statements
+// 过滤参数：subject_type（user/organization）、subject_id、period（YYYY-MM）
+func (h *StatementHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	filter := service.StatementFilter{
+		SubjectType: strings.TrimSpace(c.Query("subject_type")),
+	}
+	if v := strings.TrimSpace(c.Query("subject_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid subject_id")
+			return
+		}
+		filter.SubjectID = id
+	}
+	if v := strings.TrimSpace(c.Query("period")); v != "" {
+		// 列表允许查询任意月份，不要求账期已结束
+		periodStart, err := service.ParseStatementPeriod(v, time.Now().AddDate(1, 0, 0))
+		if err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+		filter.PeriodStart = &periodStart
+	}
+
+	items, result, err := h.statementService.AdminList(c.Request.Context(), filter, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, items, result.Total, page, pageSize)
+}
+
+// Get 获取 / 下载对账单
+// GET /api/v1/admin/statements/:id?format=html|csv|pdf
+func (h *StatementHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid statement ID")
+		return
+	}
+	st, err := h.statementService.AdminGet(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(c.Query("format")))
+	if format == "" {
+		response.Success(c, st)
+		return
+	}
+	data, err := h.statementService.Render(st, format, h.statementService.SiteName(c.Request.Context()))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", service.StatementFilename(st, format)))
+	c.Data(http.StatusOK, service.StatementContentType(format), data)
+}
+
+// Generate （重新）生成对账单，编号保持不变
+// POST /api/v1/admin/statements/generate
+func (h *StatementHandler) Generate(c *gin.Context) {
+	var req GenerateStatementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	st, err := h.statementService.AdminGenerate(c.Request.Context(), req.SubjectType, req.SubjectID, req.Period)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, st)
+}
+
+// Email 批量生成并通过邮件队列发送对账单，subject_ids 为空时发送给账期内所有活跃主体
+// POST /api/v1/admin/statements/email
+func (h *StatementHandler) Email(c *gin.Context) {
+	var req EmailStatementsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	count, err := h.statementService.StartBulkEmail(c.Request.Context(), req.SubjectType, req.Period, req.SubjectIDs)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"queued": count})
+}
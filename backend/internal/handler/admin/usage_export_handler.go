@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageExportHandler handles admin usage export tasks
+type UsageExportHandler struct {
+	exportService *service.UsageExportService
+}
+
+// NewUsageExportHandler creates a new admin usage export handler
+func NewUsageExportHandler(exportService *service.UsageExportService) *UsageExportHandler {
+	return &UsageExportHandler{exportService: exportService}
+}
+
+// CreateUsageExportTaskRequest represents export task creation request
+// 过滤字段与 GET /api/v1/admin/usage 的查询参数一致
+type CreateUsageExportTaskRequest struct {
+	Format      string `json:"format" binding:"required"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	Timezone    string `json:"timezone"`
+	UserID      int64  `json:"user_id"`
+	APIKeyID    int64  `json:"api_key_id"`
+	AccountID   int64  `json:"account_id"`
+	GroupID     int64  `json:"group_id"`
+	Model       string `json:"model"`
+	Stream      *bool  `json:"stream"`
+	BillingType *int8  `json:"billing_type"`
+	TagKey      string `json:"tag_key"`
+	TagValue    string `json:"tag_value"`
+}
+
+// List handles listing admin usage export tasks
+// GET /api/v1/admin/usage/exports
+func (h *UsageExportHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	tasks, result, err := h.exportService.ListTasks(c.Request.Context(), service.UsageExportScopeAdmin, 0, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.UsageExportTask, 0, len(tasks))
+	for i := range tasks {
+		out = append(out, *dto.UsageExportTaskFromService(&tasks[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// Create handles creating an admin usage export task
+// POST /api/v1/admin/usage/exports
+func (h *UsageExportHandler) Create(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req CreateUsageExportTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	req.StartDate = strings.TrimSpace(req.StartDate)
+	req.EndDate = strings.TrimSpace(req.EndDate)
+	if req.StartDate == "" || req.EndDate == "" {
+		response.BadRequest(c, "start_date and end_date are required")
+		return
+	}
+	startTime, err := timezone.ParseInUserLocation("2006-01-02", req.StartDate, req.Timezone)
+	if err != nil {
+		response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+		return
+	}
+	endTime, err := timezone.ParseInUserLocation("2006-01-02", req.EndDate, req.Timezone)
+	if err != nil {
+		response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+		return
+	}
+	endTime = endTime.Add(24*time.Hour - time.Nanosecond)
+
+	tagKey, tagValue, err := service.NormalizeUsageTagFilter(req.TagKey, req.TagValue)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	filters := usagestats.UsageLogFilters{
+		UserID:      req.UserID,
+		APIKeyID:    req.APIKeyID,
+		AccountID:   req.AccountID,
+		GroupID:     req.GroupID,
+		Model:       strings.TrimSpace(req.Model),
+		Stream:      req.Stream,
+		BillingType: req.BillingType,
+		StartTime:   &startTime,
+		EndTime:     &endTime,
+		TagKey:      tagKey,
+		TagValue:    tagValue,
+	}
+
+	task, err := h.exportService.CreateTask(c.Request.Context(), service.UsageExportScopeAdmin, req.Format, filters, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageExportTaskFromService(task))
+}
+
+// Get handles getting an admin usage export task with progress
+// GET /api/v1/admin/usage/exports/:id
+func (h *UsageExportHandler) Get(c *gin.Context) {
+	taskID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || taskID <= 0 {
+		response.BadRequest(c, "Invalid task id")
+		return
+	}
+	task, err := h.exportService.GetTask(c.Request.Context(), service.UsageExportScopeAdmin, 0, taskID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageExportTaskFromService(task))
+}
+
+// Cancel handles canceling an admin usage export task
+// POST /api/v1/admin/usage/exports/:id/cancel
+func (h *UsageExportHandler) Cancel(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+	taskID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || taskID <= 0 {
+		response.BadRequest(c, "Invalid task id")
+		return
+	}
+	if err := h.exportService.CancelTask(c.Request.Context(), service.UsageExportScopeAdmin, 0, taskID, subject.UserID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"id": taskID, "status": service.UsageExportStatusCanceled})
+}
@@ -121,6 +121,12 @@ func (h *UsageHandler) List(c *gin.Context) {
 		billingType = &bt
 	}
 
+	tagKey, tagValue, err := service.NormalizeUsageTagFilter(c.Query("tag_key"), c.Query("tag_value"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
 	// Parse date range
 	var startTime, endTime *time.Time
 	userTZ := c.Query("timezone") // Get user's timezone from request
@@ -155,6 +161,8 @@ func (h *UsageHandler) List(c *gin.Context) {
 		BillingType: billingType,
 		StartTime:   startTime,
 		EndTime:     endTime,
+		TagKey:      tagKey,
+		TagValue:    tagValue,
 	}
 
 	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
@@ -234,6 +242,12 @@ func (h *UsageHandler) Stats(c *gin.Context) {
 		billingType = &bt
 	}
 
+	tagKey, tagValue, err := service.NormalizeUsageTagFilter(c.Query("tag_key"), c.Query("tag_value"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
 	// Parse date range
 	userTZ := c.Query("timezone")
 	now := timezone.NowInUserLocation(userTZ)
@@ -281,6 +295,8 @@ func (h *UsageHandler) Stats(c *gin.Context) {
 		BillingType: billingType,
 		StartTime:   &startTime,
 		EndTime:     &endTime,
+		TagKey:      tagKey,
+		TagValue:    tagValue,
 	}
 
 	stats, err := h.usageService.GetStatsWithFilters(c.Request.Context(), filters)
@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsagePartitionHandler handles usage_logs partition management
+type UsagePartitionHandler struct {
+	partitionService *service.UsagePartitionService
+}
+
+// NewUsagePartitionHandler creates a new usage partition handler
+func NewUsagePartitionHandler(partitionService *service.UsagePartitionService) *UsagePartitionHandler {
+	return &UsagePartitionHandler{partitionService: partitionService}
+}
+
+// Status handles getting usage_logs partition status
+// GET /api/v1/admin/usage/partitions
+func (h *UsagePartitionHandler) Status(c *gin.Context) {
+	status, err := h.partitionService.Status(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsagePartitionStatusFromService(status))
+}
+
+// Convert handles starting the online conversion to monthly partitions
+// POST /api/v1/admin/usage/partitions/convert
+func (h *UsagePartitionHandler) Convert(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+	task, err := h.partitionService.StartConversion(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsagePartitionTaskFromService(task))
+}
+
+// DropLegacy handles dropping usage_logs_legacy left by the conversion
+// POST /api/v1/admin/usage/partitions/drop-legacy
+func (h *UsagePartitionHandler) DropLegacy(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+	if err := h.partitionService.DropLegacyTable(c.Request.Context(), subject.UserID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"dropped": true})
+}
+
+// ListTasks handles listing partition maintenance tasks
+// GET /api/v1/admin/usage/partitions/tasks
+func (h *UsagePartitionHandler) ListTasks(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	tasks, result, err := h.partitionService.ListTasks(c.Request.Context(), params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.UsagePartitionTask, 0, len(tasks))
+	for i := range tasks {
+		out = append(out, *dto.UsagePartitionTaskFromService(&tasks[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// ListArchives handles listing archived partitions
+// GET /api/v1/admin/usage/partitions/archives
+func (h *UsagePartitionHandler) ListArchives(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	archives, result, err := h.partitionService.ListArchives(c.Request.Context(), params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.UsageLogArchive, 0, len(archives))
+	for i := range archives {
+		out = append(out, *dto.UsageLogArchiveFromService(&archives[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// RestoreArchive handles re-importing an archived partition
+// POST /api/v1/admin/usage/partitions/archives/:id/restore
+func (h *UsagePartitionHandler) RestoreArchive(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+	archiveID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || archiveID <= 0 {
+		response.BadRequest(c, "Invalid archive id")
+		return
+	}
+	task, err := h.partitionService.RestoreArchive(c.Request.Context(), archiveID, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsagePartitionTaskFromService(task))
+}
@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageWriteHandler handles the async usage write queue
+type UsageWriteHandler struct {
+	usageWriteService *service.UsageWriteService
+}
+
+// NewUsageWriteHandler creates a new usage write handler
+func NewUsageWriteHandler(usageWriteService *service.UsageWriteService) *UsageWriteHandler {
+	return &UsageWriteHandler{usageWriteService: usageWriteService}
+}
+
+// Stats handles getting the usage write queue backlog
+// GET /api/v1/admin/usage/write-queue
+func (h *UsageWriteHandler) Stats(c *gin.Context) {
+	stats, err := h.usageWriteService.Stats(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, stats)
+}
+
+// ReplayDeadLetters handles re-queueing dead-lettered usage records
+// POST /api/v1/admin/usage/write-queue/replay
+func (h *UsageWriteHandler) ReplayDeadLetters(c *gin.Context) {
+	replayed, err := h.usageWriteService.ReplayDeadLetters(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"replayed": replayed})
+}
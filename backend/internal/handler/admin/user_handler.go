@@ -6,6 +6,7 @@ import (
 
 	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -171,6 +172,10 @@ func (h *UserHandler) Create(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if req.Balance != 0 && !middleware.HasAdminPermission(c, service.AdminPermUsersBalance) {
+		response.Forbidden(c, "Missing admin permission: "+service.AdminPermUsersBalance)
+		return
+	}
 
 	user, err := h.adminService.CreateUser(c.Request.Context(), &service.CreateUserInput{
 		Email:         req.Email,
@@ -203,6 +208,9 @@ func (h *UserHandler) Update(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if !h.checkUpdatePermissions(c, userID, &req) {
+		return
+	}
 
 	// 使用指针类型直接传递，nil 表示未提供该字段
 	user, err := h.adminService.UpdateUser(c.Request.Context(), userID, &service.UpdateUserInput{
@@ -224,6 +232,32 @@ func (h *UserHandler) Update(c *gin.Context) {
 	response.Success(c, dto.UserFromServiceAdmin(user))
 }
 
+// checkUpdatePermissions 字段级权限校验：余额需要 users:balance，专属倍率属于定价需要 groups:write，
+// 修改其他管理员的资料需要 roles:manage（避免低权限角色改密码接管高权限账号）。
+func (h *UserHandler) checkUpdatePermissions(c *gin.Context, userID int64, req *UpdateUserRequest) bool {
+	if req.Balance != nil && !middleware.HasAdminPermission(c, service.AdminPermUsersBalance) {
+		response.Forbidden(c, "Missing admin permission: "+service.AdminPermUsersBalance)
+		return false
+	}
+	if req.GroupRates != nil && !middleware.HasAdminPermission(c, service.AdminPermGroupsWrite) {
+		response.Forbidden(c, "Missing admin permission: "+service.AdminPermGroupsWrite)
+		return false
+	}
+	if middleware.HasAdminPermission(c, service.AdminPermRolesManage) {
+		return true
+	}
+	target, err := h.adminService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return false
+	}
+	if target.IsAdmin() {
+		response.Forbidden(c, "Missing admin permission: "+service.AdminPermRolesManage)
+		return false
+	}
+	return true
+}
+
 // Delete handles deleting a user
 // DELETE /api/v1/admin/users/:id
 func (h *UserHandler) Delete(c *gin.Context) {
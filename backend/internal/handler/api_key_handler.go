@@ -16,13 +16,15 @@ import (
 
 // APIKeyHandler handles API key-related requests
 type APIKeyHandler struct {
-	apiKeyService *service.APIKeyService
+	apiKeyService       *service.APIKeyService
+	billingCacheService *service.BillingCacheService
 }
 
 // NewAPIKeyHandler creates a new APIKeyHandler
-func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, billingCacheService *service.BillingCacheService) *APIKeyHandler {
 	return &APIKeyHandler{
-		apiKeyService: apiKeyService,
+		apiKeyService:       apiKeyService,
+		billingCacheService: billingCacheService,
 	}
 }
 
@@ -35,6 +37,17 @@ type CreateAPIKeyRequest struct {
 	IPBlacklist   []string `json:"ip_blacklist"`    // IP 黑名单
 	Quota         *float64 `json:"quota"`           // 配额限制 (USD)
 	ExpiresInDays *int     `json:"expires_in_days"` // 过期天数
+
+	// 日/周/月消费上限 (USD)，省略或 0 表示不限制
+	DailyLimitUSD   *float64 `json:"daily_limit_usd" binding:"omitempty,gte=0"`
+	WeeklyLimitUSD  *float64 `json:"weekly_limit_usd" binding:"omitempty,gte=0"`
+	MonthlyLimitUSD *float64 `json:"monthly_limit_usd" binding:"omitempty,gte=0"`
+
+	// 成本归属标签键白名单，省略时使用用户级配置
+	AllowedTagKeys []string `json:"allowed_tag_keys"`
+
+	// 在组织下创建 Key，请求从组织资金池扣费（需为组织成员）
+	OrganizationID *int64 `json:"organization_id"`
 }
 
 // UpdateAPIKeyRequest represents the update API key request payload
@@ -47,6 +60,20 @@ type UpdateAPIKeyRequest struct {
 	Quota       *float64 `json:"quota"`        // 配额限制 (USD), 0=无限制
 	ExpiresAt   *string  `json:"expires_at"`   // 过期时间 (ISO 8601)
 	ResetQuota  *bool    `json:"reset_quota"`  // 重置已用配额
+
+	// 日/周/月消费上限 (USD)，省略表示不变，0 表示不限制
+	DailyLimitUSD   *float64 `json:"daily_limit_usd" binding:"omitempty,gte=0"`
+	WeeklyLimitUSD  *float64 `json:"weekly_limit_usd" binding:"omitempty,gte=0"`
+	MonthlyLimitUSD *float64 `json:"monthly_limit_usd" binding:"omitempty,gte=0"`
+
+	// 成本归属标签键白名单，省略表示不变，空数组清空
+	AllowedTagKeys []string `json:"allowed_tag_keys"`
+}
+
+// RotateAPIKeyRequest represents the rotate API key request payload
+type RotateAPIKeyRequest struct {
+	// GracePeriodHours 旧密钥继续有效的小时数，nil 使用默认 24 小时，0 表示立即失效
+	GracePeriodHours *int `json:"grace_period_hours" binding:"omitempty,min=0,max=720"`
 }
 
 // List handles listing user's API keys with pagination
@@ -69,11 +96,25 @@ func (h *APIKeyHandler) List(c *gin.Context) {
 
 	out := make([]dto.APIKey, 0, len(keys))
 	for i := range keys {
-		out = append(out, *dto.APIKeyFromService(&keys[i]))
+		item := dto.APIKeyFromService(&keys[i])
+		item.Spend = h.spendOf(c, &keys[i])
+		out = append(out, *item)
 	}
 	response.Paginated(c, out, result.Total, page, pageSize)
 }
 
+// spendOf 返回配置了消费上限的 Key 的当前窗口消费（best-effort，失败时省略）
+func (h *APIKeyHandler) spendOf(c *gin.Context, key *service.APIKey) *dto.APIKeySpend {
+	if h.billingCacheService == nil || !key.HasSpendLimits() {
+		return nil
+	}
+	spend, err := h.billingCacheService.GetAPIKeySpend(c.Request.Context(), key.ID)
+	if err != nil {
+		return nil
+	}
+	return dto.APIKeySpendFromService(spend)
+}
+
 // GetByID handles getting a single API key
 // GET /api/v1/api-keys/:id
 func (h *APIKeyHandler) GetByID(c *gin.Context) {
@@ -101,7 +142,9 @@ func (h *APIKeyHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, dto.APIKeyFromService(key))
+	out := dto.APIKeyFromService(key)
+	out.Spend = h.spendOf(c, key)
+	response.Success(c, out)
 }
 
 // Create handles creating a new API key
@@ -126,6 +169,13 @@ func (h *APIKeyHandler) Create(c *gin.Context) {
 		IPWhitelist:   req.IPWhitelist,
 		IPBlacklist:   req.IPBlacklist,
 		ExpiresInDays: req.ExpiresInDays,
+
+		DailyLimitUSD:   req.DailyLimitUSD,
+		WeeklyLimitUSD:  req.WeeklyLimitUSD,
+		MonthlyLimitUSD: req.MonthlyLimitUSD,
+
+		AllowedTagKeys: req.AllowedTagKeys,
+		OrganizationID: req.OrganizationID,
 	}
 	if req.Quota != nil {
 		svcReq.Quota = *req.Quota
@@ -165,6 +215,12 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 		IPBlacklist: req.IPBlacklist,
 		Quota:       req.Quota,
 		ResetQuota:  req.ResetQuota,
+
+		DailyLimitUSD:   req.DailyLimitUSD,
+		WeeklyLimitUSD:  req.WeeklyLimitUSD,
+		MonthlyLimitUSD: req.MonthlyLimitUSD,
+
+		AllowedTagKeys: req.AllowedTagKeys,
 	}
 	if req.Name != "" {
 		svcReq.Name = &req.Name
@@ -198,6 +254,43 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 	response.Success(c, dto.APIKeyFromService(key))
 }
 
+// Rotate handles issuing a new secret for an API key
+// POST /api/v1/keys/:id/rotate
+func (h *APIKeyHandler) Rotate(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	keyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid key ID")
+		return
+	}
+
+	var req RotateAPIKeyRequest
+	// 请求体可省略，省略时使用默认宽限期
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+	}
+	gracePeriod := service.DefaultAPIKeyRotationGracePeriod
+	if req.GracePeriodHours != nil {
+		gracePeriod = time.Duration(*req.GracePeriodHours) * time.Hour
+	}
+
+	key, err := h.apiKeyService.Rotate(c.Request.Context(), keyID, subject.UserID, gracePeriod)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.APIKeyFromService(key))
+}
+
 // Delete handles deleting an API key
 // DELETE /api/v1/api-keys/:id
 func (h *APIKeyHandler) Delete(c *gin.Context) {
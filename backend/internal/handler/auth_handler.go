@@ -7,6 +7,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/webauthn"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
@@ -15,25 +16,31 @@ import (
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
-	cfg           *config.Config
-	authService   *service.AuthService
-	userService   *service.UserService
-	settingSvc    *service.SettingService
-	promoService  *service.PromoService
-	redeemService *service.RedeemService
-	totpService   *service.TotpService
+	cfg            *config.Config
+	authService    *service.AuthService
+	userService    *service.UserService
+	settingSvc     *service.SettingService
+	promoService   *service.PromoService
+	redeemService  *service.RedeemService
+	totpService    *service.TotpService
+	passkeyService *service.PasskeyService
+	loginSecurity  *service.LoginSecurityService
+	referral       *service.ReferralService
 }
 
 // NewAuthHandler creates a new AuthHandler
-func NewAuthHandler(cfg *config.Config, authService *service.AuthService, userService *service.UserService, settingService *service.SettingService, promoService *service.PromoService, redeemService *service.RedeemService, totpService *service.TotpService) *AuthHandler {
+func NewAuthHandler(cfg *config.Config, authService *service.AuthService, userService *service.UserService, settingService *service.SettingService, promoService *service.PromoService, redeemService *service.RedeemService, totpService *service.TotpService, passkeyService *service.PasskeyService, loginSecurity *service.LoginSecurityService, referral *service.ReferralService) *AuthHandler {
 	return &AuthHandler{
-		cfg:           cfg,
-		authService:   authService,
-		userService:   userService,
-		settingSvc:    settingService,
-		promoService:  promoService,
-		redeemService: redeemService,
-		totpService:   totpService,
+		cfg:            cfg,
+		authService:    authService,
+		userService:    userService,
+		settingSvc:     settingService,
+		promoService:   promoService,
+		redeemService:  redeemService,
+		totpService:    totpService,
+		passkeyService: passkeyService,
+		loginSecurity:  loginSecurity,
+		referral:       referral,
 	}
 }
 
@@ -45,6 +52,7 @@ type RegisterRequest struct {
 	TurnstileToken string `json:"turnstile_token"`
 	PromoCode      string `json:"promo_code"`      // 注册优惠码
 	InvitationCode string `json:"invitation_code"` // 邀请码
+	ReferralCode   string `json:"referral_code"`   // 推荐码（推荐链接 ?ref=）
 }
 
 // SendVerifyCodeRequest 发送验证码请求
@@ -75,10 +83,15 @@ type AuthResponse struct {
 	User         *dto.User `json:"user"`
 }
 
-// respondWithTokenPair 生成 Token 对并返回认证响应
+// respondWithTokenPair 记录登录成功并生成 Token 对返回认证响应
+// method 为登录方式，写入登录事件与会话
 // 如果 Token 对生成失败，回退到只返回 Access Token（向后兼容）
-func (h *AuthHandler) respondWithTokenPair(c *gin.Context, user *service.User) {
-	tokenPair, err := h.authService.GenerateTokenPair(c.Request.Context(), user, "")
+func (h *AuthHandler) respondWithTokenPair(c *gin.Context, user *service.User, method string) {
+	ctx := service.WithLoginMethod(c.Request.Context(), method)
+	if h.loginSecurity != nil {
+		h.loginSecurity.RecordSuccess(ctx, user, method)
+	}
+	tokenPair, err := h.authService.GenerateTokenPair(ctx, user, "")
 	if err != nil {
 		slog.Error("failed to generate token pair", "error", err, "user_id", user.ID)
 		// 回退到只返回Access Token
@@ -126,7 +139,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	h.respondWithTokenPair(c, user)
+	// 建立推荐关系失败不影响注册，只记录日志
+	if req.ReferralCode != "" && h.referral != nil {
+		if err := h.referral.BindReferral(c.Request.Context(), user.ID, req.ReferralCode); err != nil {
+			slog.Info("register_referral_bind_failed", "user_id", user.ID, "error", err)
+		}
+	}
+
+	h.respondWithTokenPair(c, user, service.LoginMethodRegister)
 }
 
 // SendVerifyCode 发送邮箱验证码
@@ -171,15 +191,26 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if err := h.checkLoginLockout(c, req.Email, nil, service.LoginMethodPassword); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
 	token, user, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
+		h.recordLoginFailure(c, req.Email, nil, service.LoginMethodPassword, err)
 		response.ErrorFrom(c, err)
 		return
 	}
 	_ = token // token 由 authService.Login 返回但此处由 respondWithTokenPair 重新生成
 
-	// Check if TOTP 2FA is enabled for this user
-	if h.totpService != nil && h.settingSvc.IsTotpEnabled(c.Request.Context()) && user.TotpEnabled {
+	// Check if 2FA (TOTP or passkey) is enabled for this user
+	methods, err := h.secondFactorMethods(c.Request.Context(), user)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	if len(methods) > 0 && h.totpService != nil {
 		// Create a temporary login session for 2FA
 		tempToken, err := h.totpService.CreateLoginSession(c.Request.Context(), user.ID, user.Email)
 		if err != nil {
@@ -191,11 +222,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			Requires2FA:     true,
 			TempToken:       tempToken,
 			UserEmailMasked: service.MaskEmail(user.Email),
+			Methods:         methods,
 		})
 		return
 	}
 
-	h.respondWithTokenPair(c, user)
+	h.respondWithTokenPair(c, user, service.LoginMethodPassword)
 }
 
 // TotpLoginResponse represents the response when 2FA is required
@@ -203,12 +235,17 @@ type TotpLoginResponse struct {
 	Requires2FA     bool   `json:"requires_2fa"`
 	TempToken       string `json:"temp_token,omitempty"`
 	UserEmailMasked string `json:"user_email_masked,omitempty"`
+	// Methods 可用的第二因素：totp / passkey / recovery_code
+	Methods []string `json:"methods,omitempty"`
 }
 
 // Login2FARequest represents the 2FA login request
 type Login2FARequest struct {
 	TempToken string `json:"temp_token" binding:"required"`
-	TotpCode  string `json:"totp_code" binding:"required,len=6"`
+	// 以下三种第二因素任选其一
+	TotpCode     string                      `json:"totp_code" binding:"omitempty,len=6"`
+	Passkey      *webauthn.AssertionResponse `json:"passkey"`
+	RecoveryCode string                      `json:"recovery_code"`
 }
 
 // Login2FA completes the login with 2FA verification
@@ -242,11 +279,17 @@ func (h *AuthHandler) Login2FA(c *gin.Context) {
 		"user_id", session.UserID,
 		"email", session.Email)
 
-	// Verify the TOTP code
-	if err := h.totpService.VerifyCode(c.Request.Context(), session.UserID, req.TotpCode); err != nil {
+	if err := h.checkLoginLockout(c, session.Email, &session.UserID, service.LoginMethod2FA); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	// Verify the second factor
+	if err := h.verifySecondFactor(c.Request.Context(), session.UserID, &req); err != nil {
 		slog.Debug("login_2fa_verify_failed",
 			"user_id", session.UserID,
 			"error", err)
+		h.recordLoginFailure(c, session.Email, &session.UserID, service.LoginMethod2FA, err)
 		response.ErrorFrom(c, err)
 		return
 	}
@@ -261,7 +304,7 @@ func (h *AuthHandler) Login2FA(c *gin.Context) {
 		return
 	}
 
-	h.respondWithTokenPair(c, user)
+	h.respondWithTokenPair(c, user, service.LoginMethod2FA)
 }
 
 // GetCurrentUser handles getting current authenticated user
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/oauth"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oauthProviderCookiePathPrefix = "/api/v1/auth/oauth/providers/"
+	oauthProviderStateCookie      = "oauth_provider_state"
+	oauthProviderNonceCookie      = "oauth_provider_nonce"
+	oauthProviderVerifierCookie   = "oauth_provider_verifier"
+	oauthProviderRedirectCookie   = "oauth_provider_redirect"
+)
+
+// OAuthHandler 通用 OIDC / OAuth2 登录（Keycloak、GitHub 等），提供商配置见 settings.oauth_providers
+type OAuthHandler struct {
+	oauthProviderService *service.OAuthProviderService
+	loginSecurity        *service.LoginSecurityService
+}
+
+// NewOAuthHandler creates a new OAuthHandler
+func NewOAuthHandler(oauthProviderService *service.OAuthProviderService, loginSecurity *service.LoginSecurityService) *OAuthHandler {
+	return &OAuthHandler{oauthProviderService: oauthProviderService, loginSecurity: loginSecurity}
+}
+
+// ListProviders 登录页可用的第三方登录提供商
+// GET /api/v1/auth/oauth/providers
+func (h *OAuthHandler) ListProviders(c *gin.Context) {
+	providers, err := h.oauthProviderService.PublicProviders(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, providers)
+}
+
+// Start 启动第三方登录流程
+// GET /api/v1/auth/oauth/providers/:provider/start?redirect=/dashboard
+func (h *OAuthHandler) Start(c *gin.Context) {
+	provider, err := h.oauthProviderService.GetEnabledProvider(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	state, err := oauth.GenerateState()
+	if err != nil {
+		response.ErrorFrom(c, infraerrors.InternalServer("OAUTH_STATE_GEN_FAILED", "failed to generate oauth state").WithCause(err))
+		return
+	}
+	nonce, err := oauth.GenerateState()
+	if err != nil {
+		response.ErrorFrom(c, infraerrors.InternalServer("OAUTH_STATE_GEN_FAILED", "failed to generate oauth nonce").WithCause(err))
+		return
+	}
+
+	redirectTo := sanitizeFrontendRedirectPath(c.Query("redirect"))
+	if redirectTo == "" {
+		redirectTo = linuxDoOAuthDefaultRedirectTo
+	}
+
+	cookiePath := oauthProviderCookiePathPrefix + provider.Name
+	secureCookie := isRequestHTTPS(c)
+	setCookieAtPath(c, cookiePath, oauthProviderStateCookie, encodeCookieValue(state), linuxDoOAuthCookieMaxAgeSec, secureCookie)
+	setCookieAtPath(c, cookiePath, oauthProviderNonceCookie, encodeCookieValue(nonce), linuxDoOAuthCookieMaxAgeSec, secureCookie)
+	setCookieAtPath(c, cookiePath, oauthProviderRedirectCookie, encodeCookieValue(redirectTo), linuxDoOAuthCookieMaxAgeSec, secureCookie)
+
+	codeChallenge := ""
+	if provider.UsePKCE {
+		verifier, err := oauth.GenerateCodeVerifier()
+		if err != nil {
+			response.ErrorFrom(c, infraerrors.InternalServer("OAUTH_PKCE_GEN_FAILED", "failed to generate pkce verifier").WithCause(err))
+			return
+		}
+		codeChallenge = oauth.GenerateCodeChallenge(verifier)
+		setCookieAtPath(c, cookiePath, oauthProviderVerifierCookie, encodeCookieValue(verifier), linuxDoOAuthCookieMaxAgeSec, secureCookie)
+	}
+
+	authURL, err := h.oauthProviderService.BuildAuthorizeURL(c.Request.Context(), provider, state, nonce, codeChallenge)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 处理第三方回调：换取用户信息、绑定/注册用户，然后重定向到前端
+// GET /api/v1/auth/oauth/providers/:provider/callback?code=...&state=...
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	provider, err := h.oauthProviderService.GetEnabledProvider(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	frontendCallback := provider.FrontendRedirectURL
+
+	if providerErr := strings.TrimSpace(c.Query("error")); providerErr != "" {
+		redirectOAuthError(c, frontendCallback, "provider_error", providerErr, c.Query("error_description"))
+		return
+	}
+
+	code := strings.TrimSpace(c.Query("code"))
+	state := strings.TrimSpace(c.Query("state"))
+	if code == "" || state == "" {
+		redirectOAuthError(c, frontendCallback, "missing_params", "missing code/state", "")
+		return
+	}
+
+	cookiePath := oauthProviderCookiePathPrefix + provider.Name
+	secureCookie := isRequestHTTPS(c)
+	defer func() {
+		for _, name := range []string{oauthProviderStateCookie, oauthProviderNonceCookie, oauthProviderVerifierCookie, oauthProviderRedirectCookie} {
+			clearCookieAtPath(c, cookiePath, name, secureCookie)
+		}
+	}()
+
+	expectedState, err := readCookieDecoded(c, oauthProviderStateCookie)
+	if err != nil || expectedState == "" || state != expectedState {
+		redirectOAuthError(c, frontendCallback, "invalid_state", "invalid oauth state", "")
+		return
+	}
+	nonce, _ := readCookieDecoded(c, oauthProviderNonceCookie)
+
+	redirectTo, _ := readCookieDecoded(c, oauthProviderRedirectCookie)
+	redirectTo = sanitizeFrontendRedirectPath(redirectTo)
+	if redirectTo == "" {
+		redirectTo = linuxDoOAuthDefaultRedirectTo
+	}
+
+	codeVerifier := ""
+	if provider.UsePKCE {
+		codeVerifier, _ = readCookieDecoded(c, oauthProviderVerifierCookie)
+		if codeVerifier == "" {
+			redirectOAuthError(c, frontendCallback, "missing_verifier", "missing pkce verifier", "")
+			return
+		}
+	}
+
+	tokenPair, user, err := h.oauthProviderService.CompleteLogin(c.Request.Context(), provider, code, codeVerifier, nonce)
+	if err != nil {
+		log.Printf("[OAuth] login failed provider=%s: %v", provider.Name, err)
+		// 避免把内部细节泄露给客户端；给前端保留结构化原因与提示信息即可。
+		redirectOAuthError(c, frontendCallback, "login_failed", infraerrors.Reason(err), infraerrors.Message(err))
+		return
+	}
+	if h.loginSecurity != nil {
+		h.loginSecurity.RecordSuccess(c.Request.Context(), user, service.LoginMethodOAuth)
+	}
+
+	fragment := url.Values{}
+	fragment.Set("access_token", tokenPair.AccessToken)
+	fragment.Set("refresh_token", tokenPair.RefreshToken)
+	fragment.Set("expires_in", fmt.Sprintf("%d", tokenPair.ExpiresIn))
+	fragment.Set("token_type", "Bearer")
+	fragment.Set("redirect", redirectTo)
+	redirectWithFragment(c, frontendCallback, fragment)
+}
+
+func setCookieAtPath(c *gin.Context, path, name, value string, maxAgeSec int, secure bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAgeSec,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearCookieAtPath(c *gin.Context, path, name string, secure bool) {
+	setCookieAtPath(c, path, name, "", -1, secure)
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/webauthn"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 第二因素类型
+const (
+	secondFactorTOTP         = "totp"
+	secondFactorPasskey      = "passkey"
+	secondFactorRecoveryCode = "recovery_code"
+)
+
+// PasskeyLoginOptionsResponse 免密登录挑战
+type PasskeyLoginOptionsResponse struct {
+	SessionToken string                   `json:"session_token"`
+	PublicKey    *webauthn.RequestOptions `json:"public_key"`
+}
+
+// PasskeyLoginRequest 免密登录请求
+type PasskeyLoginRequest struct {
+	SessionToken string                      `json:"session_token" binding:"required"`
+	Credential   *webauthn.AssertionResponse `json:"credential" binding:"required"`
+}
+
+// Login2FAPasskeyOptionsRequest 2FA 通行密钥挑战请求
+type Login2FAPasskeyOptionsRequest struct {
+	TempToken string `json:"temp_token" binding:"required"`
+}
+
+// PasskeyLoginOptions 生成免密登录挑战
+// POST /api/v1/auth/passkey/login/options
+func (h *AuthHandler) PasskeyLoginOptions(c *gin.Context) {
+	if h.passkeyService == nil {
+		response.ErrorFrom(c, service.ErrPasskeyNotEnabled)
+		return
+	}
+	sessionToken, opts, err := h.passkeyService.BeginLogin(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, PasskeyLoginOptionsResponse{SessionToken: sessionToken, PublicKey: opts})
+}
+
+// PasskeyLogin 使用通行密钥免密登录（通行密钥本身即为多因素凭据，不再要求 TOTP）
+// POST /api/v1/auth/passkey/login
+func (h *AuthHandler) PasskeyLogin(c *gin.Context) {
+	if h.passkeyService == nil {
+		response.ErrorFrom(c, service.ErrPasskeyNotEnabled)
+		return
+	}
+	var req PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if err := h.checkLoginLockout(c, "", nil, service.LoginMethodPasskey); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	user, err := h.passkeyService.FinishLogin(c.Request.Context(), req.SessionToken, req.Credential)
+	if err != nil {
+		h.recordLoginFailure(c, "", nil, service.LoginMethodPasskey, err)
+		response.ErrorFrom(c, err)
+		return
+	}
+	// 免密登录在断言校验后才能确定账号，此时再检查账号维度的锁定
+	if err := h.checkLoginLockout(c, user.Email, &user.ID, service.LoginMethodPasskey); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	h.respondWithTokenPair(c, user, service.LoginMethodPasskey)
+}
+
+// Login2FAPasskeyOptions 为密码登录后的 2FA 会话生成通行密钥挑战
+// POST /api/v1/auth/login/2fa/passkey/options
+func (h *AuthHandler) Login2FAPasskeyOptions(c *gin.Context) {
+	if h.passkeyService == nil || h.totpService == nil {
+		response.ErrorFrom(c, service.ErrPasskeyNotEnabled)
+		return
+	}
+	var req Login2FAPasskeyOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	session, err := h.totpService.GetLoginSession(c.Request.Context(), req.TempToken)
+	if err != nil || session == nil {
+		response.BadRequest(c, "Invalid or expired 2FA session")
+		return
+	}
+	opts, err := h.passkeyService.BeginSecondFactor(c.Request.Context(), req.TempToken, session.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"public_key": opts})
+}
+
+// secondFactorMethods 返回用户可用的第二因素；为空表示无需 2FA。
+// 角色要求通行密钥且用户已注册时，不再接受 TOTP。
+func (h *AuthHandler) secondFactorMethods(ctx context.Context, user *service.User) ([]string, error) {
+	totpEnabled := h.totpService != nil && h.settingSvc.IsTotpEnabled(ctx) && user.TotpEnabled
+	hasPasskeys := false
+	if h.passkeyService != nil {
+		var err error
+		if hasPasskeys, err = h.passkeyService.HasPasskeys(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	methods := make([]string, 0, 3)
+	if totpEnabled && !(hasPasskeys && h.passkeyService.IsRequiredForUser(ctx, user)) {
+		methods = append(methods, secondFactorTOTP)
+	}
+	if hasPasskeys {
+		methods = append(methods, secondFactorPasskey, secondFactorRecoveryCode)
+	}
+	return methods, nil
+}
+
+// verifySecondFactor 校验 Login2FA 请求中提供的唯一一种第二因素
+func (h *AuthHandler) verifySecondFactor(ctx context.Context, userID int64, req *Login2FARequest) error {
+	provided := 0
+	for _, ok := range []bool{req.TotpCode != "", req.Passkey != nil, strings.TrimSpace(req.RecoveryCode) != ""} {
+		if ok {
+			provided++
+		}
+	}
+	if provided != 1 {
+		return service.ErrSecondFactorMethodRequired
+	}
+
+	switch {
+	case req.Passkey != nil:
+		if h.passkeyService == nil {
+			return service.ErrPasskeyNotEnabled
+		}
+		return h.passkeyService.VerifySecondFactor(ctx, req.TempToken, userID, req.Passkey)
+	case req.RecoveryCode != "":
+		if h.passkeyService == nil {
+			return service.ErrPasskeyNotEnabled
+		}
+		return h.passkeyService.VerifyRecoveryCode(ctx, userID, req.RecoveryCode)
+	default:
+		if h.passkeyService != nil {
+			user, err := h.userService.GetByID(ctx, userID)
+			if err != nil {
+				return err
+			}
+			if h.passkeyService.IsRequiredForUser(ctx, user) {
+				hasPasskeys, err := h.passkeyService.HasPasskeys(ctx, userID)
+				if err != nil {
+					return err
+				}
+				if hasPasskeys {
+					return service.ErrPasskeyRequired
+				}
+			}
+		}
+		return h.totpService.VerifyCode(ctx, userID, req.TotpCode)
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkLoginLockout 账号或 IP 被锁定时记录失败事件并返回 ErrLoginLocked
+func (h *AuthHandler) checkLoginLockout(c *gin.Context, email string, userID *int64, method string) error {
+	if h.loginSecurity == nil {
+		return nil
+	}
+	if err := h.loginSecurity.CheckLockout(c.Request.Context(), email); err != nil {
+		h.loginSecurity.RecordFailure(c.Request.Context(), email, userID, method, err)
+		return err
+	}
+	return nil
+}
+
+func (h *AuthHandler) recordLoginFailure(c *gin.Context, email string, userID *int64, method string, err error) {
+	if h.loginSecurity != nil {
+		h.loginSecurity.RecordFailure(c.Request.Context(), email, userID, method, err)
+	}
+}
+
+// ListSessions 当前用户的活跃登录会话
+// GET /api/v1/user/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	sessions, err := h.authService.ListUserSessions(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, sessions)
+}
+
+// RevokeSession 撤销当前用户的指定会话
+// DELETE /api/v1/user/sessions/:id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid session ID")
+		return
+	}
+	if err := h.authService.RevokeUserSession(c.Request.Context(), subject.UserID, id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Session revoked"})
+}
+
+// ListLoginHistory 当前用户的登录记录（含失败尝试）
+// GET /api/v1/user/login-history
+func (h *AuthHandler) ListLoginHistory(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	if h.loginSecurity == nil {
+		response.Paginated(c, []service.LoginEvent{}, 0, 1, 20)
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+	events, result, err := h.loginSecurity.ListEvents(c.Request.Context(), service.LoginEventFilter{UserID: subject.UserID}, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, events, result.Total, page, pageSize)
+}
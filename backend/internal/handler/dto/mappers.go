@@ -2,9 +2,11 @@
 package dto
 
 import (
+	"math"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/Wei-Shaw/sub2api/internal/util/logredact"
 )
 
 func UserFromServiceShallow(u *service.User) *User {
@@ -12,16 +14,17 @@ func UserFromServiceShallow(u *service.User) *User {
 		return nil
 	}
 	return &User{
-		ID:            u.ID,
-		Email:         u.Email,
-		Username:      u.Username,
-		Role:          u.Role,
-		Balance:       u.Balance,
-		Concurrency:   u.Concurrency,
-		Status:        u.Status,
-		AllowedGroups: u.AllowedGroups,
-		CreatedAt:     u.CreatedAt,
-		UpdatedAt:     u.UpdatedAt,
+		ID:             u.ID,
+		Email:          u.Email,
+		Username:       u.Username,
+		Role:           u.Role,
+		Balance:        u.Balance,
+		Concurrency:    u.Concurrency,
+		Status:         u.Status,
+		AllowedGroups:  u.AllowedGroups,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+		AllowedTagKeys: u.AllowedTagKeys,
 	}
 }
 
@@ -60,6 +63,7 @@ func UserFromServiceAdmin(u *service.User) *AdminUser {
 	return &AdminUser{
 		User:       *base,
 		Notes:      u.Notes,
+		AdminRole:  u.AdminRole,
 		GroupRates: u.GroupRates,
 	}
 }
@@ -68,22 +72,46 @@ func APIKeyFromService(k *service.APIKey) *APIKey {
 	if k == nil {
 		return nil
 	}
-	return &APIKey{
-		ID:          k.ID,
-		UserID:      k.UserID,
-		Key:         k.Key,
-		Name:        k.Name,
-		GroupID:     k.GroupID,
-		Status:      k.Status,
-		IPWhitelist: k.IPWhitelist,
-		IPBlacklist: k.IPBlacklist,
-		Quota:       k.Quota,
-		QuotaUsed:   k.QuotaUsed,
-		ExpiresAt:   k.ExpiresAt,
-		CreatedAt:   k.CreatedAt,
-		UpdatedAt:   k.UpdatedAt,
-		User:        UserFromServiceShallow(k.User),
-		Group:       GroupFromServiceShallow(k.Group),
+	out := &APIKey{
+		ID:            k.ID,
+		UserID:        k.UserID,
+		Key:           k.Key,
+		Name:          k.Name,
+		GroupID:       k.GroupID,
+		Status:        k.Status,
+		IPWhitelist:   k.IPWhitelist,
+		IPBlacklist:   k.IPBlacklist,
+		Quota:         k.Quota,
+		QuotaUsed:     k.QuotaUsed,
+		ExpiresAt:     k.ExpiresAt,
+		CreatedAt:     k.CreatedAt,
+		UpdatedAt:     k.UpdatedAt,
+		KeyGeneration: k.KeyGeneration,
+		RotatedAt:     k.RotatedAt,
+
+		DailyLimitUSD:   k.DailyLimitUSD,
+		WeeklyLimitUSD:  k.WeeklyLimitUSD,
+		MonthlyLimitUSD: k.MonthlyLimitUSD,
+		AllowedTagKeys:  k.AllowedTagKeys,
+		OrganizationID:  k.OrganizationID,
+		User:            UserFromServiceShallow(k.User),
+		Group:           GroupFromServiceShallow(k.Group),
+	}
+	if k.HasActivePreviousKey(time.Now()) {
+		out.PreviousKeyExpiresAt = k.PreviousKeyExpiresAt
+	}
+	return out
+}
+
+// APIKeySpendFromService converts API key window spend to DTO.
+func APIKeySpendFromService(s *service.APIKeySpendWindows) *APIKeySpend {
+	if s == nil {
+		return nil
+	}
+	return &APIKeySpend{
+		DailyUsageUSD:   s.Daily,
+		WeeklyUsageUSD:  s.Weekly,
+		MonthlyUsageUSD: s.Monthly,
 	}
 }
 
@@ -116,6 +144,10 @@ func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
 		SupportedModelScopes: g.SupportedModelScopes,
 		AccountCount:         g.AccountCount,
 		SortOrder:            g.SortOrder,
+		SelectionStrategy:    g.SelectionStrategy,
+		FairShareEnabled:     g.FairShareEnabled,
+		ModelFallback:        g.ModelFallback,
+		ModelFallbackEnabled: g.ModelFallbackEnabled,
 	}
 	if len(g.AccountGroups) > 0 {
 		out.AccountGroups = make([]AccountGroup, 0, len(g.AccountGroups))
@@ -165,6 +197,7 @@ func AccountFromServiceShallow(a *service.Account) *Account {
 		Credentials:             a.Credentials,
 		Extra:                   a.Extra,
 		ProxyID:                 a.ProxyID,
+		ProxyPoolID:             a.ProxyPoolID,
 		Concurrency:             a.Concurrency,
 		Priority:                a.Priority,
 		RateMultiplier:          a.BillingRateMultiplier(),
@@ -216,6 +249,19 @@ func AccountFromServiceShallow(a *service.Account) *Account {
 	return out
 }
 
+// accountSecretCredentialKeys 账号凭证中需要脱敏的字段（在 logredact 默认敏感字段之外）
+var accountSecretCredentialKeys = []string{"api_key", "session_key", "session_token", "cookie", "client_secret", "private_key"}
+
+// RedactAccountCredentials 脱敏账号凭证中的密钥类字段（token、API Key、cookie 等），
+// 保留 base_url、project_id 等配置项，供无凭证权限的管理员查看。
+func RedactAccountCredentials(a *Account) *Account {
+	if a == nil || a.Credentials == nil {
+		return a
+	}
+	a.Credentials = logredact.RedactMap(a.Credentials, accountSecretCredentialKeys...)
+	return a
+}
+
 func AccountFromService(a *service.Account) *Account {
 	if a == nil {
 		return nil
@@ -376,6 +422,7 @@ func usageLogFromServiceUser(l *service.UsageLog) UsageLog {
 		RequestID:             l.RequestID,
 		Model:                 l.Model,
 		ReasoningEffort:       l.ReasoningEffort,
+		RequestedModel:        l.RequestedModel,
 		GroupID:               l.GroupID,
 		SubscriptionID:        l.SubscriptionID,
 		InputTokens:           l.InputTokens,
@@ -397,6 +444,8 @@ func usageLogFromServiceUser(l *service.UsageLog) UsageLog {
 		FirstTokenMs:          l.FirstTokenMs,
 		ImageCount:            l.ImageCount,
 		ImageSize:             l.ImageSize,
+		KeyGeneration:         l.KeyGeneration,
+		Tags:                  l.Tags,
 		UserAgent:             l.UserAgent,
 		CreatedAt:             l.CreatedAt,
 		User:                  UserFromServiceShallow(l.User),
@@ -460,6 +509,129 @@ func UsageCleanupTaskFromService(task *service.UsageCleanupTask) *UsageCleanupTa
 	}
 }
 
+func UsageExportTaskFromService(task *service.UsageExportTask) *UsageExportTask {
+	if task == nil {
+		return nil
+	}
+	out := &UsageExportTask{
+		ID:     task.ID,
+		Scope:  task.Scope,
+		Format: task.Format,
+		Status: task.Status,
+		Filters: UsageExportFilters{
+			StartTime:      task.Filters.StartTime,
+			EndTime:        task.Filters.EndTime,
+			UserID:         task.Filters.UserID,
+			APIKeyID:       task.Filters.APIKeyID,
+			OrganizationID: task.Filters.OrganizationID,
+			AccountID:      task.Filters.AccountID,
+			GroupID:        task.Filters.GroupID,
+			Model:          task.Filters.Model,
+			Stream:         task.Filters.Stream,
+			BillingType:    task.Filters.BillingType,
+			TagKey:         task.Filters.TagKey,
+			TagValue:       task.Filters.TagValue,
+		},
+		CreatedBy:    task.CreatedBy,
+		TotalRows:    task.TotalRows,
+		ExportedRows: task.ExportedRows,
+		ErrorMessage: task.ErrorMsg,
+		CanceledBy:   task.CanceledBy,
+		CanceledAt:   task.CanceledAt,
+		StartedAt:    task.StartedAt,
+		FinishedAt:   task.FinishedAt,
+		ExpiresAt:    task.ExpiresAt,
+		CreatedAt:    task.CreatedAt,
+		UpdatedAt:    task.UpdatedAt,
+	}
+	switch {
+	case task.Status == service.UsageExportStatusSucceeded:
+		out.Progress = 1
+	case task.TotalRows > 0:
+		out.Progress = math.Min(float64(task.ExportedRows)/float64(task.TotalRows), 1)
+	}
+	if task.Downloadable(time.Now()) {
+		out.FileSize = task.FileSize
+		out.DownloadURL = "/api/v1/usage-exports/" + task.DownloadToken
+	}
+	return out
+}
+
+func UsagePartitionTaskFromService(task *service.UsagePartitionTask) *UsagePartitionTask {
+	if task == nil {
+		return nil
+	}
+	out := &UsagePartitionTask{
+		ID:            task.ID,
+		TaskType:      task.TaskType,
+		Status:        task.Status,
+		PartitionName: task.PartitionName,
+		ArchiveID:     task.ArchiveID,
+		TotalRows:     task.TotalRows,
+		ProcessedRows: task.ProcessedRows,
+		ErrorMessage:  task.ErrorMsg,
+		CreatedBy:     task.CreatedBy,
+		StartedAt:     task.StartedAt,
+		FinishedAt:    task.FinishedAt,
+		CreatedAt:     task.CreatedAt,
+		UpdatedAt:     task.UpdatedAt,
+	}
+	switch {
+	case task.Status == service.UsagePartitionTaskStatusSucceeded:
+		out.Progress = 1
+	case task.TotalRows > 0:
+		out.Progress = math.Min(float64(task.ProcessedRows)/float64(task.TotalRows), 1)
+	}
+	return out
+}
+
+func UsageLogArchiveFromService(archive *service.UsageLogArchive) *UsageLogArchive {
+	if archive == nil {
+		return nil
+	}
+	return &UsageLogArchive{
+		ID:            archive.ID,
+		PartitionName: archive.PartitionName,
+		RangeStart:    archive.RangeStart,
+		RangeEnd:      archive.RangeEnd,
+		RowCount:      archive.RowCount,
+		Storage:       archive.Storage,
+		FileSize:      archive.FileSize,
+		Checksum:      archive.Checksum,
+		Status:        archive.Status,
+		ArchivedAt:    archive.ArchivedAt,
+		RestoredAt:    archive.RestoredAt,
+		RestoredUntil: archive.RestoredUntil,
+	}
+}
+
+func UsagePartitionStatusFromService(status *service.UsagePartitionStatus) *UsagePartitionStatus {
+	if status == nil {
+		return nil
+	}
+	out := &UsagePartitionStatus{
+		Partitioned:     status.Partitioned,
+		LegacyExists:    status.LegacyExists,
+		Partitions:      make([]UsageLogPartition, 0, len(status.Partitions)),
+		ArchiveEnabled:  status.ArchiveEnabled,
+		RetentionCutoff: status.RetentionCutoff,
+	}
+	for _, p := range status.Partitions {
+		item := UsageLogPartition{
+			Name:          p.Name,
+			IsDefault:     p.IsDefault,
+			EstimatedRows: p.EstimatedRows,
+			SizeBytes:     p.SizeBytes,
+		}
+		if !p.Month.IsZero() {
+			month := p.Month
+			item.Month = &month
+		}
+		out.Partitions = append(out.Partitions, item)
+	}
+	return out
+}
+
 func SettingFromService(s *service.Setting) *Setting {
 	if s == nil {
 		return nil
@@ -14,6 +14,9 @@ type User struct {
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
+	// 成本归属标签键白名单（API Key 未单独配置时使用）
+	AllowedTagKeys []string `json:"allowed_tag_keys,omitempty"`
+
 	APIKeys       []APIKey           `json:"api_keys,omitempty"`
 	Subscriptions []UserSubscription `json:"subscriptions,omitempty"`
 }
@@ -24,6 +27,8 @@ type AdminUser struct {
 	User
 
 	Notes string `json:"notes"`
+	// AdminRole 管理员角色（仅 role=admin 时有意义，空表示超级管理员）
+	AdminRole string `json:"admin_role,omitempty"`
 	// GroupRates 用户专属分组倍率配置
 	// map[groupID]rateMultiplier
 	GroupRates map[int64]float64 `json:"group_rates,omitempty"`
@@ -44,10 +49,34 @@ type APIKey struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 
+	// 滚动消费上限（nil = 不限制）及当前窗口消费（仅配置了上限时返回）
+	DailyLimitUSD   *float64     `json:"daily_limit_usd"`
+	WeeklyLimitUSD  *float64     `json:"weekly_limit_usd"`
+	MonthlyLimitUSD *float64     `json:"monthly_limit_usd"`
+	Spend           *APIKeySpend `json:"spend,omitempty"`
+
+	// 轮换信息：旧密钥本身不返回，仅返回其失效时间（无有效旧密钥时为空）
+	KeyGeneration        int        `json:"key_generation"`
+	RotatedAt            *time.Time `json:"rotated_at,omitempty"`
+	PreviousKeyExpiresAt *time.Time `json:"previous_key_expires_at,omitempty"`
+
+	// 成本归属标签键白名单（为空时使用用户级配置）
+	AllowedTagKeys []string `json:"allowed_tag_keys,omitempty"`
+
+	// 所属组织（组织 Key 从组织资金池扣费）
+	OrganizationID *int64 `json:"organization_id,omitempty"`
+
 	User  *User  `json:"user,omitempty"`
 	Group *Group `json:"group,omitempty"`
 }
 
+// APIKeySpend API Key 当前日/周/月窗口内的消费（USD）
+type APIKeySpend struct {
+	DailyUsageUSD   float64 `json:"daily_usage_usd"`
+	WeeklyUsageUSD  float64 `json:"weekly_usage_usd"`
+	MonthlyUsageUSD float64 `json:"monthly_usage_usd"`
+}
+
 type Group struct {
 	ID             int64   `json:"id"`
 	Name           string  `json:"name"`
@@ -96,6 +125,16 @@ type AdminGroup struct {
 
 	// 分组排序
 	SortOrder int `json:"sort_order"`
+
+	// 账号选择策略
+	SelectionStrategy string `json:"selection_strategy"`
+
+	// 用户公平分配
+	FairShareEnabled bool `json:"fair_share_enabled"`
+
+	// 模型降级链
+	ModelFallback        map[string][]string `json:"model_fallback"`
+	ModelFallbackEnabled bool                `json:"model_fallback_enabled"`
 }
 
 type Account struct {
@@ -107,6 +146,7 @@ type Account struct {
 	Credentials        map[string]any `json:"credentials"`
 	Extra              map[string]any `json:"extra"`
 	ProxyID            *int64         `json:"proxy_id"`
+	ProxyPoolID        *int64         `json:"proxy_pool_id"`
 	Concurrency        int            `json:"concurrency"`
 	Priority           int            `json:"priority"`
 	RateMultiplier     float64        `json:"rate_multiplier"`
@@ -241,6 +281,9 @@ type UsageLog struct {
 	// ReasoningEffort is the request's reasoning effort level (OpenAI Responses API).
 	// nil means not provided / not applicable.
 	ReasoningEffort *string `json:"reasoning_effort,omitempty"`
+	// RequestedModel is the model originally requested by the client when a group
+	// model fallback served the request with a different model. nil means same as model.
+	RequestedModel *string `json:"requested_model,omitempty"`
 
 	GroupID        *int64 `json:"group_id"`
 	SubscriptionID *int64 `json:"subscription_id"`
@@ -270,6 +313,12 @@ type UsageLog struct {
 	ImageCount int     `json:"image_count"`
 	ImageSize  *string `json:"image_size"`
 
+	// 请求所用 API Key 密钥代数
+	KeyGeneration int `json:"key_generation"`
+
+	// 成本归属标签
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// User-Agent
 	UserAgent *string `json:"user_agent"`
 
@@ -322,6 +371,94 @@ type UsageCleanupTask struct {
 	UpdatedAt    time.Time           `json:"updated_at"`
 }
 
+type UsageExportFilters struct {
+	StartTime      *time.Time `json:"start_time,omitempty"`
+	EndTime        *time.Time `json:"end_time,omitempty"`
+	UserID         int64      `json:"user_id,omitempty"`
+	APIKeyID       int64      `json:"api_key_id,omitempty"`
+	OrganizationID int64      `json:"organization_id,omitempty"`
+	AccountID      int64      `json:"account_id,omitempty"`
+	GroupID        int64      `json:"group_id,omitempty"`
+	Model          string     `json:"model,omitempty"`
+	Stream         *bool      `json:"stream,omitempty"`
+	BillingType    *int8      `json:"billing_type,omitempty"`
+	TagKey         string     `json:"tag_key,omitempty"`
+	TagValue       string     `json:"tag_value,omitempty"`
+}
+
+// UsageExportTask 使用记录导出任务；DownloadURL 仅在文件可下载时返回
+type UsageExportTask struct {
+	ID           int64              `json:"id"`
+	Scope        string             `json:"scope"`
+	Format       string             `json:"format"`
+	Status       string             `json:"status"`
+	Filters      UsageExportFilters `json:"filters"`
+	CreatedBy    int64              `json:"created_by"`
+	TotalRows    int64              `json:"total_rows"`
+	ExportedRows int64              `json:"exported_rows"`
+	Progress     float64            `json:"progress"`
+	FileSize     int64              `json:"file_size"`
+	DownloadURL  string             `json:"download_url,omitempty"`
+	ErrorMessage *string            `json:"error_message,omitempty"`
+	CanceledBy   *int64             `json:"canceled_by,omitempty"`
+	CanceledAt   *time.Time         `json:"canceled_at,omitempty"`
+	StartedAt    *time.Time         `json:"started_at,omitempty"`
+	FinishedAt   *time.Time         `json:"finished_at,omitempty"`
+	ExpiresAt    *time.Time         `json:"expires_at,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// UsagePartitionTask usage_logs 分区维护任务
+type UsagePartitionTask struct {
+	ID            int64      `json:"id"`
+	TaskType      string     `json:"task_type"`
+	Status        string     `json:"status"`
+	PartitionName string     `json:"partition_name,omitempty"`
+	ArchiveID     *int64     `json:"archive_id,omitempty"`
+	TotalRows     int64      `json:"total_rows"`
+	ProcessedRows int64      `json:"processed_rows"`
+	Progress      float64    `json:"progress"`
+	ErrorMessage  *string    `json:"error_message,omitempty"`
+	CreatedBy     *int64     `json:"created_by,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// UsageLogArchive 已归档的 usage_logs 月分区；不返回存储位置
+type UsageLogArchive struct {
+	ID            int64      `json:"id"`
+	PartitionName string     `json:"partition_name"`
+	RangeStart    time.Time  `json:"range_start"`
+	RangeEnd      time.Time  `json:"range_end"`
+	RowCount      int64      `json:"row_count"`
+	Storage       string     `json:"storage"`
+	FileSize      int64      `json:"file_size"`
+	Checksum      string     `json:"checksum"`
+	Status        string     `json:"status"`
+	ArchivedAt    time.Time  `json:"archived_at"`
+	RestoredAt    *time.Time `json:"restored_at,omitempty"`
+	RestoredUntil *time.Time `json:"restored_until,omitempty"`
+}
+
+type UsageLogPartition struct {
+	Name          string     `json:"name"`
+	Month         *time.Time `json:"month,omitempty"`
+	IsDefault     bool       `json:"is_default"`
+	EstimatedRows int64      `json:"estimated_rows"`
+	SizeBytes     int64      `json:"size_bytes"`
+}
+
+type UsagePartitionStatus struct {
+	Partitioned     bool                `json:"partitioned"`
+	LegacyExists    bool                `json:"legacy_exists"`
+	Partitions      []UsageLogPartition `json:"partitions"`
+	ArchiveEnabled  bool                `json:"archive_enabled"`
+	RetentionCutoff *time.Time          `json:"retention_cutoff,omitempty"`
+}
+
 // AccountSummary is a minimal account info for usage log display.
 // It intentionally excludes sensitive fields like Credentials, Proxy, etc.
 type AccountSummary struct {
@@ -145,6 +145,13 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		return
 	}
 
+	// 成本归属标签：请求头优先，metadata.user_id 形如 key=value 列表时作为补充
+	usageTags, err := service.ResolveUsageTags(apiKey, c.GetHeader(service.UsageTagsHeader), parsedReq.MetadataUserID)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", pkgerrors.Message(err))
+		return
+	}
+
 	// Track if we've started streaming (for error handling)
 	streamStarted := false
 
@@ -231,8 +238,20 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	// 判断是否真的绑定了粘性会话：有 sessionKey 且已经绑定到某个账号
 	hasBoundSession := sessionKey != "" && sessionBoundAccountID > 0
 
+	// 分组模型降级链：当前模型无可用账号或 failover 耗尽时切换到下一个降级模型重试
+	modelFallback := newModelFallbackState(apiKey.Group, reqModel)
+	switchToFallbackModel := func() bool {
+		next, ok := modelFallback.Next()
+		if !ok {
+			return false
+		}
+		log.Printf("[ModelFallback] group=%d model=%s -> %s", derefGroupID(apiKey.GroupID), reqModel, next)
+		h.gatewayService.ApplyModelFallback(parsedReq, next)
+		reqModel, body = parsedReq.Model, parsedReq.Body
+		return true
+	}
+
 	if platform == service.PlatformGemini {
-		fs := NewFailoverState(h.maxAccountSwitchesGemini, hasBoundSession)
 
 		// 单账号分组提前设置 SingleAccountRetry 标记，让 Service 层首次 503 就不设模型限流标记。
 		// 避免单账号分组收到 503 (MODEL_CAPACITY_EXHAUSTED) 时设 29s 限流，导致后续请求连续快速失败。
@@ -242,155 +261,186 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		}
 
 		for {
-			selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, reqModel, fs.FailedAccountIDs, "") // Gemini 不使用会话限制
-			if err != nil {
-				if len(fs.FailedAccountIDs) == 0 {
-					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
-					return
+			fs := NewFailoverState(h.maxAccountSwitchesGemini, hasBoundSession)
+			retryWithFallback := false
+
+			for {
+				selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, reqModel, fs.FailedAccountIDs, "") // Gemini 不使用会话限制
+				if err != nil {
+					if len(fs.FailedAccountIDs) == 0 {
+						if switchToFallbackModel() {
+							retryWithFallback = true
+							break
+						}
+						h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
+						return
+					}
+					action := fs.HandleSelectionExhausted(c.Request.Context())
+					if action == FailoverExhausted && switchToFallbackModel() {
+						retryWithFallback = true
+						break
+					}
+					switch action {
+					case FailoverContinue:
+						ctx := context.WithValue(c.Request.Context(), ctxkey.SingleAccountRetry, true)
+						c.Request = c.Request.WithContext(ctx)
+						continue
+					case FailoverCanceled:
+						return
+					default: // FailoverExhausted
+						if fs.LastFailoverErr != nil {
+							h.handleFailoverExhausted(c, fs.LastFailoverErr, service.PlatformGemini, streamStarted)
+						} else {
+							h.handleFailoverExhaustedSimple(c, 502, streamStarted)
+						}
+						return
+					}
 				}
-				action := fs.HandleSelectionExhausted(c.Request.Context())
-				switch action {
-				case FailoverContinue:
-					ctx := context.WithValue(c.Request.Context(), ctxkey.SingleAccountRetry, true)
-					c.Request = c.Request.WithContext(ctx)
-					continue
-				case FailoverCanceled:
-					return
-				default: // FailoverExhausted
-					if fs.LastFailoverErr != nil {
-						h.handleFailoverExhausted(c, fs.LastFailoverErr, service.PlatformGemini, streamStarted)
-					} else {
-						h.handleFailoverExhaustedSimple(c, 502, streamStarted)
+				account := selection.Account
+				setOpsSelectedAccount(c, account.ID)
+
+				// 检查请求拦截（预热请求、SUGGESTION MODE等）
+				if account.IsInterceptWarmupEnabled() {
+					interceptType := detectInterceptType(body, reqModel, parsedReq.MaxTokens, reqStream, isClaudeCodeClient)
+					if interceptType != InterceptTypeNone {
+						if selection.Acquired && selection.ReleaseFunc != nil {
+							selection.ReleaseFunc()
+						}
+						if reqStream {
+							sendMockInterceptStream(c, reqModel, interceptType)
+						} else {
+							sendMockInterceptResponse(c, reqModel, interceptType)
+						}
+						return
 					}
-					return
 				}
-			}
-			account := selection.Account
-			setOpsSelectedAccount(c, account.ID)
 
-			// 检查请求拦截（预热请求、SUGGESTION MODE等）
-			if account.IsInterceptWarmupEnabled() {
-				interceptType := detectInterceptType(body, reqModel, parsedReq.MaxTokens, reqStream, isClaudeCodeClient)
-				if interceptType != InterceptTypeNone {
-					if selection.Acquired && selection.ReleaseFunc != nil {
-						selection.ReleaseFunc()
+				// 3. 获取账号并发槽位
+				accountReleaseFunc := selection.ReleaseFunc
+				if !selection.Acquired {
+					if selection.WaitPlan == nil {
+						h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts", streamStarted)
+						return
 					}
-					if reqStream {
-						sendMockInterceptStream(c, reqModel, interceptType)
-					} else {
-						sendMockInterceptResponse(c, reqModel, interceptType)
+					accountWaitCounted := false
+					canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
+					if err != nil {
+						log.Printf("Increment account wait count failed: %v", err)
+					} else if !canWait {
+						log.Printf("Account wait queue full: account=%d", account.ID)
+						h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
+						return
+					}
+					if err == nil && canWait {
+						accountWaitCounted = true
+					}
+					// Ensure the wait counter is decremented if we exit before acquiring the slot.
+					defer func() {
+						if accountWaitCounted {
+							h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+						}
+					}()
+
+					accountReleaseFunc, err = h.concurrencyHelper.AcquireAccountSlotWithWaitTimeout(
+						c,
+						account.ID,
+						selection.WaitPlan.MaxConcurrency,
+						selection.WaitPlan.Timeout,
+						reqStream,
+						&streamStarted,
+					)
+					if err != nil {
+						log.Printf("Account concurrency acquire failed: %v", err)
+						h.handleConcurrencyError(c, err, "account", streamStarted)
+						return
+					}
+					// Slot acquired: no longer waiting in queue.
+					if accountWaitCounted {
+						h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+						accountWaitCounted = false
+					}
+					if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
+						log.Printf("Bind sticky session failed: %v", err)
 					}
-					return
 				}
-			}
-
-			// 3. 获取账号并发槽位
-			accountReleaseFunc := selection.ReleaseFunc
-			if !selection.Acquired {
-				if selection.WaitPlan == nil {
-					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts", streamStarted)
-					return
+				// 账号槽位/等待计数需要在超时或断开时安全回收
+				accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
+
+				// 转发请求 - 根据账号平台分流
+				var result *service.ForwardResult
+				requestCtx := c.Request.Context()
+				if fs.SwitchCount > 0 {
+					requestCtx = context.WithValue(requestCtx, ctxkey.AccountSwitchCount, fs.SwitchCount)
 				}
-				accountWaitCounted := false
-				canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
-				if err != nil {
-					log.Printf("Increment account wait count failed: %v", err)
-				} else if !canWait {
-					log.Printf("Account wait queue full: account=%d", account.ID)
-					h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
-					return
+				c.Header(servedModelHeader, reqModel)
+				if account.Platform == service.PlatformAntigravity {
+					result, err = h.antigravityGatewayService.ForwardGemini(requestCtx, c, account, reqModel, "generateContent", reqStream, body, hasBoundSession)
+				} else {
+					result, err = h.geminiCompatService.Forward(requestCtx, c, account, body)
 				}
-				if err == nil && canWait {
-					accountWaitCounted = true
+				if accountReleaseFunc != nil {
+					accountReleaseFunc()
 				}
-				// Ensure the wait counter is decremented if we exit before acquiring the slot.
-				defer func() {
-					if accountWaitCounted {
-						h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
-					}
-				}()
-
-				accountReleaseFunc, err = h.concurrencyHelper.AcquireAccountSlotWithWaitTimeout(
-					c,
-					account.ID,
-					selection.WaitPlan.MaxConcurrency,
-					selection.WaitPlan.Timeout,
-					reqStream,
-					&streamStarted,
-				)
 				if err != nil {
-					log.Printf("Account concurrency acquire failed: %v", err)
-					h.handleConcurrencyError(c, err, "account", streamStarted)
+					var failoverErr *service.UpstreamFailoverError
+					if errors.As(err, &failoverErr) {
+						h.gatewayService.RecordAccountFailure(c.Request.Context(), account.ID)
+						action := fs.HandleFailoverError(c.Request.Context(), h.gatewayService, account.ID, account.Platform, failoverErr)
+						if action == FailoverExhausted && switchToFallbackModel() {
+							retryWithFallback = true
+							break
+						}
+						switch action {
+						case FailoverContinue:
+							continue
+						case FailoverExhausted:
+							h.handleFailoverExhausted(c, fs.LastFailoverErr, service.PlatformGemini, streamStarted)
+							return
+						case FailoverCanceled:
+							return
+						}
+					}
+					// 错误响应已在Forward中处理，这里只记录日志
+					log.Printf("Forward request failed: %v", err)
 					return
 				}
-				// Slot acquired: no longer waiting in queue.
-				if accountWaitCounted {
-					h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
-					accountWaitCounted = false
+
+				if modelFallback.Active() {
+					result.RequestedModel = modelFallback.requestedModel
 				}
-				if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
-					log.Printf("Bind sticky session failed: %v", err)
+
+				if requestID, ok := idempotencyRequestID(c); ok {
+					result.RequestID = requestID
 				}
-			}
-			// 账号槽位/等待计数需要在超时或断开时安全回收
-			accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
 
-			// 转发请求 - 根据账号平台分流
-			var result *service.ForwardResult
-			requestCtx := c.Request.Context()
-			if fs.SwitchCount > 0 {
-				requestCtx = context.WithValue(requestCtx, ctxkey.AccountSwitchCount, fs.SwitchCount)
-			}
-			if account.Platform == service.PlatformAntigravity {
-				result, err = h.antigravityGatewayService.ForwardGemini(requestCtx, c, account, reqModel, "generateContent", reqStream, body, hasBoundSession)
-			} else {
-				result, err = h.geminiCompatService.Forward(requestCtx, c, account, body)
-			}
-			if accountReleaseFunc != nil {
-				accountReleaseFunc()
-			}
-			if err != nil {
-				var failoverErr *service.UpstreamFailoverError
-				if errors.As(err, &failoverErr) {
-					action := fs.HandleFailoverError(c.Request.Context(), h.gatewayService, account.ID, account.Platform, failoverErr)
-					switch action {
-					case FailoverContinue:
-						continue
-					case FailoverExhausted:
-						h.handleFailoverExhausted(c, fs.LastFailoverErr, service.PlatformGemini, streamStarted)
-						return
-					case FailoverCanceled:
-						return
+				// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
+				userAgent := c.GetHeader("User-Agent")
+				clientIP := ip.GetClientIP(c)
+
+				// 异步记录使用量（subscription已在函数开头获取）
+				go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string, fcb bool) {
+					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+					defer cancel()
+					if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
+						Result:            result,
+						APIKey:            apiKey,
+						User:              apiKey.User,
+						Account:           usedAccount,
+						Subscription:      subscription,
+						UserAgent:         ua,
+						IPAddress:         clientIP,
+						ForceCacheBilling: fcb,
+						APIKeyService:     h.apiKeyService,
+						Tags:              usageTags,
+					}); err != nil {
+						log.Printf("Record usage failed: %v", err)
 					}
-				}
-				// 错误响应已在Forward中处理，这里只记录日志
-				log.Printf("Forward request failed: %v", err)
+				}(result, account, userAgent, clientIP, fs.ForceCacheBilling)
+				return
+			}
+			if !retryWithFallback {
 				return
 			}
-
-			// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
-			userAgent := c.GetHeader("User-Agent")
-			clientIP := ip.GetClientIP(c)
-
-			// 异步记录使用量（subscription已在函数开头获取）
-			go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string, fcb bool) {
-				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-				defer cancel()
-				if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
-					Result:            result,
-					APIKey:            apiKey,
-					User:              apiKey.User,
-					Account:           usedAccount,
-					Subscription:      subscription,
-					UserAgent:         ua,
-					IPAddress:         clientIP,
-					ForceCacheBilling: fcb,
-					APIKeyService:     h.apiKeyService,
-				}); err != nil {
-					log.Printf("Record usage failed: %v", err)
-				}
-			}(result, account, userAgent, clientIP, fs.ForceCacheBilling)
-			return
 		}
 	}
 
@@ -418,10 +468,18 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), currentAPIKey.GroupID, sessionKey, reqModel, fs.FailedAccountIDs, parsedReq.MetadataUserID)
 			if err != nil {
 				if len(fs.FailedAccountIDs) == 0 {
+					if switchToFallbackModel() {
+						retryWithFallback = true
+						break
+					}
 					h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 					return
 				}
 				action := fs.HandleSelectionExhausted(c.Request.Context())
+				if action == FailoverExhausted && switchToFallbackModel() {
+					retryWithFallback = true
+					break
+				}
 				switch action {
 				case FailoverContinue:
 					ctx := context.WithValue(c.Request.Context(), ctxkey.SingleAccountRetry, true)
@@ -512,6 +570,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			if fs.SwitchCount > 0 {
 				requestCtx = context.WithValue(requestCtx, ctxkey.AccountSwitchCount, fs.SwitchCount)
 			}
+			c.Header(servedModelHeader, reqModel)
 			if account.Platform == service.PlatformAntigravity && account.Type != service.AccountTypeAPIKey {
 				result, err = h.antigravityGatewayService.Forward(requestCtx, c, account, body, hasBoundSession)
 			} else {
@@ -558,7 +617,12 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				}
 				var failoverErr *service.UpstreamFailoverError
 				if errors.As(err, &failoverErr) {
+					h.gatewayService.RecordAccountFailure(c.Request.Context(), account.ID)
 					action := fs.HandleFailoverError(c.Request.Context(), h.gatewayService, account.ID, account.Platform, failoverErr)
+					if action == FailoverExhausted && switchToFallbackModel() {
+						retryWithFallback = true
+						break
+					}
 					switch action {
 					case FailoverContinue:
 						continue
@@ -574,6 +638,14 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				return
 			}
 
+			if modelFallback.Active() {
+				result.RequestedModel = modelFallback.requestedModel
+			}
+
+			if requestID, ok := idempotencyRequestID(c); ok {
+				result.RequestID = requestID
+			}
+
 			// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 			userAgent := c.GetHeader("User-Agent")
 			clientIP := ip.GetClientIP(c)
@@ -592,6 +664,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					IPAddress:         clientIP,
 					ForceCacheBilling: fcb,
 					APIKeyService:     h.apiKeyService,
+					Tags:              usageTags,
 				}); err != nil {
 					log.Printf("Record usage failed: %v", err)
 				}
@@ -723,6 +796,21 @@ func (h *GatewayHandler) Usage(c *gin.Context) {
 		}
 	}
 
+	// API Key 日/周/月消费上限及当前窗口消费（仅配置了上限时返回）
+	var keyLimits gin.H
+	if apiKey.HasSpendLimits() {
+		keyLimits = gin.H{
+			"daily_limit_usd":   apiKey.DailyLimitUSD,
+			"weekly_limit_usd":  apiKey.WeeklyLimitUSD,
+			"monthly_limit_usd": apiKey.MonthlyLimitUSD,
+		}
+		if spend, err := h.billingCacheService.GetAPIKeySpend(c.Request.Context(), apiKey.ID); err == nil {
+			keyLimits["daily_usage_usd"] = spend.Daily
+			keyLimits["weekly_usage_usd"] = spend.Weekly
+			keyLimits["monthly_usage_usd"] = spend.Monthly
+		}
+	}
+
 	// 订阅模式：返回订阅限额信息 + 用量统计
 	if apiKey.Group != nil && apiKey.Group.IsSubscriptionType() {
 		subscription, ok := middleware2.GetSubscriptionFromContext(c)
@@ -750,6 +838,9 @@ func (h *GatewayHandler) Usage(c *gin.Context) {
 		if usageData != nil {
 			resp["usage"] = usageData
 		}
+		if keyLimits != nil {
+			resp["api_key_limits"] = keyLimits
+		}
 		c.JSON(http.StatusOK, resp)
 		return
 	}
@@ -771,6 +862,9 @@ func (h *GatewayHandler) Usage(c *gin.Context) {
 	if usageData != nil {
 		resp["usage"] = usageData
 	}
+	if keyLimits != nil {
+		resp["api_key_limits"] = keyLimits
+	}
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -1273,6 +1367,12 @@ func billingErrorDetails(err error) (status int, code, message string) {
 		}
 		return http.StatusServiceUnavailable, "billing_service_error", msg
 	}
+	if service.IsAPIKeySpendLimitError(err) {
+		return http.StatusTooManyRequests, "api_key_limit_exceeded", pkgerrors.Message(err)
+	}
+	if errors.Is(err, service.ErrOrganizationMemberSpendLimitExceeded) {
+		return http.StatusTooManyRequests, "organization_member_limit_exceeded", pkgerrors.Message(err)
+	}
 	msg := pkgerrors.Message(err)
 	if msg == "" {
 		msg = err.Error()
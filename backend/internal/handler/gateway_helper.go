@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +18,13 @@ import (
 // claudeCodeValidator is a singleton validator for Claude Code client detection
 var claudeCodeValidator = service.NewClaudeCodeValidator()
 
+// idempotencyRequestID 返回 Idempotency-Key 中间件为本次请求分配的使用记录 request_id。
+// 使用记录以该值代替上游 request_id 写入，同一幂等键的重试不会重复计费。
+func idempotencyRequestID(c *gin.Context) (string, bool) {
+	requestID, ok := c.Request.Context().Value(ctxkey.IdempotencyRequestID).(string)
+	return requestID, ok && requestID != ""
+}
+
 // SetClaudeCodeClientContext 检查请求是否来自 Claude Code 客户端，并设置到 context 中
 // 返回更新后的 context
 func SetClaudeCodeClientContext(c *gin.Context, body []byte) {
@@ -17,6 +17,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/domain"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/antigravity"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/gemini"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/googleapi"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
@@ -176,6 +177,13 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 
 	setOpsRequestContext(c, modelName, stream, body)
 
+	// 成本归属标签（Gemini 原生协议仅支持请求头）
+	usageTags, err := service.ResolveUsageTags(apiKey, c.GetHeader(service.UsageTagsHeader), "")
+	if err != nil {
+		googleError(c, http.StatusBadRequest, infraerrors.Message(err))
+		return
+	}
+
 	// Get subscription (may be nil)
 	subscription, _ := middleware.GetSubscriptionFromContext(c)
 
@@ -323,6 +331,20 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 
 	fs := NewFailoverState(h.maxAccountSwitchesGemini, hasBoundSession)
 
+	// 分组模型降级链：当前模型无可用账号或 failover 耗尽时切换到下一个降级模型重试。
+	// Gemini 原生接口的模型位于 URL 路径中，切换时只需替换 modelName 并重置 failover 状态。
+	modelFallback := newModelFallbackState(apiKey.Group, modelName)
+	switchToFallbackModel := func() bool {
+		next, ok := modelFallback.Next()
+		if !ok {
+			return false
+		}
+		log.Printf("[ModelFallback] group=%d model=%s -> %s", derefGroupID(apiKey.GroupID), modelName, next)
+		modelName = next
+		fs = NewFailoverState(h.maxAccountSwitchesGemini, hasBoundSession)
+		return true
+	}
+
 	// 单账号分组提前设置 SingleAccountRetry 标记，让 Service 层首次 503 就不设模型限流标记。
 	// 避免单账号分组收到 503 (MODEL_CAPACITY_EXHAUSTED) 时设 29s 限流，导致后续请求连续快速失败。
 	if h.gatewayService.IsSingleAntigravityAccountGroup(c.Request.Context(), apiKey.GroupID) {
@@ -334,10 +356,16 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, modelName, fs.FailedAccountIDs, "") // Gemini 不使用会话限制
 		if err != nil {
 			if len(fs.FailedAccountIDs) == 0 {
+				if switchToFallbackModel() {
+					continue
+				}
 				googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts: "+err.Error())
 				return
 			}
 			action := fs.HandleSelectionExhausted(c.Request.Context())
+			if action == FailoverExhausted && switchToFallbackModel() {
+				continue
+			}
 			switch action {
 			case FailoverContinue:
 				ctx := context.WithValue(c.Request.Context(), ctxkey.SingleAccountRetry, true)
@@ -425,6 +453,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		if fs.SwitchCount > 0 {
 			requestCtx = context.WithValue(requestCtx, ctxkey.AccountSwitchCount, fs.SwitchCount)
 		}
+		c.Header(servedModelHeader, modelName)
 		if account.Platform == service.PlatformAntigravity && account.Type != service.AccountTypeAPIKey {
 			result, err = h.antigravityGatewayService.ForwardGemini(requestCtx, c, account, modelName, action, stream, body, hasBoundSession)
 		} else {
@@ -436,7 +465,11 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		if err != nil {
 			var failoverErr *service.UpstreamFailoverError
 			if errors.As(err, &failoverErr) {
+				h.gatewayService.RecordAccountFailure(c.Request.Context(), account.ID)
 				action := fs.HandleFailoverError(c.Request.Context(), h.gatewayService, account.ID, account.Platform, failoverErr)
+				if action == FailoverExhausted && switchToFallbackModel() {
+					continue
+				}
 				switch action {
 				case FailoverContinue:
 					continue
@@ -471,6 +504,14 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			}
 		}
 
+		if modelFallback.Active() {
+			result.RequestedModel = modelFallback.requestedModel
+		}
+
+		if requestID, ok := idempotencyRequestID(c); ok {
+			result.RequestID = requestID
+		}
+
 		// 6) record usage async (Gemini 使用长上下文双倍计费)
 		go func(result *service.ForwardResult, usedAccount *service.Account, ua, ip string, fcb bool) {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -488,6 +529,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				LongContextMultiplier: 2.0,    // 超出部分双倍计费
 				ForceCacheBilling:     fcb,
 				APIKeyService:         h.apiKeyService,
+				Tags:                  usageTags,
 			}); err != nil {
 				log.Printf("Record usage failed: %v", err)
 			}
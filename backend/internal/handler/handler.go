@@ -25,6 +25,23 @@ type AdminHandlers struct {
 	Usage            *admin.UsageHandler
 	UserAttribute    *admin.UserAttributeHandler
 	ErrorPassthrough *admin.ErrorPassthroughHandler
+	AccountProbe     *admin.AccountProbeHandler
+	Scheduling       *admin.SchedulingHandler
+	ProxyPool        *admin.ProxyPoolHandler
+	ProxyMonitor     *admin.ProxyMonitorHandler
+	ProxyImport      *admin.ProxyImportHandler
+	AdminRole        *admin.AdminRoleHandler
+	AdminAPIKey      *admin.AdminAPIKeyHandler
+	AdminAudit       *admin.AdminAuditHandler
+	OAuthProvider    *admin.OAuthProviderHandler
+	PasskeySettings  *admin.PasskeySettingsHandler
+	LoginSecurity    *admin.LoginSecurityHandler
+	Organization     *admin.OrganizationHandler
+	Referral         *admin.ReferralHandler
+	Statement        *admin.StatementHandler
+	UsageExport      *admin.UsageExportHandler
+	UsagePartition   *admin.UsagePartitionHandler
+	UsageWrite       *admin.UsageWriteHandler
 }
 
 // Handlers contains all HTTP handlers
@@ -41,6 +58,13 @@ type Handlers struct {
 	OpenAIGateway *OpenAIGatewayHandler
 	Setting       *SettingHandler
 	Totp          *TotpHandler
+	OAuth         *OAuthHandler
+	Passkey       *PasskeyHandler
+	Organization  *OrganizationHandler
+	Referral      *ReferralHandler
+	Statement     *StatementHandler
+	Notification  *UserNotificationHandler
+	UsageExport   *UsageExportHandler
 }
 
 // BuildInfo contains build-time information
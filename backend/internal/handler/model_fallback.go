@@ -0,0 +1,41 @@
+package handler
+
+import "github.com/Wei-Shaw/sub2api/internal/service"
+
+// servedModelHeader 响应头：实际服务本次请求的模型（分组模型降级链生效时与请求模型不同）
+const servedModelHeader = "X-Served-Model"
+
+// modelFallbackState 跟踪分组模型降级链的推进。
+// 当前模型在分组内无可用账号或 failover 耗尽时，按顺序切换到下一个降级模型。
+type modelFallbackState struct {
+	requestedModel string
+	chain          []string
+	next           int
+	active         bool
+}
+
+func newModelFallbackState(group *service.Group, requestedModel string) *modelFallbackState {
+	state := &modelFallbackState{requestedModel: requestedModel}
+	if group != nil {
+		state.chain = group.GetModelFallbackChain(requestedModel)
+	}
+	return state
+}
+
+// Next 返回降级链中的下一个模型；链已用尽时返回 false
+func (s *modelFallbackState) Next() (string, bool) {
+	for s.next < len(s.chain) {
+		model := s.chain[s.next]
+		s.next++
+		if model != "" && model != s.requestedModel {
+			s.active = true
+			return model, true
+		}
+	}
+	return "", false
+}
+
+// Active 是否已切换到降级模型
+func (s *modelFallbackState) Active() bool {
+	return s.active
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelFallbackState_Next(t *testing.T) {
+	group := &service.Group{
+		ModelFallbackEnabled: true,
+		ModelFallback: map[string][]string{
+			"claude-opus-*": {"claude-opus-4-20250514", "claude-sonnet-4-5-20250929", "claude-haiku-4-5-20251001"},
+		},
+	}
+	state := newModelFallbackState(group, "claude-opus-4-20250514")
+	require.False(t, state.Active())
+
+	// 与请求模型相同的条目被跳过
+	next, ok := state.Next()
+	require.True(t, ok)
+	require.Equal(t, "claude-sonnet-4-5-20250929", next)
+	require.True(t, state.Active())
+
+	next, ok = state.Next()
+	require.True(t, ok)
+	require.Equal(t, "claude-haiku-4-5-20251001", next)
+
+	_, ok = state.Next()
+	require.False(t, ok)
+}
+
+func TestModelFallbackState_NoChain(t *testing.T) {
+	_, ok := newModelFallbackState(nil, "claude-opus-4-20250514").Next()
+	require.False(t, ok)
+
+	disabled := &service.Group{ModelFallback: map[string][]string{"claude-opus-*": {"claude-haiku-4-5-20251001"}}}
+	state := newModelFallbackState(disabled, "claude-opus-4-20250514")
+	_, ok = state.Next()
+	require.False(t, ok)
+	require.False(t, state.Active())
+}
@@ -12,12 +12,14 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/sjson"
 )
 
 // OpenAIGatewayHandler handles OpenAI API gateway requests
@@ -108,6 +110,14 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		return
 	}
 
+	// 成本归属标签：请求头优先，user 字段形如 key=value 列表时作为补充
+	reqUser, _ := reqBody["user"].(string)
+	usageTags, err := service.ResolveUsageTags(apiKey, c.GetHeader(service.UsageTagsHeader), reqUser)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", infraerrors.Message(err))
+		return
+	}
+
 	userAgent := c.GetHeader("User-Agent")
 	if !openai.IsCodexCLIRequest(userAgent) {
 		existingInstructions, _ := reqBody["instructions"].(string)
@@ -211,12 +221,35 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	failedAccountIDs := make(map[int64]struct{})
 	var lastFailoverErr *service.UpstreamFailoverError
 
+	// 分组模型降级链：当前模型无可用账号或 failover 耗尽时切换到下一个降级模型重试
+	modelFallback := newModelFallbackState(apiKey.Group, reqModel)
+	switchToFallbackModel := func() bool {
+		next, ok := modelFallback.Next()
+		if !ok {
+			return false
+		}
+		newBody, err := sjson.SetBytes(body, "model", next)
+		if err != nil {
+			log.Printf("[ModelFallback] rewrite model failed: %v", err)
+			return false
+		}
+		log.Printf("[ModelFallback] group=%d model=%s -> %s", derefGroupID(apiKey.GroupID), reqModel, next)
+		reqModel, body = next, newBody
+		switchCount = 0
+		failedAccountIDs = make(map[int64]struct{})
+		lastFailoverErr = nil
+		return true
+	}
+
 	for {
 		// Select account supporting the requested model
 		log.Printf("[OpenAI Handler] Selecting account: groupID=%v model=%s", apiKey.GroupID, reqModel)
 		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionHash, reqModel, failedAccountIDs)
 		if err != nil {
 			log.Printf("[OpenAI Handler] SelectAccount failed: %v", err)
+			if switchToFallbackModel() {
+				continue
+			}
 			if len(failedAccountIDs) == 0 {
 				h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 				return
@@ -282,6 +315,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
 
 		// Forward request
+		c.Header(servedModelHeader, reqModel)
 		result, err := h.gatewayService.Forward(c.Request.Context(), c, account, body)
 		if accountReleaseFunc != nil {
 			accountReleaseFunc()
@@ -289,9 +323,13 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		if err != nil {
 			var failoverErr *service.UpstreamFailoverError
 			if errors.As(err, &failoverErr) {
+				h.gatewayService.RecordAccountFailure(c.Request.Context(), account.ID)
 				failedAccountIDs[account.ID] = struct{}{}
 				lastFailoverErr = failoverErr
 				if switchCount >= maxAccountSwitches {
+					if switchToFallbackModel() {
+						continue
+					}
 					h.handleFailoverExhausted(c, failoverErr, streamStarted)
 					return
 				}
@@ -304,6 +342,14 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 			return
 		}
 
+		if modelFallback.Active() {
+			result.RequestedModel = modelFallback.requestedModel
+		}
+
+		if requestID, ok := idempotencyRequestID(c); ok {
+			result.RequestID = requestID
+		}
+
 		// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
@@ -321,6 +367,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				UserAgent:     ua,
 				IPAddress:     ip,
 				APIKeyService: h.apiKeyService,
+				Tags:          usageTags,
 			}); err != nil {
 				log.Printf("Record usage failed: %v", err)
 			}
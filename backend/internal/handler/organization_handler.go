@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler handles organization (team) operations for the current user
+type OrganizationHandler struct {
+	organizationService *service.OrganizationService
+}
+
+// NewOrganizationHandler creates a new OrganizationHandler
+func NewOrganizationHandler(organizationService *service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{organizationService: organizationService}
+}
+
+// CreateOrganizationRequest represents the create organization request payload
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// RenameOrganizationRequest represents the rename organization request payload
+type RenameOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// AddOrganizationMemberRequest represents the add member request payload
+type AddOrganizationMemberRequest struct {
+	Email         string   `json:"email" binding:"required,email"`
+	Role          string   `json:"role" binding:"omitempty,oneof=admin member"`
+	SpendLimitUSD *float64 `json:"spend_limit_usd"`
+}
+
+// UpdateOrganizationMemberRequest represents the update member request payload
+type UpdateOrganizationMemberRequest struct {
+	Role            *string  `json:"role" binding:"omitempty,oneof=admin member"`
+	SpendLimitUSD   *float64 `json:"spend_limit_usd"`
+	ClearSpendLimit bool     `json:"clear_spend_limit"`
+}
+
+// OrganizationDepositRequest represents the deposit request payload
+type OrganizationDepositRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+func parseOrganizationID(c *gin.Context) (int64, bool) {
+	orgID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid organization ID")
+		return 0, false
+	}
+	return orgID, true
+}
+
+// List handles listing organizations the current user belongs to
+// GET /api/v1/organizations
+func (h *OrganizationHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	orgs, err := h.organizationService.ListForUser(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, orgs)
+}
+
+// Create handles creating an organization owned by the current user
+// POST /api/v1/organizations
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	org, err := h.organizationService.Create(c.Request.Context(), subject.UserID, service.CreateOrganizationRequest{Name: req.Name})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, org)
+}
+
+// Get handles getting an organization the current user belongs to
+// GET /api/v1/organizations/:id
+func (h *OrganizationHandler) Get(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	org, err := h.organizationService.Get(c.Request.Context(), orgID, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, org)
+}
+
+// Rename handles renaming an organization (owner / admin)
+// PUT /api/v1/organizations/:id
+func (h *OrganizationHandler) Rename(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req RenameOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	org, err := h.organizationService.Rename(c.Request.Context(), orgID, subject.UserID, req.Name)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, org)
+}
+
+// ListMembers handles listing organization members
+// GET /api/v1/organizations/:id/members
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.organizationService.ListMembers(c.Request.Context(), orgID, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, members)
+}
+
+// AddMember handles adding a registered user to the organization
+// POST /api/v1/organizations/:id/members
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req AddOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	member, err := h.organizationService.AddMember(c.Request.Context(), orgID, subject.UserID, service.AddOrganizationMemberRequest{
+		Email:         req.Email,
+		Role:          req.Role,
+		SpendLimitUSD: req.SpendLimitUSD,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, member)
+}
+
+// UpdateMember handles updating a member's role or spend limit
+// PUT /api/v1/organizations/:id/members/:user_id
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req UpdateOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	member, err := h.organizationService.UpdateMember(c.Request.Context(), orgID, subject.UserID, memberID, service.UpdateOrganizationMemberRequest{
+		Role:            req.Role,
+		SpendLimitUSD:   req.SpendLimitUSD,
+		ClearSpendLimit: req.ClearSpendLimit,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, member)
+}
+
+// RemoveMember handles removing a member, or leaving when user_id is the current user
+// DELETE /api/v1/organizations/:id/members/:user_id
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.organizationService.RemoveMember(c.Request.Context(), orgID, subject.UserID, memberID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Member removed successfully"})
+}
+
+// Deposit handles transferring personal balance into the organization pool
+// POST /api/v1/organizations/:id/deposit
+func (h *OrganizationHandler) Deposit(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	var req OrganizationDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	org, err := h.organizationService.Deposit(c.Request.Context(), orgID, subject.UserID, req.Amount)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, org)
+}
+
+// Usage handles organization usage stats
+// Owners and admins see the whole organization; members see their own usage.
+// GET /api/v1/organizations/:id/usage
+func (h *OrganizationHandler) Usage(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	startTime, endTime := parseUserTimeRange(c)
+
+	stats, err := h.organizationService.GetUsageStats(c.Request.Context(), orgID, subject.UserID, startTime, endTime)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, stats)
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/webauthn"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// PasskeyHandler handles WebAuthn passkey management for the current user
+type PasskeyHandler struct {
+	passkeyService *service.PasskeyService
+	totpService    *service.TotpService
+	userService    *service.UserService
+}
+
+// NewPasskeyHandler creates a new PasskeyHandler
+func NewPasskeyHandler(passkeyService *service.PasskeyService, totpService *service.TotpService, userService *service.UserService) *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: passkeyService,
+		totpService:    totpService,
+		userService:    userService,
+	}
+}
+
+// PasskeyIdentityRequest 敏感操作前的身份确认（与 TOTP 相同：邮箱验证码或密码）
+type PasskeyIdentityRequest struct {
+	EmailCode string `json:"email_code"`
+	Password  string `json:"password"`
+}
+
+// PasskeyRegisterRequest 完成注册请求
+type PasskeyRegisterRequest struct {
+	Name       string                         `json:"name"`
+	Credential *webauthn.RegistrationResponse `json:"credential" binding:"required"`
+}
+
+// PasskeyRenameRequest 重命名请求
+type PasskeyRenameRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// GetStatus returns the passkey status and registered passkeys of the current user
+// GET /api/v1/user/passkeys
+func (h *PasskeyHandler) GetStatus(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	status, err := h.passkeyService.GetStatus(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, status)
+}
+
+// RegisterOptions verifies identity and returns WebAuthn creation options
+// POST /api/v1/user/passkeys/register/options
+func (h *PasskeyHandler) RegisterOptions(c *gin.Context) {
+	subject, ok := h.verifyIdentity(c)
+	if !ok {
+		return
+	}
+	opts, err := h.passkeyService.BeginRegistration(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"public_key": opts})
+}
+
+// Register completes passkey registration
+// POST /api/v1/user/passkeys/register
+func (h *PasskeyHandler) Register(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	var req PasskeyRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	result, err := h.passkeyService.FinishRegistration(c.Request.Context(), subject.UserID, req.Name, req.Credential)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// Rename renames a passkey
+// PUT /api/v1/user/passkeys/:id
+func (h *PasskeyHandler) Rename(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid passkey ID")
+		return
+	}
+	var req PasskeyRenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if err := h.passkeyService.RenamePasskey(c.Request.Context(), subject.UserID, id, req.Name); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"success": true})
+}
+
+// Delete removes a passkey
+// DELETE /api/v1/user/passkeys/:id
+func (h *PasskeyHandler) Delete(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid passkey ID")
+		return
+	}
+	if err := h.passkeyService.DeletePasskey(c.Request.Context(), subject.UserID, id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"success": true})
+}
+
+// RegenerateRecoveryCodes verifies identity and replaces all recovery codes
+// POST /api/v1/user/passkeys/recovery-codes
+func (h *PasskeyHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	subject, ok := h.verifyIdentity(c)
+	if !ok {
+		return
+	}
+	codes, err := h.passkeyService.RegenerateRecoveryCodes(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"recovery_codes": codes})
+}
+
+// verifyIdentity 校验邮箱验证码或密码（验证方式见 /user/totp/verification-method）
+func (h *PasskeyHandler) verifyIdentity(c *gin.Context) (middleware2.AuthSubject, bool) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return subject, false
+	}
+	var req PasskeyIdentityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		req = PasskeyIdentityRequest{}
+	}
+	user, err := h.userService.GetByID(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return subject, false
+	}
+	if err := h.totpService.VerifyIdentity(c.Request.Context(), user, req.EmailCode, req.Password); err != nil {
+		response.ErrorFrom(c, err)
+		return subject, false
+	}
+	return subject, true
+}
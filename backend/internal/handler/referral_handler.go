@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReferralHandler handles the referrer dashboard for the current user
+type ReferralHandler struct {
+	referralService *service.ReferralService
+}
+
+// NewReferralHandler creates a new ReferralHandler
+func NewReferralHandler(referralService *service.ReferralService) *ReferralHandler {
+	return &ReferralHandler{referralService: referralService}
+}
+
+// Dashboard returns the user's referral code, reward rules and totals
+// GET /api/v1/referral
+func (h *ReferralHandler) Dashboard(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	dashboard, err := h.referralService.GetDashboard(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dashboard)
+}
+
+// ListReferees lists users referred by the current user
+// GET /api/v1/referral/referees
+func (h *ReferralHandler) ListReferees(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	items, result, err := h.referralService.ListReferees(c.Request.Context(), subject.UserID, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, items, result.Total, page, pageSize)
+}
+
+// ListCommissions lists the current user's referral commission ledger
+// GET /api/v1/referral/commissions
+// Query params:
+//   - status: pending / approved / rejected
+func (h *ReferralHandler) ListCommissions(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	items, result, err := h.referralService.ListUserCommissions(c.Request.Context(), subject.UserID, c.Query("status"), pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, items, result.Total, page, pageSize)
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatementHandler handles monthly statements for users and organizations
+type StatementHandler struct {
+	statementService *service.StatementService
+}
+
+// NewStatementHandler creates a new StatementHandler
+func NewStatementHandler(statementService *service.StatementService) *StatementHandler {
+	return &StatementHandler{statementService: statementService}
+}
+
+// List lists statements generated for the current user
+// GET /api/v1/statements
+func (h *StatementHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	items, result, err := h.statementService.ListUserStatements(c.Request.Context(), subject.UserID, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, items, result.Total, page, pageSize)
+}
+
+// Get returns (generating on first access) the current user's statement for a closed month
+// GET /api/v1/statements/:period
+// Query params:
+//   - format: html / csv / pdf, omitted for JSON
+func (h *StatementHandler) Get(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	st, err := h.statementService.GetUserStatement(c.Request.Context(), subject.UserID, c.Param("period"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	writeStatement(c, h.statementService, st)
+}
+
+// ListOrganization lists statements generated for an organization (owner / admin)
+// GET /api/v1/organizations/:id/statements
+func (h *StatementHandler) ListOrganization(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	items, result, err := h.statementService.ListOrganizationStatements(c.Request.Context(), orgID, subject.UserID, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, items, result.Total, page, pageSize)
+}
+
+// GetOrganization returns an organization's statement for a closed month (owner / admin)
+// GET /api/v1/organizations/:id/statements/:period
+func (h *StatementHandler) GetOrganization(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	orgID, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	st, err := h.statementService.GetOrganizationStatement(c.Request.Context(), orgID, subject.UserID, c.Param("period"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	writeStatement(c, h.statementService, st)
+}
+
+// writeStatement 未指定 format 时返回 JSON，否则按格式下载文件
+func writeStatement(c *gin.Context, statementService *service.StatementService, st *service.Statement) {
+	format := strings.ToLower(strings.TrimSpace(c.Query("format")))
+	if format == "" {
+		response.Success(c, st)
+		return
+	}
+	data, err := statementService.Render(st, format, statementService.SiteName(c.Request.Context()))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", service.StatementFilename(st, format)))
+	c.Data(http.StatusOK, service.StatementContentType(format), data)
+}
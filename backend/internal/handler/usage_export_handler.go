@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageExportHandler handles usage export tasks for the current user
+type UsageExportHandler struct {
+	exportService *service.UsageExportService
+	apiKeyService *service.APIKeyService
+}
+
+// NewUsageExportHandler creates a new UsageExportHandler
+func NewUsageExportHandler(exportService *service.UsageExportService, apiKeyService *service.APIKeyService) *UsageExportHandler {
+	return &UsageExportHandler{
+		exportService: exportService,
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateUsageExportRequest represents export task creation request
+// 过滤字段与 GET /api/v1/usage 的查询参数一致
+type CreateUsageExportRequest struct {
+	Format      string `json:"format" binding:"required"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	Timezone    string `json:"timezone"`
+	APIKeyID    int64  `json:"api_key_id"`
+	Model       string `json:"model"`
+	Stream      *bool  `json:"stream"`
+	BillingType *int8  `json:"billing_type"`
+	TagKey      string `json:"tag_key"`
+	TagValue    string `json:"tag_value"`
+}
+
+// List handles listing the current user's export tasks
+// GET /api/v1/usage/exports
+func (h *UsageExportHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	tasks, result, err := h.exportService.ListTasks(c.Request.Context(), service.UsageExportScopeUser, subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]dto.UsageExportTask, 0, len(tasks))
+	for i := range tasks {
+		out = append(out, *dto.UsageExportTaskFromService(&tasks[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// Create handles creating an export task for the current user
+// POST /api/v1/usage/exports
+func (h *UsageExportHandler) Create(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req CreateUsageExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if req.APIKeyID > 0 {
+		// 与列表接口一致，校验 API Key 归属，防止越权导出
+		apiKey, err := h.apiKeyService.GetByID(c.Request.Context(), req.APIKeyID)
+		if err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+		if apiKey.UserID != subject.UserID {
+			response.Forbidden(c, "Not authorized to access this API key's usage records")
+			return
+		}
+	}
+
+	req.StartDate = strings.TrimSpace(req.StartDate)
+	req.EndDate = strings.TrimSpace(req.EndDate)
+	if req.StartDate == "" || req.EndDate == "" {
+		response.BadRequest(c, "start_date and end_date are required")
+		return
+	}
+	startTime, err := timezone.ParseInUserLocation("2006-01-02", req.StartDate, req.Timezone)
+	if err != nil {
+		response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+		return
+	}
+	endTime, err := timezone.ParseInUserLocation("2006-01-02", req.EndDate, req.Timezone)
+	if err != nil {
+		response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+		return
+	}
+	endTime = endTime.Add(24*time.Hour - time.Nanosecond)
+
+	tagKey, tagValue, err := service.NormalizeUsageTagFilter(req.TagKey, req.TagValue)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	filters := usagestats.UsageLogFilters{
+		UserID:      subject.UserID, // Always filter by current user for security
+		APIKeyID:    req.APIKeyID,
+		Model:       strings.TrimSpace(req.Model),
+		Stream:      req.Stream,
+		BillingType: req.BillingType,
+		StartTime:   &startTime,
+		EndTime:     &endTime,
+		TagKey:      tagKey,
+		TagValue:    tagValue,
+	}
+
+	task, err := h.exportService.CreateTask(c.Request.Context(), service.UsageExportScopeUser, req.Format, filters, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageExportTaskFromService(task))
+}
+
+// Get handles getting one of the current user's export tasks with progress
+// GET /api/v1/usage/exports/:id
+func (h *UsageExportHandler) Get(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	taskID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || taskID <= 0 {
+		response.BadRequest(c, "Invalid task id")
+		return
+	}
+	task, err := h.exportService.GetTask(c.Request.Context(), service.UsageExportScopeUser, subject.UserID, taskID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UsageExportTaskFromService(task))
+}
+
+// Cancel handles canceling one of the current user's export tasks
+// POST /api/v1/usage/exports/:id/cancel
+func (h *UsageExportHandler) Cancel(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	taskID, err := strconv.ParseInt(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || taskID <= 0 {
+		response.BadRequest(c, "Invalid task id")
+		return
+	}
+	if err := h.exportService.CancelTask(c.Request.Context(), service.UsageExportScopeUser, subject.UserID, taskID, subject.UserID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"id": taskID, "status": service.UsageExportStatusCanceled})
+}
+
+// Download serves an export file by its download token
+// GET /api/v1/usage-exports/:token
+// 令牌即凭证，无需登录；链接在任务 expires_at 之后失效
+func (h *UsageExportHandler) Download(c *gin.Context) {
+	task, err := h.exportService.OpenDownload(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	c.Header("Content-Type", service.UsageExportContentType(task.Format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", service.UsageExportFilename(task)))
+	c.Header("Cache-Control", "private, no-store")
+	c.File(task.FilePath)
+}
@@ -17,15 +17,17 @@ import (
 
 // UsageHandler handles usage-related requests
 type UsageHandler struct {
-	usageService  *service.UsageService
-	apiKeyService *service.APIKeyService
+	usageService     *service.UsageService
+	apiKeyService    *service.APIKeyService
+	dashboardService *service.DashboardService
 }
 
 // NewUsageHandler creates a new UsageHandler
-func NewUsageHandler(usageService *service.UsageService, apiKeyService *service.APIKeyService) *UsageHandler {
+func NewUsageHandler(usageService *service.UsageService, apiKeyService *service.APIKeyService, dashboardService *service.DashboardService) *UsageHandler {
 	return &UsageHandler{
-		usageService:  usageService,
-		apiKeyService: apiKeyService,
+		usageService:     usageService,
+		apiKeyService:    apiKeyService,
+		dashboardService: dashboardService,
 	}
 }
 
@@ -86,6 +88,12 @@ func (h *UsageHandler) List(c *gin.Context) {
 		billingType = &bt
 	}
 
+	tagKey, tagValue, err := service.NormalizeUsageTagFilter(c.Query("tag_key"), c.Query("tag_value"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
 	// Parse date range
 	var startTime, endTime *time.Time
 	userTZ := c.Query("timezone") // Get user's timezone from request
@@ -118,6 +126,8 @@ func (h *UsageHandler) List(c *gin.Context) {
 		BillingType: billingType,
 		StartTime:   startTime,
 		EndTime:     endTime,
+		TagKey:      tagKey,
+		TagValue:    tagValue,
 	}
 
 	records, result, err := h.usageService.ListWithFilters(c.Request.Context(), params, filters)
@@ -350,6 +360,62 @@ func (h *UsageHandler) DashboardModels(c *gin.Context) {
 	})
 }
 
+// DashboardTags handles getting the current user's usage grouped by a cost attribution tag
+// GET /api/v1/usage/dashboard/tags?tag_key=project
+func (h *UsageHandler) DashboardTags(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var apiKeyID int64
+	if apiKeyIDStr := c.Query("api_key_id"); apiKeyIDStr != "" {
+		id, err := strconv.ParseInt(apiKeyIDStr, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid api_key_id")
+			return
+		}
+		apiKey, err := h.apiKeyService.GetByID(c.Request.Context(), id)
+		if err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+		if apiKey.UserID != subject.UserID {
+			response.Forbidden(c, "Not authorized to access this API key's usage records")
+			return
+		}
+		apiKeyID = id
+	}
+
+	tagKey, tagValue, err := service.NormalizeUsageTagFilter(c.Query("tag_key"), c.Query("tag_value"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	startTime, endTime := parseUserTimeRange(c)
+	stats, err := h.dashboardService.GetTagUsageStats(c.Request.Context(), usagestats.TagUsageFilters{
+		UserID:    subject.UserID,
+		APIKeyID:  apiKeyID,
+		TagKey:    tagKey,
+		TagValue:  tagValue,
+		StartTime: startTime,
+		EndTime:   endTime,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"tag_key":    tagKey,
+		"tags":       stats,
+		"start_date": startTime.Format("2006-01-02"),
+		"end_date":   endTime.Add(-24 * time.Hour).Format("2006-01-02"),
+	})
+}
+
 // BatchAPIKeysUsageRequest represents the request for batch API keys usage
 type BatchAPIKeysUsageRequest struct {
 	APIKeyIDs []int64 `json:"api_key_ids" binding:"required"`
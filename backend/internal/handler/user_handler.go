@@ -30,6 +30,8 @@ type ChangePasswordRequest struct {
 // UpdateProfileRequest represents the update profile request payload
 type UpdateProfileRequest struct {
 	Username *string `json:"username"`
+	// AllowedTagKeys 成本归属标签键白名单，省略表示不变，空数组清空
+	AllowedTagKeys []string `json:"allowed_tag_keys"`
 }
 
 // GetProfile handles getting user profile
@@ -94,7 +96,8 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	svcReq := service.UpdateProfileRequest{
-		Username: req.Username,
+		Username:       req.Username,
+		AllowedTagKeys: req.AllowedTagKeys,
 	}
 	updatedUser, err := h.userService.UpdateProfile(c.Request.Context(), subject.UserID, svcReq)
 	if err != nil {
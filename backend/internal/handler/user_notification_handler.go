@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserNotificationHandler handles usage alert settings and alert history for the current user
+type UserNotificationHandler struct {
+	notificationService *service.UserNotificationService
+}
+
+// NewUserNotificationHandler creates a new UserNotificationHandler
+func NewUserNotificationHandler(notificationService *service.UserNotificationService) *UserNotificationHandler {
+	return &UserNotificationHandler{notificationService: notificationService}
+}
+
+// GetSettings returns the current user's alert thresholds and channels
+// GET /api/v1/user/notifications/settings
+func (h *UserNotificationHandler) GetSettings(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	settings, err := h.notificationService.GetSettings(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, settings)
+}
+
+// UpdateSettings replaces the current user's alert thresholds and channels
+// PUT /api/v1/user/notifications/settings
+func (h *UserNotificationHandler) UpdateSettings(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	var req service.UpdateUserNotificationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	settings, err := h.notificationService.UpdateSettings(c.Request.Context(), subject.UserID, req)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, settings)
+}
+
+// List lists alerts fired for the current user
+// GET /api/v1/user/notifications
+func (h *UserNotificationHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	items, result, err := h.notificationService.ListNotifications(c.Request.Context(), subject.UserID, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Paginated(c, items, result.Total, page, pageSize)
+}
@@ -28,6 +28,23 @@ func ProvideAdminHandlers(
 	usageHandler *admin.UsageHandler,
 	userAttributeHandler *admin.UserAttributeHandler,
 	errorPassthroughHandler *admin.ErrorPassthroughHandler,
+	accountProbeHandler *admin.AccountProbeHandler,
+	schedulingHandler *admin.SchedulingHandler,
+	proxyPoolHandler *admin.ProxyPoolHandler,
+	proxyMonitorHandler *admin.ProxyMonitorHandler,
+	proxyImportHandler *admin.ProxyImportHandler,
+	adminRoleHandler *admin.AdminRoleHandler,
+	adminAPIKeyHandler *admin.AdminAPIKeyHandler,
+	adminAuditHandler *admin.AdminAuditHandler,
+	oauthProviderHandler *admin.OAuthProviderHandler,
+	passkeySettingsHandler *admin.PasskeySettingsHandler,
+	loginSecurityHandler *admin.LoginSecurityHandler,
+	organizationHandler *admin.OrganizationHandler,
+	referralHandler *admin.ReferralHandler,
+	statementHandler *admin.StatementHandler,
+	usageExportHandler *admin.UsageExportHandler,
+	usagePartitionHandler *admin.UsagePartitionHandler,
+	usageWriteHandler *admin.UsageWriteHandler,
 ) *AdminHandlers {
 	return &AdminHandlers{
 		Dashboard:        dashboardHandler,
@@ -49,6 +66,23 @@ func ProvideAdminHandlers(
 		Usage:            usageHandler,
 		UserAttribute:    userAttributeHandler,
 		ErrorPassthrough: errorPassthroughHandler,
+		AccountProbe:     accountProbeHandler,
+		Scheduling:       schedulingHandler,
+		ProxyPool:        proxyPoolHandler,
+		ProxyMonitor:     proxyMonitorHandler,
+		ProxyImport:      proxyImportHandler,
+		AdminRole:        adminRoleHandler,
+		AdminAPIKey:      adminAPIKeyHandler,
+		AdminAudit:       adminAuditHandler,
+		OAuthProvider:    oauthProviderHandler,
+		PasskeySettings:  passkeySettingsHandler,
+		LoginSecurity:    loginSecurityHandler,
+		Organization:     organizationHandler,
+		Referral:         referralHandler,
+		Statement:        statementHandler,
+		UsageExport:      usageExportHandler,
+		UsagePartition:   usagePartitionHandler,
+		UsageWrite:       usageWriteHandler,
 	}
 }
 
@@ -76,6 +110,13 @@ func ProvideHandlers(
 	openaiGatewayHandler *OpenAIGatewayHandler,
 	settingHandler *SettingHandler,
 	totpHandler *TotpHandler,
+	oauthHandler *OAuthHandler,
+	passkeyHandler *PasskeyHandler,
+	organizationHandler *OrganizationHandler,
+	referralHandler *ReferralHandler,
+	statementHandler *StatementHandler,
+	notificationHandler *UserNotificationHandler,
+	usageExportHandler *UsageExportHandler,
 ) *Handlers {
 	return &Handlers{
 		Auth:          authHandler,
@@ -90,6 +131,13 @@ func ProvideHandlers(
 		OpenAIGateway: openaiGatewayHandler,
 		Setting:       settingHandler,
 		Totp:          totpHandler,
+		OAuth:         oauthHandler,
+		Passkey:       passkeyHandler,
+		Organization:  organizationHandler,
+		Referral:      referralHandler,
+		Statement:     statementHandler,
+		Notification:  notificationHandler,
+		UsageExport:   usageExportHandler,
 	}
 }
 
@@ -106,6 +154,13 @@ var ProviderSet = wire.NewSet(
 	NewGatewayHandler,
 	NewOpenAIGatewayHandler,
 	NewTotpHandler,
+	NewOAuthHandler,
+	NewPasskeyHandler,
+	NewOrganizationHandler,
+	NewReferralHandler,
+	NewStatementHandler,
+	NewUserNotificationHandler,
+	NewUsageExportHandler,
 	ProvideSettingHandler,
 
 	// Admin handlers
@@ -128,6 +183,23 @@ var ProviderSet = wire.NewSet(
 	admin.NewUsageHandler,
 	admin.NewUserAttributeHandler,
 	admin.NewErrorPassthroughHandler,
+	admin.NewAccountProbeHandler,
+	admin.NewSchedulingHandler,
+	admin.NewProxyPoolHandler,
+	admin.NewProxyMonitorHandler,
+	admin.NewProxyImportHandler,
+	admin.NewAdminRoleHandler,
+	admin.NewAdminAPIKeyHandler,
+	admin.NewAdminAuditHandler,
+	admin.NewOAuthProviderHandler,
+	admin.NewPasskeySettingsHandler,
+	admin.NewLoginSecurityHandler,
+	admin.NewOrganizationHandler,
+	admin.NewReferralHandler,
+	admin.NewStatementHandler,
+	admin.NewUsageExportHandler,
+	admin.NewUsagePartitionHandler,
+	admin.NewUsageWriteHandler,
 
 	// AdminHandlers and Handlers constructors
 	ProvideAdminHandlers,
@@ -24,6 +24,8 @@ const (
 	ThinkingEnabled Key = "ctx_thinking_enabled"
 	// Group 认证后的分组信息，由 API Key 认证中间件设置
 	Group Key = "ctx_group"
+	// UserID 认证后的用户 ID，由 API Key 认证中间件设置（用于分组内用户公平分配）
+	UserID Key = "ctx_user_id"
 
 	// IsMaxTokensOneHaikuRequest 标识当前请求是否为 max_tokens=1 + haiku 模型的探测请求
 	// 用于 ClaudeCodeOnly 验证绕过（绕过 system prompt 检查，但仍需验证 User-Agent）
@@ -32,4 +34,15 @@ const (
 	// SingleAccountRetry 标识当前请求处于单账号 503 退避重试模式。
 	// 在此模式下，Service 层的模型限流预检查将等待限流过期而非直接切换账号。
 	SingleAccountRetry Key = "ctx_single_account_retry"
+
+	// ClientIP 客户端 IP，由 middleware.ClientInfo 在认证路由上设置（用于登录会话与登录事件记录）
+	ClientIP Key = "ctx_client_ip"
+	// UserAgent 客户端 User-Agent，由 middleware.ClientInfo 设置
+	UserAgent Key = "ctx_user_agent"
+	// LoginMethod 本次签发 Token 的登录方式（password / 2fa / passkey / oauth / register）
+	LoginMethod Key = "ctx_login_method"
+
+	// IdempotencyRequestID 携带 Idempotency-Key 的请求对应的使用记录 request_id，
+	// 由 middleware.Idempotency 设置，保证同一 Key 的重试不会重复计费
+	IdempotencyRequestID Key = "ctx_idempotency_request_id"
 )
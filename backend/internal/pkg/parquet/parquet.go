@@ -0,0 +1,381 @@
+// Package parquet 以流式方式写出扁平结构的 Parquet 文件（用于使用记录导出），不依赖第三方库。
+//
+// 仅支持无嵌套的 REQUIRED / OPTIONAL 列，数据页使用 PLAIN 编码、不压缩；
+// 行按 RowGroupSize 分组缓存在内存中，写满一组即落盘，因此内存占用与总行数无关。
+package parquet
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// DefaultRowGroupSize 默认每个 Row Group 的行数
+const DefaultRowGroupSize = 10000
+
+var magic = []byte("PAR1")
+
+// Type 列的逻辑类型
+type Type int
+
+const (
+	// Int64 对应 INT64
+	Int64 Type = iota
+	// Double 对应 DOUBLE
+	Double
+	// String 对应 BYTE_ARRAY (UTF8)
+	String
+	// Boolean 对应 BOOLEAN
+	Boolean
+	// Timestamp 对应 INT64 (TIMESTAMP_MILLIS, UTC)
+	Timestamp
+)
+
+// Parquet 物理类型 / 转换类型 / 编码等枚举值（见 parquet.thrift）
+const (
+	physicalBoolean   int32 = 0
+	physicalInt64     int32 = 2
+	physicalDouble    int32 = 5
+	physicalByteArray int32 = 6
+
+	convertedUTF8            int32 = 0
+	convertedTimestampMillis int32 = 9
+
+	repetitionRequired int32 = 0
+	repetitionOptional int32 = 1
+
+	encodingPlain int32 = 0
+	encodingRLE   int32 = 3
+
+	pageTypeData int32 = 0
+)
+
+// Column 列定义
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool
+}
+
+func (c Column) physicalType() int32 {
+	switch c.Type {
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	case Boolean:
+		return physicalBoolean
+	default:
+		return physicalInt64
+	}
+}
+
+type columnChunkMeta struct {
+	offset           int64
+	size             int64
+	numValues        int64
+	dataPageOffset   int64
+	uncompressedSize int64
+}
+
+type rowGroupMeta struct {
+	columns  []columnChunkMeta
+	numRows  int64
+	byteSize int64
+}
+
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Writer 按行写入、按列落盘的 Parquet 写入器
+type Writer struct {
+	out          *countingWriter
+	columns      []Column
+	rowGroupSize int
+
+	values    [][]any
+	rows      int
+	rowGroups []rowGroupMeta
+	totalRows int64
+	closed    bool
+}
+
+// NewWriter 创建写入器并写出文件头；rowGroupSize <= 0 时使用 DefaultRowGroupSize
+func NewWriter(w io.Writer, columns []Column, rowGroupSize int) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: no columns")
+	}
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+	pw := &Writer{
+		out:          &countingWriter{w: bufio.NewWriterSize(w, 64<<10)},
+		columns:      columns,
+		rowGroupSize: rowGroupSize,
+		values:       make([][]any, len(columns)),
+	}
+	if _, err := pw.out.Write(magic); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// Write 追加一行；值类型需与列定义一致（int64 / float64 / string / bool / time.Time），可选列允许 nil
+func (w *Writer) Write(row []any) error {
+	if w.closed {
+		return errors.New("parquet: writer closed")
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: row has %d values, want %d", len(row), len(w.columns))
+	}
+	for i, v := range row {
+		if err := checkValue(w.columns[i], v); err != nil {
+			return err
+		}
+	}
+	for i, v := range row {
+		w.values[i] = append(w.values[i], v)
+	}
+	w.rows++
+	if w.rows >= w.rowGroupSize {
+		return w.flushRowGroup()
+	}
+	return nil
+}
+
+func checkValue(col Column, v any) error {
+	if v == nil {
+		if col.Optional {
+			return nil
+		}
+		return fmt.Errorf("parquet: column %q is required", col.Name)
+	}
+	var ok bool
+	switch col.Type {
+	case Int64:
+		_, ok = v.(int64)
+	case Double:
+		_, ok = v.(float64)
+	case String:
+		_, ok = v.(string)
+	case Boolean:
+		_, ok = v.(bool)
+	case Timestamp:
+		_, ok = v.(time.Time)
+	}
+	if !ok {
+		return fmt.Errorf("parquet: column %q got unexpected value type %T", col.Name, v)
+	}
+	return nil
+}
+
+// Close 写出剩余数据与文件尾（FileMetaData），不关闭底层 io.Writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if w.rows > 0 {
+		if err := w.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+	w.closed = true
+
+	footer := w.fileMetaData()
+	if _, err := w.out.Write(footer); err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(footer)))
+	if _, err := w.out.Write(size[:]); err != nil {
+		return err
+	}
+	if _, err := w.out.Write(magic); err != nil {
+		return err
+	}
+	return w.out.w.Flush()
+}
+
+// Rows 已写入的总行数
+func (w *Writer) Rows() int64 {
+	return w.totalRows + int64(w.rows)
+}
+
+func (w *Writer) flushRowGroup() error {
+	group := rowGroupMeta{numRows: int64(w.rows)}
+	for i, col := range w.columns {
+		page := encodeDataPage(col, w.values[i])
+		header := pageHeader(len(page), w.rows)
+
+		offset := w.out.n
+		if _, err := w.out.Write(header); err != nil {
+			return err
+		}
+		if _, err := w.out.Write(page); err != nil {
+			return err
+		}
+		size := int64(len(header) + len(page))
+		group.columns = append(group.columns, columnChunkMeta{
+			offset:           offset,
+			size:             size,
+			numValues:        int64(w.rows),
+			dataPageOffset:   offset,
+			uncompressedSize: size,
+		})
+		group.byteSize += size
+		w.values[i] = w.values[i][:0]
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.totalRows += int64(w.rows)
+	w.rows = 0
+	return nil
+}
+
+// encodeDataPage 生成 Data Page V1 内容：可选列先写定义级别（RLE/Bit-Packed 混合编码，带 4 字节长度），再写 PLAIN 值
+func encodeDataPage(col Column, values []any) []byte {
+	var page []byte
+	if col.Optional {
+		levels := encodeDefinitionLevels(values)
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+		page = append(page, levels...)
+	}
+
+	if col.Type == Boolean {
+		var bits []bool
+		for _, v := range values {
+			if v != nil {
+				bits = append(bits, v.(bool))
+			}
+		}
+		return append(page, packBits(bits)...)
+	}
+
+	for _, v := range values {
+		switch val := v.(type) {
+		case nil:
+		case int64:
+			page = binary.LittleEndian.AppendUint64(page, uint64(val))
+		case float64:
+			page = binary.LittleEndian.AppendUint64(page, math.Float64bits(val))
+		case string:
+			page = binary.LittleEndian.AppendUint32(page, uint32(len(val)))
+			page = append(page, val...)
+		case time.Time:
+			page = binary.LittleEndian.AppendUint64(page, uint64(val.UnixMilli()))
+		}
+	}
+	return page
+}
+
+// encodeDefinitionLevels 最大定义级别为 1（位宽 1），整体作为一个 bit-packed run 写出
+func encodeDefinitionLevels(values []any) []byte {
+	bits := make([]bool, len(values))
+	for i, v := range values {
+		bits[i] = v != nil
+	}
+	// bit-packed run 头部为 (组数 << 1) | 1，每组 8 个值，不足的部分补 0
+	groups := (len(bits) + 7) / 8
+	out := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	return append(out, packBits(bits)...)
+}
+
+// packBits 按 LSB 优先逐位打包
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			out[i/8] |= 1 << (uint(i) % 8)
+		}
+	}
+	return out
+}
+
+func pageHeader(pageSize, numValues int) []byte {
+	var w compactWriter
+	w.i32(1, pageTypeData)
+	w.i32(2, int32(pageSize))
+	w.i32(3, int32(pageSize))
+	w.structBegin(5)
+	w.i32(1, int32(numValues))
+	w.i32(2, encodingPlain)
+	w.i32(3, encodingRLE)
+	w.i32(4, encodingRLE)
+	w.structEnd()
+	w.stop()
+	return w.buf.Bytes()
+}
+
+func (w *Writer) fileMetaData() []byte {
+	var t compactWriter
+	t.i32(1, 1)
+
+	// schema：根节点 + 各列
+	t.listBegin(2, compactStruct, len(w.columns)+1)
+	t.structBegin(0)
+	t.string(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.structEnd()
+	for _, col := range w.columns {
+		t.structBegin(0)
+		t.i32(1, col.physicalType())
+		repetition := repetitionRequired
+		if col.Optional {
+			repetition = repetitionOptional
+		}
+		t.i32(3, repetition)
+		t.string(4, col.Name)
+		switch col.Type {
+		case String:
+			t.i32(6, convertedUTF8)
+		case Timestamp:
+			t.i32(6, convertedTimestampMillis)
+		}
+		t.structEnd()
+	}
+
+	t.i64(3, w.totalRows)
+
+	t.listBegin(4, compactStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		t.structBegin(0)
+		t.listBegin(1, compactStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			col := w.columns[i]
+			t.structBegin(0)
+			t.i64(2, chunk.offset)
+			t.structBegin(3)
+			t.i32(1, col.physicalType())
+			t.listBegin(2, compactI32, 2)
+			t.listI32(encodingPlain)
+			t.listI32(encodingRLE)
+			t.listBegin(3, compactBinary, 1)
+			t.rawString(col.Name)
+			t.i32(4, 0) // UNCOMPRESSED
+			t.i64(5, chunk.numValues)
+			t.i64(6, chunk.uncompressedSize)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.dataPageOffset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, group.byteSize)
+		t.i64(3, group.numRows)
+		t.structEnd()
+	}
+
+	t.string(6, "sub2api")
+	t.stop()
+	return t.buf.Bytes()
+}
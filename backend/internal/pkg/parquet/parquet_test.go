@@ -0,0 +1,376 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// compactReader 解析 Thrift Compact 结构体为 map[字段ID]值，用于校验写出的元数据
+type compactReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *compactReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) value(typ byte) any {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case compactI32, compactI64:
+		return r.zigzag()
+	case compactBinary:
+		n := int(r.uvarint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case compactList:
+		head := r.data[r.pos]
+		r.pos++
+		size := int(head >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		items := make([]any, size)
+		for i := range items {
+			items[i] = r.value(head & 0x0F)
+		}
+		return items
+	case compactStruct:
+		return r.structValue()
+	}
+	panic("unsupported thrift type")
+}
+
+func (r *compactReader) structValue() map[int16]any {
+	out := map[int16]any{}
+	var last int16
+	for {
+		head := r.data[r.pos]
+		r.pos++
+		if head == 0 {
+			return out
+		}
+		id := last + int16(head>>4)
+		if head>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		out[id] = r.value(head & 0x0F)
+		last = id
+	}
+}
+
+func readFooter(t *testing.T, file []byte) map[int16]any {
+	t.Helper()
+	require.True(t, bytes.HasPrefix(file, magic))
+	require.True(t, bytes.HasSuffix(file, magic))
+	size := int(binary.LittleEndian.Uint32(file[len(file)-8 : len(file)-4]))
+	footer := file[len(file)-8-size : len(file)-8]
+	r := &compactReader{data: footer}
+	meta := r.structValue()
+	require.Equal(t, len(footer), r.pos)
+	return meta
+}
+
+func TestWriter_RoundTripMetadataAndPages(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Type: Int64},
+		{Name: "model", Type: String},
+		{Name: "cost", Type: Double},
+		{Name: "stream", Type: Boolean},
+		{Name: "group_id", Type: Int64, Optional: true},
+		{Name: "created_at", Type: Timestamp},
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns, 2)
+	require.NoError(t, err)
+
+	at := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, w.Write([]any{int64(1), "claude-sonnet-4", 0.5, true, int64(3), at}))
+	require.NoError(t, w.Write([]any{int64(2), "gpt-5", 1.25, false, nil, at}))
+	require.NoError(t, w.Write([]any{int64(3), "gemini", 2.0, true, nil, at}))
+	require.Equal(t, int64(3), w.Rows())
+	require.NoError(t, w.Close())
+
+	file := buf.Bytes()
+	meta := readFooter(t, file)
+	require.Equal(t, int64(3), meta[3])
+	require.Equal(t, "sub2api", meta[6])
+
+	schema := meta[2].([]any)
+	require.Len(t, schema, len(columns)+1)
+	require.Equal(t, int64(len(columns)), schema[0].(map[int16]any)[5])
+	groupCol := schema[5].(map[int16]any)
+	require.Equal(t, "group_id", groupCol[4])
+	require.Equal(t, int64(repetitionOptional), groupCol[3])
+
+	rowGroups := meta[4].([]any)
+	require.Len(t, rowGroups, 2)
+	first := rowGroups[0].(map[int16]any)
+	require.Equal(t, int64(2), first[3])
+
+	// 读取第一个 Row Group 的 cost 列数据页
+	chunk := first[1].([]any)[2].(map[int16]any)[3].(map[int16]any)
+	offset := int(chunk[9].(int64))
+	r := &compactReader{data: file, pos: offset}
+	header := r.structValue()
+	require.Equal(t, int64(2), header[5].(map[int16]any)[1])
+	page := file[r.pos : r.pos+int(header[2].(int64))]
+	require.Equal(t, 0.5, math.Float64frombits(binary.LittleEndian.Uint64(page[0:8])))
+	require.Equal(t, 1.25, math.Float64frombits(binary.LittleEndian.Uint64(page[8:16])))
+
+	// group_id 列：定义级别 [1, 0]，随后只有一个非空值
+	chunk = first[1].([]any)[4].(map[int16]any)[3].(map[int16]any)
+	r = &compactReader{data: file, pos: int(chunk[9].(int64))}
+	header = r.structValue()
+	page = file[r.pos : r.pos+int(header[2].(int64))]
+	levelsLen := int(binary.LittleEndian.Uint32(page[0:4]))
+	require.Equal(t, []byte{0x03, 0x01}, page[4:4+levelsLen])
+	require.Equal(t, int64(3), int64(binary.LittleEndian.Uint64(page[4+levelsLen:])))
+}
+
+func TestWriter_EmptyFile(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{{Name: "id", Type: Int64}}, 0)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	meta := readFooter(t, buf.Bytes())
+	require.Equal(t, int64(0), meta[3])
+	require.Empty(t, meta[4])
+}
+
+func TestWriter_RejectsInvalidValues(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{{Name: "id", Type: Int64}, {Name: "name", Type: String, Optional: true}}, 0)
+	require.NoError(t, err)
+
+	require.Error(t, w.Write([]any{int64(1)}))
+	require.Error(t, w.Write([]any{nil, "x"}))
+	require.Error(t, w.Write([]any{1, "x"}))
+	require.NoError(t, w.Write([]any{int64(1), nil}))
+	require.NoError(t, w.Close())
+	require.Error(t, w.Write([]any{int64(2), nil}))
+}
+
+func TestPackBits(t *testing.T) {
+	require.Equal(t, []byte{0x05, 0x01}, packBits([]bool{true, false, true, false, false, false, false, false, true}))
+	require.Empty(t, packBits(nil))
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/*.parquet golden files")
+
+// goldenColumns / goldenRows 覆盖全部类型、可选列空值与多个 Row Group
+var goldenColumns = []Column{
+	{Name: "id", Type: Int64},
+	{Name: "created_at", Type: Timestamp},
+	{Name: "model", Type: String},
+	{Name: "cost", Type: Double},
+	{Name: "stream", Type: Boolean},
+	{Name: "group_id", Type: Int64, Optional: true},
+	{Name: "user_agent", Type: String, Optional: true},
+}
+
+func goldenRows() [][]any {
+	at := time.Date(2026, 9, 1, 8, 30, 0, 123e6, time.UTC)
+	return [][]any{
+		{int64(1), at, "claude-sonnet-4", 0.5, true, int64(3), "curl/8.0"},
+		{int64(2), at.Add(time.Second), "gpt-5", 1.25, false, nil, nil},
+		{int64(3), at.Add(2 * time.Second), "gemini-2.5-pro", 0.0, true, nil, "模型客户端"},
+		{int64(-4), at.Add(3 * time.Second), "", -2.75, false, int64(1 << 40), ""},
+		{int64(5), at.Add(4 * time.Second), "claude-opus-4", 1e-8, true, int64(7), nil},
+	}
+}
+
+func writeGolden(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, goldenColumns, 3)
+	require.NoError(t, err)
+	for _, row := range goldenRows() {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// TestWriter_GoldenFile 写出结果必须与 testdata/usage.parquet 逐字节一致；
+// 该文件可直接用 pyarrow / parquet-tools 打开校验（见 testdata/README.md）
+func TestWriter_GoldenFile(t *testing.T) {
+	got := writeGolden(t)
+	path := filepath.Join("testdata", "usage.parquet")
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+// decodeLevels 按规范解析 RLE / Bit-Packed 混合编码的定义级别（位宽 1），两种 run 都支持，不依赖写入器实现
+func decodeLevels(t *testing.T, data []byte, n int) []int {
+	t.Helper()
+	levels := make([]int, 0, n)
+	for pos := 0; len(levels) < n; {
+		header, size := binary.Uvarint(data[pos:])
+		require.Positive(t, size, "level run header")
+		pos += size
+		if header&1 == 1 {
+			groups := int(header >> 1)
+			for _, b := range data[pos : pos+groups] {
+				for bit := 0; bit < 8; bit++ {
+					levels = append(levels, int(b>>bit)&1)
+				}
+			}
+			pos += groups
+			continue
+		}
+		value := int(data[pos])
+		pos++
+		for i := 0; i < int(header>>1); i++ {
+			levels = append(levels, value)
+		}
+	}
+	return levels[:n]
+}
+
+// decodeChunk 读取列块的数据页（单页、未压缩、PLAIN），按定义级别还原为含 nil 的值序列
+func decodeChunk(t *testing.T, file []byte, schema, chunk map[int16]any) []any {
+	t.Helper()
+	r := &compactReader{data: file, pos: int(chunk[9].(int64))}
+	header := r.structValue()
+	require.Equal(t, int64(pageTypeData), header[1])
+	dataPage := header[5].(map[int16]any)
+	numValues := int(dataPage[1].(int64))
+	page := file[r.pos : r.pos+int(header[2].(int64))]
+
+	levels := make([]int, numValues)
+	for i := range levels {
+		levels[i] = 1
+	}
+	if schema[3] == int64(repetitionOptional) {
+		levelsLen := int(binary.LittleEndian.Uint32(page[0:4]))
+		levels = decodeLevels(t, page[4:4+levelsLen], numValues)
+		page = page[4+levelsLen:]
+	}
+
+	out := make([]any, numValues)
+	pos, boolIndex := 0, 0
+	for i, level := range levels {
+		if level == 0 {
+			continue
+		}
+		switch schema[1] {
+		case int64(physicalBoolean):
+			out[i] = page[boolIndex/8]>>(uint(boolIndex)%8)&1 == 1
+			boolIndex++
+		case int64(physicalInt64):
+			v := int64(binary.LittleEndian.Uint64(page[pos:]))
+			pos += 8
+			if schema[6] == int64(convertedTimestampMillis) {
+				out[i] = time.UnixMilli(v).UTC()
+			} else {
+				out[i] = v
+			}
+		case int64(physicalDouble):
+			out[i] = math.Float64frombits(binary.LittleEndian.Uint64(page[pos:]))
+			pos += 8
+		case int64(physicalByteArray):
+			n := int(binary.LittleEndian.Uint32(page[pos:]))
+			out[i] = string(page[pos+4 : pos+4+n])
+			pos += 4 + n
+		default:
+			t.Fatalf("unexpected physical type %v", schema[1])
+		}
+	}
+	return out
+}
+
+// TestGoldenFile_Decodes 直接解析 testdata/usage.parquet 的文件尾与数据页，
+// 校验 schema、行数、Row Group 划分与各可选列的空值位置，期望值与 testdata/README.md 中的表格一致
+func TestGoldenFile_Decodes(t *testing.T) {
+	file, err := os.ReadFile(filepath.Join("testdata", "usage.parquet"))
+	require.NoError(t, err)
+	meta := readFooter(t, file)
+
+	require.Equal(t, int64(1), meta[1])
+	require.Equal(t, int64(5), meta[3])
+
+	type schemaField struct {
+		name       string
+		physical   int32
+		repetition int32
+		converted  any
+	}
+	wantSchema := []schemaField{
+		{"id", physicalInt64, repetitionRequired, nil},
+		{"created_at", physicalInt64, repetitionRequired, int64(convertedTimestampMillis)},
+		{"model", physicalByteArray, repetitionRequired, int64(convertedUTF8)},
+		{"cost", physicalDouble, repetitionRequired, nil},
+		{"stream", physicalBoolean, repetitionRequired, nil},
+		{"group_id", physicalInt64, repetitionOptional, nil},
+		{"user_agent", physicalByteArray, repetitionOptional, int64(convertedUTF8)},
+	}
+	schema := meta[2].([]any)
+	require.Len(t, schema, len(wantSchema)+1)
+	root := schema[0].(map[int16]any)
+	require.Equal(t, "schema", root[4])
+	require.Equal(t, int64(len(wantSchema)), root[5])
+	for i, want := range wantSchema {
+		got := schema[i+1].(map[int16]any)
+		require.Equal(t, want.name, got[4])
+		require.Equal(t, int64(want.physical), got[1], want.name)
+		require.Equal(t, int64(want.repetition), got[3], want.name)
+		require.Equal(t, want.converted, got[6], want.name)
+	}
+
+	at := time.Date(2026, 9, 1, 8, 30, 0, 123e6, time.UTC)
+	wantColumns := [][]any{
+		{int64(1), int64(2), int64(3), int64(-4), int64(5)},
+		{at, at.Add(time.Second), at.Add(2 * time.Second), at.Add(3 * time.Second), at.Add(4 * time.Second)},
+		{"claude-sonnet-4", "gpt-5", "gemini-2.5-pro", "", "claude-opus-4"},
+		{0.5, 1.25, 0.0, -2.75, 1e-8},
+		{true, false, true, false, true},
+		{int64(3), nil, nil, int64(1 << 40), int64(7)},
+		{"curl/8.0", nil, "模型客户端", "", nil},
+	}
+
+	rowGroups := meta[4].([]any)
+	require.Len(t, rowGroups, 2)
+	gotColumns := make([][]any, len(wantSchema))
+	for g, wantRows := range []int64{3, 2} {
+		group := rowGroups[g].(map[int16]any)
+		require.Equal(t, wantRows, group[3])
+		chunks := group[1].([]any)
+		require.Len(t, chunks, len(wantSchema))
+		for i := range chunks {
+			chunk := chunks[i].(map[int16]any)[3].(map[int16]any)
+			require.Equal(t, []any{wantSchema[i].name}, chunk[3])
+			require.Equal(t, int64(0), chunk[4], "uncompressed")
+			require.Equal(t, wantRows, chunk[5])
+			gotColumns[i] = append(gotColumns[i], decodeChunk(t, file, schema[i+1].(map[int16]any), chunk)...)
+		}
+	}
+	for i, want := range wantColumns {
+		require.Equal(t, want, gotColumns[i], wantSchema[i].name)
+	}
+}
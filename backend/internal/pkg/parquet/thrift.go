@@ -0,0 +1,101 @@
+package parquet
+
+import "bytes"
+
+// Thrift Compact Protocol 类型标识（仅包含文件元数据用到的部分）
+const (
+	compactI32    byte = 5
+	compactI64    byte = 6
+	compactBinary byte = 8
+	compactList   byte = 9
+	compactStruct byte = 12
+)
+
+// compactWriter 最小化的 Thrift Compact Protocol 编码器，字段必须按 ID 升序写入
+type compactWriter struct {
+	buf       bytes.Buffer
+	lastField int16
+	stack     []int16
+}
+
+func (w *compactWriter) varint(v uint64) {
+	for v >= 0x80 {
+		w.buf.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	w.buf.WriteByte(byte(v))
+}
+
+func zigzag32(v int32) uint64 {
+	return uint64(uint32((v << 1) ^ (v >> 31)))
+}
+
+func zigzag64(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (w *compactWriter) fieldHeader(id int16, typ byte) {
+	delta := id - w.lastField
+	if delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(zigzag32(int32(id)))
+	}
+	w.lastField = id
+}
+
+func (w *compactWriter) i32(id int16, v int32) {
+	w.fieldHeader(id, compactI32)
+	w.varint(zigzag32(v))
+}
+
+func (w *compactWriter) i64(id int16, v int64) {
+	w.fieldHeader(id, compactI64)
+	w.varint(zigzag64(v))
+}
+
+func (w *compactWriter) string(id int16, v string) {
+	w.fieldHeader(id, compactBinary)
+	w.rawString(v)
+}
+
+func (w *compactWriter) rawString(v string) {
+	w.varint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+// listBegin 写入列表头，随后由调用方依次写入 size 个元素
+func (w *compactWriter) listBegin(id int16, elemType byte, size int) {
+	w.fieldHeader(id, compactList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	w.buf.WriteByte(0xF0 | elemType)
+	w.varint(uint64(size))
+}
+
+func (w *compactWriter) listI32(v int32) {
+	w.varint(zigzag32(v))
+}
+
+// structBegin 开始嵌套结构体；id 为 0 表示列表元素（不写字段头）
+func (w *compactWriter) structBegin(id int16) {
+	if id != 0 {
+		w.fieldHeader(id, compactStruct)
+	}
+	w.stack = append(w.stack, w.lastField)
+	w.lastField = 0
+}
+
+// stop 结束顶层结构体
+func (w *compactWriter) stop() {
+	w.buf.WriteByte(0)
+}
+
+func (w *compactWriter) structEnd() {
+	w.buf.WriteByte(0)
+	w.lastField = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
@@ -0,0 +1,134 @@
+// Package pdf 生成只包含等宽文本的简单 PDF 文档（用于对账单导出），不依赖第三方库。
+//
+// 仅使用 PDF 内置的 Courier / Courier-Bold 字体，因此只能渲染 ASCII 字符，
+// 其他字符会被替换为 '?'。调用方通过空格补齐实现表格列对齐。
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 纵向页面尺寸（单位 pt）
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	margin     = 48.0
+)
+
+type textLine struct {
+	text string
+	size float64
+	bold bool
+	y    float64
+}
+
+// Document 按行从上到下排版的文本文档，超出页面时自动分页
+type Document struct {
+	pages [][]textLine
+	y     float64
+}
+
+// New 创建只有一个空白页的文档
+func New() *Document {
+	d := &Document{}
+	d.NewPage()
+	return d
+}
+
+// NewPage 开始新的一页
+func (d *Document) NewPage() {
+	d.pages = append(d.pages, nil)
+	d.y = pageHeight - margin
+}
+
+// Line 追加一行文本，size 为字号（pt）
+func (d *Document) Line(text string, size float64, bold bool) {
+	leading := size * 1.4
+	if d.y-leading < margin {
+		d.NewPage()
+	}
+	d.y -= leading
+	last := len(d.pages) - 1
+	d.pages[last] = append(d.pages[last], textLine{text: text, size: size, bold: bold, y: d.y})
+}
+
+// Blank 追加一个空行
+func (d *Document) Blank(size float64) {
+	d.Line("", size, false)
+}
+
+// PageCount 返回页数
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Bytes 序列化为 PDF 文件内容
+func (d *Document) Bytes() []byte {
+	// 对象编号：1 Catalog，2 Pages，3 Courier，4 Courier-Bold，之后每页依次为 Page + Contents
+	const firstPageObj = 5
+	var buf bytes.Buffer
+	offsets := make([]int, 0, firstPageObj-1+2*len(d.pages))
+
+	writeObj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObj+2*i)
+	}
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range d.pages {
+		contentObj := firstPageObj + 2*i + 1
+		writeObj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, contentObj))
+
+		var content bytes.Buffer
+		for _, l := range lines {
+			if l.text == "" {
+				continue
+			}
+			font := "F1"
+			if l.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, l.size, margin, l.y, escapeText(l.text))
+		}
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n", len(offsets)+1)
+	buf.WriteString("0000000000 65535 f \n")
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+	return buf.Bytes()
+}
+
+// escapeText 转义字符串对象中的特殊字符，非 ASCII 可打印字符替换为 '?'
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestDocumentBytes_XrefOffsetsPointAtObjects(t *testing.T) {
+	d := New()
+	d.Line("Statement ST-202609-U000001", 14, true)
+	d.Blank(10)
+	d.Line("claude-sonnet-4   (default)   12.50", 9, false)
+	out := d.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("missing header or trailer")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("startxref not found")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n0 7\n")) {
+		t.Fatalf("startxref does not point at xref table: %q", out[xref:xref+12])
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	if len(entries) != 6 {
+		t.Fatalf("expected 6 objects, got %d", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(out[off:], []byte(want)) {
+			t.Fatalf("xref entry %d points at %q, want %q", i+1, out[off:off+len(want)], want)
+		}
+	}
+}
+
+func TestDocumentLine_PaginatesWhenPageIsFull(t *testing.T) {
+	d := New()
+	for i := 0; i < 100; i++ {
+		d.Line("row", 10, false)
+	}
+	if d.PageCount() < 2 {
+		t.Fatalf("expected automatic page break, got %d page(s)", d.PageCount())
+	}
+	if !bytes.Contains(d.Bytes(), []byte(fmt.Sprintf("/Count %d", d.PageCount()))) {
+		t.Fatalf("page tree count mismatch")
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	got := escapeText(`a(b)c\d 分组`)
+	want := `a\(b\)c\\d ??`
+	if got != want {
+		t.Fatalf("escapeText = %q, want %q", got, want)
+	}
+}
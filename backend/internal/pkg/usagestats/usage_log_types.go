@@ -134,15 +134,41 @@ type UserDashboardStats struct {
 
 // UsageLogFilters represents filters for usage log queries
 type UsageLogFilters struct {
-	UserID      int64
-	APIKeyID    int64
-	AccountID   int64
-	GroupID     int64
-	Model       string
-	Stream      *bool
-	BillingType *int8
-	StartTime   *time.Time
-	EndTime     *time.Time
+	UserID         int64      `json:"user_id,omitempty"`
+	APIKeyID       int64      `json:"api_key_id,omitempty"`
+	OrganizationID int64      `json:"organization_id,omitempty"`
+	AccountID      int64      `json:"account_id,omitempty"`
+	GroupID        int64      `json:"group_id,omitempty"`
+	Model          string     `json:"model,omitempty"`
+	Stream         *bool      `json:"stream,omitempty"`
+	BillingType    *int8      `json:"billing_type,omitempty"`
+	StartTime      *time.Time `json:"start_time,omitempty"`
+	EndTime        *time.Time `json:"end_time,omitempty"`
+	// TagKey/TagValue 按成本归属标签过滤：仅 TagKey 时匹配携带该标签的记录
+	TagKey   string `json:"tag_key,omitempty"`
+	TagValue string `json:"tag_value,omitempty"`
+}
+
+// TagUsageFilters 按标签分组统计的查询条件
+type TagUsageFilters struct {
+	UserID    int64
+	APIKeyID  int64
+	TagKey    string // 必填：分组使用的标签键
+	TagValue  string // 可选：仅统计该标签值
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// TagUsageStat 按标签值汇总的用量（成本分摊）
+type TagUsageStat struct {
+	TagValue     string  `json:"tag_value"`
+	Requests     int64   `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CacheTokens  int64   `json:"cache_tokens"`
+	TotalTokens  int64   `json:"total_tokens"`
+	Cost         float64 `json:"cost"`        // 标准计费
+	ActualCost   float64 `json:"actual_cost"` // 实际扣除
 }
 
 // UsageStats represents usage statistics
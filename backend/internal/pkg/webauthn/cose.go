@@ -0,0 +1,100 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+)
+
+// COSE 算法与密钥类型（RFC 9053）
+const (
+	coseAlgES256 int64 = -7
+	coseAlgEdDSA int64 = -8
+	coseAlgRS256 int64 = -257
+
+	coseKtyOKP int64 = 1
+	coseKtyEC2 int64 = 2
+	coseKtyRSA int64 = 3
+
+	coseCrvP256    int64 = 1
+	coseCrvEd25519 int64 = 6
+)
+
+type coseKey struct {
+	alg int64
+	pub crypto.PublicKey
+}
+
+// parseCOSEKey 解析凭据公钥，仅接受 ES256 / EdDSA(Ed25519) / RS256
+func parseCOSEKey(raw []byte) (*coseKey, error) {
+	v, rest, err := decodeCBOR(raw)
+	if err != nil || len(rest) != 0 {
+		return nil, verifyErr("invalid COSE key encoding")
+	}
+	m, ok := v.(map[any]any)
+	if !ok {
+		return nil, verifyErr("invalid COSE key")
+	}
+	kty, _ := m[int64(1)].(int64)
+	alg, _ := m[int64(3)].(int64)
+
+	switch {
+	case kty == coseKtyEC2 && alg == coseAlgES256:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		y, _ := m[int64(-3)].([]byte)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, verifyErr("invalid EC2 key")
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, verifyErr("EC2 point is not on curve")
+		}
+		return &coseKey{alg: alg, pub: pub}, nil
+	case kty == coseKtyOKP && alg == coseAlgEdDSA:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, verifyErr("invalid OKP key")
+		}
+		return &coseKey{alg: alg, pub: ed25519.PublicKey(x)}, nil
+	case kty == coseKtyRSA && alg == coseAlgRS256:
+		n, _ := m[int64(-1)].([]byte)
+		e, _ := m[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, verifyErr("invalid RSA key")
+		}
+		exp := 0
+		for _, b := range e {
+			exp = exp<<8 | int(b)
+		}
+		return &coseKey{alg: alg, pub: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}}, nil
+	}
+	return nil, verifyErr("unsupported COSE key type %d / alg %d", kty, alg)
+}
+
+func (k *coseKey) verify(message, signature []byte) error {
+	switch pub := k.pub.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		if !ecdsa.VerifyASN1(pub, digest[:], signature) {
+			return verifyErr("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, message, signature) {
+			return verifyErr("invalid signature")
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return verifyErr("invalid signature")
+		}
+	default:
+		return verifyErr("unsupported key")
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+// Package webauthn implements the relying-party side of WebAuthn (passkey) registration and
+// assertion verification using only the standard library.
+//
+// Attestation statements are not verified (attestation conveyance "none"): the service only
+// needs proof of possession of the credential key, not authenticator provenance.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Authenticator data flags (WebAuthn §6.1)
+const (
+	FlagUserPresent    byte = 0x01
+	FlagUserVerified   byte = 0x04
+	FlagBackupEligible byte = 0x08
+	FlagBackedUp       byte = 0x10
+	FlagAttestedData   byte = 0x40
+)
+
+// UserVerification 取值
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// DefaultTimeoutMillis 浏览器端 ceremony 超时
+const DefaultTimeoutMillis = 5 * 60 * 1000
+
+// ErrVerification 所有校验失败均包装此错误
+var ErrVerification = errors.New("webauthn verification failed")
+
+func verifyErr(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrVerification, fmt.Sprintf(format, args...))
+}
+
+// RelyingParty 依赖方配置
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// Credential 注册成功后需要持久化的凭据信息
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key 原始编码
+	SignCount      uint32
+	AAGUID         []byte
+	BackupEligible bool
+	BackedUp       bool
+	Transports     []string
+}
+
+// CredentialDescriptor PublicKeyCredentialDescriptor
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// CreationOptions PublicKeyCredentialCreationOptions（JSON 形式，二进制字段为 base64url）
+type CreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// CredentialParameter PublicKeyCredentialParameters
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// AuthenticatorSelection AuthenticatorSelectionCriteria
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// RequestOptions PublicKeyCredentialRequestOptions（JSON 形式）
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int                    `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationResponse 浏览器 PublicKeyCredential.toJSON() 的注册结果
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse 浏览器 PublicKeyCredential.toJSON() 的断言结果
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// CredentialID 解码断言中的凭据 ID
+func (r *AssertionResponse) CredentialID() ([]byte, error) {
+	return DecodeBase64URL(firstNonEmpty(r.RawID, r.ID))
+}
+
+// UserHandleBytes 解码断言中的 userHandle（可发现凭据登录时由认证器返回）
+func (r *AssertionResponse) UserHandleBytes() ([]byte, error) {
+	return DecodeBase64URL(r.Response.UserHandle)
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// NewChallenge 生成 32 字节随机挑战（base64url）
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return EncodeBase64URL(b), nil
+}
+
+// EncodeBase64URL 无填充 base64url 编码
+func EncodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeBase64URL 解码 base64url（容忍末尾填充）
+func DecodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(s), "="))
+}
+
+// SupportedAlgorithms 支持的 COSE 算法（优先级顺序）
+func SupportedAlgorithms() []CredentialParameter {
+	return []CredentialParameter{
+		{Type: "public-key", Alg: coseAlgES256},
+		{Type: "public-key", Alg: coseAlgEdDSA},
+		{Type: "public-key", Alg: coseAlgRS256},
+	}
+}
+
+// VerifyRegistration 校验注册响应（clientData、rpIdHash、flags 与凭据公钥），返回待保存的凭据
+func (rp *RelyingParty) VerifyRegistration(challenge string, resp *RegistrationResponse, requireUV bool) (*Credential, error) {
+	if resp == nil || resp.Type != "public-key" {
+		return nil, verifyErr("invalid credential type")
+	}
+	rawClientData, err := DecodeBase64URL(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, verifyErr("invalid clientDataJSON encoding")
+	}
+	if err := rp.verifyClientData(rawClientData, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := DecodeBase64URL(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, verifyErr("invalid attestationObject encoding")
+	}
+	obj, _, err := decodeCBOR(rawAttestation)
+	if err != nil {
+		return nil, verifyErr("invalid attestationObject: %v", err)
+	}
+	att, ok := obj.(map[any]any)
+	if !ok {
+		return nil, verifyErr("invalid attestationObject")
+	}
+	rawAuthData, ok := att["authData"].([]byte)
+	if !ok {
+		return nil, verifyErr("missing authData")
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData, requireUV); err != nil {
+		return nil, err
+	}
+	if authData.Flags&FlagAttestedData == 0 || len(authData.CredentialID) == 0 {
+		return nil, verifyErr("missing attested credential data")
+	}
+	if _, err := parseCOSEKey(authData.PublicKey); err != nil {
+		return nil, err
+	}
+	if rawID, err := DecodeBase64URL(firstNonEmpty(resp.RawID, resp.ID)); err != nil || !bytes.Equal(rawID, authData.CredentialID) {
+		return nil, verifyErr("credential id mismatch")
+	}
+
+	return &Credential{
+		ID:             authData.CredentialID,
+		PublicKey:      authData.PublicKey,
+		SignCount:      authData.SignCount,
+		AAGUID:         authData.AAGUID,
+		BackupEligible: authData.Flags&FlagBackupEligible != 0,
+		BackedUp:       authData.Flags&FlagBackedUp != 0,
+		Transports:     resp.Response.Transports,
+	}, nil
+}
+
+// VerifyAssertion 校验断言签名，返回新的签名计数；storedSignCount 非 0 且未递增时视为克隆的认证器
+func (rp *RelyingParty) VerifyAssertion(challenge string, resp *AssertionResponse, publicKey []byte, storedSignCount uint32, requireUV bool) (uint32, error) {
+	if resp == nil || resp.Type != "public-key" {
+		return 0, verifyErr("invalid credential type")
+	}
+	rawClientData, err := DecodeBase64URL(resp.Response.ClientDataJSON)
+	if err != nil {
+		return 0, verifyErr("invalid clientDataJSON encoding")
+	}
+	if err := rp.verifyClientData(rawClientData, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	rawAuthData, err := DecodeBase64URL(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, verifyErr("invalid authenticatorData encoding")
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.verifyAuthenticatorData(authData, requireUV); err != nil {
+		return 0, err
+	}
+	signature, err := DecodeBase64URL(resp.Response.Signature)
+	if err != nil {
+		return 0, verifyErr("invalid signature encoding")
+	}
+
+	key, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+	if err := key.verify(signed, signature); err != nil {
+		return 0, err
+	}
+
+	if authData.SignCount != 0 || storedSignCount != 0 {
+		if authData.SignCount <= storedSignCount {
+			return 0, verifyErr("signature counter did not increase, authenticator may be cloned")
+		}
+	}
+	return authData.SignCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony, challenge string) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return verifyErr("invalid clientDataJSON")
+	}
+	if cd.Type != ceremony {
+		return verifyErr("unexpected ceremony type %q", cd.Type)
+	}
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(strings.TrimRight(cd.Challenge, "=")), []byte(challenge)) != 1 {
+		return verifyErr("challenge mismatch")
+	}
+	if cd.CrossOrigin {
+		return verifyErr("cross-origin ceremonies are not allowed")
+	}
+	if !slices.Contains(rp.Origins, strings.TrimRight(cd.Origin, "/")) {
+		return verifyErr("origin %q is not allowed", cd.Origin)
+	}
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData *authenticatorData, requireUV bool) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(authData.RPIDHash, rpIDHash[:]) != 1 {
+		return verifyErr("rp id hash mismatch")
+	}
+	if authData.Flags&FlagUserPresent == 0 {
+		return verifyErr("user presence flag not set")
+	}
+	if requireUV && authData.Flags&FlagUserVerified == 0 {
+		return verifyErr("user verification required")
+	}
+	return nil
+}
+
+// parseAuthenticatorData 解析 authenticatorData（WebAuthn §6.1）
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, verifyErr("authenticator data too short")
+	}
+	ad := &authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if ad.Flags&FlagAttestedData == 0 {
+		return ad, nil
+	}
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, verifyErr("attested credential data too short")
+	}
+	ad.AAGUID = rest[:16]
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, verifyErr("invalid credential id length")
+	}
+	ad.CredentialID = rest[:idLen]
+	rest = rest[idLen:]
+	_, remaining, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, verifyErr("invalid credential public key: %v", err)
+	}
+	ad.PublicKey = rest[:len(rest)-len(remaining)]
+	return ad, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
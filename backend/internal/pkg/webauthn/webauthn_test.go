@@ -0,0 +1,245 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+)
+
+// cborEncode 测试用的最小 CBOR 编码器（仅覆盖 attestationObject / COSE key 需要的类型）
+func cborEncode(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 256:
+			return []byte{major<<5 | 24, byte(n)}
+		case n < 65536:
+			b := []byte{major<<5 | 25, 0, 0}
+			binary.BigEndian.PutUint16(b[1:], uint16(n))
+			return b
+		default:
+			b := []byte{major<<5 | 26, 0, 0, 0, 0}
+			binary.BigEndian.PutUint32(b[1:], uint32(n))
+			return b
+		}
+	}
+	switch x := v.(type) {
+	case int64:
+		if x >= 0 {
+			return head(0, uint64(x))
+		}
+		return head(1, uint64(-1-x))
+	case []byte:
+		return append(head(2, uint64(len(x))), x...)
+	case string:
+		return append(head(3, uint64(len(x))), x...)
+	case map[any]any:
+		keys := make([]any, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return string(cborEncode(keys[i])) < string(cborEncode(keys[j])) })
+		out := head(5, uint64(len(x)))
+		for _, k := range keys {
+			out = append(out, cborEncode(k)...)
+			out = append(out, cborEncode(x[k])...)
+		}
+		return out
+	}
+	panic("unsupported type")
+}
+
+type fakeAuthenticator struct {
+	rpID      string
+	credID    []byte
+	ecKey     *ecdsa.PrivateKey
+	edKey     ed25519.PrivateKey
+	signCount uint32
+}
+
+func newFakeAuthenticator(t *testing.T, rpID string, ed bool) *fakeAuthenticator {
+	a := &fakeAuthenticator{rpID: rpID, credID: []byte("credential-" + rpID)}
+	var err error
+	if ed {
+		_, a.edKey, err = ed25519.GenerateKey(rand.Reader)
+	} else {
+		a.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func (a *fakeAuthenticator) coseKey() []byte {
+	if a.edKey != nil {
+		return cborEncode(map[any]any{
+			int64(1): coseKtyOKP, int64(3): coseAlgEdDSA, int64(-1): coseCrvEd25519,
+			int64(-2): []byte(a.edKey.Public().(ed25519.PublicKey)),
+		})
+	}
+	x, y := make([]byte, 32), make([]byte, 32)
+	a.ecKey.X.FillBytes(x)
+	a.ecKey.Y.FillBytes(y)
+	return cborEncode(map[any]any{
+		int64(1): coseKtyEC2, int64(3): coseAlgES256, int64(-1): coseCrvP256,
+		int64(-2): x, int64(-3): y,
+	})
+}
+
+func (a *fakeAuthenticator) authData(flags byte, attested bool) []byte {
+	rpHash := sha256.Sum256([]byte(a.rpID))
+	out := append([]byte(nil), rpHash[:]...)
+	if attested {
+		flags |= FlagAttestedData
+	}
+	out = append(out, flags)
+	out = binary.BigEndian.AppendUint32(out, a.signCount)
+	if attested {
+		out = append(out, make([]byte, 16)...)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(a.credID)))
+		out = append(out, a.credID...)
+		out = append(out, a.coseKey()...)
+	}
+	return out
+}
+
+func clientDataJSON(t *testing.T, typ, challenge, origin string) []byte {
+	b, err := json.Marshal(map[string]any{"type": typ, "challenge": challenge, "origin": origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func (a *fakeAuthenticator) register(t *testing.T, challenge, origin string) *RegistrationResponse {
+	resp := &RegistrationResponse{ID: EncodeBase64URL(a.credID), RawID: EncodeBase64URL(a.credID), Type: "public-key"}
+	resp.Response.ClientDataJSON = EncodeBase64URL(clientDataJSON(t, "webauthn.create", challenge, origin))
+	resp.Response.AttestationObject = EncodeBase64URL(cborEncode(map[any]any{
+		"fmt":      "none",
+		"attStmt":  map[any]any{},
+		"authData": a.authData(FlagUserPresent|FlagUserVerified, true),
+	}))
+	resp.Response.Transports = []string{"internal"}
+	return resp
+}
+
+func (a *fakeAuthenticator) assert(t *testing.T, challenge, origin string, flags byte) *AssertionResponse {
+	a.signCount++
+	cd := clientDataJSON(t, "webauthn.get", challenge, origin)
+	ad := a.authData(flags, false)
+	hash := sha256.Sum256(cd)
+	msg := append(append([]byte(nil), ad...), hash[:]...)
+
+	var sig []byte
+	if a.edKey != nil {
+		sig = ed25519.Sign(a.edKey, msg)
+	} else {
+		digest := sha256.Sum256(msg)
+		var err error
+		if sig, err = ecdsa.SignASN1(rand.Reader, a.ecKey, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp := &AssertionResponse{ID: EncodeBase64URL(a.credID), RawID: EncodeBase64URL(a.credID), Type: "public-key"}
+	resp.Response.ClientDataJSON = EncodeBase64URL(cd)
+	resp.Response.AuthenticatorData = EncodeBase64URL(ad)
+	resp.Response.Signature = EncodeBase64URL(sig)
+	return resp
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	rp := &RelyingParty{ID: "example.com", Name: "Example", Origins: []string{"https://example.com"}}
+	for _, ed := range []bool{false, true} {
+		auth := newFakeAuthenticator(t, "example.com", ed)
+
+		cred, err := rp.VerifyRegistration("reg-challenge", auth.register(t, "reg-challenge", "https://example.com"), true)
+		if err != nil {
+			t.Fatalf("registration (ed25519=%v): %v", ed, err)
+		}
+		if string(cred.ID) != string(auth.credID) || len(cred.AAGUID) != 16 || cred.Transports[0] != "internal" {
+			t.Fatalf("unexpected credential: %+v", cred)
+		}
+
+		count, err := rp.VerifyAssertion("login-challenge", auth.assert(t, "login-challenge", "https://example.com", FlagUserPresent|FlagUserVerified), cred.PublicKey, cred.SignCount, true)
+		if err != nil {
+			t.Fatalf("assertion (ed25519=%v): %v", ed, err)
+		}
+		if count != 1 {
+			t.Fatalf("sign count = %d, want 1", count)
+		}
+
+		// 签名计数未递增视为克隆
+		auth.signCount = 0
+		if _, err := rp.VerifyAssertion("c", auth.assert(t, "c", "https://example.com", FlagUserPresent), cred.PublicKey, 5, false); !errors.Is(err, ErrVerification) {
+			t.Fatalf("expected counter regression error, got %v", err)
+		}
+	}
+}
+
+func TestVerificationFailures(t *testing.T) {
+	rp := &RelyingParty{ID: "example.com", Origins: []string{"https://example.com"}}
+	auth := newFakeAuthenticator(t, "example.com", false)
+	cred, err := rp.VerifyRegistration("c1", auth.register(t, "c1", "https://example.com"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]func() error{
+		"challenge mismatch": func() error {
+			_, err := rp.VerifyRegistration("c2", auth.register(t, "c1", "https://example.com"), false)
+			return err
+		},
+		"origin mismatch": func() error {
+			_, err := rp.VerifyAssertion("c", auth.assert(t, "c", "https://evil.example.net", FlagUserPresent), cred.PublicKey, 0, false)
+			return err
+		},
+		"rp id mismatch": func() error {
+			other := newFakeAuthenticator(t, "evil.com", false)
+			_, err := rp.VerifyRegistration("c", other.register(t, "c", "https://example.com"), false)
+			return err
+		},
+		"user verification required": func() error {
+			_, err := rp.VerifyAssertion("c", auth.assert(t, "c", "https://example.com", FlagUserPresent), cred.PublicKey, 0, true)
+			return err
+		},
+		"bad signature": func() error {
+			resp := auth.assert(t, "c", "https://example.com", FlagUserPresent)
+			resp.Response.Signature = EncodeBase64URL([]byte("not a signature"))
+			_, err := rp.VerifyAssertion("c", resp, cred.PublicKey, 0, false)
+			return err
+		},
+		"wrong ceremony type": func() error {
+			resp := auth.assert(t, "c", "https://example.com", FlagUserPresent)
+			resp.Response.ClientDataJSON = EncodeBase64URL(clientDataJSON(t, "webauthn.create", "c", "https://example.com"))
+			_, err := rp.VerifyAssertion("c", resp, cred.PublicKey, 0, false)
+			return err
+		},
+	}
+	for name, fn := range cases {
+		if err := fn(); !errors.Is(err, ErrVerification) {
+			t.Errorf("%s: expected verification error, got %v", name, err)
+		}
+	}
+}
+
+func TestDecodeCBORRejectsMalformedInput(t *testing.T) {
+	for _, data := range [][]byte{
+		{},
+		{0x5f},             // indefinite byte string
+		{0x59, 0xff, 0xff}, // truncated byte string
+		{0xa1, 0x80, 0x01}, // array as map key
+	} {
+		if _, _, err := decodeCBOR(data); err == nil {
+			t.Errorf("expected error for %x", data)
+		}
+	}
+}
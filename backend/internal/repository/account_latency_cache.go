@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	accountLatencyKeyPrefix = "account:latency:"
+	// accountLatencyAlpha EWMA 平滑系数，越大越偏向最近样本
+	accountLatencyAlpha = 0.2
+	// accountLatencyTTL 长时间无请求的账号统计自动过期，避免陈旧数据长期影响调度
+	accountLatencyTTL = 30 * time.Minute
+)
+
+func accountLatencyKey(accountID int64) string {
+	return fmt.Sprintf("%s%d", accountLatencyKeyPrefix, accountID)
+}
+
+// accountLatencyUpdateScript 原子更新 EWMA 统计
+// KEYS[1] = hash key
+// ARGV[1] = alpha, ARGV[2] = first_token_ms（<0 表示无）, ARGV[3] = duration_ms（<0 表示无）
+// ARGV[4] = failed(0/1), ARGV[5] = ttl seconds
+var accountLatencyUpdateScript = redis.NewScript(`
+	local key = KEYS[1]
+	local alpha = tonumber(ARGV[1])
+	local ttft = tonumber(ARGV[2])
+	local dur = tonumber(ARGV[3])
+	local failed = tonumber(ARGV[4])
+	local ttl = tonumber(ARGV[5])
+
+	local function ewma(field, sample)
+		local cur = redis.call('HGET', key, field)
+		if not cur then
+			redis.call('HSET', key, field, tostring(sample))
+			return
+		end
+		cur = tonumber(cur)
+		redis.call('HSET', key, field, tostring(cur + alpha * (sample - cur)))
+	end
+
+	if ttft >= 0 then
+		ewma('ttft', ttft)
+	end
+	if dur >= 0 then
+		ewma('dur', dur)
+	end
+	ewma('err', failed)
+	redis.call('HINCRBY', key, 'n', 1)
+	redis.call('EXPIRE', key, ttl)
+	return 1
+`)
+
+type accountLatencyCache struct {
+	rdb *redis.Client
+}
+
+// NewAccountLatencyCache 创建账号延迟统计缓存
+func NewAccountLatencyCache(rdb *redis.Client) service.AccountLatencyCache {
+	return &accountLatencyCache{rdb: rdb}
+}
+
+func (c *accountLatencyCache) RecordAccountLatency(ctx context.Context, accountID int64, sample service.AccountLatencySample) error {
+	ttft, dur, failed := -1, -1, 0
+	if sample.FirstTokenMs != nil && *sample.FirstTokenMs >= 0 {
+		ttft = *sample.FirstTokenMs
+	}
+	if sample.DurationMs != nil && *sample.DurationMs >= 0 {
+		dur = *sample.DurationMs
+	}
+	if sample.Failed {
+		failed = 1
+	}
+	err := accountLatencyUpdateScript.Run(ctx, c.rdb, []string{accountLatencyKey(accountID)},
+		accountLatencyAlpha, ttft, dur, failed, int(accountLatencyTTL.Seconds())).Err()
+	if err != nil {
+		return fmt.Errorf("record account latency: %w", err)
+	}
+	return nil
+}
+
+func (c *accountLatencyCache) GetAccountLatencyStats(ctx context.Context, accountIDs []int64) (map[int64]*service.AccountLatencyStats, error) {
+	result := make(map[int64]*service.AccountLatencyStats, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return result, nil
+	}
+
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(accountIDs))
+	for i, id := range accountIDs {
+		cmds[i] = pipe.HMGet(ctx, accountLatencyKey(id), "ttft", "dur", "err", "n")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("get account latency stats: %w", err)
+	}
+
+	for i, cmd := range cmds {
+		vals, err := cmd.Result()
+		if err != nil || len(vals) != 4 || vals[3] == nil {
+			continue
+		}
+		result[accountIDs[i]] = &service.AccountLatencyStats{
+			FirstTokenMs: parseRedisFloat(vals[0]),
+			DurationMs:   parseRedisFloat(vals[1]),
+			ErrorRate:    parseRedisFloat(vals[2]),
+			Samples:      int64(parseRedisFloat(vals[3])),
+		}
+	}
+	return result, nil
+}
+
+func parseRedisFloat(v any) float64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
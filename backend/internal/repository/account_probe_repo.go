@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type accountProbeRepository struct {
+	sql sqlExecutor
+}
+
+// NewAccountProbeRepository 创建账号健康探测仓储
+func NewAccountProbeRepository(sqlDB *sql.DB) service.AccountProbeRepository {
+	return &accountProbeRepository{sql: sqlDB}
+}
+
+// InsertResult 写入一条探测结果
+func (r *accountProbeRepository) InsertResult(ctx context.Context, result *service.AccountProbeResult) error {
+	if result == nil {
+		return nil
+	}
+	createdAt := result.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	query := `
+		INSERT INTO account_probe_results (account_id, platform, model, success, latency_ms, error_message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	return scanSingleRow(ctx, r.sql, query, []any{
+		result.AccountID,
+		result.Platform,
+		result.Model,
+		result.Success,
+		result.LatencyMs,
+		opsNullString(result.ErrorMessage),
+		createdAt,
+	}, &result.ID)
+}
+
+// ListResults 按时间倒序返回账号最近的探测结果
+func (r *accountProbeRepository) ListResults(ctx context.Context, accountID int64, limit int) ([]service.AccountProbeResult, error) {
+	query := `
+		SELECT id, account_id, platform, model, success, latency_ms, COALESCE(error_message, ''), created_at
+		FROM account_probe_results
+		WHERE account_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.sql.QueryContext(ctx, query, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.AccountProbeResult, 0)
+	for rows.Next() {
+		var item service.AccountProbeResult
+		if err := rows.Scan(
+			&item.ID,
+			&item.AccountID,
+			&item.Platform,
+			&item.Model,
+			&item.Success,
+			&item.LatencyMs,
+			&item.ErrorMessage,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const accountProbeStateColumns = `
+	account_id, consecutive_failures, consecutive_successes, last_probe_at, last_success,
+	last_latency_ms, COALESCE(last_error, ''), auto_action, auto_action_at
+`
+
+func scanAccountProbeState(scan func(dest ...any) error) (*service.AccountProbeState, error) {
+	var (
+		state        service.AccountProbeState
+		lastProbeAt  sql.NullTime
+		autoActionAt sql.NullTime
+	)
+	if err := scan(
+		&state.AccountID,
+		&state.ConsecutiveFailures,
+		&state.ConsecutiveSuccesses,
+		&lastProbeAt,
+		&state.LastSuccess,
+		&state.LastLatencyMs,
+		&state.LastError,
+		&state.AutoAction,
+		&autoActionAt,
+	); err != nil {
+		return nil, err
+	}
+	if lastProbeAt.Valid {
+		t := lastProbeAt.Time
+		state.LastProbeAt = &t
+	}
+	if autoActionAt.Valid {
+		t := autoActionAt.Time
+		state.AutoActionAt = &t
+	}
+	return &state, nil
+}
+
+// GetState 获取账号探测状态，不存在时返回 nil
+func (r *accountProbeRepository) GetState(ctx context.Context, accountID int64) (*service.AccountProbeState, error) {
+	query := `SELECT ` + accountProbeStateColumns + ` FROM account_probe_states WHERE account_id = $1`
+	rows, err := r.sql.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	state, err := scanAccountProbeState(rows.Scan)
+	if err != nil {
+		return nil, err
+	}
+	return state, rows.Err()
+}
+
+// ListStates 列出所有账号的探测状态
+func (r *accountProbeRepository) ListStates(ctx context.Context) ([]service.AccountProbeState, error) {
+	query := `SELECT ` + accountProbeStateColumns + ` FROM account_probe_states ORDER BY account_id`
+	rows, err := r.sql.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.AccountProbeState, 0)
+	for rows.Next() {
+		state, err := scanAccountProbeState(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *state)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpsertState 写入或更新账号探测状态
+func (r *accountProbeRepository) UpsertState(ctx context.Context, state *service.AccountProbeState) error {
+	if state == nil {
+		return errors.New("nil probe state")
+	}
+	query := `
+		INSERT INTO account_probe_states (
+			account_id, consecutive_failures, consecutive_successes, last_probe_at, last_success,
+			last_latency_ms, last_error, auto_action, auto_action_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		ON CONFLICT (account_id) DO UPDATE SET
+			consecutive_failures = EXCLUDED.consecutive_failures,
+			consecutive_successes = EXCLUDED.consecutive_successes,
+			last_probe_at = EXCLUDED.last_probe_at,
+			last_success = EXCLUDED.last_success,
+			last_latency_ms = EXCLUDED.last_latency_ms,
+			last_error = EXCLUDED.last_error,
+			auto_action = EXCLUDED.auto_action,
+			auto_action_at = EXCLUDED.auto_action_at,
+			updated_at = NOW()
+	`
+	_, err := r.sql.ExecContext(ctx, query,
+		state.AccountID,
+		state.ConsecutiveFailures,
+		state.ConsecutiveSuccesses,
+		opsNullTime(state.LastProbeAt),
+		state.LastSuccess,
+		state.LastLatencyMs,
+		opsNullString(state.LastError),
+		state.AutoAction,
+		opsNullTime(state.AutoActionAt),
+	)
+	return err
+}
+
+// DeleteResultsBefore 删除早于 cutoff 的探测历史
+func (r *accountProbeRepository) DeleteResultsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := r.sql.ExecContext(ctx, `DELETE FROM account_probe_results WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
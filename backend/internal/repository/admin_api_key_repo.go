@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type adminAPIKeyRepository struct {
+	sql sqlExecutor
+}
+
+// NewAdminAPIKeyRepository 创建管理员 API Key 仓储
+func NewAdminAPIKeyRepository(sqlDB *sql.DB) service.AdminAPIKeyRepository {
+	return &adminAPIKeyRepository{sql: sqlDB}
+}
+
+const adminAPIKeySelectColumns = `
+	id, name, key_hash, key_prefix, role, permissions, ip_whitelist, status,
+	expires_at, last_used_at, last_used_ip, created_by, revoked_at, created_at, updated_at
+`
+
+func (r *adminAPIKeyRepository) Create(ctx context.Context, key *service.AdminAPIKey) error {
+	perms, whitelist, err := marshalAdminAPIKeyLists(key)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO admin_api_keys (
+			name, key_hash, key_prefix, role, permissions, ip_whitelist, status,
+			expires_at, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		RETURNING id, created_at, updated_at
+	`
+	err = scanSingleRow(ctx, r.sql, query, []any{
+		key.Name,
+		key.KeyHash,
+		key.KeyPrefix,
+		key.Role,
+		perms,
+		whitelist,
+		key.Status,
+		opsNullTime(key.ExpiresAt),
+		key.CreatedBy,
+		time.Now(),
+	}, &key.ID, &key.CreatedAt, &key.UpdatedAt)
+	return translatePersistenceError(err, nil, service.ErrAdminAPIKeyExists)
+}
+
+func (r *adminAPIKeyRepository) GetByID(ctx context.Context, id int64) (*service.AdminAPIKey, error) {
+	return r.getOne(ctx, "SELECT "+adminAPIKeySelectColumns+" FROM admin_api_keys WHERE id = $1", id)
+}
+
+func (r *adminAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*service.AdminAPIKey, error) {
+	return r.getOne(ctx, "SELECT "+adminAPIKeySelectColumns+" FROM admin_api_keys WHERE key_hash = $1", keyHash)
+}
+
+func (r *adminAPIKeyRepository) getOne(ctx context.Context, query string, arg any) (*service.AdminAPIKey, error) {
+	rows, err := r.sql.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrAdminAPIKeyNotFound
+	}
+	key, err := scanAdminAPIKey(rows)
+	if err != nil {
+		return nil, err
+	}
+	return key, rows.Err()
+}
+
+func (r *adminAPIKeyRepository) List(ctx context.Context) ([]service.AdminAPIKey, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+adminAPIKeySelectColumns+" FROM admin_api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.AdminAPIKey, 0)
+	for rows.Next() {
+		key, err := scanAdminAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *adminAPIKeyRepository) Update(ctx context.Context, key *service.AdminAPIKey) error {
+	perms, whitelist, err := marshalAdminAPIKeyLists(key)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE admin_api_keys
+		SET name = $2, role = $3, permissions = $4, ip_whitelist = $5, expires_at = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err = scanSingleRow(ctx, r.sql, query, []any{
+		key.ID,
+		key.Name,
+		key.Role,
+		perms,
+		whitelist,
+		opsNullTime(key.ExpiresAt),
+	}, &key.UpdatedAt)
+	return translatePersistenceError(err, service.ErrAdminAPIKeyNotFound, service.ErrAdminAPIKeyExists)
+}
+
+func (r *adminAPIKeyRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	res, err := r.sql.ExecContext(ctx, `
+		UPDATE admin_api_keys
+		SET status = $2, revoked_at = COALESCE(revoked_at, $3), updated_at = NOW()
+		WHERE id = $1
+	`, id, service.AdminAPIKeyStatusRevoked, at)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, service.ErrAdminAPIKeyNotFound)
+}
+
+func (r *adminAPIKeyRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM admin_api_keys WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, service.ErrAdminAPIKeyNotFound)
+}
+
+func (r *adminAPIKeyRepository) TouchLastUsed(ctx context.Context, id int64, ip string, at time.Time) error {
+	_, err := r.sql.ExecContext(ctx, `UPDATE admin_api_keys SET last_used_at = $2, last_used_ip = $3 WHERE id = $1`, id, at, ip)
+	return err
+}
+
+func requireAffected(res sql.Result, notFound error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func marshalAdminAPIKeyLists(key *service.AdminAPIKey) ([]byte, []byte, error) {
+	perms, err := json.Marshal(nonNilStrings(key.Permissions))
+	if err != nil {
+		return nil, nil, err
+	}
+	whitelist, err := json.Marshal(nonNilStrings(key.IPWhitelist))
+	if err != nil {
+		return nil, nil, err
+	}
+	return perms, whitelist, nil
+}
+
+func scanAdminAPIKey(scanner interface{ Scan(...any) error }) (*service.AdminAPIKey, error) {
+	var (
+		key        service.AdminAPIKey
+		perms      []byte
+		whitelist  []byte
+		expiresAt  sql.NullTime
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+	if err := scanner.Scan(
+		&key.ID,
+		&key.Name,
+		&key.KeyHash,
+		&key.KeyPrefix,
+		&key.Role,
+		&perms,
+		&whitelist,
+		&key.Status,
+		&expiresAt,
+		&lastUsedAt,
+		&key.LastUsedIP,
+		&key.CreatedBy,
+		&revokedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	key.Permissions = []string{}
+	key.IPWhitelist = []string{}
+	if len(perms) > 0 {
+		if err := json.Unmarshal(perms, &key.Permissions); err != nil {
+			return nil, err
+		}
+	}
+	if len(whitelist) > 0 {
+		if err := json.Unmarshal(whitelist, &key.IPWhitelist); err != nil {
+			return nil, err
+		}
+	}
+	if expiresAt.Valid {
+		t := expiresAt.Time
+		key.ExpiresAt = &t
+	}
+	if lastUsedAt.Valid {
+		t := lastUsedAt.Time
+		key.LastUsedAt = &t
+	}
+	if revokedAt.Valid {
+		t := revokedAt.Time
+		key.RevokedAt = &t
+	}
+	return &key, nil
+}
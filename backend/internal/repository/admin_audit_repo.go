@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type adminAuditLogRepository struct {
+	sql sqlExecutor
+}
+
+// NewAdminAuditLogRepository 创建管理操作审计日志仓储
+func NewAdminAuditLogRepository(sqlDB *sql.DB) service.AdminAuditLogRepository {
+	return &adminAuditLogRepository{sql: sqlDB}
+}
+
+const adminAuditLogSelectColumns = `
+	id, actor_user_id, actor_api_key_id, actor_api_key_name, auth_method, ip,
+	method, path, action, resource, target_id, status_code,
+	request, before, after, diff, created_at
+`
+
+func adminAuditNullJSON(v any) (any, error) {
+	if v == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func (r *adminAuditLogRepository) Insert(ctx context.Context, entry *service.AdminAuditLog) error {
+	if entry == nil {
+		return nil
+	}
+	request, err := adminAuditNullJSON(entry.Request)
+	if err != nil {
+		return err
+	}
+	before, err := opsNullJSONMap(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := opsNullJSONMap(entry.After)
+	if err != nil {
+		return err
+	}
+	var diff any = sql.NullString{}
+	if entry.Diff != nil {
+		if diff, err = adminAuditNullJSON(entry.Diff); err != nil {
+			return err
+		}
+	}
+	query := `
+		INSERT INTO admin_audit_logs (
+			actor_user_id, actor_api_key_id, actor_api_key_name, auth_method, ip,
+			method, path, action, resource, target_id, status_code,
+			request, before, after, diff, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING id
+	`
+	return scanSingleRow(ctx, r.sql, query, []any{
+		opsNullInt64(entry.ActorUserID),
+		opsNullInt64(entry.ActorAPIKeyID),
+		entry.ActorAPIKeyName,
+		entry.AuthMethod,
+		entry.IP,
+		entry.Method,
+		entry.Path,
+		entry.Action,
+		entry.Resource,
+		entry.TargetID,
+		entry.StatusCode,
+		request,
+		before,
+		after,
+		diff,
+		entry.CreatedAt,
+	}, &entry.ID)
+}
+
+func (r *adminAuditLogRepository) GetByID(ctx context.Context, id int64) (*service.AdminAuditLog, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+adminAuditLogSelectColumns+" FROM admin_audit_logs WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrAdminAuditLogNotFound
+	}
+	entry, err := scanAdminAuditLog(rows)
+	if err != nil {
+		return nil, err
+	}
+	return entry, rows.Err()
+}
+
+func (r *adminAuditLogRepository) List(ctx context.Context, filter service.AdminAuditLogFilter, params pagination.PaginationParams) ([]service.AdminAuditLog, *pagination.PaginationResult, error) {
+	where, args := buildAdminAuditLogWhere(filter)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM admin_audit_logs "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.AdminAuditLog{}, paginationResultFromTotal(0, params), nil
+	}
+
+	args = append(args, params.Limit(), params.Offset())
+	query := fmt.Sprintf(
+		"SELECT %s FROM admin_audit_logs %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		adminAuditLogSelectColumns, where, len(args)-1, len(args),
+	)
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.AdminAuditLog, 0)
+	for rows.Next() {
+		entry, err := scanAdminAuditLog(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func buildAdminAuditLogWhere(filter service.AdminAuditLogFilter) (string, []any) {
+	clauses := []string{"1=1"}
+	args := []any{}
+
+	if filter.ActorUserID > 0 {
+		args = append(args, filter.ActorUserID)
+		clauses = append(clauses, "actor_user_id = $"+itoa(len(args)))
+	}
+	if filter.APIKeyID != nil {
+		args = append(args, *filter.APIKeyID)
+		clauses = append(clauses, "actor_api_key_id = $"+itoa(len(args)))
+	}
+	if resource := strings.TrimSpace(filter.Resource); resource != "" {
+		args = append(args, resource)
+		clauses = append(clauses, "resource = $"+itoa(len(args)))
+	}
+	if action := strings.TrimSpace(filter.Action); action != "" {
+		args = append(args, action)
+		clauses = append(clauses, "action = $"+itoa(len(args)))
+	}
+	if targetID := strings.TrimSpace(filter.TargetID); targetID != "" {
+		args = append(args, targetID)
+		clauses = append(clauses, "target_id = $"+itoa(len(args)))
+	}
+	if filter.StartTime != nil && !filter.StartTime.IsZero() {
+		args = append(args, *filter.StartTime)
+		clauses = append(clauses, "created_at >= $"+itoa(len(args)))
+	}
+	if filter.EndTime != nil && !filter.EndTime.IsZero() {
+		args = append(args, *filter.EndTime)
+		clauses = append(clauses, "created_at < $"+itoa(len(args)))
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func scanAdminAuditLog(rows *sql.Rows) (*service.AdminAuditLog, error) {
+	var (
+		entry                          service.AdminAuditLog
+		actorUserID, actorAPIKeyID     sql.NullInt64
+		request, before, after, diffJS []byte
+	)
+	if err := rows.Scan(
+		&entry.ID,
+		&actorUserID,
+		&actorAPIKeyID,
+		&entry.ActorAPIKeyName,
+		&entry.AuthMethod,
+		&entry.IP,
+		&entry.Method,
+		&entry.Path,
+		&entry.Action,
+		&entry.Resource,
+		&entry.TargetID,
+		&entry.StatusCode,
+		&request,
+		&before,
+		&after,
+		&diffJS,
+		&entry.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if actorUserID.Valid {
+		v := actorUserID.Int64
+		entry.ActorUserID = &v
+	}
+	if actorAPIKeyID.Valid {
+		v := actorAPIKeyID.Int64
+		entry.ActorAPIKeyID = &v
+	}
+	for _, item := range []struct {
+		raw  []byte
+		dest any
+	}{
+		{request, &entry.Request},
+		{before, &entry.Before},
+		{after, &entry.After},
+		{diffJS, &entry.Diff},
+	} {
+		if len(item.raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(item.raw, item.dest); err != nil {
+			return nil, fmt.Errorf("parse audit log %d: %w", entry.ID, err)
+		}
+	}
+	return &entry, nil
+}
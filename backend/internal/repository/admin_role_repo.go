@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type adminRoleRepository struct {
+	sql sqlExecutor
+}
+
+// NewAdminRoleRepository 创建自定义管理员角色仓储
+func NewAdminRoleRepository(sqlDB *sql.DB) service.AdminRoleRepository {
+	return &adminRoleRepository{sql: sqlDB}
+}
+
+const adminRoleSelectColumns = `id, name, description, permissions, created_at, updated_at`
+
+func (r *adminRoleRepository) Create(ctx context.Context, role *service.AdminRole) error {
+	perms, err := json.Marshal(nonNilStrings(role.Permissions))
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO admin_roles (name, description, permissions, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		RETURNING id, created_at, updated_at
+	`
+	err = scanSingleRow(ctx, r.sql, query, []any{role.Name, role.Description, perms, time.Now()},
+		&role.ID, &role.CreatedAt, &role.UpdatedAt)
+	return translatePersistenceError(err, nil, service.ErrAdminRoleExists)
+}
+
+func (r *adminRoleRepository) GetByID(ctx context.Context, id int64) (*service.AdminRole, error) {
+	return r.getOne(ctx, "SELECT "+adminRoleSelectColumns+" FROM admin_roles WHERE id = $1", id)
+}
+
+func (r *adminRoleRepository) GetByName(ctx context.Context, name string) (*service.AdminRole, error) {
+	return r.getOne(ctx, "SELECT "+adminRoleSelectColumns+" FROM admin_roles WHERE name = $1", name)
+}
+
+func (r *adminRoleRepository) getOne(ctx context.Context, query string, arg any) (*service.AdminRole, error) {
+	rows, err := r.sql.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrAdminRoleNotFound
+	}
+	role, err := scanAdminRole(rows)
+	if err != nil {
+		return nil, err
+	}
+	return role, rows.Err()
+}
+
+func (r *adminRoleRepository) Update(ctx context.Context, role *service.AdminRole) error {
+	perms, err := json.Marshal(nonNilStrings(role.Permissions))
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE admin_roles
+		SET description = $2, permissions = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err = scanSingleRow(ctx, r.sql, query, []any{role.ID, role.Description, perms}, &role.UpdatedAt)
+	return translatePersistenceError(err, service.ErrAdminRoleNotFound, nil)
+}
+
+func (r *adminRoleRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM admin_roles WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrAdminRoleNotFound
+	}
+	return nil
+}
+
+func (r *adminRoleRepository) List(ctx context.Context) ([]service.AdminRole, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+adminRoleSelectColumns+" FROM admin_roles ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.AdminRole, 0)
+	for rows.Next() {
+		role, err := scanAdminRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *adminRoleRepository) CountAssignedUsers(ctx context.Context, name string) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM users WHERE role = $1 AND admin_role = $2 AND deleted_at IS NULL`
+	if err := scanSingleRow(ctx, r.sql, query, []any{service.RoleAdmin, name}, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func scanAdminRole(scanner interface{ Scan(...any) error }) (*service.AdminRole, error) {
+	var (
+		role  service.AdminRole
+		perms []byte
+	)
+	if err := scanner.Scan(&role.ID, &role.Name, &role.Description, &perms, &role.CreatedAt, &role.UpdatedAt); err != nil {
+		return nil, err
+	}
+	role.Permissions = []string{}
+	if len(perms) > 0 {
+		if err := json.Unmarshal(perms, &role.Permissions); err != nil {
+			return nil, err
+		}
+	}
+	return &role, nil
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -7,6 +7,7 @@ import (
 	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/ent/apikey"
 	"github.com/Wei-Shaw/sub2api/ent/group"
+	"github.com/Wei-Shaw/sub2api/ent/predicate"
 	"github.com/Wei-Shaw/sub2api/ent/schema/mixins"
 	"github.com/Wei-Shaw/sub2api/ent/user"
 	"github.com/Wei-Shaw/sub2api/internal/service"
@@ -36,7 +37,10 @@ func (r *apiKeyRepository) Create(ctx context.Context, key *service.APIKey) erro
 		SetNillableGroupID(key.GroupID).
 		SetQuota(key.Quota).
 		SetQuotaUsed(key.QuotaUsed).
-		SetNillableExpiresAt(key.ExpiresAt)
+		SetNillableExpiresAt(key.ExpiresAt).
+		SetNillableDailyLimitUsd(key.DailyLimitUSD).
+		SetNillableWeeklyLimitUsd(key.WeeklyLimitUSD).
+		SetNillableMonthlyLimitUsd(key.MonthlyLimitUSD)
 
 	if len(key.IPWhitelist) > 0 {
 		builder.SetIPWhitelist(key.IPWhitelist)
@@ -44,6 +48,10 @@ func (r *apiKeyRepository) Create(ctx context.Context, key *service.APIKey) erro
 	if len(key.IPBlacklist) > 0 {
 		builder.SetIPBlacklist(key.IPBlacklist)
 	}
+	if len(key.AllowedTagKeys) > 0 {
+		builder.SetAllowedTagKeys(key.AllowedTagKeys)
+	}
+	builder.SetNillableOrganizationID(key.OrganizationID)
 
 	created, err := builder.Save(ctx)
 	if err == nil {
@@ -105,9 +113,12 @@ func (r *apiKeyRepository) GetByKey(ctx context.Context, key string) (*service.A
 
 func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*service.APIKey, error) {
 	m, err := r.activeQuery().
-		Where(apikey.KeyEQ(key)).
+		Where(matchKeyOrActivePreviousKey(key, time.Now())).
 		Select(
 			apikey.FieldID,
+			apikey.FieldKey,
+			apikey.FieldKeyGeneration,
+			apikey.FieldPreviousKeyExpiresAt,
 			apikey.FieldUserID,
 			apikey.FieldGroupID,
 			apikey.FieldStatus,
@@ -116,6 +127,11 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 			apikey.FieldQuota,
 			apikey.FieldQuotaUsed,
 			apikey.FieldExpiresAt,
+			apikey.FieldDailyLimitUsd,
+			apikey.FieldWeeklyLimitUsd,
+			apikey.FieldMonthlyLimitUsd,
+			apikey.FieldAllowedTagKeys,
+			apikey.FieldOrganizationID,
 		).
 		WithUser(func(q *dbent.UserQuery) {
 			q.Select(
@@ -124,6 +140,7 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 				user.FieldRole,
 				user.FieldBalance,
 				user.FieldConcurrency,
+				user.FieldAllowedTagKeys,
 			)
 		}).
 		WithGroup(func(q *dbent.GroupQuery) {
@@ -147,6 +164,10 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 				group.FieldModelRouting,
 				group.FieldMcpXMLInject,
 				group.FieldSupportedModelScopes,
+				group.FieldSelectionStrategy,
+				group.FieldFairShareEnabled,
+				group.FieldModelFallback,
+				group.FieldModelFallbackEnabled,
 			)
 		}).
 		Only(ctx)
@@ -156,7 +177,46 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 		}
 		return nil, err
 	}
-	return apiKeyEntityToService(m), nil
+	out := apiKeyEntityToService(m)
+	out.SecretGeneration = m.KeyGeneration
+	if m.Key != key {
+		// 命中宽限期内的旧密钥
+		out.SecretGeneration = m.KeyGeneration - 1
+		out.SecretExpiresAt = m.PreviousKeyExpiresAt
+	}
+	return out, nil
+}
+
+// matchKeyOrActivePreviousKey 匹配当前密钥，或仍在轮换宽限期内的旧密钥
+func matchKeyOrActivePreviousKey(key string, now time.Time) predicate.APIKey {
+	return apikey.Or(
+		apikey.KeyEQ(key),
+		apikey.And(apikey.PreviousKeyEQ(key), apikey.PreviousKeyExpiresAtGT(now)),
+	)
+}
+
+// Rotate 签发新密钥：当前密钥转为旧密钥并设置失效时间（nil 表示立即失效），代数加 1。
+// expectedKey 用于乐观并发控制，避免并发轮换覆盖彼此的结果。
+func (r *apiKeyRepository) Rotate(ctx context.Context, id int64, expectedKey, newKey string, previousExpiresAt *time.Time, rotatedAt time.Time) error {
+	builder := r.client.APIKey.Update().
+		Where(apikey.IDEQ(id), apikey.KeyEQ(expectedKey), apikey.DeletedAtIsNil()).
+		SetKey(newKey).
+		AddKeyGeneration(1).
+		SetRotatedAt(rotatedAt).
+		SetUpdatedAt(rotatedAt)
+	if previousExpiresAt != nil {
+		builder.SetPreviousKey(expectedKey).SetPreviousKeyExpiresAt(*previousExpiresAt)
+	} else {
+		builder.ClearPreviousKey().ClearPreviousKeyExpiresAt()
+	}
+	affected, err := builder.Save(ctx)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrAPIKeyNotFound
+	}
+	return nil
 }
 
 func (r *apiKeyRepository) Update(ctx context.Context, key *service.APIKey) error {
@@ -186,6 +246,23 @@ func (r *apiKeyRepository) Update(ctx context.Context, key *service.APIKey) erro
 		builder.ClearExpiresAt()
 	}
 
+	// 滚动消费上限
+	if key.DailyLimitUSD != nil {
+		builder.SetDailyLimitUsd(*key.DailyLimitUSD)
+	} else {
+		builder.ClearDailyLimitUsd()
+	}
+	if key.WeeklyLimitUSD != nil {
+		builder.SetWeeklyLimitUsd(*key.WeeklyLimitUSD)
+	} else {
+		builder.ClearWeeklyLimitUsd()
+	}
+	if key.MonthlyLimitUSD != nil {
+		builder.SetMonthlyLimitUsd(*key.MonthlyLimitUSD)
+	} else {
+		builder.ClearMonthlyLimitUsd()
+	}
+
 	// IP 限制字段
 	if len(key.IPWhitelist) > 0 {
 		builder.SetIPWhitelist(key.IPWhitelist)
@@ -197,6 +274,11 @@ func (r *apiKeyRepository) Update(ctx context.Context, key *service.APIKey) erro
 	} else {
 		builder.ClearIPBlacklist()
 	}
+	if len(key.AllowedTagKeys) > 0 {
+		builder.SetAllowedTagKeys(key.AllowedTagKeys)
+	} else {
+		builder.ClearAllowedTagKeys()
+	}
 
 	affected, err := builder.Save(ctx)
 	if err != nil {
@@ -285,7 +367,8 @@ func (r *apiKeyRepository) CountByUserID(ctx context.Context, userID int64) (int
 }
 
 func (r *apiKeyRepository) ExistsByKey(ctx context.Context, key string) (bool, error) {
-	count, err := r.activeQuery().Where(apikey.KeyEQ(key)).Count(ctx)
+	// 宽限期内的旧密钥同样占用该值，避免认证时出现歧义
+	count, err := r.activeQuery().Where(matchKeyOrActivePreviousKey(key, time.Now())).Count(ctx)
 	return count > 0, err
 }
 
@@ -354,24 +437,30 @@ func (r *apiKeyRepository) CountByGroupID(ctx context.Context, groupID int64) (i
 }
 
 func (r *apiKeyRepository) ListKeysByUserID(ctx context.Context, userID int64) ([]string, error) {
-	keys, err := r.activeQuery().
-		Where(apikey.UserIDEQ(userID)).
-		Select(apikey.FieldKey).
-		Strings(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return keys, nil
+	return r.listKeys(ctx, apikey.UserIDEQ(userID))
 }
 
 func (r *apiKeyRepository) ListKeysByGroupID(ctx context.Context, groupID int64) ([]string, error) {
-	keys, err := r.activeQuery().
-		Where(apikey.GroupIDEQ(groupID)).
-		Select(apikey.FieldKey).
-		Strings(ctx)
+	return r.listKeys(ctx, apikey.GroupIDEQ(groupID))
+}
+
+// listKeys 返回匹配记录的全部可认证密钥（含宽限期内的旧密钥），用于认证缓存失效
+func (r *apiKeyRepository) listKeys(ctx context.Context, where predicate.APIKey) ([]string, error) {
+	rows, err := r.activeQuery().
+		Where(where).
+		Select(apikey.FieldKey, apikey.FieldPreviousKey, apikey.FieldPreviousKeyExpiresAt).
+		All(ctx)
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
+	keys := make([]string, 0, len(rows))
+	for _, m := range rows {
+		keys = append(keys, m.Key)
+		if m.PreviousKey != nil && m.PreviousKeyExpiresAt != nil && m.PreviousKeyExpiresAt.After(now) {
+			keys = append(keys, *m.PreviousKey)
+		}
+	}
 	return keys, nil
 }
 
@@ -425,6 +514,18 @@ func apiKeyEntityToService(m *dbent.APIKey) *service.APIKey {
 		Quota:       m.Quota,
 		QuotaUsed:   m.QuotaUsed,
 		ExpiresAt:   m.ExpiresAt,
+
+		DailyLimitUSD:   m.DailyLimitUsd,
+		WeeklyLimitUSD:  m.WeeklyLimitUsd,
+		MonthlyLimitUSD: m.MonthlyLimitUsd,
+
+		KeyGeneration:        m.KeyGeneration,
+		PreviousKey:          m.PreviousKey,
+		PreviousKeyExpiresAt: m.PreviousKeyExpiresAt,
+		RotatedAt:            m.RotatedAt,
+
+		AllowedTagKeys: m.AllowedTagKeys,
+		OrganizationID: m.OrganizationID,
 	}
 	if m.Edges.User != nil {
 		out.User = userEntityToService(m.Edges.User)
@@ -446,12 +547,14 @@ func userEntityToService(u *dbent.User) *service.User {
 		Notes:               u.Notes,
 		PasswordHash:        u.PasswordHash,
 		Role:                u.Role,
+		AdminRole:           u.AdminRole,
 		Balance:             u.Balance,
 		Concurrency:         u.Concurrency,
 		Status:              u.Status,
 		TotpSecretEncrypted: u.TotpSecretEncrypted,
 		TotpEnabled:         u.TotpEnabled,
 		TotpEnabledAt:       u.TotpEnabledAt,
+		AllowedTagKeys:      u.AllowedTagKeys,
 		CreatedAt:           u.CreatedAt,
 		UpdatedAt:           u.UpdatedAt,
 	}
@@ -486,6 +589,10 @@ func groupEntityToService(g *dbent.Group) *service.Group {
 		MCPXMLInject:                    g.McpXMLInject,
 		SupportedModelScopes:            g.SupportedModelScopes,
 		SortOrder:                       g.SortOrder,
+		SelectionStrategy:               g.SelectionStrategy,
+		FairShareEnabled:                g.FairShareEnabled,
+		ModelFallback:                   g.ModelFallback,
+		ModelFallbackEnabled:            g.ModelFallbackEnabled,
 		CreatedAt:                       g.CreatedAt,
 		UpdatedAt:                       g.UpdatedAt,
 	}
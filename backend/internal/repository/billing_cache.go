@@ -15,6 +15,8 @@ import (
 const (
 	billingBalanceKeyPrefix = "billing:balance:"
 	billingSubKeyPrefix     = "billing:sub:"
+	billingAPIKeyKeyPrefix  = "billing:apikey:"
+	billingOrgKeyPrefix     = "billing:org:"
 	billingCacheTTL         = 5 * time.Minute
 )
 
@@ -28,6 +30,28 @@ func billingSubKey(userID, groupID int64) string {
 	return fmt.Sprintf("%s%d:%d", billingSubKeyPrefix, userID, groupID)
 }
 
+// billingAPIKeySpendKey generates the Redis key for API key spend window cache.
+func billingAPIKeySpendKey(apiKeyID int64, window string) string {
+	return fmt.Sprintf("%s%d:%s", billingAPIKeyKeyPrefix, apiKeyID, window)
+}
+
+// billingOrgKey generates the Redis key for organization status and balance cache.
+func billingOrgKey(orgID int64) string {
+	return fmt.Sprintf("%s%d", billingOrgKeyPrefix, orgID)
+}
+
+// billingOrgMemberKey generates the Redis key for organization member spend cache.
+func billingOrgMemberKey(orgID, userID int64) string {
+	return fmt.Sprintf("%s%d:member:%d", billingOrgKeyPrefix, orgID, userID)
+}
+
+const (
+	orgFieldBalance    = "balance"
+	orgFieldWindow     = "window"
+	orgFieldSpendUsed  = "spend_used"
+	orgFieldSpendLimit = "spend_limit"
+)
+
 const (
 	subFieldStatus       = "status"
 	subFieldExpiresAt    = "expires_at"
@@ -61,6 +85,19 @@ var (
 		redis.call('EXPIRE', KEYS[1], ARGV[2])
 		return 1
 	`)
+
+	// updateOrgUsageScript 累加成员当月用量（仅窗口一致时），余额模式下同时扣减组织余额缓存；
+	// 缓存不存在时跳过，下次读取从数据库重建
+	updateOrgUsageScript = redis.NewScript(`
+		local cost = tonumber(ARGV[1])
+		if redis.call('HGET', KEYS[2], 'window') == ARGV[2] then
+			redis.call('HINCRBYFLOAT', KEYS[2], 'spend_used', cost)
+		end
+		if ARGV[3] == '1' and redis.call('EXISTS', KEYS[1]) == 1 then
+			redis.call('HINCRBYFLOAT', KEYS[1], 'balance', -cost)
+		end
+		return 1
+	`)
 )
 
 type billingCache struct {
@@ -181,3 +218,119 @@ func (c *billingCache) InvalidateSubscriptionCache(ctx context.Context, userID,
 	key := billingSubKey(userID, groupID)
 	return c.rdb.Del(ctx, key).Err()
 }
+
+func (c *billingCache) GetAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) (*service.APIKeySpendWindows, error) {
+	key := billingAPIKeySpendKey(apiKeyID, window)
+	result, err := c.rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, redis.Nil
+	}
+	data := &service.APIKeySpendWindows{}
+	data.Daily, _ = strconv.ParseFloat(result[subFieldDailyUsage], 64)
+	data.Weekly, _ = strconv.ParseFloat(result[subFieldWeeklyUsage], 64)
+	data.Monthly, _ = strconv.ParseFloat(result[subFieldMonthlyUsage], 64)
+	return data, nil
+}
+
+func (c *billingCache) SetAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string, data *service.APIKeySpendWindows) error {
+	if data == nil {
+		return nil
+	}
+	key := billingAPIKeySpendKey(apiKeyID, window)
+	pipe := c.rdb.Pipeline()
+	pipe.HSet(ctx, key, map[string]any{
+		subFieldDailyUsage:   data.Daily,
+		subFieldWeeklyUsage:  data.Weekly,
+		subFieldMonthlyUsage: data.Monthly,
+	})
+	pipe.Expire(ctx, key, billingCacheTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// UpdateAPIKeySpend 累加窗口消费；缓存不存在时跳过，下次读取从数据库重建
+func (c *billingCache) UpdateAPIKeySpend(ctx context.Context, apiKeyID int64, window string, cost float64) error {
+	key := billingAPIKeySpendKey(apiKeyID, window)
+	_, err := updateSubUsageScript.Run(ctx, c.rdb, []string{key}, cost, int(billingCacheTTL.Seconds())).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("Warning: update api key spend cache failed for api key %d: %v", apiKeyID, err)
+	}
+	return nil
+}
+
+func (c *billingCache) InvalidateAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) error {
+	return c.rdb.Del(ctx, billingAPIKeySpendKey(apiKeyID, window)).Err()
+}
+
+func (c *billingCache) GetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string) (*service.OrganizationBillingState, error) {
+	pipe := c.rdb.Pipeline()
+	orgCmd := pipe.HGetAll(ctx, billingOrgKey(orgID))
+	memberCmd := pipe.HGetAll(ctx, billingOrgMemberKey(orgID, userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	org, member := orgCmd.Val(), memberCmd.Val()
+	if org[subFieldStatus] == "" || member[orgFieldWindow] != window {
+		return nil, redis.Nil
+	}
+	state := &service.OrganizationBillingState{Status: org[subFieldStatus]}
+	state.Balance, _ = strconv.ParseFloat(org[orgFieldBalance], 64)
+	state.SpendUsedUSD, _ = strconv.ParseFloat(member[orgFieldSpendUsed], 64)
+	if raw, ok := member[orgFieldSpendLimit]; ok {
+		if limit, err := strconv.ParseFloat(raw, 64); err == nil {
+			state.SpendLimitUSD = &limit
+		}
+	}
+	return state, nil
+}
+
+func (c *billingCache) SetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string, state *service.OrganizationBillingState) error {
+	if state == nil {
+		return nil
+	}
+	orgKey := billingOrgKey(orgID)
+	memberKey := billingOrgMemberKey(orgID, userID)
+	member := map[string]any{
+		orgFieldWindow:    window,
+		orgFieldSpendUsed: state.SpendUsedUSD,
+	}
+	if state.SpendLimitUSD != nil {
+		member[orgFieldSpendLimit] = *state.SpendLimitUSD
+	}
+	pipe := c.rdb.TxPipeline()
+	pipe.HSet(ctx, orgKey, map[string]any{
+		subFieldStatus:  state.Status,
+		orgFieldBalance: state.Balance,
+	})
+	pipe.Expire(ctx, orgKey, billingCacheTTL)
+	// 先删除再写入，确保取消的消费上限不会残留
+	pipe.Del(ctx, memberKey)
+	pipe.HSet(ctx, memberKey, member)
+	pipe.Expire(ctx, memberKey, billingCacheTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *billingCache) UpdateOrganizationUsage(ctx context.Context, orgID, userID int64, window string, cost float64, deductBalance bool) error {
+	deduct := "0"
+	if deductBalance {
+		deduct = "1"
+	}
+	keys := []string{billingOrgKey(orgID), billingOrgMemberKey(orgID, userID)}
+	_, err := updateOrgUsageScript.Run(ctx, c.rdb, keys, cost, window, deduct).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("Warning: update organization usage cache failed for organization %d user %d: %v", orgID, userID, err)
+	}
+	return nil
+}
+
+func (c *billingCache) InvalidateOrganizationBillingCache(ctx context.Context, orgID int64) error {
+	return c.rdb.Del(ctx, billingOrgKey(orgID)).Err()
+}
+
+func (c *billingCache) InvalidateOrganizationMemberBillingCache(ctx context.Context, orgID, userID int64) error {
+	return c.rdb.Del(ctx, billingOrgMemberKey(orgID, userID)).Err()
+}
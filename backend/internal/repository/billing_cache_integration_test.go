@@ -278,6 +278,75 @@ func (s *BillingCacheSuite) TestSubscriptionCache() {
 	}
 }
 
+func (s *BillingCacheSuite) TestOrganizationBillingCache() {
+	rdb := testRedis(s.T())
+	cache := NewBillingCache(rdb)
+	ctx := context.Background()
+	limit := 20.0
+
+	_, err := cache.GetOrganizationBillingCache(ctx, 1, 2, "2026-10")
+	require.ErrorIs(s.T(), err, redis.Nil)
+
+	state := &service.OrganizationBillingState{Status: service.StatusActive, Balance: 10, SpendLimitUSD: &limit, SpendUsedUSD: 3}
+	require.NoError(s.T(), cache.SetOrganizationBillingCache(ctx, 1, 2, "2026-10", state))
+	got, err := cache.GetOrganizationBillingCache(ctx, 1, 2, "2026-10")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), state, got)
+
+	// 跨月后成员用量缓存失效
+	_, err = cache.GetOrganizationBillingCache(ctx, 1, 2, "2026-11")
+	require.ErrorIs(s.T(), err, redis.Nil)
+
+	require.NoError(s.T(), cache.UpdateOrganizationUsage(ctx, 1, 2, "2026-10", 1.5, true))
+	got, err = cache.GetOrganizationBillingCache(ctx, 1, 2, "2026-10")
+	require.NoError(s.T(), err)
+	require.InDelta(s.T(), 8.5, got.Balance, 1e-9)
+	require.InDelta(s.T(), 4.5, got.SpendUsedUSD, 1e-9)
+
+	// 订阅模式不扣组织余额
+	require.NoError(s.T(), cache.UpdateOrganizationUsage(ctx, 1, 2, "2026-10", 0.5, false))
+	got, err = cache.GetOrganizationBillingCache(ctx, 1, 2, "2026-10")
+	require.NoError(s.T(), err)
+	require.InDelta(s.T(), 8.5, got.Balance, 1e-9)
+	require.InDelta(s.T(), 5.0, got.SpendUsedUSD, 1e-9)
+
+	// 取消消费上限后重新写入不残留旧上限
+	state.SpendLimitUSD = nil
+	require.NoError(s.T(), cache.SetOrganizationBillingCache(ctx, 1, 2, "2026-10", state))
+	got, err = cache.GetOrganizationBillingCache(ctx, 1, 2, "2026-10")
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), got.SpendLimitUSD)
+
+	require.NoError(s.T(), cache.InvalidateOrganizationMemberBillingCache(ctx, 1, 2))
+	_, err = cache.GetOrganizationBillingCache(ctx, 1, 2, "2026-10")
+	require.ErrorIs(s.T(), err, redis.Nil)
+
+	require.NoError(s.T(), cache.SetOrganizationBillingCache(ctx, 1, 2, "2026-10", state))
+	require.NoError(s.T(), cache.InvalidateOrganizationBillingCache(ctx, 1))
+	_, err = cache.GetOrganizationBillingCache(ctx, 1, 2, "2026-10")
+	require.ErrorIs(s.T(), err, redis.Nil)
+}
+
+func (s *BillingCacheSuite) TestAPIKeySpendCacheInvalidate() {
+	rdb := testRedis(s.T())
+	cache := NewBillingCache(rdb)
+	ctx := context.Background()
+
+	data := &service.APIKeySpendWindows{Daily: 1, Weekly: 2, Monthly: 3}
+	require.NoError(s.T(), cache.SetAPIKeySpendCache(ctx, 7, "2026-10-19", data))
+	require.NoError(s.T(), cache.UpdateAPIKeySpend(ctx, 7, "2026-10-19", 0.5))
+	got, err := cache.GetAPIKeySpendCache(ctx, 7, "2026-10-19")
+	require.NoError(s.T(), err)
+	require.InDelta(s.T(), 1.5, got.Daily, 1e-9)
+
+	require.NoError(s.T(), cache.InvalidateAPIKeySpendCache(ctx, 7, "2026-10-19"))
+	_, err = cache.GetAPIKeySpendCache(ctx, 7, "2026-10-19")
+	require.ErrorIs(s.T(), err, redis.Nil)
+	exists, err := rdb.Exists(ctx, billingAPIKeySpendKey(7, "2026-10-19")).Result()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(0), exists)
+}
+
 func TestBillingCacheSuite(t *testing.T) {
 	suite.Run(t, new(BillingCacheSuite))
 }
@@ -85,3 +85,8 @@ func TestBillingSubKey(t *testing.T) {
 		})
 	}
 }
+
+func TestBillingOrgKeys(t *testing.T) {
+	require.Equal(t, "billing:org:7", billingOrgKey(7))
+	require.Equal(t, "billing:org:7:member:42", billingOrgMemberKey(7, 42))
+}
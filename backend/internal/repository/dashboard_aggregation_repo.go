@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/lib/pq"
 )
@@ -74,6 +75,12 @@ func (r *dashboardAggregationRepository) AggregateRange(ctx context.Context, sta
 	if err := r.upsertDailyAggregates(ctx, dayStart, dayEnd); err != nil {
 		return err
 	}
+	if err := r.upsertTagDailyAggregates(ctx, dayStart, dayEnd); err != nil {
+		return err
+	}
+	if err := r.upsertUserDailyAggregates(ctx, dayStart, dayEnd); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -130,6 +137,12 @@ func (r *dashboardAggregationRepository) recomputeRangeInTx(ctx context.Context,
 	if _, err := r.sql.ExecContext(ctx, "DELETE FROM usage_dashboard_daily_users WHERE bucket_date >= $1::date AND bucket_date < $2::date", dayStart, dayEnd); err != nil {
 		return err
 	}
+	if _, err := r.sql.ExecContext(ctx, "DELETE FROM usage_dashboard_tag_daily WHERE bucket_date >= $1::date AND bucket_date < $2::date", dayStart, dayEnd); err != nil {
+		return err
+	}
+	if _, err := r.sql.ExecContext(ctx, "DELETE FROM usage_dashboard_user_daily WHERE bucket_date >= $1::date AND bucket_date < $2::date", dayStart, dayEnd); err != nil {
+		return err
+	}
 
 	if err := r.insertHourlyActiveUsers(ctx, hourStart, hourEnd); err != nil {
 		return err
@@ -143,6 +156,12 @@ func (r *dashboardAggregationRepository) recomputeRangeInTx(ctx context.Context,
 	if err := r.upsertDailyAggregates(ctx, dayStart, dayEnd); err != nil {
 		return err
 	}
+	if err := r.upsertTagDailyAggregates(ctx, dayStart, dayEnd); err != nil {
+		return err
+	}
+	if err := r.upsertUserDailyAggregates(ctx, dayStart, dayEnd); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -184,6 +203,12 @@ func (r *dashboardAggregationRepository) CleanupAggregates(ctx context.Context,
 	if _, err := r.sql.ExecContext(ctx, "DELETE FROM usage_dashboard_daily_users WHERE bucket_date < $1::date", dailyCutoffUTC); err != nil {
 		return err
 	}
+	if _, err := r.sql.ExecContext(ctx, "DELETE FROM usage_dashboard_tag_daily WHERE bucket_date < $1::date", dailyCutoffUTC); err != nil {
+		return err
+	}
+	if _, err := r.sql.ExecContext(ctx, "DELETE FROM usage_dashboard_user_daily WHERE bucket_date < $1::date", dailyCutoffUTC); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -193,9 +218,15 @@ func (r *dashboardAggregationRepository) CleanupUsageLogs(ctx context.Context, c
 		return err
 	}
 	if isPartitioned {
-		return r.dropUsageLogsPartitions(ctx, cutoff)
+		err = r.dropUsageLogsPartitions(ctx, cutoff)
+	} else {
+		_, err = r.sql.ExecContext(ctx, "DELETE FROM usage_logs WHERE created_at < $1", cutoff.UTC())
 	}
-	_, err = r.sql.ExecContext(ctx, "DELETE FROM usage_logs WHERE created_at < $1", cutoff.UTC())
+	if err != nil {
+		return err
+	}
+	// 去重登记与使用记录同保留期
+	_, err = r.sql.ExecContext(ctx, "DELETE FROM usage_log_request_ids WHERE created_at < $1", cutoff.UTC())
 	return err
 }
 
@@ -382,6 +413,152 @@ func (r *dashboardAggregationRepository) upsertDailyAggregates(ctx context.Conte
 	return err
 }
 
+// upsertTagDailyAggregates 按 日期 + 用户 + 标签键值 聚合（仅扫描带标签的记录）
+func (r *dashboardAggregationRepository) upsertTagDailyAggregates(ctx context.Context, start, end time.Time) error {
+	tzName := timezone.Name()
+	query := `
+		INSERT INTO usage_dashboard_tag_daily (
+			bucket_date,
+			user_id,
+			tag_key,
+			tag_value,
+			total_requests,
+			input_tokens,
+			output_tokens,
+			cache_creation_tokens,
+			cache_read_tokens,
+			total_cost,
+			actual_cost,
+			computed_at
+		)
+		SELECT
+			(ul.created_at AT TIME ZONE $3)::date AS bucket_date,
+			ul.user_id,
+			t.key,
+			t.value,
+			COUNT(*),
+			COALESCE(SUM(ul.input_tokens), 0),
+			COALESCE(SUM(ul.output_tokens), 0),
+			COALESCE(SUM(ul.cache_creation_tokens), 0),
+			COALESCE(SUM(ul.cache_read_tokens), 0),
+			COALESCE(SUM(ul.total_cost), 0),
+			COALESCE(SUM(ul.actual_cost), 0),
+			NOW()
+		FROM usage_logs ul
+		CROSS JOIN LATERAL jsonb_each_text(ul.tags) AS t(key, value)
+		WHERE ul.created_at >= $1 AND ul.created_at < $2 AND ul.tags IS NOT NULL
+		GROUP BY 1, 2, 3, 4
+		ON CONFLICT (bucket_date, user_id, tag_key, tag_value)
+		DO UPDATE SET
+			total_requests = EXCLUDED.total_requests,
+			input_tokens = EXCLUDED.input_tokens,
+			output_tokens = EXCLUDED.output_tokens,
+			cache_creation_tokens = EXCLUDED.cache_creation_tokens,
+			cache_read_tokens = EXCLUDED.cache_read_tokens,
+			total_cost = EXCLUDED.total_cost,
+			actual_cost = EXCLUDED.actual_cost,
+			computed_at = EXCLUDED.computed_at
+	`
+	_, err := r.sql.ExecContext(ctx, query, start, end, tzName)
+	return err
+}
+
+// upsertUserDailyAggregates 按 日期 + 用户 + 组织 + 模型 + 分组 + 计费类型 聚合（对账单数据源）
+func (r *dashboardAggregationRepository) upsertUserDailyAggregates(ctx context.Context, start, end time.Time) error {
+	tzName := timezone.Name()
+	query := `
+		INSERT INTO usage_dashboard_user_daily (
+			bucket_date,
+			user_id,
+			organization_id,
+			model,
+			group_id,
+			billing_type,
+			total_requests,
+			input_tokens,
+			output_tokens,
+			cache_creation_tokens,
+			cache_read_tokens,
+			total_cost,
+			actual_cost,
+			computed_at
+		)
+		SELECT
+			(ul.created_at AT TIME ZONE $3)::date AS bucket_date,
+			ul.user_id,
+			COALESCE(ul.organization_id, 0),
+			ul.model,
+			COALESCE(ul.group_id, 0),
+			ul.billing_type,
+			COUNT(*),
+			COALESCE(SUM(ul.input_tokens), 0),
+			COALESCE(SUM(ul.output_tokens), 0),
+			COALESCE(SUM(ul.cache_creation_tokens), 0),
+			COALESCE(SUM(ul.cache_read_tokens), 0),
+			COALESCE(SUM(ul.total_cost), 0),
+			COALESCE(SUM(ul.actual_cost), 0),
+			NOW()
+		FROM usage_logs ul
+		WHERE ul.created_at >= $1 AND ul.created_at < $2
+		GROUP BY 1, 2, 3, 4, 5, 6
+		ON CONFLICT (bucket_date, user_id, organization_id, model, group_id, billing_type)
+		DO UPDATE SET
+			total_requests = EXCLUDED.total_requests,
+			input_tokens = EXCLUDED.input_tokens,
+			output_tokens = EXCLUDED.output_tokens,
+			cache_creation_tokens = EXCLUDED.cache_creation_tokens,
+			cache_read_tokens = EXCLUDED.cache_read_tokens,
+			total_cost = EXCLUDED.total_cost,
+			actual_cost = EXCLUDED.actual_cost,
+			computed_at = EXCLUDED.computed_at
+	`
+	_, err := r.sql.ExecContext(ctx, query, start, end, tzName)
+	return err
+}
+
+// GetTagUsageStats 从标签日聚合表按标签值汇总（按配置时区的整天计算，APIKeyID 过滤不适用）
+func (r *dashboardAggregationRepository) GetTagUsageStats(ctx context.Context, filters usagestats.TagUsageFilters) (results []usagestats.TagUsageStat, err error) {
+	conditions := []string{
+		"tag_key = $1",
+		"bucket_date >= ($2::timestamptz AT TIME ZONE $4)::date",
+		"bucket_date < ($3::timestamptz AT TIME ZONE $4)::date",
+	}
+	args := []any{filters.TagKey, filters.StartTime, filters.EndTime, timezone.Name()}
+	if filters.UserID > 0 {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)+1))
+		args = append(args, filters.UserID)
+	}
+	if filters.TagValue != "" {
+		conditions = append(conditions, fmt.Sprintf("tag_value = $%d", len(args)+1))
+		args = append(args, filters.TagValue)
+	}
+	query := `
+		SELECT
+			tag_value,
+			COALESCE(SUM(total_requests), 0) AS requests,
+			COALESCE(SUM(input_tokens), 0) AS input_tokens,
+			COALESCE(SUM(output_tokens), 0) AS output_tokens,
+			COALESCE(SUM(cache_creation_tokens + cache_read_tokens), 0) AS cache_tokens,
+			COALESCE(SUM(total_cost), 0) AS cost,
+			COALESCE(SUM(actual_cost), 0) AS actual_cost
+		FROM usage_dashboard_tag_daily
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		GROUP BY tag_value
+		ORDER BY actual_cost DESC, tag_value ASC
+	`
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			results = nil
+		}
+	}()
+	return scanTagUsageStatRows(rows)
+}
+
 func (r *dashboardAggregationRepository) isUsageLogsPartitioned(ctx context.Context) (bool, error) {
 	query := `
 		SELECT EXISTS(
@@ -438,17 +615,7 @@ func (r *dashboardAggregationRepository) dropUsageLogsPartitions(ctx context.Con
 }
 
 func (r *dashboardAggregationRepository) createUsageLogsPartition(ctx context.Context, month time.Time) error {
-	monthStart := truncateToMonthUTC(month)
-	nextMonth := monthStart.AddDate(0, 1, 0)
-	name := fmt.Sprintf("usage_logs_%s", monthStart.Format("200601"))
-	query := fmt.Sprintf(
-		"CREATE TABLE IF NOT EXISTS %s PARTITION OF usage_logs FOR VALUES FROM (%s) TO (%s)",
-		pq.QuoteIdentifier(name),
-		pq.QuoteLiteral(monthStart.Format("2006-01-02")),
-		pq.QuoteLiteral(nextMonth.Format("2006-01-02")),
-	)
-	_, err := r.sql.ExecContext(ctx, query)
-	return err
+	return createMonthlyPartition(ctx, r.sql, "usage_logs", month)
 }
 
 func truncateToDay(t time.Time) time.Time {
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// 分组内用户公平分配缓存
+//
+// 键格式:
+//   - fairshare:groups                          启用公平分配且近期活跃的分组（有序集合，分数为最后活跃时间）
+//   - fairshare:group:{groupID}:users           分组内活跃用户（有序集合，分数为最后一次请求槽位的时间）
+//   - fairshare:group:{groupID}:user:{userID}   用户在分组内的在途槽位（有序集合，成员为 requestID）
+//   - fairshare:group:{groupID}:shares          用户最近一次计算的配额（哈希）
+const (
+	fairShareGroupsKey = "fairshare:groups"
+	fairShareKeyPrefix = "fairshare:group:"
+
+	// fairShareActiveWindowSeconds 用户最后一次请求槽位后仍被视为"有待处理请求"的时间窗口
+	fairShareActiveWindowSeconds = 30
+)
+
+var (
+	// fairShareDemandsScript 标记用户为活跃并返回分组内各活跃用户的需求
+	// KEYS[1] = users 有序集合, KEYS[2] = groups 有序集合, KEYS[3] = shares 哈希
+	// ARGV[1] = userID, ARGV[2] = groupID, ARGV[3] = slot TTL（秒）
+	// ARGV[4] = 活跃窗口（秒）, ARGV[5] = 用户槽位键前缀
+	fairShareDemandsScript = redis.NewScript(`
+		local usersKey = KEYS[1]
+		local groupsKey = KEYS[2]
+		local sharesKey = KEYS[3]
+		local userID = ARGV[1]
+		local groupID = ARGV[2]
+		local slotTTL = tonumber(ARGV[3])
+		local activeWindow = tonumber(ARGV[4])
+		local prefix = ARGV[5]
+
+		local timeResult = redis.call('TIME')
+		local now = tonumber(timeResult[1])
+
+		redis.call('ZADD', usersKey, now, userID)
+		redis.call('EXPIRE', usersKey, slotTTL)
+		redis.call('ZADD', groupsKey, now, groupID)
+		redis.call('ZREMRANGEBYSCORE', groupsKey, '-inf', now - slotTTL)
+
+		local result = {}
+		local members = redis.call('ZRANGE', usersKey, 0, -1, 'WITHSCORES')
+		for i = 1, #members, 2 do
+			local uid = members[i]
+			local seen = tonumber(members[i + 1])
+			local slotKey = prefix .. uid
+			redis.call('ZREMRANGEBYSCORE', slotKey, '-inf', now - slotTTL)
+			local inflight = redis.call('ZCARD', slotKey)
+			local pending = 0
+			if seen >= now - activeWindow then
+				pending = 1
+			end
+			if inflight == 0 and pending == 0 then
+				redis.call('ZREM', usersKey, uid)
+				redis.call('HDEL', sharesKey, uid)
+			else
+				table.insert(result, uid)
+				table.insert(result, inflight + pending)
+			end
+		end
+		return result
+	`)
+
+	// fairShareAcquireScript 用户在途槽位未达配额时占用槽位
+	// KEYS[1] = 用户槽位有序集合, KEYS[2] = shares 哈希
+	// ARGV[1] = share, ARGV[2] = slot TTL（秒）, ARGV[3] = requestID, ARGV[4] = userID
+	fairShareAcquireScript = redis.NewScript(`
+		local slotKey = KEYS[1]
+		local sharesKey = KEYS[2]
+		local share = tonumber(ARGV[1])
+		local ttl = tonumber(ARGV[2])
+		local requestID = ARGV[3]
+		local userID = ARGV[4]
+
+		local timeResult = redis.call('TIME')
+		local now = tonumber(timeResult[1])
+
+		redis.call('HSET', sharesKey, userID, share)
+		redis.call('EXPIRE', sharesKey, ttl)
+
+		redis.call('ZREMRANGEBYSCORE', slotKey, '-inf', now - ttl)
+		if redis.call('ZSCORE', slotKey, requestID) ~= false then
+			redis.call('ZADD', slotKey, now, requestID)
+			redis.call('EXPIRE', slotKey, ttl)
+			return 1
+		end
+
+		if redis.call('ZCARD', slotKey) < share then
+			redis.call('ZADD', slotKey, now, requestID)
+			redis.call('EXPIRE', slotKey, ttl)
+			return 1
+		end
+		return 0
+	`)
+)
+
+type fairShareCache struct {
+	rdb            *redis.Client
+	slotTTLSeconds int
+}
+
+// NewFairShareCache 创建分组内用户公平分配缓存
+// slotTTLMinutes: 槽位过期时间（分钟），与并发槽位保持一致，0 或负数使用默认值
+func NewFairShareCache(rdb *redis.Client, slotTTLMinutes int) service.FairShareCache {
+	if slotTTLMinutes <= 0 {
+		slotTTLMinutes = defaultSlotTTLMinutes
+	}
+	return &fairShareCache{rdb: rdb, slotTTLSeconds: slotTTLMinutes * 60}
+}
+
+func fairShareUsersKey(groupID int64) string {
+	return fmt.Sprintf("%s%d:users", fairShareKeyPrefix, groupID)
+}
+
+func fairShareSharesKey(groupID int64) string {
+	return fmt.Sprintf("%s%d:shares", fairShareKeyPrefix, groupID)
+}
+
+func fairShareUserSlotPrefix(groupID int64) string {
+	return fmt.Sprintf("%s%d:user:", fairShareKeyPrefix, groupID)
+}
+
+func fairShareUserSlotKey(groupID, userID int64) string {
+	return fmt.Sprintf("%s%d", fairShareUserSlotPrefix(groupID), userID)
+}
+
+func (c *fairShareCache) GetFairShareDemands(ctx context.Context, groupID, userID int64) (map[int64]int, error) {
+	keys := []string{fairShareUsersKey(groupID), fairShareGroupsKey, fairShareSharesKey(groupID)}
+	result, err := fairShareDemandsScript.Run(ctx, c.rdb, keys,
+		userID, groupID, c.slotTTLSeconds, fairShareActiveWindowSeconds, fairShareUserSlotPrefix(groupID)).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("get fair share demands: %w", err)
+	}
+
+	demands := make(map[int64]int, len(result)/2)
+	for i := 0; i+1 < len(result); i += 2 {
+		uid, err := strconv.ParseInt(fmt.Sprintf("%v", result[i]), 10, 64)
+		if err != nil {
+			continue
+		}
+		demand, _ := strconv.Atoi(fmt.Sprintf("%v", result[i+1]))
+		demands[uid] = demand
+	}
+	return demands, nil
+}
+
+func (c *fairShareCache) AcquireFairShareSlot(ctx context.Context, groupID, userID int64, share int, requestID string) (bool, error) {
+	keys := []string{fairShareUserSlotKey(groupID, userID), fairShareSharesKey(groupID)}
+	result, err := fairShareAcquireScript.Run(ctx, c.rdb, keys, share, c.slotTTLSeconds, requestID, userID).Int()
+	if err != nil {
+		return false, fmt.Errorf("acquire fair share slot: %w", err)
+	}
+	return result == 1, nil
+}
+
+func (c *fairShareCache) ReleaseFairShareSlot(ctx context.Context, groupID, userID int64, requestID string) error {
+	return c.rdb.ZRem(ctx, fairShareUserSlotKey(groupID, userID), requestID).Err()
+}
+
+func (c *fairShareCache) ListFairShares(ctx context.Context) ([]service.UserFairShare, error) {
+	groupIDs, err := c.rdb.ZRange(ctx, fairShareGroupsKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list fair share groups: %w", err)
+	}
+
+	cutoff := strconv.FormatInt(time.Now().Unix()-int64(c.slotTTLSeconds), 10)
+	out := make([]service.UserFairShare, 0)
+	for _, rawGroupID := range groupIDs {
+		groupID, err := strconv.ParseInt(rawGroupID, 10, 64)
+		if err != nil {
+			continue
+		}
+		userIDs, err := c.rdb.ZRange(ctx, fairShareUsersKey(groupID), 0, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("list fair share users: %w", err)
+		}
+		if len(userIDs) == 0 {
+			continue
+		}
+		shares, err := c.rdb.HGetAll(ctx, fairShareSharesKey(groupID)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("get fair shares: %w", err)
+		}
+
+		pipe := c.rdb.Pipeline()
+		counts := make([]*redis.IntCmd, len(userIDs))
+		for i, rawUserID := range userIDs {
+			counts[i] = pipe.ZCount(ctx, fairShareUserSlotPrefix(groupID)+rawUserID, "("+cutoff, "+inf")
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("count fair share slots: %w", err)
+		}
+
+		for i, rawUserID := range userIDs {
+			userID, err := strconv.ParseInt(rawUserID, 10, 64)
+			if err != nil {
+				continue
+			}
+			share, _ := strconv.Atoi(shares[rawUserID])
+			out = append(out, service.UserFairShare{
+				GroupID: groupID,
+				UserID:  userID,
+				InUse:   int(counts[i].Val()),
+				Share:   share,
+			})
+		}
+	}
+	return out, nil
+}
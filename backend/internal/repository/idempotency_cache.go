@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const idempotencyKeyPrefix = "idem:"
+
+// idempotencyClaimScript 键不存在时写入 owner 与记录并设置过期时间；已存在时返回现有记录
+var idempotencyClaimScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('HSET', KEYS[1], 'owner', ARGV[1], 'data', ARGV[2])
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+	return {1, ''}
+end
+return {0, redis.call('HGET', KEYS[1], 'data') or ''}
+`)
+
+// idempotencyCompleteScript owner 匹配时覆盖记录并重设过期时间
+var idempotencyCompleteScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'data', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// idempotencyReleaseScript owner 匹配时删除记录
+var idempotencyReleaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+type idempotencyCache struct {
+	rdb *redis.Client
+}
+
+// NewIdempotencyCache 创建 Idempotency-Key 记录缓存
+func NewIdempotencyCache(rdb *redis.Client) service.IdempotencyCache {
+	return &idempotencyCache{rdb: rdb}
+}
+
+func (c *idempotencyCache) Claim(ctx context.Context, key, owner string, record []byte, ttl time.Duration) (bool, []byte, error) {
+	res, err := idempotencyClaimScript.Run(ctx, c.rdb, []string{idempotencyKeyPrefix + key}, owner, record, ttl.Milliseconds()).Slice()
+	if err != nil {
+		return false, nil, err
+	}
+	if len(res) != 2 {
+		return false, nil, fmt.Errorf("unexpected idempotency claim result: %v", res)
+	}
+	claimed, _ := res[0].(int64)
+	data, _ := res[1].(string)
+	if claimed == 1 {
+		return true, nil, nil
+	}
+	return false, []byte(data), nil
+}
+
+func (c *idempotencyCache) Complete(ctx context.Context, key, owner string, record []byte, ttl time.Duration) (bool, error) {
+	n, err := idempotencyCompleteScript.Run(ctx, c.rdb, []string{idempotencyKeyPrefix + key}, owner, record, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (c *idempotencyCache) Release(ctx context.Context, key, owner string) error {
+	return idempotencyReleaseScript.Run(ctx, c.rdb, []string{idempotencyKeyPrefix + key}, owner).Err()
+}
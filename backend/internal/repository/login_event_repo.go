@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type loginEventRepository struct {
+	sql sqlExecutor
+}
+
+// NewLoginEventRepository 创建登录事件仓储
+func NewLoginEventRepository(sqlDB *sql.DB) service.LoginEventRepository {
+	return &loginEventRepository{sql: sqlDB}
+}
+
+const loginEventSelectColumns = `
+	id, user_id, email, method, success, failure_reason, ip, user_agent, created_at
+`
+
+func (r *loginEventRepository) Insert(ctx context.Context, event *service.LoginEvent) error {
+	if event == nil {
+		return nil
+	}
+	query := `
+		INSERT INTO login_events (user_id, email, method, success, failure_reason, ip, user_agent, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	return scanSingleRow(ctx, r.sql, query, []any{
+		opsNullInt64(event.UserID),
+		event.Email,
+		event.Method,
+		event.Success,
+		event.FailureReason,
+		event.IP,
+		event.UserAgent,
+		event.CreatedAt,
+	}, &event.ID)
+}
+
+func (r *loginEventRepository) List(ctx context.Context, filter service.LoginEventFilter, params pagination.PaginationParams) ([]service.LoginEvent, *pagination.PaginationResult, error) {
+	where, args := buildLoginEventWhere(filter)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM login_events "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.LoginEvent{}, paginationResultFromTotal(0, params), nil
+	}
+
+	args = append(args, params.Limit(), params.Offset())
+	query := fmt.Sprintf(
+		"SELECT %s FROM login_events %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		loginEventSelectColumns, where, len(args)-1, len(args),
+	)
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.LoginEvent, 0)
+	for rows.Next() {
+		var (
+			event  service.LoginEvent
+			userID sql.NullInt64
+		)
+		if err := rows.Scan(
+			&event.ID,
+			&userID,
+			&event.Email,
+			&event.Method,
+			&event.Success,
+			&event.FailureReason,
+			&event.IP,
+			&event.UserAgent,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		if userID.Valid {
+			v := userID.Int64
+			event.UserID = &v
+		}
+		out = append(out, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func buildLoginEventWhere(filter service.LoginEventFilter) (string, []any) {
+	clauses := []string{"1=1"}
+	args := []any{}
+
+	if filter.UserID > 0 {
+		args = append(args, filter.UserID)
+		clauses = append(clauses, "user_id = $"+itoa(len(args)))
+	}
+	if email := strings.ToLower(strings.TrimSpace(filter.Email)); email != "" {
+		args = append(args, email)
+		clauses = append(clauses, "email = $"+itoa(len(args)))
+	}
+	if ip := strings.TrimSpace(filter.IP); ip != "" {
+		args = append(args, ip)
+		clauses = append(clauses, "ip = $"+itoa(len(args)))
+	}
+	if filter.Success != nil {
+		args = append(args, *filter.Success)
+		clauses = append(clauses, "success = $"+itoa(len(args)))
+	}
+	if filter.StartTime != nil && !filter.StartTime.IsZero() {
+		args = append(args, *filter.StartTime)
+		clauses = append(clauses, "created_at >= $"+itoa(len(args)))
+	}
+	if filter.EndTime != nil && !filter.EndTime.IsZero() {
+		args = append(args, *filter.EndTime)
+		clauses = append(clauses, "created_at < $"+itoa(len(args)))
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const (
+	loginFailureKeyPrefix = "login:fail:"
+	loginLockKeyPrefix    = "login:lock:"
+)
+
+// loginFailureIncrScript 增加失败计数，首次计数时设置窗口过期时间
+var loginFailureIncrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+type loginSecurityCache struct {
+	rdb *redis.Client
+}
+
+// NewLoginSecurityCache 创建登录失败计数与锁定缓存
+func NewLoginSecurityCache(rdb *redis.Client) service.LoginSecurityCache {
+	return &loginSecurityCache{rdb: rdb}
+}
+
+func (c *loginSecurityCache) IncrLoginFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return loginFailureIncrScript.Run(ctx, c.rdb, []string{loginFailureKeyPrefix + key}, window.Milliseconds()).Int64()
+}
+
+func (c *loginSecurityCache) SetLoginLock(ctx context.Context, key string, ttl time.Duration) error {
+	pipe := c.rdb.TxPipeline()
+	pipe.Set(ctx, loginLockKeyPrefix+key, 1, ttl)
+	// 锁定后重新开始计数，解锁后不会因旧的失败次数立即再次锁定
+	pipe.Del(ctx, loginFailureKeyPrefix+key)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *loginSecurityCache) GetLoginLockTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.rdb.PTTL(ctx, loginLockKeyPrefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// -2 不存在，-1 无过期时间（不应出现）
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (c *loginSecurityCache) ClearLoginFailures(ctx context.Context, key string) error {
+	return c.rdb.Del(ctx, loginFailureKeyPrefix+key).Err()
+}
+
+func (c *loginSecurityCache) ResetLoginFailures(ctx context.Context, key string) error {
+	return c.rdb.Del(ctx, loginFailureKeyPrefix+key, loginLockKeyPrefix+key).Err()
+}
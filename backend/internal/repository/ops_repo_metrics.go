@@ -77,7 +77,10 @@ INSERT INTO ops_system_metrics (
   db_conn_waiting,
 
   goroutine_count,
-  concurrency_queue_depth
+  concurrency_queue_depth,
+
+  usage_write_backlog,
+  usage_write_dead_letters
 ) VALUES (
   $1,$2,$3,$4,
   $5,$6,$7,$8,
@@ -89,7 +92,8 @@ INSERT INTO ops_system_metrics (
   $32,$33,
   $34,$35,
   $36,$37,$38,
-  $39,$40
+  $39,$40,
+  $41,$42
 )`
 
 	_, err := r.db.ExecContext(
@@ -145,6 +149,9 @@ INSERT INTO ops_system_metrics (
 
 		opsNullInt(input.GoroutineCount),
 		opsNullInt(input.ConcurrencyQueueDepth),
+
+		opsNullInt(input.UsageWriteBacklog),
+		opsNullInt(input.UsageWriteDeadLetters),
 	)
 	return err
 }
@@ -180,7 +187,9 @@ SELECT
 
   goroutine_count,
   concurrency_queue_depth,
-  account_switch_count
+  account_switch_count,
+  usage_write_backlog,
+  usage_write_dead_letters
 FROM ops_system_metrics
 WHERE window_minutes = $1
   AND platform IS NULL
@@ -203,6 +212,8 @@ LIMIT 1`
 	var goroutines sql.NullInt64
 	var queueDepth sql.NullInt64
 	var accountSwitchCount sql.NullInt64
+	var usageWriteBacklog sql.NullInt64
+	var usageWriteDeadLetters sql.NullInt64
 
 	if err := r.db.QueryRowContext(ctx, q, windowMinutes).Scan(
 		&out.ID,
@@ -222,6 +233,8 @@ LIMIT 1`
 		&goroutines,
 		&queueDepth,
 		&accountSwitchCount,
+		&usageWriteBacklog,
+		&usageWriteDeadLetters,
 	); err != nil {
 		return nil, err
 	}
@@ -282,6 +295,14 @@ LIMIT 1`
 		v := accountSwitchCount.Int64
 		out.AccountSwitchCount = &v
 	}
+	if usageWriteBacklog.Valid {
+		v := int(usageWriteBacklog.Int64)
+		out.UsageWriteBacklog = &v
+	}
+	if usageWriteDeadLetters.Valid {
+		v := int(usageWriteDeadLetters.Int64)
+		out.UsageWriteDeadLetters = &v
+	}
 
 	return &out, nil
 }
@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type organizationRepository struct {
+	sql sqlExecutor
+}
+
+// NewOrganizationRepository 创建组织仓储
+func NewOrganizationRepository(sqlDB *sql.DB) service.OrganizationRepository {
+	return &organizationRepository{sql: sqlDB}
+}
+
+const organizationSelectColumns = `
+	o.id, o.name, o.owner_user_id, o.balance, o.status,
+	(SELECT COUNT(*) FROM organization_members om WHERE om.organization_id = o.id),
+	o.created_at, o.updated_at
+`
+
+// organizationMemberSpendExpr 当月已用金额：记录的月份早于本月起点时视为 0
+const organizationMemberSpendExpr = `CASE WHEN m.spend_period_start >= $%d THEN m.spend_used_usd ELSE 0 END`
+
+func (r *organizationRepository) Create(ctx context.Context, org *service.Organization) error {
+	now := time.Now()
+	query := `
+		WITH o AS (
+			INSERT INTO organizations (name, owner_user_id, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $4)
+			RETURNING id, balance, created_at, updated_at
+		), owner AS (
+			INSERT INTO organization_members (organization_id, user_id, role, spend_period_start, created_at, updated_at)
+			SELECT id, $2, $5, $4, $4, $4 FROM o
+		)
+		SELECT id, balance, created_at, updated_at FROM o
+	`
+	return scanSingleRow(ctx, r.sql, query, []any{org.Name, org.OwnerUserID, org.Status, now, service.OrganizationRoleOwner},
+		&org.ID, &org.Balance, &org.CreatedAt, &org.UpdatedAt)
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id int64) (*service.Organization, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+organizationSelectColumns+" FROM organizations o WHERE o.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrOrganizationNotFound
+	}
+	org, err := scanOrganization(rows)
+	if err != nil {
+		return nil, err
+	}
+	return org, rows.Err()
+}
+
+func (r *organizationRepository) ListByUserID(ctx context.Context, userID int64) ([]service.Organization, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+organizationSelectColumns+`, m.role
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id
+		WHERE m.user_id = $1
+		ORDER BY o.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.Organization, 0)
+	for rows.Next() {
+		var role string
+		org, err := scanOrganization(rows, &role)
+		if err != nil {
+			return nil, err
+		}
+		org.Role = role
+		out = append(out, *org)
+	}
+	return out, rows.Err()
+}
+
+func (r *organizationRepository) List(ctx context.Context, params pagination.PaginationParams, search string) ([]service.Organization, *pagination.PaginationResult, error) {
+	where := "WHERE 1=1"
+	args := []any{}
+	if search != "" {
+		args = append(args, "%"+search+"%")
+		where += " AND o.name ILIKE $1"
+	}
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM organizations o "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.Organization{}, paginationResultFromTotal(0, params), nil
+	}
+
+	args = append(args, params.Limit(), params.Offset())
+	query := fmt.Sprintf(
+		"SELECT %s FROM organizations o %s ORDER BY o.id DESC LIMIT $%d OFFSET $%d",
+		organizationSelectColumns, where, len(args)-1, len(args),
+	)
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.Organization, 0)
+	for rows.Next() {
+		org, err := scanOrganization(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, *org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *organizationRepository) UpdateName(ctx context.Context, id int64, name string) error {
+	res, err := r.sql.ExecContext(ctx, "UPDATE organizations SET name = $2, updated_at = NOW() WHERE id = $1", id, name)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, service.ErrOrganizationNotFound)
+}
+
+func (r *organizationRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	res, err := r.sql.ExecContext(ctx, "UPDATE organizations SET status = $2, updated_at = NOW() WHERE id = $1", id, status)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, service.ErrOrganizationNotFound)
+}
+
+func (r *organizationRepository) AdjustBalance(ctx context.Context, id int64, amount float64, operation string) (float64, error) {
+	var expr string
+	switch operation {
+	case "set":
+		expr = "$2"
+	case "add":
+		expr = "o.balance + $2"
+	case "subtract":
+		expr = "o.balance - $2"
+	default:
+		return 0, fmt.Errorf("unsupported balance operation: %s", operation)
+	}
+	// 先锁定原余额，变动金额与余额流水在同一条语句内写入
+	query := `
+		WITH prev AS (
+			SELECT id, balance FROM organizations WHERE id = $1 FOR UPDATE
+		), upd AS (
+			UPDATE organizations o SET balance = ` + expr + `, updated_at = NOW()
+			FROM prev WHERE o.id = prev.id
+			RETURNING o.balance, o.balance - prev.balance AS delta
+		), logged AS (
+			INSERT INTO organization_balance_logs (organization_id, type, amount, balance_after)
+			SELECT $1, $3, delta, balance FROM upd
+		)
+		SELECT balance FROM upd
+	`
+	var balance float64
+	err := scanSingleRow(ctx, r.sql, query, []any{id, amount, service.OrganizationBalanceLogTypeAdmin}, &balance)
+	return balance, translatePersistenceError(err, service.ErrOrganizationNotFound, nil)
+}
+
+func (r *organizationRepository) TransferFromUser(ctx context.Context, id, userID int64, amount float64) (float64, error) {
+	// 单条语句内完成扣减与入账：用户余额不足或组织不可用时两侧均不修改
+	query := `
+		WITH debit AS (
+			UPDATE users SET balance = balance - $3, updated_at = NOW()
+			WHERE id = $2 AND deleted_at IS NULL AND balance >= $3
+				AND EXISTS (SELECT 1 FROM organizations WHERE id = $1 AND status = $4)
+			RETURNING id
+		), credit AS (
+			UPDATE organizations SET balance = balance + $3, updated_at = NOW()
+			WHERE id = $1 AND EXISTS (SELECT 1 FROM debit)
+			RETURNING balance
+		), logged AS (
+			INSERT INTO organization_balance_logs (organization_id, user_id, type, amount, balance_after)
+			SELECT $1, $2, $5, $3, balance FROM credit
+		)
+		SELECT balance FROM credit
+	`
+	var balance float64
+	err := scanSingleRow(ctx, r.sql, query, []any{id, userID, amount, service.StatusActive, service.OrganizationBalanceLogTypeDeposit}, &balance)
+	return balance, translatePersistenceError(err, service.ErrInsufficientBalance, nil)
+}
+
+func (r *organizationRepository) ListMembers(ctx context.Context, orgID int64, monthStart time.Time) ([]service.OrganizationMember, error) {
+	rows, err := r.sql.QueryContext(ctx, organizationMemberSelect(`
+		WHERE m.organization_id = $1
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, m.created_at, m.user_id
+	`), orgID, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.OrganizationMember, 0)
+	for rows.Next() {
+		member, err := scanOrganizationMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *member)
+	}
+	return out, rows.Err()
+}
+
+func (r *organizationRepository) GetMember(ctx context.Context, orgID, userID int64, monthStart time.Time) (*service.OrganizationMember, error) {
+	rows, err := r.sql.QueryContext(ctx, organizationMemberSelect("WHERE m.organization_id = $1 AND m.user_id = $3"), orgID, monthStart, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrOrganizationMemberNotFound
+	}
+	member, err := scanOrganizationMember(rows)
+	if err != nil {
+		return nil, err
+	}
+	return member, rows.Err()
+}
+
+func (r *organizationRepository) AddMember(ctx context.Context, member *service.OrganizationMember) error {
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role, spend_limit_usd, spend_period_start, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5, $5)
+		ON CONFLICT (organization_id, user_id) DO NOTHING
+		RETURNING created_at
+	`
+	// 已是成员时 ON CONFLICT 不返回行
+	err := scanSingleRow(ctx, r.sql, query, []any{
+		member.OrganizationID,
+		member.UserID,
+		member.Role,
+		member.SpendLimitUSD,
+		time.Now(),
+	}, &member.CreatedAt)
+	return translatePersistenceError(err, service.ErrOrganizationMemberExists, nil)
+}
+
+func (r *organizationRepository) UpdateMember(ctx context.Context, orgID, userID int64, role string, spendLimitUSD *float64) error {
+	res, err := r.sql.ExecContext(ctx, `
+		UPDATE organization_members SET role = $3, spend_limit_usd = $4, updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2
+	`, orgID, userID, role, spendLimitUSD)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, service.ErrOrganizationMemberNotFound)
+}
+
+func (r *organizationRepository) RemoveMember(ctx context.Context, orgID, userID int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2", orgID, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, service.ErrOrganizationMemberNotFound)
+}
+
+func (r *organizationRepository) DisableMemberAPIKeys(ctx context.Context, orgID, userID int64) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE api_keys SET status = $3, updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`, orgID, userID, service.StatusAPIKeyDisabled)
+	return err
+}
+
+func (r *organizationRepository) GetBillingState(ctx context.Context, orgID, userID int64, monthStart time.Time) (*service.OrganizationBillingState, error) {
+	query := fmt.Sprintf(`
+		SELECT o.status, o.balance, m.spend_limit_usd, %s
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id
+		WHERE m.organization_id = $1 AND m.user_id = $2
+	`, fmt.Sprintf(organizationMemberSpendExpr, 3))
+	var (
+		state service.OrganizationBillingState
+		limit sql.NullFloat64
+	)
+	err := scanSingleRow(ctx, r.sql, query, []any{orgID, userID, monthStart}, &state.Status, &state.Balance, &limit, &state.SpendUsedUSD)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrOrganizationMemberNotFound, nil)
+	}
+	if limit.Valid {
+		v := limit.Float64
+		state.SpendLimitUSD = &v
+	}
+	return &state, nil
+}
+
+func (r *organizationRepository) RecordUsage(ctx context.Context, orgID, userID int64, cost float64, deductBalance bool, monthStart time.Time) error {
+	// 单条语句内完成组织扣费与成员用量累加，避免只成功一侧；
+	// ctx 中有事务时（异步写入与使用记录同事务扣费）在该事务内执行
+	var exec sqlExecutor = r.sql
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		exec = tx.Client()
+	}
+	_, err := exec.ExecContext(ctx, `
+		WITH debit AS (
+			UPDATE organizations SET balance = balance - $3, updated_at = NOW()
+			WHERE id = $1 AND $5
+		)
+		UPDATE organization_members SET
+			spend_used_usd = CASE WHEN spend_period_start >= $4 THEN spend_used_usd + $3 ELSE $3 END,
+			spend_period_start = GREATEST(spend_period_start, $4),
+			updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2
+	`, orgID, userID, cost, monthStart, deductBalance)
+	return err
+}
+
+// organizationMemberSelect 成员查询，$2 固定为本月起点
+func organizationMemberSelect(where string) string {
+	return fmt.Sprintf(`
+		SELECT m.organization_id, m.user_id, u.email, COALESCE(u.username, ''), m.role,
+			m.spend_limit_usd, %s, m.created_at
+		FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+	`, fmt.Sprintf(organizationMemberSpendExpr, 2)) + where
+}
+
+// scanOrganization 扫描 organizationSelectColumns，extra 为查询额外追加的列
+func scanOrganization(rows *sql.Rows, extra ...any) (*service.Organization, error) {
+	var org service.Organization
+	dest := []any{
+		&org.ID,
+		&org.Name,
+		&org.OwnerUserID,
+		&org.Balance,
+		&org.Status,
+		&org.MemberCount,
+		&org.CreatedAt,
+		&org.UpdatedAt,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func scanOrganizationMember(rows *sql.Rows) (*service.OrganizationMember, error) {
+	var (
+		member service.OrganizationMember
+		limit  sql.NullFloat64
+	)
+	if err := rows.Scan(
+		&member.OrganizationID,
+		&member.UserID,
+		&member.Email,
+		&member.Username,
+		&member.Role,
+		&limit,
+		&member.SpendUsedUSD,
+		&member.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if limit.Valid {
+		v := limit.Float64
+		member.SpendLimitUSD = &v
+	}
+	return &member, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const passkeyCeremonyKeyPrefix = "passkey:ceremony:"
+
+// PasskeyCache implements service.PasskeyCache using Redis
+type PasskeyCache struct {
+	rdb *redis.Client
+}
+
+// NewPasskeyCache creates a new passkey challenge cache
+func NewPasskeyCache(rdb *redis.Client) service.PasskeyCache {
+	return &PasskeyCache{rdb: rdb}
+}
+
+// SetCeremony stores a pending WebAuthn challenge
+func (c *PasskeyCache) SetCeremony(ctx context.Context, key string, ceremony *service.PasskeyCeremony, ttl time.Duration) error {
+	data, err := json.Marshal(ceremony)
+	if err != nil {
+		return fmt.Errorf("marshal passkey ceremony: %w", err)
+	}
+	return c.rdb.Set(ctx, passkeyCeremonyKeyPrefix+key, data, ttl).Err()
+}
+
+// ConsumeCeremony atomically reads and deletes a pending challenge (single use)
+func (c *PasskeyCache) ConsumeCeremony(ctx context.Context, key string) (*service.PasskeyCeremony, error) {
+	data, err := c.rdb.GetDel(ctx, passkeyCeremonyKeyPrefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get passkey ceremony: %w", err)
+	}
+	var ceremony service.PasskeyCeremony
+	if err := json.Unmarshal(data, &ceremony); err != nil {
+		return nil, fmt.Errorf("unmarshal passkey ceremony: %w", err)
+	}
+	return &ceremony, nil
+}
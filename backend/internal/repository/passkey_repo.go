@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+type passkeyRepository struct {
+	sql sqlExecutor
+}
+
+// NewPasskeyRepository 创建通行密钥仓储
+func NewPasskeyRepository(sqlDB *sql.DB) service.PasskeyRepository {
+	return &passkeyRepository{sql: sqlDB}
+}
+
+const passkeySelectColumns = `
+	id, user_id, name, credential_id, public_key, sign_count, aaguid, transports,
+	backup_eligible, backed_up, created_at, last_used_at
+`
+
+func (r *passkeyRepository) Create(ctx context.Context, passkey *service.UserPasskey) error {
+	transports, err := json.Marshal(nonNilStrings(passkey.Transports))
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO user_passkeys (
+			user_id, name, credential_id, public_key, sign_count, aaguid, transports,
+			backup_eligible, backed_up, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+	err = scanSingleRow(ctx, r.sql, query, []any{
+		passkey.UserID,
+		passkey.Name,
+		passkey.CredentialID,
+		passkey.PublicKey,
+		passkey.SignCount,
+		passkey.AAGUID,
+		transports,
+		passkey.BackupEligible,
+		passkey.BackedUp,
+		time.Now(),
+	}, &passkey.ID, &passkey.CreatedAt)
+	return translatePersistenceError(err, nil, service.ErrPasskeyExists)
+}
+
+func (r *passkeyRepository) GetByCredentialID(ctx context.Context, credentialID string) (*service.UserPasskey, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+passkeySelectColumns+" FROM user_passkeys WHERE credential_id = $1", credentialID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrPasskeyNotFound
+	}
+	passkey, err := scanPasskey(rows)
+	if err != nil {
+		return nil, err
+	}
+	return passkey, rows.Err()
+}
+
+func (r *passkeyRepository) ListByUserID(ctx context.Context, userID int64) ([]service.UserPasskey, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+passkeySelectColumns+" FROM user_passkeys WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UserPasskey, 0)
+	for rows.Next() {
+		passkey, err := scanPasskey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *passkey)
+	}
+	return out, rows.Err()
+}
+
+func (r *passkeyRepository) CountByUserID(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM user_passkeys WHERE user_id = $1", []any{userID}, &count)
+	return count, err
+}
+
+func (r *passkeyRepository) Rename(ctx context.Context, userID, id int64, name string) error {
+	res, err := r.sql.ExecContext(ctx, "UPDATE user_passkeys SET name = $3 WHERE id = $1 AND user_id = $2", id, userID, name)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, service.ErrPasskeyNotFound)
+}
+
+func (r *passkeyRepository) Delete(ctx context.Context, userID, id int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM user_passkeys WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, service.ErrPasskeyNotFound)
+}
+
+func (r *passkeyRepository) UpdateUsage(ctx context.Context, id int64, signCount int64, backedUp bool, usedAt time.Time) error {
+	_, err := r.sql.ExecContext(ctx,
+		"UPDATE user_passkeys SET sign_count = $2, backed_up = $3, last_used_at = $4 WHERE id = $1",
+		id, signCount, backedUp, usedAt)
+	return err
+}
+
+func (r *passkeyRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	// 单条语句完成删除与插入，避免中途失败导致用户没有可用恢复码
+	query := `
+		WITH deleted AS (
+			DELETE FROM user_recovery_codes WHERE user_id = $1
+		)
+		INSERT INTO user_recovery_codes (user_id, code_hash, created_at)
+		SELECT $1, h, NOW() FROM unnest($2::text[]) AS h
+	`
+	_, err := r.sql.ExecContext(ctx, query, userID, pq.Array(codeHashes))
+	return err
+}
+
+func (r *passkeyRepository) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	query := `
+		UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE id = (
+			SELECT id FROM user_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+	res, err := r.sql.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *passkeyRepository) CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := scanSingleRow(ctx, r.sql,
+		"SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL",
+		[]any{userID}, &count)
+	return count, err
+}
+
+func scanPasskey(rows *sql.Rows) (*service.UserPasskey, error) {
+	var (
+		passkey    service.UserPasskey
+		transports []byte
+		lastUsedAt sql.NullTime
+	)
+	if err := rows.Scan(
+		&passkey.ID,
+		&passkey.UserID,
+		&passkey.Name,
+		&passkey.CredentialID,
+		&passkey.PublicKey,
+		&passkey.SignCount,
+		&passkey.AAGUID,
+		&transports,
+		&passkey.BackupEligible,
+		&passkey.BackedUp,
+		&passkey.CreatedAt,
+		&lastUsedAt,
+	); err != nil {
+		return nil, err
+	}
+	passkey.Transports = []string{}
+	if len(transports) > 0 {
+		if err := json.Unmarshal(transports, &passkey.Transports); err != nil {
+			return nil, err
+		}
+	}
+	if lastUsedAt.Valid {
+		t := lastUsedAt.Time
+		passkey.LastUsedAt = &t
+	}
+	return &passkey, nil
+}
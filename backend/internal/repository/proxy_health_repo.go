@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type proxyHealthRepository struct {
+	sql sqlExecutor
+}
+
+// NewProxyHealthRepository 创建代理健康监控仓储
+func NewProxyHealthRepository(sqlDB *sql.DB) service.ProxyHealthRepository {
+	return &proxyHealthRepository{sql: sqlDB}
+}
+
+// InsertCheck 写入一条巡检结果
+func (r *proxyHealthRepository) InsertCheck(ctx context.Context, check *service.ProxyHealthCheck) error {
+	if check == nil {
+		return nil
+	}
+	createdAt := check.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	query := `
+		INSERT INTO proxy_health_checks (
+			proxy_id, success, latency_ms, ip_address, country, country_code,
+			exit_ip_changed, country_changed, error_message, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+	return scanSingleRow(ctx, r.sql, query, []any{
+		check.ProxyID,
+		check.Success,
+		check.LatencyMs,
+		check.IPAddress,
+		check.Country,
+		check.CountryCode,
+		check.ExitIPChanged,
+		check.CountryChanged,
+		opsNullString(check.ErrorMessage),
+		createdAt,
+	}, &check.ID)
+}
+
+// ListChecks 按时间倒序返回代理最近的巡检结果
+func (r *proxyHealthRepository) ListChecks(ctx context.Context, proxyID int64, limit int) ([]service.ProxyHealthCheck, error) {
+	query := `
+		SELECT id, proxy_id, success, latency_ms, ip_address, country, country_code,
+			exit_ip_changed, country_changed, COALESCE(error_message, ''), created_at
+		FROM proxy_health_checks
+		WHERE proxy_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.sql.QueryContext(ctx, query, proxyID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.ProxyHealthCheck, 0)
+	for rows.Next() {
+		var item service.ProxyHealthCheck
+		if err := rows.Scan(
+			&item.ID,
+			&item.ProxyID,
+			&item.Success,
+			&item.LatencyMs,
+			&item.IPAddress,
+			&item.Country,
+			&item.CountryCode,
+			&item.ExitIPChanged,
+			&item.CountryChanged,
+			&item.ErrorMessage,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const proxyHealthStateColumns = `
+	proxy_id, consecutive_failures, consecutive_successes, last_check_at, last_success,
+	last_latency_ms, COALESCE(last_error, ''), last_exit_ip, last_country_code, exit_changed_at,
+	down, down_since, auto_disabled
+`
+
+func scanProxyHealthState(scan func(dest ...any) error) (*service.ProxyHealthState, error) {
+	var (
+		state         service.ProxyHealthState
+		lastCheckAt   sql.NullTime
+		exitChangedAt sql.NullTime
+		downSince     sql.NullTime
+	)
+	if err := scan(
+		&state.ProxyID,
+		&state.ConsecutiveFailures,
+		&state.ConsecutiveSuccesses,
+		&lastCheckAt,
+		&state.LastSuccess,
+		&state.LastLatencyMs,
+		&state.LastError,
+		&state.LastExitIP,
+		&state.LastCountryCode,
+		&exitChangedAt,
+		&state.Down,
+		&downSince,
+		&state.AutoDisabled,
+	); err != nil {
+		return nil, err
+	}
+	if lastCheckAt.Valid {
+		t := lastCheckAt.Time
+		state.LastCheckAt = &t
+	}
+	if exitChangedAt.Valid {
+		t := exitChangedAt.Time
+		state.ExitChangedAt = &t
+	}
+	if downSince.Valid {
+		t := downSince.Time
+		state.DownSince = &t
+	}
+	return &state, nil
+}
+
+// GetState 获取代理健康状态，不存在时返回 nil
+func (r *proxyHealthRepository) GetState(ctx context.Context, proxyID int64) (*service.ProxyHealthState, error) {
+	query := `SELECT ` + proxyHealthStateColumns + ` FROM proxy_health_states WHERE proxy_id = $1`
+	rows, err := r.sql.QueryContext(ctx, query, proxyID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	state, err := scanProxyHealthState(rows.Scan)
+	if err != nil {
+		return nil, err
+	}
+	return state, rows.Err()
+}
+
+// ListStates 列出所有代理的健康状态
+func (r *proxyHealthRepository) ListStates(ctx context.Context) ([]service.ProxyHealthState, error) {
+	query := `SELECT ` + proxyHealthStateColumns + ` FROM proxy_health_states ORDER BY proxy_id`
+	rows, err := r.sql.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.ProxyHealthState, 0)
+	for rows.Next() {
+		state, err := scanProxyHealthState(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *state)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpsertState 写入或更新代理健康状态
+func (r *proxyHealthRepository) UpsertState(ctx context.Context, state *service.ProxyHealthState) error {
+	if state == nil {
+		return errors.New("nil proxy health state")
+	}
+	query := `
+		INSERT INTO proxy_health_states (
+			proxy_id, consecutive_failures, consecutive_successes, last_check_at, last_success,
+			last_latency_ms, last_error, last_exit_ip, last_country_code, exit_changed_at,
+			down, down_since, auto_disabled, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW())
+		ON CONFLICT (proxy_id) DO UPDATE SET
+			consecutive_failures = EXCLUDED.consecutive_failures,
+			consecutive_successes = EXCLUDED.consecutive_successes,
+			last_check_at = EXCLUDED.last_check_at,
+			last_success = EXCLUDED.last_success,
+			last_latency_ms = EXCLUDED.last_latency_ms,
+			last_error = EXCLUDED.last_error,
+			last_exit_ip = EXCLUDED.last_exit_ip,
+			last_country_code = EXCLUDED.last_country_code,
+			exit_changed_at = EXCLUDED.exit_changed_at,
+			down = EXCLUDED.down,
+			down_since = EXCLUDED.down_since,
+			auto_disabled = EXCLUDED.auto_disabled,
+			updated_at = NOW()
+	`
+	_, err := r.sql.ExecContext(ctx, query,
+		state.ProxyID,
+		state.ConsecutiveFailures,
+		state.ConsecutiveSuccesses,
+		opsNullTime(state.LastCheckAt),
+		state.LastSuccess,
+		state.LastLatencyMs,
+		opsNullString(state.LastError),
+		state.LastExitIP,
+		state.LastCountryCode,
+		opsNullTime(state.ExitChangedAt),
+		state.Down,
+		opsNullTime(state.DownSince),
+		state.AutoDisabled,
+	)
+	return err
+}
+
+// DeleteChecksBefore 删除早于 cutoff 的巡检历史
+func (r *proxyHealthRepository) DeleteChecksBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := r.sql.ExecContext(ctx, `DELETE FROM proxy_health_checks WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CountDown 统计当前判定为下线的代理数量（排除已删除代理）
+func (r *proxyHealthRepository) CountDown(ctx context.Context) (int64, error) {
+	var count int64
+	query := `
+		SELECT COUNT(*)
+		FROM proxy_health_states s
+		JOIN proxies p ON p.id = s.proxy_id
+		WHERE s.down AND p.deleted_at IS NULL
+	`
+	if err := scanSingleRow(ctx, r.sql, query, nil, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountExitChanges 统计 [start, end) 内检测到的出口 IP/国家变化次数
+func (r *proxyHealthRepository) CountExitChanges(ctx context.Context, start, end time.Time) (int64, error) {
+	var count int64
+	query := `
+		SELECT COUNT(*)
+		FROM proxy_health_checks
+		WHERE (exit_ip_changed OR country_changed) AND created_at >= $1 AND created_at < $2
+	`
+	if err := scanSingleRow(ctx, r.sql, query, []any{start, end}, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListAffectedAccounts 列出直接绑定该代理，或引用了包含该代理的代理池的账号
+func (r *proxyHealthRepository) ListAffectedAccounts(ctx context.Context, proxyID int64) ([]service.ProxyAffectedAccount, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT a.id, a.name, a.platform, a.type, a.proxy_pool_id
+		FROM accounts a
+		LEFT JOIN proxy_pools pp ON pp.id = a.proxy_pool_id
+		WHERE a.deleted_at IS NULL
+			AND (
+				(a.proxy_pool_id IS NULL AND a.proxy_id = $1)
+				OR pp.proxy_ids @> jsonb_build_array($1::bigint)
+			)
+		ORDER BY a.id
+	`, proxyID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.ProxyAffectedAccount, 0)
+	for rows.Next() {
+		var (
+			item   service.ProxyAffectedAccount
+			poolID sql.NullInt64
+		)
+		if err := rows.Scan(&item.ID, &item.Name, &item.Platform, &item.Type, &poolID); err != nil {
+			return nil, err
+		}
+		if poolID.Valid {
+			v := poolID.Int64
+			item.ProxyPoolID = &v
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
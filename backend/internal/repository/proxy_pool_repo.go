@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type proxyPoolRepository struct {
+	sql sqlExecutor
+}
+
+// NewProxyPoolRepository 创建代理池仓储
+func NewProxyPoolRepository(sqlDB *sql.DB) service.ProxyPoolRepository {
+	return &proxyPoolRepository{sql: sqlDB}
+}
+
+const proxyPoolSelectColumns = "id, name, description, strategy, proxy_ids, status, created_at, updated_at"
+
+func (r *proxyPoolRepository) Create(ctx context.Context, pool *service.ProxyPool) error {
+	proxyIDs, err := json.Marshal(nonNilInt64s(pool.ProxyIDs))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	query := `
+		INSERT INTO proxy_pools (name, description, strategy, proxy_ids, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id, created_at, updated_at
+	`
+	err = scanSingleRow(ctx, r.sql, query, []any{
+		pool.Name,
+		pool.Description,
+		pool.Strategy,
+		proxyIDs,
+		pool.Status,
+		now,
+	}, &pool.ID, &pool.CreatedAt, &pool.UpdatedAt)
+	return translatePersistenceError(err, nil, service.ErrProxyPoolExists)
+}
+
+func (r *proxyPoolRepository) GetByID(ctx context.Context, id int64) (*service.ProxyPool, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+proxyPoolSelectColumns+" FROM proxy_pools WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrProxyPoolNotFound
+	}
+	pool, err := scanProxyPool(rows)
+	if err != nil {
+		return nil, err
+	}
+	return pool, rows.Err()
+}
+
+func (r *proxyPoolRepository) Update(ctx context.Context, pool *service.ProxyPool) error {
+	proxyIDs, err := json.Marshal(nonNilInt64s(pool.ProxyIDs))
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE proxy_pools
+		SET name = $2, description = $3, strategy = $4, proxy_ids = $5, status = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err = scanSingleRow(ctx, r.sql, query, []any{
+		pool.ID,
+		pool.Name,
+		pool.Description,
+		pool.Strategy,
+		proxyIDs,
+		pool.Status,
+	}, &pool.UpdatedAt)
+	return translatePersistenceError(err, service.ErrProxyPoolNotFound, service.ErrProxyPoolExists)
+}
+
+func (r *proxyPoolRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM proxy_pools WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrProxyPoolNotFound
+	}
+	return nil
+}
+
+func (r *proxyPoolRepository) List(ctx context.Context) ([]service.ProxyPool, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+proxyPoolSelectColumns+" FROM proxy_pools ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.ProxyPool, 0)
+	for rows.Next() {
+		pool, err := scanProxyPool(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *pool)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *proxyPoolRepository) CountAccountsByPoolID(ctx context.Context) (map[int64]int64, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT proxy_pool_id, COUNT(*)
+		FROM accounts
+		WHERE proxy_pool_id IS NOT NULL AND deleted_at IS NULL
+		GROUP BY proxy_pool_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make(map[int64]int64)
+	for rows.Next() {
+		var poolID, count int64
+		if err := rows.Scan(&poolID, &count); err != nil {
+			return nil, err
+		}
+		out[poolID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *proxyPoolRepository) ListAccountPoolBindings(ctx context.Context) (map[int64]int64, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT id, proxy_pool_id
+		FROM accounts
+		WHERE proxy_pool_id IS NOT NULL AND deleted_at IS NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make(map[int64]int64)
+	for rows.Next() {
+		var accountID, poolID int64
+		if err := rows.Scan(&accountID, &poolID); err != nil {
+			return nil, err
+		}
+		out[accountID] = poolID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func scanProxyPool(scanner interface{ Scan(...any) error }) (*service.ProxyPool, error) {
+	var (
+		pool     service.ProxyPool
+		proxyIDs []byte
+	)
+	if err := scanner.Scan(
+		&pool.ID,
+		&pool.Name,
+		&pool.Description,
+		&pool.Strategy,
+		&proxyIDs,
+		&pool.Status,
+		&pool.CreatedAt,
+		&pool.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	pool.ProxyIDs = []int64{}
+	if len(proxyIDs) > 0 {
+		if err := json.Unmarshal(proxyIDs, &pool.ProxyIDs); err != nil {
+			return nil, err
+		}
+	}
+	return &pool, nil
+}
+
+func nonNilInt64s(ids []int64) []int64 {
+	if ids == nil {
+		return []int64{}
+	}
+	return ids
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// proxySubscriptionMaxBytes 订阅内容大小上限
+const proxySubscriptionMaxBytes = 8 << 20
+
+type proxySubscriptionFetcher struct {
+	httpClient *http.Client
+}
+
+// NewProxySubscriptionFetcher 创建代理订阅拉取客户端
+// 开启 URL 白名单时校验解析后的 IP，防止 DNS Rebinding
+func NewProxySubscriptionFetcher(cfg *config.Config) service.ProxySubscriptionFetcher {
+	opts := httpclient.Options{Timeout: 30 * time.Second}
+	if cfg != nil {
+		opts.ValidateResolvedIP = cfg.Security.URLAllowlist.Enabled
+		opts.AllowPrivateHosts = cfg.Security.URLAllowlist.AllowPrivateHosts
+	}
+	sharedClient, err := httpclient.GetClient(opts)
+	if err != nil {
+		sharedClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &proxySubscriptionFetcher{httpClient: sharedClient}
+}
+
+func (f *proxySubscriptionFetcher) Fetch(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, proxySubscriptionMaxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(body) > proxySubscriptionMaxBytes {
+		return "", fmt.Errorf("subscription exceeds %d bytes", proxySubscriptionMaxBytes)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type proxySubscriptionRepository struct {
+	sql sqlExecutor
+}
+
+// NewProxySubscriptionRepository 创建代理订阅仓储
+func NewProxySubscriptionRepository(sqlDB *sql.DB) service.ProxySubscriptionRepository {
+	return &proxySubscriptionRepository{sql: sqlDB}
+}
+
+const proxySubscriptionSelectColumns = `
+	id, name, url, format, default_protocol, interval_minutes, status,
+	proxy_ids, disabled_proxy_ids, last_synced_at, COALESCE(last_error, ''), created_at, updated_at
+`
+
+func (r *proxySubscriptionRepository) Create(ctx context.Context, sub *service.ProxySubscription) error {
+	proxyIDs, disabledIDs, err := marshalProxySubscriptionIDs(sub)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	query := `
+		INSERT INTO proxy_subscriptions (
+			name, url, format, default_protocol, interval_minutes, status,
+			proxy_ids, disabled_proxy_ids, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING id, created_at, updated_at
+	`
+	err = scanSingleRow(ctx, r.sql, query, []any{
+		sub.Name,
+		sub.URL,
+		sub.Format,
+		sub.DefaultProtocol,
+		sub.IntervalMinutes,
+		sub.Status,
+		proxyIDs,
+		disabledIDs,
+		now,
+	}, &sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
+	return translatePersistenceError(err, nil, service.ErrProxySubscriptionExists)
+}
+
+func (r *proxySubscriptionRepository) GetByID(ctx context.Context, id int64) (*service.ProxySubscription, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+proxySubscriptionSelectColumns+" FROM proxy_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrProxySubscriptionNotFound
+	}
+	sub, err := scanProxySubscription(rows)
+	if err != nil {
+		return nil, err
+	}
+	return sub, rows.Err()
+}
+
+func (r *proxySubscriptionRepository) Update(ctx context.Context, sub *service.ProxySubscription) error {
+	query := `
+		UPDATE proxy_subscriptions
+		SET name = $2, url = $3, format = $4, default_protocol = $5, interval_minutes = $6, status = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err := scanSingleRow(ctx, r.sql, query, []any{
+		sub.ID,
+		sub.Name,
+		sub.URL,
+		sub.Format,
+		sub.DefaultProtocol,
+		sub.IntervalMinutes,
+		sub.Status,
+	}, &sub.UpdatedAt)
+	return translatePersistenceError(err, service.ErrProxySubscriptionNotFound, service.ErrProxySubscriptionExists)
+}
+
+func (r *proxySubscriptionRepository) UpdateSyncResult(ctx context.Context, sub *service.ProxySubscription) error {
+	proxyIDs, disabledIDs, err := marshalProxySubscriptionIDs(sub)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE proxy_subscriptions
+		SET proxy_ids = $2, disabled_proxy_ids = $3, last_synced_at = $4, last_error = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err = scanSingleRow(ctx, r.sql, query, []any{
+		sub.ID,
+		proxyIDs,
+		disabledIDs,
+		opsNullTime(sub.LastSyncedAt),
+		opsNullString(sub.LastError),
+	}, &sub.UpdatedAt)
+	return translatePersistenceError(err, service.ErrProxySubscriptionNotFound, nil)
+}
+
+func (r *proxySubscriptionRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM proxy_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrProxySubscriptionNotFound
+	}
+	return nil
+}
+
+func (r *proxySubscriptionRepository) List(ctx context.Context) ([]service.ProxySubscription, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+proxySubscriptionSelectColumns+" FROM proxy_subscriptions ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.ProxySubscription, 0)
+	for rows.Next() {
+		sub, err := scanProxySubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func marshalProxySubscriptionIDs(sub *service.ProxySubscription) ([]byte, []byte, error) {
+	proxyIDs, err := json.Marshal(nonNilInt64s(sub.ProxyIDs))
+	if err != nil {
+		return nil, nil, err
+	}
+	disabledIDs, err := json.Marshal(nonNilInt64s(sub.DisabledProxyIDs))
+	if err != nil {
+		return nil, nil, err
+	}
+	return proxyIDs, disabledIDs, nil
+}
+
+func scanProxySubscription(scanner interface{ Scan(...any) error }) (*service.ProxySubscription, error) {
+	var (
+		sub          service.ProxySubscription
+		proxyIDs     []byte
+		disabledIDs  []byte
+		lastSyncedAt sql.NullTime
+	)
+	if err := scanner.Scan(
+		&sub.ID,
+		&sub.Name,
+		&sub.URL,
+		&sub.Format,
+		&sub.DefaultProtocol,
+		&sub.IntervalMinutes,
+		&sub.Status,
+		&proxyIDs,
+		&disabledIDs,
+		&lastSyncedAt,
+		&sub.LastError,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	sub.ProxyIDs = []int64{}
+	sub.DisabledProxyIDs = []int64{}
+	if len(proxyIDs) > 0 {
+		if err := json.Unmarshal(proxyIDs, &sub.ProxyIDs); err != nil {
+			return nil, err
+		}
+	}
+	if len(disabledIDs) > 0 {
+		if err := json.Unmarshal(disabledIDs, &sub.DisabledProxyIDs); err != nil {
+			return nil, err
+		}
+	}
+	if lastSyncedAt.Valid {
+		t := lastSyncedAt.Time
+		sub.LastSyncedAt = &t
+	}
+	return &sub, nil
+}
@@ -0,0 +1,433 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type referralRepository struct {
+	sql sqlExecutor
+}
+
+// NewReferralRepository 创建推荐计划仓储
+func NewReferralRepository(sqlDB *sql.DB) service.ReferralRepository {
+	return &referralRepository{sql: sqlDB}
+}
+
+const referralSelectColumns = `
+	referee_user_id, referrer_user_id, code, commission_percent, commission_until, first_topup_at, created_at
+`
+
+const referralCommissionSelectColumns = `
+	c.id, c.referrer_user_id, c.referee_user_id,
+	COALESCE(ru.email, ''), COALESCE(eu.email, ''),
+	c.commission_type, c.source_amount, c.amount, to_char(c.period_date, 'YYYY-MM-DD'),
+	c.status, c.reviewed_by, c.reviewed_at, c.notes, c.created_at, c.updated_at
+`
+
+const referralCommissionFrom = `
+	FROM referral_commissions c
+	LEFT JOIN users ru ON ru.id = c.referrer_user_id
+	LEFT JOIN users eu ON eu.id = c.referee_user_id
+`
+
+func (r *referralRepository) GetCode(ctx context.Context, userID int64) (string, error) {
+	var code string
+	err := scanSingleRow(ctx, r.sql, "SELECT code FROM referral_codes WHERE user_id = $1", []any{userID}, &code)
+	if err != nil {
+		return "", translatePersistenceError(err, service.ErrReferralCodeNotFound, nil)
+	}
+	return code, nil
+}
+
+func (r *referralRepository) CreateCode(ctx context.Context, userID int64, code string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		INSERT INTO referral_codes (user_id, code, created_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO NOTHING
+	`, userID, code)
+	return translatePersistenceError(err, nil, service.ErrReferralCodeConflict)
+}
+
+func (r *referralRepository) GetUserIDByCode(ctx context.Context, code string) (int64, error) {
+	var userID int64
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT rc.user_id
+		FROM referral_codes rc
+		JOIN users u ON u.id = rc.user_id
+		WHERE rc.code = $1 AND u.deleted_at IS NULL AND u.status = $2
+	`, []any{code, service.StatusActive}, &userID)
+	if err != nil {
+		return 0, translatePersistenceError(err, service.ErrReferralCodeNotFound, nil)
+	}
+	return userID, nil
+}
+
+func (r *referralRepository) Create(ctx context.Context, referral *service.Referral) error {
+	_, err := r.sql.ExecContext(ctx, `
+		INSERT INTO referrals (referee_user_id, referrer_user_id, code, commission_percent, commission_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`,
+		referral.RefereeUserID,
+		referral.ReferrerUserID,
+		referral.Code,
+		referral.CommissionPercent,
+		referral.CommissionUntil,
+		referral.CreatedAt,
+	)
+	return translatePersistenceError(err, nil, service.ErrReferralAlreadyBound)
+}
+
+func (r *referralRepository) GetByReferee(ctx context.Context, refereeUserID int64) (*service.Referral, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+referralSelectColumns+" FROM referrals WHERE referee_user_id = $1", refereeUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrReferralNotFound
+	}
+	referral, err := scanReferral(rows)
+	if err != nil {
+		return nil, err
+	}
+	return referral, rows.Err()
+}
+
+func (r *referralRepository) MarkFirstTopUp(ctx context.Context, refereeUserID int64, at time.Time) (*service.Referral, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		UPDATE referrals SET first_topup_at = $2
+		WHERE referee_user_id = $1 AND first_topup_at IS NULL
+		RETURNING `+referralSelectColumns, refereeUserID, at)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	referral, err := scanReferral(rows)
+	if err != nil {
+		return nil, err
+	}
+	return referral, rows.Err()
+}
+
+func (r *referralRepository) ListReferees(ctx context.Context, referrerUserID int64, params pagination.PaginationParams) ([]service.ReferralReferee, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM referrals WHERE referrer_user_id = $1", []any{referrerUserID}, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.ReferralReferee{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT r.referee_user_id, COALESCE(u.email, ''), r.created_at, r.first_topup_at, r.commission_until,
+			COALESCE(SUM(c.amount) FILTER (WHERE c.status = 'pending'), 0),
+			COALESCE(SUM(c.amount) FILTER (WHERE c.status = 'approved'), 0)
+		FROM referrals r
+		LEFT JOIN users u ON u.id = r.referee_user_id
+		LEFT JOIN referral_commissions c ON c.referrer_user_id = r.referrer_user_id AND c.referee_user_id = r.referee_user_id
+		WHERE r.referrer_user_id = $1
+		GROUP BY r.referee_user_id, u.email, r.created_at, r.first_topup_at, r.commission_until
+		ORDER BY r.created_at DESC, r.referee_user_id DESC
+		LIMIT $2 OFFSET $3
+	`, referrerUserID, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.ReferralReferee, 0)
+	for rows.Next() {
+		var (
+			item            service.ReferralReferee
+			firstTopUpAt    sql.NullTime
+			commissionUntil sql.NullTime
+		)
+		if err := rows.Scan(
+			&item.UserID,
+			&item.Email,
+			&item.CreatedAt,
+			&firstTopUpAt,
+			&commissionUntil,
+			&item.PendingAmount,
+			&item.ApprovedAmount,
+		); err != nil {
+			return nil, nil, err
+		}
+		item.FirstTopUpAt = nullTimePtr(firstTopUpAt)
+		item.CommissionUntil = nullTimePtr(commissionUntil)
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *referralRepository) GetStats(ctx context.Context, referrerUserID int64) (*service.ReferralStats, error) {
+	stats := &service.ReferralStats{}
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT
+			(SELECT COUNT(*) FROM referrals WHERE referrer_user_id = $1),
+			(SELECT COUNT(*) FROM referrals WHERE referrer_user_id = $1 AND first_topup_at IS NOT NULL),
+			COALESCE(SUM(amount) FILTER (WHERE status = 'pending'), 0),
+			COALESCE(SUM(amount) FILTER (WHERE status = 'approved'), 0),
+			COALESCE(SUM(amount) FILTER (WHERE status = 'rejected'), 0)
+		FROM referral_commissions
+		WHERE referrer_user_id = $1
+	`, []any{referrerUserID},
+		&stats.RefereeCount,
+		&stats.ToppedUpCount,
+		&stats.PendingAmount,
+		&stats.ApprovedAmount,
+		&stats.RejectedAmount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (r *referralRepository) CreateCommission(ctx context.Context, commission *service.ReferralCommission) error {
+	query := `
+		INSERT INTO referral_commissions
+			(referrer_user_id, referee_user_id, commission_type, source_amount, amount, period_date, status, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+	return scanSingleRow(ctx, r.sql, query, []any{
+		commission.ReferrerUserID,
+		commission.RefereeUserID,
+		commission.Type,
+		commission.SourceAmount,
+		commission.Amount,
+		commission.PeriodDate,
+		commission.Status,
+		commission.Notes,
+	}, &commission.ID, &commission.CreatedAt, &commission.UpdatedAt)
+}
+
+func (r *referralRepository) AccrueSpendCommission(ctx context.Context, referrerUserID, refereeUserID int64, spend, amount float64, periodDate string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		INSERT INTO referral_commissions
+			(referrer_user_id, referee_user_id, commission_type, source_amount, amount, period_date, status)
+		VALUES ($1, $2, 'spend', $3, $4, $5, 'pending')
+		ON CONFLICT (referrer_user_id, referee_user_id, period_date) WHERE commission_type = 'spend' AND status = 'pending'
+		DO UPDATE SET
+			source_amount = referral_commissions.source_amount + EXCLUDED.source_amount,
+			amount = referral_commissions.amount + EXCLUDED.amount,
+			updated_at = NOW()
+	`, referrerUserID, refereeUserID, spend, amount, periodDate)
+	return err
+}
+
+func (r *referralRepository) ListCommissions(ctx context.Context, filter service.ReferralCommissionFilter, params pagination.PaginationParams) ([]service.ReferralCommission, *pagination.PaginationResult, error) {
+	where, args := buildReferralCommissionWhere(filter)
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM referral_commissions c "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.ReferralCommission{}, paginationResultFromTotal(0, params), nil
+	}
+
+	args = append(args, params.Limit(), params.Offset())
+	query := fmt.Sprintf(
+		"SELECT %s %s %s ORDER BY c.created_at DESC, c.id DESC LIMIT $%d OFFSET $%d",
+		referralCommissionSelectColumns, referralCommissionFrom, where, len(args)-1, len(args),
+	)
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.ReferralCommission, 0)
+	for rows.Next() {
+		item, err := scanReferralCommission(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func buildReferralCommissionWhere(filter service.ReferralCommissionFilter) (string, []any) {
+	clauses := []string{"1=1"}
+	args := []any{}
+
+	if filter.ReferrerUserID > 0 {
+		args = append(args, filter.ReferrerUserID)
+		clauses = append(clauses, "c.referrer_user_id = $"+itoa(len(args)))
+	}
+	if filter.RefereeUserID > 0 {
+		args = append(args, filter.RefereeUserID)
+		clauses = append(clauses, "c.referee_user_id = $"+itoa(len(args)))
+	}
+	if t := strings.TrimSpace(filter.Type); t != "" {
+		args = append(args, t)
+		clauses = append(clauses, "c.commission_type = $"+itoa(len(args)))
+	}
+	if status := strings.TrimSpace(filter.Status); status != "" {
+		args = append(args, status)
+		clauses = append(clauses, "c.status = $"+itoa(len(args)))
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (r *referralRepository) ApproveCommission(ctx context.Context, id, reviewerID int64, notes string, rewardCode string) (*service.ReferralCommission, error) {
+	// 审核状态变更、推荐人余额入账与余额变动记录在同一条语句中完成
+	query := `
+		WITH c AS (
+			UPDATE referral_commissions
+			SET status = 'approved', reviewed_by = $2, reviewed_at = NOW(), notes = $3, updated_at = NOW()
+			WHERE id = $1 AND status = 'pending'
+			RETURNING *
+		), credit AS (
+			UPDATE users u SET balance = u.balance + c.amount, updated_at = NOW()
+			FROM c
+			WHERE u.id = c.referrer_user_id
+		), reward AS (
+			INSERT INTO redeem_codes (code, type, value, status, used_by, used_at, notes, created_at)
+			SELECT $4, $5, c.amount, $6, c.referrer_user_id, NOW(),
+				'referral commission #' || c.id || ' (' || c.commission_type || ')', NOW()
+			FROM c
+			WHERE c.amount > 0 AND $4 <> ''
+		)
+		SELECT ` + referralCommissionSelectColumns + `
+		FROM c
+		LEFT JOIN users ru ON ru.id = c.referrer_user_id
+		LEFT JOIN users eu ON eu.id = c.referee_user_id
+	`
+	return r.reviewCommission(ctx, query, id, reviewerID, notes, rewardCode, service.AdjustmentTypeReferralReward, service.StatusUsed)
+}
+
+func (r *referralRepository) RejectCommission(ctx context.Context, id, reviewerID int64, notes string) (*service.ReferralCommission, error) {
+	query := `
+		WITH c AS (
+			UPDATE referral_commissions
+			SET status = 'rejected', reviewed_by = $2, reviewed_at = NOW(), notes = $3, updated_at = NOW()
+			WHERE id = $1 AND status = 'pending'
+			RETURNING *
+		)
+		SELECT ` + referralCommissionSelectColumns + `
+		FROM c
+		LEFT JOIN users ru ON ru.id = c.referrer_user_id
+		LEFT JOIN users eu ON eu.id = c.referee_user_id
+	`
+	return r.reviewCommission(ctx, query, id, reviewerID, notes)
+}
+
+func (r *referralRepository) reviewCommission(ctx context.Context, query string, id, reviewerID int64, notes string, extra ...any) (*service.ReferralCommission, error) {
+	args := append([]any{id, opsNullInt64(&reviewerID), notes}, extra...)
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if rows.Next() {
+		item, err := scanReferralCommission(rows)
+		if err != nil {
+			return nil, err
+		}
+		return item, rows.Err()
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 未更新任何记录：区分不存在与已审核
+	var status string
+	err = scanSingleRow(ctx, r.sql, "SELECT status FROM referral_commissions WHERE id = $1", []any{id}, &status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service.ErrReferralCommissionNotFound
+		}
+		return nil, err
+	}
+	return nil, service.ErrReferralCommissionNotPending
+}
+
+func scanReferral(rows *sql.Rows) (*service.Referral, error) {
+	var (
+		referral        service.Referral
+		commissionUntil sql.NullTime
+		firstTopUpAt    sql.NullTime
+	)
+	if err := rows.Scan(
+		&referral.RefereeUserID,
+		&referral.ReferrerUserID,
+		&referral.Code,
+		&referral.CommissionPercent,
+		&commissionUntil,
+		&firstTopUpAt,
+		&referral.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	referral.CommissionUntil = nullTimePtr(commissionUntil)
+	referral.FirstTopUpAt = nullTimePtr(firstTopUpAt)
+	return &referral, nil
+}
+
+func scanReferralCommission(rows *sql.Rows) (*service.ReferralCommission, error) {
+	var (
+		item       service.ReferralCommission
+		reviewedBy sql.NullInt64
+		reviewedAt sql.NullTime
+	)
+	if err := rows.Scan(
+		&item.ID,
+		&item.ReferrerUserID,
+		&item.RefereeUserID,
+		&item.ReferrerEmail,
+		&item.RefereeEmail,
+		&item.Type,
+		&item.SourceAmount,
+		&item.Amount,
+		&item.PeriodDate,
+		&item.Status,
+		&reviewedBy,
+		&reviewedAt,
+		&item.Notes,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if reviewedBy.Valid {
+		v := reviewedBy.Int64
+		item.ReviewedBy = &v
+	}
+	item.ReviewedAt = nullTimePtr(reviewedAt)
+	return &item, nil
+}
+
+func nullTimePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time
+	return &t
+}
@@ -0,0 +1,113 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReferralRepoSuite struct {
+	suite.Suite
+	ctx    context.Context
+	client *dbent.Client
+	repo   *referralRepository
+}
+
+func (s *ReferralRepoSuite) SetupTest() {
+	s.ctx = context.Background()
+	tx := testEntTx(s.T())
+	s.client = tx.Client()
+	s.repo = &referralRepository{sql: tx}
+}
+
+func TestReferralRepoSuite(t *testing.T) {
+	suite.Run(t, new(ReferralRepoSuite))
+}
+
+func (s *ReferralRepoSuite) createUser(email string, balance float64) *dbent.User {
+	u, err := s.client.User.Create().
+		SetEmail(email).
+		SetPasswordHash("test-password-hash").
+		SetBalance(balance).
+		Save(s.ctx)
+	s.Require().NoError(err, "create user")
+	return u
+}
+
+func (s *ReferralRepoSuite) createPendingCommission(referrerID, refereeID int64, amount float64) *service.ReferralCommission {
+	commission := &service.ReferralCommission{
+		ReferrerUserID: referrerID,
+		RefereeUserID:  refereeID,
+		Type:           service.ReferralCommissionTypeFirstTopUp,
+		SourceAmount:   100,
+		Amount:         amount,
+		PeriodDate:     "2026-10-01",
+		Status:         service.ReferralCommissionStatusPending,
+	}
+	s.Require().NoError(s.repo.CreateCommission(s.ctx, commission), "create commission")
+	return commission
+}
+
+// --- ApproveCommission / RejectCommission ---
+
+func (s *ReferralRepoSuite) TestApproveCommission_CreditsReferrerAndRecordsReward() {
+	referrer := s.createUser("referral-approve-referrer@test.com", 10)
+	referee := s.createUser("referral-approve-referee@test.com", 0)
+	commission := s.createPendingCommission(referrer.ID, referee.ID, 5)
+
+	got, err := s.repo.ApproveCommission(s.ctx, commission.ID, 99, "ok", "REFERRAL-REWARD-APPROVE")
+	s.Require().NoError(err, "ApproveCommission")
+	s.Require().Equal(service.ReferralCommissionStatusApproved, got.Status)
+	s.Require().Equal(service.ReferralCommissionTypeFirstTopUp, got.Type)
+	s.Require().Equal("ok", got.Notes)
+	s.Require().NotNil(got.ReviewedBy)
+	s.Require().Equal(int64(99), *got.ReviewedBy)
+	s.Require().Equal(referrer.Email, got.ReferrerEmail)
+
+	updated, err := s.client.User.Get(s.ctx, referrer.ID)
+	s.Require().NoError(err)
+	s.Require().InDelta(15, updated.Balance, 1e-9, "referrer balance credited")
+
+	reward, err := NewRedeemCodeRepository(s.client).GetByCode(s.ctx, "REFERRAL-REWARD-APPROVE")
+	s.Require().NoError(err, "reward record")
+	s.Require().Equal(service.AdjustmentTypeReferralReward, reward.Type)
+	s.Require().Equal(service.StatusUsed, reward.Status)
+	s.Require().InDelta(5, reward.Value, 1e-9)
+	s.Require().NotNil(reward.UsedBy)
+	s.Require().Equal(referrer.ID, *reward.UsedBy)
+	s.Require().Contains(reward.Notes, "(first_topup)")
+
+	// 已审核的记录不可再次入账
+	_, err = s.repo.ApproveCommission(s.ctx, commission.ID, 99, "again", "REFERRAL-REWARD-AGAIN")
+	s.Require().ErrorIs(err, service.ErrReferralCommissionNotPending)
+	updated, err = s.client.User.Get(s.ctx, referrer.ID)
+	s.Require().NoError(err)
+	s.Require().InDelta(15, updated.Balance, 1e-9, "balance unchanged on second approval")
+}
+
+func (s *ReferralRepoSuite) TestApproveCommission_NotFound() {
+	_, err := s.repo.ApproveCommission(s.ctx, 999999999, 99, "", "REFERRAL-REWARD-MISSING")
+	s.Require().ErrorIs(err, service.ErrReferralCommissionNotFound)
+}
+
+func (s *ReferralRepoSuite) TestRejectCommission_LeavesBalanceUntouched() {
+	referrer := s.createUser("referral-reject-referrer@test.com", 10)
+	referee := s.createUser("referral-reject-referee@test.com", 0)
+	commission := s.createPendingCommission(referrer.ID, referee.ID, 5)
+
+	got, err := s.repo.RejectCommission(s.ctx, commission.ID, 99, "fraud")
+	s.Require().NoError(err, "RejectCommission")
+	s.Require().Equal(service.ReferralCommissionStatusRejected, got.Status)
+
+	updated, err := s.client.User.Get(s.ctx, referrer.ID)
+	s.Require().NoError(err)
+	s.Require().InDelta(10, updated.Balance, 1e-9)
+
+	_, err = s.repo.ApproveCommission(s.ctx, commission.ID, 99, "", "REFERRAL-REWARD-REJECTED")
+	s.Require().ErrorIs(err, service.ErrReferralCommissionNotPending)
+}
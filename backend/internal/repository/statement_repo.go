@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+type statementRepository struct {
+	sql sqlExecutor
+}
+
+// NewStatementRepository 创建对账单仓储
+func NewStatementRepository(sqlDB *sql.DB) service.StatementRepository {
+	return &statementRepository{sql: sqlDB}
+}
+
+const statementDateLayout = "2006-01-02"
+
+const statementSelectColumns = `
+	id, number, subject_type, subject_id, to_char(period_start, 'YYYY-MM-DD'),
+	opening_balance, top_ups, adjustments, balance_usage, subscription_usage, closing_balance,
+	lines, generated_at, emailed_at
+`
+
+// statementBalanceCreditTypes 影响用户余额的兑换/调整记录类型
+var statementBalanceCreditTypes = []string{
+	service.RedeemTypeBalance,
+	service.AdjustmentTypeAdminBalance,
+	service.AdjustmentTypeReferralReward,
+}
+
+func (r *statementRepository) Upsert(ctx context.Context, st *service.Statement) error {
+	lines, err := json.Marshal(st.Lines)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO statements (
+			number, subject_type, subject_id, period_start,
+			opening_balance, top_ups, adjustments, balance_usage, subscription_usage, closing_balance,
+			lines, generated_at
+		) VALUES ($1, $2, $3, $4::date, $5, $6, $7, $8, $9, $10, $11, NOW())
+		ON CONFLICT (subject_type, subject_id, period_start) DO UPDATE SET
+			opening_balance = EXCLUDED.opening_balance,
+			top_ups = EXCLUDED.top_ups,
+			adjustments = EXCLUDED.adjustments,
+			balance_usage = EXCLUDED.balance_usage,
+			subscription_usage = EXCLUDED.subscription_usage,
+			closing_balance = EXCLUDED.closing_balance,
+			lines = EXCLUDED.lines,
+			generated_at = EXCLUDED.generated_at
+		RETURNING id, number, generated_at, emailed_at
+	`
+	var emailedAt sql.NullTime
+	err = scanSingleRow(ctx, r.sql, query, []any{
+		st.Number, st.SubjectType, st.SubjectID, st.PeriodStart.Format(statementDateLayout),
+		st.OpeningBalance, st.TopUps, st.Adjustments, st.BalanceUsage, st.SubscriptionUsage, st.ClosingBalance,
+		lines,
+	}, &st.ID, &st.Number, &st.GeneratedAt, &emailedAt)
+	if err != nil {
+		return err
+	}
+	st.EmailedAt = nullTimePtr(emailedAt)
+	return nil
+}
+
+func (r *statementRepository) GetByID(ctx context.Context, id int64) (*service.Statement, error) {
+	return r.getOne(ctx, "WHERE id = $1", id)
+}
+
+func (r *statementRepository) GetBySubjectPeriod(ctx context.Context, subjectType string, subjectID int64, periodStart time.Time) (*service.Statement, error) {
+	return r.getOne(ctx, "WHERE subject_type = $1 AND subject_id = $2 AND period_start = $3::date",
+		subjectType, subjectID, periodStart.Format(statementDateLayout))
+}
+
+func (r *statementRepository) getOne(ctx context.Context, where string, args ...any) (*service.Statement, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+statementSelectColumns+" FROM statements "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrStatementNotFound
+	}
+	return scanStatement(rows)
+}
+
+func (r *statementRepository) List(ctx context.Context, filter service.StatementFilter, params pagination.PaginationParams) ([]service.Statement, *pagination.PaginationResult, error) {
+	clauses := []string{"1=1"}
+	args := []any{}
+	if filter.SubjectType != "" {
+		args = append(args, filter.SubjectType)
+		clauses = append(clauses, "subject_type = $"+itoa(len(args)))
+	}
+	if filter.SubjectID > 0 {
+		args = append(args, filter.SubjectID)
+		clauses = append(clauses, "subject_id = $"+itoa(len(args)))
+	}
+	if filter.PeriodStart != nil {
+		args = append(args, filter.PeriodStart.Format(statementDateLayout))
+		clauses = append(clauses, "period_start = $"+itoa(len(args))+"::date")
+	}
+	where := "WHERE " + strings.Join(clauses, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM statements "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.Statement{}, paginationResultFromTotal(0, params), nil
+	}
+
+	args = append(args, params.Limit(), params.Offset())
+	query := fmt.Sprintf("SELECT %s FROM statements %s ORDER BY period_start DESC, id DESC LIMIT $%d OFFSET $%d",
+		statementSelectColumns, where, len(args)-1, len(args))
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.Statement, 0)
+	for rows.Next() {
+		st, err := scanStatement(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, *st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *statementRepository) MarkEmailed(ctx context.Context, id int64, at time.Time) error {
+	res, err := r.sql.ExecContext(ctx, "UPDATE statements SET emailed_at = $2 WHERE id = $1", id, at)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, service.ErrStatementNotFound)
+}
+
+func (r *statementRepository) GetSubject(ctx context.Context, subjectType string, subjectID int64) (*service.StatementSubject, error) {
+	var query string
+	switch subjectType {
+	case service.StatementSubjectUser:
+		query = `
+			SELECT COALESCE(NULLIF(username, ''), email), email, balance
+			FROM users WHERE id = $1 AND deleted_at IS NULL
+		`
+	case service.StatementSubjectOrganization:
+		// 组织对账单发送给所有者
+		query = `
+			SELECT o.name, COALESCE(u.email, ''), o.balance
+			FROM organizations o
+			LEFT JOIN users u ON u.id = o.owner_user_id AND u.deleted_at IS NULL
+			WHERE o.id = $1
+		`
+	default:
+		return nil, service.ErrStatementSubjectTypeInvalid
+	}
+	subject := &service.StatementSubject{}
+	err := scanSingleRow(ctx, r.sql, query, []any{subjectID}, &subject.Name, &subject.Email, &subject.Balance)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrStatementSubjectNotFound, nil)
+	}
+	return subject, nil
+}
+
+func (r *statementRepository) ListActiveSubjects(ctx context.Context, subjectType string, start, end time.Time) ([]int64, error) {
+	var query string
+	args := []any{start.Format(statementDateLayout), end.Format(statementDateLayout), start, end}
+	switch subjectType {
+	case service.StatementSubjectUser:
+		query = `
+			SELECT id FROM users
+			WHERE deleted_at IS NULL AND id IN (
+				SELECT user_id FROM usage_dashboard_user_daily
+				WHERE organization_id = 0 AND bucket_date >= $1::date AND bucket_date < $2::date
+				UNION
+				SELECT used_by FROM redeem_codes
+				WHERE used_by IS NOT NULL AND status = $5 AND type = ANY($6) AND used_at >= $3 AND used_at < $4
+				UNION
+				SELECT user_id FROM organization_balance_logs
+				WHERE user_id IS NOT NULL AND created_at >= $3 AND created_at < $4
+			)
+			ORDER BY id
+		`
+		args = append(args, service.StatusUsed, pq.Array(statementBalanceCreditTypes))
+	case service.StatementSubjectOrganization:
+		query = `
+			SELECT id FROM organizations
+			WHERE id IN (
+				SELECT organization_id FROM usage_dashboard_user_daily
+				WHERE organization_id <> 0 AND bucket_date >= $1::date AND bucket_date < $2::date
+				UNION
+				SELECT organization_id FROM organization_balance_logs
+				WHERE created_at >= $3 AND created_at < $4
+			)
+			ORDER BY id
+		`
+	default:
+		return nil, service.ErrStatementSubjectTypeInvalid
+	}
+
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// statementUsageSubjectClause 个人对账单只统计个人 Key（organization_id = 0），组织 Key 的用量计入组织对账单
+func statementUsageSubjectClause(subjectType string) (string, error) {
+	switch subjectType {
+	case service.StatementSubjectUser:
+		return "d.user_id = $1 AND d.organization_id = 0", nil
+	case service.StatementSubjectOrganization:
+		return "d.organization_id = $1", nil
+	default:
+		return "", service.ErrStatementSubjectTypeInvalid
+	}
+}
+
+func (r *statementRepository) GetUsageLines(ctx context.Context, subjectType string, subjectID int64, startDate, endDate string) ([]service.StatementLine, error) {
+	subjectClause, err := statementUsageSubjectClause(subjectType)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT
+			d.model,
+			d.group_id,
+			COALESCE(g.name, ''),
+			d.billing_type,
+			COALESCE(SUM(d.total_requests), 0),
+			COALESCE(SUM(d.input_tokens), 0),
+			COALESCE(SUM(d.output_tokens), 0),
+			COALESCE(SUM(d.cache_creation_tokens), 0),
+			COALESCE(SUM(d.cache_read_tokens), 0),
+			COALESCE(SUM(d.total_cost), 0),
+			COALESCE(SUM(d.actual_cost), 0)
+		FROM usage_dashboard_user_daily d
+		LEFT JOIN groups g ON g.id = d.group_id
+		WHERE ` + subjectClause + ` AND d.bucket_date >= $2::date AND d.bucket_date < $3::date
+		GROUP BY d.model, d.group_id, g.name, d.billing_type
+		ORDER BY SUM(d.actual_cost) DESC, d.model ASC, d.group_id ASC
+	`
+	rows, err := r.sql.QueryContext(ctx, query, subjectID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	lines := make([]service.StatementLine, 0)
+	for rows.Next() {
+		var line service.StatementLine
+		if err := rows.Scan(
+			&line.Model,
+			&line.GroupID,
+			&line.GroupName,
+			&line.BillingType,
+			&line.Requests,
+			&line.InputTokens,
+			&line.OutputTokens,
+			&line.CacheCreationTokens,
+			&line.CacheReadTokens,
+			&line.TotalCost,
+			&line.ActualCost,
+		); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+func (r *statementRepository) SumBalanceUsageSince(ctx context.Context, subjectType string, subjectID int64, since time.Time) (float64, error) {
+	// 当前余额已实时扣减，倒推期末余额时必须读取明细表，日聚合可能尚未追上
+	var subjectClause string
+	switch subjectType {
+	case service.StatementSubjectUser:
+		subjectClause = "user_id = $1 AND organization_id IS NULL"
+	case service.StatementSubjectOrganization:
+		subjectClause = "organization_id = $1"
+	default:
+		return 0, service.ErrStatementSubjectTypeInvalid
+	}
+	query := `
+		SELECT COALESCE(SUM(actual_cost), 0)
+		FROM usage_logs
+		WHERE ` + subjectClause + ` AND billing_type = $3 AND created_at >= $2
+	`
+	var total float64
+	err := scanSingleRow(ctx, r.sql, query, []any{subjectID, since, service.BillingTypeBalance}, &total)
+	return total, err
+}
+
+func (r *statementRepository) SumCredits(ctx context.Context, subjectType string, subjectID int64, start time.Time, end *time.Time) (*service.StatementCredits, error) {
+	var endArg any
+	if end != nil {
+		endArg = *end
+	}
+	var query string
+	args := []any{subjectID, start, endArg}
+	switch subjectType {
+	case service.StatementSubjectUser:
+		// 兑换码 / 管理员调整 / 推荐返佣按记录金额计入，划转到组织计为扣减
+		query = `
+			SELECT
+				COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0),
+				COALESCE(SUM(CASE WHEN amount < 0 THEN amount ELSE 0 END), 0)
+			FROM (
+				SELECT value AS amount FROM redeem_codes
+				WHERE used_by = $1 AND status = $4 AND type = ANY($5)
+					AND used_at >= $2 AND ($3::timestamptz IS NULL OR used_at < $3)
+				UNION ALL
+				SELECT -amount FROM organization_balance_logs
+				WHERE user_id = $1 AND type = $6
+					AND created_at >= $2 AND ($3::timestamptz IS NULL OR created_at < $3)
+			) credits
+		`
+		args = append(args, service.StatusUsed, pq.Array(statementBalanceCreditTypes), service.OrganizationBalanceLogTypeDeposit)
+	case service.StatementSubjectOrganization:
+		query = `
+			SELECT
+				COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0),
+				COALESCE(SUM(CASE WHEN amount < 0 THEN amount ELSE 0 END), 0)
+			FROM organization_balance_logs
+			WHERE organization_id = $1
+				AND created_at >= $2 AND ($3::timestamptz IS NULL OR created_at < $3)
+		`
+	default:
+		return nil, service.ErrStatementSubjectTypeInvalid
+	}
+	credits := &service.StatementCredits{}
+	if err := scanSingleRow(ctx, r.sql, query, args, &credits.TopUps, &credits.Adjustments); err != nil {
+		return nil, err
+	}
+	return credits, nil
+}
+
+func scanStatement(scanner interface{ Scan(...any) error }) (*service.Statement, error) {
+	var (
+		st          service.Statement
+		periodStart string
+		lines       []byte
+		emailedAt   sql.NullTime
+	)
+	if err := scanner.Scan(
+		&st.ID,
+		&st.Number,
+		&st.SubjectType,
+		&st.SubjectID,
+		&periodStart,
+		&st.OpeningBalance,
+		&st.TopUps,
+		&st.Adjustments,
+		&st.BalanceUsage,
+		&st.SubscriptionUsage,
+		&st.ClosingBalance,
+		&lines,
+		&st.GeneratedAt,
+		&emailedAt,
+	); err != nil {
+		return nil, err
+	}
+	start, err := timezone.ParseInLocation(statementDateLayout, periodStart)
+	if err != nil {
+		return nil, err
+	}
+	st.PeriodStart = start
+	st.PeriodEnd = start.AddDate(0, 1, 0)
+	st.Period = start.Format("2006-01")
+	st.EmailedAt = nullTimePtr(emailedAt)
+	if len(lines) > 0 {
+		if err := json.Unmarshal(lines, &st.Lines); err != nil {
+			return nil, err
+		}
+	}
+	if st.Lines == nil {
+		st.Lines = []service.StatementLine{}
+	}
+	return &st, nil
+}
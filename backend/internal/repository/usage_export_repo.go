@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type usageExportRepository struct {
+	db  *sql.DB
+	sql sqlExecutor
+}
+
+// NewUsageExportRepository 创建使用记录导出任务仓储
+func NewUsageExportRepository(sqlDB *sql.DB) service.UsageExportRepository {
+	return &usageExportRepository{db: sqlDB, sql: sqlDB}
+}
+
+const usageExportTaskSelectColumns = `
+	id, scope, format, status, filters, created_by, total_rows, exported_rows,
+	COALESCE(file_path, ''), file_size, download_token, error_message,
+	canceled_by, canceled_at, started_at, finished_at, expires_at, created_at, updated_at
+`
+
+func (r *usageExportRepository) CreateTask(ctx context.Context, task *service.UsageExportTask) error {
+	filtersJSON, err := json.Marshal(task.Filters)
+	if err != nil {
+		return fmt.Errorf("marshal export filters: %w", err)
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO usage_export_tasks (scope, format, status, filters, created_by, download_token)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`, []any{task.Scope, task.Format, task.Status, filtersJSON, task.CreatedBy, task.DownloadToken},
+		&task.ID, &task.CreatedAt, &task.UpdatedAt)
+}
+
+func (r *usageExportRepository) ListTasks(ctx context.Context, filter service.UsageExportTaskFilter, params pagination.PaginationParams) ([]service.UsageExportTask, *pagination.PaginationResult, error) {
+	where := "WHERE scope = $1"
+	args := []any{filter.Scope}
+	if filter.CreatedBy > 0 {
+		where += " AND created_by = $2"
+		args = append(args, filter.CreatedBy)
+	}
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_export_tasks "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UsageExportTask{}, paginationResultFromTotal(0, params), nil
+	}
+
+	args = append(args, params.Limit(), params.Offset())
+	query := fmt.Sprintf("SELECT %s FROM usage_export_tasks %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		usageExportTaskSelectColumns, where, len(args)-1, len(args))
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	tasks := make([]service.UsageExportTask, 0)
+	for rows.Next() {
+		task, err := scanUsageExportTask(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return tasks, paginationResultFromTotal(total, params), nil
+}
+
+func (r *usageExportRepository) GetTask(ctx context.Context, taskID int64) (*service.UsageExportTask, error) {
+	return r.getTask(ctx, "id = $1", taskID)
+}
+
+func (r *usageExportRepository) GetTaskByToken(ctx context.Context, token string) (*service.UsageExportTask, error) {
+	return r.getTask(ctx, "download_token = $1", token)
+}
+
+func (r *usageExportRepository) getTask(ctx context.Context, condition string, arg any) (*service.UsageExportTask, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+usageExportTaskSelectColumns+" FROM usage_export_tasks WHERE "+condition, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrUsageExportTaskNotFound
+	}
+	task, err := scanUsageExportTask(rows)
+	if err != nil {
+		return nil, err
+	}
+	return task, rows.Err()
+}
+
+func (r *usageExportRepository) CountActiveTasks(ctx context.Context, createdBy int64) (int, error) {
+	var count int
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT COUNT(*) FROM usage_export_tasks
+		WHERE created_by = $1 AND status IN ($2, $3)
+	`, []any{createdBy, service.UsageExportStatusPending, service.UsageExportStatusRunning}, &count)
+	return count, err
+}
+
+func (r *usageExportRepository) ClaimNextPendingTask(ctx context.Context, staleRunningAfterSeconds int64) (*service.UsageExportTask, error) {
+	if staleRunningAfterSeconds <= 0 {
+		staleRunningAfterSeconds = 3600
+	}
+	rows, err := r.sql.QueryContext(ctx, `
+		WITH next AS (
+			SELECT id AS next_id
+			FROM usage_export_tasks
+			WHERE status = $1
+				OR (
+					status = $2
+					AND started_at IS NOT NULL
+					AND started_at < NOW() - ($3 * interval '1 second')
+				)
+			ORDER BY created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE usage_export_tasks AS tasks
+		SET status = $2,
+			started_at = NOW(),
+			finished_at = NULL,
+			exported_rows = 0,
+			error_message = NULL,
+			updated_at = NOW()
+		FROM next
+		WHERE tasks.id = next.next_id
+		RETURNING `+usageExportTaskSelectColumns,
+		service.UsageExportStatusPending,
+		service.UsageExportStatusRunning,
+		staleRunningAfterSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	task, err := scanUsageExportTask(rows)
+	if err != nil {
+		return nil, err
+	}
+	return task, rows.Err()
+}
+
+func (r *usageExportRepository) GetTaskStatus(ctx context.Context, taskID int64) (string, error) {
+	var status string
+	if err := scanSingleRow(ctx, r.sql, "SELECT status FROM usage_export_tasks WHERE id = $1", []any{taskID}, &status); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+func (r *usageExportRepository) UpdateTaskProgress(ctx context.Context, taskID int64, totalRows, exportedRows int64) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_export_tasks
+		SET total_rows = $2, exported_rows = $3, updated_at = NOW()
+		WHERE id = $1
+	`, taskID, totalRows, exportedRows)
+	return err
+}
+
+func (r *usageExportRepository) CancelTask(ctx context.Context, taskID int64, canceledBy int64) (bool, error) {
+	res, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_export_tasks
+		SET status = $1,
+			canceled_by = $3,
+			canceled_at = NOW(),
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $2
+			AND status IN ($4, $5)
+	`, service.UsageExportStatusCanceled, taskID, canceledBy, service.UsageExportStatusPending, service.UsageExportStatusRunning)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *usageExportRepository) MarkTaskSucceeded(ctx context.Context, taskID int64, exportedRows int64, filePath string, fileSize int64, expiresAt time.Time) error {
+	// 仅更新仍处于 running 的任务，避免覆盖执行期间的取消
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_export_tasks
+		SET status = $2,
+			exported_rows = $3,
+			file_path = $4,
+			file_size = $5,
+			expires_at = $6,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND status = $7
+	`, taskID, service.UsageExportStatusSucceeded, exportedRows, filePath, fileSize, expiresAt, service.UsageExportStatusRunning)
+	return err
+}
+
+func (r *usageExportRepository) MarkTaskFailed(ctx context.Context, taskID int64, exportedRows int64, errorMsg string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_export_tasks
+		SET status = $2,
+			exported_rows = $3,
+			error_message = $4,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND status = $5
+	`, taskID, service.UsageExportStatusFailed, exportedRows, errorMsg, service.UsageExportStatusRunning)
+	return err
+}
+
+func (r *usageExportRepository) PurgeExpiredFiles(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		UPDATE usage_export_tasks AS tasks
+		SET file_path = NULL, updated_at = NOW()
+		FROM (
+			SELECT id, file_path FROM usage_export_tasks
+			WHERE file_path IS NOT NULL AND expires_at < $1
+			FOR UPDATE SKIP LOCKED
+		) AS expired
+		WHERE tasks.id = expired.id
+		RETURNING expired.file_path
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+func (r *usageExportRepository) CountUsageLogs(ctx context.Context, filters usagestats.UsageLogFilters) (int64, error) {
+	conditions, args := buildUsageLogFilterConditions(filters)
+	var total int64
+	err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_logs "+buildWhere(conditions), args, &total)
+	return total, err
+}
+
+// StreamUsageLogs 在只读事务中声明服务端游标，按批 FETCH，避免一次性加载全部结果
+func (r *usageExportRepository) StreamUsageLogs(ctx context.Context, filters usagestats.UsageLogFilters, batchSize int, fn func([]service.UsageLog) error) error {
+	if r.db == nil {
+		return errors.New("usage export requires a database connection")
+	}
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	conditions, args := buildUsageLogFilterConditions(filters)
+	declare := fmt.Sprintf("DECLARE usage_export_cursor NO SCROLL CURSOR FOR SELECT %s FROM usage_logs %s ORDER BY id ASC",
+		usageLogSelectColumns, buildWhere(conditions))
+	if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM usage_export_cursor", batchSize)
+	batch := make([]service.UsageLog, 0, batchSize)
+	for {
+		batch = batch[:0]
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			log, err := scanUsageLog(rows)
+			if err != nil {
+				_ = rows.Close()
+				return err
+			}
+			batch = append(batch, *log)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "CLOSE usage_export_cursor"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanUsageExportTask(row interface{ Scan(...any) error }) (*service.UsageExportTask, error) {
+	var (
+		task        service.UsageExportTask
+		filtersJSON []byte
+		errMsg      sql.NullString
+		canceledBy  sql.NullInt64
+		canceledAt  sql.NullTime
+		startedAt   sql.NullTime
+		finishedAt  sql.NullTime
+		expiresAt   sql.NullTime
+	)
+	if err := row.Scan(
+		&task.ID,
+		&task.Scope,
+		&task.Format,
+		&task.Status,
+		&filtersJSON,
+		&task.CreatedBy,
+		&task.TotalRows,
+		&task.ExportedRows,
+		&task.FilePath,
+		&task.FileSize,
+		&task.DownloadToken,
+		&errMsg,
+		&canceledBy,
+		&canceledAt,
+		&startedAt,
+		&finishedAt,
+		&expiresAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filtersJSON, &task.Filters); err != nil {
+		return nil, fmt.Errorf("parse export filters: %w", err)
+	}
+	if errMsg.Valid {
+		task.ErrorMsg = &errMsg.String
+	}
+	if canceledBy.Valid {
+		v := canceledBy.Int64
+		task.CanceledBy = &v
+	}
+	task.CanceledAt = nullTimePtr(canceledAt)
+	task.StartedAt = nullTimePtr(startedAt)
+	task.FinishedAt = nullTimePtr(finishedAt)
+	task.ExpiresAt = nullTimePtr(expiresAt)
+	return &task, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/s3"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const (
+	usageLogArchiveStorageLocal = "local"
+	usageLogArchiveStorageS3    = "s3"
+)
+
+// NewUsageLogArchiveStore 按 usage_partition.archive.storage 创建归档存储。
+// 未启用归档且 S3 配置不完整时返回 nil，此时不能回灌 S3 上的归档。
+func NewUsageLogArchiveStore(cfg *config.Config) (service.UsageLogArchiveStore, error) {
+	archiveCfg := cfg.UsagePartition.Archive
+	if strings.ToLower(strings.TrimSpace(archiveCfg.Storage)) != usageLogArchiveStorageS3 {
+		return &localUsageLogArchiveStore{dir: archiveCfg.Dir}, nil
+	}
+	client, err := s3.New(s3.Config{
+		Endpoint:        archiveCfg.S3.Endpoint,
+		Region:          archiveCfg.S3.Region,
+		Bucket:          archiveCfg.S3.Bucket,
+		AccessKeyID:     archiveCfg.S3.AccessKeyID,
+		SecretAccessKey: archiveCfg.S3.SecretAccessKey,
+		ForcePathStyle:  archiveCfg.S3.ForcePathStyle,
+	}, nil)
+	if err != nil {
+		if cfg.UsageLogsRetentionManaged() {
+			return nil, fmt.Errorf("usage log archive store: %w", err)
+		}
+		log.Printf("[UsagePartition] s3 archive store unavailable: %v", err)
+		return nil, nil
+	}
+	return &s3UsageLogArchiveStore{client: client, prefix: archiveCfg.S3.Prefix}, nil
+}
+
+// localUsageLogArchiveStore 将归档文件移动到本地归档目录
+type localUsageLogArchiveStore struct {
+	dir string
+}
+
+func (s *localUsageLogArchiveStore) Kind() string {
+	return usageLogArchiveStorageLocal
+}
+
+func (s *localUsageLogArchiveStore) Put(_ context.Context, name, localPath string) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+	target := filepath.Join(s.dir, filepath.Base(name))
+	if err := os.Rename(localPath, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+func (s *localUsageLogArchiveStore) Open(_ context.Context, location string) (io.ReadCloser, error) {
+	return os.Open(location)
+}
+
+// s3UsageLogArchiveStore 上传归档到 S3 兼容对象存储，上传成功后删除本地临时文件
+type s3UsageLogArchiveStore struct {
+	client *s3.Client
+	prefix string
+}
+
+func (s *s3UsageLogArchiveStore) Kind() string {
+	return usageLogArchiveStorageS3
+}
+
+func (s *s3UsageLogArchiveStore) Put(ctx context.Context, name, localPath string) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	key := s.prefix + name
+	err = s.client.PutObject(ctx, key, file, "application/gzip")
+	_ = file.Close()
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(localPath); err != nil {
+		log.Printf("[UsagePartition] remove uploaded archive temp file failed: path=%s err=%v", localPath, err)
+	}
+	return key, nil
+}
+
+func (s *s3UsageLogArchiveStore) Open(ctx context.Context, location string) (io.ReadCloser, error) {
+	return s.client.GetObject(ctx, location)
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -22,7 +23,7 @@ import (
 	"github.com/lib/pq"
 )
 
-const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, stream, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, reasoning_effort, created_at"
+const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, stream, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, reasoning_effort, requested_model, key_generation, tags, organization_id, created_at"
 
 type usageLogRepository struct {
 	client *dbent.Client
@@ -33,6 +34,16 @@ func NewUsageLogRepository(client *dbent.Client, sqlDB *sql.DB) service.UsageLog
 	return newUsageLogRepositoryWithSQL(client, sqlDB)
 }
 
+// NewUsageLogBatchWriter 创建使用记录批量写入器（异步落库使用）
+func NewUsageLogBatchWriter(client *dbent.Client, sqlDB *sql.DB) service.UsageLogBatchWriter {
+	return newUsageLogRepositoryWithSQL(client, sqlDB)
+}
+
+// NewIdempotencyUsageLookup 创建幂等请求的使用记录查询
+func NewIdempotencyUsageLookup(client *dbent.Client, sqlDB *sql.DB) service.IdempotencyUsageLookup {
+	return newUsageLogRepositoryWithSQL(client, sqlDB)
+}
+
 func newUsageLogRepositoryWithSQL(client *dbent.Client, sqlq sqlExecutor) *usageLogRepository {
 	// 使用 scanSingleRow 替代 QueryRowContext，保证 ent.Tx 作为 sqlExecutor 可用。
 	return &usageLogRepository{client: client, sql: sqlq}
@@ -61,95 +72,296 @@ func (r *usageLogRepository) getPerformanceStats(ctx context.Context, userID int
 	return requestCount / 5, tokenCount / 5, nil
 }
 
+// usageLogInsertColumns 与 usageLogInsertArgs 返回的参数一一对应
+const usageLogInsertColumns = `user_id, api_key_id, account_id, request_id, model, group_id, subscription_id,
+	input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens,
+	input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost,
+	rate_multiplier, account_rate_multiplier, billing_type, stream, duration_ms, first_token_ms,
+	user_agent, ip_address, image_count, image_size, reasoning_effort, requested_model, key_generation, tags,
+	organization_id, created_at`
+
+const usageLogInsertColumnCount = 35
+
 func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog) (bool, error) {
 	if log == nil {
 		return false, nil
 	}
 
+	requestID := strings.TrimSpace(log.RequestID)
+	log.RequestID = requestID
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+
+	rateMultiplier := log.RateMultiplier
+
+	args, err := usageLogInsertArgs(log)
+	if err != nil {
+		return false, err
+	}
+
 	// 在事务上下文中，使用 tx 绑定的 ExecQuerier 执行原生 SQL，保证与其他更新同事务。
-	// 无事务时回退到默认的 *sql.DB 执行器。
-	sqlq := r.sql
+	// 无事务时开启新事务，保证去重登记与使用记录同时写入。
+	sqlq, commit, rollback, err := r.beginUsageLogWrite(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer rollback()
+
+	if requestID != "" {
+		key := usageLogKey{requestID: requestID, apiKeyID: log.APIKeyID}
+		claimed, err := claimUsageLogRequestIDs(ctx, sqlq, []usageLogKey{key}, []time.Time{log.CreatedAt})
+		if err != nil {
+			return false, err
+		}
+		if _, ok := claimed[key]; !ok {
+			log.RateMultiplier = rateMultiplier
+			return false, r.loadExistingUsageLog(ctx, sqlq, log)
+		}
+	}
+
+	query := "INSERT INTO usage_logs (" + usageLogInsertColumns + ") VALUES " + usageLogInsertPlaceholders(1) +
+		" ON CONFLICT DO NOTHING RETURNING id, created_at"
+	if err := scanSingleRow(ctx, sqlq, query, args, &log.ID, &log.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) && requestID != "" {
+			// 去重表上线前已写入的记录
+			log.RateMultiplier = rateMultiplier
+			return false, r.loadExistingUsageLog(ctx, sqlq, log)
+		}
+		return false, err
+	}
+	if err := commit(); err != nil {
+		return false, err
+	}
+	log.RateMultiplier = rateMultiplier
+	return true, nil
+}
+
+// loadExistingUsageLog 重复请求时回填已有记录的 id / created_at；
+// 原记录已被清理（去重登记仍在）时保持 id 为 0，同样按重复处理
+func (r *usageLogRepository) loadExistingUsageLog(ctx context.Context, sqlq sqlExecutor, log *service.UsageLog) error {
+	selectQuery := "SELECT id, created_at FROM usage_logs WHERE request_id = $1 AND api_key_id = $2 ORDER BY id LIMIT 1"
+	err := scanSingleRow(ctx, sqlq, selectQuery, []any{log.RequestID, log.APIKeyID}, &log.ID, &log.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+type usageLogKey struct {
+	requestID string
+	apiKeyID  int64
+}
+
+// beginUsageLogWrite 返回写入使用记录的执行器：ctx 中已有事务时复用（由外层提交），
+// r.sql 为 *sql.DB 时开启新事务，commit 提交、rollback 在未提交时回滚
+func (r *usageLogRepository) beginUsageLogWrite(ctx context.Context) (sqlExecutor, func() error, func(), error) {
 	if tx := dbent.TxFromContext(ctx); tx != nil {
-		sqlq = tx.Client()
+		return tx.Client(), func() error { return nil }, func() {}, nil
 	}
+	db, ok := r.sql.(*sql.DB)
+	if !ok {
+		// r.sql 本身已是事务（如集成测试中的 ent.Tx）
+		return r.sql, func() error { return nil }, func() {}, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tx, tx.Commit, func() { _ = tx.Rollback() }, nil
+}
 
-	createdAt := log.CreatedAt
-	if createdAt.IsZero() {
-		createdAt = time.Now()
+// claimUsageLogRequestIDs 在非分区的 usage_log_request_ids 中登记 (request_id, api_key_id)，
+// 返回本次登记成功（此前未写入过）的键。usage_logs 分区后唯一索引包含 created_at，
+// 无法再以 (request_id, api_key_id) 作为冲突目标，去重统一由该表保证。
+func claimUsageLogRequestIDs(ctx context.Context, sqlq sqlExecutor, keys []usageLogKey, createdAt []time.Time) (map[usageLogKey]struct{}, error) {
+	claimed := make(map[usageLogKey]struct{}, len(keys))
+	if len(keys) == 0 {
+		return claimed, nil
+	}
+	var values strings.Builder
+	args := make([]any, 0, len(keys)*3)
+	for i, key := range keys {
+		if i > 0 {
+			values.WriteString(", ")
+		}
+		fmt.Fprintf(&values, "($%d, $%d, $%d)", len(args)+1, len(args)+2, len(args)+3)
+		args = append(args, key.requestID, key.apiKeyID, createdAt[i])
 	}
+	rows, err := sqlq.QueryContext(ctx, "INSERT INTO usage_log_request_ids (request_id, api_key_id, created_at) VALUES "+values.String()+
+		" ON CONFLICT (request_id, api_key_id) DO NOTHING RETURNING request_id, api_key_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var key usageLogKey
+		if err := rows.Scan(&key.requestID, &key.apiKeyID); err != nil {
+			return nil, err
+		}
+		claimed[key] = struct{}{}
+	}
+	return claimed, rows.Err()
+}
 
-	requestID := strings.TrimSpace(log.RequestID)
-	log.RequestID = requestID
+// ExistsByRequestID 判断指定 API Key 下是否已存在该 request_id 的使用记录
+// 去重登记表（usage_log_request_ids，不受分区影响）与 usage_logs 任一存在即视为已使用
+func (r *usageLogRepository) ExistsByRequestID(ctx context.Context, apiKeyID int64, requestID string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM usage_log_request_ids WHERE request_id = $1 AND api_key_id = $2)
+		OR EXISTS(SELECT 1 FROM usage_logs WHERE request_id = $1 AND api_key_id = $2)`
+	if err := scanSingleRow(ctx, r.sql, query, []any{requestID, apiKeyID}, &exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
 
-	rateMultiplier := log.RateMultiplier
+// CreateBatch 在同一事务内先登记去重键、再以单条多行 INSERT 写入一批使用记录，幂等性同 Create。
+// 批内重复的 (request_id, api_key_id) 只写入第一条，其余视为未插入。
+func (r *usageLogRepository) CreateBatch(ctx context.Context, logs []*service.UsageLog) ([]bool, error) {
+	inserted := make([]bool, len(logs))
+	if len(logs) == 0 {
+		return inserted, nil
+	}
 
-	query := `
-		INSERT INTO usage_logs (
-			user_id,
-			api_key_id,
-			account_id,
-			request_id,
-			model,
-			group_id,
-			subscription_id,
-			input_tokens,
-			output_tokens,
-			cache_creation_tokens,
-			cache_read_tokens,
-			cache_creation_5m_tokens,
-			cache_creation_1h_tokens,
-			input_cost,
-			output_cost,
-			cache_creation_cost,
-			cache_read_cost,
-			total_cost,
-			actual_cost,
-			rate_multiplier,
-			account_rate_multiplier,
-			billing_type,
-			stream,
-			duration_ms,
-			first_token_ms,
-			user_agent,
-				ip_address,
-				image_count,
-				image_size,
-				reasoning_effort,
-				created_at
-			) VALUES (
-				$1, $2, $3, $4, $5,
-				$6, $7,
-				$8, $9, $10, $11,
-				$12, $13,
-				$14, $15, $16, $17, $18, $19,
-				$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31
-			)
-			ON CONFLICT (request_id, api_key_id) DO NOTHING
-			RETURNING id, created_at
-		`
-
-	groupID := nullInt64(log.GroupID)
-	subscriptionID := nullInt64(log.SubscriptionID)
-	duration := nullInt(log.DurationMs)
-	firstToken := nullInt(log.FirstTokenMs)
-	userAgent := nullString(log.UserAgent)
-	ipAddress := nullString(log.IPAddress)
-	imageSize := nullString(log.ImageSize)
-	reasoningEffort := nullString(log.ReasoningEffort)
+	seen := make(map[usageLogKey]struct{}, len(logs))
+	candidates := make([]int, 0, len(logs))
+	keys := make([]usageLogKey, 0, len(logs))
+	keyTimes := make([]time.Time, 0, len(logs))
+	for i, log := range logs {
+		if log == nil {
+			continue
+		}
+		log.RequestID = strings.TrimSpace(log.RequestID)
+		if log.CreatedAt.IsZero() {
+			log.CreatedAt = time.Now()
+		}
+		if log.RequestID != "" {
+			key := usageLogKey{requestID: log.RequestID, apiKeyID: log.APIKeyID}
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+			keyTimes = append(keyTimes, log.CreatedAt)
+		}
+		candidates = append(candidates, i)
+	}
+	if len(candidates) == 0 {
+		return inserted, nil
+	}
+
+	sqlq, commit, rollback, err := r.beginUsageLogWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback()
+
+	claimed, err := claimUsageLogRequestIDs(ctx, sqlq, keys, keyTimes)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]any, 0, len(candidates)*usageLogInsertColumnCount)
+	var values strings.Builder
+	writes := make([]int, 0, len(candidates))
+	for _, i := range candidates {
+		log := logs[i]
+		if log.RequestID != "" {
+			if _, ok := claimed[usageLogKey{requestID: log.RequestID, apiKeyID: log.APIKeyID}]; !ok {
+				continue
+			}
+		}
+		rowArgs, err := usageLogInsertArgs(log)
+		if err != nil {
+			return nil, err
+		}
+		if len(writes) > 0 {
+			values.WriteString(", ")
+		}
+		values.WriteString(usageLogInsertPlaceholders(len(args) + 1))
+		args = append(args, rowArgs...)
+		writes = append(writes, i)
+	}
+	if len(writes) == 0 {
+		return inserted, commit()
+	}
+
+	rows, err := sqlq.QueryContext(ctx, "INSERT INTO usage_logs ("+usageLogInsertColumns+") VALUES "+values.String()+
+		" ON CONFLICT DO NOTHING RETURNING COALESCE(request_id, ''), api_key_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	written := make(map[usageLogKey]struct{}, len(writes))
+	for rows.Next() {
+		var key usageLogKey
+		if err := rows.Scan(&key.requestID, &key.apiKeyID); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		written[key] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	_ = rows.Close()
+	if err := commit(); err != nil {
+		return nil, err
+	}
+
+	for _, i := range writes {
+		log := logs[i]
+		if log.RequestID == "" {
+			// 无 request_id 的记录不参与去重，必然写入
+			inserted[i] = true
+			continue
+		}
+		_, inserted[i] = written[usageLogKey{requestID: log.RequestID, apiKeyID: log.APIKeyID}]
+	}
+	return inserted, nil
+}
+
+func usageLogInsertPlaceholders(first int) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i := 0; i < usageLogInsertColumnCount; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "$%d", first+i)
+	}
+	b.WriteByte(')')
+	return b.String()
+}
 
+func usageLogInsertArgs(log *service.UsageLog) ([]any, error) {
+	createdAt := log.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	// 未经 API Key 认证路径写入的记录（如管理员补录）按第 1 代处理
+	keyGeneration := log.KeyGeneration
+	if keyGeneration <= 0 {
+		keyGeneration = 1
+	}
 	var requestIDArg any
-	if requestID != "" {
+	if requestID := strings.TrimSpace(log.RequestID); requestID != "" {
 		requestIDArg = requestID
 	}
+	tags, err := marshalUsageLogTags(log.Tags)
+	if err != nil {
+		return nil, err
+	}
 
-	args := []any{
+	return []any{
 		log.UserID,
 		log.APIKeyID,
 		log.AccountID,
 		requestIDArg,
 		log.Model,
-		groupID,
-		subscriptionID,
+		nullInt64(log.GroupID),
+		nullInt64(log.SubscriptionID),
 		log.InputTokens,
 		log.OutputTokens,
 		log.CacheCreationTokens,
@@ -162,33 +374,23 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 		log.CacheReadCost,
 		log.TotalCost,
 		log.ActualCost,
-		rateMultiplier,
+		log.RateMultiplier,
 		log.AccountRateMultiplier,
 		log.BillingType,
 		log.Stream,
-		duration,
-		firstToken,
-		userAgent,
-		ipAddress,
+		nullInt(log.DurationMs),
+		nullInt(log.FirstTokenMs),
+		nullString(log.UserAgent),
+		nullString(log.IPAddress),
 		log.ImageCount,
-		imageSize,
-		reasoningEffort,
+		nullString(log.ImageSize),
+		nullString(log.ReasoningEffort),
+		nullString(log.RequestedModel),
+		keyGeneration,
+		tags,
+		nullInt64(log.OrganizationID),
 		createdAt,
-	}
-	if err := scanSingleRow(ctx, sqlq, query, args, &log.ID, &log.CreatedAt); err != nil {
-		if errors.Is(err, sql.ErrNoRows) && requestID != "" {
-			selectQuery := "SELECT id, created_at FROM usage_logs WHERE request_id = $1 AND api_key_id = $2"
-			if err := scanSingleRow(ctx, sqlq, selectQuery, []any{requestID, log.APIKeyID}, &log.ID, &log.CreatedAt); err != nil {
-				return false, err
-			}
-			log.RateMultiplier = rateMultiplier
-			return false, nil
-		} else {
-			return false, err
-		}
-	}
-	log.RateMultiplier = rateMultiplier
-	return true, nil
+	}, nil
 }
 
 func (r *usageLogRepository) GetByID(ctx context.Context, id int64) (log *service.UsageLog, err error) {
@@ -639,6 +841,23 @@ func (r *usageLogRepository) GetAPIKeyStatsAggregated(ctx context.Context, apiKe
 	return &stats, nil
 }
 
+// GetAPIKeySpendWindows 单次扫描聚合 API Key 日/周/月窗口内的实际消费
+func (r *usageLogRepository) GetAPIKeySpendWindows(ctx context.Context, apiKeyID int64, starts service.APIKeySpendWindowStarts) (*service.APIKeySpendWindows, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(actual_cost) FILTER (WHERE created_at >= $2), 0),
+			COALESCE(SUM(actual_cost) FILTER (WHERE created_at >= $3), 0),
+			COALESCE(SUM(actual_cost) FILTER (WHERE created_at >= $4), 0)
+		FROM usage_logs
+		WHERE api_key_id = $1 AND created_at >= LEAST($2::timestamptz, $3::timestamptz, $4::timestamptz)
+	`
+	var out service.APIKeySpendWindows
+	if err := scanSingleRow(ctx, r.sql, query, []any{apiKeyID, starts.Day, starts.Week, starts.Month}, &out.Daily, &out.Weekly, &out.Monthly); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 // GetAccountStatsAggregated 使用 SQL 聚合统计账号使用数据
 //
 // 性能优化说明：
@@ -1311,6 +1530,21 @@ type UsageLogFilters = usagestats.UsageLogFilters
 
 // ListWithFilters lists usage logs with optional filters (for admin)
 func (r *usageLogRepository) ListWithFilters(ctx context.Context, params pagination.PaginationParams, filters UsageLogFilters) ([]service.UsageLog, *pagination.PaginationResult, error) {
+	conditions, args := buildUsageLogFilterConditions(filters)
+	whereClause := buildWhere(conditions)
+	logs, page, err := r.listUsageLogsWithPagination(ctx, whereClause, args, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := r.hydrateUsageLogAssociations(ctx, logs); err != nil {
+		return nil, nil, err
+	}
+	return logs, page, nil
+}
+
+// buildUsageLogFilterConditions 将列表过滤条件转换为 WHERE 子句条件（列表与批量导出共用）
+func buildUsageLogFilterConditions(filters UsageLogFilters) ([]string, []any) {
 	conditions := make([]string, 0, 8)
 	args := make([]any, 0, 8)
 
@@ -1322,6 +1556,10 @@ func (r *usageLogRepository) ListWithFilters(ctx context.Context, params paginat
 		conditions = append(conditions, fmt.Sprintf("api_key_id = $%d", len(args)+1))
 		args = append(args, filters.APIKeyID)
 	}
+	if filters.OrganizationID > 0 {
+		conditions = append(conditions, fmt.Sprintf("organization_id = $%d", len(args)+1))
+		args = append(args, filters.OrganizationID)
+	}
 	if filters.AccountID > 0 {
 		conditions = append(conditions, fmt.Sprintf("account_id = $%d", len(args)+1))
 		args = append(args, filters.AccountID)
@@ -1350,17 +1588,8 @@ func (r *usageLogRepository) ListWithFilters(ctx context.Context, params paginat
 		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)+1))
 		args = append(args, *filters.EndTime)
 	}
-
-	whereClause := buildWhere(conditions)
-	logs, page, err := r.listUsageLogsWithPagination(ctx, whereClause, args, params)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if err := r.hydrateUsageLogAssociations(ctx, logs); err != nil {
-		return nil, nil, err
-	}
-	return logs, page, nil
+	conditions, args = appendUsageTagCondition(conditions, args, filters.TagKey, filters.TagValue)
+	return conditions, args
 }
 
 // UsageStats represents usage statistics
@@ -1702,6 +1931,10 @@ func (r *usageLogRepository) GetStatsWithFilters(ctx context.Context, filters Us
 		conditions = append(conditions, fmt.Sprintf("api_key_id = $%d", len(args)+1))
 		args = append(args, filters.APIKeyID)
 	}
+	if filters.OrganizationID > 0 {
+		conditions = append(conditions, fmt.Sprintf("organization_id = $%d", len(args)+1))
+		args = append(args, filters.OrganizationID)
+	}
 	if filters.AccountID > 0 {
 		conditions = append(conditions, fmt.Sprintf("account_id = $%d", len(args)+1))
 		args = append(args, filters.AccountID)
@@ -1730,6 +1963,7 @@ func (r *usageLogRepository) GetStatsWithFilters(ctx context.Context, filters Us
 		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)+1))
 		args = append(args, *filters.EndTime)
 	}
+	conditions, args = appendUsageTagCondition(conditions, args, filters.TagKey, filters.TagValue)
 
 	query := fmt.Sprintf(`
 		SELECT
@@ -1770,6 +2004,87 @@ func (r *usageLogRepository) GetStatsWithFilters(ctx context.Context, filters Us
 	return stats, nil
 }
 
+// appendUsageTagCondition 追加标签过滤条件（可命中 usage_logs.tags 的 GIN 索引）
+func appendUsageTagCondition(conditions []string, args []any, key, value string) ([]string, []any) {
+	if key == "" {
+		return conditions, args
+	}
+	if value != "" {
+		conditions = append(conditions, fmt.Sprintf("tags @> jsonb_build_object($%d::text, $%d::text)", len(args)+1, len(args)+2))
+		return conditions, append(args, key, value)
+	}
+	conditions = append(conditions, fmt.Sprintf("tags ? $%d", len(args)+1))
+	return conditions, append(args, key)
+}
+
+// GetTagUsageStats 直接从 usage_logs 按标签值分组统计（未启用预聚合或需要按 API Key 过滤时使用）
+func (r *usageLogRepository) GetTagUsageStats(ctx context.Context, filters usagestats.TagUsageFilters) (results []usagestats.TagUsageStat, err error) {
+	conditions := []string{"created_at >= $1", "created_at < $2"}
+	args := []any{filters.StartTime, filters.EndTime}
+	if filters.UserID > 0 {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)+1))
+		args = append(args, filters.UserID)
+	}
+	if filters.APIKeyID > 0 {
+		conditions = append(conditions, fmt.Sprintf("api_key_id = $%d", len(args)+1))
+		args = append(args, filters.APIKeyID)
+	}
+	conditions, args = appendUsageTagCondition(conditions, args, filters.TagKey, filters.TagValue)
+
+	query := fmt.Sprintf(`
+		SELECT
+			tags->>$%d AS tag_value,
+			COUNT(*) AS requests,
+			COALESCE(SUM(input_tokens), 0) AS input_tokens,
+			COALESCE(SUM(output_tokens), 0) AS output_tokens,
+			COALESCE(SUM(cache_creation_tokens + cache_read_tokens), 0) AS cache_tokens,
+			COALESCE(SUM(total_cost), 0) AS cost,
+			COALESCE(SUM(actual_cost), 0) AS actual_cost
+		FROM usage_logs
+		%s
+		GROUP BY 1
+		ORDER BY actual_cost DESC, tag_value ASC
+	`, len(args)+1, buildWhere(conditions))
+	args = append(args, filters.TagKey)
+
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			results = nil
+		}
+	}()
+	return scanTagUsageStatRows(rows)
+}
+
+// scanTagUsageStatRows 扫描 tag_value, requests, input, output, cache, cost, actual_cost 列
+func scanTagUsageStatRows(rows *sql.Rows) ([]usagestats.TagUsageStat, error) {
+	results := make([]usagestats.TagUsageStat, 0)
+	for rows.Next() {
+		var row usagestats.TagUsageStat
+		if err := rows.Scan(
+			&row.TagValue,
+			&row.Requests,
+			&row.InputTokens,
+			&row.OutputTokens,
+			&row.CacheTokens,
+			&row.Cost,
+			&row.ActualCost,
+		); err != nil {
+			return nil, err
+		}
+		row.TotalTokens = row.InputTokens + row.OutputTokens + row.CacheTokens
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // AccountUsageHistory represents daily usage history for an account
 type AccountUsageHistory = usagestats.AccountUsageHistory
 
@@ -2195,6 +2510,10 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		imageCount            int
 		imageSize             sql.NullString
 		reasoningEffort       sql.NullString
+		requestedModel        sql.NullString
+		keyGeneration         int
+		tags                  []byte
+		organizationID        sql.NullInt64
 		createdAt             time.Time
 	)
 
@@ -2230,6 +2549,10 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		&imageCount,
 		&imageSize,
 		&reasoningEffort,
+		&requestedModel,
+		&keyGeneration,
+		&tags,
+		&organizationID,
 		&createdAt,
 	); err != nil {
 		return nil, err
@@ -2258,6 +2581,7 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		BillingType:           int8(billingType),
 		Stream:                stream,
 		ImageCount:            imageCount,
+		KeyGeneration:         keyGeneration,
 		CreatedAt:             createdAt,
 	}
 
@@ -2292,10 +2616,34 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 	if reasoningEffort.Valid {
 		log.ReasoningEffort = &reasoningEffort.String
 	}
+	if requestedModel.Valid {
+		log.RequestedModel = &requestedModel.String
+	}
+	if organizationID.Valid {
+		value := organizationID.Int64
+		log.OrganizationID = &value
+	}
+	if len(tags) > 0 {
+		if err := json.Unmarshal(tags, &log.Tags); err != nil {
+			return nil, fmt.Errorf("decode usage log tags: %w", err)
+		}
+	}
 
 	return log, nil
 }
 
+// marshalUsageLogTags 无标签时写入 NULL，保持部分 GIN 索引精简
+func marshalUsageLogTags(tags map[string]string) (any, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("encode usage log tags: %w", err)
+	}
+	return string(data), nil
+}
+
 func scanTrendRows(rows *sql.Rows) ([]TrendDataPoint, error) {
 	results := make([]TrendDataPoint, 0)
 	for rows.Next() {
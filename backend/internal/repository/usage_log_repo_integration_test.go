@@ -84,6 +84,58 @@ func (s *UsageLogRepoSuite) TestCreate() {
 	s.Require().NotZero(log.ID)
 }
 
+// TestCreate_DuplicateRequestOnPartitionedTable 分区表的唯一索引包含 created_at，
+// 同一请求以不同 created_at 重复写入时仍只能写入一次
+func (s *UsageLogRepoSuite) TestCreate_DuplicateRequestOnPartitionedTable() {
+	// 在测试事务内将 usage_logs 换成分区表（与在线转换后的索引一致），事务回滚后恢复
+	for _, stmt := range []string{
+		"ALTER TABLE usage_logs RENAME TO usage_logs_plain_test",
+		"CREATE TABLE usage_logs (LIKE usage_logs_plain_test INCLUDING DEFAULTS) PARTITION BY RANGE (created_at)",
+		"CREATE TABLE usage_logs_default_test PARTITION OF usage_logs DEFAULT",
+		"CREATE UNIQUE INDEX ON usage_logs (request_id, api_key_id, created_at)",
+	} {
+		_, err := s.tx.ExecContext(s.ctx, stmt)
+		s.Require().NoError(err, stmt)
+	}
+
+	user := mustCreateUser(s.T(), s.client, &service.User{Email: "partition-dup@test.com"})
+	apiKey := mustCreateApiKey(s.T(), s.client, &service.APIKey{UserID: user.ID, Key: "sk-partition-dup", Name: "k"})
+	account := mustCreateAccount(s.T(), s.client, &service.Account{Name: "acc-partition-dup"})
+
+	requestID := uuid.New().String()
+	newLog := func(createdAt time.Time) *service.UsageLog {
+		return &service.UsageLog{
+			UserID:     user.ID,
+			APIKeyID:   apiKey.ID,
+			AccountID:  account.ID,
+			RequestID:  requestID,
+			Model:      "claude-3",
+			TotalCost:  1,
+			ActualCost: 1,
+			CreatedAt:  createdAt,
+		}
+	}
+
+	first := newLog(time.Now().Add(-time.Minute))
+	inserted, err := s.repo.Create(s.ctx, first)
+	s.Require().NoError(err)
+	s.Require().True(inserted)
+
+	second := newLog(time.Now())
+	inserted, err = s.repo.Create(s.ctx, second)
+	s.Require().NoError(err)
+	s.Require().False(inserted, "same request_id must not be inserted twice")
+	s.Require().Equal(first.ID, second.ID)
+
+	batchInserted, err := s.repo.CreateBatch(s.ctx, []*service.UsageLog{newLog(time.Now().Add(time.Minute))})
+	s.Require().NoError(err)
+	s.Require().Equal([]bool{false}, batchInserted)
+
+	var count int
+	s.Require().NoError(scanSingleRow(s.ctx, s.tx, "SELECT COUNT(*) FROM usage_logs WHERE request_id = $1", []any{requestID}, &count))
+	s.Require().Equal(1, count)
+}
+
 func (s *UsageLogRepoSuite) TestGetByID() {
 	user := mustCreateUser(s.T(), s.client, &service.User{Email: "getbyid@test.com"})
 	apiKey := mustCreateApiKey(s.T(), s.client, &service.APIKey{UserID: user.ID, Key: "sk-getbyid", Name: "k"})
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageLogRepositoryCreateBatch(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageLogRepositoryWithSQL(nil, db)
+
+	now := time.Now().UTC()
+	logs := []*service.UsageLog{
+		{UserID: 1, APIKeyID: 10, AccountID: 100, RequestID: "req-1", Model: "m", CreatedAt: now},
+		{UserID: 1, APIKeyID: 10, AccountID: 100, RequestID: "req-2", Model: "m", CreatedAt: now},
+		// 批内重复，不参与写入
+		{UserID: 1, APIKeyID: 10, AccountID: 100, RequestID: " req-1 ", Model: "m", CreatedAt: now},
+		// 无 request_id 的记录总是写入
+		{UserID: 2, APIKeyID: 20, AccountID: 100, Model: "m", CreatedAt: now},
+	}
+
+	// req-2 已登记过（冲突跳过），不进入 usage_logs 写入
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO usage_log_request_ids \(request_id, api_key_id, created_at\) VALUES \(\$1, \$2, \$3\), \(\$4, \$5, \$6\) ON CONFLICT \(request_id, api_key_id\) DO NOTHING RETURNING`).
+		WithArgs("req-1", int64(10), now, "req-2", int64(10), now).
+		WillReturnRows(sqlmock.NewRows([]string{"request_id", "api_key_id"}).AddRow("req-1", int64(10)))
+	mock.ExpectQuery(`INSERT INTO usage_logs \(.+\) VALUES \(\$1, .+\$35\), \(\$36, .+\$70\) ON CONFLICT DO NOTHING RETURNING`).
+		WillReturnRows(sqlmock.NewRows([]string{"request_id", "api_key_id"}).
+			AddRow("req-1", int64(10)).
+			AddRow("", int64(20)))
+	mock.ExpectCommit()
+
+	inserted, err := repo.CreateBatch(context.Background(), logs)
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false, false, true}, inserted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageLogRepositoryCreate_DuplicateRequestSkipsInsert(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageLogRepositoryWithSQL(nil, db)
+
+	// 分区表上 created_at 不同也不会再次写入：去重登记冲突后直接返回已有记录
+	createdAt := time.Now().UTC()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO usage_log_request_ids .+ ON CONFLICT \(request_id, api_key_id\) DO NOTHING RETURNING`).
+		WithArgs("req-1", int64(10), createdAt).
+		WillReturnRows(sqlmock.NewRows([]string{"request_id", "api_key_id"}))
+	mock.ExpectQuery(`SELECT id, created_at FROM usage_logs WHERE request_id = \$1 AND api_key_id = \$2`).
+		WithArgs("req-1", int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(7), createdAt.Add(-time.Minute)))
+	mock.ExpectRollback()
+
+	log := &service.UsageLog{UserID: 1, APIKeyID: 10, AccountID: 100, RequestID: "req-1", Model: "m", CreatedAt: createdAt}
+	inserted, err := repo.Create(context.Background(), log)
+	require.NoError(t, err)
+	require.False(t, inserted)
+	require.Equal(t, int64(7), log.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageLogRepositoryCreateBatchEmpty(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageLogRepositoryWithSQL(nil, db)
+
+	inserted, err := repo.CreateBatch(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, inserted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsageLogRepositoryExistsByRequestID(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := newUsageLogRepositoryWithSQL(nil, db)
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM usage_log_request_ids WHERE request_id = \$1 AND api_key_id = \$2\)\s+OR EXISTS\(SELECT 1 FROM usage_logs WHERE request_id = \$1 AND api_key_id = \$2\)`).
+		WithArgs("idem-abc", int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	exists, err := repo.ExistsByRequestID(context.Background(), 10, "idem-abc")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,962 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+const (
+	usageLogsShadowTable      = "usage_logs_partitioned"
+	usageLogsLegacyTable      = "usage_logs_legacy"
+	usageLogsDefaultPartition = "usage_logs_default"
+	// 影子表索引名后缀，切换后去掉；旧表索引在切换时追加 _legacy
+	usageLogsShadowIndexSuffix = "_p"
+	usageLogsLegacyIndexSuffix = "_legacy"
+)
+
+type usagePartitionRepository struct {
+	db  *sql.DB
+	sql sqlExecutor
+}
+
+// NewUsagePartitionRepository 创建 usage_logs 分区维护仓储
+func NewUsagePartitionRepository(sqlDB *sql.DB) service.UsagePartitionRepository {
+	return &usagePartitionRepository{db: sqlDB, sql: sqlDB}
+}
+
+const usagePartitionTaskSelectColumns = `
+	id, task_type, status, COALESCE(partition_name, ''), archive_id, cursor_id, total_rows, processed_rows,
+	error_message, created_by, started_at, finished_at, created_at, updated_at
+`
+
+const usageLogArchiveSelectColumns = `
+	id, partition_name, range_start, range_end, row_count, storage, location, file_size, checksum,
+	status, archived_at, restored_at, restored_until, created_at, updated_at
+`
+
+func (r *usagePartitionRepository) CreateTask(ctx context.Context, task *service.UsagePartitionTask) error {
+	var partitionName any
+	if task.PartitionName != "" {
+		partitionName = task.PartitionName
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO usage_log_partition_tasks (task_type, status, partition_name, archive_id, total_rows, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`, []any{task.TaskType, task.Status, partitionName, task.ArchiveID, task.TotalRows, task.CreatedBy},
+		&task.ID, &task.CreatedAt, &task.UpdatedAt)
+}
+
+func (r *usagePartitionRepository) ListTasks(ctx context.Context, params pagination.PaginationParams) ([]service.UsagePartitionTask, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_log_partition_tasks", nil, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UsagePartitionTask{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.sql.QueryContext(ctx,
+		"SELECT "+usagePartitionTaskSelectColumns+" FROM usage_log_partition_tasks ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2",
+		params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	tasks := make([]service.UsagePartitionTask, 0)
+	for rows.Next() {
+		task, err := scanUsagePartitionTask(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return tasks, paginationResultFromTotal(total, params), nil
+}
+
+func (r *usagePartitionRepository) HasActiveTask(ctx context.Context, taskType, partitionName string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM usage_log_partition_tasks
+			WHERE task_type = $1 AND status IN ($2, $3)
+	`
+	args := []any{taskType, service.UsagePartitionTaskStatusPending, service.UsagePartitionTaskStatusRunning}
+	if partitionName != "" {
+		query += " AND partition_name = $4"
+		args = append(args, partitionName)
+	}
+	query += ")"
+	var exists bool
+	err := scanSingleRow(ctx, r.sql, query, args, &exists)
+	return exists, err
+}
+
+func (r *usagePartitionRepository) ClaimNextPendingTask(ctx context.Context, staleRunningAfterSeconds int64) (*service.UsagePartitionTask, error) {
+	if staleRunningAfterSeconds <= 0 {
+		staleRunningAfterSeconds = 7200
+	}
+	rows, err := r.sql.QueryContext(ctx, `
+		WITH next AS (
+			SELECT id AS next_id
+			FROM usage_log_partition_tasks
+			WHERE status = $1
+				OR (
+					status = $2
+					AND started_at IS NOT NULL
+					AND started_at < NOW() - ($3 * interval '1 second')
+				)
+			ORDER BY created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE usage_log_partition_tasks AS tasks
+		SET status = $2,
+			started_at = NOW(),
+			finished_at = NULL,
+			error_message = NULL,
+			updated_at = NOW()
+		FROM next
+		WHERE tasks.id = next.next_id
+		RETURNING `+usagePartitionTaskSelectColumns,
+		service.UsagePartitionTaskStatusPending,
+		service.UsagePartitionTaskStatusRunning,
+		staleRunningAfterSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	task, err := scanUsagePartitionTask(rows)
+	if err != nil {
+		return nil, err
+	}
+	return task, rows.Err()
+}
+
+func (r *usagePartitionRepository) UpdateTaskProgress(ctx context.Context, taskID, cursorID, totalRows, processedRows int64) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_log_partition_tasks
+		SET cursor_id = $2, total_rows = $3, processed_rows = $4, updated_at = NOW()
+		WHERE id = $1
+	`, taskID, cursorID, totalRows, processedRows)
+	return err
+}
+
+func (r *usagePartitionRepository) YieldTask(ctx context.Context, taskID int64) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_log_partition_tasks
+		SET status = $2, started_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = $3
+	`, taskID, service.UsagePartitionTaskStatusPending, service.UsagePartitionTaskStatusRunning)
+	return err
+}
+
+func (r *usagePartitionRepository) MarkTaskSucceeded(ctx context.Context, taskID int64) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_log_partition_tasks
+		SET status = $2, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = $3
+	`, taskID, service.UsagePartitionTaskStatusSucceeded, service.UsagePartitionTaskStatusRunning)
+	return err
+}
+
+func (r *usagePartitionRepository) MarkTaskFailed(ctx context.Context, taskID int64, errorMsg string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_log_partition_tasks
+		SET status = $2, error_message = $3, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = $4
+	`, taskID, service.UsagePartitionTaskStatusFailed, errorMsg, service.UsagePartitionTaskStatusRunning)
+	return err
+}
+
+func (r *usagePartitionRepository) HasSucceededTask(ctx context.Context, taskType string) (bool, error) {
+	var exists bool
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT EXISTS(SELECT 1 FROM usage_log_partition_tasks WHERE task_type = $1 AND status = $2)
+	`, []any{taskType, service.UsagePartitionTaskStatusSucceeded}, &exists)
+	return exists, err
+}
+
+func (r *usagePartitionRepository) HasActiveCleanupTasks(ctx context.Context) (bool, error) {
+	var exists bool
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT EXISTS(SELECT 1 FROM usage_cleanup_tasks WHERE status IN ($1, $2))
+	`, []any{service.UsageCleanupStatusPending, service.UsageCleanupStatusRunning}, &exists)
+	return exists, err
+}
+
+func (r *usagePartitionRepository) ListArchives(ctx context.Context, params pagination.PaginationParams) ([]service.UsageLogArchive, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM usage_log_archives", nil, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UsageLogArchive{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.sql.QueryContext(ctx,
+		"SELECT "+usageLogArchiveSelectColumns+" FROM usage_log_archives ORDER BY range_start DESC, id DESC LIMIT $1 OFFSET $2",
+		params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	archives := make([]service.UsageLogArchive, 0)
+	for rows.Next() {
+		archive, err := scanUsageLogArchive(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		archives = append(archives, *archive)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return archives, paginationResultFromTotal(total, params), nil
+}
+
+func (r *usagePartitionRepository) GetArchive(ctx context.Context, archiveID int64) (*service.UsageLogArchive, error) {
+	return r.getArchive(ctx, "id = $1", archiveID)
+}
+
+func (r *usagePartitionRepository) GetArchiveByPartition(ctx context.Context, partitionName string) (*service.UsageLogArchive, error) {
+	return r.getArchive(ctx, "partition_name = $1", partitionName)
+}
+
+func (r *usagePartitionRepository) getArchive(ctx context.Context, condition string, arg any) (*service.UsageLogArchive, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+usageLogArchiveSelectColumns+" FROM usage_log_archives WHERE "+condition, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrUsageLogArchiveNotFound
+	}
+	archive, err := scanUsageLogArchive(rows)
+	if err != nil {
+		return nil, err
+	}
+	return archive, rows.Err()
+}
+
+func (r *usagePartitionRepository) UpsertArchive(ctx context.Context, archive *service.UsageLogArchive) error {
+	archive.Status = service.UsageLogArchiveStatusArchived
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO usage_log_archives (partition_name, range_start, range_end, row_count, storage, location, file_size, checksum, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (partition_name) DO UPDATE SET
+			range_start = EXCLUDED.range_start,
+			range_end = EXCLUDED.range_end,
+			row_count = EXCLUDED.row_count,
+			storage = EXCLUDED.storage,
+			location = EXCLUDED.location,
+			file_size = EXCLUDED.file_size,
+			checksum = EXCLUDED.checksum,
+			status = EXCLUDED.status,
+			archived_at = NOW(),
+			restored_at = NULL,
+			restored_until = NULL,
+			updated_at = NOW()
+		RETURNING id, archived_at, created_at, updated_at
+	`, []any{
+		archive.PartitionName, archive.RangeStart, archive.RangeEnd, archive.RowCount,
+		archive.Storage, archive.Location, archive.FileSize, archive.Checksum, archive.Status,
+	}, &archive.ID, &archive.ArchivedAt, &archive.CreatedAt, &archive.UpdatedAt)
+}
+
+func (r *usagePartitionRepository) MarkArchiveRestored(ctx context.Context, archiveID int64, restoredUntil time.Time) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_log_archives
+		SET status = $2, restored_at = NOW(), restored_until = $3, updated_at = NOW()
+		WHERE id = $1
+	`, archiveID, service.UsageLogArchiveStatusRestored, restoredUntil)
+	return err
+}
+
+func (r *usagePartitionRepository) MarkArchiveDetached(ctx context.Context, archiveID int64) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE usage_log_archives
+		SET status = $2, restored_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, archiveID, service.UsageLogArchiveStatusArchived)
+	return err
+}
+
+func (r *usagePartitionRepository) IsPartitioned(ctx context.Context) (bool, error) {
+	var partitioned bool
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM pg_partitioned_table pt
+			JOIN pg_class c ON c.oid = pt.partrelid
+			WHERE c.relname = 'usage_logs'
+		)
+	`, nil, &partitioned)
+	return partitioned, err
+}
+
+func (r *usagePartitionRepository) ListPartitions(ctx context.Context) ([]service.UsageLogPartition, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT c.relname,
+			GREATEST(c.reltuples, 0)::bigint,
+			pg_total_relation_size(c.oid),
+			COALESCE(pg_get_expr(c.relpartbound, c.oid), '') = 'DEFAULT'
+		FROM pg_inherits
+		JOIN pg_class c ON c.oid = pg_inherits.inhrelid
+		JOIN pg_class p ON p.oid = pg_inherits.inhparent
+		WHERE p.relname = 'usage_logs'
+		ORDER BY c.relname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	partitions := make([]service.UsageLogPartition, 0)
+	for rows.Next() {
+		var p service.UsageLogPartition
+		if err := rows.Scan(&p.Name, &p.EstimatedRows, &p.SizeBytes, &p.IsDefault); err != nil {
+			return nil, err
+		}
+		if month, ok := service.ParseUsageLogPartitionName(p.Name); ok {
+			p.Month = month
+		}
+		partitions = append(partitions, p)
+	}
+	return partitions, rows.Err()
+}
+
+func (r *usagePartitionRepository) EnsurePartition(ctx context.Context, month time.Time) error {
+	return createMonthlyPartition(ctx, r.sql, "usage_logs", month)
+}
+
+// DetachAndDropPartition 在同一事务中分离并删除分区；分区已不存在时视为成功（任务重试）
+func (r *usagePartitionRepository) DetachAndDropPartition(ctx context.Context, partitionName string) error {
+	if _, ok := service.ParseUsageLogPartitionName(partitionName); !ok {
+		return fmt.Errorf("invalid partition name %q", partitionName)
+	}
+	if r.db == nil {
+		return errors.New("usage partition requires a database connection")
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var attached bool
+	if err := scanSingleRow(ctx, tx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM pg_inherits
+			JOIN pg_class c ON c.oid = pg_inherits.inhrelid
+			JOIN pg_class p ON p.oid = pg_inherits.inhparent
+			WHERE p.relname = 'usage_logs' AND c.relname = $1
+		)
+	`, []any{partitionName}, &attached); err != nil {
+		return err
+	}
+	quoted := pq.QuoteIdentifier(partitionName)
+	if attached {
+		if _, err := tx.ExecContext(ctx, "ALTER TABLE usage_logs DETACH PARTITION "+quoted); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DROP TABLE IF EXISTS "+quoted); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *usagePartitionRepository) CountPartitionRows(ctx context.Context, partitionName string) (int64, error) {
+	if _, ok := service.ParseUsageLogPartitionName(partitionName); !ok {
+		return 0, fmt.Errorf("invalid partition name %q", partitionName)
+	}
+	var total int64
+	err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM "+pq.QuoteIdentifier(partitionName), nil, &total)
+	return total, err
+}
+
+// StreamPartitionRows 与 usage 导出相同，在只读事务中声明服务端游标按批 FETCH
+func (r *usagePartitionRepository) StreamPartitionRows(ctx context.Context, partitionName string, batchSize int, fn func(rows [][]byte) error) error {
+	if _, ok := service.ParseUsageLogPartitionName(partitionName); !ok {
+		return fmt.Errorf("invalid partition name %q", partitionName)
+	}
+	if r.db == nil {
+		return errors.New("usage partition requires a database connection")
+	}
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	declare := fmt.Sprintf("DECLARE usage_archive_cursor NO SCROLL CURSOR FOR SELECT row_to_json(t)::text FROM %s t ORDER BY t.id ASC",
+		pq.QuoteIdentifier(partitionName))
+	if _, err := tx.ExecContext(ctx, declare); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM usage_archive_cursor", batchSize)
+	for {
+		batch := make([][]byte, 0, batchSize)
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var row []byte
+			if err := rows.Scan(&row); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			batch = append(batch, row)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "CLOSE usage_archive_cursor"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InsertArchivedRows 用 json_populate_recordset 按 usage_logs 行类型还原 row_to_json 导出的记录
+func (r *usagePartitionRepository) InsertArchivedRows(ctx context.Context, rows [][]byte) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(row)
+	}
+	b.WriteByte(']')
+
+	res, err := r.sql.ExecContext(ctx, `
+		INSERT INTO usage_logs
+		SELECT * FROM json_populate_recordset(NULL::usage_logs, $1::json)
+		ON CONFLICT DO NOTHING
+	`, b.String())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *usagePartitionRepository) LegacyTableExists(ctx context.Context) (bool, error) {
+	var exists bool
+	err := scanSingleRow(ctx, r.sql, "SELECT to_regclass($1) IS NOT NULL", []any{usageLogsLegacyTable}, &exists)
+	return exists, err
+}
+
+func (r *usagePartitionRepository) DropLegacyTable(ctx context.Context) error {
+	_, err := r.sql.ExecContext(ctx, "DROP TABLE IF EXISTS "+pq.QuoteIdentifier(usageLogsLegacyTable))
+	return err
+}
+
+// PrepareConversion 按 usage_logs 结构创建影子分区表：
+//   - 主键改为 (id, created_at)，唯一索引追加分区键 created_at
+//   - 复制其余索引（名称追加 _p，切换时去掉）与出站外键
+//   - 预建最早记录所在月到 now+aheadMonths 的月分区，以及兜底的默认分区
+//
+// 每一步均可重复执行，任务中断后重新抢占时直接复用已创建的对象。
+func (r *usagePartitionRepository) PrepareConversion(ctx context.Context, now time.Time, aheadMonths int) (int64, error) {
+	shadow := pq.QuoteIdentifier(usageLogsShadowTable)
+	if _, err := r.sql.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			LIKE usage_logs INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING STORAGE INCLUDING COMMENTS
+		) PARTITION BY RANGE (created_at)
+	`, shadow)); err != nil {
+		return 0, fmt.Errorf("create shadow table: %w", err)
+	}
+
+	pkeyName := usageLogsShadowTable + "_pkey"
+	hasPkey, err := r.constraintExists(ctx, usageLogsShadowTable, pkeyName)
+	if err != nil {
+		return 0, err
+	}
+	if !hasPkey {
+		if _, err := r.sql.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s PRIMARY KEY (id, created_at)",
+			shadow, pq.QuoteIdentifier(pkeyName))); err != nil {
+			return 0, fmt.Errorf("add shadow primary key: %w", err)
+		}
+	}
+
+	indexes, err := r.listIndexes(ctx, "usage_logs", false)
+	if err != nil {
+		return 0, err
+	}
+	for _, idx := range indexes {
+		def, ok := partitionedIndexDef(idx.def, idx.name, idx.name+usageLogsShadowIndexSuffix, usageLogsShadowTable, idx.unique)
+		if !ok {
+			return 0, fmt.Errorf("unsupported index definition for %s: %s", idx.name, idx.def)
+		}
+		if _, err := r.sql.ExecContext(ctx, def); err != nil {
+			return 0, fmt.Errorf("create shadow index %s: %w", idx.name, err)
+		}
+	}
+
+	if err := r.copyForeignKeys(ctx); err != nil {
+		return 0, err
+	}
+
+	var minCreatedAt sql.NullTime
+	if err := scanSingleRow(ctx, r.sql, "SELECT MIN(created_at) FROM usage_logs", nil, &minCreatedAt); err != nil {
+		return 0, err
+	}
+	first := truncateToMonthUTC(now)
+	if minCreatedAt.Valid && minCreatedAt.Time.Before(first) {
+		first = truncateToMonthUTC(minCreatedAt.Time)
+	}
+	last := truncateToMonthUTC(now).AddDate(0, aheadMonths, 0)
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		if err := createMonthlyPartition(ctx, r.sql, usageLogsShadowTable, m); err != nil {
+			return 0, fmt.Errorf("create shadow partition %s: %w", service.UsageLogPartitionName(m), err)
+		}
+	}
+	if _, err := r.sql.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s DEFAULT",
+		pq.QuoteIdentifier(usageLogsDefaultPartition), shadow)); err != nil {
+		return 0, fmt.Errorf("create default partition: %w", err)
+	}
+
+	var estimate int64
+	if err := scanSingleRow(ctx, r.sql,
+		"SELECT GREATEST(reltuples, 0)::bigint FROM pg_class WHERE oid = 'usage_logs'::regclass", nil, &estimate); err != nil {
+		return 0, err
+	}
+	return estimate, nil
+}
+
+// CopyConversionBatch 复制一批记录到影子表；已复制的行（重试时）由 ON CONFLICT 忽略
+func (r *usagePartitionRepository) CopyConversionBatch(ctx context.Context, afterID int64, limit int) (int64, int64, error) {
+	var lastID, copied int64
+	err := scanSingleRow(ctx, r.sql, fmt.Sprintf(`
+		WITH batch AS (
+			SELECT * FROM usage_logs WHERE id > $1 ORDER BY id ASC LIMIT $2
+		), ins AS (
+			INSERT INTO %s SELECT * FROM batch ON CONFLICT DO NOTHING
+		)
+		SELECT COALESCE(MAX(id), $1), COUNT(*) FROM batch
+	`, pq.QuoteIdentifier(usageLogsShadowTable)), []any{afterID, limit}, &lastID, &copied)
+	return lastID, copied, err
+}
+
+// SwapConversion 在单个事务内完成切换：
+//  1. 以 lock_timeout 获取 usage_logs 排他锁，拿不到时返回 ErrUsagePartitionSwapBusy
+//  2. 复制游标之后新写入的记录
+//  3. 删除指向 usage_logs 的外键（分区表主键包含 created_at，无法被单列 id 引用）
+//  4. 旧表及其索引改名为 *_legacy，影子表改名为 usage_logs 并去掉索引后缀
+//  5. id 序列归属转移到新表，删除旧表时不会连带删除序列
+func (r *usagePartitionRepository) SwapConversion(ctx context.Context, afterID int64) error {
+	if r.db == nil {
+		return errors.New("usage partition requires a database connection")
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	txRepo := &usagePartitionRepository{sql: tx}
+
+	if _, err := tx.ExecContext(ctx, "SET LOCAL lock_timeout = '10s'"); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE usage_logs IN ACCESS EXCLUSIVE MODE"); err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "55P03" {
+			return service.ErrUsagePartitionSwapBusy
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s SELECT * FROM usage_logs WHERE id > $1 ON CONFLICT DO NOTHING",
+		pq.QuoteIdentifier(usageLogsShadowTable)), afterID); err != nil {
+		return fmt.Errorf("copy remaining rows: %w", err)
+	}
+
+	if err := txRepo.dropInboundForeignKeys(ctx); err != nil {
+		return err
+	}
+
+	var sequence sql.NullString
+	if err := scanSingleRow(ctx, tx, "SELECT pg_get_serial_sequence('usage_logs', 'id')", nil, &sequence); err != nil {
+		return err
+	}
+
+	legacyIndexes, err := txRepo.listIndexes(ctx, "usage_logs", true)
+	if err != nil {
+		return err
+	}
+	for _, idx := range legacyIndexes {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER INDEX %s RENAME TO %s",
+			pq.QuoteIdentifier(idx.name), pq.QuoteIdentifier(idx.name+usageLogsLegacyIndexSuffix))); err != nil {
+			return fmt.Errorf("rename legacy index %s: %w", idx.name, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "ALTER TABLE usage_logs RENAME TO "+pq.QuoteIdentifier(usageLogsLegacyTable)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s RENAME TO usage_logs", pq.QuoteIdentifier(usageLogsShadowTable))); err != nil {
+		return err
+	}
+
+	shadowIndexes, err := txRepo.listIndexes(ctx, "usage_logs", true)
+	if err != nil {
+		return err
+	}
+	for _, idx := range shadowIndexes {
+		var target string
+		switch {
+		case idx.name == usageLogsShadowTable+"_pkey":
+			target = "usage_logs_pkey"
+		case strings.HasSuffix(idx.name, usageLogsShadowIndexSuffix):
+			target = strings.TrimSuffix(idx.name, usageLogsShadowIndexSuffix)
+		default:
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER INDEX %s RENAME TO %s",
+			pq.QuoteIdentifier(idx.name), pq.QuoteIdentifier(target))); err != nil {
+			return fmt.Errorf("rename index %s: %w", idx.name, err)
+		}
+	}
+
+	if sequence.Valid && sequence.String != "" {
+		// pg_get_serial_sequence 返回的名称已按需加引号
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY usage_logs.id", sequence.String)); err != nil {
+			return fmt.Errorf("move id sequence: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+type usageLogsIndex struct {
+	name   string
+	def    string
+	unique bool
+}
+
+func (r *usagePartitionRepository) listIndexes(ctx context.Context, table string, includePrimary bool) ([]usageLogsIndex, error) {
+	query := `
+		SELECT i.relname, pg_get_indexdef(ix.indexrelid), ix.indisunique
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		WHERE ix.indrelid = to_regclass($1)
+	`
+	if !includePrimary {
+		query += " AND NOT ix.indisprimary"
+	}
+	query += " ORDER BY i.relname"
+	rows, err := r.sql.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var indexes []usageLogsIndex
+	for rows.Next() {
+		var idx usageLogsIndex
+		if err := rows.Scan(&idx.name, &idx.def, &idx.unique); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, idx)
+	}
+	return indexes, rows.Err()
+}
+
+func (r *usagePartitionRepository) constraintExists(ctx context.Context, table, name string) (bool, error) {
+	var exists bool
+	err := scanSingleRow(ctx, r.sql, `
+		SELECT EXISTS(SELECT 1 FROM pg_constraint WHERE conrelid = to_regclass($1) AND conname = $2)
+	`, []any{table, name}, &exists)
+	return exists, err
+}
+
+// copyForeignKeys 将 usage_logs 的出站外键复制到影子表（外键名按表区分，保持原名）
+func (r *usagePartitionRepository) copyForeignKeys(ctx context.Context) error {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT conname, pg_get_constraintdef(oid)
+		FROM pg_constraint
+		WHERE conrelid = 'usage_logs'::regclass AND contype = 'f'
+		ORDER BY conname
+	`)
+	if err != nil {
+		return err
+	}
+	type foreignKey struct{ name, def string }
+	var fks []foreignKey
+	for rows.Next() {
+		var fk foreignKey
+		if err := rows.Scan(&fk.name, &fk.def); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		fks = append(fks, fk)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, fk := range fks {
+		exists, err := r.constraintExists(ctx, usageLogsShadowTable, fk.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := r.sql.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s",
+			pq.QuoteIdentifier(usageLogsShadowTable), pq.QuoteIdentifier(fk.name), fk.def)); err != nil {
+			return fmt.Errorf("copy foreign key %s: %w", fk.name, err)
+		}
+	}
+	return nil
+}
+
+func (r *usagePartitionRepository) dropInboundForeignKeys(ctx context.Context) error {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT conrelid::regclass::text, conname
+		FROM pg_constraint
+		WHERE confrelid = 'usage_logs'::regclass AND contype = 'f'
+	`)
+	if err != nil {
+		return err
+	}
+	type foreignKey struct{ table, name string }
+	var fks []foreignKey
+	for rows.Next() {
+		var fk foreignKey
+		if err := rows.Scan(&fk.table, &fk.name); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		fks = append(fks, fk)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, fk := range fks {
+		// regclass::text 已按需加引号
+		if _, err := r.sql.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", fk.table, pq.QuoteIdentifier(fk.name))); err != nil {
+			return fmt.Errorf("drop foreign key %s: %w", fk.name, err)
+		}
+	}
+	return nil
+}
+
+func createMonthlyPartition(ctx context.Context, exec sqlExecutor, parent string, month time.Time) error {
+	monthStart := truncateToMonthUTC(month)
+	nextMonth := monthStart.AddDate(0, 1, 0)
+	query := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%s) TO (%s)",
+		pq.QuoteIdentifier(service.UsageLogPartitionName(monthStart)),
+		pq.QuoteIdentifier(parent),
+		pq.QuoteLiteral(monthStart.Format("2006-01-02")),
+		pq.QuoteLiteral(nextMonth.Format("2006-01-02")),
+	)
+	_, err := exec.ExecContext(ctx, query)
+	return err
+}
+
+// partitionedIndexDef 将 pg_get_indexdef 的结果改写为影子表上的同名（带后缀）索引。
+// 分区表的唯一索引必须包含分区键，因此唯一索引在键列末尾追加 created_at。
+func partitionedIndexDef(def, name, newName, table string, unique bool) (string, bool) {
+	onPos := strings.Index(def, " ON ")
+	usingPos := strings.Index(def, " USING ")
+	if onPos < 0 || usingPos < onPos {
+		return "", false
+	}
+	head := def[:onPos]
+	quotedName := pq.QuoteIdentifier(name)
+	switch {
+	case strings.HasSuffix(head, " "+name):
+		head = strings.TrimSuffix(head, name)
+	case strings.HasSuffix(head, " "+quotedName):
+		head = strings.TrimSuffix(head, quotedName)
+	default:
+		return "", false
+	}
+	head = strings.Replace(head, " INDEX ", " INDEX IF NOT EXISTS ", 1) + pq.QuoteIdentifier(newName)
+	rest := def[usingPos:]
+
+	if unique {
+		open := strings.Index(rest, "(")
+		if open < 0 {
+			return "", false
+		}
+		depth, closePos := 0, -1
+		for i := open; i < len(rest); i++ {
+			if rest[i] == '(' {
+				depth++
+			} else if rest[i] == ')' {
+				depth--
+				if depth == 0 {
+					closePos = i
+					break
+				}
+			}
+		}
+		if closePos < 0 {
+			return "", false
+		}
+		columns := rest[open+1 : closePos]
+		hasCreatedAt := false
+		for _, col := range strings.Split(columns, ",") {
+			if strings.TrimSpace(col) == "created_at" {
+				hasCreatedAt = true
+			}
+		}
+		if !hasCreatedAt {
+			rest = rest[:closePos] + ", created_at" + rest[closePos:]
+		}
+	}
+	return head + " ON " + pq.QuoteIdentifier(table) + rest, true
+}
+
+func scanUsagePartitionTask(row interface{ Scan(...any) error }) (*service.UsagePartitionTask, error) {
+	var (
+		task       service.UsagePartitionTask
+		archiveID  sql.NullInt64
+		errMsg     sql.NullString
+		createdBy  sql.NullInt64
+		startedAt  sql.NullTime
+		finishedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&task.ID,
+		&task.TaskType,
+		&task.Status,
+		&task.PartitionName,
+		&archiveID,
+		&task.CursorID,
+		&task.TotalRows,
+		&task.ProcessedRows,
+		&errMsg,
+		&createdBy,
+		&startedAt,
+		&finishedAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if archiveID.Valid {
+		v := archiveID.Int64
+		task.ArchiveID = &v
+	}
+	if errMsg.Valid {
+		v := errMsg.String
+		task.ErrorMsg = &v
+	}
+	if createdBy.Valid {
+		v := createdBy.Int64
+		task.CreatedBy = &v
+	}
+	task.StartedAt = nullTimePtr(startedAt)
+	task.FinishedAt = nullTimePtr(finishedAt)
+	return &task, nil
+}
+
+func scanUsageLogArchive(row interface{ Scan(...any) error }) (*service.UsageLogArchive, error) {
+	var (
+		archive       service.UsageLogArchive
+		restoredAt    sql.NullTime
+		restoredUntil sql.NullTime
+	)
+	if err := row.Scan(
+		&archive.ID,
+		&archive.PartitionName,
+		&archive.RangeStart,
+		&archive.RangeEnd,
+		&archive.RowCount,
+		&archive.Storage,
+		&archive.Location,
+		&archive.FileSize,
+		&archive.Checksum,
+		&archive.Status,
+		&archive.ArchivedAt,
+		&restoredAt,
+		&restoredUntil,
+		&archive.CreatedAt,
+		&archive.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	archive.RestoredAt = nullTimePtr(restoredAt)
+	archive.RestoredUntil = nullTimePtr(restoredUntil)
+	return &archive, nil
+}
+
+func (r *usagePartitionRepository) OldestUsageLogTime(ctx context.Context) (*time.Time, error) {
+	var oldest sql.NullTime
+	if err := scanSingleRow(ctx, r.sql, "SELECT MIN(created_at) FROM usage_logs", nil, &oldest); err != nil {
+		return nil, err
+	}
+	if !oldest.Valid {
+		return nil, nil
+	}
+	return &oldest.Time, nil
+}
+
+func (r *usagePartitionRepository) BackfillRequestIDs(ctx context.Context, from, to time.Time) (int64, error) {
+	// 同一 (request_id, api_key_id) 跨时间窗时按升序先登记的最早记录保留
+	result, err := r.sql.ExecContext(ctx, `
+		INSERT INTO usage_log_request_ids (request_id, api_key_id, created_at)
+		SELECT request_id, api_key_id, MIN(created_at)
+		FROM usage_logs
+		WHERE created_at >= $1 AND created_at < $2 AND request_id IS NOT NULL AND request_id <> ''
+		GROUP BY request_id, api_key_id
+		ON CONFLICT (request_id, api_key_id) DO NOTHING
+	`, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
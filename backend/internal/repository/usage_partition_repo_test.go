@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionedIndexDef(t *testing.T) {
+	cases := []struct {
+		name   string
+		def    string
+		unique bool
+		want   string
+	}{
+		{
+			name: "idx_usage_logs_created_at",
+			def:  "CREATE INDEX idx_usage_logs_created_at ON public.usage_logs USING btree (created_at)",
+			want: `CREATE INDEX IF NOT EXISTS "idx_usage_logs_created_at_p" ON "usage_logs_partitioned" USING btree (created_at)`,
+		},
+		{
+			name:   "idx_usage_logs_request_id_api_key_unique",
+			def:    "CREATE UNIQUE INDEX idx_usage_logs_request_id_api_key_unique ON public.usage_logs USING btree (request_id, api_key_id)",
+			unique: true,
+			want:   `CREATE UNIQUE INDEX IF NOT EXISTS "idx_usage_logs_request_id_api_key_unique_p" ON "usage_logs_partitioned" USING btree (request_id, api_key_id, created_at)`,
+		},
+		{
+			name:   "idx_usage_logs_partial_unique",
+			def:    "CREATE UNIQUE INDEX idx_usage_logs_partial_unique ON public.usage_logs USING btree (lower((model)::text), created_at) WHERE (organization_id IS NOT NULL)",
+			unique: true,
+			want:   `CREATE UNIQUE INDEX IF NOT EXISTS "idx_usage_logs_partial_unique_p" ON "usage_logs_partitioned" USING btree (lower((model)::text), created_at) WHERE (organization_id IS NOT NULL)`,
+		},
+		{
+			name: "idx_usage_logs_tags",
+			def:  "CREATE INDEX idx_usage_logs_tags ON public.usage_logs USING gin (tags jsonb_path_ops) WHERE (tags IS NOT NULL)",
+			want: `CREATE INDEX IF NOT EXISTS "idx_usage_logs_tags_p" ON "usage_logs_partitioned" USING gin (tags jsonb_path_ops) WHERE (tags IS NOT NULL)`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := partitionedIndexDef(tc.def, tc.name, tc.name+usageLogsShadowIndexSuffix, usageLogsShadowTable, tc.unique)
+			require.True(t, ok)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	_, ok := partitionedIndexDef("CREATE INDEX other ON public.usage_logs USING btree (id)", "idx_x", "idx_x_p", usageLogsShadowTable, false)
+	require.False(t, ok)
+}
+
+func TestUsagePartitionRepositoryBackfillRequestIDs(t *testing.T) {
+	db, mock := newSQLMock(t)
+	repo := NewUsagePartitionRepository(db)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	mock.ExpectExec(`INSERT INTO usage_log_request_ids \(request_id, api_key_id, created_at\)\s+SELECT request_id, api_key_id, MIN\(created_at\)\s+FROM usage_logs\s+WHERE created_at >= \$1 AND created_at < \$2 .*ON CONFLICT \(request_id, api_key_id\) DO NOTHING`).
+		WithArgs(from, to).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	n, err := repo.BackfillRequestIDs(context.Background(), from, to)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	usageWriteStreamKey     = "usage:write:stream"
+	usageWriteDeadStreamKey = "usage:write:dead"
+	// usageWritePendingKey 尚未落库的 (api_key_id, request_id) 索引（Hash），随入队写入、随确认删除
+	usageWritePendingKey      = "usage:write:pending"
+	usageWriteGroup           = "usage-writers"
+	usageWritePayloadField    = "data"
+	usageWriteRequestKeyField = "request_key"
+)
+
+// usageWriteQueue 基于 Redis Stream 消费组的使用记录队列
+// 消息确认后立即 XDEL，XLEN 即为尚未落库的积压量。
+// 入队与确认时同步维护 request_key 索引，供幂等请求判断同一 request_id 是否仍在队列中；
+// 死信保留索引，重放后随正常确认删除。
+type usageWriteQueue struct {
+	rdb          *redis.Client
+	groupCreated atomic.Bool
+}
+
+// NewUsageWriteQueue 创建使用记录写入队列
+func NewUsageWriteQueue(rdb *redis.Client) service.UsageWriteQueue {
+	return &usageWriteQueue{rdb: rdb}
+}
+
+func (q *usageWriteQueue) Enqueue(ctx context.Context, requestKey string, payload []byte) error {
+	_, err := q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: usageWriteStreamKey,
+			Values: map[string]any{usageWritePayloadField: payload, usageWriteRequestKeyField: requestKey},
+		})
+		pipe.HSet(ctx, usageWritePendingKey, requestKey, time.Now().Unix())
+		return nil
+	})
+	return err
+}
+
+func (q *usageWriteQueue) HasPending(ctx context.Context, requestKey string) (bool, error) {
+	return q.rdb.HExists(ctx, usageWritePendingKey, requestKey).Result()
+}
+
+func (q *usageWriteQueue) Read(ctx context.Context, consumer string, count int, block time.Duration) ([]service.UsageWriteMessage, error) {
+	if err := q.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+	streams, err := q.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    usageWriteGroup,
+		Consumer: consumer,
+		Streams:  []string{usageWriteStreamKey, ">"},
+		Count:    int64(count),
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		q.resetGroupOnMissing(err)
+		return nil, err
+	}
+	var out []service.UsageWriteMessage
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			out = append(out, usageWriteMessageFromXMessage(msg, 1))
+		}
+	}
+	return out, nil
+}
+
+func (q *usageWriteQueue) ClaimStale(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]service.UsageWriteMessage, error) {
+	if err := q.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+	msgs, _, err := q.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   usageWriteStreamKey,
+		Group:    usageWriteGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    int64(count),
+	}).Result()
+	if err != nil {
+		q.resetGroupOnMissing(err)
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+
+	// XAUTOCLAIM 不返回投递次数，从 PEL 中补齐
+	deliveries := make(map[string]int64, len(msgs))
+	pending, err := q.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   usageWriteStreamKey,
+		Group:    usageWriteGroup,
+		Start:    msgs[0].ID,
+		End:      msgs[len(msgs)-1].ID,
+		Count:    int64(len(msgs)),
+		Consumer: consumer,
+	}).Result()
+	if err == nil {
+		for _, p := range pending {
+			deliveries[p.ID] = p.RetryCount
+		}
+	}
+
+	out := make([]service.UsageWriteMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		n := deliveries[msg.ID]
+		if n <= 0 {
+			n = 1
+		}
+		out = append(out, usageWriteMessageFromXMessage(msg, n))
+	}
+	return out, nil
+}
+
+func (q *usageWriteQueue) Ack(ctx context.Context, msgs ...service.UsageWriteMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(msgs))
+	requestKeys := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		ids = append(ids, msg.ID)
+		if msg.RequestKey != "" {
+			requestKeys = append(requestKeys, msg.RequestKey)
+		}
+	}
+	_, err := q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, usageWriteStreamKey, usageWriteGroup, ids...)
+		pipe.XDel(ctx, usageWriteStreamKey, ids...)
+		if len(requestKeys) > 0 {
+			pipe.HDel(ctx, usageWritePendingKey, requestKeys...)
+		}
+		return nil
+	})
+	return err
+}
+
+func (q *usageWriteQueue) DeadLetter(ctx context.Context, msg service.UsageWriteMessage, reason string) error {
+	_, err := q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: usageWriteDeadStreamKey,
+			Values: map[string]any{
+				usageWritePayloadField:    msg.Payload,
+				usageWriteRequestKeyField: msg.RequestKey,
+				"source_id":               msg.ID,
+				"deliveries":              msg.Deliveries,
+				"reason":                  reason,
+				"failed_at":               time.Now().UTC().Format(time.RFC3339),
+			},
+		})
+		pipe.XAck(ctx, usageWriteStreamKey, usageWriteGroup, msg.ID)
+		pipe.XDel(ctx, usageWriteStreamKey, msg.ID)
+		return nil
+	})
+	return err
+}
+
+func (q *usageWriteQueue) ReplayDeadLetters(ctx context.Context, count int) (int, error) {
+	msgs, err := q.rdb.XRangeN(ctx, usageWriteDeadStreamKey, "-", "+", int64(count)).Result()
+	if err != nil {
+		return 0, err
+	}
+	replayed := 0
+	for _, msg := range msgs {
+		payload, _ := msg.Values[usageWritePayloadField].(string)
+		requestKey, _ := msg.Values[usageWriteRequestKeyField].(string)
+		_, err := q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: usageWriteStreamKey,
+				Values: map[string]any{usageWritePayloadField: payload, usageWriteRequestKeyField: requestKey},
+			})
+			pipe.XDel(ctx, usageWriteDeadStreamKey, msg.ID)
+			return nil
+		})
+		if err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+func (q *usageWriteQueue) Stats(ctx context.Context) (*service.UsageWriteQueueStats, error) {
+	pipe := q.rdb.Pipeline()
+	streamLen := pipe.XLen(ctx, usageWriteStreamKey)
+	deadLen := pipe.XLen(ctx, usageWriteDeadStreamKey)
+	pending := pipe.XPending(ctx, usageWriteStreamKey, usageWriteGroup)
+	_, _ = pipe.Exec(ctx)
+
+	stats := &service.UsageWriteQueueStats{}
+	var err error
+	if stats.Length, err = streamLen.Result(); err != nil {
+		return nil, err
+	}
+	if stats.DeadLetters, err = deadLen.Result(); err != nil {
+		return nil, err
+	}
+	// 消费组尚未创建（从未启动 worker）时没有待确认消息
+	if summary, err := pending.Result(); err == nil {
+		stats.Pending = summary.Count
+	} else if !isNoGroupError(err) {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ensureGroup 创建消费组（从头消费，保证创建前入队的记录也会落库）
+func (q *usageWriteQueue) ensureGroup(ctx context.Context) error {
+	if q.groupCreated.Load() {
+		return nil
+	}
+	err := q.rdb.XGroupCreateMkStream(ctx, usageWriteStreamKey, usageWriteGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	q.groupCreated.Store(true)
+	return nil
+}
+
+// resetGroupOnMissing Redis 数据丢失（如重启且未开启持久化）后重新创建消费组
+func (q *usageWriteQueue) resetGroupOnMissing(err error) {
+	if isNoGroupError(err) {
+		q.groupCreated.Store(false)
+	}
+}
+
+func isNoGroupError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
+
+func usageWriteMessageFromXMessage(msg redis.XMessage, deliveries int64) service.UsageWriteMessage {
+	var payload []byte
+	switch v := msg.Values[usageWritePayloadField].(type) {
+	case string:
+		payload = []byte(v)
+	case []byte:
+		payload = v
+	}
+	requestKey, _ := msg.Values[usageWriteRequestKeyField].(string)
+	return service.UsageWriteMessage{ID: msg.ID, RequestKey: requestKey, Payload: payload, Deliveries: deliveries}
+}
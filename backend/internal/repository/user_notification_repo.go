@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+type userNotificationRepository struct {
+	sql sqlExecutor
+}
+
+// NewUserNotificationRepository 创建用户提醒仓储
+func NewUserNotificationRepository(sqlDB *sql.DB) service.UserNotificationRepository {
+	return &userNotificationRepository{sql: sqlDB}
+}
+
+const userNotificationSettingsSelectColumns = `
+	s.user_id, s.email_enabled, COALESCE(s.webhook_url, ''), COALESCE(s.webhook_secret, ''),
+	s.balance_below_usd, s.key_quota_percent, s.subscription_usage_percent, s.expiry_days, s.updated_at
+`
+
+const userNotificationSelectColumns = `
+	id, user_id, kind, target_id, dedup_key, title, message, channels, COALESCE(delivery_error, ''), resolved_at, created_at
+`
+
+func (r *userNotificationRepository) GetSettings(ctx context.Context, userID int64) (*service.UserNotificationSettings, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+userNotificationSettingsSelectColumns+" FROM user_notification_settings s WHERE s.user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	settings, err := scanUserNotificationSettings(rows)
+	if err != nil {
+		return nil, err
+	}
+	return settings, rows.Err()
+}
+
+func (r *userNotificationRepository) UpsertSettings(ctx context.Context, settings *service.UserNotificationSettings) error {
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO user_notification_settings
+			(user_id, email_enabled, webhook_url, webhook_secret,
+			 balance_below_usd, key_quota_percent, subscription_usage_percent, expiry_days, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			email_enabled = EXCLUDED.email_enabled,
+			webhook_url = EXCLUDED.webhook_url,
+			webhook_secret = EXCLUDED.webhook_secret,
+			balance_below_usd = EXCLUDED.balance_below_usd,
+			key_quota_percent = EXCLUDED.key_quota_percent,
+			subscription_usage_percent = EXCLUDED.subscription_usage_percent,
+			expiry_days = EXCLUDED.expiry_days,
+			updated_at = NOW()
+		RETURNING updated_at
+	`, []any{
+		settings.UserID,
+		settings.EmailEnabled,
+		settings.WebhookURL,
+		settings.WebhookSecret,
+		settings.BalanceBelowUSD,
+		settings.KeyQuotaPercent,
+		settings.SubscriptionUsagePercent,
+		settings.ExpiryDays,
+	}, &settings.UpdatedAt)
+}
+
+func (r *userNotificationRepository) ListUsersWithThresholds(ctx context.Context, afterUserID int64, limit int) ([]service.UserNotificationSettings, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT `+userNotificationSettingsSelectColumns+`
+		FROM user_notification_settings s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.user_id > $1
+			AND u.deleted_at IS NULL AND u.status = $2
+			AND (s.balance_below_usd IS NOT NULL OR s.key_quota_percent IS NOT NULL
+				OR s.subscription_usage_percent IS NOT NULL OR s.expiry_days IS NOT NULL)
+		ORDER BY s.user_id
+		LIMIT $3
+	`, afterUserID, service.StatusActive, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UserNotificationSettings, 0)
+	for rows.Next() {
+		settings, err := scanUserNotificationSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *settings)
+	}
+	return out, rows.Err()
+}
+
+func (r *userNotificationRepository) CreateIfAbsent(ctx context.Context, n *service.UserNotification) (bool, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		INSERT INTO user_notifications (user_id, kind, target_id, dedup_key, title, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (user_id, dedup_key) WHERE resolved_at IS NULL DO NOTHING
+		RETURNING id, created_at
+	`, n.UserID, n.Kind, n.TargetID, n.DedupKey, n.Title, n.Message)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	if err := rows.Scan(&n.ID, &n.CreatedAt); err != nil {
+		return false, err
+	}
+	return true, rows.Err()
+}
+
+func (r *userNotificationRepository) UpdateDelivery(ctx context.Context, id int64, channels, deliveryError string) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE user_notifications SET channels = $2, delivery_error = NULLIF($3, '')
+		WHERE id = $1
+	`, id, channels, deliveryError)
+	return err
+}
+
+func (r *userNotificationRepository) ResolveExcept(ctx context.Context, userID int64, activeKeys []string, at time.Time) error {
+	if activeKeys == nil {
+		activeKeys = []string{}
+	}
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE user_notifications SET resolved_at = $3
+		WHERE user_id = $1 AND resolved_at IS NULL AND NOT (dedup_key = ANY($2))
+	`, userID, pq.Array(activeKeys), at)
+	return err
+}
+
+func (r *userNotificationRepository) List(ctx context.Context, userID int64, params pagination.PaginationParams) ([]service.UserNotification, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM user_notifications WHERE user_id = $1", []any{userID}, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.UserNotification{}, paginationResultFromTotal(0, params), nil
+	}
+
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT `+userNotificationSelectColumns+`
+		FROM user_notifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UserNotification, 0)
+	for rows.Next() {
+		var (
+			item       service.UserNotification
+			resolvedAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&item.ID,
+			&item.UserID,
+			&item.Kind,
+			&item.TargetID,
+			&item.DedupKey,
+			&item.Title,
+			&item.Message,
+			&item.Channels,
+			&item.DeliveryError,
+			&resolvedAt,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		item.ResolvedAt = nullTimePtr(resolvedAt)
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func scanUserNotificationSettings(row interface{ Scan(...any) error }) (*service.UserNotificationSettings, error) {
+	var (
+		settings        service.UserNotificationSettings
+		balanceBelow    sql.NullFloat64
+		keyQuotaPercent sql.NullInt64
+		subUsagePercent sql.NullInt64
+		expiryDays      sql.NullInt64
+	)
+	if err := row.Scan(
+		&settings.UserID,
+		&settings.EmailEnabled,
+		&settings.WebhookURL,
+		&settings.WebhookSecret,
+		&balanceBelow,
+		&keyQuotaPercent,
+		&subUsagePercent,
+		&expiryDays,
+		&settings.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if balanceBelow.Valid {
+		v := balanceBelow.Float64
+		settings.BalanceBelowUSD = &v
+	}
+	settings.KeyQuotaPercent = nullIntPtr(keyQuotaPercent)
+	settings.SubscriptionUsagePercent = nullIntPtr(subUsagePercent)
+	settings.ExpiryDays = nullIntPtr(expiryDays)
+	return &settings, nil
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type userNotificationWebhookSender struct {
+	httpClient *http.Client
+}
+
+// NewUserNotificationWebhookSender 创建用户提醒 Webhook 客户端
+// 地址由用户提供，始终校验解析后的 IP，防止 SSRF / DNS Rebinding；
+// 无法创建带校验的客户端时直接返回错误，不降级为普通客户端
+func NewUserNotificationWebhookSender(cfg *config.Config) (service.UserNotificationWebhookSender, error) {
+	opts := httpclient.Options{Timeout: 10 * time.Second, ValidateResolvedIP: true}
+	if cfg != nil {
+		opts.AllowPrivateHosts = cfg.Security.URLAllowlist.AllowPrivateHosts
+	}
+	sharedClient, err := httpclient.GetClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("create user notification webhook client: %w", err)
+	}
+	return &userNotificationWebhookSender{httpClient: sharedClient}, nil
+}
+
+func (s *userNotificationWebhookSender) Send(ctx context.Context, url string, body []byte, signature string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Sub2API-Webhook/1.0")
+	req.Header.Set("X-Sub2API-Signature", "sha256="+signature)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
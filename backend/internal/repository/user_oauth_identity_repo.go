@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type userOAuthIdentityRepository struct {
+	sql sqlExecutor
+}
+
+// NewUserOAuthIdentityRepository 创建第三方身份绑定仓储
+func NewUserOAuthIdentityRepository(sqlDB *sql.DB) service.UserOAuthIdentityRepository {
+	return &userOAuthIdentityRepository{sql: sqlDB}
+}
+
+const userOAuthIdentitySelectColumns = `id, user_id, provider, subject, email, created_at, last_login_at`
+
+func (r *userOAuthIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*service.UserOAuthIdentity, error) {
+	rows, err := r.sql.QueryContext(ctx,
+		"SELECT "+userOAuthIdentitySelectColumns+" FROM user_oauth_identities WHERE provider = $1 AND subject = $2",
+		provider, subject)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrOAuthIdentityNotFound
+	}
+	identity, err := scanUserOAuthIdentity(rows)
+	if err != nil {
+		return nil, err
+	}
+	return identity, rows.Err()
+}
+
+func (r *userOAuthIdentityRepository) Upsert(ctx context.Context, identity *service.UserOAuthIdentity) error {
+	now := time.Now()
+	query := `
+		INSERT INTO user_oauth_identities (user_id, provider, subject, email, created_at, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (provider, subject) DO UPDATE SET
+			email = EXCLUDED.email,
+			last_login_at = EXCLUDED.last_login_at
+		RETURNING id, user_id, created_at, last_login_at
+	`
+	return scanSingleRow(ctx, r.sql, query, []any{
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		now,
+	}, &identity.ID, &identity.UserID, &identity.CreatedAt, &identity.LastLoginAt)
+}
+
+func (r *userOAuthIdentityRepository) ListByUserID(ctx context.Context, userID int64) ([]service.UserOAuthIdentity, error) {
+	rows, err := r.sql.QueryContext(ctx,
+		"SELECT "+userOAuthIdentitySelectColumns+" FROM user_oauth_identities WHERE user_id = $1 ORDER BY id",
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UserOAuthIdentity, 0)
+	for rows.Next() {
+		identity, err := scanUserOAuthIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *identity)
+	}
+	return out, rows.Err()
+}
+
+func scanUserOAuthIdentity(rows *sql.Rows) (*service.UserOAuthIdentity, error) {
+	var identity service.UserOAuthIdentity
+	if err := rows.Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+		&identity.LastLoginAt,
+	); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
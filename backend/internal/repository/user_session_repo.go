@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type userSessionRepository struct {
+	sql sqlExecutor
+}
+
+// NewUserSessionRepository 创建登录会话仓储
+func NewUserSessionRepository(sqlDB *sql.DB) service.UserSessionRepository {
+	return &userSessionRepository{sql: sqlDB}
+}
+
+const userSessionSelectColumns = `
+	id, user_id, family_id, name, login_method, ip, user_agent,
+	created_at, last_refreshed_at, expires_at, revoked_at
+`
+
+func (r *userSessionRepository) Create(ctx context.Context, session *service.UserSession) error {
+	query := `
+		INSERT INTO user_sessions (
+			user_id, family_id, name, login_method, ip, user_agent,
+			created_at, last_refreshed_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (family_id) DO NOTHING
+		RETURNING id
+	`
+	err := scanSingleRow(ctx, r.sql, query, []any{
+		session.UserID,
+		session.FamilyID,
+		session.Name,
+		session.LoginMethod,
+		session.IP,
+		session.UserAgent,
+		session.CreatedAt,
+		session.LastRefreshedAt,
+		session.ExpiresAt,
+	}, &session.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 同一家族重复写入（并发刷新）时保留已有会话
+		return nil
+	}
+	return err
+}
+
+func (r *userSessionRepository) Touch(ctx context.Context, familyID, ip, userAgent string, refreshedAt, expiresAt time.Time) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE user_sessions
+		SET last_refreshed_at = $2,
+			expires_at = $3,
+			ip = CASE WHEN $4 = '' THEN ip ELSE $4 END,
+			user_agent = CASE WHEN $5 = '' THEN user_agent ELSE $5 END
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID, refreshedAt, expiresAt, ip, userAgent)
+	return err
+}
+
+func (r *userSessionRepository) GetByID(ctx context.Context, id int64) (*service.UserSession, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+userSessionSelectColumns+" FROM user_sessions WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrUserSessionNotFound
+	}
+	session, err := scanUserSession(rows)
+	if err != nil {
+		return nil, err
+	}
+	return session, rows.Err()
+}
+
+func (r *userSessionRepository) ListActiveByUserID(ctx context.Context, userID int64, now time.Time) ([]service.UserSession, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+userSessionSelectColumns+`
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_refreshed_at DESC, id DESC
+	`, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.UserSession, 0)
+	for rows.Next() {
+		session, err := scanUserSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *session)
+	}
+	return out, rows.Err()
+}
+
+func (r *userSessionRepository) RevokeByFamilyID(ctx context.Context, familyID string, revokedAt time.Time) error {
+	_, err := r.sql.ExecContext(ctx, "UPDATE user_sessions SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL", familyID, revokedAt)
+	return err
+}
+
+func (r *userSessionRepository) RevokeAllByUserID(ctx context.Context, userID int64, revokedAt time.Time) error {
+	_, err := r.sql.ExecContext(ctx, "UPDATE user_sessions SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL", userID, revokedAt)
+	return err
+}
+
+func scanUserSession(rows *sql.Rows) (*service.UserSession, error) {
+	var (
+		session   service.UserSession
+		revokedAt sql.NullTime
+	)
+	if err := rows.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.FamilyID,
+		&session.Name,
+		&session.LoginMethod,
+		&session.IP,
+		&session.UserAgent,
+		&session.CreatedAt,
+		&session.LastRefreshedAt,
+		&session.ExpiresAt,
+		&revokedAt,
+	); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		t := revokedAt.Time
+		session.RevokedAt = &t
+	}
+	return &session, nil
+}
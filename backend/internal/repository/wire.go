@@ -25,6 +25,16 @@ func ProvideConcurrencyCache(rdb *redis.Client, cfg *config.Config) service.Conc
 	return NewConcurrencyCache(rdb, cfg.Gateway.ConcurrencySlotTTLMinutes, waitTTLSeconds)
 }
 
+// ProvideFairShareCache 创建分组内用户公平分配缓存，槽位过期时间与并发槽位一致
+func ProvideFairShareCache(rdb *redis.Client, cfg *config.Config) service.FairShareCache {
+	return NewFairShareCache(rdb, cfg.Gateway.ConcurrencySlotTTLMinutes)
+}
+
+// ProvideHTTPUpstream 创建上游 HTTP 客户端，并为引用代理池的账号启用代理池选择与故障切换
+func ProvideHTTPUpstream(cfg *config.Config, proxyPools *service.ProxyPoolService) service.HTTPUpstream {
+	return service.NewProxyPoolHTTPUpstream(NewHTTPUpstream(cfg), proxyPools)
+}
+
 // ProvideGitHubReleaseClient 创建 GitHub Release 客户端
 // 从配置中读取代理设置，支持国内服务器通过代理访问 GitHub
 func ProvideGitHubReleaseClient(cfg *config.Config) service.GitHubReleaseClient {
@@ -67,6 +77,29 @@ var ProviderSet = wire.NewSet(
 	NewUserAttributeDefinitionRepository,
 	NewUserAttributeValueRepository,
 	NewUserGroupRateRepository,
+	NewAccountProbeRepository,
+	NewProxyHealthRepository,
+	NewProxySubscriptionRepository,
+	NewProxySubscriptionFetcher,
+	NewAdminRoleRepository,
+	NewAdminAPIKeyRepository,
+	NewAdminAuditLogRepository,
+	NewUserOAuthIdentityRepository,
+	NewPasskeyRepository,
+	NewUserSessionRepository,
+	NewOrganizationRepository,
+	NewReferralRepository,
+	NewStatementRepository,
+	NewUserNotificationRepository,
+	NewUserNotificationWebhookSender,
+	NewUsageExportRepository,
+	NewUsagePartitionRepository,
+	NewUsageLogArchiveStore,
+	NewUsageLogBatchWriter,
+	NewIdempotencyUsageLookup,
+	NewUsageWriteQueue,
+	NewLoginEventRepository,
+	NewProxyPoolRepository,
 	NewErrorPassthroughRepository,
 
 	// Cache implementations
@@ -76,6 +109,7 @@ var ProviderSet = wire.NewSet(
 	NewTempUnschedCache,
 	NewTimeoutCounterCache,
 	ProvideConcurrencyCache,
+	ProvideFairShareCache,
 	ProvideSessionLimitCache,
 	NewDashboardCache,
 	NewEmailCache,
@@ -86,9 +120,13 @@ var ProviderSet = wire.NewSet(
 	NewSchedulerCache,
 	NewSchedulerOutboxRepository,
 	NewProxyLatencyCache,
+	NewAccountLatencyCache,
 	NewTotpCache,
+	NewPasskeyCache,
+	NewLoginSecurityCache,
 	NewRefreshTokenCache,
 	NewErrorPassthroughCache,
+	NewIdempotencyCache,
 
 	// Encryptors
 	NewAESEncryptor,
@@ -100,7 +138,7 @@ var ProviderSet = wire.NewSet(
 	NewProxyExitInfoProber,
 	NewClaudeUsageFetcher,
 	NewClaudeOAuthClient,
-	NewHTTPUpstream,
+	ProvideHTTPUpstream,
 	NewOpenAIOAuthClient,
 	NewGeminiOAuthClient,
 	NewGeminiCliCodeAssistClient,
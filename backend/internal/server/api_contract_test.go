@@ -87,7 +87,11 @@ func TestAPIContracts(t *testing.T) {
 					"quota_used": 0,
 					"expires_at": null,
 					"created_at": "2025-01-02T03:04:05Z",
-					"updated_at": "2025-01-02T03:04:05Z"
+					"updated_at": "2025-01-02T03:04:05Z",
+					"daily_limit_usd": null,
+					"weekly_limit_usd": null,
+					"monthly_limit_usd": null,
+					"key_generation": 0
 				}
 			}`,
 		},
@@ -126,7 +130,11 @@ func TestAPIContracts(t *testing.T) {
 							"quota_used": 0,
 							"expires_at": null,
 							"created_at": "2025-01-02T03:04:05Z",
-							"updated_at": "2025-01-02T03:04:05Z"
+							"updated_at": "2025-01-02T03:04:05Z",
+							"daily_limit_usd": null,
+							"weekly_limit_usd": null,
+							"monthly_limit_usd": null,
+							"key_generation": 0
 						}
 					],
 					"total": 1,
@@ -401,6 +409,7 @@ func TestAPIContracts(t *testing.T) {
 							"first_token_ms": 50,
 							"image_count": 0,
 							"image_size": null,
+							"key_generation": 0,
 							"created_at": "2025-01-02T03:04:05Z",
 							"user_agent": null
 						}
@@ -607,10 +616,10 @@ func newContractDeps(t *testing.T) *contractDeps {
 	settingRepo := newStubSettingRepo()
 	settingService := service.NewSettingService(settingRepo, cfg)
 
-	adminService := service.NewAdminService(userRepo, groupRepo, &accountRepo, proxyRepo, apiKeyRepo, redeemRepo, nil, nil, nil, nil, nil)
-	authHandler := handler.NewAuthHandler(cfg, nil, userService, settingService, nil, redeemService, nil)
-	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
-	usageHandler := handler.NewUsageHandler(usageService, apiKeyService)
+	adminService := service.NewAdminService(userRepo, groupRepo, &accountRepo, proxyRepo, apiKeyRepo, redeemRepo, nil, nil, nil, nil, nil, nil)
+	authHandler := handler.NewAuthHandler(cfg, nil, userService, settingService, nil, redeemService, nil, nil, nil, nil)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, nil)
+	usageHandler := handler.NewUsageHandler(usageService, apiKeyService, nil)
 	adminSettingHandler := adminhandler.NewSettingHandler(settingService, nil, nil, nil)
 	adminAccountHandler := adminhandler.NewAccountHandler(adminService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
@@ -1461,6 +1470,10 @@ func (r *stubApiKeyRepo) IncrementQuotaUsed(ctx context.Context, id int64, amoun
 	return 0, errors.New("not implemented")
 }
 
+func (r *stubApiKeyRepo) Rotate(ctx context.Context, id int64, expectedKey, newKey string, previousExpiresAt *time.Time, rotatedAt time.Time) error {
+	return errors.New("not implemented")
+}
+
 type stubUsageLogRepo struct {
 	userLogs map[int64][]service.UsageLog
 }
@@ -1537,6 +1550,10 @@ func (r *stubUsageLogRepo) GetModelStatsWithFilters(ctx context.Context, startTi
 	return nil, errors.New("not implemented")
 }
 
+func (r *stubUsageLogRepo) GetTagUsageStats(ctx context.Context, filters usagestats.TagUsageFilters) ([]usagestats.TagUsageStat, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (r *stubUsageLogRepo) GetAPIKeyUsageTrend(ctx context.Context, startTime, endTime time.Time, granularity string, limit int) ([]usagestats.APIKeyUsageTrendPoint, error) {
 	return nil, errors.New("not implemented")
 }
@@ -1594,6 +1611,10 @@ func (r *stubUsageLogRepo) GetAPIKeyStatsAggregated(ctx context.Context, apiKeyI
 	return nil, errors.New("not implemented")
 }
 
+func (r *stubUsageLogRepo) GetAPIKeySpendWindows(ctx context.Context, apiKeyID int64, starts service.APIKeySpendWindowStarts) (*service.APIKeySpendWindows, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (r *stubUsageLogRepo) GetAccountStatsAggregated(ctx context.Context, accountID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error) {
 	return nil, errors.New("not implemented")
 }
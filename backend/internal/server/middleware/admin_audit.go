@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminAuditResponseLimit 创建类接口响应的最大缓冲字节数（仅用于提取新对象 ID）
+const adminAuditResponseLimit = 64 * 1024
+
+// adminAuditSkipRoutes 只读的 POST 接口（批量查询、预览、测试），不记录审计日志
+var adminAuditSkipRoutes = map[string]struct{}{
+	"/dashboard/users-usage":        {},
+	"/dashboard/api-keys-usage":     {},
+	"/user-attributes/batch":        {},
+	"/scheduling/explain":           {},
+	"/accounts/check-mixed-channel": {},
+	"/accounts/sync/crs/preview":    {},
+	"/settings/test-smtp":           {},
+	"/settings/send-test-email":     {},
+}
+
+// NewAdminAuditMiddleware 创建管理操作审计中间件
+func NewAdminAuditMiddleware(auditService *service.AdminAuditService) AdminAuditMiddleware {
+	return AdminAuditMiddleware(adminAudit(auditService))
+}
+
+type adminAuditCaptureWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *adminAuditCaptureWriter) Write(b []byte) (int, error) {
+	if remaining := adminAuditResponseLimit - w.buf.Len(); remaining > 0 {
+		if len(b) > remaining {
+			_, _ = w.buf.Write(b[:remaining])
+		} else {
+			_, _ = w.buf.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *adminAuditCaptureWriter) WriteString(s string) (int, error) {
+	if remaining := adminAuditResponseLimit - w.buf.Len(); remaining > 0 {
+		if len(s) > remaining {
+			_, _ = w.buf.WriteString(s[:remaining])
+		} else {
+			_, _ = w.buf.WriteString(s)
+		}
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// adminAuditRoute 返回相对 /admin 的路由模板，如 /api/v1/admin/users/:id -> /users/:id
+func adminAuditRoute(fullPath string) string {
+	idx := strings.Index(fullPath, "/admin")
+	if idx < 0 {
+		return fullPath
+	}
+	return fullPath[idx+len("/admin"):]
+}
+
+// adminAudit 记录每个变更类管理接口（POST/PUT/PATCH/DELETE）的操作者、目标与前后差异。
+// 需挂在 adminAuth 之后；被权限中间件拒绝的请求同样记录（状态码 403），便于追查越权尝试。
+func adminAudit(auditService *service.AdminAuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+		route := adminAuditRoute(c.FullPath())
+		if _, skip := adminAuditSkipRoutes[route]; skip {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil && strings.Contains(c.ContentType(), "json") {
+			raw, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				AbortWithError(c, http.StatusBadRequest, "INVALID_REQUEST_BODY", "Failed to read request body")
+				return
+			}
+			body = raw
+			c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+		}
+
+		targetID := c.Param("id")
+		before := auditService.Snapshot(c.Request.Context(), route, targetID)
+
+		var capture *adminAuditCaptureWriter
+		if targetID == "" && method == http.MethodPost {
+			capture = &adminAuditCaptureWriter{ResponseWriter: c.Writer}
+			c.Writer = capture
+		}
+
+		c.Next()
+
+		input := &service.AdminAuditRecordInput{
+			IP:          ip.GetClientIP(c),
+			Method:      method,
+			Path:        c.Request.URL.Path,
+			Route:       route,
+			TargetID:    targetID,
+			StatusCode:  c.Writer.Status(),
+			RequestBody: body,
+			Before:      before,
+		}
+		if subject, ok := GetAuthSubjectFromContext(c); ok {
+			input.ActorUserID = subject.UserID
+		}
+		if key, ok := GetAdminAPIKeyFromContext(c); ok {
+			input.HasAPIKey = true
+			input.APIKeyID = key.ID
+			input.APIKeyName = key.Name
+		}
+		if v, ok := c.Get("auth_method"); ok {
+			input.AuthMethod, _ = v.(string)
+		}
+		if capture != nil {
+			input.ResponseBody = capture.buf.Bytes()
+		}
+		auditService.Record(c.Request.Context(), input)
+	}
+}
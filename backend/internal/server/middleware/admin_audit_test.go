@@ -0,0 +1,111 @@
+//go:build unit
+
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type auditRepoStub struct {
+	entries []*service.AdminAuditLog
+}
+
+func (r *auditRepoStub) Insert(ctx context.Context, entry *service.AdminAuditLog) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *auditRepoStub) GetByID(ctx context.Context, id int64) (*service.AdminAuditLog, error) {
+	return nil, service.ErrAdminAuditLogNotFound
+}
+
+func (r *auditRepoStub) List(ctx context.Context, filter service.AdminAuditLogFilter, params pagination.PaginationParams) ([]service.AdminAuditLog, *pagination.PaginationResult, error) {
+	return nil, nil, nil
+}
+
+func newAdminAuditRouter(repo *auditRepoStub) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	auditService := service.NewAdminAuditService(repo, nil, nil, nil, nil, nil, nil)
+
+	r := gin.New()
+	admin := r.Group("/api/v1/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set(string(ContextKeyUser), AuthSubject{UserID: 9})
+		c.Set(string(ContextKeyAdminPermissions), service.NewAdminPermissionSet(service.AdminPermAnnouncementsRead))
+		c.Set("auth_method", "jwt")
+		c.Next()
+	})
+	admin.Use(gin.HandlerFunc(NewAdminAuditMiddleware(auditService)))
+
+	announcements := admin.Group("/announcements")
+	announcements.GET("/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	announcements.PUT("/:id", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	admin.POST("/dashboard/users-usage", func(c *gin.Context) { c.Status(http.StatusOK) })
+	admin.POST("/system/restart", RequireAdminPermission(service.AdminPermSystemUpdate), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestAdminAuditMiddlewareRecordsMutations(t *testing.T) {
+	repo := &auditRepoStub{}
+	r := newAdminAuditRouter(repo)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/announcements/5", strings.NewReader(`{"title":"hi","password":"p"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	// 审计中间件读取请求体后需还原，处理函数仍能读到完整内容
+	require.Equal(t, `{"title":"hi","password":"p"}`, w.Body.String())
+	require.Len(t, repo.entries, 1)
+	entry := repo.entries[0]
+	require.Equal(t, "announcements.update", entry.Action)
+	require.Equal(t, "announcements", entry.Resource)
+	require.Equal(t, "5", entry.TargetID)
+	require.Equal(t, "/api/v1/admin/announcements/5", entry.Path)
+	require.Equal(t, "jwt", entry.AuthMethod)
+	require.Equal(t, int64(9), *entry.ActorUserID)
+	require.Nil(t, entry.ActorAPIKeyID)
+	require.Equal(t, map[string]any{"title": "hi", "password": "***"}, entry.Request)
+}
+
+func TestAdminAuditMiddlewareSkipsReads(t *testing.T) {
+	repo := &auditRepoStub{}
+	r := newAdminAuditRouter(repo)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/v1/admin/announcements/5", nil),
+		httptest.NewRequest(http.MethodPost, "/api/v1/admin/dashboard/users-usage", strings.NewReader(`{}`)),
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	require.Empty(t, repo.entries)
+}
+
+func TestAdminAuditMiddlewareRecordsDeniedAttempts(t *testing.T) {
+	repo := &auditRepoStub{}
+	r := newAdminAuditRouter(repo)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/system/restart", nil))
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Len(t, repo.entries, 1)
+	require.Equal(t, "system.restart", repo.entries[0].Action)
+	require.Equal(t, http.StatusForbidden, repo.entries[0].StatusCode)
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"strings"
 
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -16,18 +18,26 @@ func NewAdminAuthMiddleware(
 	authService *service.AuthService,
 	userService *service.UserService,
 	settingService *service.SettingService,
+	adminRoleService *service.AdminRoleService,
+	adminAPIKeyService *service.AdminAPIKeyService,
+	passkeyService *service.PasskeyService,
 ) AdminAuthMiddleware {
-	return AdminAuthMiddleware(adminAuth(authService, userService, settingService))
+	return AdminAuthMiddleware(adminAuth(authService, userService, settingService, adminRoleService, adminAPIKeyService, passkeyService))
 }
 
 // adminAuth 管理员认证中间件实现
 // 支持两种认证方式（通过不同的 header 区分）：
-// 1. Admin API Key: x-api-key: <admin-api-key>
+// 1. Admin API Key: x-api-key: <admin-api-key>（具名 Key 按权限范围授权，兼容旧版全局 Key）
 // 2. JWT Token: Authorization: Bearer <jwt-token> (需要管理员角色)
+//
+// 认证通过后将管理员权限集合写入上下文，由 RequireAdminPermission / RequireAdminAccess 按路由组校验。
 func adminAuth(
 	authService *service.AuthService,
 	userService *service.UserService,
 	settingService *service.SettingService,
+	adminRoleService *service.AdminRoleService,
+	adminAPIKeyService *service.AdminAPIKeyService,
+	passkeyService *service.PasskeyService,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// WebSocket upgrade requests cannot set Authorization headers in browsers.
@@ -36,7 +46,7 @@ func adminAuth(
 		//   Sec-WebSocket-Protocol: sub2api-admin, jwt.<token>
 		if isWebSocketUpgradeRequest(c) {
 			if token := extractJWTFromWebSocketSubprotocol(c); token != "" {
-				if !validateJWTForAdmin(c, token, authService, userService) {
+				if !validateJWTForAdmin(c, token, authService, userService, adminRoleService, passkeyService) {
 					return
 				}
 				c.Next()
@@ -47,7 +57,7 @@ func adminAuth(
 		// 检查 x-api-key header（Admin API Key 认证）
 		apiKey := c.GetHeader("x-api-key")
 		if apiKey != "" {
-			if !validateAdminAPIKey(c, apiKey, settingService, userService) {
+			if !validateAdminAPIKey(c, apiKey, settingService, userService, adminAPIKeyService) {
 				return
 			}
 			c.Next()
@@ -59,7 +69,7 @@ func adminAuth(
 		if authHeader != "" {
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) == 2 && parts[0] == "Bearer" {
-				if !validateJWTForAdmin(c, parts[1], authService, userService) {
+				if !validateJWTForAdmin(c, parts[1], authService, userService, adminRoleService, passkeyService) {
 					return
 				}
 				c.Next()
@@ -116,7 +126,31 @@ func validateAdminAPIKey(
 	key string,
 	settingService *service.SettingService,
 	userService *service.UserService,
+	adminAPIKeyService *service.AdminAPIKeyService,
 ) bool {
+	// 优先匹配具名管理员 API Key
+	scoped, owner, perms, err := adminAPIKeyService.Authenticate(c.Request.Context(), key, ip.GetClientIP(c))
+	switch {
+	case err == nil:
+		c.Set(string(ContextKeyUser), AuthSubject{
+			UserID:      owner.ID,
+			Concurrency: owner.Concurrency,
+		})
+		c.Set(string(ContextKeyUserRole), owner.Role)
+		c.Set(string(ContextKeyAdminPermissions), perms)
+		c.Set(string(ContextKeyAdminAPIKey), AdminAPIKeySubject{ID: scoped.ID, Name: scoped.Name})
+		c.Set("auth_method", "admin_api_key")
+		return true
+	case errors.Is(err, service.ErrAdminAPIKeyInvalid):
+		// 未匹配具名 Key，继续校验旧版全局 Key
+	case infraerrors.Code(err) >= 400 && infraerrors.Code(err) < 500:
+		AbortWithError(c, infraerrors.Code(err), infraerrors.Reason(err), infraerrors.Message(err))
+		return false
+	default:
+		AbortWithError(c, 500, "INTERNAL_ERROR", "Internal server error")
+		return false
+	}
+
 	storedKey, err := settingService.GetAdminAPIKey(c.Request.Context())
 	if err != nil {
 		AbortWithError(c, 500, "INTERNAL_ERROR", "Internal server error")
@@ -141,6 +175,9 @@ func validateAdminAPIKey(
 		Concurrency: admin.Concurrency,
 	})
 	c.Set(string(ContextKeyUserRole), admin.Role)
+	// 全局 Admin API Key 拥有全部权限
+	c.Set(string(ContextKeyAdminPermissions), service.NewAdminPermissionSet(service.AllAdminPermissions()...))
+	c.Set(string(ContextKeyAdminAPIKey), AdminAPIKeySubject{Name: "global"})
 	c.Set("auth_method", "admin_api_key")
 	return true
 }
@@ -151,6 +188,8 @@ func validateJWTForAdmin(
 	token string,
 	authService *service.AuthService,
 	userService *service.UserService,
+	adminRoleService *service.AdminRoleService,
+	passkeyService *service.PasskeyService,
 ) bool {
 	// 验证 JWT token
 	claims, err := authService.ValidateToken(token)
@@ -182,11 +221,30 @@ func validateJWTForAdmin(
 		return false
 	}
 
+	// 角色要求通行密钥但尚未注册时，仅允许访问用户侧接口完成注册
+	if passkeyService != nil {
+		if err := passkeyService.CheckEnrollment(c.Request.Context(), user); err != nil {
+			if infraerrors.Code(err) == 403 {
+				AbortWithError(c, 403, infraerrors.Reason(err), infraerrors.Message(err))
+				return false
+			}
+			AbortWithError(c, 500, "INTERNAL_ERROR", "Internal server error")
+			return false
+		}
+	}
+
+	perms, err := adminRoleService.ResolvePermissions(c.Request.Context(), user)
+	if err != nil {
+		AbortWithError(c, 500, "INTERNAL_ERROR", "Internal server error")
+		return false
+	}
+
 	c.Set(string(ContextKeyUser), AuthSubject{
 		UserID:      user.ID,
 		Concurrency: user.Concurrency,
 	})
 	c.Set(string(ContextKeyUserRole), user.Role)
+	c.Set(string(ContextKeyAdminPermissions), perms)
 	c.Set("auth_method", "jwt")
 
 	return true
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdminPermission 要求当前管理员拥有全部指定权限。
+// 必须在 adminAuth 之后使用。
+func RequireAdminPermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set, ok := GetAdminPermissionsFromContext(c)
+		if !ok {
+			AbortWithError(c, 403, "FORBIDDEN", "Admin permissions not resolved")
+			return
+		}
+		for _, p := range perms {
+			if !set.Has(p) {
+				AbortWithError(c, 403, "PERMISSION_DENIED", "Missing admin permission: "+p)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireAdminAccess 按请求方法校验路由组权限：GET/HEAD/OPTIONS 需要 readPerm，其余方法需要 writePerm。
+// 只读语义的 POST 接口（如批量查询）应单独注册并使用 RequireAdminPermission(readPerm)。
+func RequireAdminAccess(readPerm, writePerm string) gin.HandlerFunc {
+	read := RequireAdminPermission(readPerm)
+	write := RequireAdminPermission(writePerm)
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			read(c)
+		default:
+			write(c)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+//go:build unit
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newAdminPermissionRouter(perms service.AdminPermissionSet) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if perms != nil {
+			c.Set(string(ContextKeyAdminPermissions), perms)
+		}
+		c.Next()
+	})
+	users := r.Group("/users", RequireAdminAccess(service.AdminPermUsersRead, service.AdminPermUsersWrite))
+	users.GET("", func(c *gin.Context) { c.Status(http.StatusOK) })
+	users.POST("", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/system/update", RequireAdminPermission(service.AdminPermSystemUpdate), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestRequireAdminAccess(t *testing.T) {
+	support := service.NewAdminPermissionSet(service.AdminPermUsersRead)
+	r := newAdminPermissionRouter(support)
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/users", http.StatusOK},
+		{http.MethodPost, "/users", http.StatusForbidden},
+		{http.MethodPost, "/system/update", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		require.Equal(t, tc.want, w.Code, "%s %s", tc.method, tc.path)
+	}
+
+	// 未经 adminAuth 解析权限时一律拒绝
+	r = newAdminPermissionRouter(nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
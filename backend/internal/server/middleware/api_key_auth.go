@@ -125,6 +125,7 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 				Concurrency: apiKey.User.Concurrency,
 			})
 			c.Set(string(ContextKeyUserRole), apiKey.User.Role)
+			setUserContext(c, apiKey.User.ID)
 			setGroupContext(c, apiKey.Group)
 			c.Next()
 			return
@@ -134,10 +135,10 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 		isSubscriptionType := apiKey.Group != nil && apiKey.Group.IsSubscriptionType()
 
 		if isSubscriptionType && subscriptionService != nil {
-			// 订阅模式：验证订阅
+			// 订阅模式：验证订阅（组织 Key 使用组织所有者的订阅）
 			subscription, err := subscriptionService.GetActiveSubscription(
 				c.Request.Context(),
-				apiKey.User.ID,
+				apiKey.BillingUserID(),
 				apiKey.Group.ID,
 			)
 			if err != nil {
@@ -169,8 +170,8 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 
 			// 将订阅信息存入上下文
 			c.Set(string(ContextKeySubscription), subscription)
-		} else {
-			// 余额模式：检查用户余额
+		} else if apiKey.OrganizationID == nil {
+			// 余额模式：检查用户余额（组织 Key 的组织余额由 CheckBillingEligibility 检查）
 			if apiKey.User.Balance <= 0 {
 				AbortWithError(c, 403, "INSUFFICIENT_BALANCE", "Insufficient account balance")
 				return
@@ -184,6 +185,7 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			Concurrency: apiKey.User.Concurrency,
 		})
 		c.Set(string(ContextKeyUserRole), apiKey.User.Role)
+		setUserContext(c, apiKey.User.ID)
 		setGroupContext(c, apiKey.Group)
 
 		c.Next()
@@ -210,6 +212,14 @@ func GetSubscriptionFromContext(c *gin.Context) (*service.UserSubscription, bool
 	return subscription, ok
 }
 
+func setUserContext(c *gin.Context, userID int64) {
+	if userID <= 0 {
+		return
+	}
+	ctx := context.WithValue(c.Request.Context(), ctxkey.UserID, userID)
+	c.Request = c.Request.WithContext(ctx)
+}
+
 func setGroupContext(c *gin.Context, group *service.Group) {
 	if !service.IsGroupContextValid(group) {
 		return
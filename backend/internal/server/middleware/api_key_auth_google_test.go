@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
@@ -79,6 +80,10 @@ func (f fakeAPIKeyRepo) IncrementQuotaUsed(ctx context.Context, id int64, amount
 	return 0, errors.New("not implemented")
 }
 
+func (f fakeAPIKeyRepo) Rotate(ctx context.Context, id int64, expectedKey, newKey string, previousExpiresAt *time.Time, rotatedAt time.Time) error {
+	return errors.New("not implemented")
+}
+
 type googleErrorResponse struct {
 	Error struct {
 		Code    int    `json:"code"`
@@ -1,6 +1,10 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
 
 // AuthSubject is the minimal authenticated identity stored in gin context.
 // Decision: {UserID int64, Concurrency int}
@@ -9,6 +13,12 @@ type AuthSubject struct {
 	Concurrency int
 }
 
+// AdminAPIKeySubject 标识通过管理员 API Key 认证的请求来源。ID 为 0 表示旧版全局 Admin API Key。
+type AdminAPIKeySubject struct {
+	ID   int64
+	Name string
+}
+
 func GetAuthSubjectFromContext(c *gin.Context) (AuthSubject, bool) {
 	value, exists := c.Get(string(ContextKeyUser))
 	if !exists {
@@ -26,3 +36,29 @@ func GetUserRoleFromContext(c *gin.Context) (string, bool) {
 	role, ok := value.(string)
 	return role, ok
 }
+
+// GetAdminPermissionsFromContext 获取 adminAuth 写入的管理员权限集合
+func GetAdminPermissionsFromContext(c *gin.Context) (service.AdminPermissionSet, bool) {
+	value, exists := c.Get(string(ContextKeyAdminPermissions))
+	if !exists {
+		return nil, false
+	}
+	perms, ok := value.(service.AdminPermissionSet)
+	return perms, ok
+}
+
+// HasAdminPermission 判断当前管理员是否拥有全部指定权限，上下文中没有权限集合时返回 false
+func HasAdminPermission(c *gin.Context, perms ...string) bool {
+	set, ok := GetAdminPermissionsFromContext(c)
+	return ok && set.Has(perms...)
+}
+
+// GetAdminAPIKeyFromContext 获取本次请求使用的管理员 API Key
+func GetAdminAPIKeyFromContext(c *gin.Context) (AdminAPIKeySubject, bool) {
+	value, exists := c.Get(string(ContextKeyAdminAPIKey))
+	if !exists {
+		return AdminAPIKeySubject{}, false
+	}
+	subject, ok := value.(AdminAPIKeySubject)
+	return subject, ok
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientInfo stores the client IP and User-Agent in request.Context().
+//
+// Services that do not see the gin.Context (login sessions, login events) read them from the context.
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request == nil {
+			c.Next()
+			return
+		}
+		ctx := context.WithValue(c.Request.Context(), ctxkey.ClientIP, ip.GetClientIP(c))
+		ctx = context.WithValue(ctx, ctxkey.UserAgent, c.Request.UserAgent())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// idempotencySkipHeaders 不随保存的响应重放的响应头
+var idempotencySkipHeaders = map[string]struct{}{
+	"Content-Length":    {},
+	"Date":              {},
+	"Connection":        {},
+	"Transfer-Encoding": {},
+	"Set-Cookie":        {},
+}
+
+// NewIdempotencyMiddleware 创建 Idempotency-Key 中间件
+func NewIdempotencyMiddleware(idempotencyService *service.IdempotencyService) IdempotencyMiddleware {
+	return IdempotencyMiddleware(idempotency(idempotencyService))
+}
+
+type idempotencyCaptureWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (w *idempotencyCaptureWriter) capture(n int, write func()) {
+	if w.overflow {
+		return
+	}
+	if w.buf.Len()+n > w.limit {
+		w.overflow = true
+		w.buf.Reset()
+		return
+	}
+	write()
+}
+
+func (w *idempotencyCaptureWriter) Write(b []byte) (int, error) {
+	w.capture(len(b), func() { _, _ = w.buf.Write(b) })
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture(len(s), func() { _, _ = w.buf.WriteString(s) })
+	return w.ResponseWriter.WriteString(s)
+}
+
+// errorAfterReader 读完已缓冲的内容后返回原始读取错误（如请求体超限），交由 handler 按原逻辑处理
+type errorAfterReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errorAfterReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err == io.EOF {
+		return n, e.err
+	}
+	return n, err
+}
+
+// idempotency 处理网关请求的 Idempotency-Key 请求头，需挂在 API Key 认证之后。
+//
+// 首个请求正常执行，成功的非流式响应被保存；TTL 内同 Key 的重复请求直接重放该响应
+// （附加 Idempotent-Replayed: true），进行中时等待其完成，流式请求返回 409。
+// 失败（状态码 >= 400）的请求会释放幂等键，允许客户端重试。
+func idempotency(idempotencyService *service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(service.IdempotencyKeyHeader)
+		if key == "" || !idempotencyService.Enabled() {
+			c.Next()
+			return
+		}
+		apiKey, ok := GetAPIKeyFromContext(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		if err := service.ValidateIdempotencyKey(key); err != nil {
+			abortWithIdempotencyError(c, err)
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			raw, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.Request.Body = io.NopCloser(&errorAfterReader{r: bytes.NewReader(raw), err: err})
+				c.Next()
+				return
+			}
+			body = raw
+			c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+		}
+
+		stream := isIdempotencyStreamRequest(c.Request.URL.Path, body)
+		fingerprint := idempotencyFingerprint(c.Request.Method, c.Request.URL.Path, body)
+		result, err := idempotencyService.Begin(c.Request.Context(), service.IdempotencyBeginInput{
+			APIKeyID:    apiKey.ID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			Stream:      stream,
+		})
+		if err != nil {
+			abortWithIdempotencyError(c, err)
+			return
+		}
+		if result.Replay != nil {
+			replayIdempotentResponse(c, result.Replay)
+			return
+		}
+
+		claim := result.Claim
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxkey.IdempotencyRequestID, claim.RequestID))
+		capture := &idempotencyCaptureWriter{ResponseWriter: c.Writer, limit: idempotencyService.MaxResponseBytes()}
+		c.Writer = capture
+
+		c.Next()
+
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := c.Writer.Status()
+		// 未写出任何响应（如客户端提前断开）与失败请求一样释放，允许重试
+		if status >= http.StatusBadRequest || !c.Writer.Written() {
+			idempotencyService.Release(ctx, claim)
+			return
+		}
+		eventStream := strings.HasPrefix(c.Writer.Header().Get("Content-Type"), "text/event-stream")
+		resp := &service.IdempotencyResponse{
+			StatusCode: status,
+			Replayable: !stream && !eventStream && !capture.overflow,
+		}
+		if resp.Replayable {
+			resp.Header = idempotencyResponseHeader(c.Writer.Header())
+			resp.Body = capture.buf.Bytes()
+		}
+		idempotencyService.Complete(ctx, claim, resp)
+	}
+}
+
+// isIdempotencyStreamRequest 判断请求是否为流式：Claude/OpenAI 通过请求体 stream 字段，Gemini 通过 streamGenerateContent 动作
+func isIdempotencyStreamRequest(path string, body []byte) bool {
+	if strings.Contains(path, ":streamGenerateContent") {
+		return true
+	}
+	return gjson.GetBytes(body, "stream").Bool()
+}
+
+func idempotencyFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(method + " " + path + "\n"))
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func idempotencyResponseHeader(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		if _, skip := idempotencySkipHeaders[name]; skip || len(values) == 0 {
+			continue
+		}
+		out[name] = values[0]
+	}
+	return out
+}
+
+func replayIdempotentResponse(c *gin.Context, record *service.IdempotencyRecord) {
+	for name, value := range record.Header {
+		c.Header(name, value)
+	}
+	c.Header(service.IdempotencyReplayedHeader, "true")
+	c.Data(record.StatusCode, record.Header["Content-Type"], record.Body)
+	c.Abort()
+}
+
+// abortWithIdempotencyError 按路由所属协议返回错误（Gemini / OpenAI Responses / Claude）
+func abortWithIdempotencyError(c *gin.Context, err error) {
+	status := infraerrors.Code(err)
+	message := infraerrors.Message(err)
+	path := c.Request.URL.Path
+	switch {
+	case strings.Contains(path, "/v1beta/"):
+		abortWithGoogleError(c, status, message)
+		return
+	case strings.HasSuffix(path, "/responses"):
+		c.JSON(status, gin.H{
+			"error": gin.H{
+				"type":    idempotencyErrorType(status),
+				"code":    infraerrors.Reason(err),
+				"message": message,
+			},
+		})
+	default:
+		c.JSON(status, gin.H{
+			"type": "error",
+			"error": gin.H{
+				"type":    idempotencyErrorType(status),
+				"message": message,
+			},
+		})
+	}
+	c.Abort()
+}
+
+func idempotencyErrorType(status int) string {
+	if status >= http.StatusInternalServerError {
+		return "api_error"
+	}
+	return "invalid_request_error"
+}
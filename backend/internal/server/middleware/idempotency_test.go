@@ -0,0 +1,169 @@
+//go:build unit
+
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryIdempotencyCache struct {
+	mu      sync.Mutex
+	owners  map[string]string
+	records map[string][]byte
+}
+
+func (m *memoryIdempotencyCache) Claim(ctx context.Context, key, owner string, record []byte, ttl time.Duration) (bool, []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.records[key]; ok {
+		return false, existing, nil
+	}
+	m.owners[key] = owner
+	m.records[key] = record
+	return true, nil, nil
+}
+
+func (m *memoryIdempotencyCache) Complete(ctx context.Context, key, owner string, record []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owners[key] != owner {
+		return false, nil
+	}
+	m.records[key] = record
+	return true, nil
+}
+
+func (m *memoryIdempotencyCache) Release(ctx context.Context, key, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owners[key] == owner {
+		delete(m.owners, key)
+		delete(m.records, key)
+	}
+	return nil
+}
+
+func newIdempotencyRouter(t *testing.T, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Gateway.Idempotency = config.GatewayIdempotencyConfig{
+		Enabled:          true,
+		TTLSeconds:       60,
+		LockTTLSeconds:   30,
+		MaxResponseBytes: 1024,
+	}
+	cache := &memoryIdempotencyCache{owners: map[string]string{}, records: map[string][]byte{}}
+	svc := service.NewIdempotencyService(cache, nil, nil, cfg)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(string(ContextKeyAPIKey), &service.APIKey{ID: 7})
+		c.Next()
+	})
+	r.POST("/v1/messages", gin.HandlerFunc(NewIdempotencyMiddleware(svc)), handler)
+	return r
+}
+
+func doIdempotentRequest(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(service.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysNonStreamingResponse(t *testing.T) {
+	calls := 0
+	var requestID string
+	r := newIdempotencyRouter(t, func(c *gin.Context) {
+		calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		require.Contains(t, string(body), "hello")
+		requestID, _ = c.Request.Context().Value(ctxkey.IdempotencyRequestID).(string)
+		c.Header("request-id", "req_upstream")
+		c.JSON(http.StatusOK, gin.H{"id": "msg_1"})
+	})
+	body := `{"model":"claude","messages":[{"role":"user","content":"hello"}]}`
+
+	first := doIdempotentRequest(r, "retry-1", body)
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Equal(t, service.IdempotencyRequestID("retry-1"), requestID)
+
+	second := doIdempotentRequest(r, "retry-1", body)
+	require.Equal(t, http.StatusOK, second.Code)
+	require.Equal(t, 1, calls)
+	require.Equal(t, "true", second.Header().Get(service.IdempotencyReplayedHeader))
+	require.Equal(t, "req_upstream", second.Header().Get("request-id"))
+	require.JSONEq(t, first.Body.String(), second.Body.String())
+
+	// 相同 Key 不同请求体
+	mismatch := doIdempotentRequest(r, "retry-1", `{"model":"other"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(mismatch.Body.Bytes(), &resp))
+	require.Equal(t, "error", resp["type"])
+
+	// 未携带 Key 的请求不受影响
+	doIdempotentRequest(r, "", body)
+	require.Equal(t, 2, calls)
+}
+
+func TestIdempotencyStreamingDuplicateConflicts(t *testing.T) {
+	calls := 0
+	r := newIdempotencyRouter(t, func(c *gin.Context) {
+		calls++
+		c.Header("Content-Type", "text/event-stream")
+		c.String(http.StatusOK, "event: message_stop\ndata: {}\n\n")
+	})
+	body := `{"model":"claude","stream":true}`
+
+	require.Equal(t, http.StatusOK, doIdempotentRequest(r, "s-1", body).Code)
+	dup := doIdempotentRequest(r, "s-1", body)
+	require.Equal(t, http.StatusConflict, dup.Code)
+	require.Contains(t, dup.Body.String(), "cannot be replayed")
+	require.Equal(t, 1, calls)
+}
+
+func TestIdempotencyFailedRequestCanBeRetried(t *testing.T) {
+	calls := 0
+	r := newIdempotencyRouter(t, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusBadGateway, gin.H{"type": "error"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": "msg_2"})
+	})
+	body := `{"model":"claude"}`
+
+	require.Equal(t, http.StatusBadGateway, doIdempotentRequest(r, "f-1", body).Code)
+	require.Equal(t, http.StatusOK, doIdempotentRequest(r, "f-1", body).Code)
+	require.Equal(t, http.StatusOK, doIdempotentRequest(r, "f-1", body).Code)
+	require.Equal(t, 2, calls)
+}
+
+func TestIdempotencyInvalidKey(t *testing.T) {
+	r := newIdempotencyRouter(t, func(c *gin.Context) {
+		t.Fatal("handler should not be called")
+	})
+	w := doIdempotentRequest(r, strings.Repeat("k", 256), `{}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
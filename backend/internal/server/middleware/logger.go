@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fmt"
 	"log"
 	"time"
 
@@ -37,8 +38,14 @@ func Logger() gin.HandlerFunc {
 		// 协议版本
 		protocol := c.Request.Proto
 
-		// 日志格式: [时间] 状态码 | 延迟 | IP | 协议 | 方法 路径
-		log.Printf("[GIN] %v | %3d | %13v | %15s | %-6s | %-7s %s",
+		// 管理员 API Key 请求附加 Key 归属
+		keySuffix := ""
+		if key, ok := GetAdminAPIKeyFromContext(c); ok {
+			keySuffix = fmt.Sprintf(" | admin_key=%s#%d", key.Name, key.ID)
+		}
+
+		// 日志格式: [时间] 状态码 | 延迟 | IP | 协议 | 方法 路径 [| admin_key=名称#ID]
+		log.Printf("[GIN] %v | %3d | %13v | %15s | %-6s | %-7s %s%s",
 			endTime.Format("2006/01/02 - 15:04:05"),
 			statusCode,
 			latency,
@@ -46,6 +53,7 @@ func Logger() gin.HandlerFunc {
 			protocol,
 			method,
 			path,
+			keySuffix,
 		)
 
 		// 如果有错误，额外记录错误信息
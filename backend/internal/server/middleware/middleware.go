@@ -15,6 +15,10 @@ const (
 	ContextKeyUser ContextKey = "user"
 	// ContextKeyUserRole 当前用户角色（string）
 	ContextKeyUserRole ContextKey = "user_role"
+	// ContextKeyAdminPermissions 当前管理员权限集合（service.AdminPermissionSet）
+	ContextKeyAdminPermissions ContextKey = "admin_permissions"
+	// ContextKeyAdminAPIKey 本次请求使用的管理员 API Key（AdminAPIKeySubject），用于日志归属
+	ContextKeyAdminAPIKey ContextKey = "admin_api_key"
 	// ContextKeyAPIKey API密钥上下文键
 	ContextKeyAPIKey ContextKey = "api_key"
 	// ContextKeySubscription 订阅上下文键
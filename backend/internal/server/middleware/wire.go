@@ -11,12 +11,20 @@ type JWTAuthMiddleware gin.HandlerFunc
 // AdminAuthMiddleware 管理员认证中间件类型
 type AdminAuthMiddleware gin.HandlerFunc
 
+// AdminAuditMiddleware 管理操作审计中间件类型
+type AdminAuditMiddleware gin.HandlerFunc
+
 // APIKeyAuthMiddleware API Key 认证中间件类型
 type APIKeyAuthMiddleware gin.HandlerFunc
 
+// IdempotencyMiddleware 网关 Idempotency-Key 中间件类型
+type IdempotencyMiddleware gin.HandlerFunc
+
 // ProviderSet 中间件层的依赖注入
 var ProviderSet = wire.NewSet(
 	NewJWTAuthMiddleware,
 	NewAdminAuthMiddleware,
+	NewAdminAuditMiddleware,
 	NewAPIKeyAuthMiddleware,
+	NewIdempotencyMiddleware,
 )
@@ -4,18 +4,24 @@ package routes
 import (
 	"github.com/Wei-Shaw/sub2api/internal/handler"
 	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterAdminRoutes 注册管理员路由
+// 每个路由组通过 RequireAdminAccess / RequireAdminPermission 校验管理员角色权限（见 service/admin_role.go），
+// 所有变更类请求由 adminAudit 写入审计日志。
 func RegisterAdminRoutes(
 	v1 *gin.RouterGroup,
 	h *handler.Handlers,
 	adminAuth middleware.AdminAuthMiddleware,
+	adminAudit middleware.AdminAuditMiddleware,
 ) {
 	admin := v1.Group("/admin")
 	admin.Use(gin.HandlerFunc(adminAuth))
+	// 审计中间件在权限校验之前执行，越权尝试同样留痕
+	admin.Use(gin.HandlerFunc(adminAudit))
 	{
 		// 仪表盘
 		registerDashboardRoutes(admin, h)
@@ -70,11 +76,20 @@ func RegisterAdminRoutes(
 
 		// 错误透传规则管理
 		registerErrorPassthroughRoutes(admin, h)
+
+		// 调度诊断
+		registerSchedulingRoutes(admin, h)
+
+		// 角色与权限
+		registerAdminRoleRoutes(admin, h)
+
+		// 审计日志
+		registerAdminAuditRoutes(admin, h)
 	}
 }
 
 func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	ops := admin.Group("/ops")
+	ops := admin.Group("/ops", middleware.RequireAdminAccess(service.AdminPermOpsRead, service.AdminPermOpsWrite))
 	{
 		// Realtime ops signals
 		ops.GET("/concurrency", h.Admin.Ops.GetConcurrencyStats)
@@ -154,41 +169,68 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerDashboardRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	dashboard := admin.Group("/dashboard")
+	// 批量用量查询为只读 POST，整组只要求 dashboard:read
+	dashboard := admin.Group("/dashboard", middleware.RequireAdminPermission(service.AdminPermDashboardRead))
 	{
 		dashboard.GET("/stats", h.Admin.Dashboard.GetStats)
 		dashboard.GET("/realtime", h.Admin.Dashboard.GetRealtimeMetrics)
 		dashboard.GET("/trend", h.Admin.Dashboard.GetUsageTrend)
 		dashboard.GET("/models", h.Admin.Dashboard.GetModelStats)
+		dashboard.GET("/tags", h.Admin.Dashboard.GetTagUsage)
 		dashboard.GET("/api-keys-trend", h.Admin.Dashboard.GetAPIKeyUsageTrend)
 		dashboard.GET("/users-trend", h.Admin.Dashboard.GetUserUsageTrend)
 		dashboard.POST("/users-usage", h.Admin.Dashboard.GetBatchUsersUsage)
 		dashboard.POST("/api-keys-usage", h.Admin.Dashboard.GetBatchAPIKeysUsage)
-		dashboard.POST("/aggregation/backfill", h.Admin.Dashboard.BackfillAggregation)
+		dashboard.POST("/aggregation/backfill", middleware.RequireAdminPermission(service.AdminPermOpsWrite), h.Admin.Dashboard.BackfillAggregation)
 	}
 }
 
 func registerUserManagementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	users := admin.Group("/users")
+	users := admin.Group("/users", middleware.RequireAdminAccess(service.AdminPermUsersRead, service.AdminPermUsersWrite))
 	{
 		users.GET("", h.Admin.User.List)
 		users.GET("/:id", h.Admin.User.GetByID)
 		users.POST("", h.Admin.User.Create)
 		users.PUT("/:id", h.Admin.User.Update)
 		users.DELETE("/:id", h.Admin.User.Delete)
-		users.POST("/:id/balance", h.Admin.User.UpdateBalance)
 		users.GET("/:id/api-keys", h.Admin.User.GetUserAPIKeys)
 		users.GET("/:id/usage", h.Admin.User.GetUserUsage)
 		users.GET("/:id/balance-history", h.Admin.User.GetBalanceHistory)
 
+		// 登录会话
+		users.GET("/:id/sessions", h.Admin.LoginSecurity.ListUserSessions)
+		users.DELETE("/:id/sessions", h.Admin.LoginSecurity.RevokeAllUserSessions)
+		users.DELETE("/:id/sessions/:session_id", h.Admin.LoginSecurity.RevokeUserSession)
+
 		// User attribute values
 		users.GET("/:id/attributes", h.Admin.UserAttribute.GetUserAttributes)
 		users.PUT("/:id/attributes", h.Admin.UserAttribute.UpdateUserAttributes)
 	}
+
+	// 余额调整单独授权（billing 角色无 users:write）
+	admin.POST("/users/:id/balance", middleware.RequireAdminPermission(service.AdminPermUsersBalance), h.Admin.User.UpdateBalance)
+
+	// 登录事件与登录锁定
+	admin.GET("/login-events", middleware.RequireAdminPermission(service.AdminPermAuditRead), h.Admin.LoginSecurity.ListEvents)
+	admin.POST("/login-lockouts/unlock", middleware.RequireAdminPermission(service.AdminPermUsersWrite), h.Admin.LoginSecurity.Unlock)
+
+	// 组织（团队）管理，沿用用户管理权限
+	organizations := admin.Group("/organizations", middleware.RequireAdminAccess(service.AdminPermUsersRead, service.AdminPermUsersWrite))
+	{
+		organizations.GET("", h.Admin.Organization.List)
+		organizations.GET("/:id", h.Admin.Organization.Get)
+		organizations.PUT("/:id/status", h.Admin.Organization.UpdateStatus)
+	}
+	admin.POST("/organizations/:id/balance", middleware.RequireAdminPermission(service.AdminPermUsersBalance), h.Admin.Organization.UpdateBalance)
+
+	// 推荐返佣台账：审核通过即入账到推荐人余额，需要余额调整权限
+	admin.GET("/referral-commissions", middleware.RequireAdminPermission(service.AdminPermUsersRead), h.Admin.Referral.ListCommissions)
+	admin.POST("/referral-commissions/:id/approve", middleware.RequireAdminPermission(service.AdminPermUsersBalance), h.Admin.Referral.ApproveCommission)
+	admin.POST("/referral-commissions/:id/reject", middleware.RequireAdminPermission(service.AdminPermUsersBalance), h.Admin.Referral.RejectCommission)
 }
 
 func registerGroupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	groups := admin.Group("/groups")
+	groups := admin.Group("/groups", middleware.RequireAdminAccess(service.AdminPermGroupsRead, service.AdminPermGroupsWrite))
 	{
 		groups.GET("", h.Admin.Group.List)
 		groups.GET("/all", h.Admin.Group.GetAll)
@@ -203,14 +245,16 @@ func registerGroupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerAccountRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	accounts := admin.Group("/accounts")
+	accounts := admin.Group("/accounts", middleware.RequireAdminAccess(service.AdminPermAccountsRead, service.AdminPermAccountsWrite))
+	// 涉及明文凭证的接口（创建、导入导出、凭证批量修改、OAuth 授权）额外要求 accounts:credentials
+	credentials := middleware.RequireAdminPermission(service.AdminPermAccountsCredentials)
 	{
 		accounts.GET("", h.Admin.Account.List)
 		accounts.GET("/:id", h.Admin.Account.GetByID)
-		accounts.POST("", h.Admin.Account.Create)
+		accounts.POST("", credentials, h.Admin.Account.Create)
 		accounts.POST("/check-mixed-channel", h.Admin.Account.CheckMixedChannel)
-		accounts.POST("/sync/crs", h.Admin.Account.SyncFromCRS)
-		accounts.POST("/sync/crs/preview", h.Admin.Account.PreviewFromCRS)
+		accounts.POST("/sync/crs", credentials, h.Admin.Account.SyncFromCRS)
+		accounts.POST("/sync/crs/preview", credentials, h.Admin.Account.PreviewFromCRS)
 		accounts.PUT("/:id", h.Admin.Account.Update)
 		accounts.DELETE("/:id", h.Admin.Account.Delete)
 		accounts.POST("/:id/test", h.Admin.Account.Test)
@@ -225,28 +269,34 @@ func registerAccountRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		accounts.DELETE("/:id/temp-unschedulable", h.Admin.Account.ClearTempUnschedulable)
 		accounts.POST("/:id/schedulable", h.Admin.Account.SetSchedulable)
 		accounts.GET("/:id/models", h.Admin.Account.GetAvailableModels)
-		accounts.POST("/batch", h.Admin.Account.BatchCreate)
-		accounts.GET("/data", h.Admin.Account.ExportData)
-		accounts.POST("/data", h.Admin.Account.ImportData)
-		accounts.POST("/batch-update-credentials", h.Admin.Account.BatchUpdateCredentials)
+		accounts.POST("/batch", credentials, h.Admin.Account.BatchCreate)
+		accounts.GET("/data", credentials, h.Admin.Account.ExportData)
+		accounts.POST("/data", credentials, h.Admin.Account.ImportData)
+		accounts.POST("/batch-update-credentials", credentials, h.Admin.Account.BatchUpdateCredentials)
 		accounts.POST("/batch-refresh-tier", h.Admin.Account.BatchRefreshTier)
 		accounts.POST("/bulk-update", h.Admin.Account.BulkUpdate)
 
+		// 账号健康探测
+		accounts.GET("/probe-settings", h.Admin.AccountProbe.GetSettings)
+		accounts.PUT("/probe-settings", h.Admin.AccountProbe.UpdateSettings)
+		accounts.GET("/:id/probe-results", h.Admin.AccountProbe.ListResults)
+		accounts.POST("/:id/probe", h.Admin.AccountProbe.Probe)
+
 		// Antigravity 默认模型映射
 		accounts.GET("/antigravity/default-model-mapping", h.Admin.Account.GetAntigravityDefaultModelMapping)
 
 		// Claude OAuth routes
-		accounts.POST("/generate-auth-url", h.Admin.OAuth.GenerateAuthURL)
-		accounts.POST("/generate-setup-token-url", h.Admin.OAuth.GenerateSetupTokenURL)
-		accounts.POST("/exchange-code", h.Admin.OAuth.ExchangeCode)
-		accounts.POST("/exchange-setup-token-code", h.Admin.OAuth.ExchangeSetupTokenCode)
-		accounts.POST("/cookie-auth", h.Admin.OAuth.CookieAuth)
-		accounts.POST("/setup-token-cookie-auth", h.Admin.OAuth.SetupTokenCookieAuth)
+		accounts.POST("/generate-auth-url", credentials, h.Admin.OAuth.GenerateAuthURL)
+		accounts.POST("/generate-setup-token-url", credentials, h.Admin.OAuth.GenerateSetupTokenURL)
+		accounts.POST("/exchange-code", credentials, h.Admin.OAuth.ExchangeCode)
+		accounts.POST("/exchange-setup-token-code", credentials, h.Admin.OAuth.ExchangeSetupTokenCode)
+		accounts.POST("/cookie-auth", credentials, h.Admin.OAuth.CookieAuth)
+		accounts.POST("/setup-token-cookie-auth", credentials, h.Admin.OAuth.SetupTokenCookieAuth)
 	}
 }
 
 func registerAnnouncementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	announcements := admin.Group("/announcements")
+	announcements := admin.Group("/announcements", middleware.RequireAdminAccess(service.AdminPermAnnouncementsRead, service.AdminPermAnnouncementsWrite))
 	{
 		announcements.GET("", h.Admin.Announcement.List)
 		announcements.POST("", h.Admin.Announcement.Create)
@@ -258,7 +308,7 @@ func registerAnnouncementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerOpenAIOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	openai := admin.Group("/openai")
+	openai := admin.Group("/openai", middleware.RequireAdminPermission(service.AdminPermAccountsWrite, service.AdminPermAccountsCredentials))
 	{
 		openai.POST("/generate-auth-url", h.Admin.OpenAIOAuth.GenerateAuthURL)
 		openai.POST("/exchange-code", h.Admin.OpenAIOAuth.ExchangeCode)
@@ -269,7 +319,7 @@ func registerOpenAIOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerGeminiOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	gemini := admin.Group("/gemini")
+	gemini := admin.Group("/gemini", middleware.RequireAdminPermission(service.AdminPermAccountsWrite, service.AdminPermAccountsCredentials))
 	{
 		gemini.POST("/oauth/auth-url", h.Admin.GeminiOAuth.GenerateAuthURL)
 		gemini.POST("/oauth/exchange-code", h.Admin.GeminiOAuth.ExchangeCode)
@@ -278,7 +328,7 @@ func registerGeminiOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerAntigravityOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	antigravity := admin.Group("/antigravity")
+	antigravity := admin.Group("/antigravity", middleware.RequireAdminPermission(service.AdminPermAccountsWrite, service.AdminPermAccountsCredentials))
 	{
 		antigravity.POST("/oauth/auth-url", h.Admin.AntigravityOAuth.GenerateAuthURL)
 		antigravity.POST("/oauth/exchange-code", h.Admin.AntigravityOAuth.ExchangeCode)
@@ -287,11 +337,14 @@ func registerAntigravityOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers)
 }
 
 func registerProxyRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	proxies := admin.Group("/proxies")
+	proxies := admin.Group("/proxies", middleware.RequireAdminAccess(service.AdminPermProxiesRead, service.AdminPermProxiesWrite))
 	{
 		proxies.GET("", h.Admin.Proxy.List)
 		proxies.GET("/all", h.Admin.Proxy.GetAll)
-		proxies.GET("/data", h.Admin.Proxy.ExportData)
+		proxies.GET("/data", middleware.RequireAdminPermission(service.AdminPermProxiesWrite), h.Admin.Proxy.ExportData) // 导出包含代理密码
+		proxies.GET("/monitor-settings", h.Admin.ProxyMonitor.GetSettings)
+		proxies.PUT("/monitor-settings", h.Admin.ProxyMonitor.UpdateSettings)
+		proxies.GET("/health", h.Admin.ProxyMonitor.ListStates)
 		proxies.POST("/data", h.Admin.Proxy.ImportData)
 		proxies.GET("/:id", h.Admin.Proxy.GetByID)
 		proxies.POST("", h.Admin.Proxy.Create)
@@ -300,13 +353,36 @@ func registerProxyRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		proxies.POST("/:id/test", h.Admin.Proxy.Test)
 		proxies.GET("/:id/stats", h.Admin.Proxy.GetStats)
 		proxies.GET("/:id/accounts", h.Admin.Proxy.GetProxyAccounts)
+		proxies.GET("/:id/health", h.Admin.ProxyMonitor.GetReport)
 		proxies.POST("/batch-delete", h.Admin.Proxy.BatchDelete)
 		proxies.POST("/batch", h.Admin.Proxy.BatchCreate)
+		proxies.POST("/import", h.Admin.ProxyImport.Import)
+	}
+
+	// 代理池
+	pools := admin.Group("/proxy-pools", middleware.RequireAdminAccess(service.AdminPermProxiesRead, service.AdminPermProxiesWrite))
+	{
+		pools.GET("", h.Admin.ProxyPool.List)
+		pools.GET("/:id", h.Admin.ProxyPool.GetByID)
+		pools.POST("", h.Admin.ProxyPool.Create)
+		pools.PUT("/:id", h.Admin.ProxyPool.Update)
+		pools.DELETE("/:id", h.Admin.ProxyPool.Delete)
+	}
+
+	// 代理订阅
+	subscriptions := admin.Group("/proxy-subscriptions", middleware.RequireAdminAccess(service.AdminPermProxiesRead, service.AdminPermProxiesWrite))
+	{
+		subscriptions.GET("", h.Admin.ProxyImport.ListSubscriptions)
+		subscriptions.GET("/:id", h.Admin.ProxyImport.GetSubscription)
+		subscriptions.POST("", h.Admin.ProxyImport.CreateSubscription)
+		subscriptions.PUT("/:id", h.Admin.ProxyImport.UpdateSubscription)
+		subscriptions.DELETE("/:id", h.Admin.ProxyImport.DeleteSubscription)
+		subscriptions.POST("/:id/sync", h.Admin.ProxyImport.SyncSubscription)
 	}
 }
 
 func registerRedeemCodeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	codes := admin.Group("/redeem-codes")
+	codes := admin.Group("/redeem-codes", middleware.RequireAdminAccess(service.AdminPermRedeemRead, service.AdminPermRedeemWrite))
 	{
 		codes.GET("", h.Admin.Redeem.List)
 		codes.GET("/stats", h.Admin.Redeem.GetStats)
@@ -320,7 +396,7 @@ func registerRedeemCodeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerPromoCodeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	promoCodes := admin.Group("/promo-codes")
+	promoCodes := admin.Group("/promo-codes", middleware.RequireAdminAccess(service.AdminPermPromoRead, service.AdminPermPromoWrite))
 	{
 		promoCodes.GET("", h.Admin.Promo.List)
 		promoCodes.GET("/:id", h.Admin.Promo.GetByID)
@@ -332,24 +408,52 @@ func registerPromoCodeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerSettingsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	adminSettings := admin.Group("/settings")
+	adminSettings := admin.Group("/settings", middleware.RequireAdminAccess(service.AdminPermSettingsRead, service.AdminPermSettingsWrite))
 	{
 		adminSettings.GET("", h.Admin.Setting.GetSettings)
 		adminSettings.PUT("", h.Admin.Setting.UpdateSettings)
 		adminSettings.POST("/test-smtp", h.Admin.Setting.TestSMTPConnection)
 		adminSettings.POST("/send-test-email", h.Admin.Setting.SendTestEmail)
-		// Admin API Key 管理
-		adminSettings.GET("/admin-api-key", h.Admin.Setting.GetAdminAPIKey)
-		adminSettings.POST("/admin-api-key/regenerate", h.Admin.Setting.RegenerateAdminAPIKey)
-		adminSettings.DELETE("/admin-api-key", h.Admin.Setting.DeleteAdminAPIKey)
 		// 流超时处理配置
 		adminSettings.GET("/stream-timeout", h.Admin.Setting.GetStreamTimeoutSettings)
 		adminSettings.PUT("/stream-timeout", h.Admin.Setting.UpdateStreamTimeoutSettings)
+		// 通用 OIDC / OAuth2 登录提供商
+		adminSettings.GET("/oauth-providers", h.Admin.OAuthProvider.List)
+		adminSettings.PUT("/oauth-providers/:name", h.Admin.OAuthProvider.Upsert)
+		adminSettings.DELETE("/oauth-providers/:name", h.Admin.OAuthProvider.Delete)
+		// WebAuthn 通行密钥
+		adminSettings.GET("/passkeys", h.Admin.PasskeySettings.GetSettings)
+		adminSettings.PUT("/passkeys", h.Admin.PasskeySettings.UpdateSettings)
+		// 登录失败锁定
+		adminSettings.GET("/login-security", h.Admin.LoginSecurity.GetSettings)
+		adminSettings.PUT("/login-security", h.Admin.LoginSecurity.UpdateSettings)
+		// 推荐计划奖励规则
+		adminSettings.GET("/referral", h.Admin.Referral.GetSettings)
+		adminSettings.PUT("/referral", h.Admin.Referral.UpdateSettings)
+	}
+
+	// Admin API Key 管理（可读取明文 Key，单独授权）
+	adminKeys := admin.Group("/settings/admin-api-key", middleware.RequireAdminPermission(service.AdminPermAdminKeysManage))
+	{
+		adminKeys.GET("", h.Admin.Setting.GetAdminAPIKey)
+		adminKeys.POST("/regenerate", h.Admin.Setting.RegenerateAdminAPIKey)
+		adminKeys.DELETE("", h.Admin.Setting.DeleteAdminAPIKey)
+	}
+
+	// 具名管理员 API Key（按权限范围授权）
+	scopedKeys := admin.Group("/admin-api-keys", middleware.RequireAdminPermission(service.AdminPermAdminKeysManage))
+	{
+		scopedKeys.GET("", h.Admin.AdminAPIKey.List)
+		scopedKeys.GET("/:id", h.Admin.AdminAPIKey.GetByID)
+		scopedKeys.POST("", h.Admin.AdminAPIKey.Create)
+		scopedKeys.PUT("/:id", h.Admin.AdminAPIKey.Update)
+		scopedKeys.POST("/:id/revoke", h.Admin.AdminAPIKey.Revoke)
+		scopedKeys.DELETE("/:id", h.Admin.AdminAPIKey.Delete)
 	}
 }
 
 func registerSystemRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	system := admin.Group("/system")
+	system := admin.Group("/system", middleware.RequireAdminAccess(service.AdminPermSystemRead, service.AdminPermSystemUpdate))
 	{
 		system.GET("/version", h.Admin.System.GetVersion)
 		system.GET("/check-updates", h.Admin.System.CheckUpdates)
@@ -360,7 +464,7 @@ func registerSystemRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerSubscriptionRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	subscriptions := admin.Group("/subscriptions")
+	subscriptions := admin.Group("/subscriptions", middleware.RequireAdminAccess(service.AdminPermSubscriptionsRead, service.AdminPermSubscriptionsWrite))
 	{
 		subscriptions.GET("", h.Admin.Subscription.List)
 		subscriptions.GET("/:id", h.Admin.Subscription.GetByID)
@@ -372,14 +476,14 @@ func registerSubscriptionRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	}
 
 	// 分组下的订阅列表
-	admin.GET("/groups/:id/subscriptions", h.Admin.Subscription.ListByGroup)
+	admin.GET("/groups/:id/subscriptions", middleware.RequireAdminPermission(service.AdminPermSubscriptionsRead), h.Admin.Subscription.ListByGroup)
 
 	// 用户下的订阅列表
-	admin.GET("/users/:id/subscriptions", h.Admin.Subscription.ListByUser)
+	admin.GET("/users/:id/subscriptions", middleware.RequireAdminPermission(service.AdminPermSubscriptionsRead), h.Admin.Subscription.ListByUser)
 }
 
 func registerUsageRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	usage := admin.Group("/usage")
+	usage := admin.Group("/usage", middleware.RequireAdminAccess(service.AdminPermUsageRead, service.AdminPermUsageWrite))
 	{
 		usage.GET("", h.Admin.Usage.List)
 		usage.GET("/stats", h.Admin.Usage.Stats)
@@ -388,23 +492,48 @@ func registerUsageRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		usage.GET("/cleanup-tasks", h.Admin.Usage.ListCleanupTasks)
 		usage.POST("/cleanup-tasks", h.Admin.Usage.CreateCleanupTask)
 		usage.POST("/cleanup-tasks/:id/cancel", h.Admin.Usage.CancelCleanupTask)
+		usage.GET("/exports", h.Admin.UsageExport.List)
+		usage.POST("/exports", h.Admin.UsageExport.Create)
+		usage.GET("/exports/:id", h.Admin.UsageExport.Get)
+		usage.POST("/exports/:id/cancel", h.Admin.UsageExport.Cancel)
+		usage.GET("/partitions", h.Admin.UsagePartition.Status)
+		// 表结构变更与归档回灌属于系统维护操作，额外要求 system:update
+		usage.POST("/partitions/convert", middleware.RequireAdminPermission(service.AdminPermSystemUpdate), h.Admin.UsagePartition.Convert)
+		usage.POST("/partitions/drop-legacy", middleware.RequireAdminPermission(service.AdminPermSystemUpdate), h.Admin.UsagePartition.DropLegacy)
+		usage.GET("/partitions/tasks", h.Admin.UsagePartition.ListTasks)
+		usage.GET("/partitions/archives", h.Admin.UsagePartition.ListArchives)
+		usage.POST("/partitions/archives/:id/restore", middleware.RequireAdminPermission(service.AdminPermSystemUpdate), h.Admin.UsagePartition.RestoreArchive)
+		usage.GET("/write-queue", h.Admin.UsageWrite.Stats)
+		// 死信重放会重新写入使用记录并扣费，同样要求 system:update
+		usage.POST("/write-queue/replay", middleware.RequireAdminPermission(service.AdminPermSystemUpdate), h.Admin.UsageWrite.ReplayDeadLetters)
+	}
+
+	// 月度对账单，沿用使用记录权限
+	statements := admin.Group("/statements", middleware.RequireAdminAccess(service.AdminPermUsageRead, service.AdminPermUsageWrite))
+	{
+		statements.GET("", h.Admin.Statement.List)
+		statements.GET("/:id", h.Admin.Statement.Get)
+		statements.POST("/generate", h.Admin.Statement.Generate)
+		statements.POST("/email", h.Admin.Statement.Email)
 	}
 }
 
 func registerUserAttributeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	attrs := admin.Group("/user-attributes")
+	attrs := admin.Group("/user-attributes", middleware.RequireAdminAccess(service.AdminPermUsersRead, service.AdminPermUsersWrite))
 	{
 		attrs.GET("", h.Admin.UserAttribute.ListDefinitions)
 		attrs.POST("", h.Admin.UserAttribute.CreateDefinition)
-		attrs.POST("/batch", h.Admin.UserAttribute.GetBatchUserAttributes)
 		attrs.PUT("/reorder", h.Admin.UserAttribute.ReorderDefinitions)
 		attrs.PUT("/:id", h.Admin.UserAttribute.UpdateDefinition)
 		attrs.DELETE("/:id", h.Admin.UserAttribute.DeleteDefinition)
 	}
+
+	// 批量查询为只读 POST
+	admin.POST("/user-attributes/batch", middleware.RequireAdminPermission(service.AdminPermUsersRead), h.Admin.UserAttribute.GetBatchUserAttributes)
 }
 
 func registerErrorPassthroughRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	rules := admin.Group("/error-passthrough-rules")
+	rules := admin.Group("/error-passthrough-rules", middleware.RequireAdminAccess(service.AdminPermSettingsRead, service.AdminPermSettingsWrite))
 	{
 		rules.GET("", h.Admin.ErrorPassthrough.List)
 		rules.GET("/:id", h.Admin.ErrorPassthrough.GetByID)
@@ -413,3 +542,34 @@ func registerErrorPassthroughRoutes(admin *gin.RouterGroup, h *handler.Handlers)
 		rules.DELETE("/:id", h.Admin.ErrorPassthrough.Delete)
 	}
 }
+
+func registerSchedulingRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	scheduling := admin.Group("/scheduling", middleware.RequireAdminPermission(service.AdminPermAccountsRead))
+	{
+		scheduling.POST("/explain", h.Admin.Scheduling.Explain)
+	}
+}
+
+func registerAdminRoleRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	// 当前管理员权限（任意管理员可访问）
+	admin.GET("/me/permissions", h.Admin.AdminRole.GetMyPermissions)
+
+	roles := admin.Group("/roles", middleware.RequireAdminPermission(service.AdminPermRolesManage))
+	{
+		roles.GET("", h.Admin.AdminRole.List)
+		roles.GET("/permissions", h.Admin.AdminRole.ListPermissions)
+		roles.POST("", h.Admin.AdminRole.Create)
+		roles.PUT("/:id", h.Admin.AdminRole.Update)
+		roles.DELETE("/:id", h.Admin.AdminRole.Delete)
+	}
+
+	admin.PUT("/users/:id/admin-role", middleware.RequireAdminPermission(service.AdminPermRolesManage), h.Admin.AdminRole.AssignRole)
+}
+
+func registerAdminAuditRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	auditLogs := admin.Group("/audit-logs", middleware.RequireAdminPermission(service.AdminPermAuditRead))
+	{
+		auditLogs.GET("", h.Admin.AdminAudit.List)
+		auditLogs.GET("/:id", h.Admin.AdminAudit.GetByID)
+	}
+}
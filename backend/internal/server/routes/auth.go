@@ -21,12 +21,17 @@ func RegisterAuthRoutes(
 	// 创建速率限制器
 	rateLimiter := middleware.NewRateLimiter(redisClient)
 
-	// 公开接口
+	// 公开接口（记录客户端 IP / User-Agent，用于登录会话与登录事件）
 	auth := v1.Group("/auth")
+	auth.Use(servermiddleware.ClientInfo())
 	{
 		auth.POST("/register", h.Auth.Register)
 		auth.POST("/login", h.Auth.Login)
 		auth.POST("/login/2fa", h.Auth.Login2FA)
+		auth.POST("/login/2fa/passkey/options", h.Auth.Login2FAPasskeyOptions)
+		// 通行密钥免密登录
+		auth.POST("/passkey/login/options", h.Auth.PasskeyLoginOptions)
+		auth.POST("/passkey/login", h.Auth.PasskeyLogin)
 		auth.POST("/send-verify-code", h.Auth.SendVerifyCode)
 		// Token刷新接口添加速率限制：每分钟最多 30 次（Redis 故障时 fail-close）
 		auth.POST("/refresh", rateLimiter.LimitWithOptions("refresh-token", 30, time.Minute, middleware.RateLimitOptions{
@@ -52,6 +57,9 @@ func RegisterAuthRoutes(
 		}), h.Auth.ResetPassword)
 		auth.GET("/oauth/linuxdo/start", h.Auth.LinuxDoOAuthStart)
 		auth.GET("/oauth/linuxdo/callback", h.Auth.LinuxDoOAuthCallback)
+		auth.GET("/oauth/providers", h.OAuth.ListProviders)
+		auth.GET("/oauth/providers/:provider/start", h.OAuth.Start)
+		auth.GET("/oauth/providers/:provider/callback", h.OAuth.Callback)
 	}
 
 	// 公开设置（无需认证）
@@ -13,6 +13,9 @@ func RegisterUserRoutes(
 	h *handler.Handlers,
 	jwtAuth middleware.JWTAuthMiddleware,
 ) {
+	// 导出文件下载：令牌即凭证，便于直接在浏览器或脚本中下载
+	v1.GET("/usage-exports/:token", h.UsageExport.Download)
+
 	authenticated := v1.Group("")
 	authenticated.Use(gin.HandlerFunc(jwtAuth))
 	{
@@ -33,6 +36,27 @@ func RegisterUserRoutes(
 				totp.POST("/enable", h.Totp.Enable)
 				totp.POST("/disable", h.Totp.Disable)
 			}
+
+			// WebAuthn 通行密钥
+			passkeys := user.Group("/passkeys")
+			{
+				passkeys.GET("", h.Passkey.GetStatus)
+				passkeys.POST("/register/options", h.Passkey.RegisterOptions)
+				passkeys.POST("/register", h.Passkey.Register)
+				passkeys.PUT("/:id", h.Passkey.Rename)
+				passkeys.DELETE("/:id", h.Passkey.Delete)
+				passkeys.POST("/recovery-codes", h.Passkey.RegenerateRecoveryCodes)
+			}
+
+			// 登录会话与登录记录
+			user.GET("/sessions", h.Auth.ListSessions)
+			user.DELETE("/sessions/:id", h.Auth.RevokeSession)
+			user.GET("/login-history", h.Auth.ListLoginHistory)
+
+			// 用量提醒（余额 / 额度 / 到期）
+			user.GET("/notifications", h.Notification.List)
+			user.GET("/notifications/settings", h.Notification.GetSettings)
+			user.PUT("/notifications/settings", h.Notification.UpdateSettings)
 		}
 
 		// API Key管理
@@ -42,6 +66,7 @@ func RegisterUserRoutes(
 			keys.GET("/:id", h.APIKey.GetByID)
 			keys.POST("", h.APIKey.Create)
 			keys.PUT("/:id", h.APIKey.Update)
+			keys.POST("/:id/rotate", h.APIKey.Rotate)
 			keys.DELETE("/:id", h.APIKey.Delete)
 		}
 
@@ -62,7 +87,13 @@ func RegisterUserRoutes(
 			usage.GET("/dashboard/stats", h.Usage.DashboardStats)
 			usage.GET("/dashboard/trend", h.Usage.DashboardTrend)
 			usage.GET("/dashboard/models", h.Usage.DashboardModels)
+			usage.GET("/dashboard/tags", h.Usage.DashboardTags)
 			usage.POST("/dashboard/api-keys-usage", h.Usage.DashboardAPIKeysUsage)
+			// 异步导出
+			usage.GET("/exports", h.UsageExport.List)
+			usage.POST("/exports", h.UsageExport.Create)
+			usage.GET("/exports/:id", h.UsageExport.Get)
+			usage.POST("/exports/:id/cancel", h.UsageExport.Cancel)
 		}
 
 		// 公告（用户可见）
@@ -87,5 +118,37 @@ func RegisterUserRoutes(
 			subscriptions.GET("/progress", h.Subscription.GetProgress)
 			subscriptions.GET("/summary", h.Subscription.GetSummary)
 		}
+
+		// 组织（团队）
+		organizations := authenticated.Group("/organizations")
+		{
+			organizations.GET("", h.Organization.List)
+			organizations.POST("", h.Organization.Create)
+			organizations.GET("/:id", h.Organization.Get)
+			organizations.PUT("/:id", h.Organization.Rename)
+			organizations.GET("/:id/members", h.Organization.ListMembers)
+			organizations.POST("/:id/members", h.Organization.AddMember)
+			organizations.PUT("/:id/members/:user_id", h.Organization.UpdateMember)
+			organizations.DELETE("/:id/members/:user_id", h.Organization.RemoveMember)
+			organizations.POST("/:id/deposit", h.Organization.Deposit)
+			organizations.GET("/:id/usage", h.Organization.Usage)
+			organizations.GET("/:id/statements", h.Statement.ListOrganization)
+			organizations.GET("/:id/statements/:period", h.Statement.GetOrganization)
+		}
+
+		// 推荐计划（推荐人面板）
+		referral := authenticated.Group("/referral")
+		{
+			referral.GET("", h.Referral.Dashboard)
+			referral.GET("/referees", h.Referral.ListReferees)
+			referral.GET("/commissions", h.Referral.ListCommissions)
+		}
+
+		// 月度对账单
+		statements := authenticated.Group("/statements")
+		{
+			statements.GET("", h.Statement.List)
+			statements.GET("/:period", h.Statement.Get)
+		}
 	}
 }
@@ -20,6 +20,8 @@ type Account struct {
 	Credentials map[string]any
 	Extra       map[string]any
 	ProxyID     *int64
+	// ProxyPoolID 代理池 ID，设置后由代理池选择代理（优先于 ProxyID）
+	ProxyPoolID *int64
 	Concurrency int
 	Priority    int
 	// RateMultiplier 账号计费倍率（>=0，允许 0 表示该账号计费为 0）。
@@ -779,6 +781,20 @@ func (a *Account) GetWindowCostStickyReserve() float64 {
 	return 10.0
 }
 
+// GetSelectionWeight 获取加权调度（weighted 策略）使用的账号权重
+// 未配置或配置为非正数时返回默认值 1
+func (a *Account) GetSelectionWeight() int {
+	if a.Extra == nil {
+		return 1
+	}
+	if v, ok := a.Extra["selection_weight"]; ok {
+		if weight := parseExtraInt(v); weight > 0 {
+			return weight
+		}
+	}
+	return 1
+}
+
 // GetMaxSessions 获取最大并发会话数
 // 返回 0 表示未启用
 func (a *Account) GetMaxSessions() int {
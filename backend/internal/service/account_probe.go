@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// AccountProbeAction 探测连续失败后的处理方式常量
+const (
+	AccountProbeActionTempUnsched = "temp_unsched" // 临时不可调度
+	AccountProbeActionError       = "error"        // 标记为错误状态
+	AccountProbeActionNone        = "none"         // 仅记录，不处理
+)
+
+// accountProbeMatchedKeyword 写入 TempUnschedState.MatchedKeyword，用于识别由探测器触发的临时不可调度。
+const accountProbeMatchedKeyword = "health_probe"
+
+// AccountProbeResult 单次探测结果
+type AccountProbeResult struct {
+	ID           int64     `json:"id"`
+	AccountID    int64     `json:"account_id"`
+	Platform     string    `json:"platform"`
+	Model        string    `json:"model"`
+	Success      bool      `json:"success"`
+	LatencyMs    int64     `json:"latency_ms"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AccountProbeState 账号探测状态（连续计数 + 探测器执行过的自动处置）
+type AccountProbeState struct {
+	AccountID            int64      `json:"account_id"`
+	ConsecutiveFailures  int        `json:"consecutive_failures"`
+	ConsecutiveSuccesses int        `json:"consecutive_successes"`
+	LastProbeAt          *time.Time `json:"last_probe_at,omitempty"`
+	LastSuccess          bool       `json:"last_success"`
+	LastLatencyMs        int64      `json:"last_latency_ms"`
+	LastError            string     `json:"last_error,omitempty"`
+	// AutoAction 探测器自动执行的处置（temp_unsched / error），空表示账号状态未被探测器修改。
+	// 自动恢复只会撤销这里记录的处置，不会误清除人工或其他规则设置的状态。
+	AutoAction   string     `json:"auto_action,omitempty"`
+	AutoActionAt *time.Time `json:"auto_action_at,omitempty"`
+}
+
+// AccountProbeRepository 账号探测结果/状态存储
+type AccountProbeRepository interface {
+	InsertResult(ctx context.Context, result *AccountProbeResult) error
+	ListResults(ctx context.Context, accountID int64, limit int) ([]AccountProbeResult, error)
+	// GetState 返回账号探测状态；不存在时返回 (nil, nil)。
+	GetState(ctx context.Context, accountID int64) (*AccountProbeState, error)
+	ListStates(ctx context.Context) ([]AccountProbeState, error)
+	UpsertState(ctx context.Context, state *AccountProbeState) error
+	DeleteResultsBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// AccountProbeSchedule 探测计划规则。
+// GroupID > 0 时按分组匹配，否则按 Platform 匹配；分组规则优先于平台规则。
+type AccountProbeSchedule struct {
+	Platform string `json:"platform,omitempty"`
+	GroupID  int64  `json:"group_id,omitempty"`
+	Enabled  bool   `json:"enabled"`
+	// IntervalSeconds 为 0 时使用全局间隔
+	IntervalSeconds int `json:"interval_seconds,omitempty"`
+	// Model 为空时使用 Models[platform]，再为空则使用测试流程的默认模型
+	Model string `json:"model,omitempty"`
+}
+
+// AccountProbeSettings 账号健康探测配置（DB 存储）
+type AccountProbeSettings struct {
+	Enabled bool `json:"enabled"`
+	// IntervalSeconds 默认探测间隔（秒）
+	IntervalSeconds int `json:"interval_seconds"`
+	// TimeoutSeconds 单次探测超时（秒）
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// Concurrency 同时进行的探测数量
+	Concurrency int `json:"concurrency"`
+	// FailureThreshold 连续失败多少次后执行 FailureAction
+	FailureThreshold int `json:"failure_threshold"`
+	// RecoveryThreshold 连续成功多少次后自动撤销探测器设置的状态
+	RecoveryThreshold int `json:"recovery_threshold"`
+	// FailureAction "temp_unsched" | "error" | "none"
+	FailureAction string `json:"failure_action"`
+	// TempUnschedMinutes 临时不可调度持续时间（分钟）
+	TempUnschedMinutes int `json:"temp_unsched_minutes"`
+	// Models 各平台使用的探测模型（platform -> model），建议配置为廉价模型
+	Models map[string]string `json:"models"`
+	// Schedules 按平台/分组覆盖的探测计划
+	Schedules []AccountProbeSchedule `json:"schedules"`
+	// HistoryRetentionDays 探测历史保留天数
+	HistoryRetentionDays int `json:"history_retention_days"`
+}
+
+// DefaultAccountProbeSettings 返回默认的账号探测配置
+func DefaultAccountProbeSettings() *AccountProbeSettings {
+	return &AccountProbeSettings{
+		Enabled:              false,
+		IntervalSeconds:      600,
+		TimeoutSeconds:       30,
+		Concurrency:          4,
+		FailureThreshold:     3,
+		RecoveryThreshold:    2,
+		FailureAction:        AccountProbeActionTempUnsched,
+		TempUnschedMinutes:   10,
+		Models:               map[string]string{},
+		Schedules:            []AccountProbeSchedule{},
+		HistoryRetentionDays: 7,
+	}
+}
+
+// resolveSchedule 返回账号适用的探测间隔与模型；enabled=false 表示该账号不参与探测。
+func (s *AccountProbeSettings) resolveSchedule(account *Account) (interval time.Duration, model string, enabled bool) {
+	interval = time.Duration(s.IntervalSeconds) * time.Second
+	model = s.Models[account.Platform]
+	enabled = true
+
+	var matched *AccountProbeSchedule
+	for i := range s.Schedules {
+		rule := &s.Schedules[i]
+		if rule.GroupID <= 0 {
+			continue
+		}
+		for _, gid := range account.GroupIDs {
+			if gid == rule.GroupID {
+				matched = rule
+				break
+			}
+		}
+		if matched != nil {
+			break
+		}
+	}
+	if matched == nil {
+		for i := range s.Schedules {
+			rule := &s.Schedules[i]
+			if rule.GroupID <= 0 && rule.Platform == account.Platform {
+				matched = rule
+				break
+			}
+		}
+	}
+	if matched == nil {
+		return interval, model, enabled
+	}
+
+	if !matched.Enabled {
+		return 0, "", false
+	}
+	if matched.IntervalSeconds > 0 {
+		interval = time.Duration(matched.IntervalSeconds) * time.Second
+	}
+	if matched.Model != "" {
+		model = matched.Model
+	}
+	return interval, model, true
+}
@@ -0,0 +1,766 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	accountProbeJobName = "account_probe"
+
+	accountProbeTickInterval     = 30 * time.Second
+	accountProbeLeaderLockKey    = "account:probe:leader"
+	accountProbeLeaderLockTTL    = 10 * time.Minute
+	accountProbeRunTimeout       = 9 * time.Minute
+	accountProbeCaptureBytes     = 16 * 1024
+	accountProbeErrorMaxLen      = 1024
+	accountProbeResultsMaxLimit  = 500
+	accountProbePruneMinInterval = time.Hour
+)
+
+var accountProbeReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+var ErrAccountProbeSettingsInvalid = infraerrors.BadRequest("ACCOUNT_PROBE_SETTINGS_INVALID", "invalid account probe settings")
+
+// accountConnectionTester 抽象账号连通性测试（由 AccountTestService 实现），便于测试替换。
+type accountConnectionTester interface {
+	RunAccountTest(ctx context.Context, w AccountTestOutput, accountID int64, modelID string) error
+}
+
+// AccountProbeService 定时对上游账号执行合成健康探测。
+//
+// - 探测复用账号测试流程（AccountTestService），使用廉价模型与 "hi" 提示词。
+// - 连续失败达到阈值后自动将账号标记为临时不可调度或错误状态；连续成功后自动撤销。
+// - 多实例：Redis leader lock（失败时回退到 DB advisory lock），保证同一时刻仅一个实例探测。
+type AccountProbeService struct {
+	probeRepo        AccountProbeRepository
+	accountRepo      AccountRepository
+	settingRepo      SettingRepository
+	tester           accountConnectionTester
+	tempUnschedCache TempUnschedCache
+	opsRepo          OpsRepository
+	db               *sql.DB
+	redisClient      *redis.Client
+	cfg              *config.Config
+
+	instanceID string
+
+	stopCh    chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+
+	warnNoRedisOnce sync.Once
+	skipLogMu       sync.Mutex
+	skipLogAt       time.Time
+	lastPruneAt     time.Time
+}
+
+// NewAccountProbeService creates a new AccountProbeService.
+func NewAccountProbeService(
+	probeRepo AccountProbeRepository,
+	accountRepo AccountRepository,
+	settingRepo SettingRepository,
+	accountTestService *AccountTestService,
+	tempUnschedCache TempUnschedCache,
+	opsRepo OpsRepository,
+	db *sql.DB,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *AccountProbeService {
+	svc := &AccountProbeService{
+		probeRepo:        probeRepo,
+		accountRepo:      accountRepo,
+		settingRepo:      settingRepo,
+		tempUnschedCache: tempUnschedCache,
+		opsRepo:          opsRepo,
+		db:               db,
+		redisClient:      redisClient,
+		cfg:              cfg,
+		instanceID:       uuid.NewString(),
+		stopCh:           make(chan struct{}),
+	}
+	if accountTestService != nil {
+		svc.tester = accountTestService
+	}
+	return svc
+}
+
+func (s *AccountProbeService) Start() {
+	if s == nil || s.probeRepo == nil || s.accountRepo == nil || s.tester == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ticker := time.NewTicker(accountProbeTickInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					s.runScheduled()
+				case <-s.stopCh:
+					return
+				}
+			}
+		}()
+	})
+}
+
+func (s *AccountProbeService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+// GetSettings 获取账号探测配置
+func (s *AccountProbeService) GetSettings(ctx context.Context) (*AccountProbeSettings, error) {
+	if s.settingRepo == nil {
+		return DefaultAccountProbeSettings(), nil
+	}
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyAccountProbeSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultAccountProbeSettings(), nil
+		}
+		return nil, fmt.Errorf("get account probe settings: %w", err)
+	}
+	if value == "" {
+		return DefaultAccountProbeSettings(), nil
+	}
+
+	settings := DefaultAccountProbeSettings()
+	if err := json.Unmarshal([]byte(value), settings); err != nil {
+		return DefaultAccountProbeSettings(), nil
+	}
+	normalizeAccountProbeSettings(settings)
+	return settings, nil
+}
+
+// UpdateSettings 更新账号探测配置
+func (s *AccountProbeService) UpdateSettings(ctx context.Context, settings *AccountProbeSettings) (*AccountProbeSettings, error) {
+	if settings == nil {
+		return nil, ErrAccountProbeSettingsInvalid
+	}
+	if err := validateAccountProbeSettings(settings); err != nil {
+		return nil, err
+	}
+	if settings.Models == nil {
+		settings.Models = map[string]string{}
+	}
+	if settings.Schedules == nil {
+		settings.Schedules = []AccountProbeSchedule{}
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("marshal account probe settings: %w", err)
+	}
+	if err := s.settingRepo.Set(ctx, SettingKeyAccountProbeSettings, string(data)); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(ctx)
+}
+
+func validateAccountProbeSettings(settings *AccountProbeSettings) error {
+	invalid := func(msg string) error {
+		return infraerrors.BadRequest("ACCOUNT_PROBE_SETTINGS_INVALID", msg)
+	}
+	if settings.IntervalSeconds < 60 || settings.IntervalSeconds > 86400 {
+		return invalid("interval_seconds must be between 60-86400")
+	}
+	if settings.TimeoutSeconds < 5 || settings.TimeoutSeconds > 120 {
+		return invalid("timeout_seconds must be between 5-120")
+	}
+	if settings.Concurrency < 1 || settings.Concurrency > 32 {
+		return invalid("concurrency must be between 1-32")
+	}
+	if settings.FailureThreshold < 1 || settings.FailureThreshold > 20 {
+		return invalid("failure_threshold must be between 1-20")
+	}
+	if settings.RecoveryThreshold < 1 || settings.RecoveryThreshold > 20 {
+		return invalid("recovery_threshold must be between 1-20")
+	}
+	if settings.TempUnschedMinutes < 1 || settings.TempUnschedMinutes > 1440 {
+		return invalid("temp_unsched_minutes must be between 1-1440")
+	}
+	if settings.HistoryRetentionDays < 1 || settings.HistoryRetentionDays > 90 {
+		return invalid("history_retention_days must be between 1-90")
+	}
+	switch settings.FailureAction {
+	case AccountProbeActionTempUnsched, AccountProbeActionError, AccountProbeActionNone:
+	default:
+		return invalid("invalid failure_action: " + settings.FailureAction)
+	}
+	for i, rule := range settings.Schedules {
+		if rule.GroupID <= 0 && strings.TrimSpace(rule.Platform) == "" {
+			return invalid(fmt.Sprintf("schedules[%d]: platform or group_id is required", i))
+		}
+		if rule.IntervalSeconds != 0 && (rule.IntervalSeconds < 60 || rule.IntervalSeconds > 86400) {
+			return invalid(fmt.Sprintf("schedules[%d]: interval_seconds must be 0 or between 60-86400", i))
+		}
+	}
+	return nil
+}
+
+func normalizeAccountProbeSettings(settings *AccountProbeSettings) {
+	defaults := DefaultAccountProbeSettings()
+	if settings.IntervalSeconds < 60 {
+		settings.IntervalSeconds = defaults.IntervalSeconds
+	}
+	if settings.TimeoutSeconds < 5 || settings.TimeoutSeconds > 120 {
+		settings.TimeoutSeconds = defaults.TimeoutSeconds
+	}
+	if settings.Concurrency < 1 || settings.Concurrency > 32 {
+		settings.Concurrency = defaults.Concurrency
+	}
+	if settings.FailureThreshold < 1 {
+		settings.FailureThreshold = defaults.FailureThreshold
+	}
+	if settings.RecoveryThreshold < 1 {
+		settings.RecoveryThreshold = defaults.RecoveryThreshold
+	}
+	if settings.TempUnschedMinutes < 1 {
+		settings.TempUnschedMinutes = defaults.TempUnschedMinutes
+	}
+	if settings.HistoryRetentionDays < 1 {
+		settings.HistoryRetentionDays = defaults.HistoryRetentionDays
+	}
+	switch settings.FailureAction {
+	case AccountProbeActionTempUnsched, AccountProbeActionError, AccountProbeActionNone:
+	default:
+		settings.FailureAction = defaults.FailureAction
+	}
+	if settings.Models == nil {
+		settings.Models = map[string]string{}
+	}
+	if settings.Schedules == nil {
+		settings.Schedules = []AccountProbeSchedule{}
+	}
+}
+
+// ListResults 查询账号最近的探测历史
+func (s *AccountProbeService) ListResults(ctx context.Context, accountID int64, limit int) ([]AccountProbeResult, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > accountProbeResultsMaxLimit {
+		limit = accountProbeResultsMaxLimit
+	}
+	return s.probeRepo.ListResults(ctx, accountID, limit)
+}
+
+// GetState 查询账号当前探测状态（可能为 nil）
+func (s *AccountProbeService) GetState(ctx context.Context, accountID int64) (*AccountProbeState, error) {
+	return s.probeRepo.GetState(ctx, accountID)
+}
+
+// ProbeNow 立即探测指定账号并记录结果。
+// 自动处置（阈值判断）仅在探测功能启用时生效。
+func (s *AccountProbeService) ProbeNow(ctx context.Context, accountID int64, model string) (*AccountProbeResult, error) {
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(model) == "" {
+		_, model, _ = settings.resolveSchedule(account)
+	}
+
+	state, err := s.probeRepo.GetState(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, time.Duration(settings.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	result := s.probeAccount(probeCtx, account, model)
+	s.recordResult(ctx, account, state, result, settings)
+	return result, nil
+}
+
+func (s *AccountProbeService) runScheduled() {
+	ctx, cancel := context.WithTimeout(context.Background(), accountProbeRunTimeout)
+	defer cancel()
+
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		log.Printf("[AccountProbe] load settings failed: %v", err)
+		return
+	}
+	if !settings.Enabled {
+		return
+	}
+
+	release, ok := s.tryAcquireLeaderLock(ctx)
+	if !ok {
+		return
+	}
+	if release != nil {
+		defer release()
+	}
+
+	startedAt := time.Now().UTC()
+	probed, failed, err := s.runOnce(ctx, settings, startedAt)
+	if err != nil {
+		s.recordHeartbeatError(startedAt, time.Since(startedAt), err)
+		log.Printf("[AccountProbe] run failed: %v", err)
+		return
+	}
+	if probed > 0 {
+		s.recordHeartbeatSuccess(startedAt, time.Since(startedAt), fmt.Sprintf("probed=%d failed=%d", probed, failed))
+	}
+
+	if time.Since(s.lastPruneAt) >= accountProbePruneMinInterval {
+		s.lastPruneAt = time.Now()
+		cutoff := time.Now().AddDate(0, 0, -settings.HistoryRetentionDays)
+		if n, err := s.probeRepo.DeleteResultsBefore(ctx, cutoff); err != nil {
+			log.Printf("[AccountProbe] prune history failed: %v", err)
+		} else if n > 0 {
+			log.Printf("[AccountProbe] pruned %d probe results older than %s", n, cutoff.Format(time.RFC3339))
+		}
+	}
+}
+
+type accountProbeTask struct {
+	account *Account
+	state   *AccountProbeState
+	model   string
+}
+
+// runOnce 执行一轮到期账号的探测，返回探测数与失败数。
+func (s *AccountProbeService) runOnce(ctx context.Context, settings *AccountProbeSettings, now time.Time) (int, int, error) {
+	states, err := s.probeRepo.ListStates(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("list probe states: %w", err)
+	}
+	stateByID := make(map[int64]*AccountProbeState, len(states))
+	var autoErrorIDs []int64
+	for i := range states {
+		st := &states[i]
+		stateByID[st.AccountID] = st
+		if st.AutoAction == AccountProbeActionError {
+			autoErrorIDs = append(autoErrorIDs, st.AccountID)
+		}
+	}
+
+	active, err := s.accountRepo.ListActive(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("list active accounts: %w", err)
+	}
+	candidates := make([]*Account, 0, len(active)+len(autoErrorIDs))
+	for i := range active {
+		candidates = append(candidates, &active[i])
+	}
+	// 被探测器标记为 error 的账号不在 ListActive 中，需要单独加载以便自动恢复。
+	if len(autoErrorIDs) > 0 {
+		marked, err := s.accountRepo.GetByIDs(ctx, autoErrorIDs)
+		if err != nil {
+			return 0, 0, fmt.Errorf("load probe-marked accounts: %w", err)
+		}
+		for _, acc := range marked {
+			if acc != nil && acc.Status == StatusError {
+				candidates = append(candidates, acc)
+			}
+		}
+	}
+
+	tasks := make([]accountProbeTask, 0)
+	for _, acc := range candidates {
+		state := stateByID[acc.ID]
+		interval, model, enabled := settings.resolveSchedule(acc)
+		if !enabled || !shouldProbeAccount(acc, state, interval, now) {
+			continue
+		}
+		tasks = append(tasks, accountProbeTask{account: acc, state: state, model: model})
+	}
+	if len(tasks) == 0 {
+		return 0, 0, nil
+	}
+
+	var (
+		mu     sync.Mutex
+		failed int
+		wg     sync.WaitGroup
+	)
+	sem := make(chan struct{}, settings.Concurrency)
+	for _, task := range tasks {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return len(tasks), failed, ctx.Err()
+		case <-s.stopCh:
+			wg.Wait()
+			return len(tasks), failed, nil
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(task accountProbeTask) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			probeCtx, cancel := context.WithTimeout(ctx, time.Duration(settings.TimeoutSeconds)*time.Second)
+			result := s.probeAccount(probeCtx, task.account, task.model)
+			cancel()
+
+			s.recordResult(ctx, task.account, task.state, result, settings)
+			if !result.Success {
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}(task)
+	}
+	wg.Wait()
+	return len(tasks), failed, nil
+}
+
+// shouldProbeAccount 判断账号本轮是否需要探测。
+// 人工暂停调度、限流/过载中、或被其他规则临时不可调度的账号不参与探测，避免产生无意义的失败计数。
+func shouldProbeAccount(account *Account, state *AccountProbeState, interval time.Duration, now time.Time) bool {
+	if account == nil || interval <= 0 {
+		return false
+	}
+	probeMarked := state != nil && state.AutoAction != ""
+	if !probeMarked {
+		if account.Status != StatusActive || !account.Schedulable {
+			return false
+		}
+		if account.IsRateLimited() || account.IsOverloaded() {
+			return false
+		}
+		if account.TempUnschedulableUntil != nil && now.Before(*account.TempUnschedulableUntil) {
+			return false
+		}
+	}
+	if state == nil || state.LastProbeAt == nil {
+		return true
+	}
+	return now.Sub(*state.LastProbeAt) >= interval
+}
+
+// probeAccount 执行账号测试流程（事件输出到内存缓冲），返回探测结果。
+func (s *AccountProbeService) probeAccount(ctx context.Context, account *Account, model string) *AccountProbeResult {
+	w := newLimitedResponseWriter(accountProbeCaptureBytes)
+
+	start := time.Now()
+	err := s.tester.RunAccountTest(ctx, w, account.ID, model)
+	latency := time.Since(start)
+
+	result := &AccountProbeResult{
+		AccountID: account.ID,
+		Platform:  account.Platform,
+		Model:     model,
+		Success:   err == nil,
+		LatencyMs: latency.Milliseconds(),
+		CreatedAt: time.Now(),
+	}
+	if effective := extractProbeModel(w.bodyBytes()); effective != "" {
+		result.Model = effective
+	}
+	if err != nil {
+		result.ErrorMessage = truncateString(err.Error(), accountProbeErrorMaxLen)
+	}
+	return result
+}
+
+// extractProbeModel 从测试流程输出的 SSE 中解析实际使用的模型（test_start 事件）。
+func extractProbeModel(body []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !sseDataPrefix.MatchString(line) {
+			continue
+		}
+		var event TestEvent
+		if err := json.Unmarshal([]byte(sseDataPrefix.ReplaceAllString(line, "")), &event); err != nil {
+			continue
+		}
+		if event.Type == "test_start" {
+			return event.Model
+		}
+	}
+	return ""
+}
+
+// recordResult 持久化探测结果并根据阈值执行自动处置/恢复。
+func (s *AccountProbeService) recordResult(ctx context.Context, account *Account, state *AccountProbeState, result *AccountProbeResult, settings *AccountProbeSettings) {
+	if err := s.probeRepo.InsertResult(ctx, result); err != nil {
+		log.Printf("[AccountProbe] insert result failed: account=%d err=%v", account.ID, err)
+	}
+
+	next := s.applyResult(ctx, account, state, result, settings)
+	if err := s.probeRepo.UpsertState(ctx, next); err != nil {
+		log.Printf("[AccountProbe] upsert state failed: account=%d err=%v", account.ID, err)
+	}
+}
+
+// applyResult 计算新的探测状态，并在达到阈值时修改账号状态。
+func (s *AccountProbeService) applyResult(ctx context.Context, account *Account, prev *AccountProbeState, result *AccountProbeResult, settings *AccountProbeSettings) *AccountProbeState {
+	next := &AccountProbeState{AccountID: account.ID}
+	if prev != nil {
+		*next = *prev
+	}
+	probedAt := result.CreatedAt
+	next.LastProbeAt = &probedAt
+	next.LastSuccess = result.Success
+	next.LastLatencyMs = result.LatencyMs
+	next.LastError = result.ErrorMessage
+
+	if result.Success {
+		next.ConsecutiveSuccesses++
+		next.ConsecutiveFailures = 0
+	} else {
+		next.ConsecutiveFailures++
+		next.ConsecutiveSuccesses = 0
+	}
+
+	if !settings.Enabled {
+		return next
+	}
+
+	if result.Success {
+		if next.AutoAction != "" && next.ConsecutiveSuccesses >= settings.RecoveryThreshold {
+			if s.revertAutoAction(ctx, account, next.AutoAction) {
+				next.AutoAction = ""
+				next.AutoActionAt = nil
+			}
+		}
+		return next
+	}
+
+	if next.ConsecutiveFailures < settings.FailureThreshold || settings.FailureAction == AccountProbeActionNone {
+		return next
+	}
+	if !needsProbeAction(account, settings.FailureAction, time.Now()) {
+		return next
+	}
+	if s.applyFailureAction(ctx, account, settings, result) {
+		now := time.Now()
+		next.AutoAction = settings.FailureAction
+		next.AutoActionAt = &now
+	}
+	return next
+}
+
+// needsProbeAction 判断是否需要（重新）执行失败处置。
+// 临时不可调度到期后若探测仍失败，会再次设置；已是错误状态的账号不重复设置。
+func needsProbeAction(account *Account, action string, now time.Time) bool {
+	switch action {
+	case AccountProbeActionError:
+		return account.Status != StatusError
+	case AccountProbeActionTempUnsched:
+		if account.Status == StatusError {
+			return false
+		}
+		return account.TempUnschedulableUntil == nil || !now.Before(*account.TempUnschedulableUntil)
+	default:
+		return false
+	}
+}
+
+func (s *AccountProbeService) applyFailureAction(ctx context.Context, account *Account, settings *AccountProbeSettings, result *AccountProbeResult) bool {
+	errorMsg := "Health probe failed: " + result.ErrorMessage
+
+	switch settings.FailureAction {
+	case AccountProbeActionTempUnsched:
+		now := time.Now()
+		until := now.Add(time.Duration(settings.TempUnschedMinutes) * time.Minute)
+		state := &TempUnschedState{
+			UntilUnix:       until.Unix(),
+			TriggeredAtUnix: now.Unix(),
+			StatusCode:      0,
+			MatchedKeyword:  accountProbeMatchedKeyword,
+			RuleIndex:       -1, // 表示系统级规则
+			ErrorMessage:    errorMsg,
+		}
+		reason := ""
+		if raw, err := json.Marshal(state); err == nil {
+			reason = string(raw)
+		}
+		if reason == "" {
+			reason = state.ErrorMessage
+		}
+		if err := s.accountRepo.SetTempUnschedulable(ctx, account.ID, until, reason); err != nil {
+			log.Printf("[AccountProbe] set temp unschedulable failed: account=%d err=%v", account.ID, err)
+			return false
+		}
+		if s.tempUnschedCache != nil {
+			if err := s.tempUnschedCache.SetTempUnsched(ctx, account.ID, state); err != nil {
+				log.Printf("[AccountProbe] set temp unsched cache failed: account=%d err=%v", account.ID, err)
+			}
+		}
+		account.TempUnschedulableUntil = &until
+		account.TempUnschedulableReason = reason
+		log.Printf("[AccountProbe] account %d temp unschedulable until %s after consecutive probe failures", account.ID, until.Format(time.RFC3339))
+		return true
+	case AccountProbeActionError:
+		if err := s.accountRepo.SetError(ctx, account.ID, errorMsg); err != nil {
+			log.Printf("[AccountProbe] set error failed: account=%d err=%v", account.ID, err)
+			return false
+		}
+		account.Status = StatusError
+		account.ErrorMessage = errorMsg
+		log.Printf("[AccountProbe] account %d marked as error after consecutive probe failures", account.ID)
+		return true
+	default:
+		return false
+	}
+}
+
+// revertAutoAction 撤销探测器设置的状态。若账号状态已被人工或其他规则修改，则只清除记录不做变更。
+func (s *AccountProbeService) revertAutoAction(ctx context.Context, account *Account, action string) bool {
+	switch action {
+	case AccountProbeActionError:
+		if account.Status != StatusError || !strings.HasPrefix(account.ErrorMessage, "Health probe failed") {
+			return true
+		}
+		if err := s.accountRepo.ClearError(ctx, account.ID); err != nil {
+			log.Printf("[AccountProbe] clear error failed: account=%d err=%v", account.ID, err)
+			return false
+		}
+		account.Status = StatusActive
+		account.ErrorMessage = ""
+		log.Printf("[AccountProbe] account %d recovered from error after consecutive probe successes", account.ID)
+		return true
+	case AccountProbeActionTempUnsched:
+		if !isProbeTempUnsched(account) {
+			return true
+		}
+		if err := s.accountRepo.ClearTempUnschedulable(ctx, account.ID); err != nil {
+			log.Printf("[AccountProbe] clear temp unschedulable failed: account=%d err=%v", account.ID, err)
+			return false
+		}
+		if s.tempUnschedCache != nil {
+			if err := s.tempUnschedCache.DeleteTempUnsched(ctx, account.ID); err != nil {
+				log.Printf("[AccountProbe] delete temp unsched cache failed: account=%d err=%v", account.ID, err)
+			}
+		}
+		account.TempUnschedulableUntil = nil
+		account.TempUnschedulableReason = ""
+		log.Printf("[AccountProbe] account %d recovered from temp unschedulable after consecutive probe successes", account.ID)
+		return true
+	default:
+		return true
+	}
+}
+
+func isProbeTempUnsched(account *Account) bool {
+	if account.TempUnschedulableUntil == nil || !time.Now().Before(*account.TempUnschedulableUntil) {
+		return false
+	}
+	var state TempUnschedState
+	if err := json.Unmarshal([]byte(account.TempUnschedulableReason), &state); err != nil {
+		return false
+	}
+	return state.MatchedKeyword == accountProbeMatchedKeyword
+}
+
+func (s *AccountProbeService) tryAcquireLeaderLock(ctx context.Context) (func(), bool) {
+	// In simple run mode, assume single instance.
+	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
+		return nil, true
+	}
+
+	key := accountProbeLeaderLockKey
+	if s.redisClient != nil {
+		ok, err := s.redisClient.SetNX(ctx, key, s.instanceID, accountProbeLeaderLockTTL).Result()
+		if err == nil {
+			if !ok {
+				s.maybeLogSkip()
+				return nil, false
+			}
+			return func() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				defer cancel()
+				_, _ = accountProbeReleaseScript.Run(releaseCtx, s.redisClient, []string{key}, s.instanceID).Result()
+			}, true
+		}
+		// Redis error: fall back to DB advisory lock.
+		s.warnNoRedisOnce.Do(func() {
+			log.Printf("[AccountProbe] leader lock SetNX failed; falling back to DB advisory lock: %v", err)
+		})
+	}
+
+	release, ok := tryAcquireDBAdvisoryLock(ctx, s.db, hashAdvisoryLockID(key))
+	if !ok {
+		s.maybeLogSkip()
+		return nil, false
+	}
+	return release, true
+}
+
+func (s *AccountProbeService) maybeLogSkip() {
+	s.skipLogMu.Lock()
+	defer s.skipLogMu.Unlock()
+
+	now := time.Now()
+	if !s.skipLogAt.IsZero() && now.Sub(s.skipLogAt) < time.Minute {
+		return
+	}
+	s.skipLogAt = now
+	log.Printf("[AccountProbe] leader lock held by another instance; skipping")
+}
+
+func (s *AccountProbeService) recordHeartbeatSuccess(runAt time.Time, duration time.Duration, result string) {
+	if s.opsRepo == nil {
+		return
+	}
+	now := time.Now().UTC()
+	durMs := duration.Milliseconds()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.opsRepo.UpsertJobHeartbeat(ctx, &OpsUpsertJobHeartbeatInput{
+		JobName:        accountProbeJobName,
+		LastRunAt:      &runAt,
+		LastSuccessAt:  &now,
+		LastDurationMs: &durMs,
+		LastResult:     &result,
+	})
+}
+
+func (s *AccountProbeService) recordHeartbeatError(runAt time.Time, duration time.Duration, err error) {
+	if s.opsRepo == nil || err == nil {
+		return
+	}
+	now := time.Now().UTC()
+	durMs := duration.Milliseconds()
+	msg := truncateString(err.Error(), 2048)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.opsRepo.UpsertJobHeartbeat(ctx, &OpsUpsertJobHeartbeatInput{
+		JobName:        accountProbeJobName,
+		LastRunAt:      &runAt,
+		LastErrorAt:    &now,
+		LastError:      &msg,
+		LastDurationMs: &durMs,
+	})
+}
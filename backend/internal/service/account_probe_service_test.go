@@ -0,0 +1,257 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type probeAccountRepoStub struct {
+	accountRepoStub
+	tempUnschedCalls int
+	clearTempCalls   int
+	setErrorCalls    int
+	clearErrorCalls  int
+}
+
+func (s *probeAccountRepoStub) SetTempUnschedulable(ctx context.Context, id int64, until time.Time, reason string) error {
+	s.tempUnschedCalls++
+	return nil
+}
+
+func (s *probeAccountRepoStub) ClearTempUnschedulable(ctx context.Context, id int64) error {
+	s.clearTempCalls++
+	return nil
+}
+
+func (s *probeAccountRepoStub) SetError(ctx context.Context, id int64, errorMsg string) error {
+	s.setErrorCalls++
+	return nil
+}
+
+func (s *probeAccountRepoStub) ClearError(ctx context.Context, id int64) error {
+	s.clearErrorCalls++
+	return nil
+}
+
+type probeRepoStub struct {
+	results []AccountProbeResult
+	states  map[int64]*AccountProbeState
+}
+
+func newProbeRepoStub() *probeRepoStub {
+	return &probeRepoStub{states: map[int64]*AccountProbeState{}}
+}
+
+func (r *probeRepoStub) InsertResult(ctx context.Context, result *AccountProbeResult) error {
+	r.results = append(r.results, *result)
+	return nil
+}
+
+func (r *probeRepoStub) ListResults(ctx context.Context, accountID int64, limit int) ([]AccountProbeResult, error) {
+	return r.results, nil
+}
+
+func (r *probeRepoStub) GetState(ctx context.Context, accountID int64) (*AccountProbeState, error) {
+	st, ok := r.states[accountID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *st
+	return &cp, nil
+}
+
+func (r *probeRepoStub) ListStates(ctx context.Context) ([]AccountProbeState, error) {
+	out := make([]AccountProbeState, 0, len(r.states))
+	for _, st := range r.states {
+		out = append(out, *st)
+	}
+	return out, nil
+}
+
+func (r *probeRepoStub) UpsertState(ctx context.Context, state *AccountProbeState) error {
+	cp := *state
+	r.states[state.AccountID] = &cp
+	return nil
+}
+
+func (r *probeRepoStub) DeleteResultsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+type probeTesterStub struct {
+	err error
+}
+
+func (t *probeTesterStub) RunAccountTest(ctx context.Context, w AccountTestOutput, accountID int64, modelID string) error {
+	return t.err
+}
+
+func newProbeServiceForTest(accountRepo AccountRepository, probeRepo AccountProbeRepository, tester accountConnectionTester) *AccountProbeService {
+	svc := NewAccountProbeService(probeRepo, accountRepo, nil, nil, nil, nil, nil, nil, nil)
+	svc.tester = tester
+	return svc
+}
+
+func runProbe(t *testing.T, svc *AccountProbeService, probeRepo *probeRepoStub, account *Account, settings *AccountProbeSettings) {
+	t.Helper()
+	ctx := context.Background()
+	state, err := probeRepo.GetState(ctx, account.ID)
+	require.NoError(t, err)
+	result := svc.probeAccount(ctx, account, "")
+	svc.recordResult(ctx, account, state, result, settings)
+}
+
+func TestAccountProbe_TempUnschedAfterThresholdAndRecover(t *testing.T) {
+	accountRepo := &probeAccountRepoStub{}
+	probeRepo := newProbeRepoStub()
+	tester := &probeTesterStub{err: errors.New("API returned 500")}
+	svc := newProbeServiceForTest(accountRepo, probeRepo, tester)
+
+	settings := DefaultAccountProbeSettings()
+	settings.Enabled = true
+	settings.FailureThreshold = 2
+	settings.RecoveryThreshold = 2
+
+	account := &Account{ID: 1, Platform: PlatformAnthropic, Status: StatusActive, Schedulable: true}
+
+	runProbe(t, svc, probeRepo, account, settings)
+	require.Equal(t, 0, accountRepo.tempUnschedCalls)
+	require.Equal(t, 1, probeRepo.states[1].ConsecutiveFailures)
+
+	runProbe(t, svc, probeRepo, account, settings)
+	require.Equal(t, 1, accountRepo.tempUnschedCalls)
+	require.Equal(t, AccountProbeActionTempUnsched, probeRepo.states[1].AutoAction)
+	require.True(t, isProbeTempUnsched(account))
+
+	// 仍处于临时不可调度期间，继续失败不重复设置
+	runProbe(t, svc, probeRepo, account, settings)
+	require.Equal(t, 1, accountRepo.tempUnschedCalls)
+
+	tester.err = nil
+	runProbe(t, svc, probeRepo, account, settings)
+	require.Equal(t, 0, accountRepo.clearTempCalls)
+	require.Equal(t, AccountProbeActionTempUnsched, probeRepo.states[1].AutoAction)
+
+	runProbe(t, svc, probeRepo, account, settings)
+	require.Equal(t, 1, accountRepo.clearTempCalls)
+	require.Empty(t, probeRepo.states[1].AutoAction)
+	require.Nil(t, account.TempUnschedulableUntil)
+	require.Len(t, probeRepo.results, 5)
+}
+
+func TestAccountProbe_ErrorActionAndRecover(t *testing.T) {
+	accountRepo := &probeAccountRepoStub{}
+	probeRepo := newProbeRepoStub()
+	tester := &probeTesterStub{err: errors.New("Request failed: timeout")}
+	svc := newProbeServiceForTest(accountRepo, probeRepo, tester)
+
+	settings := DefaultAccountProbeSettings()
+	settings.Enabled = true
+	settings.FailureThreshold = 1
+	settings.RecoveryThreshold = 1
+	settings.FailureAction = AccountProbeActionError
+
+	account := &Account{ID: 2, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true}
+
+	runProbe(t, svc, probeRepo, account, settings)
+	require.Equal(t, 1, accountRepo.setErrorCalls)
+	require.Equal(t, StatusError, account.Status)
+	require.Equal(t, AccountProbeActionError, probeRepo.states[2].AutoAction)
+
+	tester.err = nil
+	runProbe(t, svc, probeRepo, account, settings)
+	require.Equal(t, 1, accountRepo.clearErrorCalls)
+	require.Equal(t, StatusActive, account.Status)
+	require.Empty(t, probeRepo.states[2].AutoAction)
+}
+
+func TestAccountProbe_RecoverDoesNotClearForeignError(t *testing.T) {
+	accountRepo := &probeAccountRepoStub{}
+	probeRepo := newProbeRepoStub()
+	probeRepo.states[3] = &AccountProbeState{AccountID: 3, AutoAction: AccountProbeActionError}
+	svc := newProbeServiceForTest(accountRepo, probeRepo, &probeTesterStub{})
+
+	settings := DefaultAccountProbeSettings()
+	settings.Enabled = true
+	settings.RecoveryThreshold = 1
+
+	// 错误状态已被人工改写，不应被探测器清除
+	account := &Account{ID: 3, Platform: PlatformAnthropic, Status: StatusError, ErrorMessage: "manually disabled"}
+
+	runProbe(t, svc, probeRepo, account, settings)
+	require.Equal(t, 0, accountRepo.clearErrorCalls)
+	require.Empty(t, probeRepo.states[3].AutoAction)
+}
+
+func TestAccountProbe_DisabledOnlyRecords(t *testing.T) {
+	accountRepo := &probeAccountRepoStub{}
+	probeRepo := newProbeRepoStub()
+	svc := newProbeServiceForTest(accountRepo, probeRepo, &probeTesterStub{err: errors.New("boom")})
+
+	settings := DefaultAccountProbeSettings()
+	settings.FailureThreshold = 1
+
+	account := &Account{ID: 4, Platform: PlatformAnthropic, Status: StatusActive, Schedulable: true}
+	runProbe(t, svc, probeRepo, account, settings)
+
+	require.Equal(t, 0, accountRepo.tempUnschedCalls)
+	require.Equal(t, 1, probeRepo.states[4].ConsecutiveFailures)
+	require.Equal(t, "boom", probeRepo.results[0].ErrorMessage)
+}
+
+func TestAccountProbeSettings_ResolveSchedule(t *testing.T) {
+	settings := DefaultAccountProbeSettings()
+	settings.IntervalSeconds = 600
+	settings.Models = map[string]string{PlatformAnthropic: "claude-haiku-4-5"}
+	settings.Schedules = []AccountProbeSchedule{
+		{Platform: PlatformAnthropic, Enabled: true, IntervalSeconds: 300},
+		{GroupID: 7, Enabled: true, IntervalSeconds: 120, Model: "claude-sonnet-4-5"},
+		{Platform: PlatformGemini, Enabled: false},
+	}
+
+	interval, model, enabled := settings.resolveSchedule(&Account{Platform: PlatformAnthropic})
+	require.True(t, enabled)
+	require.Equal(t, 300*time.Second, interval)
+	require.Equal(t, "claude-haiku-4-5", model)
+
+	interval, model, enabled = settings.resolveSchedule(&Account{Platform: PlatformAnthropic, GroupIDs: []int64{3, 7}})
+	require.True(t, enabled)
+	require.Equal(t, 120*time.Second, interval)
+	require.Equal(t, "claude-sonnet-4-5", model)
+
+	_, _, enabled = settings.resolveSchedule(&Account{Platform: PlatformGemini})
+	require.False(t, enabled)
+
+	interval, model, enabled = settings.resolveSchedule(&Account{Platform: PlatformOpenAI})
+	require.True(t, enabled)
+	require.Equal(t, 600*time.Second, interval)
+	require.Empty(t, model)
+}
+
+func TestShouldProbeAccount(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+	old := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	active := &Account{Status: StatusActive, Schedulable: true}
+	require.True(t, shouldProbeAccount(active, nil, 10*time.Minute, now))
+	require.False(t, shouldProbeAccount(active, &AccountProbeState{LastProbeAt: &recent}, 10*time.Minute, now))
+	require.True(t, shouldProbeAccount(active, &AccountProbeState{LastProbeAt: &old}, 10*time.Minute, now))
+
+	paused := &Account{Status: StatusActive, Schedulable: false}
+	require.False(t, shouldProbeAccount(paused, nil, 10*time.Minute, now))
+
+	rateLimited := &Account{Status: StatusActive, Schedulable: true, RateLimitResetAt: &future}
+	require.False(t, shouldProbeAccount(rateLimited, nil, 10*time.Minute, now))
+
+	// 探测器自己标记的账号需要继续探测以便自动恢复
+	marked := &Account{Status: StatusError}
+	require.True(t, shouldProbeAccount(marked, &AccountProbeState{AutoAction: AccountProbeActionError, LastProbeAt: &old}, 10*time.Minute, now))
+}
@@ -138,37 +138,55 @@ func createTestPayload(modelID string) (map[string]any, error) {
 	}, nil
 }
 
+// AccountTestOutput receives the SSE events of an account test (gin.ResponseWriter satisfies it)
+type AccountTestOutput interface {
+	http.ResponseWriter
+	http.Flusher
+}
+
+// accountTestRun carries the context and event output of a single account test
+type accountTestRun struct {
+	ctx context.Context
+	w   AccountTestOutput
+}
+
 // TestAccountConnection tests an account's connection by sending a test request
 // All account types use full Claude Code client characteristics, only auth header differs
 // modelID is optional - if empty, defaults to claude.DefaultTestModel
 func (s *AccountTestService) TestAccountConnection(c *gin.Context, accountID int64, modelID string) error {
-	ctx := c.Request.Context()
+	return s.RunAccountTest(c.Request.Context(), c.Writer, accountID, modelID)
+}
+
+// RunAccountTest runs the account connection test and streams SSE events to w.
+// It does not depend on a gin context, so background jobs (scheduled probes) can call it directly.
+func (s *AccountTestService) RunAccountTest(ctx context.Context, w AccountTestOutput, accountID int64, modelID string) error {
+	run := &accountTestRun{ctx: ctx, w: w}
 
 	// Get account
 	account, err := s.accountRepo.GetByID(ctx, accountID)
 	if err != nil {
-		return s.sendErrorAndEnd(c, "Account not found")
+		return s.sendErrorAndEnd(run, "Account not found")
 	}
 
 	// Route to platform-specific test method
 	if account.IsOpenAI() {
-		return s.testOpenAIAccountConnection(c, account, modelID)
+		return s.testOpenAIAccountConnection(run, account, modelID)
 	}
 
 	if account.IsGemini() {
-		return s.testGeminiAccountConnection(c, account, modelID)
+		return s.testGeminiAccountConnection(run, account, modelID)
 	}
 
 	if account.Platform == PlatformAntigravity {
-		return s.testAntigravityAccountConnection(c, account, modelID)
+		return s.testAntigravityAccountConnection(run, account, modelID)
 	}
 
-	return s.testClaudeAccountConnection(c, account, modelID)
+	return s.testClaudeAccountConnection(run, account, modelID)
 }
 
 // testClaudeAccountConnection tests an Anthropic Claude account's connection
-func (s *AccountTestService) testClaudeAccountConnection(c *gin.Context, account *Account, modelID string) error {
-	ctx := c.Request.Context()
+func (s *AccountTestService) testClaudeAccountConnection(run *accountTestRun, account *Account, modelID string) error {
+	ctx := run.ctx
 
 	// Determine the model to use
 	testModelID := modelID
@@ -197,14 +215,14 @@ func (s *AccountTestService) testClaudeAccountConnection(c *gin.Context, account
 		apiURL = testClaudeAPIURL
 		authToken = account.GetCredential("access_token")
 		if authToken == "" {
-			return s.sendErrorAndEnd(c, "No access token available")
+			return s.sendErrorAndEnd(run, "No access token available")
 		}
 	} else if account.Type == "apikey" {
 		// API Key - use x-api-key header
 		useBearer = false
 		authToken = account.GetCredential("api_key")
 		if authToken == "" {
-			return s.sendErrorAndEnd(c, "No API key available")
+			return s.sendErrorAndEnd(run, "No API key available")
 		}
 
 		baseURL := account.GetBaseURL()
@@ -213,33 +231,33 @@ func (s *AccountTestService) testClaudeAccountConnection(c *gin.Context, account
 		}
 		normalizedBaseURL, err := s.validateUpstreamBaseURL(baseURL)
 		if err != nil {
-			return s.sendErrorAndEnd(c, fmt.Sprintf("Invalid base URL: %s", err.Error()))
+			return s.sendErrorAndEnd(run, fmt.Sprintf("Invalid base URL: %s", err.Error()))
 		}
 		apiURL = strings.TrimSuffix(normalizedBaseURL, "/") + "/v1/messages"
 	} else {
-		return s.sendErrorAndEnd(c, fmt.Sprintf("Unsupported account type: %s", account.Type))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("Unsupported account type: %s", account.Type))
 	}
 
 	// Set SSE headers
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("X-Accel-Buffering", "no")
-	c.Writer.Flush()
+	run.w.Header().Set("Content-Type", "text/event-stream")
+	run.w.Header().Set("Cache-Control", "no-cache")
+	run.w.Header().Set("Connection", "keep-alive")
+	run.w.Header().Set("X-Accel-Buffering", "no")
+	run.w.Flush()
 
 	// Create Claude Code style payload (same for all account types)
 	payload, err := createTestPayload(testModelID)
 	if err != nil {
-		return s.sendErrorAndEnd(c, "Failed to create test payload")
+		return s.sendErrorAndEnd(run, "Failed to create test payload")
 	}
 	payloadBytes, _ := json.Marshal(payload)
 
 	// Send test_start event
-	s.sendEvent(c, TestEvent{Type: "test_start", Model: testModelID})
+	s.sendEvent(run, TestEvent{Type: "test_start", Model: testModelID})
 
 	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(payloadBytes))
 	if err != nil {
-		return s.sendErrorAndEnd(c, "Failed to create request")
+		return s.sendErrorAndEnd(run, "Failed to create request")
 	}
 
 	// Set common headers
@@ -268,22 +286,22 @@ func (s *AccountTestService) testClaudeAccountConnection(c *gin.Context, account
 
 	resp, err := s.httpUpstream.DoWithTLS(req, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
 	if err != nil {
-		return s.sendErrorAndEnd(c, fmt.Sprintf("Request failed: %s", err.Error()))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("Request failed: %s", err.Error()))
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return s.sendErrorAndEnd(c, fmt.Sprintf("API returned %d: %s", resp.StatusCode, string(body)))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("API returned %d: %s", resp.StatusCode, string(body)))
 	}
 
 	// Process SSE stream
-	return s.processClaudeStream(c, resp.Body)
+	return s.processClaudeStream(run, resp.Body)
 }
 
 // testOpenAIAccountConnection tests an OpenAI account's connection
-func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account *Account, modelID string) error {
-	ctx := c.Request.Context()
+func (s *AccountTestService) testOpenAIAccountConnection(run *accountTestRun, account *Account, modelID string) error {
+	ctx := run.ctx
 
 	// Default to openai.DefaultTestModel for OpenAI testing
 	testModelID := modelID
@@ -312,7 +330,7 @@ func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account
 		// OAuth - use Bearer token with ChatGPT internal API
 		authToken = account.GetOpenAIAccessToken()
 		if authToken == "" {
-			return s.sendErrorAndEnd(c, "No access token available")
+			return s.sendErrorAndEnd(run, "No access token available")
 		}
 
 		// OAuth uses ChatGPT internal API
@@ -322,7 +340,7 @@ func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account
 		// API Key - use Platform API
 		authToken = account.GetOpenAIApiKey()
 		if authToken == "" {
-			return s.sendErrorAndEnd(c, "No API key available")
+			return s.sendErrorAndEnd(run, "No API key available")
 		}
 
 		baseURL := account.GetOpenAIBaseURL()
@@ -331,30 +349,30 @@ func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account
 		}
 		normalizedBaseURL, err := s.validateUpstreamBaseURL(baseURL)
 		if err != nil {
-			return s.sendErrorAndEnd(c, fmt.Sprintf("Invalid base URL: %s", err.Error()))
+			return s.sendErrorAndEnd(run, fmt.Sprintf("Invalid base URL: %s", err.Error()))
 		}
 		apiURL = strings.TrimSuffix(normalizedBaseURL, "/") + "/responses"
 	} else {
-		return s.sendErrorAndEnd(c, fmt.Sprintf("Unsupported account type: %s", account.Type))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("Unsupported account type: %s", account.Type))
 	}
 
 	// Set SSE headers
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("X-Accel-Buffering", "no")
-	c.Writer.Flush()
+	run.w.Header().Set("Content-Type", "text/event-stream")
+	run.w.Header().Set("Cache-Control", "no-cache")
+	run.w.Header().Set("Connection", "keep-alive")
+	run.w.Header().Set("X-Accel-Buffering", "no")
+	run.w.Flush()
 
 	// Create OpenAI Responses API payload
 	payload := createOpenAITestPayload(testModelID, isOAuth)
 	payloadBytes, _ := json.Marshal(payload)
 
 	// Send test_start event
-	s.sendEvent(c, TestEvent{Type: "test_start", Model: testModelID})
+	s.sendEvent(run, TestEvent{Type: "test_start", Model: testModelID})
 
 	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(payloadBytes))
 	if err != nil {
-		return s.sendErrorAndEnd(c, "Failed to create request")
+		return s.sendErrorAndEnd(run, "Failed to create request")
 	}
 
 	// Set common headers
@@ -378,22 +396,22 @@ func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account
 
 	resp, err := s.httpUpstream.DoWithTLS(req, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
 	if err != nil {
-		return s.sendErrorAndEnd(c, fmt.Sprintf("Request failed: %s", err.Error()))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("Request failed: %s", err.Error()))
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return s.sendErrorAndEnd(c, fmt.Sprintf("API returned %d: %s", resp.StatusCode, string(body)))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("API returned %d: %s", resp.StatusCode, string(body)))
 	}
 
 	// Process SSE stream
-	return s.processOpenAIStream(c, resp.Body)
+	return s.processOpenAIStream(run, resp.Body)
 }
 
 // testGeminiAccountConnection tests a Gemini account's connection
-func (s *AccountTestService) testGeminiAccountConnection(c *gin.Context, account *Account, modelID string) error {
-	ctx := c.Request.Context()
+func (s *AccountTestService) testGeminiAccountConnection(run *accountTestRun, account *Account, modelID string) error {
+	ctx := run.ctx
 
 	// Determine the model to use
 	testModelID := modelID
@@ -412,11 +430,11 @@ func (s *AccountTestService) testGeminiAccountConnection(c *gin.Context, account
 	}
 
 	// Set SSE headers
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("X-Accel-Buffering", "no")
-	c.Writer.Flush()
+	run.w.Header().Set("Content-Type", "text/event-stream")
+	run.w.Header().Set("Cache-Control", "no-cache")
+	run.w.Header().Set("Connection", "keep-alive")
+	run.w.Header().Set("X-Accel-Buffering", "no")
+	run.w.Flush()
 
 	// Create test payload (Gemini format)
 	payload := createGeminiTestPayload()
@@ -431,15 +449,15 @@ func (s *AccountTestService) testGeminiAccountConnection(c *gin.Context, account
 	case AccountTypeOAuth:
 		req, err = s.buildGeminiOAuthRequest(ctx, account, testModelID, payload)
 	default:
-		return s.sendErrorAndEnd(c, fmt.Sprintf("Unsupported account type: %s", account.Type))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("Unsupported account type: %s", account.Type))
 	}
 
 	if err != nil {
-		return s.sendErrorAndEnd(c, fmt.Sprintf("Failed to build request: %s", err.Error()))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("Failed to build request: %s", err.Error()))
 	}
 
 	// Send test_start event
-	s.sendEvent(c, TestEvent{Type: "test_start", Model: testModelID})
+	s.sendEvent(run, TestEvent{Type: "test_start", Model: testModelID})
 
 	// Get proxy and execute request
 	proxyURL := ""
@@ -449,23 +467,23 @@ func (s *AccountTestService) testGeminiAccountConnection(c *gin.Context, account
 
 	resp, err := s.httpUpstream.DoWithTLS(req, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
 	if err != nil {
-		return s.sendErrorAndEnd(c, fmt.Sprintf("Request failed: %s", err.Error()))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("Request failed: %s", err.Error()))
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return s.sendErrorAndEnd(c, fmt.Sprintf("API returned %d: %s", resp.StatusCode, string(body)))
+		return s.sendErrorAndEnd(run, fmt.Sprintf("API returned %d: %s", resp.StatusCode, string(body)))
 	}
 
 	// Process SSE stream
-	return s.processGeminiStream(c, resp.Body)
+	return s.processGeminiStream(run, resp.Body)
 }
 
 // testAntigravityAccountConnection tests an Antigravity account's connection
 // 支持 Claude 和 Gemini 两种协议，使用非流式请求
-func (s *AccountTestService) testAntigravityAccountConnection(c *gin.Context, account *Account, modelID string) error {
-	ctx := c.Request.Context()
+func (s *AccountTestService) testAntigravityAccountConnection(run *accountTestRun, account *Account, modelID string) error {
+	ctx := run.ctx
 
 	// 默认模型：Claude 使用 claude-sonnet-4-5，Gemini 使用 gemini-3-pro-preview
 	testModelID := modelID
@@ -474,31 +492,31 @@ func (s *AccountTestService) testAntigravityAccountConnection(c *gin.Context, ac
 	}
 
 	if s.antigravityGatewayService == nil {
-		return s.sendErrorAndEnd(c, "Antigravity gateway service not configured")
+		return s.sendErrorAndEnd(run, "Antigravity gateway service not configured")
 	}
 
 	// Set SSE headers
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("X-Accel-Buffering", "no")
-	c.Writer.Flush()
+	run.w.Header().Set("Content-Type", "text/event-stream")
+	run.w.Header().Set("Cache-Control", "no-cache")
+	run.w.Header().Set("Connection", "keep-alive")
+	run.w.Header().Set("X-Accel-Buffering", "no")
+	run.w.Flush()
 
 	// Send test_start event
-	s.sendEvent(c, TestEvent{Type: "test_start", Model: testModelID})
+	s.sendEvent(run, TestEvent{Type: "test_start", Model: testModelID})
 
 	// 调用 AntigravityGatewayService.TestConnection（复用协议转换逻辑）
 	result, err := s.antigravityGatewayService.TestConnection(ctx, account, testModelID)
 	if err != nil {
-		return s.sendErrorAndEnd(c, err.Error())
+		return s.sendErrorAndEnd(run, err.Error())
 	}
 
 	// 发送响应内容
 	if result.Text != "" {
-		s.sendEvent(c, TestEvent{Type: "content", Text: result.Text})
+		s.sendEvent(run, TestEvent{Type: "content", Text: result.Text})
 	}
 
-	s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+	s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 	return nil
 }
 
@@ -625,17 +643,17 @@ func createGeminiTestPayload() []byte {
 }
 
 // processGeminiStream processes SSE stream from Gemini API
-func (s *AccountTestService) processGeminiStream(c *gin.Context, body io.Reader) error {
+func (s *AccountTestService) processGeminiStream(run *accountTestRun, body io.Reader) error {
 	reader := bufio.NewReader(body)
 
 	for {
 		line, err := reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
-				s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+				s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 				return nil
 			}
-			return s.sendErrorAndEnd(c, fmt.Sprintf("Stream read error: %s", err.Error()))
+			return s.sendErrorAndEnd(run, fmt.Sprintf("Stream read error: %s", err.Error()))
 		}
 
 		line = strings.TrimSpace(line)
@@ -645,7 +663,7 @@ func (s *AccountTestService) processGeminiStream(c *gin.Context, body io.Reader)
 
 		jsonStr := strings.TrimPrefix(line, "data: ")
 		if jsonStr == "[DONE]" {
-			s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+			s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 			return nil
 		}
 
@@ -668,7 +686,7 @@ func (s *AccountTestService) processGeminiStream(c *gin.Context, body io.Reader)
 						for _, part := range parts {
 							if partMap, ok := part.(map[string]any); ok {
 								if text, ok := partMap["text"].(string); ok && text != "" {
-									s.sendEvent(c, TestEvent{Type: "content", Text: text})
+									s.sendEvent(run, TestEvent{Type: "content", Text: text})
 								}
 							}
 						}
@@ -677,7 +695,7 @@ func (s *AccountTestService) processGeminiStream(c *gin.Context, body io.Reader)
 
 				// Check for completion after extracting content
 				if finishReason, ok := candidate["finishReason"].(string); ok && finishReason != "" {
-					s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+					s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 					return nil
 				}
 			}
@@ -689,7 +707,7 @@ func (s *AccountTestService) processGeminiStream(c *gin.Context, body io.Reader)
 			if msg, ok := errData["message"].(string); ok {
 				errorMsg = msg
 			}
-			return s.sendErrorAndEnd(c, errorMsg)
+			return s.sendErrorAndEnd(run, errorMsg)
 		}
 	}
 }
@@ -724,17 +742,17 @@ func createOpenAITestPayload(modelID string, isOAuth bool) map[string]any {
 }
 
 // processClaudeStream processes the SSE stream from Claude API
-func (s *AccountTestService) processClaudeStream(c *gin.Context, body io.Reader) error {
+func (s *AccountTestService) processClaudeStream(run *accountTestRun, body io.Reader) error {
 	reader := bufio.NewReader(body)
 
 	for {
 		line, err := reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
-				s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+				s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 				return nil
 			}
-			return s.sendErrorAndEnd(c, fmt.Sprintf("Stream read error: %s", err.Error()))
+			return s.sendErrorAndEnd(run, fmt.Sprintf("Stream read error: %s", err.Error()))
 		}
 
 		line = strings.TrimSpace(line)
@@ -744,7 +762,7 @@ func (s *AccountTestService) processClaudeStream(c *gin.Context, body io.Reader)
 
 		jsonStr := sseDataPrefix.ReplaceAllString(line, "")
 		if jsonStr == "[DONE]" {
-			s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+			s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 			return nil
 		}
 
@@ -759,11 +777,11 @@ func (s *AccountTestService) processClaudeStream(c *gin.Context, body io.Reader)
 		case "content_block_delta":
 			if delta, ok := data["delta"].(map[string]any); ok {
 				if text, ok := delta["text"].(string); ok {
-					s.sendEvent(c, TestEvent{Type: "content", Text: text})
+					s.sendEvent(run, TestEvent{Type: "content", Text: text})
 				}
 			}
 		case "message_stop":
-			s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+			s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 			return nil
 		case "error":
 			errorMsg := "Unknown error"
@@ -772,23 +790,23 @@ func (s *AccountTestService) processClaudeStream(c *gin.Context, body io.Reader)
 					errorMsg = msg
 				}
 			}
-			return s.sendErrorAndEnd(c, errorMsg)
+			return s.sendErrorAndEnd(run, errorMsg)
 		}
 	}
 }
 
 // processOpenAIStream processes the SSE stream from OpenAI Responses API
-func (s *AccountTestService) processOpenAIStream(c *gin.Context, body io.Reader) error {
+func (s *AccountTestService) processOpenAIStream(run *accountTestRun, body io.Reader) error {
 	reader := bufio.NewReader(body)
 
 	for {
 		line, err := reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
-				s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+				s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 				return nil
 			}
-			return s.sendErrorAndEnd(c, fmt.Sprintf("Stream read error: %s", err.Error()))
+			return s.sendErrorAndEnd(run, fmt.Sprintf("Stream read error: %s", err.Error()))
 		}
 
 		line = strings.TrimSpace(line)
@@ -798,7 +816,7 @@ func (s *AccountTestService) processOpenAIStream(c *gin.Context, body io.Reader)
 
 		jsonStr := sseDataPrefix.ReplaceAllString(line, "")
 		if jsonStr == "[DONE]" {
-			s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+			s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 			return nil
 		}
 
@@ -813,10 +831,10 @@ func (s *AccountTestService) processOpenAIStream(c *gin.Context, body io.Reader)
 		case "response.output_text.delta":
 			// OpenAI Responses API uses "delta" field for text content
 			if delta, ok := data["delta"].(string); ok && delta != "" {
-				s.sendEvent(c, TestEvent{Type: "content", Text: delta})
+				s.sendEvent(run, TestEvent{Type: "content", Text: delta})
 			}
 		case "response.completed":
-			s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+			s.sendEvent(run, TestEvent{Type: "test_complete", Success: true})
 			return nil
 		case "error":
 			errorMsg := "Unknown error"
@@ -825,24 +843,24 @@ func (s *AccountTestService) processOpenAIStream(c *gin.Context, body io.Reader)
 					errorMsg = msg
 				}
 			}
-			return s.sendErrorAndEnd(c, errorMsg)
+			return s.sendErrorAndEnd(run, errorMsg)
 		}
 	}
 }
 
 // sendEvent sends a SSE event to the client
-func (s *AccountTestService) sendEvent(c *gin.Context, event TestEvent) {
+func (s *AccountTestService) sendEvent(run *accountTestRun, event TestEvent) {
 	eventJSON, _ := json.Marshal(event)
-	if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON); err != nil {
+	if _, err := fmt.Fprintf(run.w, "data: %s\n\n", eventJSON); err != nil {
 		log.Printf("failed to write SSE event: %v", err)
 		return
 	}
-	c.Writer.Flush()
+	run.w.Flush()
 }
 
 // sendErrorAndEnd sends an error event and ends the stream
-func (s *AccountTestService) sendErrorAndEnd(c *gin.Context, errorMsg string) error {
+func (s *AccountTestService) sendErrorAndEnd(run *accountTestRun, errorMsg string) error {
 	log.Printf("Account test error: %s", errorMsg)
-	s.sendEvent(c, TestEvent{Type: "error", Error: errorMsg})
+	s.sendEvent(run, TestEvent{Type: "error", Error: errorMsg})
 	return fmt.Errorf("%s", errorMsg)
 }
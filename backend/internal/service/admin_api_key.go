@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// AdminAPIKeyStatusRevoked 已吊销的管理员 API Key
+const AdminAPIKeyStatusRevoked = "revoked"
+
+var (
+	ErrAdminAPIKeyNotFound    = infraerrors.NotFound("ADMIN_API_KEY_NOT_FOUND", "admin api key not found")
+	ErrAdminAPIKeyExists      = infraerrors.Conflict("ADMIN_API_KEY_EXISTS", "admin api key name already exists")
+	ErrAdminAPIKeyNoScope     = infraerrors.BadRequest("ADMIN_API_KEY_NO_SCOPE", "admin api key requires a role or at least one permission")
+	ErrAdminAPIKeyScopeDenied = infraerrors.Forbidden("ADMIN_API_KEY_SCOPE_DENIED", "cannot grant permissions you do not have")
+	ErrAdminAPIKeyInvalidIP   = infraerrors.BadRequest("ADMIN_API_KEY_INVALID_IP", "invalid ip whitelist pattern")
+	ErrAdminAPIKeyExpiresAt   = infraerrors.BadRequest("ADMIN_API_KEY_INVALID_EXPIRY", "expires_at must be in the future")
+
+	// 认证失败原因（中间件统一映射为 401，避免泄露 Key 是否存在）
+	ErrAdminAPIKeyInvalid      = infraerrors.Unauthorized("INVALID_ADMIN_KEY", "Invalid admin API key")
+	ErrAdminAPIKeyExpired      = infraerrors.Unauthorized("ADMIN_KEY_EXPIRED", "Admin API key has expired")
+	ErrAdminAPIKeyRevoked      = infraerrors.Unauthorized("ADMIN_KEY_REVOKED", "Admin API key has been revoked")
+	ErrAdminAPIKeyIPNotAllowed = infraerrors.Forbidden("ADMIN_KEY_IP_NOT_ALLOWED", "Client IP is not allowed for this admin API key")
+)
+
+// AdminAPIKey 具名管理员 API Key（仅保存哈希）
+type AdminAPIKey struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	KeyHash     string     `json:"-"`
+	KeyPrefix   string     `json:"key_prefix"`
+	Role        string     `json:"role"`
+	Permissions []string   `json:"permissions"`
+	IPWhitelist []string   `json:"ip_whitelist"`
+	Status      string     `json:"status"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  string     `json:"last_used_ip"`
+	CreatedBy   int64      `json:"created_by"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// IsExpired 是否已过期
+func (k *AdminAPIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// AdminAPIKeyRepository 管理员 API Key 存储
+type AdminAPIKeyRepository interface {
+	Create(ctx context.Context, key *AdminAPIKey) error
+	GetByID(ctx context.Context, id int64) (*AdminAPIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*AdminAPIKey, error)
+	List(ctx context.Context) ([]AdminAPIKey, error)
+	Update(ctx context.Context, key *AdminAPIKey) error
+	Revoke(ctx context.Context, id int64, at time.Time) error
+	Delete(ctx context.Context, id int64) error
+	TouchLastUsed(ctx context.Context, id int64, ip string, at time.Time) error
+}
+
+// CreateAdminAPIKeyInput 创建管理员 API Key 参数
+type CreateAdminAPIKeyInput struct {
+	Name        string
+	Role        string
+	Permissions []string
+	IPWhitelist []string
+	ExpiresAt   *time.Time
+}
+
+// UpdateAdminAPIKeyInput 更新管理员 API Key 参数，nil 表示不修改
+type UpdateAdminAPIKeyInput struct {
+	Name        *string
+	Role        *string
+	Permissions []string
+	IPWhitelist []string
+	ExpiresAt   *time.Time
+	// ClearExpiresAt 为 true 时取消过期时间
+	ClearExpiresAt bool
+}
+
+// HashAdminAPIKey 计算管理员 API Key 的存储哈希
+func HashAdminAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+)
+
+// adminAPIKeyTouchInterval 最近使用时间的写入间隔，避免每个请求都写库
+const adminAPIKeyTouchInterval = time.Minute
+
+// AdminAPIKeyService 具名管理员 API Key 管理与认证
+type AdminAPIKeyService struct {
+	keyRepo     AdminAPIKeyRepository
+	userRepo    UserRepository
+	roleService *AdminRoleService
+
+	touchMu   sync.Mutex
+	lastTouch map[int64]time.Time
+}
+
+// NewAdminAPIKeyService 创建管理员 API Key 服务
+func NewAdminAPIKeyService(keyRepo AdminAPIKeyRepository, userRepo UserRepository, roleService *AdminRoleService) *AdminAPIKeyService {
+	return &AdminAPIKeyService{
+		keyRepo:     keyRepo,
+		userRepo:    userRepo,
+		roleService: roleService,
+		lastTouch:   make(map[int64]time.Time),
+	}
+}
+
+// keyPermissions 计算 Key 自身声明的权限：角色权限 ∪ 额外权限点
+func (s *AdminAPIKeyService) keyPermissions(ctx context.Context, role string, perms []string) (AdminPermissionSet, error) {
+	out := NewAdminPermissionSet(perms...)
+	if role == "" {
+		return out, nil
+	}
+	rolePerms, err := s.roleService.RolePermissions(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	for p := range rolePerms {
+		out[p] = struct{}{}
+	}
+	return out, nil
+}
+
+// Authenticate 校验管理员 API Key，返回 Key、执行身份（创建者）与生效权限。
+// 生效权限为 Key 权限与创建者当前权限的交集：创建者被降权或取消管理员身份后，其创建的 Key 同步收缩或失效。
+func (s *AdminAPIKeyService) Authenticate(ctx context.Context, rawKey, clientIP string) (*AdminAPIKey, *User, AdminPermissionSet, error) {
+	key, err := s.keyRepo.GetByHash(ctx, HashAdminAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, ErrAdminAPIKeyNotFound) {
+			return nil, nil, nil, ErrAdminAPIKeyInvalid
+		}
+		return nil, nil, nil, err
+	}
+	now := time.Now()
+	if key.Status == AdminAPIKeyStatusRevoked {
+		return nil, nil, nil, ErrAdminAPIKeyRevoked
+	}
+	if key.IsExpired(now) {
+		return nil, nil, nil, ErrAdminAPIKeyExpired
+	}
+	if len(key.IPWhitelist) > 0 {
+		if allowed, _ := ip.CheckIPRestriction(clientIP, key.IPWhitelist, nil); !allowed {
+			return nil, nil, nil, ErrAdminAPIKeyIPNotAllowed
+		}
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, key.CreatedBy)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, nil, nil, ErrAdminAPIKeyInvalid
+		}
+		return nil, nil, nil, err
+	}
+	if !owner.IsAdmin() || !owner.IsActive() {
+		return nil, nil, nil, ErrAdminAPIKeyInvalid
+	}
+	ownerPerms, err := s.roleService.ResolvePermissions(ctx, owner)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keyPerms, err := s.keyPermissions(ctx, key.Role, key.Permissions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	effective := AdminPermissionSet{}
+	for p := range keyPerms {
+		if ownerPerms.Has(p) {
+			effective[p] = struct{}{}
+		}
+	}
+
+	s.touch(ctx, key.ID, clientIP, now)
+	return key, owner, effective, nil
+}
+
+func (s *AdminAPIKeyService) touch(ctx context.Context, id int64, clientIP string, now time.Time) {
+	s.touchMu.Lock()
+	last, ok := s.lastTouch[id]
+	if ok && now.Sub(last) < adminAPIKeyTouchInterval {
+		s.touchMu.Unlock()
+		return
+	}
+	s.lastTouch[id] = now
+	s.touchMu.Unlock()
+
+	if err := s.keyRepo.TouchLastUsed(ctx, id, clientIP, now); err != nil {
+		log.Printf("[AdminAPIKey] update last used failed: key=%d err=%v", id, err)
+	}
+}
+
+// List 列出全部管理员 API Key
+func (s *AdminAPIKeyService) List(ctx context.Context) ([]AdminAPIKey, error) {
+	return s.keyRepo.List(ctx)
+}
+
+// Get 获取管理员 API Key
+func (s *AdminAPIKeyService) Get(ctx context.Context, id int64) (*AdminAPIKey, error) {
+	return s.keyRepo.GetByID(ctx, id)
+}
+
+// validateScope 校验 Key 的授权范围：必须非空，且不得超出操作者自身权限
+func (s *AdminAPIKeyService) validateScope(ctx context.Context, operatorPerms AdminPermissionSet, role string, perms []string) ([]string, error) {
+	normalized, err := normalizeAdminPermissions(perms)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" {
+		if err := s.roleService.ValidateRoleName(ctx, role); err != nil {
+			return nil, err
+		}
+	}
+	if role == "" && len(normalized) == 0 {
+		return nil, ErrAdminAPIKeyNoScope
+	}
+	requested, err := s.keyPermissions(ctx, role, normalized)
+	if err != nil {
+		return nil, err
+	}
+	for p := range requested {
+		if !operatorPerms.Has(p) {
+			return nil, ErrAdminAPIKeyScopeDenied.WithMetadata(map[string]string{"permission": p})
+		}
+	}
+	return normalized, nil
+}
+
+func normalizeAdminAPIKeyIPWhitelist(patterns []string) ([]string, error) {
+	out := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	if invalid := ip.ValidateIPPatterns(out); len(invalid) > 0 {
+		return nil, ErrAdminAPIKeyInvalidIP.WithMetadata(map[string]string{"patterns": strings.Join(invalid, ",")})
+	}
+	return out, nil
+}
+
+// Create 创建管理员 API Key，返回的明文 Key 仅此一次可见
+func (s *AdminAPIKeyService) Create(ctx context.Context, operatorID int64, operatorPerms AdminPermissionSet, input *CreateAdminAPIKeyInput) (*AdminAPIKey, string, error) {
+	role := strings.TrimSpace(input.Role)
+	perms, err := s.validateScope(ctx, operatorPerms, role, input.Permissions)
+	if err != nil {
+		return nil, "", err
+	}
+	whitelist, err := normalizeAdminAPIKeyIPWhitelist(input.IPWhitelist)
+	if err != nil {
+		return nil, "", err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrAdminAPIKeyExpiresAt
+	}
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, "", fmt.Errorf("generate random bytes: %w", err)
+	}
+	rawKey := AdminAPIKeyPrefix + hex.EncodeToString(bytes)
+
+	key := &AdminAPIKey{
+		Name:        strings.TrimSpace(input.Name),
+		KeyHash:     HashAdminAPIKey(rawKey),
+		KeyPrefix:   rawKey[:len(AdminAPIKeyPrefix)+8],
+		Role:        role,
+		Permissions: perms,
+		IPWhitelist: whitelist,
+		Status:      StatusActive,
+		ExpiresAt:   input.ExpiresAt,
+		CreatedBy:   operatorID,
+	}
+	if err := s.keyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, rawKey, nil
+}
+
+// Update 更新管理员 API Key 的名称、授权范围、IP 白名单或过期时间
+func (s *AdminAPIKeyService) Update(ctx context.Context, id int64, operatorPerms AdminPermissionSet, input *UpdateAdminAPIKeyInput) (*AdminAPIKey, error) {
+	key, err := s.keyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil {
+		key.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Role != nil || input.Permissions != nil {
+		role := key.Role
+		if input.Role != nil {
+			role = strings.TrimSpace(*input.Role)
+		}
+		perms := key.Permissions
+		if input.Permissions != nil {
+			perms = input.Permissions
+		}
+		normalized, err := s.validateScope(ctx, operatorPerms, role, perms)
+		if err != nil {
+			return nil, err
+		}
+		key.Role = role
+		key.Permissions = normalized
+	}
+	if input.IPWhitelist != nil {
+		whitelist, err := normalizeAdminAPIKeyIPWhitelist(input.IPWhitelist)
+		if err != nil {
+			return nil, err
+		}
+		key.IPWhitelist = whitelist
+	}
+	if input.ClearExpiresAt {
+		key.ExpiresAt = nil
+	} else if input.ExpiresAt != nil {
+		if !input.ExpiresAt.After(time.Now()) {
+			return nil, ErrAdminAPIKeyExpiresAt
+		}
+		key.ExpiresAt = input.ExpiresAt
+	}
+	if err := s.keyRepo.Update(ctx, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Revoke 吊销管理员 API Key（保留记录用于审计）
+func (s *AdminAPIKeyService) Revoke(ctx context.Context, id int64) (*AdminAPIKey, error) {
+	if err := s.keyRepo.Revoke(ctx, id, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.keyRepo.GetByID(ctx, id)
+}
+
+// Delete 删除管理员 API Key
+func (s *AdminAPIKeyService) Delete(ctx context.Context, id int64) error {
+	return s.keyRepo.Delete(ctx, id)
+}
@@ -0,0 +1,164 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type adminAPIKeyRepoStub struct {
+	keys    map[int64]*AdminAPIKey
+	touches int
+}
+
+func (r *adminAPIKeyRepoStub) Create(ctx context.Context, key *AdminAPIKey) error {
+	key.ID = int64(len(r.keys) + 1)
+	cp := *key
+	r.keys[key.ID] = &cp
+	return nil
+}
+
+func (r *adminAPIKeyRepoStub) GetByID(ctx context.Context, id int64) (*AdminAPIKey, error) {
+	if key, ok := r.keys[id]; ok {
+		cp := *key
+		return &cp, nil
+	}
+	return nil, ErrAdminAPIKeyNotFound
+}
+
+func (r *adminAPIKeyRepoStub) GetByHash(ctx context.Context, keyHash string) (*AdminAPIKey, error) {
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			cp := *key
+			return &cp, nil
+		}
+	}
+	return nil, ErrAdminAPIKeyNotFound
+}
+
+func (r *adminAPIKeyRepoStub) List(ctx context.Context) ([]AdminAPIKey, error) {
+	out := make([]AdminAPIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		out = append(out, *key)
+	}
+	return out, nil
+}
+
+func (r *adminAPIKeyRepoStub) Update(ctx context.Context, key *AdminAPIKey) error {
+	cp := *key
+	r.keys[key.ID] = &cp
+	return nil
+}
+
+func (r *adminAPIKeyRepoStub) Revoke(ctx context.Context, id int64, at time.Time) error {
+	key, ok := r.keys[id]
+	if !ok {
+		return ErrAdminAPIKeyNotFound
+	}
+	key.Status = AdminAPIKeyStatusRevoked
+	key.RevokedAt = &at
+	return nil
+}
+
+func (r *adminAPIKeyRepoStub) Delete(ctx context.Context, id int64) error {
+	delete(r.keys, id)
+	return nil
+}
+
+func (r *adminAPIKeyRepoStub) TouchLastUsed(ctx context.Context, id int64, ip string, at time.Time) error {
+	r.touches++
+	return nil
+}
+
+func newAdminAPIKeyTestService(owner *User) (*AdminAPIKeyService, *adminAPIKeyRepoStub) {
+	repo := &adminAPIKeyRepoStub{keys: map[int64]*AdminAPIKey{}}
+	userRepo := &userRepoStub{user: owner}
+	svc := NewAdminAPIKeyService(repo, userRepo, NewAdminRoleService(newAdminRoleRepoStub(), userRepo))
+	return svc, repo
+}
+
+func TestAdminAPIKeyCreateAndAuthenticate(t *testing.T) {
+	owner := &User{ID: 1, Role: RoleAdmin, Status: StatusActive}
+	svc, repo := newAdminAPIKeyTestService(owner)
+	ctx := context.Background()
+	superPerms := NewAdminPermissionSet(AllAdminPermissions()...)
+
+	key, rawKey, err := svc.Create(ctx, owner.ID, superPerms, &CreateAdminAPIKeyInput{
+		Name:        "billing-sync",
+		Permissions: []string{AdminPermUsageRead, AdminPermUsersRead},
+		IPWhitelist: []string{"10.0.0.0/8"},
+	})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(rawKey, AdminAPIKeyPrefix))
+	require.Equal(t, HashAdminAPIKey(rawKey), repo.keys[key.ID].KeyHash)
+	require.NotContains(t, repo.keys[key.ID].KeyHash, rawKey, "只保存哈希")
+	require.True(t, strings.HasPrefix(rawKey, key.KeyPrefix))
+
+	got, user, perms, err := svc.Authenticate(ctx, rawKey, "10.1.2.3")
+	require.NoError(t, err)
+	require.Equal(t, key.ID, got.ID)
+	require.Equal(t, owner.ID, user.ID)
+	require.Equal(t, []string{AdminPermUsageRead, AdminPermUsersRead}, perms.List())
+
+	// 最近使用时间按间隔节流写入
+	_, _, _, err = svc.Authenticate(ctx, rawKey, "10.1.2.3")
+	require.NoError(t, err)
+	require.Equal(t, 1, repo.touches)
+
+	_, _, _, err = svc.Authenticate(ctx, rawKey, "192.168.1.1")
+	require.ErrorIs(t, err, ErrAdminAPIKeyIPNotAllowed)
+
+	_, _, _, err = svc.Authenticate(ctx, "admin-unknown", "10.1.2.3")
+	require.ErrorIs(t, err, ErrAdminAPIKeyInvalid)
+
+	// 吊销只影响当前 Key
+	other, otherRaw, err := svc.Create(ctx, owner.ID, superPerms, &CreateAdminAPIKeyInput{Name: "monitoring", Role: AdminRoleReadOnly})
+	require.NoError(t, err)
+	_, err = svc.Revoke(ctx, key.ID)
+	require.NoError(t, err)
+	_, _, _, err = svc.Authenticate(ctx, rawKey, "10.1.2.3")
+	require.ErrorIs(t, err, ErrAdminAPIKeyRevoked)
+	got, _, _, err = svc.Authenticate(ctx, otherRaw, "203.0.113.9")
+	require.NoError(t, err)
+	require.Equal(t, other.ID, got.ID)
+
+	past := time.Now().Add(-time.Minute)
+	repo.keys[other.ID].ExpiresAt = &past
+	_, _, _, err = svc.Authenticate(ctx, otherRaw, "203.0.113.9")
+	require.ErrorIs(t, err, ErrAdminAPIKeyExpired)
+}
+
+func TestAdminAPIKeyScope(t *testing.T) {
+	owner := &User{ID: 1, Role: RoleAdmin, AdminRole: AdminRoleSupport, Status: StatusActive}
+	svc, _ := newAdminAPIKeyTestService(owner)
+	ctx := context.Background()
+	supportPerms, err := svc.roleService.RolePermissions(ctx, AdminRoleSupport)
+	require.NoError(t, err)
+
+	_, _, err = svc.Create(ctx, owner.ID, supportPerms, &CreateAdminAPIKeyInput{Name: "empty"})
+	require.ErrorIs(t, err, ErrAdminAPIKeyNoScope)
+
+	_, _, err = svc.Create(ctx, owner.ID, supportPerms, &CreateAdminAPIKeyInput{Name: "escalate", Permissions: []string{AdminPermAccountsCredentials}})
+	require.ErrorIs(t, err, ErrAdminAPIKeyScopeDenied)
+
+	_, _, err = svc.Create(ctx, owner.ID, supportPerms, &CreateAdminAPIKeyInput{Name: "bad-ip", Permissions: []string{AdminPermUsageRead}, IPWhitelist: []string{"not-an-ip"}})
+	require.ErrorIs(t, err, ErrAdminAPIKeyInvalidIP)
+
+	// 创建者被降权后，Key 的生效权限随之收缩
+	_, rawKey, err := svc.Create(ctx, owner.ID, supportPerms, &CreateAdminAPIKeyInput{Name: "usage", Permissions: []string{AdminPermUsageRead, AdminPermRedeemWrite}})
+	require.NoError(t, err)
+	owner.AdminRole = AdminRoleReadOnly
+	_, _, perms, err := svc.Authenticate(ctx, rawKey, "127.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, []string{AdminPermUsageRead}, perms.List())
+
+	// 创建者不再是管理员时 Key 失效
+	owner.Role = RoleUser
+	_, _, _, err = svc.Authenticate(ctx, rawKey, "127.0.0.1")
+	require.ErrorIs(t, err, ErrAdminAPIKeyInvalid)
+}
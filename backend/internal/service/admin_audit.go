@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// adminAuditRedacted 敏感字段的脱敏占位
+const adminAuditRedacted = "***"
+
+// adminAuditMaxBodyBytes 请求体超过该大小时不记录内容
+const adminAuditMaxBodyBytes = 64 * 1024
+
+var ErrAdminAuditLogNotFound = infraerrors.NotFound("ADMIN_AUDIT_LOG_NOT_FOUND", "admin audit log not found")
+
+// AdminAuditLog 一条管理操作审计记录
+type AdminAuditLog struct {
+	ID              int64                       `json:"id"`
+	ActorUserID     *int64                      `json:"actor_user_id"`
+	ActorAPIKeyID   *int64                      `json:"actor_api_key_id"`
+	ActorAPIKeyName string                      `json:"actor_api_key_name"`
+	AuthMethod      string                      `json:"auth_method"`
+	IP              string                      `json:"ip"`
+	Method          string                      `json:"method"`
+	Path            string                      `json:"path"`
+	Action          string                      `json:"action"`
+	Resource        string                      `json:"resource"`
+	TargetID        string                      `json:"target_id"`
+	StatusCode      int                         `json:"status_code"`
+	Request         any                         `json:"request,omitempty"`
+	Before          map[string]any              `json:"before,omitempty"`
+	After           map[string]any              `json:"after,omitempty"`
+	Diff            map[string]AdminAuditChange `json:"diff,omitempty"`
+	CreatedAt       time.Time                   `json:"created_at"`
+}
+
+// AdminAuditChange 单个字段的变更，敏感字段的前后值均为 "***"
+type AdminAuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AdminAuditLogFilter 审计日志查询条件，零值表示不过滤
+type AdminAuditLogFilter struct {
+	ActorUserID int64
+	APIKeyID    *int64
+	Resource    string
+	Action      string
+	TargetID    string
+	StartTime   *time.Time
+	EndTime     *time.Time
+}
+
+// AdminAuditLogRepository 审计日志存储（仅追加，清理由 OpsCleanupService 负责）
+type AdminAuditLogRepository interface {
+	Insert(ctx context.Context, entry *AdminAuditLog) error
+	GetByID(ctx context.Context, id int64) (*AdminAuditLog, error)
+	List(ctx context.Context, filter AdminAuditLogFilter, params pagination.PaginationParams) ([]AdminAuditLog, *pagination.PaginationResult, error)
+}
+
+// AdminAuditRecordInput 中间件采集的一次管理操作
+type AdminAuditRecordInput struct {
+	ActorUserID int64
+	// APIKeyID/APIKeyName 仅 Admin API Key 请求有效，HasAPIKey 区分旧版全局 Key（ID 为 0）与 JWT
+	HasAPIKey  bool
+	APIKeyID   int64
+	APIKeyName string
+	AuthMethod string
+	IP         string
+	Method     string
+	Path       string
+	// Route 相对 /admin 的路由模板，如 /users/:id/balance
+	Route        string
+	TargetID     string
+	StatusCode   int
+	RequestBody  []byte
+	ResponseBody []byte
+	Before       map[string]any
+}
+
+// AdminAuditSnapshotKey 返回路由对应的快照键：含 :id 的路由截取到 :id，否则为路由本身
+func AdminAuditSnapshotKey(route string) string {
+	if idx := strings.Index(route, "/:id"); idx >= 0 {
+		return route[:idx+len("/:id")]
+	}
+	return route
+}
+
+// adminAuditResourceAction 由路由模板推导资源与操作名。
+// 资源为第一段路径；操作名为去掉参数后的路径段，以 "." 连接。
+// PUT/PATCH/DELETE 以及以参数结尾的 POST 追加动词，例如：
+//
+//	PUT  /users/:id          -> users, users.update
+//	POST /users              -> users, users.create
+//	POST /users/:id/balance  -> users, users.balance
+//	PUT  /settings           -> settings, settings.update
+func adminAuditResourceAction(method, route string) (string, string) {
+	resource := ""
+	parts := make([]string, 0)
+	endsWithParam := false
+	for _, seg := range strings.Split(strings.Trim(route, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			endsWithParam = true
+			continue
+		}
+		endsWithParam = false
+		if resource == "" {
+			resource = seg
+		}
+		parts = append(parts, strings.ReplaceAll(seg, "-", "_"))
+	}
+	if len(parts) == 0 {
+		return "", strings.ToLower(method)
+	}
+
+	verb := ""
+	switch method {
+	case http.MethodPost:
+		if endsWithParam || len(parts) == 1 {
+			verb = "create"
+		}
+	case http.MethodPut, http.MethodPatch:
+		verb = "update"
+	case http.MethodDelete:
+		verb = "delete"
+	}
+	if verb != "" {
+		parts = append(parts, verb)
+	}
+	return resource, strings.Join(parts, ".")
+}
+
+// isAdminAuditSensitiveKey 判断字段是否为敏感字段。
+// 同时匹配 JSON 字段名（snake_case）与未加 json tag 的 Go 字段名（CamelCase）。
+func isAdminAuditSensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	k = strings.NewReplacer("_", "", "-", "").Replace(k)
+	if k == "" || strings.HasSuffix(k, "configured") || strings.HasSuffix(k, "enabled") {
+		return false
+	}
+	if k == "code" || strings.HasSuffix(k, "token") {
+		return true
+	}
+	for _, term := range []string{"password", "secret", "apikey", "sessionkey", "privatekey", "cookie", "codeverifier", "authorizationcode"} {
+		if strings.Contains(k, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAdminAuditValue 递归脱敏 JSON 值中的敏感字段
+func redactAdminAuditValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			if isAdminAuditSensitiveKey(k) {
+				out[k] = adminAuditRedacted
+				continue
+			}
+			out[k] = redactAdminAuditValue(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactAdminAuditValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// redactAdminAuditMap 脱敏快照
+func redactAdminAuditMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out, _ := redactAdminAuditValue(m).(map[string]any)
+	return out
+}
+
+// toAdminAuditMap 将任意对象序列化为 JSON 对象，用于快照与差异计算
+func toAdminAuditMap(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// diffAdminAuditMaps 计算前后快照的字段级差异。
+// 嵌套对象按 "a.b" 路径展开，数组整体比较；敏感字段在原始值上比较，输出时仅保留 "***"，
+// 因此凭证被修改时可以看到"哪个字段变了"，但看不到值。
+func diffAdminAuditMaps(before, after map[string]any) map[string]AdminAuditChange {
+	out := make(map[string]AdminAuditChange)
+	diffAdminAuditInto(out, "", before, after, false)
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func diffAdminAuditInto(out map[string]AdminAuditChange, prefix string, before, after map[string]any, sensitive bool) {
+	keys := make(map[string]struct{}, len(before)+len(after))
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		b, hasB := before[k]
+		a, hasA := after[k]
+		if hasB && hasA && reflect.DeepEqual(b, a) {
+			continue
+		}
+		keySensitive := sensitive || isAdminAuditSensitiveKey(k)
+
+		bm, bIsMap := b.(map[string]any)
+		am, aIsMap := a.(map[string]any)
+		if bIsMap && aIsMap {
+			diffAdminAuditInto(out, path, bm, am, keySensitive)
+			continue
+		}
+
+		var change AdminAuditChange
+		if keySensitive {
+			change = AdminAuditChange{Before: adminAuditRedactedOrNil(hasB, b), After: adminAuditRedactedOrNil(hasA, a)}
+		} else {
+			change = AdminAuditChange{Before: redactAdminAuditValue(b), After: redactAdminAuditValue(a)}
+		}
+		out[path] = change
+	}
+}
+
+// adminAuditRedactedOrNil 敏感字段：不存在或为空时保留 nil，便于区分"新增/清空"与"修改"
+func adminAuditRedactedOrNil(present bool, v any) any {
+	if !present || v == nil || v == "" {
+		return nil
+	}
+	return adminAuditRedacted
+}
+
+// parseAdminAuditRequestBody 解析并脱敏请求体，非 JSON 或过大的请求体只记录摘要
+func parseAdminAuditRequestBody(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	if len(body) > adminAuditMaxBodyBytes {
+		return map[string]any{"_truncated": true, "size": len(body)}
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return map[string]any{"_non_json": true, "size": len(body)}
+	}
+	return redactAdminAuditValue(value)
+}
+
+// adminAuditCreatedID 从创建类接口的响应 {"code":0,"data":{"id":...}} 中提取新对象 ID
+func adminAuditCreatedID(responseBody []byte) string {
+	if len(responseBody) == 0 {
+		return ""
+	}
+	var resp struct {
+		Data struct {
+			ID json.Number `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(responseBody, &resp); err != nil {
+		return ""
+	}
+	return resp.Data.ID.String()
+}
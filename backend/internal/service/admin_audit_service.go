@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// adminAuditWriteTimeout 写入审计记录的超时，与请求上下文解耦，客户端断开也会落库
+const adminAuditWriteTimeout = 5 * time.Second
+
+// adminAuditSnapshotLoader 按目标 ID 加载对象快照，无 ID 的单例配置（如系统设置）忽略 id
+type adminAuditSnapshotLoader func(ctx context.Context, id int64) (any, error)
+
+// AdminAuditService 管理操作审计：采集变更前后快照、计算差异并写入仅追加的审计日志
+type AdminAuditService struct {
+	repo    AdminAuditLogRepository
+	loaders map[string]adminAuditSnapshotLoader
+}
+
+// NewAdminAuditService 创建管理操作审计服务
+func NewAdminAuditService(
+	repo AdminAuditLogRepository,
+	adminService AdminService,
+	settingService *SettingService,
+	promoService *PromoService,
+	opsService *OpsService,
+	errorPassthroughService *ErrorPassthroughService,
+	adminAPIKeyService *AdminAPIKeyService,
+) *AdminAuditService {
+	s := &AdminAuditService{repo: repo}
+	account := func(ctx context.Context, id int64) (any, error) { return adminService.GetAccount(ctx, id) }
+	// 键为 AdminAuditSnapshotKey 的返回值（相对 /admin 的路由模板）
+	s.loaders = map[string]adminAuditSnapshotLoader{
+		"/users/:id":           func(ctx context.Context, id int64) (any, error) { return adminService.GetUser(ctx, id) },
+		"/groups/:id":          func(ctx context.Context, id int64) (any, error) { return adminService.GetGroup(ctx, id) },
+		"/accounts/:id":        account,
+		"/openai/accounts/:id": account,
+		"/proxies/:id":         func(ctx context.Context, id int64) (any, error) { return adminService.GetProxy(ctx, id) },
+		"/redeem-codes/:id":    func(ctx context.Context, id int64) (any, error) { return adminService.GetRedeemCode(ctx, id) },
+		"/promo-codes/:id":     func(ctx context.Context, id int64) (any, error) { return promoService.GetByID(ctx, id) },
+		"/error-passthrough-rules/:id": func(ctx context.Context, id int64) (any, error) {
+			return errorPassthroughService.GetByID(ctx, id)
+		},
+		"/ops/alert-rules/:id": func(ctx context.Context, id int64) (any, error) {
+			rules, err := opsService.ListAlertRules(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, rule := range rules {
+				if rule != nil && rule.ID == id {
+					return rule, nil
+				}
+			}
+			return nil, nil
+		},
+		"/admin-api-keys/:id": func(ctx context.Context, id int64) (any, error) { return adminAPIKeyService.Get(ctx, id) },
+		"/settings":           func(ctx context.Context, _ int64) (any, error) { return settingService.GetAllSettings(ctx) },
+		"/settings/stream-timeout": func(ctx context.Context, _ int64) (any, error) {
+			return settingService.GetStreamTimeoutSettings(ctx)
+		},
+	}
+	return s
+}
+
+// Snapshot 加载路由目标对象的当前快照（未脱敏，仅用于差异计算）。
+// 路由没有对应的加载器、ID 非法或对象不存在时返回 nil。
+func (s *AdminAuditService) Snapshot(ctx context.Context, route, targetID string) map[string]any {
+	if s == nil {
+		return nil
+	}
+	loader, ok := s.loaders[AdminAuditSnapshotKey(route)]
+	if !ok {
+		return nil
+	}
+	var id int64
+	if targetID != "" {
+		parsed, err := strconv.ParseInt(targetID, 10, 64)
+		if err != nil {
+			return nil
+		}
+		id = parsed
+	}
+	v, err := loader(ctx, id)
+	if err != nil || v == nil {
+		return nil
+	}
+	return toAdminAuditMap(v)
+}
+
+// Record 写入一条审计记录。失败的请求（状态码 >= 400）只记录请求本身，不记录快照。
+// 写入失败只打日志，不影响管理接口的响应。
+func (s *AdminAuditService) Record(ctx context.Context, input *AdminAuditRecordInput) {
+	if s == nil || s.repo == nil || input == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), adminAuditWriteTimeout)
+	defer cancel()
+
+	resource, action := adminAuditResourceAction(input.Method, input.Route)
+	entry := &AdminAuditLog{
+		AuthMethod: input.AuthMethod,
+		IP:         input.IP,
+		Method:     input.Method,
+		Path:       input.Path,
+		Action:     action,
+		Resource:   resource,
+		TargetID:   input.TargetID,
+		StatusCode: input.StatusCode,
+		Request:    parseAdminAuditRequestBody(input.RequestBody),
+		CreatedAt:  time.Now(),
+	}
+	if input.ActorUserID > 0 {
+		actor := input.ActorUserID
+		entry.ActorUserID = &actor
+	}
+	if input.HasAPIKey {
+		keyID := input.APIKeyID
+		entry.ActorAPIKeyID = &keyID
+		entry.ActorAPIKeyName = input.APIKeyName
+	}
+
+	if input.StatusCode < http.StatusBadRequest {
+		route := input.Route
+		// 创建类接口：从响应中取新对象 ID，按 "<路由>/:id" 加载创建后的快照
+		if entry.TargetID == "" && input.Method == http.MethodPost {
+			if id := adminAuditCreatedID(input.ResponseBody); id != "" {
+				if _, ok := s.loaders[input.Route+"/:id"]; ok {
+					entry.TargetID = id
+					route = input.Route + "/:id"
+				}
+			}
+		}
+		after := s.Snapshot(ctx, route, entry.TargetID)
+		entry.Diff = diffAdminAuditMaps(input.Before, after)
+		entry.Before = redactAdminAuditMap(input.Before)
+		entry.After = redactAdminAuditMap(after)
+	}
+
+	if err := s.repo.Insert(ctx, entry); err != nil {
+		log.Printf("[AdminAudit] write audit log failed: action=%s target=%s err=%v", entry.Action, entry.TargetID, err)
+	}
+}
+
+// List 按条件分页查询审计日志
+func (s *AdminAuditService) List(ctx context.Context, filter AdminAuditLogFilter, params pagination.PaginationParams) ([]AdminAuditLog, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, filter, params)
+}
+
+// GetByID 获取单条审计日志
+func (s *AdminAuditService) GetByID(ctx context.Context, id int64) (*AdminAuditLog, error) {
+	return s.repo.GetByID(ctx, id)
+}
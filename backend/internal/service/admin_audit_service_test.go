@@ -0,0 +1,172 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type adminAuditRepoStub struct {
+	entries []*AdminAuditLog
+}
+
+func (r *adminAuditRepoStub) Insert(ctx context.Context, entry *AdminAuditLog) error {
+	entry.ID = int64(len(r.entries) + 1)
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *adminAuditRepoStub) GetByID(ctx context.Context, id int64) (*AdminAuditLog, error) {
+	panic("unexpected GetByID call")
+}
+
+func (r *adminAuditRepoStub) List(ctx context.Context, filter AdminAuditLogFilter, params pagination.PaginationParams) ([]AdminAuditLog, *pagination.PaginationResult, error) {
+	panic("unexpected List call")
+}
+
+type auditAccountFixture struct {
+	Name        string
+	Concurrency int
+	Credentials map[string]any
+}
+
+func TestAdminAuditResourceAction(t *testing.T) {
+	cases := []struct {
+		method, route, resource, action string
+	}{
+		{http.MethodPut, "/users/:id", "users", "users.update"},
+		{http.MethodPost, "/users", "users", "users.create"},
+		{http.MethodDelete, "/users/:id", "users", "users.delete"},
+		{http.MethodPost, "/users/:id/balance", "users", "users.balance"},
+		{http.MethodPut, "/settings", "settings", "settings.update"},
+		{http.MethodPost, "/system/restart", "system", "system.restart"},
+		{http.MethodDelete, "/accounts/:id/temp-unschedulable", "accounts", "accounts.temp_unschedulable.delete"},
+		{http.MethodPut, "/ops/alert-rules/:id", "ops", "ops.alert_rules.update"},
+		{http.MethodPost, "/redeem-codes/generate", "redeem-codes", "redeem_codes.generate"},
+	}
+	for _, tc := range cases {
+		resource, action := adminAuditResourceAction(tc.method, tc.route)
+		require.Equal(t, tc.resource, resource, tc.route)
+		require.Equal(t, tc.action, action, tc.route)
+	}
+}
+
+func TestAdminAuditSensitiveKey(t *testing.T) {
+	for _, k := range []string{"password", "PasswordHash", "access_token", "refresh_token", "api_key", "APIKeys", "session_key", "TotpSecretEncrypted", "SMTPPassword", "cookie", "code"} {
+		require.True(t, isAdminAuditSensitiveKey(k), k)
+	}
+	for _, k := range []string{"SMTPPasswordConfigured", "PasswordResetEnabled", "TokenVersion", "base_url", "Balance", "ModelRouting"} {
+		require.False(t, isAdminAuditSensitiveKey(k), k)
+	}
+}
+
+func TestDiffAdminAuditMapsRedactsSecrets(t *testing.T) {
+	before := toAdminAuditMap(auditAccountFixture{
+		Name:        "acc",
+		Concurrency: 3,
+		Credentials: map[string]any{"access_token": "old-token", "base_url": "https://a"},
+	})
+	after := toAdminAuditMap(auditAccountFixture{
+		Name:        "acc",
+		Concurrency: 5,
+		Credentials: map[string]any{"access_token": "new-token", "base_url": "https://b", "api_key": "sk-new"},
+	})
+
+	diff := diffAdminAuditMaps(before, after)
+	require.Len(t, diff, 4)
+	require.Equal(t, AdminAuditChange{Before: float64(3), After: float64(5)}, diff["Concurrency"])
+	require.Equal(t, AdminAuditChange{Before: "https://a", After: "https://b"}, diff["Credentials.base_url"])
+	require.Equal(t, AdminAuditChange{Before: adminAuditRedacted, After: adminAuditRedacted}, diff["Credentials.access_token"])
+	require.Equal(t, AdminAuditChange{Before: nil, After: adminAuditRedacted}, diff["Credentials.api_key"])
+
+	require.Nil(t, diffAdminAuditMaps(before, before))
+}
+
+func TestAdminAuditRecordUpdate(t *testing.T) {
+	repo := &adminAuditRepoStub{}
+	current := auditAccountFixture{Name: "acc", Credentials: map[string]any{"access_token": "new"}}
+	svc := &AdminAuditService{
+		repo: repo,
+		loaders: map[string]adminAuditSnapshotLoader{
+			"/accounts/:id": func(ctx context.Context, id int64) (any, error) {
+				require.Equal(t, int64(7), id)
+				return current, nil
+			},
+		},
+	}
+	before := svc.Snapshot(context.Background(), "/accounts/:id", "7")
+	current.Credentials = map[string]any{"access_token": "rotated"}
+
+	svc.Record(context.Background(), &AdminAuditRecordInput{
+		ActorUserID: 1,
+		HasAPIKey:   true,
+		APIKeyID:    3,
+		APIKeyName:  "ci",
+		AuthMethod:  "admin_api_key",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/admin/accounts/7",
+		Route:       "/accounts/:id",
+		TargetID:    "7",
+		StatusCode:  http.StatusOK,
+		RequestBody: []byte(`{"credentials":{"access_token":"rotated"}}`),
+		Before:      before,
+	})
+
+	require.Len(t, repo.entries, 1)
+	entry := repo.entries[0]
+	require.Equal(t, "accounts", entry.Resource)
+	require.Equal(t, "accounts.update", entry.Action)
+	require.Equal(t, int64(1), *entry.ActorUserID)
+	require.Equal(t, int64(3), *entry.ActorAPIKeyID)
+	require.Equal(t, "ci", entry.ActorAPIKeyName)
+	require.Equal(t, map[string]any{"credentials": map[string]any{"access_token": adminAuditRedacted}}, entry.Request)
+	require.Equal(t, adminAuditRedacted, entry.Before["Credentials"].(map[string]any)["access_token"])
+	require.Equal(t, adminAuditRedacted, entry.After["Credentials"].(map[string]any)["access_token"])
+	require.Contains(t, entry.Diff, "Credentials.access_token")
+}
+
+func TestAdminAuditRecordCreateAndFailure(t *testing.T) {
+	repo := &adminAuditRepoStub{}
+	svc := &AdminAuditService{
+		repo: repo,
+		loaders: map[string]adminAuditSnapshotLoader{
+			"/groups/:id": func(ctx context.Context, id int64) (any, error) {
+				return map[string]any{"id": id, "name": "vip"}, nil
+			},
+		},
+	}
+
+	svc.Record(context.Background(), &AdminAuditRecordInput{
+		Method:       http.MethodPost,
+		Route:        "/groups",
+		StatusCode:   http.StatusOK,
+		ResponseBody: []byte(`{"code":0,"message":"success","data":{"id":42,"name":"vip"}}`),
+	})
+	require.Len(t, repo.entries, 1)
+	created := repo.entries[0]
+	require.Equal(t, "groups.create", created.Action)
+	require.Equal(t, "42", created.TargetID)
+	require.Nil(t, created.Before)
+	require.Equal(t, "vip", created.After["name"])
+	require.Contains(t, created.Diff, "name")
+	require.Nil(t, created.ActorAPIKeyID)
+
+	svc.Record(context.Background(), &AdminAuditRecordInput{
+		Method:     http.MethodPut,
+		Route:      "/groups/:id",
+		TargetID:   "42",
+		StatusCode: http.StatusForbidden,
+		Before:     map[string]any{"name": "vip"},
+	})
+	require.Len(t, repo.entries, 2)
+	denied := repo.entries[1]
+	require.Equal(t, http.StatusForbidden, denied.StatusCode)
+	require.Nil(t, denied.Before)
+	require.Nil(t, denied.After)
+	require.Nil(t, denied.Diff)
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// 管理后台权限点。约定 "<资源>:<动作>"，read 对应查询类接口，write 对应变更类接口。
+const (
+	AdminPermDashboardRead = "dashboard:read"
+
+	AdminPermUsersRead    = "users:read"
+	AdminPermUsersWrite   = "users:write"
+	AdminPermUsersBalance = "users:balance"
+
+	// 分组包含倍率等定价配置，groups:write 即改价权限
+	AdminPermGroupsRead  = "groups:read"
+	AdminPermGroupsWrite = "groups:write"
+
+	AdminPermAccountsRead  = "accounts:read"
+	AdminPermAccountsWrite = "accounts:write"
+	// AdminPermAccountsCredentials 查看/导出/修改账号凭证（token、cookie、API Key 等），缺失时 DTO 中凭证字段被脱敏
+	AdminPermAccountsCredentials = "accounts:credentials"
+
+	AdminPermProxiesRead  = "proxies:read"
+	AdminPermProxiesWrite = "proxies:write"
+
+	AdminPermRedeemRead  = "redeem:read"
+	AdminPermRedeemWrite = "redeem:write"
+
+	AdminPermPromoRead  = "promo:read"
+	AdminPermPromoWrite = "promo:write"
+
+	AdminPermSubscriptionsRead  = "subscriptions:read"
+	AdminPermSubscriptionsWrite = "subscriptions:write"
+
+	AdminPermUsageRead  = "usage:read"
+	AdminPermUsageWrite = "usage:write"
+
+	AdminPermAnnouncementsRead  = "announcements:read"
+	AdminPermAnnouncementsWrite = "announcements:write"
+
+	AdminPermSettingsRead  = "settings:read"
+	AdminPermSettingsWrite = "settings:write"
+
+	AdminPermOpsRead  = "ops:read"
+	AdminPermOpsWrite = "ops:write"
+
+	AdminPermSystemRead   = "system:read"
+	AdminPermSystemUpdate = "system:update"
+
+	// AdminPermAuditRead 查看管理操作审计日志
+	AdminPermAuditRead = "audit:read"
+
+	// AdminPermRolesManage 管理角色、为管理员分配角色、授予管理员身份
+	AdminPermRolesManage = "roles:manage"
+	// AdminPermAdminKeysManage 管理 Admin API Key
+	AdminPermAdminKeysManage = "admin_keys:manage"
+)
+
+// 内置管理员角色
+const (
+	AdminRoleSuperAdmin = "super_admin"
+	AdminRoleOperator   = "operator"
+	AdminRoleBilling    = "billing"
+	AdminRoleSupport    = "support"
+	AdminRoleReadOnly   = "read_only"
+)
+
+var (
+	ErrAdminRoleNotFound   = infraerrors.NotFound("ADMIN_ROLE_NOT_FOUND", "admin role not found")
+	ErrAdminRoleExists     = infraerrors.Conflict("ADMIN_ROLE_EXISTS", "admin role name already exists")
+	ErrAdminRoleBuiltin    = infraerrors.BadRequest("ADMIN_ROLE_BUILTIN", "built-in admin roles cannot be modified")
+	ErrAdminRoleInUse      = infraerrors.Conflict("ADMIN_ROLE_IN_USE", "admin role is assigned to users")
+	ErrAdminRoleInvalid    = infraerrors.BadRequest("ADMIN_ROLE_INVALID", "invalid admin role")
+	ErrAdminRoleSelfAssign = infraerrors.BadRequest("ADMIN_ROLE_SELF_ASSIGN", "cannot change your own admin role")
+	ErrAdminRoleNotAdmin   = infraerrors.BadRequest("ADMIN_ROLE_NOT_ADMIN", "admin role can only be assigned to admin users")
+	ErrAdminPermInvalid    = infraerrors.BadRequest("ADMIN_PERMISSION_INVALID", "unknown admin permission")
+)
+
+// AdminPermissionInfo 权限点说明（用于前端展示可选权限）
+type AdminPermissionInfo struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+// adminPermissionCatalog 全部权限点，顺序即展示顺序
+var adminPermissionCatalog = []AdminPermissionInfo{
+	{AdminPermDashboardRead, "View dashboard statistics"},
+	{AdminPermUsersRead, "View users, their API keys and usage"},
+	{AdminPermUsersWrite, "Create, update and delete users"},
+	{AdminPermUsersBalance, "Adjust user balance and concurrency"},
+	{AdminPermGroupsRead, "View groups"},
+	{AdminPermGroupsWrite, "Manage groups and pricing (rate multipliers)"},
+	{AdminPermAccountsRead, "View upstream accounts (credentials redacted)"},
+	{AdminPermAccountsWrite, "Manage upstream accounts"},
+	{AdminPermAccountsCredentials, "View, export and change account credentials"},
+	{AdminPermProxiesRead, "View proxies"},
+	{AdminPermProxiesWrite, "Manage proxies, proxy pools and subscriptions"},
+	{AdminPermRedeemRead, "View redeem codes"},
+	{AdminPermRedeemWrite, "Generate and expire redeem codes"},
+	{AdminPermPromoRead, "View promo codes"},
+	{AdminPermPromoWrite, "Manage promo codes"},
+	{AdminPermSubscriptionsRead, "View subscriptions"},
+	{AdminPermSubscriptionsWrite, "Assign, extend and revoke subscriptions"},
+	{AdminPermUsageRead, "View usage logs and statistics"},
+	{AdminPermUsageWrite, "Manage usage cleanup tasks"},
+	{AdminPermAnnouncementsRead, "View announcements"},
+	{AdminPermAnnouncementsWrite, "Manage announcements"},
+	{AdminPermSettingsRead, "View system settings"},
+	{AdminPermSettingsWrite, "Change system settings"},
+	{AdminPermOpsRead, "View ops monitoring, errors and alerts"},
+	{AdminPermOpsWrite, "Manage alert rules, retry and resolve errors"},
+	{AdminPermSystemRead, "View version and check for updates"},
+	{AdminPermSystemUpdate, "Update, roll back and restart the service"},
+	{AdminPermAuditRead, "View the admin audit log"},
+	{AdminPermRolesManage, "Manage admin roles and admin assignments"},
+	{AdminPermAdminKeysManage, "Manage admin API keys"},
+}
+
+var adminPermissionKeys = func() map[string]struct{} {
+	out := make(map[string]struct{}, len(adminPermissionCatalog))
+	for _, p := range adminPermissionCatalog {
+		out[p.Key] = struct{}{}
+	}
+	return out
+}()
+
+// AdminPermissionCatalog 返回全部权限点
+func AdminPermissionCatalog() []AdminPermissionInfo {
+	out := make([]AdminPermissionInfo, len(adminPermissionCatalog))
+	copy(out, adminPermissionCatalog)
+	return out
+}
+
+// AllAdminPermissions 返回全部权限点 key
+func AllAdminPermissions() []string {
+	out := make([]string, 0, len(adminPermissionCatalog))
+	for _, p := range adminPermissionCatalog {
+		out = append(out, p.Key)
+	}
+	return out
+}
+
+// IsValidAdminPermission 判断是否为已知权限点
+func IsValidAdminPermission(perm string) bool {
+	_, ok := adminPermissionKeys[perm]
+	return ok
+}
+
+// allAdminReadPermissions 返回全部 *:read 权限
+func allAdminReadPermissions() []string {
+	out := make([]string, 0)
+	for _, p := range adminPermissionCatalog {
+		if strings.HasSuffix(p.Key, ":read") {
+			out = append(out, p.Key)
+		}
+	}
+	return out
+}
+
+// AdminRole 管理员角色（内置或自定义）
+type AdminRole struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	Builtin     bool      `json:"builtin"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// builtinAdminRoles 内置角色定义
+var builtinAdminRoles = []AdminRole{
+	{
+		Name:        AdminRoleSuperAdmin,
+		Description: "Full access to everything",
+		Permissions: AllAdminPermissions(),
+	},
+	{
+		Name:        AdminRoleOperator,
+		Description: "Day-to-day operations: accounts, proxies, groups, users and ops monitoring",
+		Permissions: append(allAdminReadPermissions(),
+			AdminPermUsersWrite,
+			AdminPermGroupsWrite,
+			AdminPermAccountsWrite,
+			AdminPermAccountsCredentials,
+			AdminPermProxiesWrite,
+			AdminPermSubscriptionsWrite,
+			AdminPermUsageWrite,
+			AdminPermAnnouncementsWrite,
+			AdminPermOpsWrite,
+		),
+	},
+	{
+		Name:        AdminRoleBilling,
+		Description: "Balances, pricing, redeem/promo codes and subscriptions",
+		Permissions: []string{
+			AdminPermDashboardRead,
+			AdminPermUsersRead,
+			AdminPermUsersBalance,
+			AdminPermGroupsRead,
+			AdminPermGroupsWrite,
+			AdminPermRedeemRead,
+			AdminPermRedeemWrite,
+			AdminPermPromoRead,
+			AdminPermPromoWrite,
+			AdminPermSubscriptionsRead,
+			AdminPermSubscriptionsWrite,
+			AdminPermUsageRead,
+		},
+	},
+	{
+		Name:        AdminRoleSupport,
+		Description: "Look up users and usage, issue redeem codes",
+		Permissions: []string{
+			AdminPermDashboardRead,
+			AdminPermUsersRead,
+			AdminPermGroupsRead,
+			AdminPermAccountsRead,
+			AdminPermRedeemRead,
+			AdminPermRedeemWrite,
+			AdminPermSubscriptionsRead,
+			AdminPermUsageRead,
+			AdminPermAnnouncementsRead,
+		},
+	},
+	{
+		Name:        AdminRoleReadOnly,
+		Description: "Read-only access without credentials",
+		Permissions: allAdminReadPermissions(),
+	},
+}
+
+// BuiltinAdminRoles 返回内置角色
+func BuiltinAdminRoles() []AdminRole {
+	out := make([]AdminRole, 0, len(builtinAdminRoles))
+	for _, r := range builtinAdminRoles {
+		r.Builtin = true
+		r.Permissions = append([]string(nil), r.Permissions...)
+		out = append(out, r)
+	}
+	return out
+}
+
+func builtinAdminRole(name string) (*AdminRole, bool) {
+	for i := range builtinAdminRoles {
+		if builtinAdminRoles[i].Name == name {
+			r := builtinAdminRoles[i]
+			r.Builtin = true
+			return &r, true
+		}
+	}
+	return nil, false
+}
+
+// AdminPermissionSet 当前管理员拥有的权限集合
+type AdminPermissionSet map[string]struct{}
+
+// NewAdminPermissionSet 由权限列表构造集合
+func NewAdminPermissionSet(perms ...string) AdminPermissionSet {
+	set := make(AdminPermissionSet, len(perms))
+	for _, p := range perms {
+		set[p] = struct{}{}
+	}
+	return set
+}
+
+// Has 判断是否拥有全部指定权限
+func (s AdminPermissionSet) Has(perms ...string) bool {
+	for _, p := range perms {
+		if _, ok := s[p]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// List 返回排序后的权限列表
+func (s AdminPermissionSet) List() []string {
+	out := make([]string, 0, len(s))
+	for p := range s {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// AdminRoleRepository 自定义管理员角色存储
+type AdminRoleRepository interface {
+	Create(ctx context.Context, role *AdminRole) error
+	GetByID(ctx context.Context, id int64) (*AdminRole, error)
+	GetByName(ctx context.Context, name string) (*AdminRole, error)
+	Update(ctx context.Context, role *AdminRole) error
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context) ([]AdminRole, error)
+	// CountAssignedUsers 统计分配了该角色的管理员数量
+	CountAssignedUsers(ctx context.Context, name string) (int64, error)
+}
+
+// CreateAdminRoleInput 创建自定义角色参数
+type CreateAdminRoleInput struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// UpdateAdminRoleInput 更新自定义角色参数（角色名不可修改，避免已分配的用户失效）
+type UpdateAdminRoleInput struct {
+	Description *string
+	Permissions []string
+}
+
+// normalizeAdminPermissions 去重、排序并校验权限列表
+func normalizeAdminPermissions(perms []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(perms))
+	out := make([]string, 0, len(perms))
+	for _, p := range perms {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !IsValidAdminPermission(p) {
+			return nil, ErrAdminPermInvalid.WithMetadata(map[string]string{"permission": p})
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out, nil
+}
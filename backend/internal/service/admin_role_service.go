@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// adminRoleCacheTTL 自定义角色权限的进程内缓存时间。
+// 本实例的修改会立即失效缓存；其他实例最多延迟一个 TTL 生效。
+const adminRoleCacheTTL = 30 * time.Second
+
+var adminRoleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+type adminRoleCacheEntry struct {
+	perms     AdminPermissionSet
+	expiresAt time.Time
+}
+
+// AdminRoleService 管理后台角色与权限解析
+type AdminRoleService struct {
+	roleRepo AdminRoleRepository
+	userRepo UserRepository
+
+	cacheMu sync.RWMutex
+	cache   map[string]adminRoleCacheEntry
+}
+
+// NewAdminRoleService 创建管理员角色服务
+func NewAdminRoleService(roleRepo AdminRoleRepository, userRepo UserRepository) *AdminRoleService {
+	return &AdminRoleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+		cache:    make(map[string]adminRoleCacheEntry),
+	}
+}
+
+// ResolvePermissions 解析管理员的权限集合。
+// 非管理员返回空集合；未分配角色的管理员视为超级管理员（兼容 RBAC 之前的管理员）；
+// 角色已被删除时返回空集合（拒绝访问）。
+func (s *AdminRoleService) ResolvePermissions(ctx context.Context, user *User) (AdminPermissionSet, error) {
+	if user == nil || !user.IsAdmin() {
+		return AdminPermissionSet{}, nil
+	}
+	return s.RolePermissions(ctx, user.AdminRole)
+}
+
+// RolePermissions 返回指定角色的权限集合，空角色名视为超级管理员
+func (s *AdminRoleService) RolePermissions(ctx context.Context, roleName string) (AdminPermissionSet, error) {
+	roleName = strings.TrimSpace(roleName)
+	if roleName == "" {
+		roleName = AdminRoleSuperAdmin
+	}
+	if role, ok := builtinAdminRole(roleName); ok {
+		return NewAdminPermissionSet(role.Permissions...), nil
+	}
+
+	s.cacheMu.RLock()
+	entry, ok := s.cache[roleName]
+	s.cacheMu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.perms, nil
+	}
+
+	perms := AdminPermissionSet{}
+	role, err := s.roleRepo.GetByName(ctx, roleName)
+	switch {
+	case err == nil:
+		perms = NewAdminPermissionSet(role.Permissions...)
+	case !errors.Is(err, ErrAdminRoleNotFound):
+		return nil, err
+	}
+
+	s.cacheMu.Lock()
+	s.cache[roleName] = adminRoleCacheEntry{perms: perms, expiresAt: time.Now().Add(adminRoleCacheTTL)}
+	s.cacheMu.Unlock()
+	return perms, nil
+}
+
+func (s *AdminRoleService) invalidate(roleName string) {
+	s.cacheMu.Lock()
+	delete(s.cache, roleName)
+	s.cacheMu.Unlock()
+}
+
+// ListRoles 列出内置角色与自定义角色
+func (s *AdminRoleService) ListRoles(ctx context.Context) ([]AdminRole, error) {
+	custom, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := BuiltinAdminRoles()
+	return append(out, custom...), nil
+}
+
+// ValidateRoleName 校验角色是否存在（内置或自定义）
+func (s *AdminRoleService) ValidateRoleName(ctx context.Context, roleName string) error {
+	if _, ok := builtinAdminRole(roleName); ok {
+		return nil
+	}
+	if _, err := s.roleRepo.GetByName(ctx, roleName); err != nil {
+		if errors.Is(err, ErrAdminRoleNotFound) {
+			return ErrAdminRoleInvalid
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateRole 创建自定义角色
+func (s *AdminRoleService) CreateRole(ctx context.Context, input *CreateAdminRoleInput) (*AdminRole, error) {
+	name := strings.TrimSpace(input.Name)
+	if !adminRoleNamePattern.MatchString(name) {
+		return nil, ErrAdminRoleInvalid.WithMetadata(map[string]string{"name": "must match ^[a-z][a-z0-9_-]{1,49}$"})
+	}
+	if _, ok := builtinAdminRole(name); ok {
+		return nil, ErrAdminRoleExists
+	}
+	perms, err := normalizeAdminPermissions(input.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	role := &AdminRole{
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+		Permissions: perms,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+	s.invalidate(name)
+	return role, nil
+}
+
+// UpdateRole 更新自定义角色
+func (s *AdminRoleService) UpdateRole(ctx context.Context, id int64, input *UpdateAdminRoleInput) (*AdminRole, error) {
+	role, err := s.roleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Description != nil {
+		role.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Permissions != nil {
+		perms, err := normalizeAdminPermissions(input.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = perms
+	}
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+	s.invalidate(role.Name)
+	return role, nil
+}
+
+// DeleteRole 删除自定义角色，仍有管理员使用时拒绝删除
+func (s *AdminRoleService) DeleteRole(ctx context.Context, id int64) error {
+	role, err := s.roleRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	count, err := s.roleRepo.CountAssignedUsers(ctx, role.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAdminRoleInUse
+	}
+	if err := s.roleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate(role.Name)
+	return nil
+}
+
+// AssignRole 为管理员分配角色。operatorID 为当前操作者，不允许修改自己的角色以免误把自己锁在外面。
+func (s *AdminRoleService) AssignRole(ctx context.Context, operatorID, userID int64, roleName string) (*User, error) {
+	if operatorID == userID {
+		return nil, ErrAdminRoleSelfAssign
+	}
+	roleName = strings.TrimSpace(roleName)
+	if err := s.ValidateRoleName(ctx, roleName); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsAdmin() {
+		return nil, ErrAdminRoleNotAdmin
+	}
+	user.AdminRole = roleName
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,199 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type adminRoleRepoStub struct {
+	roles    map[string]*AdminRole
+	assigned map[string]int64
+	lookups  int
+}
+
+func newAdminRoleRepoStub(roles ...AdminRole) *adminRoleRepoStub {
+	repo := &adminRoleRepoStub{roles: map[string]*AdminRole{}, assigned: map[string]int64{}}
+	for i := range roles {
+		r := roles[i]
+		repo.roles[r.Name] = &r
+	}
+	return repo
+}
+
+func (r *adminRoleRepoStub) Create(ctx context.Context, role *AdminRole) error {
+	if _, ok := r.roles[role.Name]; ok {
+		return ErrAdminRoleExists
+	}
+	role.ID = int64(len(r.roles) + 1)
+	cp := *role
+	r.roles[role.Name] = &cp
+	return nil
+}
+
+func (r *adminRoleRepoStub) GetByID(ctx context.Context, id int64) (*AdminRole, error) {
+	for _, role := range r.roles {
+		if role.ID == id {
+			cp := *role
+			return &cp, nil
+		}
+	}
+	return nil, ErrAdminRoleNotFound
+}
+
+func (r *adminRoleRepoStub) GetByName(ctx context.Context, name string) (*AdminRole, error) {
+	r.lookups++
+	if role, ok := r.roles[name]; ok {
+		cp := *role
+		return &cp, nil
+	}
+	return nil, ErrAdminRoleNotFound
+}
+
+func (r *adminRoleRepoStub) Update(ctx context.Context, role *AdminRole) error {
+	cp := *role
+	r.roles[role.Name] = &cp
+	return nil
+}
+
+func (r *adminRoleRepoStub) Delete(ctx context.Context, id int64) error {
+	for name, role := range r.roles {
+		if role.ID == id {
+			delete(r.roles, name)
+			return nil
+		}
+	}
+	return ErrAdminRoleNotFound
+}
+
+func (r *adminRoleRepoStub) List(ctx context.Context) ([]AdminRole, error) {
+	out := make([]AdminRole, 0, len(r.roles))
+	for _, role := range r.roles {
+		out = append(out, *role)
+	}
+	return out, nil
+}
+
+func (r *adminRoleRepoStub) CountAssignedUsers(ctx context.Context, name string) (int64, error) {
+	return r.assigned[name], nil
+}
+
+type roleUserRepoStub struct {
+	userRepoStub
+	updated *User
+}
+
+func (s *roleUserRepoStub) Update(ctx context.Context, user *User) error {
+	s.updated = user
+	return nil
+}
+
+func TestBuiltinAdminRoles(t *testing.T) {
+	svc := NewAdminRoleService(newAdminRoleRepoStub(), nil)
+	ctx := context.Background()
+
+	// 未分配角色的管理员视为超级管理员
+	perms, err := svc.ResolvePermissions(ctx, &User{Role: RoleAdmin})
+	require.NoError(t, err)
+	require.True(t, perms.Has(AllAdminPermissions()...))
+
+	perms, err = svc.ResolvePermissions(ctx, &User{Role: RoleUser, AdminRole: AdminRoleSuperAdmin})
+	require.NoError(t, err)
+	require.Empty(t, perms, "普通用户没有任何管理权限")
+
+	support, err := svc.RolePermissions(ctx, AdminRoleSupport)
+	require.NoError(t, err)
+	require.True(t, support.Has(AdminPermUsersRead, AdminPermUsageRead, AdminPermRedeemWrite))
+	require.False(t, support.Has(AdminPermAccountsCredentials))
+	require.False(t, support.Has(AdminPermGroupsWrite))
+	require.False(t, support.Has(AdminPermSystemUpdate))
+
+	readOnly, err := svc.RolePermissions(ctx, AdminRoleReadOnly)
+	require.NoError(t, err)
+	for p := range readOnly {
+		require.Contains(t, p, ":read")
+	}
+
+	for _, role := range BuiltinAdminRoles() {
+		for _, p := range role.Permissions {
+			require.True(t, IsValidAdminPermission(p), "%s: %s", role.Name, p)
+		}
+		if role.Name != AdminRoleSuperAdmin {
+			require.NotContains(t, role.Permissions, AdminPermSystemUpdate)
+			require.NotContains(t, role.Permissions, AdminPermRolesManage)
+		}
+	}
+}
+
+func TestCustomAdminRoleResolveAndCache(t *testing.T) {
+	repo := newAdminRoleRepoStub(AdminRole{ID: 1, Name: "auditor", Permissions: []string{AdminPermUsageRead}})
+	svc := NewAdminRoleService(repo, nil)
+	ctx := context.Background()
+
+	perms, err := svc.ResolvePermissions(ctx, &User{Role: RoleAdmin, AdminRole: "auditor"})
+	require.NoError(t, err)
+	require.Equal(t, []string{AdminPermUsageRead}, perms.List())
+
+	_, err = svc.RolePermissions(ctx, "auditor")
+	require.NoError(t, err)
+	require.Equal(t, 1, repo.lookups, "第二次解析命中缓存")
+
+	// 修改后立即失效缓存
+	_, err = svc.UpdateRole(ctx, 1, &UpdateAdminRoleInput{Permissions: []string{AdminPermUsageRead, AdminPermUsersRead, AdminPermUsageRead}})
+	require.NoError(t, err)
+	perms, err = svc.RolePermissions(ctx, "auditor")
+	require.NoError(t, err)
+	require.Equal(t, []string{AdminPermUsageRead, AdminPermUsersRead}, perms.List())
+
+	// 角色不存在时拒绝全部权限
+	perms, err = svc.ResolvePermissions(ctx, &User{Role: RoleAdmin, AdminRole: "ghost"})
+	require.NoError(t, err)
+	require.Empty(t, perms)
+}
+
+func TestCreateAdminRoleValidation(t *testing.T) {
+	repo := newAdminRoleRepoStub()
+	svc := NewAdminRoleService(repo, nil)
+	ctx := context.Background()
+
+	_, err := svc.CreateRole(ctx, &CreateAdminRoleInput{Name: "Bad Name"})
+	require.ErrorIs(t, err, ErrAdminRoleInvalid)
+
+	_, err = svc.CreateRole(ctx, &CreateAdminRoleInput{Name: AdminRoleSupport})
+	require.ErrorIs(t, err, ErrAdminRoleExists)
+
+	_, err = svc.CreateRole(ctx, &CreateAdminRoleInput{Name: "auditor", Permissions: []string{"users:delete_all"}})
+	require.ErrorIs(t, err, ErrAdminPermInvalid)
+
+	role, err := svc.CreateRole(ctx, &CreateAdminRoleInput{Name: "auditor", Permissions: []string{AdminPermUsageRead}})
+	require.NoError(t, err)
+
+	repo.assigned["auditor"] = 2
+	require.ErrorIs(t, svc.DeleteRole(ctx, role.ID), ErrAdminRoleInUse)
+	repo.assigned["auditor"] = 0
+	require.NoError(t, svc.DeleteRole(ctx, role.ID))
+}
+
+func TestAssignAdminRole(t *testing.T) {
+	userRepo := &roleUserRepoStub{userRepoStub: userRepoStub{user: &User{ID: 2, Role: RoleAdmin}}}
+	svc := NewAdminRoleService(newAdminRoleRepoStub(), userRepo)
+	ctx := context.Background()
+
+	_, err := svc.AssignRole(ctx, 2, 2, AdminRoleSupport)
+	require.ErrorIs(t, err, ErrAdminRoleSelfAssign)
+
+	_, err = svc.AssignRole(ctx, 1, 2, "ghost")
+	require.ErrorIs(t, err, ErrAdminRoleInvalid)
+
+	user, err := svc.AssignRole(ctx, 1, 2, AdminRoleSupport)
+	require.NoError(t, err)
+	require.Equal(t, AdminRoleSupport, user.AdminRole)
+	require.Same(t, userRepo.user, userRepo.updated)
+
+	userRepo.user = &User{ID: 3, Role: RoleUser}
+	_, err = svc.AssignRole(ctx, 1, 3, AdminRoleSupport)
+	require.ErrorIs(t, err, ErrAdminRoleNotAdmin)
+}
@@ -125,6 +125,13 @@ type CreateGroupInput struct {
 	MCPXMLInject        *bool
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes []string
+	// 账号选择策略（空表示 default）
+	SelectionStrategy string
+	// 分组内用户公平分配
+	FairShareEnabled bool
+	// 模型降级链
+	ModelFallback        map[string][]string
+	ModelFallbackEnabled bool
 	// 从指定分组复制账号（创建分组后在同一事务内绑定）
 	CopyAccountsFromGroupIDs []int64
 }
@@ -154,6 +161,13 @@ type UpdateGroupInput struct {
 	MCPXMLInject        *bool
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes *[]string
+	// 账号选择策略
+	SelectionStrategy *string
+	// 分组内用户公平分配
+	FairShareEnabled *bool
+	// 模型降级链（nil 表示不修改）
+	ModelFallback        map[string][]string
+	ModelFallbackEnabled *bool
 	// 从指定分组复制账号（同步操作：先清空当前分组的账号绑定，再绑定源分组的账号）
 	CopyAccountsFromGroupIDs []int64
 }
@@ -166,6 +180,7 @@ type CreateAccountInput struct {
 	Credentials        map[string]any
 	Extra              map[string]any
 	ProxyID            *int64
+	ProxyPoolID        *int64 // 代理池 ID（设置后优先于 ProxyID）
 	Concurrency        int
 	Priority           int
 	RateMultiplier     *float64 // 账号计费倍率（>=0，允许 0）
@@ -186,6 +201,7 @@ type UpdateAccountInput struct {
 	Credentials           map[string]any
 	Extra                 map[string]any
 	ProxyID               *int64
+	ProxyPoolID           *int64   // 0 表示清除代理池
 	Concurrency           *int     // 使用指针区分"未提供"和"设置为0"
 	Priority              *int     // 使用指针区分"未提供"和"设置为0"
 	RateMultiplier        *float64 // 账号计费倍率（>=0，允许 0）
@@ -201,6 +217,7 @@ type BulkUpdateAccountsInput struct {
 	AccountIDs     []int64
 	Name           string
 	ProxyID        *int64
+	ProxyPoolID    *int64 // 0 表示清除代理池
 	Concurrency    *int
 	Priority       *int
 	RateMultiplier *float64 // 账号计费倍率（>=0，允许 0）
@@ -306,6 +323,7 @@ type adminServiceImpl struct {
 	proxyProber          ProxyExitInfoProber
 	proxyLatencyCache    ProxyLatencyCache
 	authCacheInvalidator APIKeyAuthCacheInvalidator
+	referralService      *ReferralService
 }
 
 // NewAdminService creates a new AdminService
@@ -321,6 +339,7 @@ func NewAdminService(
 	proxyProber ProxyExitInfoProber,
 	proxyLatencyCache ProxyLatencyCache,
 	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	referralService *ReferralService,
 ) AdminService {
 	return &adminServiceImpl{
 		userRepo:             userRepo,
@@ -334,6 +353,7 @@ func NewAdminService(
 		proxyProber:          proxyProber,
 		proxyLatencyCache:    proxyLatencyCache,
 		authCacheInvalidator: authCacheInvalidator,
+		referralService:      referralService,
 	}
 }
 
@@ -560,6 +580,11 @@ func (s *adminServiceImpl) UpdateUserBalance(ctx context.Context, userID int64,
 		}
 	}
 
+	// 管理员加款视为充值（首充推荐奖励）
+	if operation == "add" && balanceDiff > 0 && s.referralService != nil {
+		s.referralService.RecordTopUp(ctx, userID, balanceDiff)
+	}
+
 	return user, nil
 }
 
@@ -658,6 +683,15 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 		}
 	}
 
+	selectionStrategy, err := normalizeSelectionStrategy(input.SelectionStrategy)
+	if err != nil {
+		return nil, err
+	}
+	modelFallback, err := normalizeModelFallback(input.ModelFallback)
+	if err != nil {
+		return nil, err
+	}
+
 	// MCPXMLInject：默认为 true，仅当显式传入 false 时关闭
 	mcpXMLInject := true
 	if input.MCPXMLInject != nil {
@@ -716,6 +750,10 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 		ModelRouting:                    input.ModelRouting,
 		MCPXMLInject:                    mcpXMLInject,
 		SupportedModelScopes:            input.SupportedModelScopes,
+		SelectionStrategy:               selectionStrategy,
+		FairShareEnabled:                input.FairShareEnabled,
+		ModelFallback:                   modelFallback,
+		ModelFallbackEnabled:            input.ModelFallbackEnabled,
 	}
 	if err := s.groupRepo.Create(ctx, group); err != nil {
 		return nil, err
@@ -914,6 +952,30 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 		group.SupportedModelScopes = *input.SupportedModelScopes
 	}
 
+	// 账号选择策略
+	if input.SelectionStrategy != nil {
+		strategy, err := normalizeSelectionStrategy(*input.SelectionStrategy)
+		if err != nil {
+			return nil, err
+		}
+		group.SelectionStrategy = strategy
+	}
+	if input.FairShareEnabled != nil {
+		group.FairShareEnabled = *input.FairShareEnabled
+	}
+
+	// 模型降级链
+	if input.ModelFallback != nil {
+		modelFallback, err := normalizeModelFallback(input.ModelFallback)
+		if err != nil {
+			return nil, err
+		}
+		group.ModelFallback = modelFallback
+	}
+	if input.ModelFallbackEnabled != nil {
+		group.ModelFallbackEnabled = *input.ModelFallbackEnabled
+	}
+
 	if err := s.groupRepo.Update(ctx, group); err != nil {
 		return nil, err
 	}
@@ -1080,6 +1142,7 @@ func (s *adminServiceImpl) CreateAccount(ctx context.Context, input *CreateAccou
 		Credentials: input.Credentials,
 		Extra:       input.Extra,
 		ProxyID:     input.ProxyID,
+		ProxyPoolID: normalizeProxyPoolID(input.ProxyPoolID),
 		Concurrency: input.Concurrency,
 		Priority:    input.Priority,
 		Status:      StatusActive,
@@ -1144,6 +1207,9 @@ func (s *adminServiceImpl) UpdateAccount(ctx context.Context, id int64, input *U
 		}
 		account.Proxy = nil // 清除关联对象，防止 GORM Save 时根据 Proxy.ID 覆盖 ProxyID
 	}
+	if input.ProxyPoolID != nil {
+		account.ProxyPoolID = normalizeProxyPoolID(input.ProxyPoolID)
+	}
 	// 只在指针非 nil 时更新 Concurrency（支持设置为 0）
 	if input.Concurrency != nil {
 		account.Concurrency = *input.Concurrency
@@ -1248,6 +1314,9 @@ func (s *adminServiceImpl) BulkUpdateAccounts(ctx context.Context, input *BulkUp
 	if input.ProxyID != nil {
 		repoUpdates.ProxyID = input.ProxyID
 	}
+	if input.ProxyPoolID != nil {
+		repoUpdates.ProxyPoolID = input.ProxyPoolID
+	}
 	if input.Concurrency != nil {
 		repoUpdates.Concurrency = input.Concurrency
 	}
@@ -344,6 +344,42 @@ func (s *billingCacheStub) InvalidateSubscriptionCache(ctx context.Context, user
 	return nil
 }
 
+func (s *billingCacheStub) GetAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) (*APIKeySpendWindows, error) {
+	panic("unexpected GetAPIKeySpendCache call")
+}
+
+func (s *billingCacheStub) SetAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string, data *APIKeySpendWindows) error {
+	panic("unexpected SetAPIKeySpendCache call")
+}
+
+func (s *billingCacheStub) UpdateAPIKeySpend(ctx context.Context, apiKeyID int64, window string, cost float64) error {
+	panic("unexpected UpdateAPIKeySpend call")
+}
+
+func (s *billingCacheStub) InvalidateAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) error {
+	panic("unexpected InvalidateAPIKeySpendCache call")
+}
+
+func (s *billingCacheStub) GetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string) (*OrganizationBillingState, error) {
+	panic("unexpected GetOrganizationBillingCache call")
+}
+
+func (s *billingCacheStub) SetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string, state *OrganizationBillingState) error {
+	panic("unexpected SetOrganizationBillingCache call")
+}
+
+func (s *billingCacheStub) UpdateOrganizationUsage(ctx context.Context, orgID, userID int64, window string, cost float64, deductBalance bool) error {
+	panic("unexpected UpdateOrganizationUsage call")
+}
+
+func (s *billingCacheStub) InvalidateOrganizationBillingCache(ctx context.Context, orgID int64) error {
+	panic("unexpected InvalidateOrganizationBillingCache call")
+}
+
+func (s *billingCacheStub) InvalidateOrganizationMemberBillingCache(ctx context.Context, orgID, userID int64) error {
+	panic("unexpected InvalidateOrganizationMemberBillingCache call")
+}
+
 func waitForInvalidations(t *testing.T, ch <-chan subscriptionInvalidateCall, expected int) []subscriptionInvalidateCall {
 	t.Helper()
 	calls := make([]subscriptionInvalidateCall, 0, expected)
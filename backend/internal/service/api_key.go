@@ -28,6 +28,29 @@ type APIKey struct {
 	Quota     float64    // Quota limit in USD (0 = unlimited)
 	QuotaUsed float64    // Used quota amount
 	ExpiresAt *time.Time // Expiration time (nil = never expires)
+
+	// Rolling spend limits in USD (nil = unlimited)
+	DailyLimitUSD   *float64
+	WeeklyLimitUSD  *float64
+	MonthlyLimitUSD *float64
+
+	// Rotation fields
+	KeyGeneration        int        // 当前密钥代数，每次轮换加 1
+	PreviousKey          *string    // 轮换前的旧密钥，宽限期内仍可认证
+	PreviousKeyExpiresAt *time.Time // 旧密钥失效时间
+	RotatedAt            *time.Time // 最近一次轮换时间
+	// SecretGeneration 本次认证所用密钥的代数（使用旧密钥时为 KeyGeneration-1），仅认证路径填充
+	SecretGeneration int
+	// SecretExpiresAt 本次认证所用密钥的失效时间（仅使用旧密钥时非空）
+	SecretExpiresAt *time.Time
+
+	// AllowedTagKeys 请求可携带的成本归属标签键（为空时使用用户级配置）
+	AllowedTagKeys []string
+
+	// OrganizationID 所属组织，非空时从组织资金池扣费（UserID 为创建该 Key 的成员）
+	OrganizationID *int64
+	// OrganizationOwnerID 组织所有者，订阅类型分组下使用其名下订阅（仅认证路径填充）
+	OrganizationOwnerID int64
 }
 
 func (k *APIKey) IsActive() bool {
@@ -42,6 +65,27 @@ func (k *APIKey) IsExpired() bool {
 	return time.Now().After(*k.ExpiresAt)
 }
 
+// HasActivePreviousKey 旧密钥是否仍在宽限期内
+func (k *APIKey) HasActivePreviousKey(now time.Time) bool {
+	return k.PreviousKey != nil && k.PreviousKeyExpiresAt != nil && now.Before(*k.PreviousKeyExpiresAt)
+}
+
+// BillingUserID 订阅归属用户：组织 Key 使用组织所有者的订阅，其余为 Key 所属用户
+func (k *APIKey) BillingUserID() int64 {
+	if k.OrganizationID != nil && k.OrganizationOwnerID > 0 {
+		return k.OrganizationOwnerID
+	}
+	if k.User != nil {
+		return k.User.ID
+	}
+	return k.UserID
+}
+
+// HasSpendLimits 是否配置了任一日/周/月消费上限
+func (k *APIKey) HasSpendLimits() bool {
+	return k.DailyLimitUSD != nil || k.WeeklyLimitUSD != nil || k.MonthlyLimitUSD != nil
+}
+
 // IsQuotaExhausted checks if the API key quota is exhausted
 func (k *APIKey) IsQuotaExhausted() bool {
 	if k.Quota <= 0 {
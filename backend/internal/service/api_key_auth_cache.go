@@ -19,6 +19,22 @@ type APIKeyAuthSnapshot struct {
 
 	// Expiration field for API Key expiration feature
 	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Expiration time (nil = never expires)
+
+	// Rolling spend limits（计费资格检查使用）
+	DailyLimitUSD   *float64 `json:"daily_limit_usd,omitempty"`
+	WeeklyLimitUSD  *float64 `json:"weekly_limit_usd,omitempty"`
+	MonthlyLimitUSD *float64 `json:"monthly_limit_usd,omitempty"`
+
+	// 缓存按密钥分别存储，记录该密钥的代数；轮换后的旧密钥带失效时间，过期即视为不存在
+	SecretGeneration int        `json:"secret_generation,omitempty"`
+	SecretExpiresAt  *time.Time `json:"secret_expires_at,omitempty"`
+
+	// 成本归属标签键白名单（网关解析请求标签时使用）
+	AllowedTagKeys []string `json:"allowed_tag_keys,omitempty"`
+
+	// 组织 Key：计费走组织资金池，订阅类型分组使用组织所有者的订阅
+	OrganizationID      *int64 `json:"organization_id,omitempty"`
+	OrganizationOwnerID int64  `json:"organization_owner_id,omitempty"`
 }
 
 // APIKeyAuthUserSnapshot 用户快照
@@ -28,6 +44,8 @@ type APIKeyAuthUserSnapshot struct {
 	Role        string  `json:"role"`
 	Balance     float64 `json:"balance"`
 	Concurrency int     `json:"concurrency"`
+
+	AllowedTagKeys []string `json:"allowed_tag_keys,omitempty"`
 }
 
 // APIKeyAuthGroupSnapshot 分组快照
@@ -56,6 +74,14 @@ type APIKeyAuthGroupSnapshot struct {
 
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes []string `json:"supported_model_scopes,omitempty"`
+
+	// 账号选择策略同样参与网关调度
+	SelectionStrategy string `json:"selection_strategy,omitempty"`
+	FairShareEnabled  bool   `json:"fair_share_enabled,omitempty"`
+
+	// 模型降级链在 handler 层选择失败/failover 耗尽时使用
+	ModelFallback        map[string][]string `json:"model_fallback,omitempty"`
+	ModelFallbackEnabled bool                `json:"model_fallback_enabled,omitempty"`
 }
 
 // APIKeyAuthCacheEntry 缓存条目，支持负缓存
@@ -179,6 +179,9 @@ func (s *APIKeyService) loadAuthCacheEntry(ctx context.Context, key, cacheKey st
 		}
 		return nil, fmt.Errorf("get api key: %w", err)
 	}
+	if err := s.attachOrganizationOwner(ctx, apiKey); err != nil {
+		return nil, err
+	}
 	apiKey.Key = key
 	snapshot := s.snapshotFromAPIKey(apiKey)
 	if snapshot == nil {
@@ -199,6 +202,9 @@ func (s *APIKeyService) applyAuthCacheEntry(key string, entry *APIKeyAuthCacheEn
 	if entry.Snapshot == nil {
 		return nil, false, nil
 	}
+	if entry.Snapshot.SecretExpiresAt != nil && !time.Now().Before(*entry.Snapshot.SecretExpiresAt) {
+		return nil, true, ErrAPIKeyNotFound
+	}
 	return s.snapshotToAPIKey(key, entry.Snapshot), true, nil
 }
 
@@ -216,12 +222,24 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 		Quota:       apiKey.Quota,
 		QuotaUsed:   apiKey.QuotaUsed,
 		ExpiresAt:   apiKey.ExpiresAt,
+
+		DailyLimitUSD:   apiKey.DailyLimitUSD,
+		WeeklyLimitUSD:  apiKey.WeeklyLimitUSD,
+		MonthlyLimitUSD: apiKey.MonthlyLimitUSD,
+
+		SecretGeneration: apiKey.SecretGeneration,
+		SecretExpiresAt:  apiKey.SecretExpiresAt,
+		AllowedTagKeys:   apiKey.AllowedTagKeys,
+
+		OrganizationID:      apiKey.OrganizationID,
+		OrganizationOwnerID: apiKey.OrganizationOwnerID,
 		User: APIKeyAuthUserSnapshot{
-			ID:          apiKey.User.ID,
-			Status:      apiKey.User.Status,
-			Role:        apiKey.User.Role,
-			Balance:     apiKey.User.Balance,
-			Concurrency: apiKey.User.Concurrency,
+			ID:             apiKey.User.ID,
+			Status:         apiKey.User.Status,
+			Role:           apiKey.User.Role,
+			Balance:        apiKey.User.Balance,
+			Concurrency:    apiKey.User.Concurrency,
+			AllowedTagKeys: apiKey.User.AllowedTagKeys,
 		},
 	}
 	if apiKey.Group != nil {
@@ -245,6 +263,10 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 			ModelRoutingEnabled:             apiKey.Group.ModelRoutingEnabled,
 			MCPXMLInject:                    apiKey.Group.MCPXMLInject,
 			SupportedModelScopes:            apiKey.Group.SupportedModelScopes,
+			SelectionStrategy:               apiKey.Group.SelectionStrategy,
+			FairShareEnabled:                apiKey.Group.FairShareEnabled,
+			ModelFallback:                   apiKey.Group.ModelFallback,
+			ModelFallbackEnabled:            apiKey.Group.ModelFallbackEnabled,
 		}
 	}
 	return snapshot
@@ -265,12 +287,24 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 		Quota:       snapshot.Quota,
 		QuotaUsed:   snapshot.QuotaUsed,
 		ExpiresAt:   snapshot.ExpiresAt,
+
+		DailyLimitUSD:   snapshot.DailyLimitUSD,
+		WeeklyLimitUSD:  snapshot.WeeklyLimitUSD,
+		MonthlyLimitUSD: snapshot.MonthlyLimitUSD,
+
+		SecretGeneration: snapshot.SecretGeneration,
+		SecretExpiresAt:  snapshot.SecretExpiresAt,
+		AllowedTagKeys:   snapshot.AllowedTagKeys,
+
+		OrganizationID:      snapshot.OrganizationID,
+		OrganizationOwnerID: snapshot.OrganizationOwnerID,
 		User: &User{
-			ID:          snapshot.User.ID,
-			Status:      snapshot.User.Status,
-			Role:        snapshot.User.Role,
-			Balance:     snapshot.User.Balance,
-			Concurrency: snapshot.User.Concurrency,
+			ID:             snapshot.User.ID,
+			Status:         snapshot.User.Status,
+			Role:           snapshot.User.Role,
+			Balance:        snapshot.User.Balance,
+			Concurrency:    snapshot.User.Concurrency,
+			AllowedTagKeys: snapshot.User.AllowedTagKeys,
 		},
 	}
 	if snapshot.Group != nil {
@@ -295,6 +329,10 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 			ModelRoutingEnabled:             snapshot.Group.ModelRoutingEnabled,
 			MCPXMLInject:                    snapshot.Group.MCPXMLInject,
 			SupportedModelScopes:            snapshot.Group.SupportedModelScopes,
+			SelectionStrategy:               snapshot.Group.SelectionStrategy,
+			FairShareEnabled:                snapshot.Group.FairShareEnabled,
+			ModelFallback:                   snapshot.Group.ModelFallback,
+			ModelFallbackEnabled:            snapshot.Group.ModelFallbackEnabled,
 		}
 	}
 	return apiKey
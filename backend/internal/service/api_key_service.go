@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
@@ -27,11 +28,16 @@ var (
 	// ErrAPIKeyExpired        = infraerrors.Forbidden("API_KEY_EXPIRED", "api key has expired")
 	ErrAPIKeyExpired = infraerrors.Forbidden("API_KEY_EXPIRED", "api key 已过期")
 	// ErrAPIKeyQuotaExhausted = infraerrors.TooManyRequests("API_KEY_QUOTA_EXHAUSTED", "api key quota exhausted")
-	ErrAPIKeyQuotaExhausted = infraerrors.TooManyRequests("API_KEY_QUOTA_EXHAUSTED", "api key 额度已用完")
+	ErrAPIKeyQuotaExhausted             = infraerrors.TooManyRequests("API_KEY_QUOTA_EXHAUSTED", "api key 额度已用完")
+	ErrAPIKeyRotationGracePeriodInvalid = infraerrors.BadRequest("API_KEY_ROTATION_GRACE_INVALID", "grace period must be between 0 and 720 hours")
 )
 
 const (
 	apiKeyMaxErrorsPerHour = 20
+
+	// 轮换宽限期（旧密钥在此期间仍可使用）
+	DefaultAPIKeyRotationGracePeriod = 24 * time.Hour
+	MaxAPIKeyRotationGracePeriod     = 30 * 24 * time.Hour
 )
 
 type APIKeyRepository interface {
@@ -58,6 +64,10 @@ type APIKeyRepository interface {
 
 	// Quota methods
 	IncrementQuotaUsed(ctx context.Context, id int64, amount float64) (float64, error)
+
+	// Rotate 将 expectedKey 替换为 newKey；previousExpiresAt 非空时旧密钥在该时间前仍可认证，
+	// 为空时旧密钥立即失效。当前密钥已不是 expectedKey（并发轮换）时返回 ErrAPIKeyNotFound
+	Rotate(ctx context.Context, id int64, expectedKey, newKey string, previousExpiresAt *time.Time, rotatedAt time.Time) error
 }
 
 // APIKeyCache defines cache operations for API key service
@@ -96,6 +106,17 @@ type CreateAPIKeyRequest struct {
 	// Quota fields
 	Quota         float64 `json:"quota"`           // Quota limit in USD (0 = unlimited)
 	ExpiresInDays *int    `json:"expires_in_days"` // Days until expiry (nil = never expires)
+
+	// Rolling spend limits in USD (nil or 0 = unlimited)
+	DailyLimitUSD   *float64 `json:"daily_limit_usd"`
+	WeeklyLimitUSD  *float64 `json:"weekly_limit_usd"`
+	MonthlyLimitUSD *float64 `json:"monthly_limit_usd"`
+
+	// AllowedTagKeys 成本归属标签键白名单（为空时使用用户级配置）
+	AllowedTagKeys []string `json:"allowed_tag_keys"`
+
+	// OrganizationID 在组织下创建 Key（需为组织成员），创建后不可修改
+	OrganizationID *int64 `json:"organization_id"`
 }
 
 // UpdateAPIKeyRequest 更新API Key请求
@@ -111,6 +132,26 @@ type UpdateAPIKeyRequest struct {
 	ExpiresAt       *time.Time `json:"expires_at"`  // Expiration time (nil = no change)
 	ClearExpiration bool       `json:"-"`           // Clear expiration (internal use)
 	ResetQuota      *bool      `json:"reset_quota"` // Reset quota_used to 0
+
+	// Rolling spend limits in USD (nil = no change, 0 = unlimited)
+	DailyLimitUSD   *float64 `json:"daily_limit_usd"`
+	WeeklyLimitUSD  *float64 `json:"weekly_limit_usd"`
+	MonthlyLimitUSD *float64 `json:"monthly_limit_usd"`
+
+	// AllowedTagKeys 成本归属标签键白名单（nil 不修改，空数组清空）
+	AllowedTagKeys []string `json:"allowed_tag_keys"`
+}
+
+// normalizeSpendLimit 0 表示不限制（存储为 NULL），负数非法
+func normalizeSpendLimit(limit *float64) (*float64, error) {
+	if limit == nil || *limit == 0 {
+		return nil, nil
+	}
+	if err := validateAPIKeySpendLimits(limit); err != nil {
+		return nil, err
+	}
+	v := *limit
+	return &v, nil
 }
 
 // APIKeyService API Key服务
@@ -122,6 +163,7 @@ type APIKeyService struct {
 	userGroupRateRepo UserGroupRateRepository
 	cache             APIKeyCache
 	cfg               *config.Config
+	orgRepo           OrganizationRepository
 	authCacheL1       *ristretto.Cache
 	authCfg           apiKeyAuthCacheConfig
 	authGroup         singleflight.Group
@@ -150,6 +192,11 @@ func NewAPIKeyService(
 	return svc
 }
 
+// SetOrganizationRepository 注入组织仓储（组织 Key 创建校验与认证时加载组织所有者）
+func (s *APIKeyService) SetOrganizationRepository(repo OrganizationRepository) {
+	s.orgRepo = repo
+}
+
 // GenerateKey 生成随机API Key
 func (s *APIKeyService) GenerateKey() (string, error) {
 	// 生成32字节随机数据
@@ -252,6 +299,30 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		}
 	}
 
+	allowedTagKeys, err := NormalizeAllowedTagKeys(req.AllowedTagKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.OrganizationID != nil {
+		if err := s.validateOrganizationMember(ctx, *req.OrganizationID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	dailyLimit, err := normalizeSpendLimit(req.DailyLimitUSD)
+	if err != nil {
+		return nil, err
+	}
+	weeklyLimit, err := normalizeSpendLimit(req.WeeklyLimitUSD)
+	if err != nil {
+		return nil, err
+	}
+	monthlyLimit, err := normalizeSpendLimit(req.MonthlyLimitUSD)
+	if err != nil {
+		return nil, err
+	}
+
 	// 验证分组权限（如果指定了分组）
 	if req.GroupID != nil {
 		group, err := s.groupRepo.GetByID(ctx, *req.GroupID)
@@ -311,6 +382,13 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		IPBlacklist: req.IPBlacklist,
 		Quota:       req.Quota,
 		QuotaUsed:   0,
+
+		DailyLimitUSD:   dailyLimit,
+		WeeklyLimitUSD:  weeklyLimit,
+		MonthlyLimitUSD: monthlyLimit,
+
+		AllowedTagKeys: allowedTagKeys,
+		OrganizationID: req.OrganizationID,
 	}
 
 	// Set expiration time if specified
@@ -328,6 +406,37 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 	return apiKey, nil
 }
 
+// validateOrganizationMember 校验组织可用且用户为成员；非成员统一返回组织不存在
+func (s *APIKeyService) validateOrganizationMember(ctx context.Context, orgID, userID int64) error {
+	if s.orgRepo == nil {
+		return ErrOrganizationNotFound
+	}
+	state, err := s.orgRepo.GetBillingState(ctx, orgID, userID, timezone.StartOfMonth(time.Now()))
+	if err != nil {
+		if errors.Is(err, ErrOrganizationMemberNotFound) {
+			return ErrOrganizationNotFound
+		}
+		return fmt.Errorf("get organization member: %w", err)
+	}
+	if state.Status != StatusActive {
+		return ErrOrganizationDisabled
+	}
+	return nil
+}
+
+// attachOrganizationOwner 认证路径加载组织所有者（订阅类型分组使用其订阅）
+func (s *APIKeyService) attachOrganizationOwner(ctx context.Context, apiKey *APIKey) error {
+	if apiKey.OrganizationID == nil || s.orgRepo == nil {
+		return nil
+	}
+	org, err := s.orgRepo.GetByID(ctx, *apiKey.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("get organization: %w", err)
+	}
+	apiKey.OrganizationOwnerID = org.OwnerUserID
+	return nil
+}
+
 // List 获取用户的API Key列表
 func (s *APIKeyService) List(ctx context.Context, userID int64, params pagination.PaginationParams) ([]APIKey, *pagination.PaginationResult, error) {
 	keys, pagination, err := s.apiKeyRepo.ListByUserID(ctx, userID, params)
@@ -402,6 +511,9 @@ func (s *APIKeyService) GetByKey(ctx context.Context, key string) (*APIKey, erro
 	if err != nil {
 		return nil, fmt.Errorf("get api key: %w", err)
 	}
+	if err := s.attachOrganizationOwner(ctx, apiKey); err != nil {
+		return nil, err
+	}
 	apiKey.Key = key
 	return apiKey, nil
 }
@@ -493,6 +605,33 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 		}
 	}
 
+	// 滚动消费上限（0 清除）
+	for _, l := range []struct {
+		req    *float64
+		target **float64
+	}{
+		{req.DailyLimitUSD, &apiKey.DailyLimitUSD},
+		{req.WeeklyLimitUSD, &apiKey.WeeklyLimitUSD},
+		{req.MonthlyLimitUSD, &apiKey.MonthlyLimitUSD},
+	} {
+		if l.req == nil {
+			continue
+		}
+		limit, err := normalizeSpendLimit(l.req)
+		if err != nil {
+			return nil, err
+		}
+		*l.target = limit
+	}
+
+	if req.AllowedTagKeys != nil {
+		allowedTagKeys, err := NormalizeAllowedTagKeys(req.AllowedTagKeys)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.AllowedTagKeys = allowedTagKeys
+	}
+
 	// 更新 IP 限制（空数组会清空设置）
 	apiKey.IPWhitelist = req.IPWhitelist
 	apiKey.IPBlacklist = req.IPBlacklist
@@ -502,19 +641,72 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 	}
 
 	s.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+	if apiKey.PreviousKey != nil {
+		s.InvalidateAuthCacheByKey(ctx, *apiKey.PreviousKey)
+	}
+
+	return apiKey, nil
+}
+
+// Rotate 轮换 API Key 密钥：同一条记录签发新密钥，分组、额度、限制与使用记录保持不变。
+// 旧密钥在 gracePeriod 内仍可认证，gracePeriod 为 0 时立即失效；
+// 再次轮换时，更早的旧密钥直接失效（同一时间最多两个有效密钥）。
+func (s *APIKeyService) Rotate(ctx context.Context, id int64, userID int64, gracePeriod time.Duration) (*APIKey, error) {
+	if gracePeriod < 0 || gracePeriod > MaxAPIKeyRotationGracePeriod {
+		return nil, ErrAPIKeyRotationGracePeriodInvalid
+	}
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+	if apiKey.UserID != userID {
+		return nil, ErrInsufficientPerms
+	}
+
+	newKey, err := s.GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	now := time.Now()
+	var previousExpiresAt *time.Time
+	if gracePeriod > 0 {
+		expiresAt := now.Add(gracePeriod)
+		previousExpiresAt = &expiresAt
+	}
+	if err := s.apiKeyRepo.Rotate(ctx, id, apiKey.Key, newKey, previousExpiresAt, now); err != nil {
+		return nil, fmt.Errorf("rotate api key: %w", err)
+	}
+
+	// 旧密钥的缓存快照需要带上失效时间；更早的旧密钥与新密钥的负缓存也一并清除
+	s.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+	if apiKey.PreviousKey != nil {
+		s.InvalidateAuthCacheByKey(ctx, *apiKey.PreviousKey)
+	}
+	s.InvalidateAuthCacheByKey(ctx, newKey)
 
+	oldKey := apiKey.Key
+	apiKey.Key = newKey
+	apiKey.KeyGeneration++
+	apiKey.RotatedAt = &now
+	apiKey.UpdatedAt = now
+	apiKey.PreviousKey = nil
+	apiKey.PreviousKeyExpiresAt = previousExpiresAt
+	if previousExpiresAt != nil {
+		apiKey.PreviousKey = &oldKey
+	}
 	return apiKey, nil
 }
 
 // Delete 删除API Key
 func (s *APIKeyService) Delete(ctx context.Context, id int64, userID int64) error {
-	key, ownerID, err := s.apiKeyRepo.GetKeyAndOwnerID(ctx, id)
+	// 需要完整记录：轮换宽限期内的旧 Key 也有认证缓存，删除时一并清除
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("get api key: %w", err)
 	}
 
 	// 验证当前用户是否为该 API Key 的所有者
-	if ownerID != userID {
+	if apiKey.UserID != userID {
 		return ErrInsufficientPerms
 	}
 
@@ -522,7 +714,10 @@ func (s *APIKeyService) Delete(ctx context.Context, id int64, userID int64) erro
 	if s.cache != nil {
 		_ = s.cache.DeleteCreateAttemptCount(ctx, userID)
 	}
-	s.InvalidateAuthCacheByKey(ctx, key)
+	s.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+	if apiKey.PreviousKey != nil {
+		s.InvalidateAuthCacheByKey(ctx, *apiKey.PreviousKey)
+	}
 
 	if err := s.apiKeyRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("delete api key: %w", err)
@@ -17,6 +17,8 @@ import (
 )
 
 type authRepoStub struct {
+	getByID           func(ctx context.Context, id int64) (*APIKey, error)
+	rotate            func(ctx context.Context, id int64, expectedKey, newKey string, previousExpiresAt *time.Time, rotatedAt time.Time) error
 	getByKeyForAuth   func(ctx context.Context, key string) (*APIKey, error)
 	listKeysByUserID  func(ctx context.Context, userID int64) ([]string, error)
 	listKeysByGroupID func(ctx context.Context, groupID int64) ([]string, error)
@@ -27,7 +29,10 @@ func (s *authRepoStub) Create(ctx context.Context, key *APIKey) error {
 }
 
 func (s *authRepoStub) GetByID(ctx context.Context, id int64) (*APIKey, error) {
-	panic("unexpected GetByID call")
+	if s.getByID == nil {
+		panic("unexpected GetByID call")
+	}
+	return s.getByID(ctx, id)
 }
 
 func (s *authRepoStub) GetKeyAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
@@ -103,6 +108,13 @@ func (s *authRepoStub) IncrementQuotaUsed(ctx context.Context, id int64, amount
 	panic("unexpected IncrementQuotaUsed call")
 }
 
+func (s *authRepoStub) Rotate(ctx context.Context, id int64, expectedKey, newKey string, previousExpiresAt *time.Time, rotatedAt time.Time) error {
+	if s.rotate == nil {
+		panic("unexpected Rotate call")
+	}
+	return s.rotate(ctx, id, expectedKey, newKey, previousExpiresAt, rotatedAt)
+}
+
 type authCacheStub struct {
 	getAuthCache   func(ctx context.Context, key string) (*APIKeyAuthCacheEntry, error)
 	setAuthKeys    []string
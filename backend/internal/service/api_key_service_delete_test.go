@@ -20,12 +20,12 @@ import (
 // 用于隔离测试 APIKeyService.Delete 方法，避免依赖真实数据库。
 //
 // 设计说明：
-//   - apiKey/getByIDErr: 模拟 GetKeyAndOwnerID 返回的记录与错误
+//   - apiKey/getByIDErr: 模拟 GetByID 返回的记录与错误
 //   - deleteErr: 模拟 Delete 返回的错误
 //   - deletedIDs: 记录被调用删除的 API Key ID，用于断言验证
 type apiKeyRepoStub struct {
-	apiKey     *APIKey // GetKeyAndOwnerID 的返回值
-	getByIDErr error   // GetKeyAndOwnerID 的错误返回值
+	apiKey     *APIKey // GetByID 的返回值
+	getByIDErr error   // GetByID 的错误返回值
 	deleteErr  error   // Delete 的错误返回值
 	deletedIDs []int64 // 记录已删除的 API Key ID 列表
 }
@@ -122,6 +122,10 @@ func (s *apiKeyRepoStub) IncrementQuotaUsed(ctx context.Context, id int64, amoun
 	panic("unexpected IncrementQuotaUsed call")
 }
 
+func (s *apiKeyRepoStub) Rotate(ctx context.Context, id int64, expectedKey, newKey string, previousExpiresAt *time.Time, rotatedAt time.Time) error {
+	panic("unexpected Rotate call")
+}
+
 // apiKeyCacheStub 是 APIKeyCache 接口的测试桩实现。
 // 用于验证删除操作时缓存清理逻辑是否被正确调用。
 //
@@ -182,7 +186,7 @@ func (s *apiKeyCacheStub) SubscribeAuthCacheInvalidation(ctx context.Context, ha
 
 // TestApiKeyService_Delete_OwnerMismatch 测试非所有者尝试删除时返回权限错误。
 // 预期行为：
-//   - GetKeyAndOwnerID 返回所有者 ID 为 1
+//   - GetByID 返回所有者 ID 为 1
 //   - 调用者 userID 为 2（不匹配）
 //   - 返回 ErrInsufficientPerms 错误
 //   - Delete 方法不被调用
@@ -203,7 +207,7 @@ func TestApiKeyService_Delete_OwnerMismatch(t *testing.T) {
 
 // TestApiKeyService_Delete_Success 测试所有者成功删除 API Key 的场景。
 // 预期行为：
-//   - GetKeyAndOwnerID 返回所有者 ID 为 7
+//   - GetByID 返回所有者 ID 为 7
 //   - 调用者 userID 为 7（匹配）
 //   - Delete 成功执行
 //   - 缓存被正确清除（使用 ownerID）
@@ -222,9 +226,23 @@ func TestApiKeyService_Delete_Success(t *testing.T) {
 	require.Equal(t, []string{svc.authCacheKey("k")}, cache.deleteAuthKeys)
 }
 
+// TestApiKeyService_Delete_InvalidatesPreviousKey 测试删除轮换宽限期内的 API Key 时，
+// 旧 Key 的认证缓存也被清除，避免旧 Key 在缓存过期前继续可用。
+func TestApiKeyService_Delete_InvalidatesPreviousKey(t *testing.T) {
+	previous := "k-old"
+	repo := &apiKeyRepoStub{
+		apiKey: &APIKey{ID: 42, UserID: 7, Key: "k", PreviousKey: &previous},
+	}
+	cache := &apiKeyCacheStub{}
+	svc := &APIKeyService{apiKeyRepo: repo, cache: cache}
+
+	require.NoError(t, svc.Delete(context.Background(), 42, 7))
+	require.Equal(t, []string{svc.authCacheKey("k"), svc.authCacheKey("k-old")}, cache.deleteAuthKeys)
+}
+
 // TestApiKeyService_Delete_NotFound 测试删除不存在的 API Key 时返回正确的错误。
 // 预期行为：
-//   - GetKeyAndOwnerID 返回 ErrAPIKeyNotFound 错误
+//   - GetByID 返回 ErrAPIKeyNotFound 错误
 //   - 返回 ErrAPIKeyNotFound 错误（被 fmt.Errorf 包装）
 //   - Delete 方法不被调用
 //   - 缓存不被清除
@@ -242,7 +260,7 @@ func TestApiKeyService_Delete_NotFound(t *testing.T) {
 
 // TestApiKeyService_Delete_DeleteFails 测试删除操作失败时的错误处理。
 // 预期行为：
-//   - GetKeyAndOwnerID 返回正确的所有者 ID
+//   - GetByID 返回正确的所有者 ID
 //   - 所有权验证通过
 //   - 缓存被清除（在删除之前）
 //   - Delete 被调用但返回错误
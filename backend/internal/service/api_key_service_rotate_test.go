@@ -0,0 +1,135 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newRotateTestService(repo *authRepoStub, cache *authCacheStub) *APIKeyService {
+	cfg := &config.Config{
+		Default: config.DefaultConfig{APIKeyPrefix: "sk-"},
+		APIKeyAuth: config.APIKeyAuthCacheConfig{
+			L2TTLSeconds:       60,
+			NegativeTTLSeconds: 30,
+		},
+	}
+	return NewAPIKeyService(repo, nil, nil, nil, nil, cache, cfg)
+}
+
+func TestAPIKeyService_Rotate_KeepsPreviousKeyDuringGracePeriod(t *testing.T) {
+	older := "sk-older"
+	var gotExpected, gotNew string
+	var gotExpiresAt *time.Time
+	repo := &authRepoStub{
+		getByID: func(ctx context.Context, id int64) (*APIKey, error) {
+			return &APIKey{ID: id, UserID: 7, Key: "sk-current", KeyGeneration: 2, PreviousKey: &older, Status: StatusActive}, nil
+		},
+		rotate: func(ctx context.Context, id int64, expectedKey, newKey string, previousExpiresAt *time.Time, rotatedAt time.Time) error {
+			gotExpected, gotNew, gotExpiresAt = expectedKey, newKey, previousExpiresAt
+			return nil
+		},
+	}
+	cache := &authCacheStub{}
+	svc := newRotateTestService(repo, cache)
+
+	rotated, err := svc.Rotate(context.Background(), 3, 7, 2*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, "sk-current", gotExpected)
+	require.Equal(t, gotNew, rotated.Key)
+	require.NotEqual(t, "sk-current", rotated.Key)
+	require.Equal(t, 3, rotated.KeyGeneration)
+	require.NotNil(t, gotExpiresAt)
+	require.WithinDuration(t, time.Now().Add(2*time.Hour), *gotExpiresAt, time.Minute)
+	require.Equal(t, "sk-current", *rotated.PreviousKey)
+	require.True(t, rotated.HasActivePreviousKey(time.Now()))
+
+	// 当前密钥、更早的旧密钥与新密钥的认证缓存均被清除
+	require.ElementsMatch(t, []string{
+		svc.authCacheKey("sk-current"),
+		svc.authCacheKey(older),
+		svc.authCacheKey(rotated.Key),
+	}, cache.deleteAuthKeys)
+}
+
+func TestAPIKeyService_Rotate_ZeroGraceRevokesImmediately(t *testing.T) {
+	repo := &authRepoStub{
+		getByID: func(ctx context.Context, id int64) (*APIKey, error) {
+			return &APIKey{ID: id, UserID: 7, Key: "sk-current", KeyGeneration: 1}, nil
+		},
+		rotate: func(ctx context.Context, id int64, expectedKey, newKey string, previousExpiresAt *time.Time, rotatedAt time.Time) error {
+			require.Nil(t, previousExpiresAt)
+			return nil
+		},
+	}
+	svc := newRotateTestService(repo, &authCacheStub{})
+
+	rotated, err := svc.Rotate(context.Background(), 3, 7, 0)
+	require.NoError(t, err)
+	require.Nil(t, rotated.PreviousKey)
+	require.False(t, rotated.HasActivePreviousKey(time.Now()))
+}
+
+func TestAPIKeyService_Rotate_RejectsOtherUsersAndInvalidGrace(t *testing.T) {
+	repo := &authRepoStub{
+		getByID: func(ctx context.Context, id int64) (*APIKey, error) {
+			return &APIKey{ID: id, UserID: 7, Key: "sk-current"}, nil
+		},
+	}
+	svc := newRotateTestService(repo, &authCacheStub{})
+
+	_, err := svc.Rotate(context.Background(), 3, 8, time.Hour)
+	require.ErrorIs(t, err, ErrInsufficientPerms)
+	_, err = svc.Rotate(context.Background(), 3, 7, MaxAPIKeyRotationGracePeriod+time.Hour)
+	require.ErrorIs(t, err, ErrAPIKeyRotationGracePeriodInvalid)
+	_, err = svc.Rotate(context.Background(), 3, 7, -time.Hour)
+	require.ErrorIs(t, err, ErrAPIKeyRotationGracePeriodInvalid)
+}
+
+func TestAPIKeyService_GetByKey_ExpiredPreviousKeyInCacheIsRejected(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	cache := &authCacheStub{
+		getAuthCache: func(ctx context.Context, key string) (*APIKeyAuthCacheEntry, error) {
+			return &APIKeyAuthCacheEntry{Snapshot: &APIKeyAuthSnapshot{
+				APIKeyID:         1,
+				UserID:           2,
+				Status:           StatusActive,
+				SecretGeneration: 1,
+				SecretExpiresAt:  &expired,
+				User:             APIKeyAuthUserSnapshot{ID: 2, Status: StatusActive},
+			}}, nil
+		},
+	}
+	svc := newRotateTestService(&authRepoStub{}, cache)
+
+	_, err := svc.GetByKey(context.Background(), "sk-old")
+	require.ErrorIs(t, err, ErrAPIKeyNotFound)
+}
+
+func TestAPIKeyService_GetByKey_CarriesSecretGeneration(t *testing.T) {
+	graceEnd := time.Now().Add(time.Hour)
+	repo := &authRepoStub{
+		getByKeyForAuth: func(ctx context.Context, key string) (*APIKey, error) {
+			return &APIKey{
+				ID:               5,
+				UserID:           7,
+				Status:           StatusActive,
+				KeyGeneration:    3,
+				SecretGeneration: 2,
+				SecretExpiresAt:  &graceEnd,
+				User:             &User{ID: 7, Status: StatusActive},
+			}, nil
+		},
+	}
+	svc := newRotateTestService(repo, &authCacheStub{})
+
+	apiKey, err := svc.GetByKey(context.Background(), "sk-old")
+	require.NoError(t, err)
+	require.Equal(t, 2, apiKey.SecretGeneration)
+	require.Equal(t, graceEnd, *apiKey.SecretExpiresAt)
+}
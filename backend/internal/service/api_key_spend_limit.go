@@ -0,0 +1,79 @@
+package service
+
+import (
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+var (
+	ErrAPIKeyDailyLimitExceeded   = infraerrors.TooManyRequests("API_KEY_DAILY_LIMIT_EXCEEDED", "api key daily spend limit exceeded")
+	ErrAPIKeyWeeklyLimitExceeded  = infraerrors.TooManyRequests("API_KEY_WEEKLY_LIMIT_EXCEEDED", "api key weekly spend limit exceeded")
+	ErrAPIKeyMonthlyLimitExceeded = infraerrors.TooManyRequests("API_KEY_MONTHLY_LIMIT_EXCEEDED", "api key monthly spend limit exceeded")
+	ErrAPIKeySpendLimitInvalid    = infraerrors.BadRequest("API_KEY_SPEND_LIMIT_INVALID", "spend limits must be greater than 0")
+)
+
+// APIKeySpendWindows API Key 当前日/周/月窗口内的实际消费（USD，按 actual_cost 统计）
+type APIKeySpendWindows struct {
+	Daily   float64 `json:"daily_usage_usd"`
+	Weekly  float64 `json:"weekly_usage_usd"`
+	Monthly float64 `json:"monthly_usage_usd"`
+}
+
+// APIKeySpendWindowStarts 窗口起点（按配置时区：自然日、周一起的自然周、自然月）
+type APIKeySpendWindowStarts struct {
+	Day   time.Time
+	Week  time.Time
+	Month time.Time
+}
+
+// apiKeySpendWindowStartsAt 计算 t 所在的窗口起点
+func apiKeySpendWindowStartsAt(t time.Time) APIKeySpendWindowStarts {
+	return APIKeySpendWindowStarts{
+		Day:   timezone.StartOfDay(t),
+		Week:  timezone.StartOfWeek(t),
+		Month: timezone.StartOfMonth(t),
+	}
+}
+
+// apiKeySpendCacheWindow 缓存窗口标识（按日）。同一天内周、月窗口起点也不变，
+// 因此跨日时缓存自然失效并从数据库重新聚合
+func apiKeySpendCacheWindow(starts APIKeySpendWindowStarts) string {
+	return starts.Day.Format("20060102")
+}
+
+// checkAPIKeySpendLimits 检查窗口消费是否已达到上限
+func checkAPIKeySpendLimits(apiKey *APIKey, spend *APIKeySpendWindows) error {
+	if apiKey.DailyLimitUSD != nil && spend.Daily >= *apiKey.DailyLimitUSD {
+		return ErrAPIKeyDailyLimitExceeded
+	}
+	if apiKey.WeeklyLimitUSD != nil && spend.Weekly >= *apiKey.WeeklyLimitUSD {
+		return ErrAPIKeyWeeklyLimitExceeded
+	}
+	if apiKey.MonthlyLimitUSD != nil && spend.Monthly >= *apiKey.MonthlyLimitUSD {
+		return ErrAPIKeyMonthlyLimitExceeded
+	}
+	return nil
+}
+
+// IsAPIKeySpendLimitError 是否为 API Key 消费上限错误
+func IsAPIKeySpendLimitError(err error) bool {
+	switch infraerrors.Reason(err) {
+	case infraerrors.Reason(ErrAPIKeyDailyLimitExceeded),
+		infraerrors.Reason(ErrAPIKeyWeeklyLimitExceeded),
+		infraerrors.Reason(ErrAPIKeyMonthlyLimitExceeded):
+		return true
+	}
+	return false
+}
+
+// validateAPIKeySpendLimits 上限必须为正数（不限制使用 nil）
+func validateAPIKeySpendLimits(limits ...*float64) error {
+	for _, l := range limits {
+		if l != nil && *l <= 0 {
+			return ErrAPIKeySpendLimitInvalid
+		}
+	}
+	return nil
+}
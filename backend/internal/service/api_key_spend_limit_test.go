@@ -0,0 +1,109 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/stretchr/testify/require"
+)
+
+type spendBillingCacheStub struct {
+	billingCacheWorkerStub
+	balance float64
+	spend   map[string]*APIKeySpendWindows
+}
+
+func (s *spendBillingCacheStub) GetUserBalance(ctx context.Context, userID int64) (float64, error) {
+	return s.balance, nil
+}
+
+func (s *spendBillingCacheStub) GetAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) (*APIKeySpendWindows, error) {
+	if data, ok := s.spend[window]; ok {
+		return data, nil
+	}
+	return nil, ErrAPIKeyNotFound
+}
+
+type spendUsageLogRepoStub struct {
+	UsageLogRepository
+	calls  int
+	starts APIKeySpendWindowStarts
+	spend  *APIKeySpendWindows
+}
+
+func (s *spendUsageLogRepoStub) GetAPIKeySpendWindows(ctx context.Context, apiKeyID int64, starts APIKeySpendWindowStarts) (*APIKeySpendWindows, error) {
+	s.calls++
+	s.starts = starts
+	return s.spend, nil
+}
+
+func TestCheckBillingEligibility_APIKeySpendLimits(t *testing.T) {
+	window := apiKeySpendCacheWindow(apiKeySpendWindowStartsAt(time.Now()))
+	cache := &spendBillingCacheStub{
+		balance: 100,
+		spend:   map[string]*APIKeySpendWindows{window: {Daily: 5, Weekly: 30, Monthly: 90}},
+	}
+	svc := NewBillingCacheService(cache, nil, nil, nil, &config.Config{})
+	t.Cleanup(svc.Stop)
+	ctx := context.Background()
+	user := &User{ID: 1}
+
+	require.NoError(t, svc.CheckBillingEligibility(ctx, user, &APIKey{ID: 9}, nil, nil))
+	require.NoError(t, svc.CheckBillingEligibility(ctx, user, &APIKey{ID: 9, DailyLimitUSD: float64Ptr(10)}, nil, nil))
+
+	err := svc.CheckBillingEligibility(ctx, user, &APIKey{ID: 9, DailyLimitUSD: float64Ptr(5)}, nil, nil)
+	require.ErrorIs(t, err, ErrAPIKeyDailyLimitExceeded)
+	require.True(t, IsAPIKeySpendLimitError(err))
+
+	err = svc.CheckBillingEligibility(ctx, user, &APIKey{ID: 9, WeeklyLimitUSD: float64Ptr(20)}, nil, nil)
+	require.ErrorIs(t, err, ErrAPIKeyWeeklyLimitExceeded)
+	err = svc.CheckBillingEligibility(ctx, user, &APIKey{ID: 9, MonthlyLimitUSD: float64Ptr(90)}, nil, nil)
+	require.ErrorIs(t, err, ErrAPIKeyMonthlyLimitExceeded)
+
+	// 余额不足优先于 Key 上限
+	cache.balance = 0
+	err = svc.CheckBillingEligibility(ctx, user, &APIKey{ID: 9, DailyLimitUSD: float64Ptr(5)}, nil, nil)
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+	require.False(t, IsAPIKeySpendLimitError(err))
+}
+
+func TestBillingCacheService_GetAPIKeySpend_FallsBackToUsageLogs(t *testing.T) {
+	repo := &spendUsageLogRepoStub{spend: &APIKeySpendWindows{Daily: 1, Weekly: 2, Monthly: 3}}
+	svc := NewBillingCacheService(&spendBillingCacheStub{}, nil, nil, repo, &config.Config{})
+	t.Cleanup(svc.Stop)
+
+	spend, err := svc.GetAPIKeySpend(context.Background(), 9)
+	require.NoError(t, err)
+	require.Equal(t, 3.0, spend.Monthly)
+	require.Equal(t, 1, repo.calls)
+
+	require.Equal(t, timezone.Today(), repo.starts.Day)
+	require.Equal(t, time.Monday, repo.starts.Week.Weekday())
+	require.Equal(t, 1, repo.starts.Month.Day())
+}
+
+func TestAPIKeySpendWindowStarts(t *testing.T) {
+	loc := timezone.Location()
+	// 2026-10-18 是周日，周窗口从 10-12（周一）开始
+	starts := apiKeySpendWindowStartsAt(time.Date(2026, 10, 18, 23, 30, 0, 0, loc))
+	require.Equal(t, time.Date(2026, 10, 18, 0, 0, 0, 0, loc), starts.Day)
+	require.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, loc), starts.Week)
+	require.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, loc), starts.Month)
+	require.Equal(t, "20261018", apiKeySpendCacheWindow(starts))
+}
+
+func TestNormalizeSpendLimit(t *testing.T) {
+	v, err := normalizeSpendLimit(float64Ptr(0))
+	require.NoError(t, err)
+	require.Nil(t, v)
+	v, err = normalizeSpendLimit(float64Ptr(20))
+	require.NoError(t, err)
+	require.Equal(t, 20.0, *v)
+	_, err = normalizeSpendLimit(float64Ptr(-1))
+	require.ErrorIs(t, err, ErrAPIKeySpendLimitInvalid)
+}
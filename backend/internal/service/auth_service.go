@@ -65,6 +65,9 @@ type AuthService struct {
 	turnstileService  *TurnstileService
 	emailQueueService *EmailQueueService
 	promoService      *PromoService
+	sessionRepo       UserSessionRepository
+	passkeyService    *PasskeyService
+	loginSecurity     *LoginSecurityService
 }
 
 // NewAuthService 创建认证服务实例
@@ -78,6 +81,7 @@ func NewAuthService(
 	turnstileService *TurnstileService,
 	emailQueueService *EmailQueueService,
 	promoService *PromoService,
+	sessionRepo UserSessionRepository,
 ) *AuthService {
 	return &AuthService{
 		userRepo:          userRepo,
@@ -89,9 +93,20 @@ func NewAuthService(
 		turnstileService:  turnstileService,
 		emailQueueService: emailQueueService,
 		promoService:      promoService,
+		sessionRepo:       sessionRepo,
 	}
 }
 
+// SetPasskeyService 注入通行密钥服务，用于拒绝要求通行密钥的角色通过第三方 OAuth 登录
+func (s *AuthService) SetPasskeyService(passkeyService *PasskeyService) {
+	s.passkeyService = passkeyService
+}
+
+// SetLoginSecurityService 注入登录安全服务，第三方 OAuth 登录签发令牌前检查账号锁定
+func (s *AuthService) SetLoginSecurityService(loginSecurity *LoginSecurityService) {
+	s.loginSecurity = loginSecurity
+}
+
 // Register 用户注册，返回token和用户
 func (s *AuthService) Register(ctx context.Context, email, password string) (string, *User, error) {
 	return s.RegisterWithVerification(ctx, email, password, "", "", "")
@@ -579,7 +594,20 @@ func (s *AuthService) LoginOrRegisterOAuthWithTokenPair(ctx context.Context, ema
 		}
 	}
 
-	tokenPair, err := s.GenerateTokenPair(ctx, user, "")
+	// 第三方 OAuth 无法完成通行密钥校验：角色要求通行密钥时拒绝签发令牌，必须走密码 + 第二因素或通行密钥登录
+	if s.passkeyService != nil && s.passkeyService.IsRequiredForUser(ctx, user) {
+		return nil, nil, ErrPasskeyOAuthLoginForbidden
+	}
+	// 回调前无法得知本地账号，锁定检查只能在确定用户后、签发令牌前进行
+	if s.loginSecurity != nil {
+		if err := s.loginSecurity.CheckLockout(ctx, user.Email); err != nil {
+			userID := user.ID
+			s.loginSecurity.RecordFailure(ctx, user.Email, &userID, LoginMethodOAuth, err)
+			return nil, nil, err
+		}
+	}
+
+	tokenPair, err := s.GenerateTokenPair(WithLoginMethod(ctx, LoginMethodOAuth), user, "")
 	if err != nil {
 		return nil, nil, fmt.Errorf("generate token pair: %w", err)
 	}
@@ -641,7 +669,8 @@ func randomHexString(byteLength int) (string, error) {
 
 func isReservedEmail(email string) bool {
 	normalized := strings.ToLower(strings.TrimSpace(email))
-	return strings.HasSuffix(normalized, LinuxDoConnectSyntheticEmailDomain)
+	return strings.HasSuffix(normalized, LinuxDoConnectSyntheticEmailDomain) ||
+		strings.HasSuffix(normalized, OAuthSyntheticEmailDomain)
 }
 
 // GenerateToken 生成JWT access token
@@ -935,7 +964,8 @@ func (s *AuthService) generateRefreshToken(ctx context.Context, user *User, fami
 	tokenHash := hashToken(rawToken)
 
 	// 如果没有提供familyID，生成新的
-	if familyID == "" {
+	newFamily := familyID == ""
+	if newFamily {
 		familyBytes := make([]byte, 16)
 		if _, err := rand.Read(familyBytes); err != nil {
 			return "", fmt.Errorf("generate family id: %w", err)
@@ -971,9 +1001,51 @@ func (s *AuthService) generateRefreshToken(ctx context.Context, user *User, fami
 		// 不影响主流程
 	}
 
+	// 记录登录会话（新家族创建会话，Token 轮转时刷新最近活动）
+	s.recordSession(ctx, user.ID, familyID, newFamily, now, data.ExpiresAt)
+
 	return rawToken, nil
 }
 
+// recordSession 写入或刷新 Token 家族对应的登录会话，失败不影响 Token 签发
+func (s *AuthService) recordSession(ctx context.Context, userID int64, familyID string, newFamily bool, now, expiresAt time.Time) {
+	if s.sessionRepo == nil {
+		return
+	}
+	ip, userAgent := clientInfoFromContext(ctx)
+	if !newFamily {
+		if err := s.sessionRepo.Touch(ctx, familyID, ip, userAgent, now, expiresAt); err != nil {
+			log.Printf("[Auth] Failed to touch session: %v", err)
+		}
+		return
+	}
+	session := &UserSession{
+		UserID:          userID,
+		FamilyID:        familyID,
+		Name:            sessionNameFromUserAgent(userAgent),
+		LoginMethod:     loginMethodFromContext(ctx),
+		IP:              ip,
+		UserAgent:       userAgent,
+		CreatedAt:       now,
+		LastRefreshedAt: now,
+		ExpiresAt:       expiresAt,
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		log.Printf("[Auth] Failed to record session for user %d: %v", userID, err)
+	}
+}
+
+// revokeTokenFamily 删除 Token 家族并将对应会话标记为已撤销
+func (s *AuthService) revokeTokenFamily(ctx context.Context, familyID string) error {
+	err := s.refreshTokenCache.DeleteTokenFamily(ctx, familyID)
+	if s.sessionRepo != nil {
+		if revokeErr := s.sessionRepo.RevokeByFamilyID(ctx, familyID, time.Now()); revokeErr != nil {
+			log.Printf("[Auth] Failed to mark session revoked: %v", revokeErr)
+		}
+	}
+	return err
+}
+
 // RefreshTokenPair 使用Refresh Token刷新Token对
 // 实现Token轮转：每次刷新都会生成新的Refresh Token，旧Token立即失效
 func (s *AuthService) RefreshTokenPair(ctx context.Context, refreshToken string) (*TokenPair, error) {
@@ -1013,7 +1085,7 @@ func (s *AuthService) RefreshTokenPair(ctx context.Context, refreshToken string)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
 			// 用户已删除，撤销整个Token家族
-			_ = s.refreshTokenCache.DeleteTokenFamily(ctx, data.FamilyID)
+			_ = s.revokeTokenFamily(ctx, data.FamilyID)
 			return nil, ErrRefreshTokenInvalid
 		}
 		log.Printf("[Auth] Database error getting user for token refresh: %v", err)
@@ -1023,14 +1095,14 @@ func (s *AuthService) RefreshTokenPair(ctx context.Context, refreshToken string)
 	// 检查用户状态
 	if !user.IsActive() {
 		// 用户被禁用，撤销整个Token家族
-		_ = s.refreshTokenCache.DeleteTokenFamily(ctx, data.FamilyID)
+		_ = s.revokeTokenFamily(ctx, data.FamilyID)
 		return nil, ErrUserNotActive
 	}
 
 	// 检查TokenVersion（密码更改后所有Token失效）
 	if data.TokenVersion != user.TokenVersion {
 		// TokenVersion不匹配，撤销整个Token家族
-		_ = s.refreshTokenCache.DeleteTokenFamily(ctx, data.FamilyID)
+		_ = s.revokeTokenFamily(ctx, data.FamilyID)
 		return nil, ErrTokenRevoked
 	}
 
@@ -1054,6 +1126,10 @@ func (s *AuthService) RevokeRefreshToken(ctx context.Context, refreshToken strin
 	}
 
 	tokenHash := hashToken(refreshToken)
+	// 登出即结束该会话：撤销整个 Token 家族
+	if data, err := s.refreshTokenCache.GetRefreshToken(ctx, tokenHash); err == nil && data.FamilyID != "" {
+		return s.revokeTokenFamily(ctx, data.FamilyID)
+	}
 	return s.refreshTokenCache.DeleteRefreshToken(ctx, tokenHash)
 }
 
@@ -1063,9 +1139,38 @@ func (s *AuthService) RevokeAllUserSessions(ctx context.Context, userID int64) e
 	if s.refreshTokenCache == nil {
 		return nil // No-op if cache not configured
 	}
+	if s.sessionRepo != nil {
+		if err := s.sessionRepo.RevokeAllByUserID(ctx, userID, time.Now()); err != nil {
+			log.Printf("[Auth] Failed to mark sessions revoked for user %d: %v", userID, err)
+		}
+	}
 	return s.refreshTokenCache.DeleteUserRefreshTokens(ctx, userID)
 }
 
+// ListUserSessions 列出用户的活跃会话
+func (s *AuthService) ListUserSessions(ctx context.Context, userID int64) ([]UserSession, error) {
+	if s.sessionRepo == nil {
+		return []UserSession{}, nil
+	}
+	return s.sessionRepo.ListActiveByUserID(ctx, userID, time.Now())
+}
+
+// RevokeUserSession 撤销用户的单个会话（该设备需重新登录）
+// 已签发的 Access Token 在过期前仍然有效
+func (s *AuthService) RevokeUserSession(ctx context.Context, userID, sessionID int64) error {
+	if s.sessionRepo == nil || s.refreshTokenCache == nil {
+		return ErrUserSessionNotFound
+	}
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID || session.RevokedAt != nil {
+		return ErrUserSessionNotFound
+	}
+	return s.revokeTokenFamily(ctx, session.FamilyID)
+}
+
 // hashToken 计算Token的SHA256哈希
 func hashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
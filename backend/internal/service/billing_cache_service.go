@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
 )
 
 // 错误定义
@@ -38,6 +40,9 @@ const (
 	cacheWriteSetSubscription
 	cacheWriteUpdateSubscriptionUsage
 	cacheWriteDeductBalance
+	cacheWriteSetAPIKeySpend
+	cacheWriteUpdateAPIKeySpend
+	cacheWriteSetOrganizationBilling
 )
 
 // 异步缓存写入工作池配置
@@ -68,14 +73,22 @@ type cacheWriteTask struct {
 	balance          float64
 	amount           float64
 	subscriptionData *subscriptionCacheData
+	apiKeyID         int64
+	spendWindow      string
+	spendData        *APIKeySpendWindows
+	orgID            int64
+	orgState         *OrganizationBillingState
 }
 
 // BillingCacheService 计费缓存服务
-// 负责余额和订阅数据的缓存管理，提供高性能的计费资格检查
+// 负责余额、订阅与 API Key 窗口消费的缓存管理，提供高性能的计费资格检查
 type BillingCacheService struct {
 	cache          BillingCache
 	userRepo       UserRepository
 	subRepo        UserSubscriptionRepository
+	usageLogRepo   UsageLogRepository
+	orgRepo        OrganizationRepository
+	referral       *ReferralService
 	cfg            *config.Config
 	circuitBreaker *billingCircuitBreaker
 
@@ -90,18 +103,29 @@ type BillingCacheService struct {
 }
 
 // NewBillingCacheService 创建计费缓存服务
-func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, cfg *config.Config) *BillingCacheService {
+func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, usageLogRepo UsageLogRepository, cfg *config.Config) *BillingCacheService {
 	svc := &BillingCacheService{
-		cache:    cache,
-		userRepo: userRepo,
-		subRepo:  subRepo,
-		cfg:      cfg,
+		cache:        cache,
+		userRepo:     userRepo,
+		subRepo:      subRepo,
+		usageLogRepo: usageLogRepo,
+		cfg:          cfg,
 	}
 	svc.circuitBreaker = newBillingCircuitBreaker(cfg.Billing.CircuitBreaker)
 	svc.startCacheWriteWorkers()
 	return svc
 }
 
+// SetOrganizationRepository 注入组织仓储（组织 Key 的资格检查与扣费）
+func (s *BillingCacheService) SetOrganizationRepository(repo OrganizationRepository) {
+	s.orgRepo = repo
+}
+
+// SetReferralService 注入推荐计划服务（被推荐人余额消费返佣）
+func (s *BillingCacheService) SetReferralService(referral *ReferralService) {
+	s.referral = referral
+}
+
 // Stop 关闭缓存写入工作池
 func (s *BillingCacheService) Stop() {
 	s.cacheWriteStopOnce.Do(func() {
@@ -165,6 +189,16 @@ func (s *BillingCacheService) cacheWriteWorker() {
 					log.Printf("Warning: deduct balance cache failed for user %d: %v", task.userID, err)
 				}
 			}
+		case cacheWriteSetAPIKeySpend:
+			s.setAPIKeySpendCache(ctx, task.apiKeyID, task.spendWindow, task.spendData)
+		case cacheWriteUpdateAPIKeySpend:
+			if s.cache != nil {
+				if err := s.cache.UpdateAPIKeySpend(ctx, task.apiKeyID, task.spendWindow, task.amount); err != nil {
+					log.Printf("Warning: update api key spend cache failed for api key %d: %v", task.apiKeyID, err)
+				}
+			}
+		case cacheWriteSetOrganizationBilling:
+			s.setOrganizationBillingCache(ctx, task.orgID, task.userID, task.spendWindow, task.orgState)
 		}
 		cancel()
 	}
@@ -181,6 +215,12 @@ func cacheWriteKindName(kind cacheWriteKind) string {
 		return "update_subscription_usage"
 	case cacheWriteDeductBalance:
 		return "deduct_balance"
+	case cacheWriteSetAPIKeySpend:
+		return "set_api_key_spend"
+	case cacheWriteUpdateAPIKeySpend:
+		return "update_api_key_spend"
+	case cacheWriteSetOrganizationBilling:
+		return "set_organization_billing"
 	default:
 		return "unknown"
 	}
@@ -441,6 +481,80 @@ func (s *BillingCacheService) InvalidateSubscription(ctx context.Context, userID
 	return nil
 }
 
+// ============================================
+// API Key 窗口消费缓存方法
+// ============================================
+
+// GetAPIKeySpend 获取 API Key 当前日/周/月窗口消费（优先从缓存读取）
+func (s *BillingCacheService) GetAPIKeySpend(ctx context.Context, apiKeyID int64) (*APIKeySpendWindows, error) {
+	starts := apiKeySpendWindowStartsAt(time.Now())
+	window := apiKeySpendCacheWindow(starts)
+	if s.cache != nil {
+		if data, err := s.cache.GetAPIKeySpendCache(ctx, apiKeyID, window); err == nil && data != nil {
+			return data, nil
+		}
+	}
+	if s.usageLogRepo == nil {
+		return &APIKeySpendWindows{}, nil
+	}
+	data, err := s.usageLogRepo.GetAPIKeySpendWindows(ctx, apiKeyID, starts)
+	if err != nil {
+		return nil, fmt.Errorf("get api key spend: %w", err)
+	}
+	if s.cache != nil {
+		_ = s.enqueueCacheWrite(cacheWriteTask{
+			kind:        cacheWriteSetAPIKeySpend,
+			apiKeyID:    apiKeyID,
+			spendWindow: window,
+			spendData:   data,
+		})
+	}
+	return data, nil
+}
+
+// InvalidateAPIKeySpend 失效 API Key 当前窗口消费缓存，下次读取从数据库重建
+func (s *BillingCacheService) InvalidateAPIKeySpend(ctx context.Context, apiKeyID int64) error {
+	if s.cache == nil {
+		return nil
+	}
+	window := apiKeySpendCacheWindow(apiKeySpendWindowStartsAt(time.Now()))
+	if err := s.cache.InvalidateAPIKeySpendCache(ctx, apiKeyID, window); err != nil {
+		log.Printf("Warning: invalidate api key spend cache failed for api key %d: %v", apiKeyID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *BillingCacheService) setAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string, data *APIKeySpendWindows) {
+	if s.cache == nil || data == nil {
+		return
+	}
+	if err := s.cache.SetAPIKeySpendCache(ctx, apiKeyID, window, data); err != nil {
+		log.Printf("Warning: set api key spend cache failed for api key %d: %v", apiKeyID, err)
+	}
+}
+
+// QueueUpdateAPIKeySpend 异步累加 API Key 窗口消费缓存（缓存不存在时由下次读取重建）
+func (s *BillingCacheService) QueueUpdateAPIKeySpend(apiKeyID int64, cost float64) {
+	if s.cache == nil || cost <= 0 {
+		return
+	}
+	window := apiKeySpendCacheWindow(apiKeySpendWindowStartsAt(time.Now()))
+	if s.enqueueCacheWrite(cacheWriteTask{
+		kind:        cacheWriteUpdateAPIKeySpend,
+		apiKeyID:    apiKeyID,
+		spendWindow: window,
+		amount:      cost,
+	}) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
+	defer cancel()
+	if err := s.cache.UpdateAPIKeySpend(ctx, apiKeyID, window, cost); err != nil {
+		log.Printf("Warning: update api key spend cache fallback failed for api key %d: %v", apiKeyID, err)
+	}
+}
+
 // ============================================
 // 统一检查方法
 // ============================================
@@ -448,6 +562,8 @@ func (s *BillingCacheService) InvalidateSubscription(ctx context.Context, userID
 // CheckBillingEligibility 检查用户是否有资格发起请求
 // 余额模式：检查缓存余额 > 0
 // 订阅模式：检查缓存用量未超过限额（Group限额从参数传入）
+// 两种模式下均检查 API Key 自身的日/周/月消费上限
+// 组织 Key：余额模式检查组织余额，订阅模式使用组织所有者的订阅，并检查成员月度消费上限
 func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user *User, apiKey *APIKey, group *Group, subscription *UserSubscription) error {
 	// 简易模式：跳过所有计费检查
 	if s.cfg.RunMode == config.RunModeSimple {
@@ -459,12 +575,177 @@ func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user
 
 	// 判断计费模式
 	isSubscriptionMode := group != nil && group.IsSubscriptionType() && subscription != nil
+	isOrganizationKey := apiKey != nil && apiKey.OrganizationID != nil
 
 	if isSubscriptionMode {
-		return s.checkSubscriptionEligibility(ctx, user.ID, group, subscription)
+		if err := s.checkSubscriptionEligibility(ctx, subscriptionUserID(user, apiKey), group, subscription); err != nil {
+			return err
+		}
+	} else if !isOrganizationKey {
+		if err := s.checkBalanceEligibility(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	if isOrganizationKey {
+		if err := s.checkOrganizationEligibility(ctx, apiKey, !isSubscriptionMode); err != nil {
+			return err
+		}
+	}
+
+	return s.checkAPIKeySpendEligibility(ctx, apiKey)
+}
+
+// checkOrganizationEligibility 检查组织状态、组织余额（余额模式）与成员月度消费上限
+func (s *BillingCacheService) checkOrganizationEligibility(ctx context.Context, apiKey *APIKey, checkBalance bool) error {
+	if s.orgRepo == nil {
+		return ErrBillingServiceUnavailable
+	}
+	state, err := s.getOrganizationBillingState(ctx, *apiKey.OrganizationID, apiKey.UserID)
+	if err != nil {
+		if errors.Is(err, ErrOrganizationMemberNotFound) {
+			return ErrOrganizationForbidden
+		}
+		if s.circuitBreaker != nil {
+			s.circuitBreaker.OnFailure(err)
+		}
+		log.Printf("ALERT: billing organization check failed for organization %d: %v", *apiKey.OrganizationID, err)
+		return ErrBillingServiceUnavailable.WithCause(err)
+	}
+	if s.circuitBreaker != nil {
+		s.circuitBreaker.OnSuccess()
 	}
+	return checkOrganizationBillingState(state, checkBalance)
+}
+
+// getOrganizationBillingState 获取组织计费状态（优先从缓存读取）
+func (s *BillingCacheService) getOrganizationBillingState(ctx context.Context, orgID, userID int64) (*OrganizationBillingState, error) {
+	monthStart := timezone.StartOfMonth(time.Now())
+	window := organizationBillingCacheWindow(monthStart)
+	if s.cache != nil {
+		if state, err := s.cache.GetOrganizationBillingCache(ctx, orgID, userID, window); err == nil && state != nil {
+			return state, nil
+		}
+	}
+	state, err := s.orgRepo.GetBillingState(ctx, orgID, userID, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	if s.cache != nil {
+		_ = s.enqueueCacheWrite(cacheWriteTask{
+			kind:        cacheWriteSetOrganizationBilling,
+			orgID:       orgID,
+			userID:      userID,
+			spendWindow: window,
+			orgState:    state,
+		})
+	}
+	return state, nil
+}
+
+func (s *BillingCacheService) setOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string, state *OrganizationBillingState) {
+	if s.cache == nil || state == nil {
+		return
+	}
+	if err := s.cache.SetOrganizationBillingCache(ctx, orgID, userID, window, state); err != nil {
+		log.Printf("Warning: set organization billing cache failed for organization %d user %d: %v", orgID, userID, err)
+	}
+}
+
+// organizationBillingCacheWindow 组织成员用量缓存的月度窗口标识
+func organizationBillingCacheWindow(monthStart time.Time) string {
+	return monthStart.Format("2006-01")
+}
+
+// InvalidateOrganizationBilling 组织状态或余额变更后清除组织计费缓存
+func (s *BillingCacheService) InvalidateOrganizationBilling(ctx context.Context, orgID int64) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.InvalidateOrganizationBillingCache(ctx, orgID); err != nil {
+		log.Printf("Warning: invalidate organization billing cache failed for organization %d: %v", orgID, err)
+	}
+}
+
+// InvalidateOrganizationMemberBilling 成员消费上限变更或被移除后清除成员计费缓存
+func (s *BillingCacheService) InvalidateOrganizationMemberBilling(ctx context.Context, orgID, userID int64) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.InvalidateOrganizationMemberBillingCache(ctx, orgID, userID); err != nil {
+		log.Printf("Warning: invalidate organization member billing cache failed for organization %d user %d: %v", orgID, userID, err)
+	}
+}
+
+func checkOrganizationBillingState(state *OrganizationBillingState, checkBalance bool) error {
+	if state.Status != StatusActive {
+		return ErrOrganizationDisabled
+	}
+	if checkBalance && state.Balance <= 0 {
+		return ErrOrganizationInsufficientBalance
+	}
+	if state.SpendLimitUSD != nil && state.SpendUsedUSD >= *state.SpendLimitUSD {
+		return ErrOrganizationMemberSpendLimitExceeded
+	}
+	return nil
+}
+
+// RecordOrganizationUsage 组织 Key 扣费：累加成员当月用量，余额模式下同时扣除组织余额
+func (s *BillingCacheService) RecordOrganizationUsage(ctx context.Context, apiKey *APIKey, cost float64, deductBalance bool) {
+	if apiKey == nil || apiKey.OrganizationID == nil {
+		return
+	}
+	monthStart := timezone.StartOfMonth(time.Now())
+	if err := s.ChargeOrganizationUsage(ctx, *apiKey.OrganizationID, apiKey.UserID, cost, deductBalance, monthStart); err != nil {
+		log.Printf("Record organization usage failed for organization %d: %v", *apiKey.OrganizationID, err)
+		return
+	}
+	s.UpdateOrganizationUsageCache(ctx, *apiKey.OrganizationID, apiKey.UserID, cost, deductBalance, monthStart)
+}
+
+// ChargeOrganizationUsage 组织扣费的数据库部分（ctx 中有事务时在事务内执行），不更新缓存
+func (s *BillingCacheService) ChargeOrganizationUsage(ctx context.Context, orgID, userID int64, cost float64, deductBalance bool, monthStart time.Time) error {
+	if s.orgRepo == nil || cost <= 0 {
+		return nil
+	}
+	return s.orgRepo.RecordUsage(ctx, orgID, userID, cost, deductBalance, monthStart)
+}
+
+// UpdateOrganizationUsageCache 组织扣费写入数据库后同步更新缓存
+func (s *BillingCacheService) UpdateOrganizationUsageCache(ctx context.Context, orgID, userID int64, cost float64, deductBalance bool, monthStart time.Time) {
+	if s.orgRepo == nil || s.cache == nil || cost <= 0 {
+		return
+	}
+	if err := s.cache.UpdateOrganizationUsage(ctx, orgID, userID, organizationBillingCacheWindow(monthStart), cost, deductBalance); err != nil {
+		log.Printf("Warning: update organization usage cache failed for organization %d: %v", orgID, err)
+	}
+}
+
+// RecordReferralSpend 余额扣费后累计推荐返佣（仅被推荐人在返佣期内生效）
+func (s *BillingCacheService) RecordReferralSpend(ctx context.Context, userID int64, cost float64) {
+	if s.referral == nil {
+		return
+	}
+	s.referral.RecordSpend(ctx, userID, cost)
+}
 
-	return s.checkBalanceEligibility(ctx, user.ID)
+// checkAPIKeySpendEligibility 检查 API Key 日/周/月消费上限
+func (s *BillingCacheService) checkAPIKeySpendEligibility(ctx context.Context, apiKey *APIKey) error {
+	if apiKey == nil || !apiKey.HasSpendLimits() {
+		return nil
+	}
+	spend, err := s.GetAPIKeySpend(ctx, apiKey.ID)
+	if err != nil {
+		if s.circuitBreaker != nil {
+			s.circuitBreaker.OnFailure(err)
+		}
+		log.Printf("ALERT: billing api key spend check failed for api key %d: %v", apiKey.ID, err)
+		return ErrBillingServiceUnavailable.WithCause(err)
+	}
+	if s.circuitBreaker != nil {
+		s.circuitBreaker.OnSuccess()
+	}
+	return checkAPIKeySpendLimits(apiKey, spend)
 }
 
 // checkBalanceEligibility 检查余额模式资格
@@ -52,9 +52,45 @@ func (b *billingCacheWorkerStub) InvalidateSubscriptionCache(ctx context.Context
 	return nil
 }
 
+func (b *billingCacheWorkerStub) GetAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) (*APIKeySpendWindows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (b *billingCacheWorkerStub) SetAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string, data *APIKeySpendWindows) error {
+	return nil
+}
+
+func (b *billingCacheWorkerStub) UpdateAPIKeySpend(ctx context.Context, apiKeyID int64, window string, cost float64) error {
+	return nil
+}
+
+func (b *billingCacheWorkerStub) InvalidateAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) error {
+	return nil
+}
+
+func (b *billingCacheWorkerStub) GetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string) (*OrganizationBillingState, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (b *billingCacheWorkerStub) SetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string, state *OrganizationBillingState) error {
+	return nil
+}
+
+func (b *billingCacheWorkerStub) UpdateOrganizationUsage(ctx context.Context, orgID, userID int64, window string, cost float64, deductBalance bool) error {
+	return nil
+}
+
+func (b *billingCacheWorkerStub) InvalidateOrganizationBillingCache(ctx context.Context, orgID int64) error {
+	return nil
+}
+
+func (b *billingCacheWorkerStub) InvalidateOrganizationMemberBillingCache(ctx context.Context, orgID, userID int64) error {
+	return nil
+}
+
 func TestBillingCacheServiceQueueHighLoad(t *testing.T) {
 	cache := &billingCacheWorkerStub{}
-	svc := NewBillingCacheService(cache, nil, nil, &config.Config{})
+	svc := NewBillingCacheService(cache, nil, nil, nil, &config.Config{})
 	t.Cleanup(svc.Stop)
 
 	start := time.Now()
@@ -23,6 +23,19 @@ type BillingCache interface {
 	SetSubscriptionCache(ctx context.Context, userID, groupID int64, data *SubscriptionCacheData) error
 	UpdateSubscriptionUsage(ctx context.Context, userID, groupID int64, cost float64) error
 	InvalidateSubscriptionCache(ctx context.Context, userID, groupID int64) error
+
+	// API Key spend window operations (window 为按日的窗口标识，跨日自动切换到新缓存)
+	GetAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) (*APIKeySpendWindows, error)
+	SetAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string, data *APIKeySpendWindows) error
+	UpdateAPIKeySpend(ctx context.Context, apiKeyID int64, window string, cost float64) error
+	InvalidateAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) error
+
+	// Organization billing state operations (window 为按月的窗口标识，跨月后成员用量缓存自动失效)
+	GetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string) (*OrganizationBillingState, error)
+	SetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string, state *OrganizationBillingState) error
+	UpdateOrganizationUsage(ctx context.Context, orgID, userID int64, window string, cost float64, deductBalance bool) error
+	InvalidateOrganizationBillingCache(ctx context.Context, orgID int64) error
+	InvalidateOrganizationMemberBillingCache(ctx context.Context, orgID, userID int64) error
 }
 
 // ModelPricing 模型价格配置（per-token价格，与LiteLLM格式一致）
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+)
+
+// fairShareCapacityTTL 分组总并发（账号并发之和）的本地缓存时间
+const fairShareCapacityTTL = 30 * time.Second
+
+// FairShareCache 分组内用户公平分配的 Redis 存储
+// 键格式: fairshare:group:{groupID}:users（活跃用户）、fairshare:group:{groupID}:user:{userID}（用户在途槽位）
+type FairShareCache interface {
+	// GetFairShareDemands 将用户标记为有待处理请求，并返回分组内活跃用户的需求（在途槽位数 + 是否有待处理请求）
+	GetFairShareDemands(ctx context.Context, groupID, userID int64) (map[int64]int, error)
+	// AcquireFairShareSlot 用户在途槽位数未达到 share 时占用一个槽位，并记录用户当前配额
+	AcquireFairShareSlot(ctx context.Context, groupID, userID int64, share int, requestID string) (bool, error)
+	ReleaseFairShareSlot(ctx context.Context, groupID, userID int64, requestID string) error
+	// ListFairShares 返回所有启用公平分配且近期活跃的分组中，各用户的占用与配额
+	ListFairShares(ctx context.Context) ([]UserFairShare, error)
+}
+
+// UserFairShare 用户在某个分组内的公平分配状态
+type UserFairShare struct {
+	GroupID int64 `json:"group_id"`
+	UserID  int64 `json:"user_id"`
+	InUse   int   `json:"in_use"`
+	Share   int   `json:"share"`
+}
+
+type fairShareCapacity struct {
+	capacity  int
+	expiresAt time.Time
+}
+
+// fairShareState 公平分配的可选依赖，未设置时 AcquireAccountSlot 行为不变
+type fairShareState struct {
+	cache       FairShareCache
+	accountRepo AccountRepository
+
+	mu         sync.Mutex
+	capacities map[int64]fairShareCapacity
+}
+
+// SetFairShare 启用分组内用户公平分配（可选依赖）
+func (s *ConcurrencyService) SetFairShare(cache FairShareCache, accountRepo AccountRepository) {
+	if cache == nil || accountRepo == nil {
+		s.fairShare = nil
+		return
+	}
+	s.fairShare = &fairShareState{
+		cache:       cache,
+		accountRepo: accountRepo,
+		capacities:  make(map[int64]fairShareCapacity),
+	}
+}
+
+// ListFairShares 返回当前各分组内用户的公平分配状态（用于运维监控）
+func (s *ConcurrencyService) ListFairShares(ctx context.Context) ([]UserFairShare, error) {
+	if s == nil || s.fairShare == nil {
+		return nil, nil
+	}
+	return s.fairShare.cache.ListFairShares(ctx)
+}
+
+// acquireFairShareSlot 按请求上下文中的分组与用户执行公平分配检查。
+// 返回 ok=false 表示用户已达到分组内的公平配额；release 在 ok=true 时必须调用。
+// Redis 故障时放行（fail open），与等待队列计数的处理方式一致。
+func (s *ConcurrencyService) acquireFairShareSlot(ctx context.Context, requestID string) (release func(), ok bool) {
+	noop := func() {}
+	if s.fairShare == nil {
+		return noop, true
+	}
+	group, _ := ctx.Value(ctxkey.Group).(*Group)
+	userID, _ := ctx.Value(ctxkey.UserID).(int64)
+	if group == nil || !group.FairShareEnabled || userID <= 0 {
+		return noop, true
+	}
+
+	capacity := s.fairShare.groupCapacity(ctx, group.ID)
+	if capacity <= 0 {
+		return noop, true
+	}
+
+	demands, err := s.fairShare.cache.GetFairShareDemands(ctx, group.ID, userID)
+	if err != nil {
+		log.Printf("Warning: get fair share demands failed for group %d: %v", group.ID, err)
+		return noop, true
+	}
+	share := computeFairShares(capacity, demands)[userID]
+	if share <= 0 {
+		share = 1
+	}
+
+	acquired, err := s.fairShare.cache.AcquireFairShareSlot(ctx, group.ID, userID, share, requestID)
+	if err != nil {
+		log.Printf("Warning: acquire fair share slot failed for group %d user %d: %v", group.ID, userID, err)
+		return noop, true
+	}
+	if !acquired {
+		return nil, false
+	}
+
+	groupID := group.ID
+	return func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.fairShare.cache.ReleaseFairShareSlot(bgCtx, groupID, userID, requestID); err != nil {
+			log.Printf("Warning: failed to release fair share slot for group %d user %d (req=%s): %v", groupID, userID, requestID, err)
+		}
+	}, true
+}
+
+// groupCapacity 返回分组内可调度账号的并发之和（本地缓存）
+func (f *fairShareState) groupCapacity(ctx context.Context, groupID int64) int {
+	now := time.Now()
+	f.mu.Lock()
+	cached, ok := f.capacities[groupID]
+	f.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.capacity
+	}
+
+	accounts, err := f.accountRepo.ListSchedulableByGroupID(ctx, groupID)
+	if err != nil {
+		log.Printf("Warning: list schedulable accounts for fair share failed (group=%d): %v", groupID, err)
+		if ok {
+			return cached.capacity
+		}
+		return 0
+	}
+	capacity := 0
+	for i := range accounts {
+		if accounts[i].Concurrency > 0 {
+			capacity += accounts[i].Concurrency
+		}
+	}
+
+	f.mu.Lock()
+	f.capacities[groupID] = fairShareCapacity{capacity: capacity, expiresAt: now.Add(fairShareCapacityTTL)}
+	f.mu.Unlock()
+	return capacity
+}
+
+// computeFairShares 按 max-min 公平算法在活跃用户之间分配分组总并发：
+// 需求低于平均值的用户获得其全部需求，剩余容量在其余用户之间均分。每个用户至少分得 1 个槽位。
+func computeFairShares(capacity int, demands map[int64]int) map[int64]int {
+	shares := make(map[int64]int, len(demands))
+	if len(demands) == 0 {
+		return shares
+	}
+
+	ids := make([]int64, 0, len(demands))
+	for id := range demands {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if demands[ids[i]] != demands[ids[j]] {
+			return demands[ids[i]] < demands[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	remaining := capacity
+	for i, id := range ids {
+		equal := int(math.Ceil(float64(remaining) / float64(len(ids)-i)))
+		alloc := demands[id]
+		if alloc > equal {
+			alloc = equal
+		}
+		if alloc < 1 {
+			alloc = 1
+		}
+		shares[id] = alloc
+		remaining -= alloc
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+	return shares
+}
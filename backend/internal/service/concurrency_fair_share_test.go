@@ -0,0 +1,171 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/stretchr/testify/require"
+)
+
+// fairShareCacheStub 内存版公平分配缓存：调用过 GetFairShareDemands 的用户视为有待处理请求
+type fairShareCacheStub struct {
+	pending  map[int64]bool
+	inflight map[int64]map[string]struct{}
+	shares   map[int64]int
+}
+
+func newFairShareCacheStub() *fairShareCacheStub {
+	return &fairShareCacheStub{
+		pending:  map[int64]bool{},
+		inflight: map[int64]map[string]struct{}{},
+		shares:   map[int64]int{},
+	}
+}
+
+func (c *fairShareCacheStub) GetFairShareDemands(ctx context.Context, groupID, userID int64) (map[int64]int, error) {
+	c.pending[userID] = true
+	demands := map[int64]int{}
+	for uid := range c.pending {
+		demands[uid] = len(c.inflight[uid]) + 1
+	}
+	for uid, slots := range c.inflight {
+		if !c.pending[uid] && len(slots) > 0 {
+			demands[uid] = len(slots)
+		}
+	}
+	return demands, nil
+}
+
+func (c *fairShareCacheStub) AcquireFairShareSlot(ctx context.Context, groupID, userID int64, share int, requestID string) (bool, error) {
+	c.shares[userID] = share
+	if c.inflight[userID] == nil {
+		c.inflight[userID] = map[string]struct{}{}
+	}
+	if len(c.inflight[userID]) >= share {
+		return false, nil
+	}
+	c.inflight[userID][requestID] = struct{}{}
+	return true, nil
+}
+
+func (c *fairShareCacheStub) ReleaseFairShareSlot(ctx context.Context, groupID, userID int64, requestID string) error {
+	delete(c.inflight[userID], requestID)
+	return nil
+}
+
+func (c *fairShareCacheStub) ListFairShares(ctx context.Context) ([]UserFairShare, error) {
+	var out []UserFairShare
+	for uid, share := range c.shares {
+		out = append(out, UserFairShare{GroupID: 1, UserID: uid, InUse: len(c.inflight[uid]), Share: share})
+	}
+	return out, nil
+}
+
+type fairShareAccountRepoStub struct {
+	accountRepoStub
+	accounts  []Account
+	listCalls int
+}
+
+func (r *fairShareAccountRepoStub) ListSchedulableByGroupID(ctx context.Context, groupID int64) ([]Account, error) {
+	r.listCalls++
+	return r.accounts, nil
+}
+
+func fairShareCtx(group *Group, userID int64) context.Context {
+	ctx := context.WithValue(context.Background(), ctxkey.Group, group)
+	return context.WithValue(ctx, ctxkey.UserID, userID)
+}
+
+func TestComputeFairShares(t *testing.T) {
+	// 轻量用户获得全部需求，剩余容量在重度用户之间均分
+	shares := computeFairShares(10, map[int64]int{1: 1, 2: 20, 3: 20})
+	require.Equal(t, map[int64]int{1: 1, 2: 5, 3: 4}, shares)
+
+	// 需求总和低于容量时全部满足
+	shares = computeFairShares(10, map[int64]int{1: 2, 2: 3})
+	require.Equal(t, map[int64]int{1: 2, 2: 3}, shares)
+
+	// 用户数超过容量时每人至少 1 个槽位
+	shares = computeFairShares(2, map[int64]int{1: 5, 2: 5, 3: 5})
+	for _, v := range shares {
+		require.GreaterOrEqual(t, v, 1)
+	}
+
+	require.Empty(t, computeFairShares(10, nil))
+}
+
+func TestConcurrencyService_FairShareLimitsHeavyUser(t *testing.T) {
+	fairCache := newFairShareCacheStub()
+	repo := &fairShareAccountRepoStub{accounts: []Account{{ID: 1, Concurrency: 3}, {ID: 2, Concurrency: 1}}}
+	svc := NewConcurrencyService(&mockConcurrencyCache{})
+	svc.SetFairShare(fairCache, repo)
+
+	group := &Group{ID: 1, Hydrated: true, FairShareEnabled: true}
+	heavy := fairShareCtx(group, 100)
+	light := fairShareCtx(group, 200)
+
+	// 仅有一个活跃用户时可以使用分组全部容量
+	var heavyReleases []func()
+	for i := 0; i < 4; i++ {
+		result, err := svc.AcquireAccountSlot(heavy, 1, 10)
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+		heavyReleases = append(heavyReleases, result.ReleaseFunc)
+	}
+	result, err := svc.AcquireAccountSlot(heavy, 1, 10)
+	require.NoError(t, err)
+	require.False(t, result.Acquired, "超过分组总容量后应被公平配额拒绝")
+
+	// 轻量用户到来：重度用户的配额随之收缩
+	result, err = svc.AcquireAccountSlot(light, 1, 10)
+	require.NoError(t, err)
+	require.True(t, result.Acquired)
+	require.Equal(t, 1, fairCache.shares[200])
+
+	heavyReleases[0]()
+	result, err = svc.AcquireAccountSlot(heavy, 1, 10)
+	require.NoError(t, err)
+	require.False(t, result.Acquired, "重度用户释放后不应立即重新占满")
+	require.Equal(t, 2, fairCache.shares[100])
+
+	// 分组容量在本地缓存，避免每次获取槽位都查询账号列表
+	require.Equal(t, 1, repo.listCalls)
+
+	shares, err := svc.ListFairShares(context.Background())
+	require.NoError(t, err)
+	require.Len(t, shares, 2)
+}
+
+func TestConcurrencyService_FairShareSkippedWhenDisabled(t *testing.T) {
+	fairCache := newFairShareCacheStub()
+	repo := &fairShareAccountRepoStub{accounts: []Account{{ID: 1, Concurrency: 1}}}
+	concurrencyCache := &mockConcurrencyCache{}
+	svc := NewConcurrencyService(concurrencyCache)
+	svc.SetFairShare(fairCache, repo)
+
+	ctx := fairShareCtx(&Group{ID: 1, Hydrated: true}, 100)
+	for i := 0; i < 3; i++ {
+		result, err := svc.AcquireAccountSlot(ctx, 1, 10)
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+	}
+	require.Zero(t, repo.listCalls)
+	require.Empty(t, fairCache.shares)
+}
+
+func TestConcurrencyService_FairShareReleasedWhenAccountFull(t *testing.T) {
+	fairCache := newFairShareCacheStub()
+	repo := &fairShareAccountRepoStub{accounts: []Account{{ID: 1, Concurrency: 5}}}
+	svc := NewConcurrencyService(&mockConcurrencyCache{acquireResults: map[int64]bool{1: false}})
+	svc.SetFairShare(fairCache, repo)
+
+	ctx := fairShareCtx(&Group{ID: 1, Hydrated: true, FairShareEnabled: true}, 100)
+	result, err := svc.AcquireAccountSlot(ctx, 1, 5)
+	require.NoError(t, err)
+	require.False(t, result.Acquired)
+	require.Empty(t, fairCache.inflight[100], "账号槽位获取失败时应归还公平分配槽位")
+}
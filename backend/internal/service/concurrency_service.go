@@ -59,7 +59,8 @@ const (
 
 // ConcurrencyService manages concurrent request limiting for accounts and users
 type ConcurrencyService struct {
-	cache ConcurrencyCache
+	cache     ConcurrencyCache
+	fairShare *fairShareState // 分组内用户公平分配（可选）
 }
 
 // NewConcurrencyService creates a new ConcurrencyService
@@ -112,8 +113,18 @@ func (s *ConcurrencyService) AcquireAccountSlot(ctx context.Context, accountID i
 	// Generate unique request ID for this slot
 	requestID := generateRequestID()
 
+	// 分组开启公平分配时，先检查用户在分组内的公平配额
+	releaseFairShare, ok := s.acquireFairShareSlot(ctx, requestID)
+	if !ok {
+		return &AcquireResult{
+			Acquired:    false,
+			ReleaseFunc: nil,
+		}, nil
+	}
+
 	acquired, err := s.cache.AcquireAccountSlot(ctx, accountID, maxConcurrency, requestID)
 	if err != nil {
+		releaseFairShare()
 		return nil, err
 	}
 
@@ -126,10 +137,12 @@ func (s *ConcurrencyService) AcquireAccountSlot(ctx context.Context, accountID i
 				if err := s.cache.ReleaseAccountSlot(bgCtx, accountID, requestID); err != nil {
 					log.Printf("Warning: failed to release account slot for %d (req=%s): %v", accountID, requestID, err)
 				}
+				releaseFairShare()
 			},
 		}, nil
 	}
 
+	releaseFairShare()
 	return &AcquireResult{
 		Acquired:    false,
 		ReleaseFunc: nil,
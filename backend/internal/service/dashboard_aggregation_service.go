@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
 )
 
 const (
@@ -35,13 +36,17 @@ type DashboardAggregationRepository interface {
 	CleanupAggregates(ctx context.Context, hourlyCutoff, dailyCutoff time.Time) error
 	CleanupUsageLogs(ctx context.Context, cutoff time.Time) error
 	EnsureUsageLogsPartitions(ctx context.Context, now time.Time) error
+	// GetTagUsageStats 从标签日聚合表读取按标签值汇总的用量。
+	GetTagUsageStats(ctx context.Context, filters usagestats.TagUsageFilters) ([]usagestats.TagUsageStat, error)
 }
 
 // DashboardAggregationService 负责定时聚合与回填。
 type DashboardAggregationService struct {
-	repo                 DashboardAggregationRepository
-	timingWheel          *TimingWheelService
-	cfg                  config.DashboardAggregationConfig
+	repo        DashboardAggregationRepository
+	timingWheel *TimingWheelService
+	cfg         config.DashboardAggregationConfig
+	// usageLogsManaged 为 true 时 usage_logs 保留由分区归档负责，这里不再删除明细
+	usageLogsManaged     bool
 	running              int32
 	lastRetentionCleanup atomic.Value // time.Time
 }
@@ -49,13 +54,16 @@ type DashboardAggregationService struct {
 // NewDashboardAggregationService 创建聚合服务。
 func NewDashboardAggregationService(repo DashboardAggregationRepository, timingWheel *TimingWheelService, cfg *config.Config) *DashboardAggregationService {
 	var aggCfg config.DashboardAggregationConfig
+	var usageLogsManaged bool
 	if cfg != nil {
 		aggCfg = cfg.DashboardAgg
+		usageLogsManaged = cfg.UsageLogsRetentionManaged()
 	}
 	return &DashboardAggregationService{
-		repo:        repo,
-		timingWheel: timingWheel,
-		cfg:         aggCfg,
+		repo:             repo,
+		timingWheel:      timingWheel,
+		cfg:              aggCfg,
+		usageLogsManaged: usageLogsManaged,
 	}
 }
 
@@ -300,9 +308,12 @@ func (s *DashboardAggregationService) maybeCleanupRetention(ctx context.Context,
 	if aggErr != nil {
 		log.Printf("[DashboardAggregation] 聚合保留清理失败: %v", aggErr)
 	}
-	usageErr := s.repo.CleanupUsageLogs(ctx, usageCutoff)
-	if usageErr != nil {
-		log.Printf("[DashboardAggregation] usage_logs 保留清理失败: %v", usageErr)
+	var usageErr error
+	if !s.usageLogsManaged {
+		usageErr = s.repo.CleanupUsageLogs(ctx, usageCutoff)
+		if usageErr != nil {
+			log.Printf("[DashboardAggregation] usage_logs 保留清理失败: %v", usageErr)
+		}
 	}
 	if aggErr == nil && usageErr == nil {
 		s.lastRetentionCleanup.Store(now)
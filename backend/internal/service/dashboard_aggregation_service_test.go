@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,6 +19,7 @@ type dashboardAggregationRepoTestStub struct {
 	aggregateErr         error
 	cleanupAggregatesErr error
 	cleanupUsageErr      error
+	cleanupUsageCalls    int
 }
 
 func (s *dashboardAggregationRepoTestStub) AggregateRange(ctx context.Context, start, end time.Time) error {
@@ -44,9 +46,14 @@ func (s *dashboardAggregationRepoTestStub) CleanupAggregates(ctx context.Context
 }
 
 func (s *dashboardAggregationRepoTestStub) CleanupUsageLogs(ctx context.Context, cutoff time.Time) error {
+	s.cleanupUsageCalls++
 	return s.cleanupUsageErr
 }
 
+func (s *dashboardAggregationRepoTestStub) GetTagUsageStats(ctx context.Context, filters usagestats.TagUsageFilters) ([]usagestats.TagUsageStat, error) {
+	return nil, nil
+}
+
 func (s *dashboardAggregationRepoTestStub) EnsureUsageLogsPartitions(ctx context.Context, now time.Time) error {
 	return nil
 }
@@ -92,6 +99,27 @@ func TestDashboardAggregationService_CleanupRetentionFailure_DoesNotRecord(t *te
 	require.Nil(t, svc.lastRetentionCleanup.Load())
 }
 
+func TestDashboardAggregationService_CleanupRetention_SkipsUsageLogsWhenArchiveManaged(t *testing.T) {
+	repo := &dashboardAggregationRepoTestStub{}
+	cfg := &config.Config{
+		DashboardAgg: config.DashboardAggregationConfig{
+			Retention: config.DashboardAggregationRetentionConfig{
+				UsageLogsDays: 1,
+				HourlyDays:    1,
+				DailyDays:     1,
+			},
+		},
+	}
+	cfg.UsagePartition.Enabled = true
+	cfg.UsagePartition.Archive.Enabled = true
+	svc := NewDashboardAggregationService(repo, nil, cfg)
+
+	svc.maybeCleanupRetention(context.Background(), time.Now().UTC())
+
+	require.Equal(t, 0, repo.cleanupUsageCalls)
+	require.NotNil(t, svc.lastRetentionCleanup.Load())
+}
+
 func TestDashboardAggregationService_TriggerBackfill_TooLarge(t *testing.T) {
 	repo := &dashboardAggregationRepoTestStub{}
 	svc := &DashboardAggregationService{
@@ -132,6 +132,27 @@ func (s *DashboardService) GetUsageTrendWithFilters(ctx context.Context, startTi
 	return trend, nil
 }
 
+// GetTagUsageStats 按成本归属标签值分组统计。
+// 启用预聚合时读取标签日聚合表（按整天、存在聚合延迟）；按 API Key 过滤或未启用预聚合时直接查询 usage_logs。
+func (s *DashboardService) GetTagUsageStats(ctx context.Context, filters usagestats.TagUsageFilters) ([]usagestats.TagUsageStat, error) {
+	if err := validateTagUsageFilters(&filters); err != nil {
+		return nil, err
+	}
+	var (
+		stats []usagestats.TagUsageStat
+		err   error
+	)
+	if s.aggEnabled && s.aggRepo != nil && filters.APIKeyID == 0 {
+		stats, err = s.aggRepo.GetTagUsageStats(ctx, filters)
+	} else {
+		stats, err = s.usageRepo.GetTagUsageStats(ctx, filters)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get tag usage stats: %w", err)
+	}
+	return stats, nil
+}
+
 func (s *DashboardService) GetModelStatsWithFilters(ctx context.Context, startTime, endTime time.Time, userID, apiKeyID, accountID, groupID int64, stream *bool, billingType *int8) ([]usagestats.ModelStat, error) {
 	stats, err := s.usageRepo.GetModelStatsWithFilters(ctx, startTime, endTime, userID, apiKeyID, accountID, groupID, stream, billingType)
 	if err != nil {
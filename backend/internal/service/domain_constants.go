@@ -52,6 +52,7 @@ const (
 const (
 	AdjustmentTypeAdminBalance     = domain.AdjustmentTypeAdminBalance     // 管理员调整余额
 	AdjustmentTypeAdminConcurrency = domain.AdjustmentTypeAdminConcurrency // 管理员调整并发数
+	AdjustmentTypeReferralReward   = domain.AdjustmentTypeReferralReward   // 推荐返佣入账
 )
 
 // Group subscription type constants
@@ -166,6 +167,48 @@ const (
 
 	// SettingKeyStreamTimeoutSettings stores JSON config for stream timeout handling.
 	SettingKeyStreamTimeoutSettings = "stream_timeout_settings"
+
+	// =========================
+	// Account Health Probes
+	// =========================
+
+	// SettingKeyAccountProbeSettings stores JSON config for scheduled account health probes.
+	SettingKeyAccountProbeSettings = "account_probe_settings"
+
+	// =========================
+	// Proxy Health Monitor
+	// =========================
+
+	// SettingKeyProxyMonitorSettings stores JSON config for scheduled proxy health checks.
+	SettingKeyProxyMonitorSettings = "proxy_monitor_settings"
+
+	// =========================
+	// Generic OAuth / OIDC Login
+	// =========================
+
+	// SettingKeyOAuthProviders stores a JSON array of generic OIDC/OAuth2 login providers.
+	SettingKeyOAuthProviders = "oauth_providers"
+
+	// =========================
+	// Passkeys (WebAuthn)
+	// =========================
+
+	// SettingKeyPasskeySettings stores JSON config for WebAuthn passkeys (RP, origins, per-role requirement).
+	SettingKeyPasskeySettings = "passkey_settings"
+
+	// =========================
+	// Login Security
+	// =========================
+
+	// SettingKeyLoginSecurity stores JSON config for login lockout after repeated failures (per account / per IP).
+	SettingKeyLoginSecurity = "login_security_settings"
+
+	// =========================
+	// Referral Program
+	// =========================
+
+	// SettingKeyReferralSettings stores JSON config for referral rewards (first top-up bonus, spend commission).
+	SettingKeyReferralSettings = "referral_settings"
 )
 
 // AdminAPIKeyPrefix is the prefix for admin API keys (distinct from user "sk-" keys).
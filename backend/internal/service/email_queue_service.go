@@ -12,14 +12,18 @@ import (
 const (
 	TaskTypeVerifyCode    = "verify_code"
 	TaskTypePasswordReset = "password_reset"
+	TaskTypeStatement     = "statement"
+	TaskTypeNotification  = "notification"
 )
 
 // EmailTask 邮件发送任务
 type EmailTask struct {
 	Email    string
 	SiteName string
-	TaskType string // "verify_code" or "password_reset"
+	TaskType string // "verify_code", "password_reset", "statement" or "notification"
 	ResetURL string // Only used for password_reset task type
+	Subject  string // Only used for statement / notification task types
+	Body     string // Only used for statement / notification task types
 }
 
 // EmailQueueService 异步邮件队列服务
@@ -92,6 +96,12 @@ func (s *EmailQueueService) processTask(workerID int, task EmailTask) {
 		} else {
 			log.Printf("[EmailQueue] Worker %d sent password reset to %s", workerID, task.Email)
 		}
+	case TaskTypeStatement, TaskTypeNotification:
+		if err := s.emailService.SendEmail(ctx, task.Email, task.Subject, task.Body); err != nil {
+			log.Printf("[EmailQueue] Worker %d failed to send %s to %s: %v", workerID, task.TaskType, task.Email, err)
+		} else {
+			log.Printf("[EmailQueue] Worker %d sent %s to %s", workerID, task.TaskType, task.Email)
+		}
 	default:
 		log.Printf("[EmailQueue] Worker %d unknown task type: %s", workerID, task.TaskType)
 	}
@@ -132,6 +142,38 @@ func (s *EmailQueueService) EnqueuePasswordReset(email, siteName, resetURL strin
 	}
 }
 
+// EnqueueStatement 将对账单邮件加入队列
+// 批量发送时队列容易写满，因此这里阻塞等待空位，直到 ctx 结束或队列停止
+func (s *EmailQueueService) EnqueueStatement(ctx context.Context, email, subject, body string) error {
+	return s.enqueueWait(ctx, EmailTask{
+		Email:    email,
+		TaskType: TaskTypeStatement,
+		Subject:  subject,
+		Body:     body,
+	})
+}
+
+// EnqueueNotification 将用户提醒邮件加入队列（由后台任务调用，同样阻塞等待空位）
+func (s *EmailQueueService) EnqueueNotification(ctx context.Context, email, subject, body string) error {
+	return s.enqueueWait(ctx, EmailTask{
+		Email:    email,
+		TaskType: TaskTypeNotification,
+		Subject:  subject,
+		Body:     body,
+	})
+}
+
+func (s *EmailQueueService) enqueueWait(ctx context.Context, task EmailTask) error {
+	select {
+	case s.taskChan <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.stopChan:
+		return fmt.Errorf("email queue is stopped")
+	}
+}
+
 // Stop 停止队列服务
 func (s *EmailQueueService) Stop() {
 	close(s.stopChan)
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// maxModelFallbackChainLength 单个模型模式允许配置的降级模型数量上限
+const maxModelFallbackChainLength = 5
+
+// normalizeModelFallback 规范化分组模型降级链配置：去除空白、空项与重复模型，
+// 并拒绝降级到自身或超过长度上限的配置。nil 输入返回 nil。
+func normalizeModelFallback(raw map[string][]string) (map[string][]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	out := make(map[string][]string, len(raw))
+	for rawPattern, rawChain := range raw {
+		pattern := strings.TrimSpace(rawPattern)
+		if pattern == "" {
+			continue
+		}
+		seen := make(map[string]struct{}, len(rawChain))
+		chain := make([]string, 0, len(rawChain))
+		for _, rawModel := range rawChain {
+			model := strings.TrimSpace(rawModel)
+			if model == "" {
+				continue
+			}
+			if model == pattern || matchModelPattern(pattern, model) {
+				return nil, infraerrors.BadRequest("INVALID_MODEL_FALLBACK",
+					fmt.Sprintf("fallback model %q for %q must not match the source pattern", model, pattern))
+			}
+			if _, ok := seen[model]; ok {
+				continue
+			}
+			seen[model] = struct{}{}
+			chain = append(chain, model)
+		}
+		if len(chain) == 0 {
+			continue
+		}
+		if len(chain) > maxModelFallbackChainLength {
+			return nil, infraerrors.BadRequest("INVALID_MODEL_FALLBACK",
+				fmt.Sprintf("fallback chain for %q exceeds %d models", pattern, maxModelFallbackChainLength))
+		}
+		out[pattern] = chain
+	}
+	return out, nil
+}
+
+// ApplyModelFallback 将解析后的请求切换为降级模型（同时改写请求体中的 model 字段）
+func (s *GatewayService) ApplyModelFallback(parsed *ParsedRequest, model string) {
+	if parsed == nil || model == "" {
+		return
+	}
+	parsed.Body = s.replaceModelInBody(parsed.Body, model)
+	parsed.Model = model
+}
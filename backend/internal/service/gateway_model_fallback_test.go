@@ -0,0 +1,67 @@
+//go:build unit
+
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup_GetModelFallbackChain(t *testing.T) {
+	group := &Group{
+		ModelFallbackEnabled: true,
+		ModelFallback: map[string][]string{
+			"claude-*":                 {"claude-haiku-4-5-20251001"},
+			"claude-opus-*":            {"claude-sonnet-4-5-20250929", "claude-haiku-4-5-20251001"},
+			"claude-opus-4-5-20251101": {"claude-opus-4-1-20250805"},
+		},
+	}
+
+	// 精确匹配优先
+	require.Equal(t, []string{"claude-opus-4-1-20250805"}, group.GetModelFallbackChain("claude-opus-4-5-20251101"))
+	// 多个通配符命中时选择最长模式
+	require.Equal(t, []string{"claude-sonnet-4-5-20250929", "claude-haiku-4-5-20251001"}, group.GetModelFallbackChain("claude-opus-4-20250514"))
+	require.Equal(t, []string{"claude-haiku-4-5-20251001"}, group.GetModelFallbackChain("claude-sonnet-4-20250514"))
+	require.Nil(t, group.GetModelFallbackChain("gpt-4o"))
+
+	group.ModelFallbackEnabled = false
+	require.Nil(t, group.GetModelFallbackChain("claude-opus-4-20250514"))
+}
+
+func TestNormalizeModelFallback(t *testing.T) {
+	got, err := normalizeModelFallback(nil)
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	got, err = normalizeModelFallback(map[string][]string{
+		" claude-opus-* ": {" claude-sonnet-4-5-20250929 ", "", "claude-sonnet-4-5-20250929", "claude-haiku-4-5-20251001"},
+		"empty":           {" "},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"claude-opus-*": {"claude-sonnet-4-5-20250929", "claude-haiku-4-5-20251001"},
+	}, got)
+
+	// 降级目标不能命中源模式，否则会原地循环
+	_, err = normalizeModelFallback(map[string][]string{"claude-opus-*": {"claude-opus-4-20250514"}})
+	require.Error(t, err)
+
+	_, err = normalizeModelFallback(map[string][]string{"m": {"a", "b", "c", "d", "e", "f"}})
+	require.Error(t, err)
+}
+
+func TestGatewayService_ApplyModelFallback(t *testing.T) {
+	svc := &GatewayService{}
+	parsed, err := ParseGatewayRequest([]byte(`{"model":"claude-opus-4-20250514","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`), PlatformAnthropic)
+	require.NoError(t, err)
+
+	svc.ApplyModelFallback(parsed, "claude-sonnet-4-5-20250929")
+	require.Equal(t, "claude-sonnet-4-5-20250929", parsed.Model)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(parsed.Body, &body))
+	require.Equal(t, "claude-sonnet-4-5-20250929", body["model"])
+	require.EqualValues(t, 16, body["max_tokens"])
+}
@@ -0,0 +1,449 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+)
+
+// 调度解释：候选账号被淘汰的过滤阶段（与 SelectAccountWithLoadAwareness 的分层过滤顺序一致）
+const (
+	ScheduleStageExcluded         = "excluded"           // 在排除列表中
+	ScheduleStageUnschedulable    = "unschedulable"      // 状态/限流/过载/临时不可调度
+	ScheduleStagePlatform         = "platform"           // 平台不匹配（含混合调度未开启）
+	ScheduleStageModelUnsupported = "model_unsupported"  // 模型映射/白名单不支持
+	ScheduleStageModelRateLimited = "model_rate_limited" // 模型级限流
+	ScheduleStageWindowCost       = "window_cost"        // 5h 窗口费用超限
+	ScheduleStageSessionLimit     = "session_limit"      // 会话数量已满（新会话）
+	ScheduleStageLoadFull         = "load_full"          // 负载已满，仅可进入兜底排队
+)
+
+// 调度解释：预计命中的调度层
+const (
+	ScheduleLayerModelRouting  = "model_routing"
+	ScheduleLayerStickySession = "sticky_session"
+	ScheduleLayerLoadBalance   = "load_balance"
+	ScheduleLayerWaitQueue     = "wait_queue"
+	ScheduleLayerNone          = "none"
+)
+
+// ScheduleExplainRequest 调度解释（dry-run）请求
+type ScheduleExplainRequest struct {
+	GroupID *int64
+	// Platform 非空时模拟强制平台路由（如 /antigravity）
+	Platform    string
+	Model       string
+	SessionHash string
+	ExcludedIDs []int64
+	// ClaudeCodeClient 模拟 Claude Code 客户端（影响 claude_code_only 分组的降级）
+	ClaudeCodeClient bool
+	// RequestBody 可选：真实请求体，用于按 GenerateSessionHash 相同逻辑推导会话 hash 与模型
+	RequestBody    []byte
+	SessionContext *SessionContext
+}
+
+// ScheduleExplainCandidate 单个候选账号的解释
+type ScheduleExplainCandidate struct {
+	AccountID   int64  `json:"account_id"`
+	Name        string `json:"name"`
+	Platform    string `json:"platform"`
+	Type        string `json:"type"`
+	Priority    int    `json:"priority"`
+	Concurrency int    `json:"concurrency"`
+	Routed      bool   `json:"routed"`
+	Sticky      bool   `json:"sticky"`
+	// EliminatedAt 淘汰该账号的过滤阶段，空表示通过全部过滤
+	EliminatedAt string `json:"eliminated_at,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+
+	CurrentConcurrency int `json:"current_concurrency"`
+	WaitingCount       int `json:"waiting_count"`
+	LoadRate           int `json:"load_rate"`
+	// Weight/LatencyScore 仅在对应选择策略下填充
+	Weight       int     `json:"weight,omitempty"`
+	LatencyScore float64 `json:"latency_score,omitempty"`
+	// Rank 最终排序位置（从 1 开始），0 表示未进入排序
+	Rank int `json:"rank"`
+}
+
+// ScheduleExplainResult 调度解释结果
+type ScheduleExplainResult struct {
+	GroupID           *int64                     `json:"group_id"`
+	Platform          string                     `json:"platform"`
+	UseMixed          bool                       `json:"use_mixed"`
+	Model             string                     `json:"model"`
+	SessionHash       string                     `json:"session_hash"`
+	SelectionStrategy string                     `json:"selection_strategy"`
+	LoadBatchEnabled  bool                       `json:"load_batch_enabled"`
+	StickyAccountID   int64                      `json:"sticky_account_id"`
+	RoutingAccountIDs []int64                    `json:"routing_account_ids"`
+	Layer             string                     `json:"layer"`
+	SelectedAccountID int64                      `json:"selected_account_id"`
+	Ordering          []int64                    `json:"ordering"`
+	Candidates        []ScheduleExplainCandidate `json:"candidates"`
+}
+
+// ExplainAccountSelection 以只读方式模拟 SelectAccountWithLoadAwareness 的分层过滤，
+// 返回每个候选账号被淘汰的阶段与最终排序。不会获取并发槽位、注册会话或写入粘性绑定。
+func (s *GatewayService) ExplainAccountSelection(ctx context.Context, req *ScheduleExplainRequest) (*ScheduleExplainResult, error) {
+	if req == nil {
+		req = &ScheduleExplainRequest{}
+	}
+
+	model := req.Model
+	sessionHash := req.SessionHash
+	if len(req.RequestBody) > 0 {
+		protocol := PlatformAnthropic
+		if req.Platform == PlatformGemini {
+			protocol = PlatformGemini
+		}
+		parsed, err := ParseGatewayRequest(req.RequestBody, protocol)
+		if err != nil {
+			return nil, fmt.Errorf("parse request body: %w", err)
+		}
+		parsed.SessionContext = req.SessionContext
+		if model == "" {
+			model = parsed.Model
+		}
+		if sessionHash == "" {
+			sessionHash = s.GenerateSessionHash(parsed)
+		}
+	}
+
+	if req.Platform != "" {
+		ctx = context.WithValue(ctx, ctxkey.ForcePlatform, req.Platform)
+	}
+	if req.ClaudeCodeClient {
+		ctx = context.WithValue(ctx, ctxkey.IsClaudeCodeClient, true)
+	}
+
+	group, groupID, err := s.checkClaudeCodeRestriction(ctx, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = s.withGroupContext(ctx, group)
+
+	platform, hasForcePlatform, err := s.resolvePlatform(ctx, groupID, group)
+	if err != nil {
+		return nil, err
+	}
+	preferOAuth := platform == PlatformGemini
+	cfg := s.schedulingConfig()
+
+	result := &ScheduleExplainResult{
+		GroupID:           groupID,
+		Platform:          platform,
+		Model:             model,
+		SessionHash:       sessionHash,
+		SelectionStrategy: groupSelectionStrategy(group),
+		LoadBatchEnabled:  s.concurrencyService != nil && cfg.LoadBatchEnabled,
+		RoutingAccountIDs: []int64{},
+		Layer:             ScheduleLayerNone,
+		Ordering:          []int64{},
+		Candidates:        []ScheduleExplainCandidate{},
+	}
+
+	accounts, useMixed, err := s.listSchedulableAccounts(ctx, groupID, platform, hasForcePlatform)
+	if err != nil {
+		return nil, err
+	}
+	result.UseMixed = useMixed
+
+	if sessionHash != "" && s.cache != nil {
+		if accountID, err := s.cache.GetSessionAccountID(ctx, derefGroupID(groupID), sessionHash); err == nil {
+			result.StickyAccountID = accountID
+		}
+	}
+	if group != nil && model != "" && group.Platform == PlatformAnthropic {
+		if ids := group.GetRoutingAccountIDs(model); len(ids) > 0 {
+			result.RoutingAccountIDs = ids
+		}
+	}
+
+	excluded := make(map[int64]struct{}, len(req.ExcludedIDs))
+	for _, id := range req.ExcludedIDs {
+		excluded[id] = struct{}{}
+	}
+
+	// 逐个账号执行过滤
+	candidateIdx := make(map[int64]int, len(accounts))
+	var survivors []*Account
+	for i := range accounts {
+		acc := &accounts[i]
+		isSticky := acc.ID == result.StickyAccountID
+		item := ScheduleExplainCandidate{
+			AccountID:   acc.ID,
+			Name:        acc.Name,
+			Platform:    acc.Platform,
+			Type:        acc.Type,
+			Priority:    acc.Priority,
+			Concurrency: acc.Concurrency,
+			Routed:      containsInt64(result.RoutingAccountIDs, acc.ID),
+			Sticky:      isSticky,
+		}
+		if _, ok := excluded[acc.ID]; ok {
+			item.EliminatedAt, item.Reason = ScheduleStageExcluded, "account is in excluded list"
+		} else {
+			item.EliminatedAt, item.Reason = s.explainAccountFilter(ctx, acc, platform, useMixed, model, sessionHash, isSticky)
+		}
+		if item.EliminatedAt == "" {
+			survivors = append(survivors, acc)
+		}
+		candidateIdx[acc.ID] = len(result.Candidates)
+		result.Candidates = append(result.Candidates, item)
+	}
+
+	// 补充未进入可调度列表的活跃账号（DB 查询或调度快照已将其过滤），便于定位"没有可用账号"的原因
+	for _, acc := range s.listExplainScopeAccounts(ctx, groupID, platform) {
+		if _, seen := candidateIdx[acc.ID]; seen {
+			continue
+		}
+		reason := describeUnschedulable(&acc)
+		if acc.IsSchedulable() {
+			reason = "not in schedulable list (scheduler snapshot may be stale)"
+		}
+		candidateIdx[acc.ID] = len(result.Candidates)
+		result.Candidates = append(result.Candidates, ScheduleExplainCandidate{
+			AccountID:    acc.ID,
+			Name:         acc.Name,
+			Platform:     acc.Platform,
+			Type:         acc.Type,
+			Priority:     acc.Priority,
+			Concurrency:  acc.Concurrency,
+			Routed:       containsInt64(result.RoutingAccountIDs, acc.ID),
+			Sticky:       acc.ID == result.StickyAccountID,
+			EliminatedAt: ScheduleStageUnschedulable,
+			Reason:       reason,
+		})
+	}
+
+	// 负载信息（只读）
+	loadMap := map[int64]*AccountLoadInfo{}
+	if s.concurrencyService != nil && len(survivors) > 0 {
+		loads := make([]AccountWithConcurrency, 0, len(survivors))
+		for _, acc := range survivors {
+			loads = append(loads, AccountWithConcurrency{ID: acc.ID, MaxConcurrency: acc.Concurrency})
+		}
+		if m, err := s.concurrencyService.GetAccountsLoadBatch(ctx, loads); err == nil && m != nil {
+			loadMap = m
+		}
+	}
+
+	var available []accountWithLoad
+	var full []accountWithLoad
+	for _, acc := range survivors {
+		loadInfo := loadMap[acc.ID]
+		if loadInfo == nil {
+			loadInfo = &AccountLoadInfo{AccountID: acc.ID}
+		}
+		item := &result.Candidates[candidateIdx[acc.ID]]
+		item.CurrentConcurrency = loadInfo.CurrentConcurrency
+		item.WaitingCount = loadInfo.WaitingCount
+		item.LoadRate = loadInfo.LoadRate
+		if loadInfo.LoadRate >= 100 {
+			item.EliminatedAt = ScheduleStageLoadFull
+			item.Reason = fmt.Sprintf("load rate %d%% (concurrency %d/%d, waiting %d)", loadInfo.LoadRate, loadInfo.CurrentConcurrency, acc.Concurrency, loadInfo.WaitingCount)
+			full = append(full, accountWithLoad{account: acc, loadInfo: loadInfo})
+			continue
+		}
+		available = append(available, accountWithLoad{account: acc, loadInfo: loadInfo})
+	}
+
+	// 最终排序：优先级 → 负载率 → LRU（不做同组随机打乱，保证结果可复现）；负载已满的账号按兜底排队顺序追加
+	sortAccountsWithLoadForExplain(available, preferOAuth, true)
+	sortAccountsWithLoadForExplain(full, preferOAuth, false)
+	s.applyStrategyForExplain(ctx, result, available, candidateIdx)
+	for _, item := range available {
+		result.Ordering = append(result.Ordering, item.account.ID)
+	}
+	for _, item := range full {
+		result.Ordering = append(result.Ordering, item.account.ID)
+	}
+	for rank, id := range result.Ordering {
+		result.Candidates[candidateIdx[id]].Rank = rank + 1
+	}
+
+	result.Layer, result.SelectedAccountID = explainSelectedLayer(result, available, full, candidateIdx)
+	return result, nil
+}
+
+// applyStrategyForExplain 按分组选择策略调整同优先级内的排序并填充评分信息。
+// weighted 策略实际为加权随机，这里按权重从高到低展示期望的流量分配顺序。
+func (s *GatewayService) applyStrategyForExplain(ctx context.Context, result *ScheduleExplainResult, available []accountWithLoad, candidateIdx map[int64]int) {
+	switch result.SelectionStrategy {
+	case SelectionStrategyWeighted:
+		for _, item := range available {
+			result.Candidates[candidateIdx[item.account.ID]].Weight = item.account.GetSelectionWeight()
+		}
+		sort.SliceStable(available, func(i, j int) bool {
+			a, b := available[i].account, available[j].account
+			if a.Priority != b.Priority {
+				return a.Priority < b.Priority
+			}
+			return a.GetSelectionWeight() > b.GetSelectionWeight()
+		})
+	case SelectionStrategyLatency:
+		stats := s.loadAccountLatencyStats(ctx, available)
+		if len(stats) == 0 {
+			return
+		}
+		scores := make(map[int64]float64, len(available))
+		for _, item := range available {
+			score := accountLatencyScore(stats[item.account.ID], item.loadInfo.LoadRate)
+			scores[item.account.ID] = score
+			result.Candidates[candidateIdx[item.account.ID]].LatencyScore = score
+		}
+		sort.SliceStable(available, func(i, j int) bool {
+			a, b := available[i].account, available[j].account
+			if a.Priority != b.Priority {
+				return a.Priority < b.Priority
+			}
+			return scores[a.ID] < scores[b.ID]
+		})
+	}
+}
+
+// listExplainScopeAccounts 返回调度范围内的全部活跃账号（忽略查询错误，仅用于解释）。
+func (s *GatewayService) listExplainScopeAccounts(ctx context.Context, groupID *int64, platform string) []Account {
+	if s.accountRepo == nil {
+		return nil
+	}
+	var (
+		accounts []Account
+		err      error
+	)
+	if groupID != nil {
+		accounts, err = s.accountRepo.ListByGroup(ctx, *groupID)
+	} else {
+		accounts, err = s.accountRepo.ListByPlatform(ctx, platform)
+	}
+	if err != nil {
+		return nil
+	}
+	return accounts
+}
+
+// explainAccountFilter 返回账号被淘汰的阶段与原因；均通过时返回空字符串。
+func (s *GatewayService) explainAccountFilter(ctx context.Context, acc *Account, platform string, useMixed bool, model, sessionHash string, isSticky bool) (string, string) {
+	if !acc.IsSchedulable() {
+		return ScheduleStageUnschedulable, describeUnschedulable(acc)
+	}
+	if !s.isAccountAllowedForPlatform(acc, platform, useMixed) {
+		if acc.Platform == PlatformAntigravity && useMixed {
+			return ScheduleStagePlatform, "antigravity account without mixed scheduling"
+		}
+		return ScheduleStagePlatform, fmt.Sprintf("account platform %s does not match %s", acc.Platform, platform)
+	}
+	if model != "" && !s.isModelSupportedByAccountWithContext(ctx, acc, model) {
+		return ScheduleStageModelUnsupported, fmt.Sprintf("model %s not supported by account model mapping", model)
+	}
+	if !acc.IsSchedulableForModelWithContext(ctx, model) {
+		remaining := acc.GetRateLimitRemainingTimeWithContext(ctx, model)
+		return ScheduleStageModelRateLimited, fmt.Sprintf("model rate limited, %s remaining", remaining.Truncate(time.Second))
+	}
+	if !s.isAccountSchedulableForWindowCost(ctx, acc, isSticky) {
+		return ScheduleStageWindowCost, fmt.Sprintf("window cost limit %.2f reached", acc.GetWindowCostLimit())
+	}
+	if full, count := s.isSessionLimitFull(ctx, acc, sessionHash); full {
+		return ScheduleStageSessionLimit, fmt.Sprintf("active sessions %d/%d", count, acc.GetMaxSessions())
+	}
+	return "", ""
+}
+
+// isSessionLimitFull 只读版本的 checkAndRegisterSession：新会话且活跃会话数已达上限时返回 true。
+func (s *GatewayService) isSessionLimitFull(ctx context.Context, acc *Account, sessionHash string) (bool, int) {
+	if !acc.IsAnthropicOAuthOrSetupToken() || s.sessionLimitCache == nil || sessionHash == "" {
+		return false, 0
+	}
+	maxSessions := acc.GetMaxSessions()
+	if maxSessions <= 0 {
+		return false, 0
+	}
+	if active, err := s.sessionLimitCache.IsSessionActive(ctx, acc.ID, sessionHash); err != nil || active {
+		return false, 0
+	}
+	count, err := s.sessionLimitCache.GetActiveSessionCount(ctx, acc.ID)
+	if err != nil {
+		return false, 0
+	}
+	return count >= maxSessions, count
+}
+
+func describeUnschedulable(acc *Account) string {
+	now := time.Now()
+	switch {
+	case !acc.IsActive():
+		return "status " + acc.Status
+	case !acc.Schedulable:
+		return "scheduling disabled"
+	case acc.AutoPauseOnExpired && acc.ExpiresAt != nil && !now.Before(*acc.ExpiresAt):
+		return "account expired at " + acc.ExpiresAt.Format(time.RFC3339)
+	case acc.OverloadUntil != nil && now.Before(*acc.OverloadUntil):
+		return "overloaded until " + acc.OverloadUntil.Format(time.RFC3339)
+	case acc.RateLimitResetAt != nil && now.Before(*acc.RateLimitResetAt):
+		return "rate limited until " + acc.RateLimitResetAt.Format(time.RFC3339)
+	case acc.TempUnschedulableUntil != nil && now.Before(*acc.TempUnschedulableUntil):
+		return "temporarily unschedulable until " + acc.TempUnschedulableUntil.Format(time.RFC3339)
+	default:
+		return "not schedulable"
+	}
+}
+
+// sortAccountsWithLoadForExplain 按 优先级 → 负载率（byLoad 时）→ 最后使用时间 排序，与实际调度一致但不做随机打乱。
+func sortAccountsWithLoadForExplain(accounts []accountWithLoad, preferOAuth bool, byLoad bool) {
+	sort.SliceStable(accounts, func(i, j int) bool {
+		a, b := accounts[i], accounts[j]
+		if a.account.Priority != b.account.Priority {
+			return a.account.Priority < b.account.Priority
+		}
+		if byLoad && a.loadInfo.LoadRate != b.loadInfo.LoadRate {
+			return a.loadInfo.LoadRate < b.loadInfo.LoadRate
+		}
+		switch {
+		case a.account.LastUsedAt == nil && b.account.LastUsedAt != nil:
+			return true
+		case a.account.LastUsedAt != nil && b.account.LastUsedAt == nil:
+			return false
+		case a.account.LastUsedAt == nil && b.account.LastUsedAt == nil:
+			return preferOAuth && a.account.Type != b.account.Type && a.account.Type == AccountTypeOAuth
+		default:
+			return a.account.LastUsedAt.Before(*b.account.LastUsedAt)
+		}
+	})
+}
+
+// explainSelectedLayer 推断实际调度会命中的层级与账号：模型路由 → 粘性会话 → 负载感知 → 兜底排队。
+func explainSelectedLayer(result *ScheduleExplainResult, available []accountWithLoad, full []accountWithLoad, candidateIdx map[int64]int) (string, int64) {
+	passed := func(id int64) bool {
+		idx, ok := candidateIdx[id]
+		if !ok {
+			return false
+		}
+		stage := result.Candidates[idx].EliminatedAt
+		return stage == "" || stage == ScheduleStageLoadFull
+	}
+
+	if len(result.RoutingAccountIDs) > 0 {
+		if result.StickyAccountID > 0 && containsInt64(result.RoutingAccountIDs, result.StickyAccountID) && passed(result.StickyAccountID) {
+			return ScheduleLayerStickySession, result.StickyAccountID
+		}
+		for _, item := range available {
+			if containsInt64(result.RoutingAccountIDs, item.account.ID) {
+				return ScheduleLayerModelRouting, item.account.ID
+			}
+		}
+	} else if result.StickyAccountID > 0 && passed(result.StickyAccountID) {
+		return ScheduleLayerStickySession, result.StickyAccountID
+	}
+
+	if len(available) > 0 {
+		return ScheduleLayerLoadBalance, available[0].account.ID
+	}
+	if len(full) > 0 {
+		return ScheduleLayerWaitQueue, full[0].account.ID
+	}
+	return ScheduleLayerNone, 0
+}
@@ -0,0 +1,123 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// explainAccountRepo 在可调度列表之外返回全部活跃账号，用于验证被列表过滤掉的账号也会出现在解释结果中
+type explainAccountRepo struct {
+	*mockAccountRepoForPlatform
+}
+
+func (r *explainAccountRepo) ListByPlatform(ctx context.Context, platform string) ([]Account, error) {
+	var result []Account
+	for _, acc := range r.accounts {
+		if acc.Platform == platform && acc.IsActive() {
+			result = append(result, acc)
+		}
+	}
+	return result, nil
+}
+
+func TestGatewayService_ExplainAccountSelection(t *testing.T) {
+	ctx := context.Background()
+	future := time.Now().Add(time.Hour)
+
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			{ID: 1, Name: "primary", Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 2, Name: "limited", Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, RateLimitResetAt: &future},
+			{ID: 3, Name: "no-model", Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5,
+				Credentials: map[string]any{"model_mapping": map[string]any{"claude-other": "claude-other"}}},
+			{ID: 4, Name: "busy", Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 5, Name: "backup", Platform: PlatformAnthropic, Priority: 2, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 6, Name: "excluded", Platform: PlatformAnthropic, Priority: 0, Status: StatusActive, Schedulable: true, Concurrency: 5},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+
+	concurrencyCache := &mockConcurrencyCache{
+		loadMap: map[int64]*AccountLoadInfo{
+			4: {AccountID: 4, CurrentConcurrency: 5, LoadRate: 100},
+		},
+		acquireResults: map[int64]bool{},
+	}
+
+	cfg := testConfig()
+	cfg.Gateway.Scheduling.LoadBatchEnabled = true
+
+	svc := &GatewayService{
+		accountRepo:        &explainAccountRepo{mockAccountRepoForPlatform: repo},
+		cache:              &mockGatewayCacheForPlatform{},
+		cfg:                cfg,
+		concurrencyService: NewConcurrencyService(concurrencyCache),
+	}
+
+	result, err := svc.ExplainAccountSelection(ctx, &ScheduleExplainRequest{
+		Model:       "claude-3-5-sonnet-20241022",
+		ExcludedIDs: []int64{6},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, concurrencyCache.acquireAccountCalls, "dry-run 不应获取槽位")
+
+	stages := map[int64]string{}
+	for _, c := range result.Candidates {
+		stages[c.AccountID] = c.EliminatedAt
+	}
+	require.Equal(t, "", stages[1])
+	require.Equal(t, ScheduleStageUnschedulable, stages[2])
+	require.Len(t, result.Candidates, 6)
+	require.Equal(t, ScheduleStageModelUnsupported, stages[3])
+	require.Equal(t, ScheduleStageLoadFull, stages[4])
+	require.Equal(t, "", stages[5])
+	require.Equal(t, ScheduleStageExcluded, stages[6])
+
+	require.Equal(t, []int64{1, 5, 4}, result.Ordering)
+	require.Equal(t, ScheduleLayerLoadBalance, result.Layer)
+	require.Equal(t, int64(1), result.SelectedAccountID)
+}
+
+func TestGatewayService_ExplainAccountSelection_StickyFromRequestBody(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 2, Platform: PlatformAnthropic, Priority: 5, Status: StatusActive, Schedulable: true, Concurrency: 5},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+
+	svc := &GatewayService{
+		accountRepo: repo,
+		cfg:         testConfig(),
+	}
+
+	body := []byte(`{"model":"claude-3-5-sonnet-20241022","metadata":{"user_id":"user_abc_account__session_123e4567-e89b-12d3-a456-426614174000"},"messages":[{"role":"user","content":"hi"}]}`)
+	parsed, err := ParseGatewayRequest(body, PlatformAnthropic)
+	require.NoError(t, err)
+	expectedHash := svc.GenerateSessionHash(parsed)
+	require.NotEmpty(t, expectedHash)
+
+	svc.cache = &mockGatewayCacheForPlatform{sessionBindings: map[string]int64{expectedHash: 2}}
+
+	result, err := svc.ExplainAccountSelection(ctx, &ScheduleExplainRequest{RequestBody: body})
+	require.NoError(t, err)
+	require.Equal(t, expectedHash, result.SessionHash)
+	require.Equal(t, "claude-3-5-sonnet-20241022", result.Model)
+	require.Equal(t, int64(2), result.StickyAccountID)
+	require.Equal(t, ScheduleLayerStickySession, result.Layer)
+	require.Equal(t, int64(2), result.SelectedAccountID)
+}
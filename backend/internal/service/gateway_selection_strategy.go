@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	mathrand "math/rand"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// 分组账号选择策略
+const (
+	// SelectionStrategyDefault 优先级 → 负载率 → LRU（原有行为）
+	SelectionStrategyDefault = "default"
+	// SelectionStrategyWeighted 同优先级内按账号权重（extra.selection_weight）加权随机
+	SelectionStrategyWeighted = "weighted"
+	// SelectionStrategyLatency 同优先级内按 EWMA 首 token 延迟与错误率评分，评分越低越优先
+	SelectionStrategyLatency = "latency"
+)
+
+const (
+	// latencyMinSamples 样本数不足的账号视为最优，保证新账号/冷账号能被探索到
+	latencyMinSamples = 3
+	// latencyUnknownBaseMs 只有错误样本、尚无延迟数据时使用的基准延迟
+	latencyUnknownBaseMs = 1000.0
+	// latencyErrorPenalty 错误率惩罚系数：score = latency * (1 + penalty * errorRate)
+	latencyErrorPenalty = 4.0
+)
+
+// AccountLatencySample 一次请求的延迟/结果样本
+type AccountLatencySample struct {
+	FirstTokenMs *int
+	DurationMs   *int
+	Failed       bool
+}
+
+// AccountLatencyStats 账号近期延迟与错误率的 EWMA 统计
+type AccountLatencyStats struct {
+	FirstTokenMs float64 `json:"first_token_ms"`
+	DurationMs   float64 `json:"duration_ms"`
+	ErrorRate    float64 `json:"error_rate"`
+	Samples      int64   `json:"samples"`
+}
+
+// AccountLatencyCache 账号延迟统计缓存（Redis），供 latency 选择策略使用
+type AccountLatencyCache interface {
+	// RecordAccountLatency 以 EWMA 方式更新账号的延迟与错误率
+	RecordAccountLatency(ctx context.Context, accountID int64, sample AccountLatencySample) error
+	// GetAccountLatencyStats 批量获取账号统计，无数据的账号不出现在返回值中
+	GetAccountLatencyStats(ctx context.Context, accountIDs []int64) (map[int64]*AccountLatencyStats, error)
+}
+
+// IsValidSelectionStrategy 判断是否为支持的账号选择策略
+func IsValidSelectionStrategy(strategy string) bool {
+	switch strategy {
+	case SelectionStrategyDefault, SelectionStrategyWeighted, SelectionStrategyLatency:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizeSelectionStrategy 规范化分组选择策略，空值视为 default
+func normalizeSelectionStrategy(strategy string) (string, error) {
+	strategy = strings.ToLower(strings.TrimSpace(strategy))
+	if strategy == "" {
+		return SelectionStrategyDefault, nil
+	}
+	if !IsValidSelectionStrategy(strategy) {
+		return "", infraerrors.BadRequest("INVALID_SELECTION_STRATEGY", "selection_strategy must be one of default, weighted, latency")
+	}
+	return strategy, nil
+}
+
+// groupSelectionStrategy 返回分组生效的选择策略
+func groupSelectionStrategy(group *Group) string {
+	if group == nil || !IsValidSelectionStrategy(group.SelectionStrategy) {
+		return SelectionStrategyDefault
+	}
+	return group.SelectionStrategy
+}
+
+// SetAccountLatencyCache 设置账号延迟统计缓存（可选依赖，未设置时 latency 策略退化为负载率 + LRU）
+func (s *GatewayService) SetAccountLatencyCache(cache AccountLatencyCache) {
+	s.latencyCache = cache
+}
+
+// RecordAccountFailure 记录一次上游失败，用于 latency 策略的错误率统计
+func (s *GatewayService) RecordAccountFailure(ctx context.Context, accountID int64) {
+	s.recordAccountLatency(ctx, accountID, AccountLatencySample{Failed: true})
+}
+
+func (s *GatewayService) recordAccountLatency(ctx context.Context, accountID int64, sample AccountLatencySample) {
+	if s == nil {
+		return
+	}
+	recordAccountLatencySample(ctx, s.latencyCache, accountID, sample)
+}
+
+// loadAccountLatencyStats 批量读取候选账号的延迟统计，读取失败时返回 nil（所有账号视为无数据）
+func (s *GatewayService) loadAccountLatencyStats(ctx context.Context, accounts []accountWithLoad) map[int64]*AccountLatencyStats {
+	return loadAccountLatencyStatsFrom(ctx, s.latencyCache, accounts)
+}
+
+// SetAccountLatencyCache 设置账号延迟统计缓存（可选依赖，未设置时 latency 策略退化为负载率 + LRU）
+func (s *OpenAIGatewayService) SetAccountLatencyCache(cache AccountLatencyCache) {
+	s.latencyCache = cache
+}
+
+// RecordAccountFailure 记录一次上游失败，用于 latency 策略的错误率统计
+func (s *OpenAIGatewayService) RecordAccountFailure(ctx context.Context, accountID int64) {
+	s.recordAccountLatency(ctx, accountID, AccountLatencySample{Failed: true})
+}
+
+func (s *OpenAIGatewayService) recordAccountLatency(ctx context.Context, accountID int64, sample AccountLatencySample) {
+	if s == nil {
+		return
+	}
+	recordAccountLatencySample(ctx, s.latencyCache, accountID, sample)
+}
+
+func recordAccountLatencySample(ctx context.Context, cache AccountLatencyCache, accountID int64, sample AccountLatencySample) {
+	if cache == nil || accountID <= 0 {
+		return
+	}
+	if err := cache.RecordAccountLatency(ctx, accountID, sample); err != nil {
+		slog.Warn("account_latency_record_failed", "account_id", accountID, "error", err)
+	}
+}
+
+func loadAccountLatencyStatsFrom(ctx context.Context, cache AccountLatencyCache, accounts []accountWithLoad) map[int64]*AccountLatencyStats {
+	if cache == nil || len(accounts) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(accounts))
+	for _, acc := range accounts {
+		ids = append(ids, acc.account.ID)
+	}
+	stats, err := cache.GetAccountLatencyStats(ctx, ids)
+	if err != nil {
+		slog.Warn("account_latency_load_failed", "error", err)
+		return nil
+	}
+	return stats
+}
+
+// openAIGroupFromContext 从认证上下文取分组（由 API Key 认证中间件写入，OpenAI 网关不单独查询分组）
+func openAIGroupFromContext(ctx context.Context, groupID *int64) *Group {
+	if groupID == nil {
+		return nil
+	}
+	if group, ok := ctx.Value(ctxkey.Group).(*Group); ok && IsGroupContextValid(group) && group.ID == *groupID {
+		return group
+	}
+	return nil
+}
+
+// selectByStrategy 在同优先级集合中按分组策略选择一个账号
+func selectByStrategy(strategy string, accounts []accountWithLoad, preferOAuth bool, stats map[int64]*AccountLatencyStats) *accountWithLoad {
+	switch strategy {
+	case SelectionStrategyWeighted:
+		return selectByWeight(accounts)
+	case SelectionStrategyLatency:
+		return selectByLatencyScore(accounts, preferOAuth, stats)
+	default:
+		return selectByLRU(filterByMinLoadRate(accounts), preferOAuth)
+	}
+}
+
+// selectByWeight 按账号权重加权随机选择
+func selectByWeight(accounts []accountWithLoad) *accountWithLoad {
+	if len(accounts) == 0 {
+		return nil
+	}
+	total := 0
+	for _, acc := range accounts {
+		total += acc.account.GetSelectionWeight()
+	}
+	pick := mathrand.Intn(total)
+	for i := range accounts {
+		pick -= accounts[i].account.GetSelectionWeight()
+		if pick < 0 {
+			return &accounts[i]
+		}
+	}
+	return &accounts[len(accounts)-1]
+}
+
+// accountLatencyScore 计算账号延迟评分（越低越好）
+// 优先使用首 token 延迟，非流式请求退化为总耗时；再按错误率与当前负载放大
+func accountLatencyScore(stats *AccountLatencyStats, loadRate int) float64 {
+	if stats == nil || stats.Samples < latencyMinSamples {
+		return 0
+	}
+	base := stats.FirstTokenMs
+	if base <= 0 {
+		base = stats.DurationMs
+	}
+	if base <= 0 {
+		base = latencyUnknownBaseMs
+	}
+	score := base * (1 + latencyErrorPenalty*stats.ErrorRate)
+	return score * (1 + float64(loadRate)/100)
+}
+
+// selectByLatencyScore 选择评分最低的账号，评分相同时按 LRU 选择
+func selectByLatencyScore(accounts []accountWithLoad, preferOAuth bool, stats map[int64]*AccountLatencyStats) *accountWithLoad {
+	if len(accounts) == 0 {
+		return nil
+	}
+	if len(stats) == 0 {
+		return selectByLRU(filterByMinLoadRate(accounts), preferOAuth)
+	}
+	scores := make([]float64, len(accounts))
+	minScore := -1.0
+	for i, acc := range accounts {
+		scores[i] = accountLatencyScore(stats[acc.account.ID], acc.loadInfo.LoadRate)
+		if minScore < 0 || scores[i] < minScore {
+			minScore = scores[i]
+		}
+	}
+	best := make([]accountWithLoad, 0, len(accounts))
+	for i, acc := range accounts {
+		if scores[i] == minScore {
+			best = append(best, acc)
+		}
+	}
+	return selectByLRU(best, preferOAuth)
+}
@@ -0,0 +1,210 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/stretchr/testify/require"
+)
+
+type mockAccountLatencyCache struct {
+	stats   map[int64]*AccountLatencyStats
+	samples map[int64][]AccountLatencySample
+}
+
+func (m *mockAccountLatencyCache) RecordAccountLatency(ctx context.Context, accountID int64, sample AccountLatencySample) error {
+	if m.samples == nil {
+		m.samples = map[int64][]AccountLatencySample{}
+	}
+	m.samples[accountID] = append(m.samples[accountID], sample)
+	return nil
+}
+
+func (m *mockAccountLatencyCache) GetAccountLatencyStats(ctx context.Context, accountIDs []int64) (map[int64]*AccountLatencyStats, error) {
+	out := make(map[int64]*AccountLatencyStats, len(accountIDs))
+	for _, id := range accountIDs {
+		if st, ok := m.stats[id]; ok {
+			out[id] = st
+		}
+	}
+	return out, nil
+}
+
+func newStrategyTestService(strategy string, accounts []Account, latencyCache AccountLatencyCache) (*GatewayService, int64) {
+	groupID := int64(10)
+	repo := &mockAccountRepoForPlatform{
+		accounts:     accounts,
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+	groupRepo := &mockGroupRepoForGateway{
+		groups: map[int64]*Group{
+			groupID: {ID: groupID, Platform: PlatformAnthropic, Status: StatusActive, Hydrated: true, SelectionStrategy: strategy},
+		},
+	}
+	cfg := testConfig()
+	cfg.Gateway.Scheduling.LoadBatchEnabled = true
+
+	svc := &GatewayService{
+		accountRepo:  repo,
+		groupRepo:    groupRepo,
+		cache:        &mockGatewayCacheForPlatform{},
+		cfg:          cfg,
+		latencyCache: latencyCache,
+		concurrencyService: NewConcurrencyService(&mockConcurrencyCache{
+			loadMap: map[int64]*AccountLoadInfo{},
+		}),
+	}
+	return svc, groupID
+}
+
+func TestGatewayService_SelectionStrategy_Weighted(t *testing.T) {
+	ctx := context.Background()
+	accounts := []Account{
+		{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 100,
+			Extra: map[string]any{"selection_weight": float64(70)}},
+		{ID: 2, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 100,
+			Extra: map[string]any{"selection_weight": float64(30)}},
+		// 更低优先级的账号即使权重更高也不参与
+		{ID: 3, Platform: PlatformAnthropic, Priority: 2, Status: StatusActive, Schedulable: true, Concurrency: 100,
+			Extra: map[string]any{"selection_weight": float64(1000)}},
+	}
+	svc, groupID := newStrategyTestService(SelectionStrategyWeighted, accounts, nil)
+
+	counts := map[int64]int{}
+	const rounds = 2000
+	for i := 0; i < rounds; i++ {
+		result, err := svc.SelectAccountWithLoadAwareness(ctx, &groupID, "", "claude-3-5-sonnet-20241022", nil, "")
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+		counts[result.Account.ID]++
+	}
+
+	require.Zero(t, counts[3])
+	share := float64(counts[1]) / rounds
+	require.InDelta(t, 0.7, share, 0.06, "权重 70/30 的流量占比应接近 70%%，实际 %.2f", share)
+}
+
+func TestGatewayService_SelectionStrategy_Latency(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	accounts := []Account{
+		{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+		{ID: 2, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, LastUsedAt: &now},
+		{ID: 3, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+	}
+	latency := &mockAccountLatencyCache{
+		stats: map[int64]*AccountLatencyStats{
+			1: {FirstTokenMs: 2500, DurationMs: 9000, Samples: 20},
+			2: {FirstTokenMs: 600, DurationMs: 4000, Samples: 20},
+			// 首 token 更快但错误率高，评分被放大
+			3: {FirstTokenMs: 400, DurationMs: 3000, ErrorRate: 0.5, Samples: 20},
+		},
+	}
+	svc, groupID := newStrategyTestService(SelectionStrategyLatency, accounts, latency)
+
+	result, err := svc.SelectAccountWithLoadAwareness(ctx, &groupID, "", "claude-3-5-sonnet-20241022", nil, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Account.ID, "应选择 EWMA 评分最低的账号，而不是最久未用的账号")
+
+	// 样本不足的新账号优先被探索
+	latency.stats[1] = &AccountLatencyStats{FirstTokenMs: 2500, Samples: 1}
+	result, err = svc.SelectAccountWithLoadAwareness(ctx, &groupID, "", "claude-3-5-sonnet-20241022", nil, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Account.ID)
+}
+
+func TestGatewayService_SelectionStrategy_LatencyWithoutStatsFallsBackToLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	accounts := []Account{
+		{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, LastUsedAt: &now},
+		{ID: 2, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+	}
+	svc, groupID := newStrategyTestService(SelectionStrategyLatency, accounts, &mockAccountLatencyCache{})
+
+	result, err := svc.SelectAccountWithLoadAwareness(ctx, &groupID, "", "claude-3-5-sonnet-20241022", nil, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Account.ID)
+}
+
+func TestOpenAIGatewayService_SelectionStrategy_Latency(t *testing.T) {
+	now := time.Now()
+	groupID := int64(10)
+	repo := stubOpenAIAccountRepo{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformOpenAI, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 2, Platform: PlatformOpenAI, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, LastUsedAt: &now},
+		},
+	}
+	latency := &mockAccountLatencyCache{
+		stats: map[int64]*AccountLatencyStats{
+			1: {FirstTokenMs: 3000, Samples: 20},
+			2: {FirstTokenMs: 500, Samples: 20},
+		},
+	}
+	svc := &OpenAIGatewayService{
+		accountRepo:        repo,
+		cache:              &stubGatewayCache{},
+		concurrencyService: NewConcurrencyService(stubConcurrencyCache{}),
+		latencyCache:       latency,
+	}
+	group := &Group{ID: groupID, Platform: PlatformOpenAI, Status: StatusActive, Hydrated: true, SelectionStrategy: SelectionStrategyLatency}
+	ctx := context.WithValue(context.Background(), ctxkey.Group, group)
+
+	result, err := svc.SelectAccountWithLoadAwareness(ctx, &groupID, "", "gpt-4", nil)
+	require.NoError(t, err)
+	require.True(t, result.Acquired)
+	require.Equal(t, int64(2), result.Account.ID, "latency 策略应选择评分最低的账号，而不是最久未用的账号")
+
+	// 未设置策略（default）时保持负载率 → LRU
+	result, err = svc.SelectAccountWithLoadAwareness(context.Background(), &groupID, "", "gpt-4", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Account.ID)
+
+	svc.RecordAccountFailure(ctx, 2)
+	require.Len(t, latency.samples[2], 1)
+	require.True(t, latency.samples[2][0].Failed)
+}
+
+func TestAccountLatencyScore(t *testing.T) {
+	require.Zero(t, accountLatencyScore(nil, 0))
+	require.Zero(t, accountLatencyScore(&AccountLatencyStats{FirstTokenMs: 800, Samples: 2}, 0))
+	require.InDelta(t, 800, accountLatencyScore(&AccountLatencyStats{FirstTokenMs: 800, Samples: 5}, 0), 0.001)
+	// 非流式请求没有首 token 数据时使用总耗时
+	require.InDelta(t, 3000, accountLatencyScore(&AccountLatencyStats{DurationMs: 3000, Samples: 5}, 0), 0.001)
+	// 错误率与负载率放大评分
+	require.InDelta(t, 800*(1+latencyErrorPenalty*0.25)*1.5, accountLatencyScore(&AccountLatencyStats{FirstTokenMs: 800, ErrorRate: 0.25, Samples: 5}, 50), 0.001)
+	// 只有错误样本时使用基准延迟
+	require.InDelta(t, latencyUnknownBaseMs*(1+latencyErrorPenalty), accountLatencyScore(&AccountLatencyStats{ErrorRate: 1, Samples: 3}, 0), 0.001)
+}
+
+func TestNormalizeSelectionStrategy(t *testing.T) {
+	got, err := normalizeSelectionStrategy("")
+	require.NoError(t, err)
+	require.Equal(t, SelectionStrategyDefault, got)
+
+	got, err = normalizeSelectionStrategy(" Weighted ")
+	require.NoError(t, err)
+	require.Equal(t, SelectionStrategyWeighted, got)
+
+	_, err = normalizeSelectionStrategy("round_robin")
+	require.Error(t, err)
+
+	require.Equal(t, SelectionStrategyDefault, groupSelectionStrategy(nil))
+	require.Equal(t, SelectionStrategyDefault, groupSelectionStrategy(&Group{SelectionStrategy: "bogus"}))
+	require.Equal(t, SelectionStrategyLatency, groupSelectionStrategy(&Group{SelectionStrategy: SelectionStrategyLatency}))
+}
+
+func TestAccount_GetSelectionWeight(t *testing.T) {
+	require.Equal(t, 1, (&Account{}).GetSelectionWeight())
+	require.Equal(t, 1, (&Account{Extra: map[string]any{"selection_weight": float64(0)}}).GetSelectionWeight())
+	require.Equal(t, 70, (&Account{Extra: map[string]any{"selection_weight": float64(70)}}).GetSelectionWeight())
+	require.Equal(t, 5, (&Account{Extra: map[string]any{"selection_weight": "5"}}).GetSelectionWeight())
+}
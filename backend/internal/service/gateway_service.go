@@ -366,6 +366,9 @@ type ForwardResult struct {
 	// 图片生成计费字段（仅 gemini-3-pro-image 使用）
 	ImageCount int    // 生成的图片数量
 	ImageSize  string // 图片尺寸 "1K", "2K", "4K"
+
+	// RequestedModel 分组模型降级链生效时，客户端请求的原始模型（由 handler 设置）
+	RequestedModel string
 }
 
 // UpstreamFailoverError indicates an upstream error that should trigger account failover.
@@ -415,7 +418,9 @@ type GatewayService struct {
 	deferredService     *DeferredService
 	concurrencyService  *ConcurrencyService
 	claudeTokenProvider *ClaudeTokenProvider
-	sessionLimitCache   SessionLimitCache // 会话数量限制缓存（仅 Anthropic OAuth/SetupToken）
+	sessionLimitCache   SessionLimitCache   // 会话数量限制缓存（仅 Anthropic OAuth/SetupToken）
+	latencyCache        AccountLatencyCache // 账号延迟统计缓存（latency 选择策略使用）
+	usageWriter         *UsageWriteService  // 使用记录异步写入（未启用时同步写入）
 }
 
 // NewGatewayService creates a new GatewayService
@@ -1384,14 +1389,17 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 			}
 		}
 
-		// 分层过滤选择：优先级 → 负载率 → LRU
+		// 分层过滤选择：优先级 → 分组选择策略（默认：负载率 → LRU）
+		strategy := groupSelectionStrategy(group)
+		var latencyStats map[int64]*AccountLatencyStats
+		if strategy == SelectionStrategyLatency {
+			latencyStats = s.loadAccountLatencyStats(ctx, available)
+		}
 		for len(available) > 0 {
 			// 1. 取优先级最小的集合
 			candidates := filterByMinPriority(available)
-			// 2. 取负载率最低的集合
-			candidates = filterByMinLoadRate(candidates)
-			// 3. LRU 选择最久未用的账号
-			selected := selectByLRU(candidates, preferOAuth)
+			// 2. 按策略选择：default 取负载率最低集合后 LRU；weighted 按权重随机；latency 按延迟评分
+			selected := selectByStrategy(strategy, candidates, preferOAuth, latencyStats)
 			if selected == nil {
 				break
 			}
@@ -4523,6 +4531,11 @@ func (s *GatewayService) replaceModelInResponseBody(body []byte, fromModel, toMo
 	return newBody
 }
 
+// SetUsageWriteService 设置使用记录异步写入服务（可选依赖，未设置或未启用时同步写入）
+func (s *GatewayService) SetUsageWriteService(writer *UsageWriteService) {
+	s.usageWriter = writer
+}
+
 // RecordUsageInput 记录使用量的输入参数
 type RecordUsageInput struct {
 	Result            *ForwardResult
@@ -4534,6 +4547,7 @@ type RecordUsageInput struct {
 	IPAddress         string             // 请求的客户端 IP 地址
 	ForceCacheBilling bool               // 强制缓存计费：将 input_tokens 转为 cache_read 计费（用于粘性会话切换）
 	APIKeyService     APIKeyQuotaUpdater // 可选：用于更新API Key配额
+	Tags              map[string]string  // 成本归属标签（已通过白名单校验）
 }
 
 // APIKeyQuotaUpdater defines the interface for updating API Key quota
@@ -4620,6 +4634,9 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 	usageLog := &UsageLog{
 		UserID:                user.ID,
 		APIKeyID:              apiKey.ID,
+		KeyGeneration:         apiKey.SecretGeneration,
+		Tags:                  input.Tags,
+		OrganizationID:        apiKey.OrganizationID,
 		AccountID:             account.ID,
 		RequestID:             result.RequestID,
 		Model:                 result.Model,
@@ -4645,6 +4662,10 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		ImageSize:             imageSize,
 		CreatedAt:             time.Now(),
 	}
+	if result.RequestedModel != "" && result.RequestedModel != result.Model {
+		requestedModel := result.RequestedModel
+		usageLog.RequestedModel = &requestedModel
+	}
 
 	// 添加 UserAgent
 	if input.UserAgent != "" {
@@ -4664,10 +4685,27 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		usageLog.SubscriptionID = &subscription.ID
 	}
 
+	// 异步写入：记录入队后由后台批量落库并扣费，此处仅更新 Redis 扣费缓存
+	if s.usageWriter.Enabled() {
+		var charge UsageCharge
+		if s.cfg == nil || s.cfg.RunMode != config.RunModeSimple {
+			charge = newUsageCharge(user, apiKey, subscription, cost, isSubscriptionBilling, input.APIKeyService)
+		}
+		if s.usageWriter.Submit(ctx, usageLog, charge) {
+			applyUsageChargeCache(s.billingCacheService, charge)
+			s.recordAccountLatency(ctx, account.ID, AccountLatencySample{FirstTokenMs: result.FirstTokenMs, DurationMs: &durationMs})
+			s.deferredService.ScheduleLastUsedUpdate(account.ID)
+			return nil
+		}
+	}
+
 	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
 	if err != nil {
 		log.Printf("Create usage log failed: %v", err)
 	}
+	if inserted || err != nil {
+		s.recordAccountLatency(ctx, account.ID, AccountLatencySample{FirstTokenMs: result.FirstTokenMs, DurationMs: &durationMs})
+	}
 
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
 		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
@@ -4685,9 +4723,9 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 				log.Printf("Increment subscription usage failed: %v", err)
 			}
 			// 异步更新订阅缓存
-			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
+			s.billingCacheService.QueueUpdateSubscriptionUsage(subscriptionUserID(user, apiKey), *apiKey.GroupID, cost.TotalCost)
 		}
-	} else {
+	} else if apiKey.OrganizationID == nil {
 		// 余额模式：扣除用户余额（使用 ActualCost 考虑倍率后的费用）
 		if shouldBill && cost.ActualCost > 0 {
 			if err := s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost); err != nil {
@@ -4695,9 +4733,15 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 			}
 			// 异步更新余额缓存
 			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+			s.billingCacheService.RecordReferralSpend(ctx, user.ID, cost.ActualCost)
 		}
 	}
 
+	// 组织 Key：累加成员月度用量，余额模式下从组织资金池扣费
+	if shouldBill && apiKey.OrganizationID != nil {
+		s.billingCacheService.RecordOrganizationUsage(ctx, apiKey, cost.ActualCost, !isSubscriptionBilling)
+	}
+
 	// 更新 API Key 配额（如果设置了配额限制）
 	if shouldBill && cost.ActualCost > 0 && apiKey.Quota > 0 && input.APIKeyService != nil {
 		if err := input.APIKeyService.UpdateQuotaUsed(ctx, apiKey.ID, cost.ActualCost); err != nil {
@@ -4705,6 +4749,11 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		}
 	}
 
+	// 更新 API Key 日/周/月消费缓存（仅配置了消费上限时）
+	if shouldBill && cost.ActualCost > 0 && apiKey.HasSpendLimits() {
+		s.billingCacheService.QueueUpdateAPIKeySpend(apiKey.ID, cost.ActualCost)
+	}
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
@@ -4724,6 +4773,7 @@ type RecordUsageLongContextInput struct {
 	LongContextMultiplier float64           // 超出阈值部分的倍率（如 2.0）
 	ForceCacheBilling     bool              // 强制缓存计费：将 input_tokens 转为 cache_read 计费（用于粘性会话切换）
 	APIKeyService         *APIKeyService    // API Key 配额服务（可选）
+	Tags                  map[string]string // 成本归属标签（已通过白名单校验）
 }
 
 // RecordUsageWithLongContext 记录使用量并扣费，支持长上下文双倍计费（用于 Gemini）
@@ -4805,6 +4855,9 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 	usageLog := &UsageLog{
 		UserID:                user.ID,
 		APIKeyID:              apiKey.ID,
+		KeyGeneration:         apiKey.SecretGeneration,
+		Tags:                  input.Tags,
+		OrganizationID:        apiKey.OrganizationID,
 		AccountID:             account.ID,
 		RequestID:             result.RequestID,
 		Model:                 result.Model,
@@ -4830,6 +4883,10 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 		ImageSize:             imageSize,
 		CreatedAt:             time.Now(),
 	}
+	if result.RequestedModel != "" && result.RequestedModel != result.Model {
+		requestedModel := result.RequestedModel
+		usageLog.RequestedModel = &requestedModel
+	}
 
 	// 添加 UserAgent
 	if input.UserAgent != "" {
@@ -4849,10 +4906,31 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 		usageLog.SubscriptionID = &subscription.ID
 	}
 
+	// 异步写入：记录入队后由后台批量落库并扣费，此处仅更新 Redis 扣费缓存
+	if s.usageWriter.Enabled() {
+		var charge UsageCharge
+		if s.cfg == nil || s.cfg.RunMode != config.RunModeSimple {
+			var quotaUpdater APIKeyQuotaUpdater
+			if input.APIKeyService != nil && !isSubscriptionBilling {
+				quotaUpdater = input.APIKeyService
+			}
+			charge = newUsageCharge(user, apiKey, subscription, cost, isSubscriptionBilling, quotaUpdater)
+		}
+		if s.usageWriter.Submit(ctx, usageLog, charge) {
+			applyUsageChargeCache(s.billingCacheService, charge)
+			s.recordAccountLatency(ctx, account.ID, AccountLatencySample{FirstTokenMs: result.FirstTokenMs, DurationMs: &durationMs})
+			s.deferredService.ScheduleLastUsedUpdate(account.ID)
+			return nil
+		}
+	}
+
 	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
 	if err != nil {
 		log.Printf("Create usage log failed: %v", err)
 	}
+	if inserted || err != nil {
+		s.recordAccountLatency(ctx, account.ID, AccountLatencySample{FirstTokenMs: result.FirstTokenMs, DurationMs: &durationMs})
+	}
 
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
 		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
@@ -4870,16 +4948,19 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 				log.Printf("Increment subscription usage failed: %v", err)
 			}
 			// 异步更新订阅缓存
-			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
+			s.billingCacheService.QueueUpdateSubscriptionUsage(subscriptionUserID(user, apiKey), *apiKey.GroupID, cost.TotalCost)
 		}
 	} else {
-		// 余额模式：扣除用户余额（使用 ActualCost 考虑倍率后的费用）
+		// 余额模式：扣除用户余额（使用 ActualCost 考虑倍率后的费用；组织 Key 从组织资金池扣除）
 		if shouldBill && cost.ActualCost > 0 {
-			if err := s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost); err != nil {
-				log.Printf("Deduct balance failed: %v", err)
+			if apiKey.OrganizationID == nil {
+				if err := s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost); err != nil {
+					log.Printf("Deduct balance failed: %v", err)
+				}
+				// 异步更新余额缓存
+				s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+				s.billingCacheService.RecordReferralSpend(ctx, user.ID, cost.ActualCost)
 			}
-			// 异步更新余额缓存
-			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
 			// API Key 独立配额扣费
 			if input.APIKeyService != nil && apiKey.Quota > 0 {
 				if err := input.APIKeyService.UpdateQuotaUsed(ctx, apiKey.ID, cost.ActualCost); err != nil {
@@ -4889,6 +4970,16 @@ func (s *GatewayService) RecordUsageWithLongContext(ctx context.Context, input *
 		}
 	}
 
+	// 组织 Key：累加成员月度用量，余额模式下从组织资金池扣费
+	if shouldBill && apiKey.OrganizationID != nil {
+		s.billingCacheService.RecordOrganizationUsage(ctx, apiKey, cost.ActualCost, !isSubscriptionBilling)
+	}
+
+	// 更新 API Key 日/周/月消费缓存（仅配置了消费上限时）
+	if shouldBill && cost.ActualCost > 0 && apiKey.HasSpendLimits() {
+		s.billingCacheService.QueueUpdateAPIKeySpend(apiKey.ID, cost.ActualCost)
+	}
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
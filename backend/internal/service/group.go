@@ -48,6 +48,18 @@ type Group struct {
 	// 分组排序
 	SortOrder int
 
+	// 账号选择策略：default/weighted/latency（见 SelectionStrategy* 常量）
+	SelectionStrategy string
+
+	// 用户公平分配：开启后按 max-min 公平限制每个用户在分组内的账号并发占用
+	FairShareEnabled bool
+
+	// 模型降级链
+	// key: 模型匹配模式（支持 * 通配符，如 "claude-opus-*"）
+	// value: 按顺序尝试的降级模型列表
+	ModelFallback        map[string][]string
+	ModelFallbackEnabled bool
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -134,6 +146,31 @@ func (g *Group) GetRoutingAccountIDs(requestedModel string) []int64 {
 	return nil
 }
 
+// GetModelFallbackChain 根据请求模型获取降级模型链
+// 精确匹配优先，其次选择最长的通配符模式；未启用或无匹配规则时返回 nil
+func (g *Group) GetModelFallbackChain(requestedModel string) []string {
+	if !g.ModelFallbackEnabled || len(g.ModelFallback) == 0 || requestedModel == "" {
+		return nil
+	}
+
+	if chain, ok := g.ModelFallback[requestedModel]; ok && len(chain) > 0 {
+		return chain
+	}
+
+	var matched []string
+	matchedLen := -1
+	for pattern, chain := range g.ModelFallback {
+		if len(chain) == 0 || len(pattern) <= matchedLen {
+			continue
+		}
+		if matchModelPattern(pattern, requestedModel) {
+			matched = chain
+			matchedLen = len(pattern)
+		}
+	}
+	return matched
+}
+
 // matchModelPattern 检查模型是否匹配模式
 // 支持 * 通配符，如 "claude-opus-*" 匹配 "claude-opus-4-20250514"
 func matchModelPattern(pattern, model string) bool {
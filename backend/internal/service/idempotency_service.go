@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/google/uuid"
+)
+
+const (
+	// IdempotencyKeyHeader 客户端携带的幂等键请求头
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyReplayedHeader 重放保存的响应时附加的响应头
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyKeyMaxLength = 255
+	// idempotencyRequestIDPrefix 幂等请求写入 usage_logs.request_id 的前缀（总长 45，小于列宽 64）
+	idempotencyRequestIDPrefix = "idem-"
+	idempotencyPollInterval    = 200 * time.Millisecond
+)
+
+const (
+	IdempotencyStateInFlight  = "in_flight"
+	IdempotencyStateCompleted = "completed"
+)
+
+var (
+	ErrIdempotencyKeyInvalid     = infraerrors.BadRequest("IDEMPOTENCY_KEY_INVALID", "Idempotency-Key must be 1-255 printable ASCII characters")
+	ErrIdempotencyKeyMismatch    = infraerrors.New(http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_MISMATCH", "Idempotency-Key was already used with a different request body")
+	ErrIdempotencyInProgress     = infraerrors.Conflict("IDEMPOTENCY_IN_PROGRESS", "a request with this Idempotency-Key is still in progress")
+	ErrIdempotencyStreamingDup   = infraerrors.Conflict("IDEMPOTENCY_STREAM_CONFLICT", "a streaming request with this Idempotency-Key was already sent; streaming responses cannot be replayed")
+	ErrIdempotencyKeyAlreadyUsed = infraerrors.Conflict("IDEMPOTENCY_KEY_USED", "Idempotency-Key was already used and its response cannot be replayed")
+)
+
+// IdempotencyRecord 幂等键对应的请求状态与保存的响应
+type IdempotencyRecord struct {
+	State       string `json:"state"`
+	Fingerprint string `json:"fingerprint"`
+	Stream      bool   `json:"stream"`
+	CreatedAt   int64  `json:"created_at"`
+
+	// 以下字段仅在 State 为 completed 时有效
+	Replayable bool              `json:"replayable,omitempty"`
+	StatusCode int               `json:"status_code,omitempty"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       []byte            `json:"body,omitempty"`
+}
+
+// IdempotencyCache 幂等记录存储（Redis）
+// 每条记录带有 owner 标识，只有占位的请求才能写入结果或释放占位。
+type IdempotencyCache interface {
+	// Claim 键不存在时以 owner 身份写入记录并返回 true；
+	// 已存在时返回 false 与现有记录（现有记录可能恰好过期，此时为空）
+	Claim(ctx context.Context, key, owner string, record []byte, ttl time.Duration) (bool, []byte, error)
+	// Complete owner 匹配时覆盖记录并重设过期时间
+	Complete(ctx context.Context, key, owner string, record []byte, ttl time.Duration) (bool, error)
+	// Release owner 匹配时删除记录
+	Release(ctx context.Context, key, owner string) error
+}
+
+// IdempotencyUsageLookup 查询幂等请求是否已产生使用记录
+type IdempotencyUsageLookup interface {
+	ExistsByRequestID(ctx context.Context, apiKeyID int64, requestID string) (bool, error)
+}
+
+// IdempotencyPendingUsageLookup 查询幂等请求的使用记录是否仍在异步写入队列中
+type IdempotencyPendingUsageLookup interface {
+	HasPendingUsage(ctx context.Context, apiKeyID int64, requestID string) (bool, error)
+}
+
+// IdempotencyBeginInput 幂等请求开始处理时的参数
+type IdempotencyBeginInput struct {
+	APIKeyID    int64
+	Key         string
+	Fingerprint string
+	Stream      bool
+}
+
+// IdempotencyClaim 当前请求获得的执行权
+type IdempotencyClaim struct {
+	// RequestID 写入 usage_logs.request_id，保证同一 Key 的重试不会重复计费
+	RequestID string
+
+	cacheKey    string
+	fingerprint string
+	stream      bool
+	// owner 为空表示 Redis 不可用时的降级执行，不写入结果
+	owner string
+}
+
+// IdempotencyResponse 请求完成后需要保存的响应
+type IdempotencyResponse struct {
+	StatusCode int
+	Header     map[string]string
+	Body       []byte
+	// Replayable 为 false（流式或响应过大）时仅标记幂等键已使用，重复请求返回冲突错误
+	Replayable bool
+}
+
+// IdempotencyBeginResult Begin 的结果：要么获得执行权，要么返回可重放的响应
+type IdempotencyBeginResult struct {
+	Claim  *IdempotencyClaim
+	Replay *IdempotencyRecord
+}
+
+// IdempotencyService 处理网关请求的 Idempotency-Key
+//
+// 同一 API Key 下相同的幂等键在 TTL 内：
+//   - 已完成的非流式请求直接返回保存的响应，不再调用上游；
+//   - 进行中的非流式请求等待其完成后返回同一响应；
+//   - 流式请求（或响应过大无法保存）返回冲突错误。
+//
+// 幂等键同时映射为使用记录的 request_id。Redis 记录过期或丢失后，获得执行权前会检查
+// 该 request_id 是否已落库（usage_log_request_ids 登记）或仍在异步写入队列中，
+// 已执行过的请求不再转发上游；并发穿透时由 usage_log_request_ids 的登记阻止重复计费。
+type IdempotencyService struct {
+	cache        IdempotencyCache
+	usageLookup  IdempotencyUsageLookup
+	pendingUsage IdempotencyPendingUsageLookup
+	cfg          config.GatewayIdempotencyConfig
+}
+
+// NewIdempotencyService 创建幂等请求服务
+func NewIdempotencyService(cache IdempotencyCache, usageLookup IdempotencyUsageLookup, usageWriteService *UsageWriteService, cfg *config.Config) *IdempotencyService {
+	svc := &IdempotencyService{cache: cache, usageLookup: usageLookup}
+	if usageWriteService != nil {
+		svc.pendingUsage = usageWriteService
+	}
+	if cfg != nil {
+		svc.cfg = cfg.Gateway.Idempotency
+	}
+	return svc
+}
+
+// Enabled 是否识别 Idempotency-Key
+func (s *IdempotencyService) Enabled() bool {
+	return s != nil && s.cfg.Enabled && s.cache != nil
+}
+
+// MaxResponseBytes 可保存以供重放的最大响应体大小
+func (s *IdempotencyService) MaxResponseBytes() int {
+	return s.cfg.MaxResponseBytes
+}
+
+// ValidateIdempotencyKey 校验幂等键：1-255 个可打印 ASCII 字符
+func ValidateIdempotencyKey(key string) error {
+	if key == "" || len(key) > idempotencyKeyMaxLength {
+		return ErrIdempotencyKeyInvalid
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return ErrIdempotencyKeyInvalid
+		}
+	}
+	return nil
+}
+
+// IdempotencyRequestID 幂等键对应的使用记录 request_id
+func IdempotencyRequestID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return idempotencyRequestIDPrefix + hex.EncodeToString(sum[:])[:40]
+}
+
+func idempotencyCacheKey(apiKeyID int64, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return strconv.FormatInt(apiKeyID, 10) + ":" + hex.EncodeToString(sum[:])
+}
+
+// Begin 为请求获取幂等键的执行权。
+// 非流式请求遇到进行中的同键请求时最多等待 wait_timeout_seconds。
+func (s *IdempotencyService) Begin(ctx context.Context, in IdempotencyBeginInput) (*IdempotencyBeginResult, error) {
+	if err := ValidateIdempotencyKey(in.Key); err != nil {
+		return nil, err
+	}
+	claim := &IdempotencyClaim{
+		RequestID:   IdempotencyRequestID(in.Key),
+		cacheKey:    idempotencyCacheKey(in.APIKeyID, in.Key),
+		fingerprint: in.Fingerprint,
+		stream:      in.Stream,
+		owner:       uuid.NewString(),
+	}
+	payload, err := json.Marshal(&IdempotencyRecord{
+		State:       IdempotencyStateInFlight,
+		Fingerprint: in.Fingerprint,
+		Stream:      in.Stream,
+		CreatedAt:   time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lockTTL := time.Duration(s.cfg.LockTTLSeconds) * time.Second
+	deadline := time.Now().Add(time.Duration(s.cfg.WaitTimeoutSeconds) * time.Second)
+	for {
+		claimed, raw, err := s.cache.Claim(ctx, claim.cacheKey, claim.owner, payload, lockTTL)
+		if err != nil {
+			// Redis 不可用时降级执行：仍使用固定的 request_id，重复计费由 usage_log_request_ids 登记兜底
+			log.Printf("[Idempotency] claim failed, proceeding without replay: api_key=%d err=%v", in.APIKeyID, err)
+			claim.owner = ""
+			if err := s.checkUsed(ctx, in.APIKeyID, claim); err != nil {
+				return nil, err
+			}
+			return &IdempotencyBeginResult{Claim: claim}, nil
+		}
+		if claimed {
+			if err := s.checkUsed(ctx, in.APIKeyID, claim); err != nil {
+				s.Release(context.WithoutCancel(ctx), claim)
+				return nil, err
+			}
+			return &IdempotencyBeginResult{Claim: claim}, nil
+		}
+
+		if len(raw) > 0 {
+			var existing IdempotencyRecord
+			if err := json.Unmarshal(raw, &existing); err != nil {
+				return nil, fmt.Errorf("decode idempotency record: %w", err)
+			}
+			if existing.Fingerprint != in.Fingerprint {
+				return nil, ErrIdempotencyKeyMismatch
+			}
+			if existing.State == IdempotencyStateCompleted {
+				if !existing.Replayable {
+					return nil, ErrIdempotencyKeyAlreadyUsed
+				}
+				return &IdempotencyBeginResult{Replay: &existing}, nil
+			}
+			if in.Stream || existing.Stream {
+				return nil, ErrIdempotencyStreamingDup
+			}
+		}
+
+		// 进行中：等待原请求完成（或失败释放后由本请求接管）
+		if !time.Now().Before(deadline) {
+			return nil, ErrIdempotencyInProgress
+		}
+		timer := time.NewTimer(idempotencyPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// checkUsed Redis 记录过期或丢失后，已计费或计费仍在异步写入队列中的幂等键不允许再次执行
+func (s *IdempotencyService) checkUsed(ctx context.Context, apiKeyID int64, claim *IdempotencyClaim) error {
+	if s.usageLookup != nil {
+		exists, err := s.usageLookup.ExistsByRequestID(ctx, apiKeyID, claim.RequestID)
+		if err != nil {
+			log.Printf("[Idempotency] usage lookup failed: api_key=%d err=%v", apiKeyID, err)
+		} else if exists {
+			return ErrIdempotencyKeyAlreadyUsed
+		}
+	}
+	if s.pendingUsage != nil {
+		pending, err := s.pendingUsage.HasPendingUsage(ctx, apiKeyID, claim.RequestID)
+		if err != nil {
+			log.Printf("[Idempotency] pending usage lookup failed: api_key=%d err=%v", apiKeyID, err)
+		} else if pending {
+			return ErrIdempotencyKeyAlreadyUsed
+		}
+	}
+	return nil
+}
+
+// Complete 保存请求结果，TTL 内的重复请求据此重放或返回冲突错误
+func (s *IdempotencyService) Complete(ctx context.Context, claim *IdempotencyClaim, resp *IdempotencyResponse) {
+	if claim == nil || claim.owner == "" || resp == nil {
+		return
+	}
+	record := &IdempotencyRecord{
+		State:       IdempotencyStateCompleted,
+		Fingerprint: claim.fingerprint,
+		Stream:      claim.stream,
+		CreatedAt:   time.Now().Unix(),
+		Replayable:  resp.Replayable,
+		StatusCode:  resp.StatusCode,
+	}
+	if resp.Replayable {
+		record.Header = resp.Header
+		record.Body = resp.Body
+	}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("[Idempotency] encode record failed: %v", err)
+		return
+	}
+	ok, err := s.cache.Complete(ctx, claim.cacheKey, claim.owner, payload, time.Duration(s.cfg.TTLSeconds)*time.Second)
+	if err != nil {
+		log.Printf("[Idempotency] complete failed: %v", err)
+		return
+	}
+	if !ok {
+		// 占位已过期并被其他请求接管，本次结果不再保存
+		log.Printf("[Idempotency] lock lost before completion: request_id=%s", claim.RequestID)
+	}
+}
+
+// Release 请求失败时释放占位，客户端可使用同一幂等键重试
+func (s *IdempotencyService) Release(ctx context.Context, claim *IdempotencyClaim) {
+	if claim == nil || claim.owner == "" {
+		return
+	}
+	if err := s.cache.Release(ctx, claim.cacheKey, claim.owner); err != nil {
+		log.Printf("[Idempotency] release failed: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type idempotencyCacheStub struct {
+	mu      sync.Mutex
+	owners  map[string]string
+	records map[string][]byte
+	err     error
+}
+
+func newIdempotencyCacheStub() *idempotencyCacheStub {
+	return &idempotencyCacheStub{owners: map[string]string{}, records: map[string][]byte{}}
+}
+
+func (s *idempotencyCacheStub) Claim(ctx context.Context, key, owner string, record []byte, ttl time.Duration) (bool, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return false, nil, s.err
+	}
+	if existing, ok := s.records[key]; ok {
+		return false, existing, nil
+	}
+	s.owners[key] = owner
+	s.records[key] = record
+	return true, nil, nil
+}
+
+func (s *idempotencyCacheStub) Complete(ctx context.Context, key, owner string, record []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owners[key] != owner {
+		return false, nil
+	}
+	s.records[key] = record
+	return true, nil
+}
+
+func (s *idempotencyCacheStub) Release(ctx context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owners[key] == owner {
+		delete(s.owners, key)
+		delete(s.records, key)
+	}
+	return nil
+}
+
+type idempotencyUsageLookupStub struct {
+	existing map[string]bool
+}
+
+func (s *idempotencyUsageLookupStub) ExistsByRequestID(ctx context.Context, apiKeyID int64, requestID string) (bool, error) {
+	return s.existing[requestID], nil
+}
+
+func newTestIdempotencyService(cache IdempotencyCache, lookup IdempotencyUsageLookup, waitSeconds int) *IdempotencyService {
+	cfg := &config.Config{}
+	cfg.Gateway.Idempotency = config.GatewayIdempotencyConfig{
+		Enabled:            true,
+		TTLSeconds:         60,
+		LockTTLSeconds:     30,
+		WaitTimeoutSeconds: waitSeconds,
+		MaxResponseBytes:   1024,
+	}
+	return NewIdempotencyService(cache, lookup, nil, cfg)
+}
+
+func TestIdempotencyServiceReplaysCompletedResponse(t *testing.T) {
+	svc := newTestIdempotencyService(newIdempotencyCacheStub(), nil, 0)
+	in := IdempotencyBeginInput{APIKeyID: 1, Key: "k-1", Fingerprint: "fp"}
+
+	first, err := svc.Begin(context.Background(), in)
+	require.NoError(t, err)
+	require.NotNil(t, first.Claim)
+	require.Equal(t, IdempotencyRequestID("k-1"), first.Claim.RequestID)
+	svc.Complete(context.Background(), first.Claim, &IdempotencyResponse{
+		StatusCode: 200,
+		Header:     map[string]string{"Content-Type": "application/json"},
+		Body:       []byte(`{"id":"msg_1"}`),
+		Replayable: true,
+	})
+
+	second, err := svc.Begin(context.Background(), in)
+	require.NoError(t, err)
+	require.Nil(t, second.Claim)
+	require.NotNil(t, second.Replay)
+	require.Equal(t, 200, second.Replay.StatusCode)
+	require.Equal(t, `{"id":"msg_1"}`, string(second.Replay.Body))
+
+	// 同一幂等键在其他 API Key 下互不影响
+	other, err := svc.Begin(context.Background(), IdempotencyBeginInput{APIKeyID: 2, Key: "k-1", Fingerprint: "fp"})
+	require.NoError(t, err)
+	require.NotNil(t, other.Claim)
+}
+
+func TestIdempotencyServiceRejectsMismatchAndStreaming(t *testing.T) {
+	svc := newTestIdempotencyService(newIdempotencyCacheStub(), nil, 0)
+	first, err := svc.Begin(context.Background(), IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "fp", Stream: true})
+	require.NoError(t, err)
+	require.NotNil(t, first.Claim)
+
+	_, err = svc.Begin(context.Background(), IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "other"})
+	require.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+
+	_, err = svc.Begin(context.Background(), IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "fp", Stream: true})
+	require.ErrorIs(t, err, ErrIdempotencyStreamingDup)
+
+	svc.Complete(context.Background(), first.Claim, &IdempotencyResponse{StatusCode: 200})
+	_, err = svc.Begin(context.Background(), IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "fp", Stream: true})
+	require.ErrorIs(t, err, ErrIdempotencyKeyAlreadyUsed)
+}
+
+func TestIdempotencyServiceWaitsForInFlightRequest(t *testing.T) {
+	svc := newTestIdempotencyService(newIdempotencyCacheStub(), nil, 5)
+	in := IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "fp"}
+	first, err := svc.Begin(context.Background(), in)
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		svc.Complete(context.Background(), first.Claim, &IdempotencyResponse{StatusCode: 200, Body: []byte("ok"), Replayable: true})
+	}()
+
+	second, err := svc.Begin(context.Background(), in)
+	require.NoError(t, err)
+	require.NotNil(t, second.Replay)
+	require.Equal(t, "ok", string(second.Replay.Body))
+}
+
+func TestIdempotencyServiceInProgressTimeoutAndRelease(t *testing.T) {
+	svc := newTestIdempotencyService(newIdempotencyCacheStub(), nil, 0)
+	in := IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "fp"}
+	first, err := svc.Begin(context.Background(), in)
+	require.NoError(t, err)
+
+	_, err = svc.Begin(context.Background(), in)
+	require.ErrorIs(t, err, ErrIdempotencyInProgress)
+
+	// 失败释放后重试可重新获得执行权
+	svc.Release(context.Background(), first.Claim)
+	retry, err := svc.Begin(context.Background(), in)
+	require.NoError(t, err)
+	require.NotNil(t, retry.Claim)
+}
+
+func TestIdempotencyServiceRejectsKeyAlreadyBilled(t *testing.T) {
+	cache := newIdempotencyCacheStub()
+	lookup := &idempotencyUsageLookupStub{existing: map[string]bool{IdempotencyRequestID("k"): true}}
+	svc := newTestIdempotencyService(cache, lookup, 0)
+
+	_, err := svc.Begin(context.Background(), IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "fp"})
+	require.ErrorIs(t, err, ErrIdempotencyKeyAlreadyUsed)
+	// 占位已释放
+	require.Empty(t, cache.records)
+}
+
+type idempotencyPendingUsageStub struct {
+	pending map[string]bool
+	err     error
+}
+
+func (s *idempotencyPendingUsageStub) HasPendingUsage(ctx context.Context, apiKeyID int64, requestID string) (bool, error) {
+	return s.pending[requestID], s.err
+}
+
+func TestIdempotencyServiceRejectsKeyPendingInUsageQueue(t *testing.T) {
+	cache := newIdempotencyCacheStub()
+	svc := newTestIdempotencyService(cache, &idempotencyUsageLookupStub{}, 0)
+	svc.pendingUsage = &idempotencyPendingUsageStub{pending: map[string]bool{IdempotencyRequestID("k"): true}}
+
+	_, err := svc.Begin(context.Background(), IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "fp"})
+	require.ErrorIs(t, err, ErrIdempotencyKeyAlreadyUsed)
+	require.Empty(t, cache.records)
+
+	// 队列查询失败时不阻断请求
+	svc.pendingUsage = &idempotencyPendingUsageStub{err: errors.New("redis down")}
+	result, err := svc.Begin(context.Background(), IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "fp"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Claim)
+}
+
+// usageWriterLookupStub 以批量写入桩已写入的 request_id 作为使用记录查询结果
+type usageWriterLookupStub struct {
+	writer *usageLogBatchWriterStub
+}
+
+func (s *usageWriterLookupStub) ExistsByRequestID(ctx context.Context, apiKeyID int64, requestID string) (bool, error) {
+	return s.writer.existing[requestID], nil
+}
+
+func TestIdempotencyServiceRejectsKeyBilledThroughAsyncWrite(t *testing.T) {
+	env := newUsageWriteTestEnv(t)
+	cache := newIdempotencyCacheStub()
+	svc := newTestIdempotencyService(cache, &usageWriterLookupStub{writer: env.writer}, 0)
+	svc.pendingUsage = env.svc
+	in := IdempotencyBeginInput{APIKeyID: 10, Key: "k", Fingerprint: "fp"}
+	expire := func() {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		cache.records = map[string][]byte{}
+		cache.owners = map[string]string{}
+	}
+
+	result, err := svc.Begin(context.Background(), in)
+	require.NoError(t, err)
+	usageLog := &UsageLog{UserID: 1, APIKeyID: 10, RequestID: result.Claim.RequestID}
+	require.True(t, env.svc.Submit(context.Background(), usageLog, UsageCharge{UserID: 1, APIKeyID: 10, BalanceCost: 2}))
+
+	// Redis 幂等记录过期时使用记录仍在队列中：不允许再次执行
+	expire()
+	_, err = svc.Begin(context.Background(), in)
+	require.ErrorIs(t, err, ErrIdempotencyKeyAlreadyUsed)
+	require.Empty(t, cache.records)
+
+	// 落库确认后队列登记删除，由使用记录查询拦截
+	require.NoError(t, env.svc.flush(context.Background(), []UsageWriteMessage{env.queue.message(0, "1-0")}))
+	has, err := env.svc.HasPendingUsage(context.Background(), 10, result.Claim.RequestID)
+	require.NoError(t, err)
+	require.False(t, has)
+	expire()
+	_, err = svc.Begin(context.Background(), in)
+	require.ErrorIs(t, err, ErrIdempotencyKeyAlreadyUsed)
+	require.Equal(t, map[int64]float64{1: 2}, env.users.deducted)
+}
+
+func TestIdempotencyServiceFallsBackWhenCacheUnavailable(t *testing.T) {
+	cache := newIdempotencyCacheStub()
+	cache.err = errors.New("redis down")
+	svc := newTestIdempotencyService(cache, nil, 0)
+
+	result, err := svc.Begin(context.Background(), IdempotencyBeginInput{APIKeyID: 1, Key: "k", Fingerprint: "fp"})
+	require.NoError(t, err)
+	require.NotNil(t, result.Claim)
+	require.Equal(t, IdempotencyRequestID("k"), result.Claim.RequestID)
+}
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	require.NoError(t, ValidateIdempotencyKey("0b4f6c1e-retry"))
+	require.ErrorIs(t, ValidateIdempotencyKey(""), ErrIdempotencyKeyInvalid)
+	require.ErrorIs(t, ValidateIdempotencyKey("bad\nkey"), ErrIdempotencyKeyInvalid)
+	require.LessOrEqual(t, len(IdempotencyRequestID("k")), 64)
+}
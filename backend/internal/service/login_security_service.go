@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	loginLockKeyAccountPrefix = "acct:"
+	loginLockKeyIPPrefix      = "ip:"
+)
+
+// LoginSecurityService 登录事件记录与失败锁定
+type LoginSecurityService struct {
+	eventRepo   LoginEventRepository
+	cache       LoginSecurityCache
+	settingRepo SettingRepository
+}
+
+// NewLoginSecurityService 创建登录安全服务
+func NewLoginSecurityService(eventRepo LoginEventRepository, cache LoginSecurityCache, settingRepo SettingRepository) *LoginSecurityService {
+	return &LoginSecurityService{
+		eventRepo:   eventRepo,
+		cache:       cache,
+		settingRepo: settingRepo,
+	}
+}
+
+// GetSettings 获取登录锁定配置
+func (s *LoginSecurityService) GetSettings(ctx context.Context) (*LoginSecuritySettings, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyLoginSecurity)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultLoginSecuritySettings(), nil
+		}
+		return nil, fmt.Errorf("get login security settings: %w", err)
+	}
+	settings := DefaultLoginSecuritySettings()
+	if value == "" {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(value), settings); err != nil {
+		return DefaultLoginSecuritySettings(), nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings 更新登录锁定配置
+func (s *LoginSecurityService) UpdateSettings(ctx context.Context, settings *LoginSecuritySettings) (*LoginSecuritySettings, error) {
+	if err := validateLoginSecuritySettings(settings); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("marshal login security settings: %w", err)
+	}
+	if err := s.settingRepo.Set(ctx, SettingKeyLoginSecurity, string(data)); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(ctx)
+}
+
+func validateLoginSecuritySettings(settings *LoginSecuritySettings) error {
+	if settings == nil {
+		return ErrLoginSecuritySettingsInvalid
+	}
+	invalid := func(msg string) error {
+		return infraerrors.BadRequest("LOGIN_SECURITY_SETTINGS_INVALID", msg)
+	}
+	if settings.MaxFailuresPerAccount < 0 || settings.MaxFailuresPerIP < 0 {
+		return invalid("max failures must be non-negative")
+	}
+	if settings.FailureWindowMinutes < 1 || settings.FailureWindowMinutes > 24*60 {
+		return invalid("failure_window_minutes must be between 1 and 1440")
+	}
+	if settings.LockoutMinutes < 1 || settings.LockoutMinutes > 7*24*60 {
+		return invalid("lockout_minutes must be between 1 and 10080")
+	}
+	if settings.LockoutEnabled && settings.MaxFailuresPerAccount == 0 && settings.MaxFailuresPerIP == 0 {
+		return invalid("set max_failures_per_account or max_failures_per_ip when lockout is enabled")
+	}
+	return nil
+}
+
+func loginAccountKey(email string) string {
+	return loginLockKeyAccountPrefix + strings.ToLower(strings.TrimSpace(email))
+}
+
+// CheckLockout 账号或当前 IP 被锁定时返回 ErrLoginLocked（附带 retry_after_seconds）
+func (s *LoginSecurityService) CheckLockout(ctx context.Context, email string) error {
+	settings, err := s.GetSettings(ctx)
+	if err != nil || !settings.LockoutEnabled {
+		return err
+	}
+	ip, _ := clientInfoFromContext(ctx)
+
+	keys := make([]string, 0, 2)
+	if settings.MaxFailuresPerAccount > 0 && strings.TrimSpace(email) != "" {
+		keys = append(keys, loginAccountKey(email))
+	}
+	if settings.MaxFailuresPerIP > 0 && ip != "" {
+		keys = append(keys, loginLockKeyIPPrefix+ip)
+	}
+	var longest time.Duration
+	for _, key := range keys {
+		ttl, err := s.cache.GetLoginLockTTL(ctx, key)
+		if err != nil {
+			// Redis 故障时不阻断登录
+			slog.Warn("login_lock_check_failed", "error", err)
+			return nil
+		}
+		longest = max(longest, ttl)
+	}
+	if longest <= 0 {
+		return nil
+	}
+	return ErrLoginLocked.WithMetadata(map[string]string{
+		"retry_after_seconds": fmt.Sprintf("%d", int(math.Ceil(longest.Seconds()))),
+	})
+}
+
+// RecordSuccess 记录登录成功并清除该账号的失败计数。
+// 已生效的锁定只能等待过期或由管理员解除，避免通过其他登录方式成功一次即绕过锁定。
+func (s *LoginSecurityService) RecordSuccess(ctx context.Context, user *User, method string) {
+	if user == nil {
+		return
+	}
+	userID := user.ID
+	s.insertEvent(ctx, &LoginEvent{UserID: &userID, Email: user.Email, Method: method, Success: true})
+	if err := s.cache.ClearLoginFailures(ctx, loginAccountKey(user.Email)); err != nil {
+		slog.Warn("login_failures_reset_failed", "user_id", user.ID, "error", err)
+	}
+}
+
+// RecordFailure 记录登录失败；客户端错误（凭据错误、验证码错误等）计入锁定计数
+func (s *LoginSecurityService) RecordFailure(ctx context.Context, email string, userID *int64, method string, cause error) {
+	reason := infraerrors.Reason(cause)
+	if reason == "" {
+		reason = "UNKNOWN"
+	}
+	s.insertEvent(ctx, &LoginEvent{UserID: userID, Email: email, Method: method, Success: false, FailureReason: reason})
+
+	code := infraerrors.Code(cause)
+	if code < 400 || code >= 500 || reason == infraerrors.Reason(ErrLoginLocked) {
+		return
+	}
+	settings, err := s.GetSettings(ctx)
+	if err != nil || !settings.LockoutEnabled {
+		return
+	}
+	ip, _ := clientInfoFromContext(ctx)
+	window := time.Duration(settings.FailureWindowMinutes) * time.Minute
+	lockout := time.Duration(settings.LockoutMinutes) * time.Minute
+
+	counters := []struct {
+		key   string
+		limit int
+	}{
+		{loginAccountKey(email), settings.MaxFailuresPerAccount},
+		{loginLockKeyIPPrefix + ip, settings.MaxFailuresPerIP},
+	}
+	for _, c := range counters {
+		if c.limit <= 0 || c.key == loginLockKeyIPPrefix || c.key == loginLockKeyAccountPrefix {
+			continue
+		}
+		count, err := s.cache.IncrLoginFailure(ctx, c.key, window)
+		if err != nil {
+			slog.Warn("login_failure_count_failed", "error", err)
+			return
+		}
+		if count >= int64(c.limit) {
+			if err := s.cache.SetLoginLock(ctx, c.key, lockout); err != nil {
+				slog.Warn("login_lock_set_failed", "error", err)
+				continue
+			}
+			slog.Warn("login_locked", "key", c.key, "failures", count, "lockout_minutes", settings.LockoutMinutes)
+		}
+	}
+}
+
+// Unlock 管理员解除账号或 IP 的登录锁定
+func (s *LoginSecurityService) Unlock(ctx context.Context, email, ip string) error {
+	email, ip = strings.TrimSpace(email), strings.TrimSpace(ip)
+	if email == "" && ip == "" {
+		return infraerrors.BadRequest("LOGIN_UNLOCK_TARGET_REQUIRED", "email or ip is required")
+	}
+	if email != "" {
+		if err := s.cache.ResetLoginFailures(ctx, loginAccountKey(email)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		if err := s.cache.ResetLoginFailures(ctx, loginLockKeyIPPrefix+ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListEvents 查询登录事件
+func (s *LoginSecurityService) ListEvents(ctx context.Context, filter LoginEventFilter, params pagination.PaginationParams) ([]LoginEvent, *pagination.PaginationResult, error) {
+	return s.eventRepo.List(ctx, filter, params)
+}
+
+func (s *LoginSecurityService) insertEvent(ctx context.Context, event *LoginEvent) {
+	event.IP, event.UserAgent = clientInfoFromContext(ctx)
+	event.Email = truncateString(strings.ToLower(strings.TrimSpace(event.Email)), 255)
+	event.CreatedAt = time.Now()
+	if err := s.eventRepo.Insert(ctx, event); err != nil {
+		slog.Warn("login_event_record_failed", "email", event.Email, "error", err)
+	}
+}
@@ -0,0 +1,198 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type loginEventRepoStub struct {
+	events []LoginEvent
+}
+
+func (s *loginEventRepoStub) Insert(ctx context.Context, event *LoginEvent) error {
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *loginEventRepoStub) List(ctx context.Context, filter LoginEventFilter, params pagination.PaginationParams) ([]LoginEvent, *pagination.PaginationResult, error) {
+	panic("unexpected List call")
+}
+
+type loginSecurityCacheStub struct {
+	failures map[string]int64
+	locks    map[string]time.Duration
+}
+
+func newLoginSecurityCacheStub() *loginSecurityCacheStub {
+	return &loginSecurityCacheStub{failures: map[string]int64{}, locks: map[string]time.Duration{}}
+}
+
+func (s *loginSecurityCacheStub) IncrLoginFailure(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.failures[key]++
+	return s.failures[key], nil
+}
+
+func (s *loginSecurityCacheStub) SetLoginLock(ctx context.Context, key string, ttl time.Duration) error {
+	s.locks[key] = ttl
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *loginSecurityCacheStub) GetLoginLockTTL(ctx context.Context, key string) (time.Duration, error) {
+	return s.locks[key], nil
+}
+
+func (s *loginSecurityCacheStub) ClearLoginFailures(ctx context.Context, key string) error {
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *loginSecurityCacheStub) ResetLoginFailures(ctx context.Context, key string) error {
+	delete(s.failures, key)
+	delete(s.locks, key)
+	return nil
+}
+
+func newLoginSecurityServiceForTest(settings string) (*LoginSecurityService, *loginEventRepoStub, *loginSecurityCacheStub) {
+	events := &loginEventRepoStub{}
+	cache := newLoginSecurityCacheStub()
+	values := map[string]string{}
+	if settings != "" {
+		values[SettingKeyLoginSecurity] = settings
+	}
+	return NewLoginSecurityService(events, cache, &settingRepoStub{values: values}), events, cache
+}
+
+func loginTestContext(ip string) context.Context {
+	ctx := context.WithValue(context.Background(), ctxkey.ClientIP, ip)
+	return context.WithValue(ctx, ctxkey.UserAgent, "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/537.36 Chrome/120.0 Safari/537.36")
+}
+
+func TestLoginSecurityService_LocksAccountAfterRepeatedFailures(t *testing.T) {
+	svc, events, _ := newLoginSecurityServiceForTest(`{"lockout_enabled":true,"max_failures_per_account":3,"max_failures_per_ip":0,"failure_window_minutes":10,"lockout_minutes":5}`)
+	ctx := loginTestContext("10.0.0.1")
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, svc.CheckLockout(ctx, "User@Example.com"))
+		svc.RecordFailure(ctx, "user@example.com", nil, LoginMethodPassword, ErrInvalidCredentials)
+	}
+
+	err := svc.CheckLockout(ctx, "user@example.com")
+	require.Equal(t, "LOGIN_LOCKED", infraerrors.Reason(err))
+	require.Equal(t, "300", infraerrors.FromError(err).Metadata["retry_after_seconds"])
+	// 其他账号不受影响（未启用 IP 维度）
+	require.NoError(t, svc.CheckLockout(ctx, "other@example.com"))
+
+	require.Len(t, events.events, 3)
+	require.False(t, events.events[0].Success)
+	require.Equal(t, infraerrors.Reason(ErrInvalidCredentials), events.events[0].FailureReason)
+	require.Equal(t, "10.0.0.1", events.events[0].IP)
+
+	require.NoError(t, svc.Unlock(ctx, "USER@example.com", ""))
+	require.NoError(t, svc.CheckLockout(ctx, "user@example.com"))
+}
+
+func TestLoginSecurityService_IPLockoutAndServerErrorsNotCounted(t *testing.T) {
+	svc, _, cache := newLoginSecurityServiceForTest(`{"lockout_enabled":true,"max_failures_per_account":0,"max_failures_per_ip":2,"failure_window_minutes":10,"lockout_minutes":5}`)
+	ctx := loginTestContext("10.0.0.2")
+
+	svc.RecordFailure(ctx, "a@example.com", nil, LoginMethodPassword, ErrServiceUnavailable)
+	svc.RecordFailure(ctx, "a@example.com", nil, LoginMethodPassword, ErrLoginLocked)
+	require.Empty(t, cache.failures)
+
+	svc.RecordFailure(ctx, "a@example.com", nil, LoginMethodPassword, ErrInvalidCredentials)
+	svc.RecordFailure(ctx, "b@example.com", nil, LoginMethodPasskey, ErrPasskeyVerificationFailed)
+	require.Error(t, svc.CheckLockout(ctx, "c@example.com"))
+	require.NoError(t, svc.CheckLockout(loginTestContext("10.0.0.3"), "c@example.com"))
+}
+
+func TestLoginSecurityService_SuccessResetsAccountFailures(t *testing.T) {
+	svc, events, cache := newLoginSecurityServiceForTest(`{"lockout_enabled":true,"max_failures_per_account":3,"failure_window_minutes":10,"lockout_minutes":5}`)
+	ctx := loginTestContext("10.0.0.1")
+
+	svc.RecordFailure(ctx, "user@example.com", nil, LoginMethodPassword, ErrInvalidCredentials)
+	svc.RecordFailure(ctx, "user@example.com", nil, LoginMethodPassword, ErrInvalidCredentials)
+	svc.RecordSuccess(ctx, &User{ID: 9, Email: "user@example.com"}, LoginMethodPassword)
+	require.Zero(t, cache.failures[loginAccountKey("user@example.com")])
+
+	last := events.events[len(events.events)-1]
+	require.True(t, last.Success)
+	require.Equal(t, int64(9), *last.UserID)
+}
+
+func TestLoginSecurityService_SuccessDoesNotClearLock(t *testing.T) {
+	svc, _, _ := newLoginSecurityServiceForTest(`{"lockout_enabled":true,"max_failures_per_account":2,"failure_window_minutes":10,"lockout_minutes":5}`)
+	ctx := loginTestContext("10.0.0.1")
+
+	svc.RecordFailure(ctx, "user@example.com", nil, LoginMethodPassword, ErrInvalidCredentials)
+	svc.RecordFailure(ctx, "user@example.com", nil, LoginMethodPassword, ErrInvalidCredentials)
+	require.Error(t, svc.CheckLockout(ctx, "user@example.com"))
+
+	// 其他登录方式（如 OAuth）成功不能解除账号锁定
+	svc.RecordSuccess(ctx, &User{ID: 9, Email: "user@example.com"}, LoginMethodOAuth)
+	require.Equal(t, "LOGIN_LOCKED", infraerrors.Reason(svc.CheckLockout(ctx, "user@example.com")))
+}
+
+func TestLoginSecurityService_LockoutDisabledByDefault(t *testing.T) {
+	svc, events, cache := newLoginSecurityServiceForTest("")
+	ctx := loginTestContext("10.0.0.1")
+	for i := 0; i < 10; i++ {
+		svc.RecordFailure(ctx, "user@example.com", nil, LoginMethodPassword, ErrInvalidCredentials)
+	}
+	require.NoError(t, svc.CheckLockout(ctx, "user@example.com"))
+	require.Empty(t, cache.failures)
+	require.Len(t, events.events, 10)
+}
+
+func TestValidateLoginSecuritySettings(t *testing.T) {
+	require.NoError(t, validateLoginSecuritySettings(DefaultLoginSecuritySettings()))
+	for _, s := range []*LoginSecuritySettings{
+		nil,
+		{MaxFailuresPerAccount: -1, FailureWindowMinutes: 10, LockoutMinutes: 10},
+		{FailureWindowMinutes: 0, LockoutMinutes: 10},
+		{FailureWindowMinutes: 10, LockoutMinutes: 0},
+		{LockoutEnabled: true, FailureWindowMinutes: 10, LockoutMinutes: 10},
+	} {
+		require.Error(t, validateLoginSecuritySettings(s), "%+v", s)
+	}
+}
+
+func TestSessionNameFromUserAgent(t *testing.T) {
+	cases := map[string]string{
+		"": "Unknown device",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36 Edg/120.0":                   "Edge on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1": "Safari on iOS",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0":                                                                  "Firefox on Linux",
+		"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36":                                "Chrome on Android",
+		"curl/8.4.0": "curl",
+	}
+	for ua, want := range cases {
+		require.Equal(t, want, sessionNameFromUserAgent(ua), ua)
+	}
+}
+
+func TestAuthService_OAuthLoginChecksAccountLock(t *testing.T) {
+	svc, events, _ := newLoginSecurityServiceForTest(`{"lockout_enabled":true,"max_failures_per_account":1,"failure_window_minutes":10,"lockout_minutes":5}`)
+	ctx := loginTestContext("10.0.0.1")
+	svc.RecordFailure(ctx, "user@example.com", nil, LoginMethodPassword, ErrInvalidCredentials)
+
+	repo := &passkeyOAuthUserRepoStub{user: &User{ID: 9, Email: "user@example.com", Username: "user", Role: RoleUser, Status: StatusActive}}
+	authSvc := NewAuthService(repo, nil, struct{ RefreshTokenCache }{}, &config.Config{}, nil, nil, nil, nil, nil, nil)
+	authSvc.SetLoginSecurityService(svc)
+
+	tokenPair, _, err := authSvc.LoginOrRegisterOAuthWithTokenPair(ctx, "user@example.com", "user")
+	require.Equal(t, "LOGIN_LOCKED", infraerrors.Reason(err))
+	require.Nil(t, tokenPair)
+	last := events.events[len(events.events)-1]
+	require.False(t, last.Success)
+	require.Equal(t, LoginMethodOAuth, last.Method)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+var (
+	ErrUserSessionNotFound          = infraerrors.NotFound("SESSION_NOT_FOUND", "session not found")
+	ErrLoginLocked                  = infraerrors.TooManyRequests("LOGIN_LOCKED", "too many failed login attempts, please try again later")
+	ErrLoginSecuritySettingsInvalid = infraerrors.BadRequest("LOGIN_SECURITY_SETTINGS_INVALID", "invalid login security settings")
+)
+
+// 登录方式（记录在会话与登录事件中）
+const (
+	LoginMethodPassword = "password"
+	LoginMethod2FA      = "2fa"
+	LoginMethodPasskey  = "passkey"
+	LoginMethodOAuth    = "oauth"
+	LoginMethodRegister = "register"
+)
+
+const (
+	sessionNameMaxLen      = 100
+	sessionUserAgentMaxLen = 512
+)
+
+// UserSession 登录会话，对应一个 Refresh Token 家族
+type UserSession struct {
+	ID              int64      `json:"id"`
+	UserID          int64      `json:"user_id"`
+	FamilyID        string     `json:"-"`
+	Name            string     `json:"name"`
+	LoginMethod     string     `json:"login_method"`
+	IP              string     `json:"ip"`
+	UserAgent       string     `json:"user_agent"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastRefreshedAt time.Time  `json:"last_refreshed_at"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+}
+
+// UserSessionRepository 登录会话存储
+type UserSessionRepository interface {
+	Create(ctx context.Context, session *UserSession) error
+	// Touch 在 Token 轮转时更新最近刷新时间、IP 与过期时间
+	Touch(ctx context.Context, familyID, ip, userAgent string, refreshedAt, expiresAt time.Time) error
+	GetByID(ctx context.Context, id int64) (*UserSession, error)
+	// ListActiveByUserID 列出未撤销且未过期的会话（按最近刷新时间倒序）
+	ListActiveByUserID(ctx context.Context, userID int64, now time.Time) ([]UserSession, error)
+	RevokeByFamilyID(ctx context.Context, familyID string, revokedAt time.Time) error
+	RevokeAllByUserID(ctx context.Context, userID int64, revokedAt time.Time) error
+}
+
+// LoginEvent 登录事件
+type LoginEvent struct {
+	ID            int64     `json:"id"`
+	UserID        *int64    `json:"user_id,omitempty"`
+	Email         string    `json:"email"`
+	Method        string    `json:"method"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	IP            string    `json:"ip"`
+	UserAgent     string    `json:"user_agent"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LoginEventFilter 登录事件查询条件
+type LoginEventFilter struct {
+	UserID    int64
+	Email     string
+	IP        string
+	Success   *bool
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// LoginEventRepository 登录事件存储（仅追加，按保留期清理）
+type LoginEventRepository interface {
+	Insert(ctx context.Context, event *LoginEvent) error
+	List(ctx context.Context, filter LoginEventFilter, params pagination.PaginationParams) ([]LoginEvent, *pagination.PaginationResult, error)
+}
+
+// LoginSecuritySettings 登录锁定配置（保存在 settings.login_security_settings）
+type LoginSecuritySettings struct {
+	LockoutEnabled bool `json:"lockout_enabled"`
+	// MaxFailuresPerAccount 窗口内同一账号允许的失败次数，0 表示不按账号锁定
+	MaxFailuresPerAccount int `json:"max_failures_per_account"`
+	// MaxFailuresPerIP 窗口内同一 IP 允许的失败次数，0 表示不按 IP 锁定
+	MaxFailuresPerIP     int `json:"max_failures_per_ip"`
+	FailureWindowMinutes int `json:"failure_window_minutes"`
+	LockoutMinutes       int `json:"lockout_minutes"`
+}
+
+// DefaultLoginSecuritySettings 默认配置（锁定默认关闭，登录事件始终记录）
+func DefaultLoginSecuritySettings() *LoginSecuritySettings {
+	return &LoginSecuritySettings{
+		MaxFailuresPerAccount: 5,
+		MaxFailuresPerIP:      20,
+		FailureWindowMinutes:  15,
+		LockoutMinutes:        15,
+	}
+}
+
+// LoginSecurityCache 登录失败计数与锁定状态（Redis）
+type LoginSecurityCache interface {
+	// IncrLoginFailure 增加失败计数，首次计数时设置窗口过期时间，返回当前计数
+	IncrLoginFailure(ctx context.Context, key string, window time.Duration) (int64, error)
+	SetLoginLock(ctx context.Context, key string, ttl time.Duration) error
+	// GetLoginLockTTL 返回剩余锁定时间，未锁定时返回 0
+	GetLoginLockTTL(ctx context.Context, key string) (time.Duration, error)
+	// ClearLoginFailures 仅清除失败计数，不解除已生效的锁定
+	ClearLoginFailures(ctx context.Context, key string) error
+	// ResetLoginFailures 清除失败计数与锁定
+	ResetLoginFailures(ctx context.Context, key string) error
+}
+
+// WithLoginMethod 在 context 中标记本次登录方式，签发 Refresh Token 时写入会话
+func WithLoginMethod(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, ctxkey.LoginMethod, method)
+}
+
+// clientInfoFromContext 读取 middleware.ClientInfo 写入的客户端 IP 与 User-Agent
+func clientInfoFromContext(ctx context.Context) (ip, userAgent string) {
+	ip, _ = ctx.Value(ctxkey.ClientIP).(string)
+	userAgent, _ = ctx.Value(ctxkey.UserAgent).(string)
+	return ip, truncateString(userAgent, sessionUserAgentMaxLen)
+}
+
+func loginMethodFromContext(ctx context.Context) string {
+	method, _ := ctx.Value(ctxkey.LoginMethod).(string)
+	return method
+}
+
+// sessionNameFromUserAgent 根据 User-Agent 推断设备名称，如 "Chrome on macOS"
+func sessionNameFromUserAgent(ua string) string {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"Windows", "Windows"},
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+	// 非浏览器客户端（如 curl/8.0），取产品名
+	name, _, _ := strings.Cut(strings.Fields(ua)[0], "/")
+	return truncateString(name, sessionNameMaxLen)
+}
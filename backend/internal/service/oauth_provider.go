@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// 通用登录提供商类型
+const (
+	// OAuthProviderTypeOIDC 标准 OIDC（Google、Keycloak、Authentik 等），支持 issuer 自动发现
+	OAuthProviderTypeOIDC = "oidc"
+	// OAuthProviderTypeOAuth2 非 OIDC 的 OAuth2，需要手动配置各端点与 claim 路径
+	OAuthProviderTypeOAuth2 = "oauth2"
+	// OAuthProviderTypeGitHub GitHub OAuth App，端点与 claim 路径内置，邮箱从 /user/emails 读取已验证的主邮箱
+	OAuthProviderTypeGitHub = "github"
+)
+
+// OAuthSyntheticEmailDomain 无法使用已验证邮箱时为第三方用户生成的合成邮箱后缀（RFC 保留域名）
+const OAuthSyntheticEmailDomain = "@oauth.invalid"
+
+const (
+	oauthProviderDefaultFrontendCB = "/auth/oauth/callback"
+	oauthProviderDefaultGroupClaim = "groups"
+	oauthProviderDefaultValidity   = 30
+)
+
+var oauthProviderNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+var (
+	ErrOAuthProviderNotFound = infraerrors.NotFound("OAUTH_PROVIDER_NOT_FOUND", "oauth provider not found")
+	ErrOAuthProviderDisabled = infraerrors.NotFound("OAUTH_DISABLED", "oauth login is disabled")
+	ErrOAuthProviderInvalid  = infraerrors.BadRequest("OAUTH_PROVIDER_INVALID", "invalid oauth provider config")
+	ErrOAuthDiscoveryFailed  = infraerrors.ServiceUnavailable("OAUTH_DISCOVERY_FAILED", "failed to load oidc discovery document")
+	ErrOAuthTokenExchange    = infraerrors.BadRequest("OAUTH_TOKEN_EXCHANGE_FAILED", "failed to exchange oauth code")
+	ErrOAuthInvalidIDToken   = infraerrors.Unauthorized("OAUTH_INVALID_ID_TOKEN", "invalid id_token")
+	ErrOAuthUserInfo         = infraerrors.BadRequest("OAUTH_USERINFO_FAILED", "failed to fetch user info")
+	ErrOAuthIdentityNotFound = infraerrors.NotFound("OAUTH_IDENTITY_NOT_FOUND", "oauth identity not found")
+)
+
+// OAuthGroupMapping 按 OIDC 分组 claim 自动分配分组：
+// 标准分组加入用户的可用分组，订阅分组则首次登录时分配订阅（已有订阅不重复分配）
+type OAuthGroupMapping struct {
+	Claim        string `json:"claim"`
+	GroupID      int64  `json:"group_id"`
+	ValidityDays int    `json:"validity_days,omitempty"`
+}
+
+// OAuthProviderConfig 通用 OIDC / OAuth2 登录提供商配置（保存在 settings.oauth_providers）
+type OAuthProviderConfig struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+	Enabled     bool   `json:"enabled"`
+
+	// Issuer OIDC issuer，端点未显式配置时通过 /.well-known/openid-configuration 发现
+	Issuer       string `json:"issuer,omitempty"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	AuthorizeURL string `json:"authorize_url,omitempty"`
+	TokenURL     string `json:"token_url,omitempty"`
+	UserInfoURL  string `json:"userinfo_url,omitempty"`
+	Scopes       string `json:"scopes,omitempty"`
+	UsePKCE      bool   `json:"use_pkce"`
+	// TokenAuthMethod client_secret_post（默认）/ client_secret_basic / none
+	TokenAuthMethod string `json:"token_auth_method,omitempty"`
+
+	// RedirectURL 后端回调地址：.../api/v1/auth/oauth/providers/<name>/callback
+	RedirectURL         string `json:"redirect_url"`
+	FrontendRedirectURL string `json:"frontend_redirect_url,omitempty"`
+
+	// claim 映射（gjson 路径），为空时使用各类型的默认值
+	SubjectClaim  string `json:"subject_claim,omitempty"`
+	EmailClaim    string `json:"email_claim,omitempty"`
+	UsernameClaim string `json:"username_claim,omitempty"`
+	GroupsClaim   string `json:"groups_claim,omitempty"`
+
+	// TrustEmail 提供商未返回 email_verified 时仍视邮箱为已验证（仅在确认提供商会验证邮箱时开启）
+	TrustEmail bool `json:"trust_email"`
+	// LinkByEmail 首次登录时按已验证邮箱绑定到已有本地用户
+	LinkByEmail bool `json:"link_by_email"`
+
+	GroupMappings []OAuthGroupMapping `json:"group_mappings,omitempty"`
+}
+
+// OAuthPublicProvider 登录页展示的提供商信息
+type OAuthPublicProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+}
+
+// OAuthUserClaims 从 id_token / userinfo 中提取的用户信息
+type OAuthUserClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Groups        []string
+}
+
+// UserOAuthIdentity 第三方身份与本地用户的绑定
+type UserOAuthIdentity struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Provider    string    `json:"provider"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+// UserOAuthIdentityRepository 第三方身份绑定存储
+type UserOAuthIdentityRepository interface {
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*UserOAuthIdentity, error)
+	// Upsert 按 (provider, subject) 插入绑定；已存在时仅刷新 email 与 last_login_at
+	Upsert(ctx context.Context, identity *UserOAuthIdentity) error
+	ListByUserID(ctx context.Context, userID int64) ([]UserOAuthIdentity, error)
+}
+
+// oauthProviderDefaults 各类型的默认端点、scope 与 claim 路径
+func oauthProviderDefaults(p *OAuthProviderConfig) {
+	switch p.Type {
+	case OAuthProviderTypeGitHub:
+		if p.AuthorizeURL == "" {
+			p.AuthorizeURL = "https://github.com/login/oauth/authorize"
+		}
+		if p.TokenURL == "" {
+			p.TokenURL = "https://github.com/login/oauth/access_token"
+		}
+		if p.UserInfoURL == "" {
+			p.UserInfoURL = "https://api.github.com/user"
+		}
+		if p.Scopes == "" {
+			p.Scopes = "read:user user:email"
+		}
+		if p.SubjectClaim == "" {
+			p.SubjectClaim = "id"
+		}
+		if p.UsernameClaim == "" {
+			p.UsernameClaim = "login"
+		}
+	case OAuthProviderTypeOIDC:
+		if p.Scopes == "" {
+			p.Scopes = "openid email profile"
+		}
+	}
+	if p.SubjectClaim == "" {
+		p.SubjectClaim = "sub"
+	}
+	if p.EmailClaim == "" {
+		p.EmailClaim = "email"
+	}
+	if p.UsernameClaim == "" {
+		p.UsernameClaim = "preferred_username"
+	}
+	if p.GroupsClaim == "" {
+		p.GroupsClaim = oauthProviderDefaultGroupClaim
+	}
+	if p.FrontendRedirectURL == "" {
+		p.FrontendRedirectURL = oauthProviderDefaultFrontendCB
+	}
+	for i := range p.GroupMappings {
+		if p.GroupMappings[i].ValidityDays <= 0 {
+			p.GroupMappings[i].ValidityDays = oauthProviderDefaultValidity
+		}
+	}
+}
+
+// normalizeOAuthProviderConfig 去除空白、填充默认值并校验
+func normalizeOAuthProviderConfig(p *OAuthProviderConfig) error {
+	invalid := func(msg string) error {
+		return infraerrors.BadRequest("OAUTH_PROVIDER_INVALID", msg)
+	}
+	for _, field := range []*string{
+		&p.Name, &p.DisplayName, &p.Type, &p.Issuer, &p.ClientID, &p.ClientSecret,
+		&p.AuthorizeURL, &p.TokenURL, &p.UserInfoURL, &p.Scopes, &p.TokenAuthMethod,
+		&p.RedirectURL, &p.FrontendRedirectURL,
+		&p.SubjectClaim, &p.EmailClaim, &p.UsernameClaim, &p.GroupsClaim,
+	} {
+		*field = strings.TrimSpace(*field)
+	}
+	p.Type = strings.ToLower(p.Type)
+	p.Issuer = strings.TrimRight(p.Issuer, "/")
+
+	if !oauthProviderNamePattern.MatchString(p.Name) {
+		return invalid("name must match ^[a-z][a-z0-9_-]{1,49}$")
+	}
+	if p.Name == "linuxdo" {
+		return invalid("name linuxdo is reserved for LinuxDo Connect")
+	}
+	if p.DisplayName == "" {
+		p.DisplayName = p.Name
+	}
+	switch p.Type {
+	case OAuthProviderTypeOIDC:
+		if p.Issuer == "" && (p.AuthorizeURL == "" || p.TokenURL == "") {
+			return invalid("oidc provider requires issuer or authorize_url + token_url")
+		}
+	case OAuthProviderTypeOAuth2:
+		if p.AuthorizeURL == "" || p.TokenURL == "" || p.UserInfoURL == "" {
+			return invalid("oauth2 provider requires authorize_url, token_url and userinfo_url")
+		}
+	case OAuthProviderTypeGitHub:
+	default:
+		return invalid("type must be oidc, oauth2 or github")
+	}
+	if p.ClientID == "" {
+		return invalid("client_id is required")
+	}
+	switch p.TokenAuthMethod {
+	case "", "client_secret_post", "client_secret_basic":
+		if p.ClientSecret == "" && p.Enabled {
+			return invalid("client_secret is required unless token_auth_method=none")
+		}
+	case "none":
+		if !p.UsePKCE {
+			return invalid("token_auth_method=none requires use_pkce")
+		}
+	default:
+		return invalid("token_auth_method must be client_secret_post, client_secret_basic or none")
+	}
+	if p.RedirectURL == "" {
+		return invalid("redirect_url is required")
+	}
+	for name, raw := range map[string]string{
+		"issuer":        p.Issuer,
+		"authorize_url": p.AuthorizeURL,
+		"token_url":     p.TokenURL,
+		"userinfo_url":  p.UserInfoURL,
+		"redirect_url":  p.RedirectURL,
+	} {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return invalid(name + " must be an absolute http(s) url")
+		}
+	}
+	for _, m := range p.GroupMappings {
+		if strings.TrimSpace(m.Claim) == "" || m.GroupID <= 0 {
+			return invalid("group_mappings require claim and group_id")
+		}
+		if m.ValidityDays > MaxValidityDays {
+			return invalid("group_mappings validity_days too large")
+		}
+	}
+	oauthProviderDefaults(p)
+	return nil
+}
+
+// oauthSyntheticEmail 为第三方用户生成稳定的合成邮箱（subject 取哈希，避免非法字符与超长）
+func oauthSyntheticEmail(provider, subject string) string {
+	sum := sha256.Sum256([]byte(subject))
+	return provider + "-" + hex.EncodeToString(sum[:8]) + OAuthSyntheticEmailDomain
+}
@@ -0,0 +1,611 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	oauthProviderHTTPTimeout     = 30 * time.Second
+	oauthProviderDiscoveryTTL    = time.Hour
+	oauthProviderMaxResponseSize = 1 << 20
+	// oauthProviderClockSkew id_token exp/iat 校验允许的时钟偏差
+	oauthProviderClockSkew = 2 * time.Minute
+)
+
+type oidcDiscoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+type oidcDiscoveryCacheEntry struct {
+	doc       oidcDiscoveryDocument
+	expiresAt time.Time
+}
+
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// OAuthProviderService 通用 OIDC / OAuth2 登录：提供商配置管理、授权地址构造、
+// code 换取用户信息、身份绑定以及按分组 claim 自动分配分组/订阅
+type OAuthProviderService struct {
+	settingRepo         SettingRepository
+	identityRepo        UserOAuthIdentityRepository
+	userRepo            UserRepository
+	groupRepo           GroupRepository
+	subscriptionService *SubscriptionService
+	authService         *AuthService
+	httpClient          *http.Client
+
+	mu             sync.Mutex
+	discoveryCache map[string]oidcDiscoveryCacheEntry
+}
+
+// NewOAuthProviderService creates a new OAuthProviderService
+func NewOAuthProviderService(
+	settingRepo SettingRepository,
+	identityRepo UserOAuthIdentityRepository,
+	userRepo UserRepository,
+	groupRepo GroupRepository,
+	subscriptionService *SubscriptionService,
+	authService *AuthService,
+) *OAuthProviderService {
+	return &OAuthProviderService{
+		settingRepo:         settingRepo,
+		identityRepo:        identityRepo,
+		userRepo:            userRepo,
+		groupRepo:           groupRepo,
+		subscriptionService: subscriptionService,
+		authService:         authService,
+		httpClient:          &http.Client{Timeout: oauthProviderHTTPTimeout},
+		discoveryCache:      make(map[string]oidcDiscoveryCacheEntry),
+	}
+}
+
+// ListProviders 获取全部提供商配置（包含密钥，仅供内部与管理端脱敏后使用）
+func (s *OAuthProviderService) ListProviders(ctx context.Context) ([]OAuthProviderConfig, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyOAuthProviders)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return []OAuthProviderConfig{}, nil
+		}
+		return nil, fmt.Errorf("get oauth providers: %w", err)
+	}
+	providers := []OAuthProviderConfig{}
+	if strings.TrimSpace(value) == "" {
+		return providers, nil
+	}
+	if err := json.Unmarshal([]byte(value), &providers); err != nil {
+		return nil, fmt.Errorf("parse oauth providers: %w", err)
+	}
+	for i := range providers {
+		oauthProviderDefaults(&providers[i])
+	}
+	return providers, nil
+}
+
+// GetProvider 按名称获取提供商配置
+func (s *OAuthProviderService) GetProvider(ctx context.Context, name string) (*OAuthProviderConfig, error) {
+	providers, err := s.ListProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range providers {
+		if providers[i].Name == name {
+			return &providers[i], nil
+		}
+	}
+	return nil, ErrOAuthProviderNotFound
+}
+
+// GetEnabledProvider 获取已启用的提供商配置，供登录流程使用
+func (s *OAuthProviderService) GetEnabledProvider(ctx context.Context, name string) (*OAuthProviderConfig, error) {
+	provider, err := s.GetProvider(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !provider.Enabled {
+		return nil, ErrOAuthProviderDisabled
+	}
+	return provider, nil
+}
+
+// PublicProviders 登录页可展示的已启用提供商
+func (s *OAuthProviderService) PublicProviders(ctx context.Context) ([]OAuthPublicProvider, error) {
+	providers, err := s.ListProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]OAuthPublicProvider, 0, len(providers))
+	for _, p := range providers {
+		if !p.Enabled {
+			continue
+		}
+		out = append(out, OAuthPublicProvider{Name: p.Name, DisplayName: p.DisplayName, Type: p.Type})
+	}
+	return out, nil
+}
+
+// UpsertProvider 新增或更新提供商；client_secret 留空时保留原值
+func (s *OAuthProviderService) UpsertProvider(ctx context.Context, input *OAuthProviderConfig) (*OAuthProviderConfig, error) {
+	if input == nil {
+		return nil, ErrOAuthProviderInvalid
+	}
+	providers, err := s.ListProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider := *input
+	idx := slices.IndexFunc(providers, func(p OAuthProviderConfig) bool { return p.Name == strings.TrimSpace(provider.Name) })
+	if idx >= 0 && strings.TrimSpace(provider.ClientSecret) == "" {
+		provider.ClientSecret = providers[idx].ClientSecret
+	}
+	if err := normalizeOAuthProviderConfig(&provider); err != nil {
+		return nil, err
+	}
+	for _, m := range provider.GroupMappings {
+		if _, err := s.groupRepo.GetByIDLite(ctx, m.GroupID); err != nil {
+			return nil, infraerrors.BadRequest("OAUTH_PROVIDER_INVALID", fmt.Sprintf("group %d not found", m.GroupID))
+		}
+	}
+
+	if idx >= 0 {
+		providers[idx] = provider
+	} else {
+		providers = append(providers, provider)
+	}
+	if err := s.saveProviders(ctx, providers); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	delete(s.discoveryCache, provider.Issuer)
+	s.mu.Unlock()
+	return &provider, nil
+}
+
+// DeleteProvider 删除提供商（已有的身份绑定保留，重新添加同名提供商后可继续登录）
+func (s *OAuthProviderService) DeleteProvider(ctx context.Context, name string) error {
+	providers, err := s.ListProviders(ctx)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(providers, func(p OAuthProviderConfig) bool { return p.Name == name })
+	if idx < 0 {
+		return ErrOAuthProviderNotFound
+	}
+	return s.saveProviders(ctx, slices.Delete(providers, idx, idx+1))
+}
+
+func (s *OAuthProviderService) saveProviders(ctx context.Context, providers []OAuthProviderConfig) error {
+	data, err := json.Marshal(providers)
+	if err != nil {
+		return fmt.Errorf("marshal oauth providers: %w", err)
+	}
+	return s.settingRepo.Set(ctx, SettingKeyOAuthProviders, string(data))
+}
+
+// BuildAuthorizeURL 构造授权地址；nonce 仅对 OIDC 提供商生效，codeChallenge 为空表示不使用 PKCE
+func (s *OAuthProviderService) BuildAuthorizeURL(ctx context.Context, provider *OAuthProviderConfig, state, nonce, codeChallenge string) (string, error) {
+	authorizeURL, _, _, err := s.resolveEndpoints(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(authorizeURL)
+	if err != nil {
+		return "", fmt.Errorf("parse authorize_url: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", provider.ClientID)
+	q.Set("redirect_uri", provider.RedirectURL)
+	if provider.Scopes != "" {
+		q.Set("scope", provider.Scopes)
+	}
+	q.Set("state", state)
+	if provider.Type == OAuthProviderTypeOIDC && nonce != "" {
+		q.Set("nonce", nonce)
+	}
+	if codeChallenge != "" {
+		q.Set("code_challenge", codeChallenge)
+		q.Set("code_challenge_method", "S256")
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// CompleteLogin 用授权码换取用户信息并登录：
+//  1. 已绑定的 (provider, subject) 直接登录到绑定用户；
+//  2. 邮箱已验证且开启 link_by_email 时绑定到同邮箱的已有用户；
+//  3. 邮箱已验证且本地无同邮箱用户时以该邮箱注册；
+//  4. 否则使用基于 subject 的合成邮箱，避免未验证邮箱接管本地账号。
+func (s *OAuthProviderService) CompleteLogin(ctx context.Context, provider *OAuthProviderConfig, code, codeVerifier, nonce string) (*TokenPair, *User, error) {
+	claims, err := s.fetchClaims(ctx, provider, code, codeVerifier, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	email, err := s.resolveLoginEmail(ctx, provider, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokenPair, user, err := s.authService.LoginOrRegisterOAuthWithTokenPair(ctx, email, claims.Username)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.identityRepo.Upsert(ctx, &UserOAuthIdentity{
+		UserID:   user.ID,
+		Provider: provider.Name,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); err != nil {
+		log.Printf("[OAuth] failed to save identity provider=%s user=%d: %v", provider.Name, user.ID, err)
+	}
+	s.applyGroupMappings(ctx, provider, user, claims.Groups)
+	return tokenPair, user, nil
+}
+
+// resolveLoginEmail 决定用于 LoginOrRegisterOAuthWithTokenPair 的本地邮箱
+func (s *OAuthProviderService) resolveLoginEmail(ctx context.Context, provider *OAuthProviderConfig, claims *OAuthUserClaims) (string, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, provider.Name, claims.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, identity.UserID)
+		if err == nil {
+			return user.Email, nil
+		}
+		if !errors.Is(err, ErrUserNotFound) {
+			return "", ErrServiceUnavailable
+		}
+	} else if !errors.Is(err, ErrOAuthIdentityNotFound) {
+		log.Printf("[OAuth] identity lookup failed provider=%s: %v", provider.Name, err)
+		return "", ErrServiceUnavailable
+	}
+
+	if claims.Email != "" && claims.EmailVerified && !isReservedEmail(claims.Email) {
+		exists, err := s.userRepo.ExistsByEmail(ctx, claims.Email)
+		if err != nil {
+			return "", ErrServiceUnavailable
+		}
+		if !exists || provider.LinkByEmail {
+			return claims.Email, nil
+		}
+	}
+	return oauthSyntheticEmail(provider.Name, claims.Subject), nil
+}
+
+// applyGroupMappings 按分组 claim 分配分组/订阅；失败只记录日志，不影响登录
+func (s *OAuthProviderService) applyGroupMappings(ctx context.Context, provider *OAuthProviderConfig, user *User, groups []string) {
+	if len(provider.GroupMappings) == 0 || len(groups) == 0 {
+		return
+	}
+	allowedChanged := false
+	for _, m := range provider.GroupMappings {
+		if !slices.Contains(groups, m.Claim) {
+			continue
+		}
+		group, err := s.groupRepo.GetByIDLite(ctx, m.GroupID)
+		if err != nil {
+			log.Printf("[OAuth] group mapping skipped provider=%s group=%d: %v", provider.Name, m.GroupID, err)
+			continue
+		}
+		if group.IsSubscriptionType() {
+			if s.subscriptionService == nil {
+				continue
+			}
+			_, err := s.subscriptionService.AssignSubscription(ctx, &AssignSubscriptionInput{
+				UserID:       user.ID,
+				GroupID:      m.GroupID,
+				ValidityDays: m.ValidityDays,
+				Notes:        "auto-assigned by " + provider.Name + " group claim " + m.Claim,
+			})
+			if err != nil && !errors.Is(err, ErrSubscriptionAlreadyExists) {
+				log.Printf("[OAuth] assign subscription failed provider=%s user=%d group=%d: %v", provider.Name, user.ID, m.GroupID, err)
+			}
+			continue
+		}
+		if !slices.Contains(user.AllowedGroups, m.GroupID) {
+			user.AllowedGroups = append(user.AllowedGroups, m.GroupID)
+			allowedChanged = true
+		}
+	}
+	if allowedChanged {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			log.Printf("[OAuth] update allowed groups failed provider=%s user=%d: %v", provider.Name, user.ID, err)
+		}
+	}
+}
+
+// fetchClaims 换取 token 并合并 id_token 与 userinfo 中的用户信息
+func (s *OAuthProviderService) fetchClaims(ctx context.Context, provider *OAuthProviderConfig, code, codeVerifier, nonce string) (*OAuthUserClaims, error) {
+	_, tokenURL, userInfoURL, err := s.resolveEndpoints(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.exchangeCode(ctx, provider, tokenURL, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]string, 0, 2)
+	if provider.Type == OAuthProviderTypeOIDC && token.IDToken != "" {
+		payload, err := s.decodeIDToken(ctx, provider, token.IDToken, nonce)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, payload)
+	} else if provider.Type == OAuthProviderTypeOIDC && userInfoURL == "" {
+		return nil, ErrOAuthInvalidIDToken.WithCause(errors.New("missing id_token"))
+	}
+	if userInfoURL != "" {
+		body, err := s.getJSON(ctx, userInfoURL, token)
+		if err != nil {
+			if len(sources) == 0 {
+				return nil, ErrOAuthUserInfo.WithCause(err)
+			}
+			log.Printf("[OAuth] userinfo fetch failed provider=%s, falling back to id_token: %v", provider.Name, err)
+		} else {
+			// userinfo 的 sub 必须与 id_token 一致，防止令牌替换
+			if len(sources) > 0 && provider.SubjectClaim == "sub" {
+				if sub := gjson.Get(body, "sub").String(); sub != "" && sub != gjson.Get(sources[0], "sub").String() {
+					return nil, ErrOAuthUserInfo.WithCause(errors.New("userinfo sub mismatch"))
+				}
+			}
+			// userinfo 优先
+			sources = append([]string{body}, sources...)
+		}
+	}
+
+	claims := parseOAuthClaims(provider, sources...)
+	if claims.Subject == "" {
+		return nil, ErrOAuthUserInfo.WithCause(errors.New("missing subject claim " + provider.SubjectClaim))
+	}
+	if provider.Type == OAuthProviderTypeGitHub {
+		claims.Email, claims.EmailVerified = "", false
+		if body, err := s.getJSON(ctx, strings.TrimSuffix(provider.UserInfoURL, "/user")+"/user/emails", token); err == nil {
+			for _, item := range gjson.Parse(body).Array() {
+				if item.Get("primary").Bool() && item.Get("verified").Bool() {
+					claims.Email, claims.EmailVerified = strings.TrimSpace(item.Get("email").String()), true
+					break
+				}
+			}
+		} else {
+			log.Printf("[OAuth] github emails fetch failed provider=%s: %v", provider.Name, err)
+		}
+	}
+	return claims, nil
+}
+
+// parseOAuthClaims 按配置的 gjson 路径从多个来源中依次提取 claim（靠前的来源优先）
+func parseOAuthClaims(provider *OAuthProviderConfig, sources ...string) *OAuthUserClaims {
+	lookup := func(path string) gjson.Result {
+		for _, src := range sources {
+			if v := gjson.Get(src, path); v.Exists() && v.String() != "" {
+				return v
+			}
+		}
+		return gjson.Result{}
+	}
+
+	claims := &OAuthUserClaims{
+		Subject: strings.TrimSpace(lookup(provider.SubjectClaim).String()),
+		Email:   strings.ToLower(strings.TrimSpace(lookup(provider.EmailClaim).String())),
+	}
+	claims.Username = strings.TrimSpace(firstNonEmptyString(
+		lookup(provider.UsernameClaim).String(),
+		lookup("name").String(),
+		lookup("login").String(),
+	))
+	if claims.Username == "" && claims.Email != "" {
+		claims.Username = strings.Split(claims.Email, "@")[0]
+	}
+
+	verified := lookup("email_verified")
+	claims.EmailVerified = provider.TrustEmail || verified.Bool() || strings.EqualFold(verified.String(), "true")
+
+	groups := lookup(provider.GroupsClaim)
+	if groups.IsArray() {
+		for _, g := range groups.Array() {
+			if v := strings.TrimSpace(g.String()); v != "" {
+				claims.Groups = append(claims.Groups, v)
+			}
+		}
+	} else if groups.Exists() {
+		for _, g := range strings.FieldsFunc(groups.String(), func(r rune) bool { return r == ',' || r == ' ' }) {
+			claims.Groups = append(claims.Groups, g)
+		}
+	}
+	return claims
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// decodeIDToken 校验 id_token 的 iss / aud / exp / nonce 并返回 payload JSON。
+// id_token 由后端直接通过 TLS 从 token 端点获取，按 OIDC Core 3.1.3.7 可不校验签名。
+func (s *OAuthProviderService) decodeIDToken(ctx context.Context, provider *OAuthProviderConfig, idToken, nonce string) (string, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return "", ErrOAuthInvalidIDToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil || !gjson.ValidBytes(raw) {
+		return "", ErrOAuthInvalidIDToken
+	}
+	payload := string(raw)
+
+	expectedIssuer := provider.Issuer
+	if expectedIssuer != "" {
+		if doc, err := s.discover(ctx, provider.Issuer); err == nil && doc.Issuer != "" {
+			expectedIssuer = doc.Issuer
+		}
+		if strings.TrimRight(gjson.Get(payload, "iss").String(), "/") != strings.TrimRight(expectedIssuer, "/") {
+			return "", ErrOAuthInvalidIDToken.WithCause(errors.New("issuer mismatch"))
+		}
+	}
+
+	aud := gjson.Get(payload, "aud")
+	audOK := aud.String() == provider.ClientID
+	if aud.IsArray() {
+		audOK = false
+		for _, a := range aud.Array() {
+			if a.String() == provider.ClientID {
+				audOK = true
+				break
+			}
+		}
+	}
+	if !audOK {
+		return "", ErrOAuthInvalidIDToken.WithCause(errors.New("audience mismatch"))
+	}
+
+	exp := gjson.Get(payload, "exp").Int()
+	if exp == 0 || time.Unix(exp, 0).Add(oauthProviderClockSkew).Before(time.Now()) {
+		return "", ErrOAuthInvalidIDToken.WithCause(errors.New("id_token expired"))
+	}
+	if nonce != "" && gjson.Get(payload, "nonce").String() != nonce {
+		return "", ErrOAuthInvalidIDToken.WithCause(errors.New("nonce mismatch"))
+	}
+	return payload, nil
+}
+
+// resolveEndpoints 返回 authorize / token / userinfo 端点，显式配置优先，其余从 OIDC discovery 补全
+func (s *OAuthProviderService) resolveEndpoints(ctx context.Context, provider *OAuthProviderConfig) (authorizeURL, tokenURL, userInfoURL string, err error) {
+	authorizeURL, tokenURL, userInfoURL = provider.AuthorizeURL, provider.TokenURL, provider.UserInfoURL
+	if provider.Type == OAuthProviderTypeOIDC && provider.Issuer != "" && (authorizeURL == "" || tokenURL == "" || userInfoURL == "") {
+		doc, err := s.discover(ctx, provider.Issuer)
+		if err != nil {
+			return "", "", "", err
+		}
+		authorizeURL = firstNonEmptyString(authorizeURL, doc.AuthorizationEndpoint)
+		tokenURL = firstNonEmptyString(tokenURL, doc.TokenEndpoint)
+		userInfoURL = firstNonEmptyString(userInfoURL, doc.UserinfoEndpoint)
+	}
+	if authorizeURL == "" || tokenURL == "" {
+		return "", "", "", ErrOAuthDiscoveryFailed
+	}
+	return authorizeURL, tokenURL, userInfoURL, nil
+}
+
+// discover 获取并缓存 OIDC discovery 文档
+func (s *OAuthProviderService) discover(ctx context.Context, issuer string) (*oidcDiscoveryDocument, error) {
+	s.mu.Lock()
+	entry, ok := s.discoveryCache[issuer]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return &entry.doc, nil
+	}
+
+	body, err := s.getJSON(ctx, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, ErrOAuthDiscoveryFailed.WithCause(err)
+	}
+	var doc oidcDiscoveryDocument
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, ErrOAuthDiscoveryFailed.WithCause(err)
+	}
+
+	s.mu.Lock()
+	s.discoveryCache[issuer] = oidcDiscoveryCacheEntry{doc: doc, expiresAt: time.Now().Add(oauthProviderDiscoveryTTL)}
+	s.mu.Unlock()
+	return &doc, nil
+}
+
+func (s *OAuthProviderService) exchangeCode(ctx context.Context, provider *OAuthProviderConfig, tokenURL, code, codeVerifier string) (*oauthTokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.RedirectURL)
+	if codeVerifier != "" {
+		form.Set("code_verifier", codeVerifier)
+	}
+	switch provider.TokenAuthMethod {
+	case "client_secret_basic":
+	case "none":
+		form.Set("client_id", provider.ClientID)
+	default:
+		form.Set("client_id", provider.ClientID)
+		form.Set("client_secret", provider.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, ErrOAuthTokenExchange.WithCause(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if provider.TokenAuthMethod == "client_secret_basic" {
+		req.SetBasicAuth(url.QueryEscape(provider.ClientID), url.QueryEscape(provider.ClientSecret))
+	}
+
+	body, status, err := s.do(req)
+	if err != nil {
+		return nil, ErrOAuthTokenExchange.WithCause(err)
+	}
+	var token oauthTokenResponse
+	if status >= 300 || json.Unmarshal([]byte(body), &token) != nil || token.AccessToken == "" {
+		providerErr := gjson.Get(body, "error").String()
+		return nil, ErrOAuthTokenExchange.WithCause(fmt.Errorf("token endpoint status=%d error=%s", status, providerErr))
+	}
+	return &token, nil
+}
+
+// getJSON GET 一个 JSON 端点；token 不为空时携带 Bearer 授权
+func (s *OAuthProviderService) getJSON(ctx context.Context, endpoint string, token *oauthTokenResponse) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != nil {
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	}
+	body, status, err := s.do(req)
+	if err != nil {
+		return "", err
+	}
+	if status >= 300 {
+		return "", fmt.Errorf("GET %s status=%d", endpoint, status)
+	}
+	if !gjson.Valid(body) {
+		return "", fmt.Errorf("GET %s returned invalid json", endpoint)
+	}
+	return body, nil
+}
+
+func (s *OAuthProviderService) do(req *http.Request) (string, int, error) {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, oauthProviderMaxResponseSize))
+	if err != nil {
+		return "", resp.StatusCode, err
+	}
+	return string(body), resp.StatusCode, nil
+}
@@ -0,0 +1,201 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type oauthIdentityRepoStub struct {
+	identities map[string]*UserOAuthIdentity
+}
+
+func (r *oauthIdentityRepoStub) GetByProviderSubject(ctx context.Context, provider, subject string) (*UserOAuthIdentity, error) {
+	if identity, ok := r.identities[provider+"/"+subject]; ok {
+		return identity, nil
+	}
+	return nil, ErrOAuthIdentityNotFound
+}
+
+func (r *oauthIdentityRepoStub) Upsert(ctx context.Context, identity *UserOAuthIdentity) error {
+	panic("unexpected Upsert call")
+}
+
+func (r *oauthIdentityRepoStub) ListByUserID(ctx context.Context, userID int64) ([]UserOAuthIdentity, error) {
+	panic("unexpected ListByUserID call")
+}
+
+func testOIDCToken(t *testing.T, claims map[string]any) string {
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"RS256"}`)) + "." + enc(payload) + ".sig"
+}
+
+func TestNormalizeOAuthProviderConfig(t *testing.T) {
+	p := &OAuthProviderConfig{
+		Name:         " keycloak ",
+		Type:         "OIDC",
+		Issuer:       "https://sso.example.com/realms/main/",
+		ClientID:     "sub2api",
+		ClientSecret: "s",
+		RedirectURL:  "https://api.example.com/api/v1/auth/oauth/providers/keycloak/callback",
+		GroupMappings: []OAuthGroupMapping{
+			{Claim: "vip", GroupID: 3},
+		},
+	}
+	require.NoError(t, normalizeOAuthProviderConfig(p))
+	require.Equal(t, "keycloak", p.Name)
+	require.Equal(t, "keycloak", p.DisplayName)
+	require.Equal(t, "https://sso.example.com/realms/main", p.Issuer)
+	require.Equal(t, "openid email profile", p.Scopes)
+	require.Equal(t, "sub", p.SubjectClaim)
+	require.Equal(t, 30, p.GroupMappings[0].ValidityDays)
+
+	gh := &OAuthProviderConfig{Name: "github", Type: "github", ClientID: "id", ClientSecret: "s", RedirectURL: "https://a/cb"}
+	require.NoError(t, normalizeOAuthProviderConfig(gh))
+	require.Equal(t, "https://github.com/login/oauth/authorize", gh.AuthorizeURL)
+	require.Equal(t, "id", gh.SubjectClaim)
+	require.Equal(t, "login", gh.UsernameClaim)
+
+	invalid := []OAuthProviderConfig{
+		{Name: "linuxdo", Type: "github", ClientID: "id", ClientSecret: "s", RedirectURL: "https://a/cb"},
+		{Name: "Bad Name", Type: "github", ClientID: "id", ClientSecret: "s", RedirectURL: "https://a/cb"},
+		{Name: "corp", Type: "oidc", ClientID: "id", ClientSecret: "s", RedirectURL: "https://a/cb"},
+		{Name: "corp", Type: "oauth2", AuthorizeURL: "https://a/auth", TokenURL: "https://a/token", ClientID: "id", ClientSecret: "s", RedirectURL: "https://a/cb"},
+		{Name: "corp", Type: "github", ClientID: "id", TokenAuthMethod: "none", RedirectURL: "https://a/cb"},
+		{Name: "corp", Type: "github", ClientID: "id", ClientSecret: "s", RedirectURL: "javascript:alert(1)"},
+	}
+	for i := range invalid {
+		require.Error(t, normalizeOAuthProviderConfig(&invalid[i]), invalid[i].Name)
+	}
+}
+
+func TestParseOAuthClaimsMapping(t *testing.T) {
+	p := &OAuthProviderConfig{
+		Type:          OAuthProviderTypeOIDC,
+		UsernameClaim: "attributes.nickname",
+		GroupsClaim:   "realm_access.roles",
+	}
+	oauthProviderDefaults(p)
+
+	userinfo := `{"sub":"u-1","email":"Alice@Example.com","email_verified":"true","attributes":{"nickname":"alice"}}`
+	idToken := `{"sub":"u-1","email":"ignored@example.com","realm_access":{"roles":["vip","staff"]}}`
+	claims := parseOAuthClaims(p, userinfo, idToken)
+	require.Equal(t, "u-1", claims.Subject)
+	require.Equal(t, "alice@example.com", claims.Email)
+	require.True(t, claims.EmailVerified)
+	require.Equal(t, "alice", claims.Username)
+	require.Equal(t, []string{"vip", "staff"}, claims.Groups)
+
+	claims = parseOAuthClaims(p, `{"sub":"u-2","email":"bob@example.com","realm_access":{"roles":"a,b"}}`)
+	require.False(t, claims.EmailVerified)
+	require.Equal(t, "bob", claims.Username)
+	require.Equal(t, []string{"a", "b"}, claims.Groups)
+}
+
+func TestOAuthProviderFetchClaimsOIDC(t *testing.T) {
+	var server *httptest.Server
+	var idToken string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 server.URL,
+				"authorization_endpoint": server.URL + "/auth",
+				"token_endpoint":         server.URL + "/token",
+				"userinfo_endpoint":      server.URL + "/userinfo",
+			})
+		case "/token":
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "the-code", r.PostForm.Get("code"))
+			require.Equal(t, "verifier", r.PostForm.Get("code_verifier"))
+			require.Equal(t, "secret", r.PostForm.Get("client_secret"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": idToken})
+		case "/userinfo":
+			require.Equal(t, "Bearer at", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"sub":"kc-1","email":"carol@example.com","email_verified":true,"preferred_username":"carol"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	idToken = testOIDCToken(t, map[string]any{
+		"iss":    server.URL,
+		"aud":    []string{"sub2api"},
+		"sub":    "kc-1",
+		"nonce":  "n-1",
+		"exp":    time.Now().Add(time.Minute).Unix(),
+		"groups": []string{"vip"},
+	})
+
+	svc := NewOAuthProviderService(nil, nil, nil, nil, nil, nil)
+	p := &OAuthProviderConfig{Name: "keycloak", Type: OAuthProviderTypeOIDC, Issuer: server.URL, ClientID: "sub2api", ClientSecret: "secret", RedirectURL: "https://a/cb", UsePKCE: true}
+	require.NoError(t, normalizeOAuthProviderConfig(p))
+
+	authURL, err := svc.BuildAuthorizeURL(context.Background(), p, "st", "n-1", "challenge")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(authURL, server.URL+"/auth?"))
+	require.Contains(t, authURL, "code_challenge_method=S256")
+	require.Contains(t, authURL, "nonce=n-1")
+
+	claims, err := svc.fetchClaims(context.Background(), p, "the-code", "verifier", "n-1")
+	require.NoError(t, err)
+	require.Equal(t, "kc-1", claims.Subject)
+	require.Equal(t, "carol@example.com", claims.Email)
+	require.True(t, claims.EmailVerified)
+	require.Equal(t, "carol", claims.Username)
+	require.Equal(t, []string{"vip"}, claims.Groups)
+
+	_, err = svc.fetchClaims(context.Background(), p, "the-code", "verifier", "other-nonce")
+	require.ErrorIs(t, err, ErrOAuthInvalidIDToken)
+}
+
+func TestOAuthProviderResolveLoginEmail(t *testing.T) {
+	p := &OAuthProviderConfig{Name: "keycloak"}
+	verified := &OAuthUserClaims{Subject: "kc-1", Email: "dave@example.com", EmailVerified: true}
+	synthetic := oauthSyntheticEmail("keycloak", "kc-1")
+	require.True(t, strings.HasSuffix(synthetic, OAuthSyntheticEmailDomain))
+	require.True(t, isReservedEmail(synthetic))
+
+	newService := func(users *userRepoStub, identities map[string]*UserOAuthIdentity) *OAuthProviderService {
+		return &OAuthProviderService{userRepo: users, identityRepo: &oauthIdentityRepoStub{identities: identities}}
+	}
+
+	// 本地无同邮箱用户：以已验证邮箱注册
+	email, err := newService(&userRepoStub{}, nil).resolveLoginEmail(context.Background(), p, verified)
+	require.NoError(t, err)
+	require.Equal(t, "dave@example.com", email)
+
+	// 同邮箱用户已存在但未开启 link_by_email：不接管本地账号
+	email, err = newService(&userRepoStub{exists: true}, nil).resolveLoginEmail(context.Background(), p, verified)
+	require.NoError(t, err)
+	require.Equal(t, synthetic, email)
+
+	p.LinkByEmail = true
+	email, err = newService(&userRepoStub{exists: true}, nil).resolveLoginEmail(context.Background(), p, verified)
+	require.NoError(t, err)
+	require.Equal(t, "dave@example.com", email)
+
+	// 未验证邮箱一律使用合成邮箱
+	email, err = newService(&userRepoStub{}, nil).resolveLoginEmail(context.Background(), p, &OAuthUserClaims{Subject: "kc-1", Email: "dave@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, synthetic, email)
+
+	// 已绑定身份：登录到绑定用户（即使用户已修改邮箱）
+	users := &userRepoStub{user: &User{ID: 5, Email: "renamed@example.com"}}
+	email, err = newService(users, map[string]*UserOAuthIdentity{"keycloak/kc-1": {UserID: 5}}).resolveLoginEmail(context.Background(), p, verified)
+	require.NoError(t, err)
+	require.Equal(t, "renamed@example.com", email)
+}
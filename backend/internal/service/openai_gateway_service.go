@@ -165,6 +165,8 @@ type OpenAIForwardResult struct {
 	Stream          bool
 	Duration        time.Duration
 	FirstTokenMs    *int
+	// RequestedModel 分组模型降级链生效时，客户端请求的原始模型（由 handler 设置）
+	RequestedModel string
 }
 
 // OpenAIGatewayService handles OpenAI API gateway operations
@@ -184,6 +186,8 @@ type OpenAIGatewayService struct {
 	deferredService     *DeferredService
 	openAITokenProvider *OpenAITokenProvider
 	toolCorrector       *CodexToolCorrector
+	usageWriter         *UsageWriteService
+	latencyCache        AccountLatencyCache // 账号延迟统计缓存（latency 选择策略使用）
 }
 
 // NewOpenAIGatewayService creates a new OpenAIGatewayService
@@ -594,6 +598,40 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 			}
 		}
 
+		// 非默认策略：优先级 → 分组选择策略（与 Claude 调度一致）
+		if strategy := groupSelectionStrategy(openAIGroupFromContext(ctx, groupID)); strategy != SelectionStrategyDefault {
+			var latencyStats map[int64]*AccountLatencyStats
+			if strategy == SelectionStrategyLatency {
+				latencyStats = loadAccountLatencyStatsFrom(ctx, s.latencyCache, available)
+			}
+			for len(available) > 0 {
+				selected := selectByStrategy(strategy, filterByMinPriority(available), false, latencyStats)
+				if selected == nil {
+					break
+				}
+				result, err := s.tryAcquireAccountSlot(ctx, selected.account.ID, selected.account.Concurrency)
+				if err == nil && result.Acquired {
+					if sessionHash != "" {
+						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, selected.account.ID, openaiStickySessionTTL)
+					}
+					return &AccountSelectionResult{
+						Account:     selected.account,
+						Acquired:    true,
+						ReleaseFunc: result.ReleaseFunc,
+					}, nil
+				}
+				// 移除已尝试的账号，重新进行分层过滤
+				selectedID := selected.account.ID
+				remaining := make([]accountWithLoad, 0, len(available)-1)
+				for _, acc := range available {
+					if acc.account.ID != selectedID {
+						remaining = append(remaining, acc)
+					}
+				}
+				available = remaining
+			}
+		}
+
 		if len(available) > 0 {
 			sort.SliceStable(available, func(i, j int) bool {
 				a, b := available[i], available[j]
@@ -1702,6 +1740,11 @@ func (s *OpenAIGatewayService) replaceModelInResponseBody(body []byte, fromModel
 	return newBody
 }
 
+// SetUsageWriteService sets the optional async usage writer (sync writes when unset or disabled)
+func (s *OpenAIGatewayService) SetUsageWriteService(writer *UsageWriteService) {
+	s.usageWriter = writer
+}
+
 // OpenAIRecordUsageInput input for recording usage
 type OpenAIRecordUsageInput struct {
 	Result        *OpenAIForwardResult
@@ -1712,6 +1755,7 @@ type OpenAIRecordUsageInput struct {
 	UserAgent     string // 请求的 User-Agent
 	IPAddress     string // 请求的客户端 IP 地址
 	APIKeyService APIKeyQuotaUpdater
+	Tags          map[string]string // 成本归属标签（已通过白名单校验）
 }
 
 // RecordUsage records usage and deducts balance
@@ -1761,6 +1805,9 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	usageLog := &UsageLog{
 		UserID:                user.ID,
 		APIKeyID:              apiKey.ID,
+		KeyGeneration:         apiKey.SecretGeneration,
+		Tags:                  input.Tags,
+		OrganizationID:        apiKey.OrganizationID,
 		AccountID:             account.ID,
 		RequestID:             result.RequestID,
 		Model:                 result.Model,
@@ -1783,6 +1830,10 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		FirstTokenMs:          result.FirstTokenMs,
 		CreatedAt:             time.Now(),
 	}
+	if result.RequestedModel != "" && result.RequestedModel != result.Model {
+		requestedModel := result.RequestedModel
+		usageLog.RequestedModel = &requestedModel
+	}
 
 	// 添加 UserAgent
 	if input.UserAgent != "" {
@@ -1801,6 +1852,21 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		usageLog.SubscriptionID = &subscription.ID
 	}
 
+	s.recordAccountLatency(ctx, account.ID, AccountLatencySample{FirstTokenMs: result.FirstTokenMs, DurationMs: &durationMs})
+
+	// 异步写入：记录入队后由后台批量落库并扣费，此处仅更新 Redis 扣费缓存
+	if s.usageWriter.Enabled() {
+		var charge UsageCharge
+		if s.cfg == nil || s.cfg.RunMode != config.RunModeSimple {
+			charge = newUsageCharge(user, apiKey, subscription, cost, isSubscriptionBilling, input.APIKeyService)
+		}
+		if s.usageWriter.Submit(ctx, usageLog, charge) {
+			applyUsageChargeCache(s.billingCacheService, charge)
+			s.deferredService.ScheduleLastUsedUpdate(account.ID)
+			return nil
+		}
+	}
+
 	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
 		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
@@ -1814,15 +1880,21 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	if isSubscriptionBilling {
 		if shouldBill && cost.TotalCost > 0 {
 			_ = s.userSubRepo.IncrementUsage(ctx, subscription.ID, cost.TotalCost)
-			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
+			s.billingCacheService.QueueUpdateSubscriptionUsage(subscriptionUserID(user, apiKey), *apiKey.GroupID, cost.TotalCost)
 		}
-	} else {
+	} else if apiKey.OrganizationID == nil {
 		if shouldBill && cost.ActualCost > 0 {
 			_ = s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost)
 			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+			s.billingCacheService.RecordReferralSpend(ctx, user.ID, cost.ActualCost)
 		}
 	}
 
+	// 组织 Key：累加成员月度用量，余额模式下从组织资金池扣费
+	if shouldBill && apiKey.OrganizationID != nil {
+		s.billingCacheService.RecordOrganizationUsage(ctx, apiKey, cost.ActualCost, !isSubscriptionBilling)
+	}
+
 	// Update API key quota if applicable (only for balance mode with quota set)
 	if shouldBill && cost.ActualCost > 0 && apiKey.Quota > 0 && input.APIKeyService != nil {
 		if err := input.APIKeyService.UpdateQuotaUsed(ctx, apiKey.ID, cost.ActualCost); err != nil {
@@ -1830,6 +1902,11 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		}
 	}
 
+	// 更新 API Key 日/周/月消费缓存（仅配置了消费上限时）
+	if shouldBill && cost.ActualCost > 0 && apiKey.HasSpendLimits() {
+		s.billingCacheService.QueueUpdateAPIKeySpend(apiKey.ID, cost.ActualCost)
+	}
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
@@ -35,6 +35,7 @@ type OpsAlertEvaluatorService struct {
 	opsService   *OpsService
 	opsRepo      OpsRepository
 	emailService *EmailService
+	proxyHealth  ProxyHealthRepository
 
 	redisClient *redis.Client
 	cfg         *config.Config
@@ -80,6 +81,14 @@ func NewOpsAlertEvaluatorService(
 	}
 }
 
+// SetProxyHealthRepository 注入代理健康状态存储，用于 proxy_down_count / proxy_exit_ip_changed 指标。
+func (s *OpsAlertEvaluatorService) SetProxyHealthRepository(repo ProxyHealthRepository) {
+	if s == nil {
+		return
+	}
+	s.proxyHealth = repo
+}
+
 func (s *OpsAlertEvaluatorService) Start() {
 	if s == nil {
 		return
@@ -457,6 +466,16 @@ func (s *OpsAlertEvaluatorService) computeRuleMetric(
 			return float64(*systemMetrics.ConcurrencyQueueDepth), true
 		}
 		return 0, false
+	case "usage_write_backlog":
+		if systemMetrics != nil && systemMetrics.UsageWriteBacklog != nil {
+			return float64(*systemMetrics.UsageWriteBacklog), true
+		}
+		return 0, false
+	case "usage_write_dead_letters":
+		if systemMetrics != nil && systemMetrics.UsageWriteDeadLetters != nil {
+			return float64(*systemMetrics.UsageWriteDeadLetters), true
+		}
+		return 0, false
 	case "group_available_accounts":
 		if groupID == nil || *groupID <= 0 {
 			return 0, false
@@ -506,6 +525,24 @@ func (s *OpsAlertEvaluatorService) computeRuleMetric(
 		return float64(countAccountsByCondition(availability.Accounts, func(acc *AccountAvailability) bool {
 			return acc.HasError && acc.TempUnschedulableUntil == nil
 		})), true
+	case "proxy_down_count":
+		if s == nil || s.proxyHealth == nil {
+			return 0, false
+		}
+		count, err := s.proxyHealth.CountDown(ctx)
+		if err != nil {
+			return 0, false
+		}
+		return float64(count), true
+	case "proxy_exit_ip_changed":
+		if s == nil || s.proxyHealth == nil {
+			return 0, false
+		}
+		count, err := s.proxyHealth.CountExitChanges(ctx, start, end)
+		if err != nil {
+			return 0, false
+		}
+		return float64(count), true
 	}
 
 	overview, err := s.opsRepo.GetDashboardOverview(ctx, &OpsDashboardFilter{
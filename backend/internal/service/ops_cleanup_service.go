@@ -160,17 +160,23 @@ type opsCleanupDeletedCounts struct {
 	systemMetrics int64
 	hourlyPreagg  int64
 	dailyPreagg   int64
+	auditLogs     int64
+	loginEvents   int64
+	userSessions  int64
 }
 
 func (c opsCleanupDeletedCounts) String() string {
 	return fmt.Sprintf(
-		"error_logs=%d retry_attempts=%d alert_events=%d system_metrics=%d hourly_preagg=%d daily_preagg=%d",
+		"error_logs=%d retry_attempts=%d alert_events=%d system_metrics=%d hourly_preagg=%d daily_preagg=%d audit_logs=%d login_events=%d user_sessions=%d",
 		c.errorLogs,
 		c.retryAttempts,
 		c.alertEvents,
 		c.systemMetrics,
 		c.hourlyPreagg,
 		c.dailyPreagg,
+		c.auditLogs,
+		c.loginEvents,
+		c.userSessions,
 	)
 }
 
@@ -232,6 +238,31 @@ func (s *OpsCleanupService) runCleanupOnce(ctx context.Context) (opsCleanupDelet
 		out.dailyPreagg = n
 	}
 
+	// Admin audit log (append-only, only pruned by retention).
+	if days := s.cfg.Ops.Cleanup.AuditLogRetentionDays; days > 0 {
+		cutoff := now.AddDate(0, 0, -days)
+		n, err := deleteOldRowsByID(ctx, s.db, "admin_audit_logs", "created_at", cutoff, batchSize, false)
+		if err != nil {
+			return out, err
+		}
+		out.auditLogs = n
+	}
+
+	// Login events, plus sessions whose refresh tokens have already expired.
+	if days := s.cfg.Ops.Cleanup.LoginEventRetentionDays; days > 0 {
+		cutoff := now.AddDate(0, 0, -days)
+		n, err := deleteOldRowsByID(ctx, s.db, "login_events", "created_at", cutoff, batchSize, false)
+		if err != nil {
+			return out, err
+		}
+		out.loginEvents = n
+	}
+	n, err := deleteOldRowsByID(ctx, s.db, "user_sessions", "expires_at", now, batchSize, false)
+	if err != nil {
+		return out, err
+	}
+	out.userSessions = n
+
 	return out, nil
 }
 
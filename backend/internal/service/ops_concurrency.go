@@ -392,5 +392,25 @@ func (s *OpsService) GetUserConcurrencyStats(ctx context.Context) (map[int64]*Us
 		result[u.ID] = info
 	}
 
+	s.attachFairSharesBestEffort(ctx, result)
+
 	return result, &collectedAt, nil
 }
+
+// attachFairSharesBestEffort 为用户附加分组内公平分配的占用与配额
+func (s *OpsService) attachFairSharesBestEffort(ctx context.Context, users map[int64]*UserConcurrencyInfo) {
+	if s.concurrencyService == nil || len(users) == 0 {
+		return
+	}
+	shares, err := s.concurrencyService.ListFairShares(ctx)
+	if err != nil {
+		// Best-effort: fair share data is optional for the ops UI.
+		log.Printf("[Ops] ListFairShares failed: %v", err)
+		return
+	}
+	for _, share := range shares {
+		if info := users[share.UserID]; info != nil {
+			info.FairShares = append(info.FairShares, share)
+		}
+	}
+}
@@ -46,6 +46,7 @@ type OpsMetricsCollector struct {
 
 	accountRepo        AccountRepository
 	concurrencyService *ConcurrencyService
+	usageWriter        *UsageWriteService
 
 	db          *sql.DB
 	redisClient *redis.Client
@@ -83,6 +84,11 @@ func NewOpsMetricsCollector(
 	}
 }
 
+// SetUsageWriteService 设置使用记录异步写入服务，用于采集写入队列积压（可选依赖）
+func (c *OpsMetricsCollector) SetUsageWriteService(writer *UsageWriteService) {
+	c.usageWriter = writer
+}
+
 func (c *OpsMetricsCollector) Start() {
 	if c == nil {
 		return
@@ -300,6 +306,7 @@ func (c *OpsMetricsCollector) collectAndPersist(ctx context.Context) error {
 
 	goroutines := runtime.NumGoroutine()
 	concurrencyQueueDepth := c.collectConcurrencyQueueDepth(ctx)
+	usageWriteBacklog, usageWriteDeadLetters := c.collectUsageWriteQueue(ctx)
 
 	input := &OpsInsertSystemMetricsInput{
 		CreatedAt:     windowEnd,
@@ -358,11 +365,33 @@ func (c *OpsMetricsCollector) collectAndPersist(ctx context.Context) error {
 		DBConnIdle:            intPtr(idle),
 		GoroutineCount:        intPtr(goroutines),
 		ConcurrencyQueueDepth: concurrencyQueueDepth,
+
+		UsageWriteBacklog:     usageWriteBacklog,
+		UsageWriteDeadLetters: usageWriteDeadLetters,
 	}
 
 	return c.opsRepo.InsertSystemMetrics(ctx, input)
 }
 
+func (c *OpsMetricsCollector) collectUsageWriteQueue(parentCtx context.Context) (*int, *int) {
+	if c == nil || !c.usageWriter.Enabled() {
+		return nil, nil
+	}
+	if parentCtx == nil {
+		parentCtx = context.Background()
+	}
+
+	// Best-effort: the queue lives in Redis, a Redis outage must not break the collector.
+	ctx, cancel := context.WithTimeout(parentCtx, 2*time.Second)
+	defer cancel()
+
+	stats, err := c.usageWriter.Stats(ctx)
+	if err != nil {
+		return nil, nil
+	}
+	return intPtr(int(stats.Backlog)), intPtr(int(stats.DeadLetters))
+}
+
 func (c *OpsMetricsCollector) collectConcurrencyQueueDepth(parentCtx context.Context) *int {
 	if c == nil || c.accountRepo == nil || c.concurrencyService == nil {
 		return nil
@@ -198,6 +198,10 @@ type OpsInsertSystemMetricsInput struct {
 
 	GoroutineCount        *int
 	ConcurrencyQueueDepth *int
+
+	// 使用记录异步写入队列（未启用时为空）
+	UsageWriteBacklog     *int
+	UsageWriteDeadLetters *int
 }
 
 type OpsSystemMetricsSnapshot struct {
@@ -227,6 +231,9 @@ type OpsSystemMetricsSnapshot struct {
 	GoroutineCount        *int   `json:"goroutine_count"`
 	ConcurrencyQueueDepth *int   `json:"concurrency_queue_depth"`
 	AccountSwitchCount    *int64 `json:"account_switch_count"`
+
+	UsageWriteBacklog     *int `json:"usage_write_backlog"`
+	UsageWriteDeadLetters *int `json:"usage_write_dead_letters"`
 }
 
 type OpsUpsertJobHeartbeatInput struct {
@@ -46,6 +46,9 @@ type UserConcurrencyInfo struct {
 	MaxCapacity    int64   `json:"max_capacity"`
 	LoadPercentage float64 `json:"load_percentage"`
 	WaitingInQueue int64   `json:"waiting_in_queue"`
+
+	// FairShares 用户在开启公平分配的分组内的当前占用与配额
+	FairShares []UserFairShare `json:"fair_shares,omitempty"`
 }
 
 // PlatformAvailability aggregates account availability by platform.
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 组织成员角色
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+// 组织余额流水类型（organization_balance_logs.type）
+const (
+	OrganizationBalanceLogTypeDeposit = "deposit" // 成员从个人余额划转
+	OrganizationBalanceLogTypeAdmin   = "admin"   // 管理员调整
+)
+
+var (
+	ErrOrganizationNotFound                 = infraerrors.NotFound("ORGANIZATION_NOT_FOUND", "organization not found")
+	ErrOrganizationMemberNotFound           = infraerrors.NotFound("ORGANIZATION_MEMBER_NOT_FOUND", "organization member not found")
+	ErrOrganizationMemberExists             = infraerrors.Conflict("ORGANIZATION_MEMBER_EXISTS", "user is already a member of this organization")
+	ErrOrganizationForbidden                = infraerrors.Forbidden("ORGANIZATION_FORBIDDEN", "insufficient organization role")
+	ErrOrganizationDisabled                 = infraerrors.Forbidden("ORGANIZATION_DISABLED", "organization is disabled")
+	ErrOrganizationOwnerImmutable           = infraerrors.BadRequest("ORGANIZATION_OWNER_IMMUTABLE", "the organization owner cannot be removed or demoted")
+	ErrOrganizationInvalidRole              = infraerrors.BadRequest("ORGANIZATION_INVALID_ROLE", "role must be admin or member")
+	ErrOrganizationInsufficientBalance      = infraerrors.Forbidden("ORGANIZATION_INSUFFICIENT_BALANCE", "insufficient organization balance")
+	ErrOrganizationMemberSpendLimitExceeded = infraerrors.TooManyRequests("ORGANIZATION_MEMBER_SPEND_LIMIT_EXCEEDED", "monthly spend limit for this organization member exceeded")
+)
+
+// Organization 组织（团队），成员共享余额；订阅类型分组下组织 Key 使用所有者名下的订阅
+type Organization struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	OwnerUserID int64     `json:"owner_user_id"`
+	Balance     float64   `json:"balance"`
+	Status      string    `json:"status"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Role 当前用户在组织中的角色（仅按用户列出组织时填充）
+	Role string `json:"role,omitempty"`
+}
+
+// IsActive 组织是否可用
+func (o *Organization) IsActive() bool {
+	return o.Status == StatusActive
+}
+
+// OrganizationMember 组织成员
+type OrganizationMember struct {
+	OrganizationID int64  `json:"organization_id"`
+	UserID         int64  `json:"user_id"`
+	Email          string `json:"email"`
+	Username       string `json:"username"`
+	Role           string `json:"role"`
+	// SpendLimitUSD 每月可消耗组织资源的上限，nil 表示不限制
+	SpendLimitUSD *float64 `json:"spend_limit_usd"`
+	// SpendUsedUSD 当月已用金额（跨月后为 0）
+	SpendUsedUSD float64   `json:"spend_used_usd"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CanManageMembers 是否可以管理成员（owner / admin）
+func (m *OrganizationMember) CanManageMembers() bool {
+	return m.Role == OrganizationRoleOwner || m.Role == OrganizationRoleAdmin
+}
+
+// OrganizationBillingState 组织 Key 计费资格检查所需的组织与成员状态
+type OrganizationBillingState struct {
+	Status        string
+	Balance       float64
+	SpendLimitUSD *float64
+	SpendUsedUSD  float64
+}
+
+// OrganizationRepository 组织与成员存储
+type OrganizationRepository interface {
+	// Create 创建组织并将所有者加入成员（角色 owner）
+	Create(ctx context.Context, org *Organization) error
+	GetByID(ctx context.Context, id int64) (*Organization, error)
+	// ListByUserID 列出用户所在的组织（填充 Role）
+	ListByUserID(ctx context.Context, userID int64) ([]Organization, error)
+	List(ctx context.Context, params pagination.PaginationParams, search string) ([]Organization, *pagination.PaginationResult, error)
+	UpdateName(ctx context.Context, id int64, name string) error
+	UpdateStatus(ctx context.Context, id int64, status string) error
+	// AdjustBalance 调整组织余额（set / add / subtract），返回调整后的余额
+	AdjustBalance(ctx context.Context, id int64, amount float64, operation string) (float64, error)
+	// TransferFromUser 从用户个人余额划转到组织余额（余额不足时返回 ErrInsufficientBalance）
+	TransferFromUser(ctx context.Context, id, userID int64, amount float64) (float64, error)
+
+	// ListMembers 列出成员，monthStart 用于计算当月已用金额
+	ListMembers(ctx context.Context, orgID int64, monthStart time.Time) ([]OrganizationMember, error)
+	GetMember(ctx context.Context, orgID, userID int64, monthStart time.Time) (*OrganizationMember, error)
+	AddMember(ctx context.Context, member *OrganizationMember) error
+	UpdateMember(ctx context.Context, orgID, userID int64, role string, spendLimitUSD *float64) error
+	RemoveMember(ctx context.Context, orgID, userID int64) error
+	// DisableMemberAPIKeys 禁用成员创建的组织 Key（成员移出组织时调用）
+	DisableMemberAPIKeys(ctx context.Context, orgID, userID int64) error
+
+	// GetBillingState 读取组织余额与成员当月用量；不是成员时返回 ErrOrganizationMemberNotFound
+	GetBillingState(ctx context.Context, orgID, userID int64, monthStart time.Time) (*OrganizationBillingState, error)
+	// RecordUsage 累加成员当月用量，deductBalance 为 true 时同时从组织余额扣除
+	RecordUsage(ctx context.Context, orgID, userID int64, cost float64, deductBalance bool, monthStart time.Time) error
+}
+
+// subscriptionUserID 订阅归属用户：组织 Key 使用组织所有者的订阅
+func subscriptionUserID(user *User, apiKey *APIKey) int64 {
+	if apiKey != nil && apiKey.OrganizationID != nil {
+		return apiKey.BillingUserID()
+	}
+	return user.ID
+}
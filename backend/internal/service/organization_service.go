@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+const organizationNameMaxLen = 100
+
+// CreateOrganizationRequest 创建组织请求
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+// AddOrganizationMemberRequest 添加成员请求（按邮箱查找已注册用户）
+type AddOrganizationMemberRequest struct {
+	Email         string   `json:"email"`
+	Role          string   `json:"role"`
+	SpendLimitUSD *float64 `json:"spend_limit_usd"`
+}
+
+// UpdateOrganizationMemberRequest 更新成员请求，字段为 nil 表示不修改
+type UpdateOrganizationMemberRequest struct {
+	Role          *string  `json:"role"`
+	SpendLimitUSD *float64 `json:"spend_limit_usd"`
+	// ClearSpendLimit 为 true 时取消成员消费上限
+	ClearSpendLimit bool `json:"clear_spend_limit"`
+}
+
+// OrganizationService 组织与成员管理
+type OrganizationService struct {
+	orgRepo             OrganizationRepository
+	userRepo            UserRepository
+	usageLogRepo        UsageLogRepository
+	apiKeyService       *APIKeyService
+	billingCacheService *BillingCacheService
+}
+
+// NewOrganizationService 创建组织服务
+func NewOrganizationService(
+	orgRepo OrganizationRepository,
+	userRepo UserRepository,
+	usageLogRepo UsageLogRepository,
+	apiKeyService *APIKeyService,
+	billingCacheService *BillingCacheService,
+) *OrganizationService {
+	return &OrganizationService{
+		orgRepo:             orgRepo,
+		userRepo:            userRepo,
+		usageLogRepo:        usageLogRepo,
+		apiKeyService:       apiKeyService,
+		billingCacheService: billingCacheService,
+	}
+}
+
+func normalizeOrganizationName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > organizationNameMaxLen {
+		return "", infraerrors.BadRequest("ORGANIZATION_NAME_INVALID", fmt.Sprintf("name is required and must be at most %d characters", organizationNameMaxLen))
+	}
+	return name, nil
+}
+
+func validateOrganizationSpendLimit(limit *float64) error {
+	if limit != nil && *limit < 0 {
+		return infraerrors.BadRequest("ORGANIZATION_SPEND_LIMIT_INVALID", "spend_limit_usd must be non-negative")
+	}
+	return nil
+}
+
+// Create 创建组织，创建者成为所有者
+func (s *OrganizationService) Create(ctx context.Context, userID int64, req CreateOrganizationRequest) (*Organization, error) {
+	name, err := normalizeOrganizationName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	org := &Organization{
+		Name:        name,
+		OwnerUserID: userID,
+		Status:      StatusActive,
+	}
+	if err := s.orgRepo.Create(ctx, org); err != nil {
+		return nil, fmt.Errorf("create organization: %w", err)
+	}
+	org.Role = OrganizationRoleOwner
+	org.MemberCount = 1
+	return org, nil
+}
+
+// ListForUser 列出用户所在的组织
+func (s *OrganizationService) ListForUser(ctx context.Context, userID int64) ([]Organization, error) {
+	return s.orgRepo.ListByUserID(ctx, userID)
+}
+
+// requireMember 校验用户是组织成员，返回组织与成员信息
+func (s *OrganizationService) requireMember(ctx context.Context, orgID, userID int64) (*Organization, *OrganizationMember, error) {
+	member, err := s.orgRepo.GetMember(ctx, orgID, userID, timezone.StartOfMonth(time.Now()))
+	if err != nil {
+		if infraerrors.IsNotFound(err) {
+			// 非成员统一返回组织不存在，避免探测组织 ID
+			return nil, nil, ErrOrganizationNotFound
+		}
+		return nil, nil, err
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	org.Role = member.Role
+	return org, member, nil
+}
+
+func (s *OrganizationService) requireManager(ctx context.Context, orgID, userID int64) (*Organization, *OrganizationMember, error) {
+	org, member, err := s.requireMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !member.CanManageMembers() {
+		return nil, nil, ErrOrganizationForbidden
+	}
+	return org, member, nil
+}
+
+// CheckManager 校验用户是组织的 owner / admin（供对账单等组织级资源复用）
+func (s *OrganizationService) CheckManager(ctx context.Context, orgID, userID int64) error {
+	_, _, err := s.requireManager(ctx, orgID, userID)
+	return err
+}
+
+// Get 获取组织详情（成员可见）
+func (s *OrganizationService) Get(ctx context.Context, orgID, userID int64) (*Organization, error) {
+	org, _, err := s.requireMember(ctx, orgID, userID)
+	return org, err
+}
+
+// Rename 修改组织名称（owner / admin）
+func (s *OrganizationService) Rename(ctx context.Context, orgID, userID int64, name string) (*Organization, error) {
+	name, err := normalizeOrganizationName(name)
+	if err != nil {
+		return nil, err
+	}
+	org, _, err := s.requireManager(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.orgRepo.UpdateName(ctx, orgID, name); err != nil {
+		return nil, err
+	}
+	org.Name = name
+	return org, nil
+}
+
+// ListMembers 列出成员（成员可见，含当月已用金额）
+func (s *OrganizationService) ListMembers(ctx context.Context, orgID, userID int64) ([]OrganizationMember, error) {
+	if _, _, err := s.requireMember(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+	return s.orgRepo.ListMembers(ctx, orgID, timezone.StartOfMonth(time.Now()))
+}
+
+// AddMember 添加成员（owner / admin）。只有所有者可以添加 admin。
+func (s *OrganizationService) AddMember(ctx context.Context, orgID, operatorID int64, req AddOrganizationMemberRequest) (*OrganizationMember, error) {
+	role := strings.TrimSpace(req.Role)
+	if role == "" {
+		role = OrganizationRoleMember
+	}
+	if role != OrganizationRoleAdmin && role != OrganizationRoleMember {
+		return nil, ErrOrganizationInvalidRole
+	}
+	if err := validateOrganizationSpendLimit(req.SpendLimitUSD); err != nil {
+		return nil, err
+	}
+	_, operator, err := s.requireManager(ctx, orgID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if role == OrganizationRoleAdmin && operator.Role != OrganizationRoleOwner {
+		return nil, ErrOrganizationForbidden
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, strings.TrimSpace(req.Email))
+	if err != nil {
+		if infraerrors.IsNotFound(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, ErrUserNotActive
+	}
+
+	member := &OrganizationMember{
+		OrganizationID: orgID,
+		UserID:         user.ID,
+		Email:          user.Email,
+		Username:       user.Username,
+		Role:           role,
+		SpendLimitUSD:  req.SpendLimitUSD,
+	}
+	if err := s.orgRepo.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// UpdateMember 修改成员角色或消费上限（owner / admin）。
+// 所有者不可被修改角色；admin 只能调整普通成员，角色变更仅所有者可操作。
+func (s *OrganizationService) UpdateMember(ctx context.Context, orgID, operatorID, memberID int64, req UpdateOrganizationMemberRequest) (*OrganizationMember, error) {
+	if err := validateOrganizationSpendLimit(req.SpendLimitUSD); err != nil {
+		return nil, err
+	}
+	_, operator, err := s.requireManager(ctx, orgID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.orgRepo.GetMember(ctx, orgID, memberID, timezone.StartOfMonth(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	if operator.Role != OrganizationRoleOwner && target.Role != OrganizationRoleMember {
+		return nil, ErrOrganizationForbidden
+	}
+
+	role := target.Role
+	if req.Role != nil && *req.Role != target.Role {
+		if target.Role == OrganizationRoleOwner {
+			return nil, ErrOrganizationOwnerImmutable
+		}
+		if *req.Role != OrganizationRoleAdmin && *req.Role != OrganizationRoleMember {
+			return nil, ErrOrganizationInvalidRole
+		}
+		if operator.Role != OrganizationRoleOwner {
+			return nil, ErrOrganizationForbidden
+		}
+		role = *req.Role
+	}
+	limit := target.SpendLimitUSD
+	if req.ClearSpendLimit {
+		limit = nil
+	} else if req.SpendLimitUSD != nil {
+		limit = req.SpendLimitUSD
+	}
+
+	if err := s.orgRepo.UpdateMember(ctx, orgID, memberID, role, limit); err != nil {
+		return nil, err
+	}
+	if s.billingCacheService != nil {
+		s.billingCacheService.InvalidateOrganizationMemberBilling(ctx, orgID, memberID)
+	}
+	target.Role = role
+	target.SpendLimitUSD = limit
+	return target, nil
+}
+
+// RemoveMember 移除成员或主动退出（memberID == operatorID）。
+// 所有者不能被移除；被移除成员创建的组织 Key 会被禁用。
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgID, operatorID, memberID int64) error {
+	_, operator, err := s.requireMember(ctx, orgID, operatorID)
+	if err != nil {
+		return err
+	}
+	target := operator
+	if memberID != operatorID {
+		if !operator.CanManageMembers() {
+			return ErrOrganizationForbidden
+		}
+		if target, err = s.orgRepo.GetMember(ctx, orgID, memberID, timezone.StartOfMonth(time.Now())); err != nil {
+			return err
+		}
+		if operator.Role != OrganizationRoleOwner && target.Role != OrganizationRoleMember {
+			return ErrOrganizationForbidden
+		}
+	}
+	if target.Role == OrganizationRoleOwner {
+		return ErrOrganizationOwnerImmutable
+	}
+
+	if err := s.orgRepo.RemoveMember(ctx, orgID, memberID); err != nil {
+		return err
+	}
+	if s.billingCacheService != nil {
+		s.billingCacheService.InvalidateOrganizationMemberBilling(ctx, orgID, memberID)
+	}
+	if err := s.orgRepo.DisableMemberAPIKeys(ctx, orgID, memberID); err != nil {
+		slog.Warn("organization_member_keys_disable_failed", "organization_id", orgID, "user_id", memberID, "error", err)
+	}
+	if s.apiKeyService != nil {
+		s.apiKeyService.InvalidateAuthCacheByUserID(ctx, memberID)
+	}
+	return nil
+}
+
+// Deposit 从成员个人余额划转到组织余额（owner / admin）
+func (s *OrganizationService) Deposit(ctx context.Context, orgID, userID int64, amount float64) (*Organization, error) {
+	if amount <= 0 {
+		return nil, infraerrors.BadRequest("ORGANIZATION_DEPOSIT_INVALID", "amount must be positive")
+	}
+	org, _, err := s.requireManager(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !org.IsActive() {
+		return nil, ErrOrganizationDisabled
+	}
+	balance, err := s.orgRepo.TransferFromUser(ctx, orgID, userID, amount)
+	if err != nil {
+		return nil, err
+	}
+	if s.billingCacheService != nil {
+		_ = s.billingCacheService.InvalidateUserBalance(ctx, userID)
+		s.billingCacheService.InvalidateOrganizationBilling(ctx, orgID)
+	}
+	if s.apiKeyService != nil {
+		// 认证缓存快照包含用户余额
+		s.apiKeyService.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+	org.Balance = balance
+	return org, nil
+}
+
+// GetUsageStats 组织用量统计：owner / admin 查看整个组织，普通成员只能查看自己的用量
+func (s *OrganizationService) GetUsageStats(ctx context.Context, orgID, userID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error) {
+	_, member, err := s.requireMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	filters := usagestats.UsageLogFilters{
+		OrganizationID: orgID,
+		StartTime:      &startTime,
+		EndTime:        &endTime,
+	}
+	if !member.CanManageMembers() {
+		filters.UserID = userID
+	}
+	return s.usageLogRepo.GetStatsWithFilters(ctx, filters)
+}
+
+// ValidateAPIKeyOrganization 校验用户可以在组织下创建 API Key（组织可用且为成员）
+func (s *OrganizationService) ValidateAPIKeyOrganization(ctx context.Context, orgID, userID int64) error {
+	org, _, err := s.requireMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !org.IsActive() {
+		return ErrOrganizationDisabled
+	}
+	return nil
+}
+
+// ========== 管理员接口 ==========
+
+// AdminList 管理员分页列出组织
+func (s *OrganizationService) AdminList(ctx context.Context, params pagination.PaginationParams, search string) ([]Organization, *pagination.PaginationResult, error) {
+	return s.orgRepo.List(ctx, params, strings.TrimSpace(search))
+}
+
+// AdminGet 管理员获取组织详情与成员
+func (s *OrganizationService) AdminGet(ctx context.Context, orgID int64) (*Organization, []OrganizationMember, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := s.orgRepo.ListMembers(ctx, orgID, timezone.StartOfMonth(time.Now()))
+	if err != nil {
+		return nil, nil, err
+	}
+	return org, members, nil
+}
+
+// AdminUpdateBalance 管理员调整组织余额
+func (s *OrganizationService) AdminUpdateBalance(ctx context.Context, orgID int64, amount float64, operation string) (*Organization, error) {
+	if _, err := s.orgRepo.AdjustBalance(ctx, orgID, amount, operation); err != nil {
+		return nil, err
+	}
+	if s.billingCacheService != nil {
+		s.billingCacheService.InvalidateOrganizationBilling(ctx, orgID)
+	}
+	return s.orgRepo.GetByID(ctx, orgID)
+}
+
+// AdminUpdateStatus 管理员启用/禁用组织
+func (s *OrganizationService) AdminUpdateStatus(ctx context.Context, orgID int64, status string) (*Organization, error) {
+	if status != StatusActive && status != StatusDisabled {
+		return nil, infraerrors.BadRequest("ORGANIZATION_STATUS_INVALID", "status must be active or disabled")
+	}
+	if err := s.orgRepo.UpdateStatus(ctx, orgID, status); err != nil {
+		return nil, err
+	}
+	if s.billingCacheService != nil {
+		s.billingCacheService.InvalidateOrganizationBilling(ctx, orgID)
+	}
+	return s.orgRepo.GetByID(ctx, orgID)
+}
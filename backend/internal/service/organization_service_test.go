@@ -0,0 +1,326 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// organizationRepoStub 仅实现成员相关方法，其余方法调用时 panic
+type organizationRepoStub struct {
+	OrganizationRepository
+
+	org           *Organization
+	members       map[int64]*OrganizationMember
+	removed       []int64
+	disabledKeys  []int64
+	updatedRole   string
+	updatedLimit  *float64
+	updateCalls   int
+	transferCalls int
+
+	billingStateCalls int
+}
+
+func newOrganizationRepoStub(members ...OrganizationMember) *organizationRepoStub {
+	s := &organizationRepoStub{
+		org:     &Organization{ID: 1, Name: "team", OwnerUserID: 1, Status: StatusActive},
+		members: map[int64]*OrganizationMember{},
+	}
+	for i := range members {
+		m := members[i]
+		m.OrganizationID = s.org.ID
+		s.members[m.UserID] = &m
+	}
+	return s
+}
+
+func (s *organizationRepoStub) GetByID(ctx context.Context, id int64) (*Organization, error) {
+	if id != s.org.ID {
+		return nil, ErrOrganizationNotFound
+	}
+	org := *s.org
+	return &org, nil
+}
+
+func (s *organizationRepoStub) GetMember(ctx context.Context, orgID, userID int64, monthStart time.Time) (*OrganizationMember, error) {
+	m, ok := s.members[userID]
+	if !ok || orgID != s.org.ID {
+		return nil, ErrOrganizationMemberNotFound
+	}
+	out := *m
+	return &out, nil
+}
+
+func (s *organizationRepoStub) UpdateMember(ctx context.Context, orgID, userID int64, role string, spendLimitUSD *float64) error {
+	s.updateCalls++
+	s.updatedRole = role
+	s.updatedLimit = spendLimitUSD
+	return nil
+}
+
+func (s *organizationRepoStub) RemoveMember(ctx context.Context, orgID, userID int64) error {
+	s.removed = append(s.removed, userID)
+	return nil
+}
+
+func (s *organizationRepoStub) DisableMemberAPIKeys(ctx context.Context, orgID, userID int64) error {
+	s.disabledKeys = append(s.disabledKeys, userID)
+	return nil
+}
+
+func (s *organizationRepoStub) TransferFromUser(ctx context.Context, id, userID int64, amount float64) (float64, error) {
+	s.transferCalls++
+	return amount, nil
+}
+
+func newTestOrganizationService(repo *organizationRepoStub) *OrganizationService {
+	return NewOrganizationService(repo, nil, nil, nil, nil)
+}
+
+func defaultOrganizationMembers() []OrganizationMember {
+	return []OrganizationMember{
+		{UserID: 1, Role: OrganizationRoleOwner},
+		{UserID: 2, Role: OrganizationRoleAdmin},
+		{UserID: 3, Role: OrganizationRoleMember},
+		{UserID: 4, Role: OrganizationRoleMember},
+	}
+}
+
+func TestOrganizationService_UpdateMember_RoleRules(t *testing.T) {
+	ctx := context.Background()
+	admin := OrganizationRoleAdmin
+	member := OrganizationRoleMember
+	limit := 25.0
+
+	t.Run("owner promotes member to admin", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		got, err := newTestOrganizationService(repo).UpdateMember(ctx, 1, 1, 3, UpdateOrganizationMemberRequest{Role: &admin})
+		require.NoError(t, err)
+		require.Equal(t, OrganizationRoleAdmin, got.Role)
+		require.Equal(t, OrganizationRoleAdmin, repo.updatedRole)
+	})
+
+	t.Run("admin cannot change roles", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		_, err := newTestOrganizationService(repo).UpdateMember(ctx, 1, 2, 3, UpdateOrganizationMemberRequest{Role: &admin})
+		require.ErrorIs(t, err, ErrOrganizationForbidden)
+		require.Zero(t, repo.updateCalls)
+	})
+
+	t.Run("admin sets member spend limit", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		got, err := newTestOrganizationService(repo).UpdateMember(ctx, 1, 2, 3, UpdateOrganizationMemberRequest{SpendLimitUSD: &limit})
+		require.NoError(t, err)
+		require.NotNil(t, got.SpendLimitUSD)
+		require.Equal(t, limit, *repo.updatedLimit)
+	})
+
+	t.Run("admin cannot modify another admin", func(t *testing.T) {
+		members := append(defaultOrganizationMembers(), OrganizationMember{UserID: 5, Role: OrganizationRoleAdmin})
+		repo := newOrganizationRepoStub(members...)
+		_, err := newTestOrganizationService(repo).UpdateMember(ctx, 1, 2, 5, UpdateOrganizationMemberRequest{SpendLimitUSD: &limit})
+		require.ErrorIs(t, err, ErrOrganizationForbidden)
+	})
+
+	t.Run("owner role is immutable", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		_, err := newTestOrganizationService(repo).UpdateMember(ctx, 1, 1, 1, UpdateOrganizationMemberRequest{Role: &member})
+		require.ErrorIs(t, err, ErrOrganizationOwnerImmutable)
+	})
+
+	t.Run("member cannot manage", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		_, err := newTestOrganizationService(repo).UpdateMember(ctx, 1, 3, 4, UpdateOrganizationMemberRequest{SpendLimitUSD: &limit})
+		require.ErrorIs(t, err, ErrOrganizationForbidden)
+	})
+
+	t.Run("non member sees not found", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		_, err := newTestOrganizationService(repo).UpdateMember(ctx, 1, 99, 3, UpdateOrganizationMemberRequest{SpendLimitUSD: &limit})
+		require.ErrorIs(t, err, ErrOrganizationNotFound)
+	})
+}
+
+func TestOrganizationService_RemoveMember(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("admin removes member and disables their keys", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		require.NoError(t, newTestOrganizationService(repo).RemoveMember(ctx, 1, 2, 3))
+		require.Equal(t, []int64{3}, repo.removed)
+		require.Equal(t, []int64{3}, repo.disabledKeys)
+	})
+
+	t.Run("member can leave", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		require.NoError(t, newTestOrganizationService(repo).RemoveMember(ctx, 1, 4, 4))
+		require.Equal(t, []int64{4}, repo.removed)
+	})
+
+	t.Run("member cannot remove others", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		require.ErrorIs(t, newTestOrganizationService(repo).RemoveMember(ctx, 1, 3, 4), ErrOrganizationForbidden)
+		require.Empty(t, repo.removed)
+	})
+
+	t.Run("owner cannot be removed or leave", func(t *testing.T) {
+		repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+		svc := newTestOrganizationService(repo)
+		require.ErrorIs(t, svc.RemoveMember(ctx, 1, 2, 1), ErrOrganizationForbidden)
+		require.ErrorIs(t, svc.RemoveMember(ctx, 1, 1, 1), ErrOrganizationOwnerImmutable)
+		require.Empty(t, repo.removed)
+	})
+}
+
+func TestOrganizationService_Deposit_RequiresManager(t *testing.T) {
+	ctx := context.Background()
+	repo := newOrganizationRepoStub(defaultOrganizationMembers()...)
+	svc := newTestOrganizationService(repo)
+
+	_, err := svc.Deposit(ctx, 1, 3, 10)
+	require.ErrorIs(t, err, ErrOrganizationForbidden)
+	require.Zero(t, repo.transferCalls)
+
+	org, err := svc.Deposit(ctx, 1, 2, 10)
+	require.NoError(t, err)
+	require.Equal(t, 10.0, org.Balance)
+
+	repo.org.Status = StatusDisabled
+	_, err = svc.Deposit(ctx, 1, 1, 10)
+	require.ErrorIs(t, err, ErrOrganizationDisabled)
+}
+
+func TestCheckOrganizationBillingState(t *testing.T) {
+	limit := 5.0
+
+	require.NoError(t, checkOrganizationBillingState(&OrganizationBillingState{Status: StatusActive, Balance: 1}, true))
+	require.ErrorIs(t, checkOrganizationBillingState(&OrganizationBillingState{Status: StatusDisabled, Balance: 1}, true), ErrOrganizationDisabled)
+	require.ErrorIs(t, checkOrganizationBillingState(&OrganizationBillingState{Status: StatusActive}, true), ErrOrganizationInsufficientBalance)
+	// 订阅模式不检查组织余额
+	require.NoError(t, checkOrganizationBillingState(&OrganizationBillingState{Status: StatusActive}, false))
+	require.NoError(t, checkOrganizationBillingState(&OrganizationBillingState{Status: StatusActive, Balance: 1, SpendLimitUSD: &limit, SpendUsedUSD: 4.99}, true))
+	require.ErrorIs(t, checkOrganizationBillingState(&OrganizationBillingState{Status: StatusActive, Balance: 1, SpendLimitUSD: &limit, SpendUsedUSD: 5}, false), ErrOrganizationMemberSpendLimitExceeded)
+}
+
+func TestSubscriptionUserID_OrganizationKeyUsesOwner(t *testing.T) {
+	orgID := int64(7)
+	user := &User{ID: 3}
+
+	require.Equal(t, int64(3), subscriptionUserID(user, &APIKey{UserID: 3}))
+	require.Equal(t, int64(1), subscriptionUserID(user, &APIKey{UserID: 3, OrganizationID: &orgID, OrganizationOwnerID: 1}))
+}
+
+// orgBillingCacheStub 内存实现组织计费缓存，其余方法沿用 billingCacheWorkerStub
+type orgBillingCacheStub struct {
+	billingCacheWorkerStub
+
+	mu          sync.Mutex
+	states      map[int64]OrganizationBillingState
+	invalidated []int64
+}
+
+func (c *orgBillingCacheStub) GetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string) (*OrganizationBillingState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, ok := c.states[userID]
+	if !ok {
+		return nil, errors.New("cache miss")
+	}
+	return &state, nil
+}
+
+func (c *orgBillingCacheStub) SetOrganizationBillingCache(ctx context.Context, orgID, userID int64, window string, state *OrganizationBillingState) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[userID] = *state
+	return nil
+}
+
+func (c *orgBillingCacheStub) UpdateOrganizationUsage(ctx context.Context, orgID, userID int64, window string, cost float64, deductBalance bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if state, ok := c.states[userID]; ok {
+		state.SpendUsedUSD += cost
+		if deductBalance {
+			state.Balance -= cost
+		}
+		c.states[userID] = state
+	}
+	return nil
+}
+
+func (c *orgBillingCacheStub) InvalidateOrganizationMemberBillingCache(ctx context.Context, orgID, userID int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.states, userID)
+	c.invalidated = append(c.invalidated, userID)
+	return nil
+}
+
+func (c *orgBillingCacheStub) cached(userID int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.states[userID]
+	return ok
+}
+
+func (s *organizationRepoStub) GetBillingState(ctx context.Context, orgID, userID int64, monthStart time.Time) (*OrganizationBillingState, error) {
+	s.billingStateCalls++
+	m, ok := s.members[userID]
+	if !ok {
+		return nil, ErrOrganizationMemberNotFound
+	}
+	return &OrganizationBillingState{Status: s.org.Status, Balance: s.org.Balance, SpendLimitUSD: m.SpendLimitUSD, SpendUsedUSD: m.SpendUsedUSD}, nil
+}
+
+func (s *organizationRepoStub) RecordUsage(ctx context.Context, orgID, userID int64, cost float64, deductBalance bool, monthStart time.Time) error {
+	s.members[userID].SpendUsedUSD += cost
+	if deductBalance {
+		s.org.Balance -= cost
+	}
+	return nil
+}
+
+func TestBillingCacheService_OrganizationStateCached(t *testing.T) {
+	ctx := context.Background()
+	limit := 5.0
+	repo := newOrganizationRepoStub(OrganizationMember{UserID: 3, Role: OrganizationRoleMember, SpendLimitUSD: &limit})
+	repo.org.Balance = 10
+	cache := &orgBillingCacheStub{states: map[int64]OrganizationBillingState{}}
+	billing := NewBillingCacheService(cache, nil, nil, nil, &config.Config{})
+	t.Cleanup(billing.Stop)
+	billing.SetOrganizationRepository(repo)
+
+	orgID := repo.org.ID
+	apiKey := &APIKey{ID: 9, UserID: 3, OrganizationID: &orgID}
+	user := &User{ID: 3}
+
+	require.NoError(t, billing.CheckBillingEligibility(ctx, user, apiKey, nil, nil))
+	require.Eventually(t, func() bool { return cache.cached(3) }, 2*time.Second, 10*time.Millisecond)
+
+	// 命中缓存时不再查询数据库；扣费同步累加缓存中的成员用量
+	require.NoError(t, billing.CheckBillingEligibility(ctx, user, apiKey, nil, nil))
+	require.Equal(t, 1, repo.billingStateCalls)
+	billing.RecordOrganizationUsage(ctx, apiKey, 5, true)
+	require.ErrorIs(t, billing.CheckBillingEligibility(ctx, user, apiKey, nil, nil), ErrOrganizationMemberSpendLimitExceeded)
+	require.Equal(t, 1, repo.billingStateCalls)
+
+	// 调整消费上限后清除成员缓存，下次检查读取最新状态
+	svc := NewOrganizationService(repo, nil, nil, nil, billing)
+	newLimit := 50.0
+	repo.members[1] = &OrganizationMember{OrganizationID: orgID, UserID: 1, Role: OrganizationRoleOwner}
+	_, err := svc.UpdateMember(ctx, orgID, 1, 3, UpdateOrganizationMemberRequest{SpendLimitUSD: &newLimit})
+	require.NoError(t, err)
+	require.Equal(t, []int64{3}, cache.invalidated)
+	repo.members[3].SpendLimitUSD = repo.updatedLimit
+	require.NoError(t, billing.CheckBillingEligibility(ctx, user, apiKey, nil, nil))
+	require.Equal(t, 2, repo.billingStateCalls)
+}
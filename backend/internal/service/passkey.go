@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/webauthn"
+)
+
+var (
+	ErrPasskeyNotEnabled          = infraerrors.BadRequest("PASSKEY_NOT_ENABLED", "passkey feature is not enabled")
+	ErrPasskeyNotFound            = infraerrors.NotFound("PASSKEY_NOT_FOUND", "passkey not found")
+	ErrPasskeyExists              = infraerrors.Conflict("PASSKEY_EXISTS", "passkey is already registered")
+	ErrPasskeyLimitReached        = infraerrors.BadRequest("PASSKEY_LIMIT_REACHED", "too many passkeys registered")
+	ErrPasskeyCeremonyExpired     = infraerrors.BadRequest("PASSKEY_CEREMONY_EXPIRED", "passkey challenge expired, please try again")
+	ErrPasskeyVerificationFailed  = infraerrors.Unauthorized("PASSKEY_VERIFICATION_FAILED", "passkey verification failed")
+	ErrPasskeyRequired            = infraerrors.Forbidden("PASSKEY_REQUIRED", "a passkey is required for this account")
+	ErrPasskeyOAuthLoginForbidden = infraerrors.Forbidden("PASSKEY_OAUTH_LOGIN_FORBIDDEN", "this account requires a passkey; sign in with password or passkey instead")
+	ErrPasskeyEnrollmentRequired  = infraerrors.Forbidden("PASSKEY_ENROLLMENT_REQUIRED", "register a passkey before accessing the admin console")
+	ErrPasskeySettingsInvalid     = infraerrors.BadRequest("PASSKEY_SETTINGS_INVALID", "invalid passkey settings")
+	ErrRecoveryCodeInvalid        = infraerrors.BadRequest("RECOVERY_CODE_INVALID", "invalid recovery code")
+	ErrSecondFactorMethodRequired = infraerrors.BadRequest("SECOND_FACTOR_REQUIRED", "provide exactly one of totp_code, passkey or recovery_code")
+)
+
+const (
+	passkeyCeremonyTTL     = 5 * time.Minute
+	passkeyMaxPerUser      = 20
+	passkeyMaxNameLen      = 100
+	recoveryCodeCount      = 10
+	recoveryCodeByteLength = 5
+)
+
+// PasskeySettings 通行密钥配置（保存在 settings.passkey_settings）
+type PasskeySettings struct {
+	Enabled bool `json:"enabled"`
+	// RPID 依赖方 ID，通常为前端域名（如 example.com），注册后不可随意更改
+	RPID   string `json:"rp_id"`
+	RPName string `json:"rp_name"`
+	// Origins 允许发起 WebAuthn 的前端来源（如 https://example.com）
+	Origins []string `json:"origins"`
+	// UserVerification required / preferred
+	UserVerification string `json:"user_verification"`
+	// RequireForRoles 强制使用通行密钥的角色（如 admin）：已注册则登录必须使用通行密钥或恢复码，
+	// 未注册则无法访问管理后台
+	RequireForRoles []string `json:"require_for_roles"`
+}
+
+// DefaultPasskeySettings 默认配置（未配置 RP 时功能关闭）
+func DefaultPasskeySettings() *PasskeySettings {
+	return &PasskeySettings{
+		RPName:           "Sub2API",
+		Origins:          []string{},
+		UserVerification: webauthn.UserVerificationPreferred,
+		RequireForRoles:  []string{},
+	}
+}
+
+// RequiredForRole 是否对该角色强制通行密钥
+func (s *PasskeySettings) RequiredForRole(role string) bool {
+	return s != nil && s.Enabled && slices.Contains(s.RequireForRoles, role)
+}
+
+func (s *PasskeySettings) relyingParty() *webauthn.RelyingParty {
+	return &webauthn.RelyingParty{ID: s.RPID, Name: s.RPName, Origins: s.Origins}
+}
+
+func (s *PasskeySettings) requireUV() bool {
+	return s.UserVerification == webauthn.UserVerificationRequired
+}
+
+// UserPasskey 用户注册的通行密钥
+type UserPasskey struct {
+	ID             int64      `json:"id"`
+	UserID         int64      `json:"user_id"`
+	Name           string     `json:"name"`
+	CredentialID   string     `json:"credential_id"`
+	PublicKey      []byte     `json:"-"`
+	SignCount      int64      `json:"-"`
+	AAGUID         string     `json:"aaguid"`
+	Transports     []string   `json:"transports"`
+	BackupEligible bool       `json:"backup_eligible"`
+	BackedUp       bool       `json:"backed_up"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+// PasskeyStatus 当前用户的通行密钥状态
+type PasskeyStatus struct {
+	FeatureEnabled         bool          `json:"feature_enabled"`
+	Required               bool          `json:"required"`
+	Passkeys               []UserPasskey `json:"passkeys"`
+	RecoveryCodesRemaining int           `json:"recovery_codes_remaining"`
+}
+
+// PasskeyRegistrationResult 注册结果；首次注册时附带一次性展示的恢复码
+type PasskeyRegistrationResult struct {
+	Passkey       *UserPasskey `json:"passkey"`
+	RecoveryCodes []string     `json:"recovery_codes,omitempty"`
+}
+
+// PasskeyCeremony 进行中的注册/认证挑战
+type PasskeyCeremony struct {
+	Challenge string `json:"challenge"`
+	UserID    int64  `json:"user_id"`
+}
+
+// PasskeyRepository 通行密钥与恢复码存储
+type PasskeyRepository interface {
+	Create(ctx context.Context, passkey *UserPasskey) error
+	GetByCredentialID(ctx context.Context, credentialID string) (*UserPasskey, error)
+	ListByUserID(ctx context.Context, userID int64) ([]UserPasskey, error)
+	CountByUserID(ctx context.Context, userID int64) (int, error)
+	Rename(ctx context.Context, userID, id int64, name string) error
+	Delete(ctx context.Context, userID, id int64) error
+	UpdateUsage(ctx context.Context, id int64, signCount int64, backedUp bool, usedAt time.Time) error
+
+	// ReplaceRecoveryCodes 删除用户全部旧恢复码并写入新的哈希
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	// ConsumeRecoveryCode 将未使用的恢复码标记为已用，返回是否命中
+	ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int, error)
+}
+
+// PasskeyCache 挑战会话缓存（一次性消费）
+type PasskeyCache interface {
+	SetCeremony(ctx context.Context, key string, ceremony *PasskeyCeremony, ttl time.Duration) error
+	// ConsumeCeremony 读取并删除挑战，不存在时返回 nil
+	ConsumeCeremony(ctx context.Context, key string) (*PasskeyCeremony, error)
+}
+
+// normalizeRecoveryCode 去除分隔符与空白并转小写
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,543 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/webauthn"
+)
+
+const (
+	passkeyCeremonyRegisterPrefix = "register:"
+	passkeyCeremonyLoginPrefix    = "login:"
+	passkeyCeremony2FAPrefix      = "2fa:"
+)
+
+// PasskeyService WebAuthn 通行密钥：注册、免密登录、作为第二因素验证以及恢复码
+type PasskeyService struct {
+	repo        PasskeyRepository
+	cache       PasskeyCache
+	settingRepo SettingRepository
+	userRepo    UserRepository
+}
+
+// NewPasskeyService creates a new PasskeyService
+func NewPasskeyService(repo PasskeyRepository, cache PasskeyCache, settingRepo SettingRepository, userRepo UserRepository) *PasskeyService {
+	return &PasskeyService{
+		repo:        repo,
+		cache:       cache,
+		settingRepo: settingRepo,
+		userRepo:    userRepo,
+	}
+}
+
+// GetSettings 获取通行密钥配置
+func (s *PasskeyService) GetSettings(ctx context.Context) (*PasskeySettings, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyPasskeySettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultPasskeySettings(), nil
+		}
+		return nil, fmt.Errorf("get passkey settings: %w", err)
+	}
+	settings := DefaultPasskeySettings()
+	if value == "" {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(value), settings); err != nil {
+		return DefaultPasskeySettings(), nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings 更新通行密钥配置
+func (s *PasskeyService) UpdateSettings(ctx context.Context, settings *PasskeySettings) (*PasskeySettings, error) {
+	if settings == nil {
+		return nil, ErrPasskeySettingsInvalid
+	}
+	if err := normalizePasskeySettings(settings); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("marshal passkey settings: %w", err)
+	}
+	if err := s.settingRepo.Set(ctx, SettingKeyPasskeySettings, string(data)); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(ctx)
+}
+
+func normalizePasskeySettings(settings *PasskeySettings) error {
+	invalid := func(msg string) error {
+		return infraerrors.BadRequest("PASSKEY_SETTINGS_INVALID", msg)
+	}
+	settings.RPID = strings.ToLower(strings.TrimSpace(settings.RPID))
+	settings.RPName = strings.TrimSpace(settings.RPName)
+	if settings.RPName == "" {
+		settings.RPName = DefaultPasskeySettings().RPName
+	}
+	switch settings.UserVerification {
+	case "":
+		settings.UserVerification = webauthn.UserVerificationPreferred
+	case webauthn.UserVerificationPreferred, webauthn.UserVerificationRequired:
+	default:
+		return invalid("user_verification must be preferred or required")
+	}
+
+	origins := make([]string, 0, len(settings.Origins))
+	for _, raw := range settings.Origins {
+		origin := strings.TrimRight(strings.TrimSpace(raw), "/")
+		if origin == "" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" || u.Path != "" || (u.Scheme != "https" && !(u.Scheme == "http" && u.Hostname() == "localhost")) {
+			return invalid("origins must be https origins like https://example.com")
+		}
+		host := u.Hostname()
+		if settings.RPID != "" && host != settings.RPID && !strings.HasSuffix(host, "."+settings.RPID) {
+			return invalid("origin " + origin + " is not within rp_id " + settings.RPID)
+		}
+		origins = append(origins, origin)
+	}
+	settings.Origins = origins
+
+	roles := make([]string, 0, len(settings.RequireForRoles))
+	for _, role := range settings.RequireForRoles {
+		role = strings.TrimSpace(role)
+		if role != RoleAdmin && role != RoleUser {
+			return invalid("require_for_roles only supports admin and user")
+		}
+		roles = append(roles, role)
+	}
+	settings.RequireForRoles = roles
+
+	if settings.Enabled && (settings.RPID == "" || len(settings.Origins) == 0) {
+		return invalid("rp_id and origins are required when passkeys are enabled")
+	}
+	return nil
+}
+
+// enabledSettings 返回已启用的配置，未启用时返回 ErrPasskeyNotEnabled
+func (s *PasskeyService) enabledSettings(ctx context.Context) (*PasskeySettings, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled || settings.RPID == "" || len(settings.Origins) == 0 {
+		return nil, ErrPasskeyNotEnabled
+	}
+	return settings, nil
+}
+
+// IsEnabled 通行密钥功能是否可用
+func (s *PasskeyService) IsEnabled(ctx context.Context) bool {
+	_, err := s.enabledSettings(ctx)
+	return err == nil
+}
+
+// HasPasskeys 用户是否注册了通行密钥（功能关闭时视为没有）
+func (s *PasskeyService) HasPasskeys(ctx context.Context, userID int64) (bool, error) {
+	if !s.IsEnabled(ctx) {
+		return false, nil
+	}
+	count, err := s.repo.CountByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// IsRequiredForUser 用户角色是否被要求使用通行密钥
+func (s *PasskeyService) IsRequiredForUser(ctx context.Context, user *User) bool {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		slog.Warn("passkey_settings_load_failed", "error", err)
+		return false
+	}
+	return user != nil && settings.RequiredForRole(user.Role)
+}
+
+// CheckEnrollment 角色要求通行密钥但用户尚未注册时返回 ErrPasskeyEnrollmentRequired
+func (s *PasskeyService) CheckEnrollment(ctx context.Context, user *User) error {
+	if !s.IsRequiredForUser(ctx, user) {
+		return nil
+	}
+	count, err := s.repo.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrPasskeyEnrollmentRequired
+	}
+	return nil
+}
+
+// GetStatus 获取用户的通行密钥状态
+func (s *PasskeyService) GetStatus(ctx context.Context, userID int64) (*PasskeyStatus, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	passkeys, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := s.repo.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &PasskeyStatus{
+		FeatureEnabled:         s.IsEnabled(ctx),
+		Required:               s.IsRequiredForUser(ctx, user),
+		Passkeys:               passkeys,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// BeginRegistration 生成注册挑战
+func (s *PasskeyService) BeginRegistration(ctx context.Context, userID int64) (*webauthn.CreationOptions, error) {
+	settings, err := s.enabledSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= passkeyMaxPerUser {
+		return nil, ErrPasskeyLimitReached
+	}
+
+	challenge, err := s.startCeremony(ctx, passkeyCeremonyRegisterPrefix+strconv.FormatInt(userID, 10), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &webauthn.CreationOptions{
+		Challenge:          challenge,
+		PubKeyCredParams:   webauthn.SupportedAlgorithms(),
+		Timeout:            webauthn.DefaultTimeoutMillis,
+		ExcludeCredentials: passkeyDescriptors(existing),
+		AuthenticatorSelection: webauthn.AuthenticatorSelection{
+			ResidentKey:        "preferred",
+			RequireResidentKey: false,
+			UserVerification:   settings.UserVerification,
+		},
+		Attestation: "none",
+	}
+	opts.RP.ID = settings.RPID
+	opts.RP.Name = settings.RPName
+	opts.User.ID = webauthn.EncodeBase64URL(passkeyUserHandle(userID))
+	opts.User.Name = user.Email
+	opts.User.DisplayName = firstNonEmptyString(user.Username, user.Email)
+	return opts, nil
+}
+
+// FinishRegistration 校验注册响应并保存凭据；用户尚无恢复码时同时生成一组
+func (s *PasskeyService) FinishRegistration(ctx context.Context, userID int64, name string, resp *webauthn.RegistrationResponse) (*PasskeyRegistrationResult, error) {
+	settings, err := s.enabledSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ceremony, err := s.consumeCeremony(ctx, passkeyCeremonyRegisterPrefix+strconv.FormatInt(userID, 10))
+	if err != nil {
+		return nil, err
+	}
+	cred, err := settings.relyingParty().VerifyRegistration(ceremony.Challenge, resp, settings.requireUV())
+	if err != nil {
+		slog.Info("passkey_registration_failed", "user_id", userID, "error", err)
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey " + time.Now().Format("2006-01-02")
+	}
+	if len([]rune(name)) > passkeyMaxNameLen {
+		name = string([]rune(name)[:passkeyMaxNameLen])
+	}
+	passkey := &UserPasskey{
+		UserID:         userID,
+		Name:           name,
+		CredentialID:   webauthn.EncodeBase64URL(cred.ID),
+		PublicKey:      cred.PublicKey,
+		SignCount:      int64(cred.SignCount),
+		AAGUID:         formatAAGUID(cred.AAGUID),
+		Transports:     cred.Transports,
+		BackupEligible: cred.BackupEligible,
+		BackedUp:       cred.BackedUp,
+	}
+	if passkey.Transports == nil {
+		passkey.Transports = []string{}
+	}
+	if err := s.repo.Create(ctx, passkey); err != nil {
+		return nil, err
+	}
+
+	result := &PasskeyRegistrationResult{Passkey: passkey}
+	remaining, err := s.repo.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if remaining == 0 {
+		codes, err := s.RegenerateRecoveryCodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		result.RecoveryCodes = codes
+	}
+	return result, nil
+}
+
+// ListPasskeys 列出用户的通行密钥
+func (s *PasskeyService) ListPasskeys(ctx context.Context, userID int64) ([]UserPasskey, error) {
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+// RenamePasskey 重命名通行密钥
+func (s *PasskeyService) RenamePasskey(ctx context.Context, userID, id int64, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > passkeyMaxNameLen {
+		return infraerrors.BadRequest("PASSKEY_NAME_INVALID", "name must be 1-100 characters")
+	}
+	return s.repo.Rename(ctx, userID, id, name)
+}
+
+// DeletePasskey 删除通行密钥；角色要求通行密钥时不允许删除最后一个
+func (s *PasskeyService) DeletePasskey(ctx context.Context, userID, id int64) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.IsRequiredForUser(ctx, user) {
+		count, err := s.repo.CountByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if count <= 1 {
+			return ErrPasskeyRequired
+		}
+	}
+	return s.repo.Delete(ctx, userID, id)
+}
+
+// RegenerateRecoveryCodes 生成新的一组恢复码（旧码全部作废），明文仅返回这一次
+func (s *PasskeyService) RegenerateRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeByteLength*2)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		code := hex.EncodeToString(b[:recoveryCodeByteLength]) + "-" + hex.EncodeToString(b[recoveryCodeByteLength:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyRecoveryCode 校验并消费一个恢复码
+func (s *PasskeyService) VerifyRecoveryCode(ctx context.Context, userID int64, code string) error {
+	if normalizeRecoveryCode(code) == "" {
+		return ErrRecoveryCodeInvalid
+	}
+	ok, err := s.repo.ConsumeRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRecoveryCodeInvalid
+	}
+	slog.Info("recovery_code_used", "user_id", userID)
+	return nil
+}
+
+// BeginLogin 生成免密登录挑战（可发现凭据，不限定用户），返回会话令牌与请求参数
+func (s *PasskeyService) BeginLogin(ctx context.Context) (string, *webauthn.RequestOptions, error) {
+	settings, err := s.enabledSettings(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	sessionToken, err := generateRandomToken(32)
+	if err != nil {
+		return "", nil, fmt.Errorf("generate session token: %w", err)
+	}
+	challenge, err := s.startCeremony(ctx, passkeyCeremonyLoginPrefix+sessionToken, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	return sessionToken, &webauthn.RequestOptions{
+		Challenge:        challenge,
+		Timeout:          webauthn.DefaultTimeoutMillis,
+		RPID:             settings.RPID,
+		AllowCredentials: []webauthn.CredentialDescriptor{},
+		UserVerification: settings.UserVerification,
+	}, nil
+}
+
+// FinishLogin 校验免密登录断言并返回对应用户
+func (s *PasskeyService) FinishLogin(ctx context.Context, sessionToken string, resp *webauthn.AssertionResponse) (*User, error) {
+	settings, err := s.enabledSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ceremony, err := s.consumeCeremony(ctx, passkeyCeremonyLoginPrefix+sessionToken)
+	if err != nil {
+		return nil, err
+	}
+	passkey, err := s.verifyAssertion(ctx, settings, ceremony, resp)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, passkey.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, ErrUserNotActive
+	}
+	return user, nil
+}
+
+// BeginSecondFactor 为密码登录后的 2FA 会话生成断言挑战（限定该用户的凭据）
+func (s *PasskeyService) BeginSecondFactor(ctx context.Context, tempToken string, userID int64) (*webauthn.RequestOptions, error) {
+	settings, err := s.enabledSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	passkeys, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(passkeys) == 0 {
+		return nil, ErrPasskeyNotFound
+	}
+	challenge, err := s.startCeremony(ctx, passkeyCeremony2FAPrefix+tempToken, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &webauthn.RequestOptions{
+		Challenge:        challenge,
+		Timeout:          webauthn.DefaultTimeoutMillis,
+		RPID:             settings.RPID,
+		AllowCredentials: passkeyDescriptors(passkeys),
+		UserVerification: settings.UserVerification,
+	}, nil
+}
+
+// VerifySecondFactor 校验 2FA 会话中的通行密钥断言
+func (s *PasskeyService) VerifySecondFactor(ctx context.Context, tempToken string, userID int64, resp *webauthn.AssertionResponse) error {
+	settings, err := s.enabledSettings(ctx)
+	if err != nil {
+		return err
+	}
+	ceremony, err := s.consumeCeremony(ctx, passkeyCeremony2FAPrefix+tempToken)
+	if err != nil {
+		return err
+	}
+	if ceremony.UserID != userID {
+		return ErrPasskeyVerificationFailed
+	}
+	_, err = s.verifyAssertion(ctx, settings, ceremony, resp)
+	return err
+}
+
+// verifyAssertion 查找凭据、校验签名并更新签名计数；ceremony.UserID 非 0 时要求凭据属于该用户
+func (s *PasskeyService) verifyAssertion(ctx context.Context, settings *PasskeySettings, ceremony *PasskeyCeremony, resp *webauthn.AssertionResponse) (*UserPasskey, error) {
+	if resp == nil {
+		return nil, ErrPasskeyVerificationFailed
+	}
+	credID, err := resp.CredentialID()
+	if err != nil || len(credID) == 0 {
+		return nil, ErrPasskeyVerificationFailed
+	}
+	passkey, err := s.repo.GetByCredentialID(ctx, webauthn.EncodeBase64URL(credID))
+	if err != nil {
+		if errors.Is(err, ErrPasskeyNotFound) {
+			return nil, ErrPasskeyVerificationFailed
+		}
+		return nil, err
+	}
+	if ceremony.UserID != 0 && passkey.UserID != ceremony.UserID {
+		return nil, ErrPasskeyVerificationFailed
+	}
+	if resp.Response.UserHandle != "" {
+		handle, err := resp.UserHandleBytes()
+		if err != nil || string(handle) != string(passkeyUserHandle(passkey.UserID)) {
+			return nil, ErrPasskeyVerificationFailed
+		}
+	}
+
+	signCount, err := settings.relyingParty().VerifyAssertion(ceremony.Challenge, resp, passkey.PublicKey, uint32(passkey.SignCount), settings.requireUV())
+	if err != nil {
+		slog.Info("passkey_assertion_failed", "user_id", passkey.UserID, "passkey_id", passkey.ID, "error", err)
+		return nil, ErrPasskeyVerificationFailed
+	}
+	if err := s.repo.UpdateUsage(ctx, passkey.ID, int64(signCount), passkey.BackedUp, time.Now()); err != nil {
+		slog.Warn("passkey_usage_update_failed", "passkey_id", passkey.ID, "error", err)
+	}
+	return passkey, nil
+}
+
+func (s *PasskeyService) startCeremony(ctx context.Context, key string, userID int64) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", fmt.Errorf("generate challenge: %w", err)
+	}
+	if err := s.cache.SetCeremony(ctx, key, &PasskeyCeremony{Challenge: challenge, UserID: userID}, passkeyCeremonyTTL); err != nil {
+		return "", fmt.Errorf("store passkey challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+func (s *PasskeyService) consumeCeremony(ctx context.Context, key string) (*PasskeyCeremony, error) {
+	ceremony, err := s.cache.ConsumeCeremony(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("load passkey challenge: %w", err)
+	}
+	if ceremony == nil {
+		return nil, ErrPasskeyCeremonyExpired
+	}
+	return ceremony, nil
+}
+
+// passkeyUserHandle WebAuthn user.id：不含邮箱等个人信息，使用用户 ID
+func passkeyUserHandle(userID int64) []byte {
+	return []byte(strconv.FormatInt(userID, 10))
+}
+
+func passkeyDescriptors(passkeys []UserPasskey) []webauthn.CredentialDescriptor {
+	out := make([]webauthn.CredentialDescriptor, 0, len(passkeys))
+	for _, p := range passkeys {
+		out = append(out, webauthn.CredentialDescriptor{Type: "public-key", ID: p.CredentialID, Transports: p.Transports})
+	}
+	return out
+}
+
+func formatAAGUID(b []byte) string {
+	if len(b) != 16 {
+		return ""
+	}
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
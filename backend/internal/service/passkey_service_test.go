@@ -0,0 +1,225 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/webauthn"
+	"github.com/stretchr/testify/require"
+)
+
+type passkeyRepoStub struct {
+	passkeys      map[string]*UserPasskey
+	recoveryCodes map[int64]map[string]bool
+}
+
+func newPasskeyRepoStub() *passkeyRepoStub {
+	return &passkeyRepoStub{passkeys: map[string]*UserPasskey{}, recoveryCodes: map[int64]map[string]bool{}}
+}
+
+func (s *passkeyRepoStub) Create(ctx context.Context, passkey *UserPasskey) error {
+	passkey.ID = int64(len(s.passkeys) + 1)
+	s.passkeys[passkey.CredentialID] = passkey
+	return nil
+}
+
+func (s *passkeyRepoStub) GetByCredentialID(ctx context.Context, credentialID string) (*UserPasskey, error) {
+	if p, ok := s.passkeys[credentialID]; ok {
+		return p, nil
+	}
+	return nil, ErrPasskeyNotFound
+}
+
+func (s *passkeyRepoStub) ListByUserID(ctx context.Context, userID int64) ([]UserPasskey, error) {
+	out := []UserPasskey{}
+	for _, p := range s.passkeys {
+		if p.UserID == userID {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (s *passkeyRepoStub) CountByUserID(ctx context.Context, userID int64) (int, error) {
+	list, _ := s.ListByUserID(ctx, userID)
+	return len(list), nil
+}
+
+func (s *passkeyRepoStub) Rename(ctx context.Context, userID, id int64, name string) error {
+	panic("unexpected Rename call")
+}
+
+func (s *passkeyRepoStub) Delete(ctx context.Context, userID, id int64) error {
+	panic("unexpected Delete call")
+}
+
+func (s *passkeyRepoStub) UpdateUsage(ctx context.Context, id int64, signCount int64, backedUp bool, usedAt time.Time) error {
+	return nil
+}
+
+func (s *passkeyRepoStub) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	codes := map[string]bool{}
+	for _, h := range codeHashes {
+		codes[h] = false
+	}
+	s.recoveryCodes[userID] = codes
+	return nil
+}
+
+func (s *passkeyRepoStub) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	used, ok := s.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	s.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (s *passkeyRepoStub) CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	n := 0
+	for _, used := range s.recoveryCodes[userID] {
+		if !used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+type passkeyCacheStub struct {
+	ceremonies map[string]*PasskeyCeremony
+}
+
+func (s *passkeyCacheStub) SetCeremony(ctx context.Context, key string, ceremony *PasskeyCeremony, ttl time.Duration) error {
+	s.ceremonies[key] = ceremony
+	return nil
+}
+
+func (s *passkeyCacheStub) ConsumeCeremony(ctx context.Context, key string) (*PasskeyCeremony, error) {
+	c := s.ceremonies[key]
+	delete(s.ceremonies, key)
+	return c, nil
+}
+
+const passkeyTestSettings = `{"enabled":true,"rp_id":"example.com","origins":["https://example.com"],"require_for_roles":["admin"]}`
+
+func newPasskeyServiceForTest(repo *passkeyRepoStub) (*PasskeyService, *passkeyCacheStub) {
+	cache := &passkeyCacheStub{ceremonies: map[string]*PasskeyCeremony{}}
+	settingRepo := &settingRepoStub{values: map[string]string{SettingKeyPasskeySettings: passkeyTestSettings}}
+	return NewPasskeyService(repo, cache, settingRepo, &userRepoStub{}), cache
+}
+
+func TestNormalizePasskeySettings(t *testing.T) {
+	s := &PasskeySettings{
+		Enabled:         true,
+		RPID:            " Example.COM ",
+		Origins:         []string{"https://example.com/", "https://app.example.com", ""},
+		RequireForRoles: []string{" admin "},
+	}
+	require.NoError(t, normalizePasskeySettings(s))
+	require.Equal(t, "example.com", s.RPID)
+	require.Equal(t, []string{"https://example.com", "https://app.example.com"}, s.Origins)
+	require.Equal(t, []string{RoleAdmin}, s.RequireForRoles)
+	require.Equal(t, webauthn.UserVerificationPreferred, s.UserVerification)
+	require.Equal(t, "Sub2API", s.RPName)
+
+	invalid := []*PasskeySettings{
+		{RPID: "example.com", Origins: []string{"http://example.com"}},
+		{RPID: "example.com", Origins: []string{"https://evil.com"}},
+		{RPID: "example.com", Origins: []string{"https://example.com/login"}},
+		{RequireForRoles: []string{"owner"}},
+		{UserVerification: "discouraged"},
+		{Enabled: true, RPID: "example.com"},
+	}
+	for _, s := range invalid {
+		require.Error(t, normalizePasskeySettings(s), "%+v", s)
+	}
+
+	local := &PasskeySettings{RPID: "localhost", Origins: []string{"http://localhost:5173"}}
+	require.NoError(t, normalizePasskeySettings(local))
+}
+
+func TestPasskeyService_RecoveryCodes(t *testing.T) {
+	repo := newPasskeyRepoStub()
+	svc, _ := newPasskeyServiceForTest(repo)
+	ctx := context.Background()
+
+	codes, err := svc.RegenerateRecoveryCodes(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Regexp(t, `^[0-9a-f]{10}-[0-9a-f]{10}$`, codes[0])
+
+	// 恢复码忽略大小写与分隔符，且只能使用一次
+	require.NoError(t, svc.VerifyRecoveryCode(ctx, 1, " "+codes[0][:10]+codes[0][11:]+" "))
+	require.ErrorIs(t, svc.VerifyRecoveryCode(ctx, 1, codes[0]), ErrRecoveryCodeInvalid)
+	require.ErrorIs(t, svc.VerifyRecoveryCode(ctx, 2, codes[1]), ErrRecoveryCodeInvalid)
+	require.ErrorIs(t, svc.VerifyRecoveryCode(ctx, 1, " - "), ErrRecoveryCodeInvalid)
+
+	remaining, err := repo.CountUnusedRecoveryCodes(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, recoveryCodeCount-1, remaining)
+}
+
+func TestPasskeyService_CheckEnrollment(t *testing.T) {
+	repo := newPasskeyRepoStub()
+	svc, _ := newPasskeyServiceForTest(repo)
+	ctx := context.Background()
+	admin := &User{ID: 7, Role: RoleAdmin}
+
+	require.ErrorIs(t, svc.CheckEnrollment(ctx, admin), ErrPasskeyEnrollmentRequired)
+	require.NoError(t, svc.CheckEnrollment(ctx, &User{ID: 8, Role: RoleUser}))
+
+	require.NoError(t, repo.Create(ctx, &UserPasskey{UserID: 7, CredentialID: "cred"}))
+	require.NoError(t, svc.CheckEnrollment(ctx, admin))
+	has, err := svc.HasPasskeys(ctx, 7)
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
+func TestPasskeyService_SecondFactorCeremonyIsBoundToUser(t *testing.T) {
+	repo := newPasskeyRepoStub()
+	require.NoError(t, repo.Create(context.Background(), &UserPasskey{UserID: 7, CredentialID: "cred"}))
+	svc, cache := newPasskeyServiceForTest(repo)
+	ctx := context.Background()
+
+	opts, err := svc.BeginSecondFactor(ctx, "temp-token", 7)
+	require.NoError(t, err)
+	require.Equal(t, "example.com", opts.RPID)
+	require.Len(t, opts.AllowCredentials, 1)
+
+	// 其他用户不能复用该 2FA 挑战，且挑战被一次性消费
+	err = svc.VerifySecondFactor(ctx, "temp-token", 8, &webauthn.AssertionResponse{})
+	require.ErrorIs(t, err, ErrPasskeyVerificationFailed)
+	require.Empty(t, cache.ceremonies)
+
+	err = svc.VerifySecondFactor(ctx, "temp-token", 7, &webauthn.AssertionResponse{})
+	require.ErrorIs(t, err, ErrPasskeyCeremonyExpired)
+
+	_, err = svc.BeginSecondFactor(ctx, "other-token", 9)
+	require.ErrorIs(t, err, ErrPasskeyNotFound)
+}
+
+type passkeyOAuthUserRepoStub struct {
+	userRepoStub
+	user *User
+}
+
+func (s *passkeyOAuthUserRepoStub) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return s.user, nil
+}
+
+func TestAuthService_OAuthLoginRefusedWhenPasskeyRequired(t *testing.T) {
+	passkeySvc, _ := newPasskeyServiceForTest(newPasskeyRepoStub())
+	repo := &passkeyOAuthUserRepoStub{user: &User{ID: 7, Email: "admin@example.com", Username: "admin", Role: RoleAdmin, Status: StatusActive}}
+	authSvc := NewAuthService(repo, nil, struct{ RefreshTokenCache }{}, &config.Config{}, nil, nil, nil, nil, nil, nil)
+	authSvc.SetPasskeyService(passkeySvc)
+
+	// 第三方 OAuth 无法完成通行密钥校验，要求通行密钥的角色不能借此获得令牌
+	tokenPair, user, err := authSvc.LoginOrRegisterOAuthWithTokenPair(context.Background(), "admin@example.com", "admin")
+	require.ErrorIs(t, err, ErrPasskeyOAuthLoginForbidden)
+	require.Nil(t, tokenPair)
+	require.Nil(t, user)
+}
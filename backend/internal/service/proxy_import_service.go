@@ -0,0 +1,638 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	proxySubscriptionTickInterval  = time.Minute
+	proxySubscriptionLeaderLockKey = "proxy:subscription:leader"
+	proxySubscriptionLeaderLockTTL = 10 * time.Minute
+	proxySubscriptionRunTimeout    = 5 * time.Minute
+	proxySubscriptionSyncTimeout   = time.Minute
+	proxyImportListPageSize        = 1000
+	proxyImportMaxItems            = 5000
+)
+
+var proxySubscriptionReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// ProxySubscriptionFetcher 拉取订阅内容
+type ProxySubscriptionFetcher interface {
+	Fetch(ctx context.Context, url string) (string, error)
+}
+
+// ProxyImportService 从 Clash / URI / CSV 批量导入代理，并定时同步代理订阅。
+//
+// - 以 host + port + username 与现有代理去重；密码、协议、名称变化视为更新。
+// - dry_run 仅返回变更计划，不写入数据库。
+// - 订阅同步会停用从订阅中消失的代理（仅限订阅管理的代理），重新出现时自动启用。
+type ProxyImportService struct {
+	proxyRepo   ProxyRepository
+	subRepo     ProxySubscriptionRepository
+	fetcher     ProxySubscriptionFetcher
+	db          *sql.DB
+	redisClient *redis.Client
+	cfg         *config.Config
+
+	instanceID string
+
+	stopCh    chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+
+	// syncMu 串行化导入/同步，避免并发写入产生重复代理
+	syncMu sync.Mutex
+
+	warnNoRedisOnce sync.Once
+}
+
+// NewProxyImportService creates a new ProxyImportService.
+func NewProxyImportService(
+	proxyRepo ProxyRepository,
+	subRepo ProxySubscriptionRepository,
+	fetcher ProxySubscriptionFetcher,
+	db *sql.DB,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *ProxyImportService {
+	return &ProxyImportService{
+		proxyRepo:   proxyRepo,
+		subRepo:     subRepo,
+		fetcher:     fetcher,
+		db:          db,
+		redisClient: redisClient,
+		cfg:         cfg,
+		instanceID:  uuid.NewString(),
+		stopCh:      make(chan struct{}),
+	}
+}
+
+func (s *ProxyImportService) Start() {
+	if s == nil || s.subRepo == nil || s.fetcher == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ticker := time.NewTicker(proxySubscriptionTickInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					s.runScheduled()
+				case <-s.stopCh:
+					return
+				}
+			}
+		}()
+	})
+}
+
+func (s *ProxyImportService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+// Import 解析并导入代理列表；DryRun 时仅返回预览。
+func (s *ProxyImportService) Import(ctx context.Context, input *ProxyImportInput) (*ProxyImportResult, error) {
+	items, format, parseErrs := ParseProxyImport(input.Content, input.Format, input.DefaultProtocol)
+	if len(items) > proxyImportMaxItems {
+		return nil, infraerrors.BadRequest("PROXY_IMPORT_TOO_LARGE", fmt.Sprintf("at most %d proxies per import", proxyImportMaxItems))
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	result, _, err := s.apply(ctx, items, nil, input.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	result.Format = format
+	result.Failed += len(parseErrs)
+	result.Errors = append(parseErrs, result.Errors...)
+	return result, nil
+}
+
+// ListSubscriptions 获取代理订阅列表
+func (s *ProxyImportService) ListSubscriptions(ctx context.Context) ([]ProxySubscription, error) {
+	return s.subRepo.List(ctx)
+}
+
+// GetSubscription 获取代理订阅详情
+func (s *ProxyImportService) GetSubscription(ctx context.Context, id int64) (*ProxySubscription, error) {
+	return s.subRepo.GetByID(ctx, id)
+}
+
+// CreateSubscription 创建代理订阅
+func (s *ProxyImportService) CreateSubscription(ctx context.Context, input *CreateProxySubscriptionInput) (*ProxySubscription, error) {
+	subURL, err := s.validateSubscriptionURL(input.URL)
+	if err != nil {
+		return nil, err
+	}
+	sub := &ProxySubscription{
+		Name:             strings.TrimSpace(input.Name),
+		URL:              subURL,
+		Format:           normalizeProxyImportFormat(input.Format),
+		DefaultProtocol:  input.DefaultProtocol,
+		IntervalMinutes:  input.IntervalMinutes,
+		Status:           StatusActive,
+		ProxyIDs:         []int64{},
+		DisabledProxyIDs: []int64{},
+	}
+	if sub.DefaultProtocol == "" {
+		sub.DefaultProtocol = "http"
+	}
+	if err := s.subRepo.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// UpdateSubscription 更新代理订阅
+func (s *ProxyImportService) UpdateSubscription(ctx context.Context, id int64, input *UpdateProxySubscriptionInput) (*ProxySubscription, error) {
+	sub, err := s.subRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil {
+		sub.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.URL != nil {
+		subURL, err := s.validateSubscriptionURL(*input.URL)
+		if err != nil {
+			return nil, err
+		}
+		sub.URL = subURL
+	}
+	if input.Format != nil {
+		sub.Format = normalizeProxyImportFormat(*input.Format)
+	}
+	if input.DefaultProtocol != nil && *input.DefaultProtocol != "" {
+		sub.DefaultProtocol = *input.DefaultProtocol
+	}
+	if input.IntervalMinutes != nil {
+		sub.IntervalMinutes = *input.IntervalMinutes
+	}
+	if input.Status != nil {
+		sub.Status = *input.Status
+	}
+	if err := s.subRepo.Update(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// DeleteSubscription 删除代理订阅（已导入的代理保留）
+func (s *ProxyImportService) DeleteSubscription(ctx context.Context, id int64) error {
+	return s.subRepo.Delete(ctx, id)
+}
+
+// SyncSubscription 立即拉取订阅并同步代理；dryRun 时仅返回预览。
+func (s *ProxyImportService) SyncSubscription(ctx context.Context, id int64, dryRun bool) (*ProxyImportResult, error) {
+	sub, err := s.subRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.syncSubscription(ctx, sub, dryRun)
+}
+
+func (s *ProxyImportService) syncSubscription(ctx context.Context, sub *ProxySubscription, dryRun bool) (*ProxyImportResult, error) {
+	result, err := s.fetchAndApply(ctx, sub, dryRun)
+	if dryRun {
+		return result, err
+	}
+
+	now := time.Now()
+	sub.LastSyncedAt = &now
+	sub.LastError = ""
+	if err != nil {
+		sub.LastError = truncateString(err.Error(), 1024)
+	}
+	if updateErr := s.subRepo.UpdateSyncResult(ctx, sub); updateErr != nil {
+		log.Printf("[ProxySubscription] update sync result failed: subscription=%d err=%v", sub.ID, updateErr)
+	}
+	return result, err
+}
+
+func (s *ProxyImportService) fetchAndApply(ctx context.Context, sub *ProxySubscription, dryRun bool) (*ProxyImportResult, error) {
+	if s.fetcher == nil {
+		return nil, errors.New("subscription fetcher is not available")
+	}
+	subURL, err := s.validateSubscriptionURL(sub.URL)
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.fetcher.Fetch(ctx, subURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch subscription: %w", err)
+	}
+	items, format, parseErrs := ParseProxyImport(content, sub.Format, sub.DefaultProtocol)
+	// 订阅为空通常意味着供应商异常，此时不停用任何代理
+	if len(items) == 0 {
+		return nil, infraerrors.BadRequest("PROXY_SUBSCRIPTION_EMPTY", "subscription returned no usable proxies")
+	}
+	if len(items) > proxyImportMaxItems {
+		return nil, infraerrors.BadRequest("PROXY_IMPORT_TOO_LARGE", fmt.Sprintf("at most %d proxies per subscription", proxyImportMaxItems))
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	result, owned, err := s.apply(ctx, items, sub, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	result.Format = format
+	result.Failed += len(parseErrs)
+	result.Errors = append(parseErrs, result.Errors...)
+	if !dryRun {
+		sub.ProxyIDs = owned.proxyIDs
+		sub.DisabledProxyIDs = owned.disabledIDs
+	}
+	return result, nil
+}
+
+type proxySubscriptionOwnership struct {
+	proxyIDs    []int64
+	disabledIDs []int64
+}
+
+// apply 计算变更计划并在非 dryRun 时写入；sub 非空时处理订阅管理代理的停用/启用。
+func (s *ProxyImportService) apply(ctx context.Context, items []ProxyImportItem, sub *ProxySubscription, dryRun bool) (*ProxyImportResult, *proxySubscriptionOwnership, error) {
+	existing, err := s.listAllProxies(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan := planProxyImport(existing, items, sub)
+
+	result := &ProxyImportResult{
+		DryRun:  dryRun,
+		Changes: make([]ProxyImportChange, 0, len(plan)),
+		Errors:  []ProxyImportError{},
+	}
+	owned := &proxySubscriptionOwnership{}
+	ownedSet := map[int64]struct{}{}
+	disabledSet := map[int64]struct{}{}
+	if sub != nil {
+		for _, id := range sub.DisabledProxyIDs {
+			disabledSet[id] = struct{}{}
+		}
+	}
+
+	for _, step := range plan {
+		change := step.change
+		if !dryRun {
+			proxyID, err := s.applyStep(ctx, step)
+			if err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, ProxyImportError{
+					Source:  fmt.Sprintf("%s:%d", change.Host, change.Port),
+					Message: fmt.Sprintf("%s failed: %v", change.Action, err),
+				})
+				if step.proxy != nil {
+					ownedSet[step.proxy.ID] = struct{}{}
+				}
+				continue
+			}
+			change.ProxyID = proxyID
+		}
+		if change.ProxyID > 0 {
+			ownedSet[change.ProxyID] = struct{}{}
+		}
+
+		switch change.Action {
+		case ProxyImportActionCreate:
+			result.Created++
+		case ProxyImportActionUpdate:
+			result.Updated++
+		case ProxyImportActionUnchanged:
+			result.Unchanged++
+		case ProxyImportActionEnable:
+			result.Enabled++
+			delete(disabledSet, change.ProxyID)
+		case ProxyImportActionDisable:
+			result.Disabled++
+			disabledSet[change.ProxyID] = struct{}{}
+		}
+		if change.Action != ProxyImportActionUnchanged {
+			result.Changes = append(result.Changes, change)
+		}
+	}
+
+	for id := range ownedSet {
+		owned.proxyIDs = append(owned.proxyIDs, id)
+	}
+	for id := range disabledSet {
+		if _, ok := ownedSet[id]; ok {
+			owned.disabledIDs = append(owned.disabledIDs, id)
+		}
+	}
+	slices.Sort(owned.proxyIDs)
+	slices.Sort(owned.disabledIDs)
+	if owned.proxyIDs == nil {
+		owned.proxyIDs = []int64{}
+	}
+	if owned.disabledIDs == nil {
+		owned.disabledIDs = []int64{}
+	}
+	return result, owned, nil
+}
+
+func (s *ProxyImportService) applyStep(ctx context.Context, step proxyImportStep) (int64, error) {
+	switch step.change.Action {
+	case ProxyImportActionCreate:
+		name := step.item.Name
+		if name == "" {
+			name = "default"
+		}
+		proxy := &Proxy{
+			Name:     name,
+			Protocol: step.item.Protocol,
+			Host:     step.item.Host,
+			Port:     step.item.Port,
+			Username: step.item.Username,
+			Password: step.item.Password,
+			Status:   StatusActive,
+		}
+		if err := s.proxyRepo.Create(ctx, proxy); err != nil {
+			return 0, err
+		}
+		return proxy.ID, nil
+	case ProxyImportActionUpdate, ProxyImportActionEnable, ProxyImportActionDisable:
+		updated := *step.proxy
+		if step.item != nil {
+			updated.Protocol = step.item.Protocol
+			updated.Password = step.item.Password
+			if step.item.Name != "" {
+				updated.Name = step.item.Name
+			}
+		}
+		switch step.change.Action {
+		case ProxyImportActionEnable:
+			updated.Status = StatusActive
+		case ProxyImportActionDisable:
+			updated.Status = proxyStatusInactive
+		}
+		if err := s.proxyRepo.Update(ctx, &updated); err != nil {
+			return 0, err
+		}
+		return updated.ID, nil
+	default:
+		if step.proxy != nil {
+			return step.proxy.ID, nil
+		}
+		return 0, nil
+	}
+}
+
+type proxyImportStep struct {
+	change ProxyImportChange
+	item   *ProxyImportItem
+	proxy  *Proxy
+}
+
+// planProxyImport 根据现有代理计算导入计划（纯函数，便于预览与测试）。
+func planProxyImport(existing []Proxy, items []ProxyImportItem, sub *ProxySubscription) []proxyImportStep {
+	byKey := make(map[string]*Proxy, len(existing))
+	byID := make(map[int64]*Proxy, len(existing))
+	for i := range existing {
+		p := &existing[i]
+		byID[p.ID] = p
+		key := proxyImportKey(p.Host, p.Port, p.Username)
+		if _, ok := byKey[key]; !ok {
+			byKey[key] = p
+		}
+	}
+	disabledBySub := map[int64]bool{}
+	if sub != nil {
+		for _, id := range sub.DisabledProxyIDs {
+			disabledBySub[id] = true
+		}
+	}
+
+	steps := make([]proxyImportStep, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	matched := make(map[int64]struct{}, len(items))
+	for i := range items {
+		item := &items[i]
+		key := item.key()
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		change := ProxyImportChange{
+			Name:     item.Name,
+			Protocol: item.Protocol,
+			Host:     item.Host,
+			Port:     item.Port,
+			Username: item.Username,
+		}
+		proxy, ok := byKey[key]
+		if !ok {
+			change.Action = ProxyImportActionCreate
+			steps = append(steps, proxyImportStep{change: change, item: item})
+			continue
+		}
+		matched[proxy.ID] = struct{}{}
+		change.ProxyID = proxy.ID
+
+		if proxy.Protocol != item.Protocol {
+			change.Fields = append(change.Fields, "protocol")
+		}
+		if proxy.Password != item.Password {
+			change.Fields = append(change.Fields, "password")
+		}
+		if item.Name != "" && proxy.Name != item.Name {
+			change.Fields = append(change.Fields, "name")
+		}
+		switch {
+		case disabledBySub[proxy.ID] && !proxy.IsActive():
+			change.Action = ProxyImportActionEnable
+		case len(change.Fields) > 0:
+			change.Action = ProxyImportActionUpdate
+		default:
+			change.Action = ProxyImportActionUnchanged
+		}
+		steps = append(steps, proxyImportStep{change: change, item: item, proxy: proxy})
+	}
+
+	if sub == nil {
+		return steps
+	}
+	// 订阅管理但本次未出现的代理：停用（已停用的不重复处理）
+	for _, id := range sub.ProxyIDs {
+		proxy, ok := byID[id]
+		if !ok {
+			continue
+		}
+		if _, ok := matched[id]; ok {
+			continue
+		}
+		change := ProxyImportChange{
+			ProxyID:  proxy.ID,
+			Name:     proxy.Name,
+			Protocol: proxy.Protocol,
+			Host:     proxy.Host,
+			Port:     proxy.Port,
+			Username: proxy.Username,
+			Action:   ProxyImportActionUnchanged,
+		}
+		if proxy.IsActive() {
+			change.Action = ProxyImportActionDisable
+		}
+		steps = append(steps, proxyImportStep{change: change, proxy: proxy})
+	}
+	return steps
+}
+
+func (s *ProxyImportService) listAllProxies(ctx context.Context) ([]Proxy, error) {
+	var out []Proxy
+	for page := 1; ; page++ {
+		items, result, err := s.proxyRepo.ListWithFilters(ctx, pagination.PaginationParams{Page: page, PageSize: proxyImportListPageSize}, "", "", "")
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+		if len(items) == 0 || result == nil || int64(len(out)) >= result.Total {
+			break
+		}
+	}
+	return out, nil
+}
+
+// validateSubscriptionURL 校验订阅地址（与 CRS 同步一致，受 URL 白名单配置约束）
+func (s *ProxyImportService) validateSubscriptionURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	invalid := func(err error) error {
+		return infraerrors.BadRequest("INVALID_SUBSCRIPTION_URL", "invalid subscription url: "+err.Error())
+	}
+	if s.cfg != nil && s.cfg.Security.URLAllowlist.Enabled {
+		normalized, err := urlvalidator.ValidateHTTPSURL(raw, urlvalidator.ValidationOptions{
+			AllowPrivate: s.cfg.Security.URLAllowlist.AllowPrivateHosts,
+		})
+		if err != nil {
+			return "", invalid(err)
+		}
+		return normalized, nil
+	}
+	allowInsecure := s.cfg != nil && s.cfg.Security.URLAllowlist.AllowInsecureHTTP
+	normalized, err := urlvalidator.ValidateURLFormat(raw, allowInsecure)
+	if err != nil {
+		return "", invalid(err)
+	}
+	return normalized, nil
+}
+
+func (s *ProxyImportService) runScheduled() {
+	ctx, cancel := context.WithTimeout(context.Background(), proxySubscriptionRunTimeout)
+	defer cancel()
+
+	subs, err := s.subRepo.List(ctx)
+	if err != nil {
+		log.Printf("[ProxySubscription] list subscriptions failed: %v", err)
+		return
+	}
+	now := time.Now()
+	due := make([]ProxySubscription, 0, len(subs))
+	for _, sub := range subs {
+		if isProxySubscriptionDue(&sub, now) {
+			due = append(due, sub)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	release, ok := s.tryAcquireLeaderLock(ctx)
+	if !ok {
+		return
+	}
+	if release != nil {
+		defer release()
+	}
+
+	for i := range due {
+		select {
+		case <-s.stopCh:
+			return
+		default:
+		}
+		sub := &due[i]
+		syncCtx, cancelSync := context.WithTimeout(ctx, proxySubscriptionSyncTimeout)
+		result, err := s.syncSubscription(syncCtx, sub, false)
+		cancelSync()
+		if err != nil {
+			log.Printf("[ProxySubscription] sync failed: subscription=%d name=%s err=%v", sub.ID, sub.Name, err)
+			continue
+		}
+		log.Printf("[ProxySubscription] synced subscription=%d name=%s created=%d updated=%d enabled=%d disabled=%d failed=%d",
+			sub.ID, sub.Name, result.Created, result.Updated, result.Enabled, result.Disabled, result.Failed)
+	}
+}
+
+func isProxySubscriptionDue(sub *ProxySubscription, now time.Time) bool {
+	if sub == nil || !sub.IsActive() || sub.IntervalMinutes <= 0 {
+		return false
+	}
+	if sub.LastSyncedAt == nil {
+		return true
+	}
+	return now.Sub(*sub.LastSyncedAt) >= time.Duration(sub.IntervalMinutes)*time.Minute
+}
+
+func (s *ProxyImportService) tryAcquireLeaderLock(ctx context.Context) (func(), bool) {
+	// In simple run mode, assume single instance.
+	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
+		return nil, true
+	}
+
+	key := proxySubscriptionLeaderLockKey
+	if s.redisClient != nil {
+		ok, err := s.redisClient.SetNX(ctx, key, s.instanceID, proxySubscriptionLeaderLockTTL).Result()
+		if err == nil {
+			if !ok {
+				return nil, false
+			}
+			return func() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				defer cancel()
+				_, _ = proxySubscriptionReleaseScript.Run(releaseCtx, s.redisClient, []string{key}, s.instanceID).Result()
+			}, true
+		}
+		// Redis error: fall back to DB advisory lock.
+		s.warnNoRedisOnce.Do(func() {
+			log.Printf("[ProxySubscription] leader lock SetNX failed; falling back to DB advisory lock: %v", err)
+		})
+	}
+
+	return tryAcquireDBAdvisoryLock(ctx, s.db, hashAdvisoryLockID(key))
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// ProxyHealthCheck 单次代理巡检结果
+type ProxyHealthCheck struct {
+	ID           int64  `json:"id"`
+	ProxyID      int64  `json:"proxy_id"`
+	Success      bool   `json:"success"`
+	LatencyMs    int64  `json:"latency_ms"`
+	IPAddress    string `json:"ip_address,omitempty"`
+	Country      string `json:"country,omitempty"`
+	CountryCode  string `json:"country_code,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+	// ExitIPChanged / CountryChanged 与上一次成功巡检相比出口是否变化（OAuth 账号换出口可能触发风控）
+	ExitIPChanged  bool      `json:"exit_ip_changed"`
+	CountryChanged bool      `json:"country_changed"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ProxyHealthState 代理健康状态（连续计数 + 最近出口信息 + 监控器执行过的自动下线）
+type ProxyHealthState struct {
+	ProxyID              int64      `json:"proxy_id"`
+	ConsecutiveFailures  int        `json:"consecutive_failures"`
+	ConsecutiveSuccesses int        `json:"consecutive_successes"`
+	LastCheckAt          *time.Time `json:"last_check_at,omitempty"`
+	LastSuccess          bool       `json:"last_success"`
+	LastLatencyMs        int64      `json:"last_latency_ms"`
+	LastError            string     `json:"last_error,omitempty"`
+	LastExitIP           string     `json:"last_exit_ip,omitempty"`
+	LastCountryCode      string     `json:"last_country_code,omitempty"`
+	ExitChangedAt        *time.Time `json:"exit_changed_at,omitempty"`
+	// Down 连续失败达到阈值后为 true，连续成功达到阈值后恢复
+	Down      bool       `json:"down"`
+	DownSince *time.Time `json:"down_since,omitempty"`
+	// AutoDisabled 监控器是否将代理状态置为 inactive；自动恢复只撤销这里记录的修改
+	AutoDisabled bool `json:"auto_disabled"`
+}
+
+// ProxyAffectedAccount 受代理状态变化影响的账号（直接绑定或通过代理池引用）
+type ProxyAffectedAccount struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Platform    string `json:"platform"`
+	Type        string `json:"type"`
+	ProxyPoolID *int64 `json:"proxy_pool_id,omitempty"`
+}
+
+// ProxyHealthReport 代理健康详情（管理端展示）
+type ProxyHealthReport struct {
+	State            *ProxyHealthState      `json:"state"`
+	Checks           []ProxyHealthCheck     `json:"checks"`
+	AffectedAccounts []ProxyAffectedAccount `json:"affected_accounts"`
+}
+
+// ProxyHealthRepository 代理巡检结果/状态存储
+type ProxyHealthRepository interface {
+	InsertCheck(ctx context.Context, check *ProxyHealthCheck) error
+	ListChecks(ctx context.Context, proxyID int64, limit int) ([]ProxyHealthCheck, error)
+	// GetState 返回代理健康状态；不存在时返回 (nil, nil)。
+	GetState(ctx context.Context, proxyID int64) (*ProxyHealthState, error)
+	ListStates(ctx context.Context) ([]ProxyHealthState, error)
+	UpsertState(ctx context.Context, state *ProxyHealthState) error
+	DeleteChecksBefore(ctx context.Context, cutoff time.Time) (int64, error)
+
+	// CountDown 统计当前判定为下线的代理数量
+	CountDown(ctx context.Context) (int64, error)
+	// CountExitChanges 统计时间窗口内检测到出口 IP/国家变化的次数
+	CountExitChanges(ctx context.Context, start, end time.Time) (int64, error)
+	// ListAffectedAccounts 列出直接使用该代理或引用包含该代理的代理池的账号
+	ListAffectedAccounts(ctx context.Context, proxyID int64) ([]ProxyAffectedAccount, error)
+}
+
+// ProxyMonitorSettings 代理健康监控配置（DB 存储）
+type ProxyMonitorSettings struct {
+	Enabled bool `json:"enabled"`
+	// IntervalSeconds 巡检间隔（秒）
+	IntervalSeconds int `json:"interval_seconds"`
+	// TimeoutSeconds 单次巡检超时（秒）
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// Concurrency 同时进行的巡检数量
+	Concurrency int `json:"concurrency"`
+	// FailureThreshold 连续失败多少次后判定代理下线
+	FailureThreshold int `json:"failure_threshold"`
+	// RecoveryThreshold 连续成功多少次后判定代理恢复
+	RecoveryThreshold int `json:"recovery_threshold"`
+	// AutoDisable 下线时自动将代理置为 inactive（代理池不再选择），恢复时自动启用
+	AutoDisable bool `json:"auto_disable"`
+	// HistoryRetentionDays 巡检历史保留天数
+	HistoryRetentionDays int `json:"history_retention_days"`
+}
+
+// DefaultProxyMonitorSettings 返回默认的代理监控配置
+func DefaultProxyMonitorSettings() *ProxyMonitorSettings {
+	return &ProxyMonitorSettings{
+		Enabled:              false,
+		IntervalSeconds:      300,
+		TimeoutSeconds:       15,
+		Concurrency:          4,
+		FailureThreshold:     3,
+		RecoveryThreshold:    2,
+		AutoDisable:          true,
+		HistoryRetentionDays: 7,
+	}
+}
@@ -0,0 +1,609 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	proxyMonitorJobName = "proxy_monitor"
+
+	proxyMonitorTickInterval     = 30 * time.Second
+	proxyMonitorLeaderLockKey    = "proxy:monitor:leader"
+	proxyMonitorLeaderLockTTL    = 10 * time.Minute
+	proxyMonitorRunTimeout       = 9 * time.Minute
+	proxyMonitorErrorMaxLen      = 1024
+	proxyMonitorChecksMaxLimit   = 500
+	proxyMonitorPruneMinInterval = time.Hour
+
+	// proxyStatusInactive 代理停用状态（管理端使用 active / inactive）
+	proxyStatusInactive = "inactive"
+)
+
+var proxyMonitorReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+var ErrProxyMonitorSettingsInvalid = infraerrors.BadRequest("PROXY_MONITOR_SETTINGS_INVALID", "invalid proxy monitor settings")
+
+// ProxyMonitorService 定时巡检代理的连通性与出口信息。
+//
+// - 巡检复用代理测试的探测器（ProxyExitInfoProber），结果同时写入延迟缓存，供代理列表与代理池选择使用。
+// - 连续失败达到阈值后判定代理下线（可选自动置为 inactive）；连续成功后自动恢复。
+// - 出口 IP / 国家变化会被记录并告警，便于及时处理可能被风控的 OAuth 账号。
+// - 多实例：Redis leader lock（失败时回退到 DB advisory lock），保证同一时刻仅一个实例巡检。
+type ProxyMonitorService struct {
+	healthRepo   ProxyHealthRepository
+	proxyRepo    ProxyRepository
+	settingRepo  SettingRepository
+	prober       ProxyExitInfoProber
+	latencyCache ProxyLatencyCache
+	opsRepo      OpsRepository
+	db           *sql.DB
+	redisClient  *redis.Client
+	cfg          *config.Config
+
+	instanceID string
+
+	stopCh    chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+
+	warnNoRedisOnce sync.Once
+	skipLogMu       sync.Mutex
+	skipLogAt       time.Time
+	lastPruneAt     time.Time
+}
+
+// NewProxyMonitorService creates a new ProxyMonitorService.
+func NewProxyMonitorService(
+	healthRepo ProxyHealthRepository,
+	proxyRepo ProxyRepository,
+	settingRepo SettingRepository,
+	prober ProxyExitInfoProber,
+	latencyCache ProxyLatencyCache,
+	opsRepo OpsRepository,
+	db *sql.DB,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *ProxyMonitorService {
+	return &ProxyMonitorService{
+		healthRepo:   healthRepo,
+		proxyRepo:    proxyRepo,
+		settingRepo:  settingRepo,
+		prober:       prober,
+		latencyCache: latencyCache,
+		opsRepo:      opsRepo,
+		db:           db,
+		redisClient:  redisClient,
+		cfg:          cfg,
+		instanceID:   uuid.NewString(),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+func (s *ProxyMonitorService) Start() {
+	if s == nil || s.healthRepo == nil || s.proxyRepo == nil || s.prober == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ticker := time.NewTicker(proxyMonitorTickInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					s.runScheduled()
+				case <-s.stopCh:
+					return
+				}
+			}
+		}()
+	})
+}
+
+func (s *ProxyMonitorService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+// GetSettings 获取代理监控配置
+func (s *ProxyMonitorService) GetSettings(ctx context.Context) (*ProxyMonitorSettings, error) {
+	if s.settingRepo == nil {
+		return DefaultProxyMonitorSettings(), nil
+	}
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyProxyMonitorSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultProxyMonitorSettings(), nil
+		}
+		return nil, fmt.Errorf("get proxy monitor settings: %w", err)
+	}
+	if value == "" {
+		return DefaultProxyMonitorSettings(), nil
+	}
+
+	settings := DefaultProxyMonitorSettings()
+	if err := json.Unmarshal([]byte(value), settings); err != nil {
+		return DefaultProxyMonitorSettings(), nil
+	}
+	normalizeProxyMonitorSettings(settings)
+	return settings, nil
+}
+
+// UpdateSettings 更新代理监控配置
+func (s *ProxyMonitorService) UpdateSettings(ctx context.Context, settings *ProxyMonitorSettings) (*ProxyMonitorSettings, error) {
+	if settings == nil {
+		return nil, ErrProxyMonitorSettingsInvalid
+	}
+	if err := validateProxyMonitorSettings(settings); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("marshal proxy monitor settings: %w", err)
+	}
+	if err := s.settingRepo.Set(ctx, SettingKeyProxyMonitorSettings, string(data)); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(ctx)
+}
+
+func validateProxyMonitorSettings(settings *ProxyMonitorSettings) error {
+	invalid := func(msg string) error {
+		return infraerrors.BadRequest("PROXY_MONITOR_SETTINGS_INVALID", msg)
+	}
+	if settings.IntervalSeconds < 60 || settings.IntervalSeconds > 86400 {
+		return invalid("interval_seconds must be between 60-86400")
+	}
+	if settings.TimeoutSeconds < 5 || settings.TimeoutSeconds > 60 {
+		return invalid("timeout_seconds must be between 5-60")
+	}
+	if settings.Concurrency < 1 || settings.Concurrency > 32 {
+		return invalid("concurrency must be between 1-32")
+	}
+	if settings.FailureThreshold < 1 || settings.FailureThreshold > 20 {
+		return invalid("failure_threshold must be between 1-20")
+	}
+	if settings.RecoveryThreshold < 1 || settings.RecoveryThreshold > 20 {
+		return invalid("recovery_threshold must be between 1-20")
+	}
+	if settings.HistoryRetentionDays < 1 || settings.HistoryRetentionDays > 90 {
+		return invalid("history_retention_days must be between 1-90")
+	}
+	return nil
+}
+
+func normalizeProxyMonitorSettings(settings *ProxyMonitorSettings) {
+	defaults := DefaultProxyMonitorSettings()
+	if settings.IntervalSeconds < 60 {
+		settings.IntervalSeconds = defaults.IntervalSeconds
+	}
+	if settings.TimeoutSeconds < 5 || settings.TimeoutSeconds > 60 {
+		settings.TimeoutSeconds = defaults.TimeoutSeconds
+	}
+	if settings.Concurrency < 1 || settings.Concurrency > 32 {
+		settings.Concurrency = defaults.Concurrency
+	}
+	if settings.FailureThreshold < 1 {
+		settings.FailureThreshold = defaults.FailureThreshold
+	}
+	if settings.RecoveryThreshold < 1 {
+		settings.RecoveryThreshold = defaults.RecoveryThreshold
+	}
+	if settings.HistoryRetentionDays < 1 {
+		settings.HistoryRetentionDays = defaults.HistoryRetentionDays
+	}
+}
+
+// ListStates 列出所有代理的健康状态
+func (s *ProxyMonitorService) ListStates(ctx context.Context) ([]ProxyHealthState, error) {
+	return s.healthRepo.ListStates(ctx)
+}
+
+// GetReport 查询代理的健康状态、巡检历史与受影响账号
+func (s *ProxyMonitorService) GetReport(ctx context.Context, proxyID int64, limit int) (*ProxyHealthReport, error) {
+	if _, err := s.proxyRepo.GetByID(ctx, proxyID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > proxyMonitorChecksMaxLimit {
+		limit = proxyMonitorChecksMaxLimit
+	}
+	state, err := s.healthRepo.GetState(ctx, proxyID)
+	if err != nil {
+		return nil, err
+	}
+	checks, err := s.healthRepo.ListChecks(ctx, proxyID, limit)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := s.healthRepo.ListAffectedAccounts(ctx, proxyID)
+	if err != nil {
+		return nil, err
+	}
+	return &ProxyHealthReport{State: state, Checks: checks, AffectedAccounts: affected}, nil
+}
+
+func (s *ProxyMonitorService) runScheduled() {
+	ctx, cancel := context.WithTimeout(context.Background(), proxyMonitorRunTimeout)
+	defer cancel()
+
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		log.Printf("[ProxyMonitor] load settings failed: %v", err)
+		return
+	}
+	if !settings.Enabled {
+		return
+	}
+
+	release, ok := s.tryAcquireLeaderLock(ctx)
+	if !ok {
+		return
+	}
+	if release != nil {
+		defer release()
+	}
+
+	startedAt := time.Now().UTC()
+	checked, failed, err := s.runOnce(ctx, settings, startedAt)
+	if err != nil {
+		s.recordHeartbeatError(startedAt, time.Since(startedAt), err)
+		log.Printf("[ProxyMonitor] run failed: %v", err)
+		return
+	}
+	if checked > 0 {
+		s.recordHeartbeatSuccess(startedAt, time.Since(startedAt), fmt.Sprintf("checked=%d failed=%d", checked, failed))
+	}
+
+	if time.Since(s.lastPruneAt) >= proxyMonitorPruneMinInterval {
+		s.lastPruneAt = time.Now()
+		cutoff := time.Now().AddDate(0, 0, -settings.HistoryRetentionDays)
+		if n, err := s.healthRepo.DeleteChecksBefore(ctx, cutoff); err != nil {
+			log.Printf("[ProxyMonitor] prune history failed: %v", err)
+		} else if n > 0 {
+			log.Printf("[ProxyMonitor] pruned %d proxy checks older than %s", n, cutoff.Format(time.RFC3339))
+		}
+	}
+}
+
+// runOnce 执行一轮到期代理的巡检，返回巡检数与失败数。
+func (s *ProxyMonitorService) runOnce(ctx context.Context, settings *ProxyMonitorSettings, now time.Time) (int, int, error) {
+	states, err := s.healthRepo.ListStates(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("list proxy health states: %w", err)
+	}
+	stateByID := make(map[int64]*ProxyHealthState, len(states))
+	var autoDisabledIDs []int64
+	for i := range states {
+		st := &states[i]
+		stateByID[st.ProxyID] = st
+		if st.AutoDisabled {
+			autoDisabledIDs = append(autoDisabledIDs, st.ProxyID)
+		}
+	}
+
+	active, err := s.proxyRepo.ListActive(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("list active proxies: %w", err)
+	}
+	candidates := make([]*Proxy, 0, len(active)+len(autoDisabledIDs))
+	for i := range active {
+		candidates = append(candidates, &active[i])
+	}
+	// 被监控器自动停用的代理不在 ListActive 中，需要单独加载以便自动恢复。
+	if len(autoDisabledIDs) > 0 {
+		disabled, err := s.proxyRepo.ListByIDs(ctx, autoDisabledIDs)
+		if err != nil {
+			return 0, 0, fmt.Errorf("load auto-disabled proxies: %w", err)
+		}
+		for i := range disabled {
+			if !disabled[i].IsActive() {
+				candidates = append(candidates, &disabled[i])
+			}
+		}
+	}
+
+	interval := time.Duration(settings.IntervalSeconds) * time.Second
+	due := make([]*Proxy, 0, len(candidates))
+	for _, proxy := range candidates {
+		state := stateByID[proxy.ID]
+		if state != nil && state.LastCheckAt != nil && now.Sub(*state.LastCheckAt) < interval {
+			continue
+		}
+		due = append(due, proxy)
+	}
+	if len(due) == 0 {
+		return 0, 0, nil
+	}
+
+	var (
+		mu     sync.Mutex
+		failed int
+		wg     sync.WaitGroup
+	)
+	sem := make(chan struct{}, settings.Concurrency)
+	for _, proxy := range due {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return len(due), failed, ctx.Err()
+		case <-s.stopCh:
+			wg.Wait()
+			return len(due), failed, nil
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(proxy *Proxy) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			probeCtx, cancel := context.WithTimeout(ctx, time.Duration(settings.TimeoutSeconds)*time.Second)
+			check := s.checkProxy(probeCtx, proxy)
+			cancel()
+
+			s.recordCheck(ctx, proxy, stateByID[proxy.ID], check, settings)
+			if !check.Success {
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}(proxy)
+	}
+	wg.Wait()
+	return len(due), failed, nil
+}
+
+// checkProxy 探测代理并同步更新延迟缓存。
+func (s *ProxyMonitorService) checkProxy(ctx context.Context, proxy *Proxy) *ProxyHealthCheck {
+	check := &ProxyHealthCheck{ProxyID: proxy.ID, CreatedAt: time.Now()}
+	exitInfo, latencyMs, err := s.prober.ProbeProxy(ctx, proxy.URL())
+	if err != nil {
+		check.ErrorMessage = truncateString(err.Error(), proxyMonitorErrorMaxLen)
+		s.saveLatency(ctx, proxy.ID, &ProxyLatencyInfo{
+			Success:   false,
+			Message:   err.Error(),
+			UpdatedAt: check.CreatedAt,
+		})
+		return check
+	}
+
+	check.Success = true
+	check.LatencyMs = latencyMs
+	if exitInfo != nil {
+		check.IPAddress = exitInfo.IP
+		check.Country = exitInfo.Country
+		check.CountryCode = exitInfo.CountryCode
+	}
+	latency := latencyMs
+	info := &ProxyLatencyInfo{
+		Success:   true,
+		LatencyMs: &latency,
+		Message:   "Proxy is accessible",
+		UpdatedAt: check.CreatedAt,
+	}
+	if exitInfo != nil {
+		info.IPAddress = exitInfo.IP
+		info.Country = exitInfo.Country
+		info.CountryCode = exitInfo.CountryCode
+		info.Region = exitInfo.Region
+		info.City = exitInfo.City
+	}
+	s.saveLatency(ctx, proxy.ID, info)
+	return check
+}
+
+func (s *ProxyMonitorService) saveLatency(ctx context.Context, proxyID int64, info *ProxyLatencyInfo) {
+	if s.latencyCache == nil || info == nil {
+		return
+	}
+	if err := s.latencyCache.SetProxyLatency(ctx, proxyID, info); err != nil {
+		log.Printf("[ProxyMonitor] save latency failed: proxy=%d err=%v", proxyID, err)
+	}
+}
+
+// recordCheck 持久化巡检结果并更新代理健康状态。
+func (s *ProxyMonitorService) recordCheck(ctx context.Context, proxy *Proxy, state *ProxyHealthState, check *ProxyHealthCheck, settings *ProxyMonitorSettings) {
+	next := s.applyCheck(ctx, proxy, state, check, settings)
+	if err := s.healthRepo.InsertCheck(ctx, check); err != nil {
+		log.Printf("[ProxyMonitor] insert check failed: proxy=%d err=%v", proxy.ID, err)
+	}
+	if err := s.healthRepo.UpsertState(ctx, next); err != nil {
+		log.Printf("[ProxyMonitor] upsert state failed: proxy=%d err=%v", proxy.ID, err)
+	}
+}
+
+// applyCheck 计算新的健康状态：检测出口变化，并在达到阈值时标记下线/恢复。
+func (s *ProxyMonitorService) applyCheck(ctx context.Context, proxy *Proxy, prev *ProxyHealthState, check *ProxyHealthCheck, settings *ProxyMonitorSettings) *ProxyHealthState {
+	next := &ProxyHealthState{ProxyID: proxy.ID}
+	if prev != nil {
+		*next = *prev
+	}
+	checkedAt := check.CreatedAt
+	next.LastCheckAt = &checkedAt
+	next.LastSuccess = check.Success
+	next.LastLatencyMs = check.LatencyMs
+	next.LastError = check.ErrorMessage
+
+	if !check.Success {
+		next.ConsecutiveFailures++
+		next.ConsecutiveSuccesses = 0
+		if !next.Down && next.ConsecutiveFailures >= settings.FailureThreshold {
+			next.Down = true
+			next.DownSince = &checkedAt
+			s.reportAffectedAccounts(ctx, proxy.ID, fmt.Sprintf("proxy %d (%s) is down after %d consecutive failures: %s", proxy.ID, proxy.Name, next.ConsecutiveFailures, check.ErrorMessage))
+		}
+		if next.Down && settings.AutoDisable && !next.AutoDisabled && proxy.IsActive() {
+			next.AutoDisabled = s.setProxyStatus(ctx, proxy, proxyStatusInactive)
+		}
+		return next
+	}
+
+	next.ConsecutiveSuccesses++
+	next.ConsecutiveFailures = 0
+
+	if ip := strings.TrimSpace(check.IPAddress); ip != "" {
+		if next.LastExitIP != "" && next.LastExitIP != ip {
+			check.ExitIPChanged = true
+		}
+		if next.LastCountryCode != "" && check.CountryCode != "" && !strings.EqualFold(next.LastCountryCode, check.CountryCode) {
+			check.CountryChanged = true
+		}
+		if check.ExitIPChanged || check.CountryChanged {
+			next.ExitChangedAt = &checkedAt
+			s.reportAffectedAccounts(ctx, proxy.ID, fmt.Sprintf("proxy %d (%s) exit changed: ip %s -> %s, country %s -> %s",
+				proxy.ID, proxy.Name, next.LastExitIP, ip, next.LastCountryCode, check.CountryCode))
+		}
+		next.LastExitIP = ip
+		if check.CountryCode != "" {
+			next.LastCountryCode = check.CountryCode
+		}
+	}
+
+	if next.Down && next.ConsecutiveSuccesses >= settings.RecoveryThreshold {
+		next.Down = false
+		next.DownSince = nil
+		log.Printf("[ProxyMonitor] proxy %d (%s) recovered after %d consecutive successes", proxy.ID, proxy.Name, next.ConsecutiveSuccesses)
+	}
+	// 仅撤销监控器自己设置的停用；若管理员已手动修改状态则只清除标记。
+	if !next.Down && next.AutoDisabled {
+		if proxy.IsActive() || s.setProxyStatus(ctx, proxy, StatusActive) {
+			next.AutoDisabled = false
+		}
+	}
+	return next
+}
+
+func (s *ProxyMonitorService) setProxyStatus(ctx context.Context, proxy *Proxy, status string) bool {
+	updated := *proxy
+	updated.Status = status
+	if err := s.proxyRepo.Update(ctx, &updated); err != nil {
+		log.Printf("[ProxyMonitor] set proxy status failed: proxy=%d status=%s err=%v", proxy.ID, status, err)
+		return false
+	}
+	proxy.Status = status
+	log.Printf("[ProxyMonitor] proxy %d (%s) status set to %s", proxy.ID, proxy.Name, status)
+	return true
+}
+
+// reportAffectedAccounts 记录代理状态变化及受影响的账号，便于运维排查。
+func (s *ProxyMonitorService) reportAffectedAccounts(ctx context.Context, proxyID int64, message string) {
+	affected, err := s.healthRepo.ListAffectedAccounts(ctx, proxyID)
+	if err != nil {
+		log.Printf("[ProxyMonitor] %s (list affected accounts failed: %v)", message, err)
+		return
+	}
+	ids := make([]string, 0, len(affected))
+	for _, acc := range affected {
+		ids = append(ids, fmt.Sprintf("%d", acc.ID))
+	}
+	log.Printf("[ProxyMonitor] %s; affected accounts (%d): [%s]", message, len(affected), strings.Join(ids, ","))
+}
+
+func (s *ProxyMonitorService) tryAcquireLeaderLock(ctx context.Context) (func(), bool) {
+	// In simple run mode, assume single instance.
+	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
+		return nil, true
+	}
+
+	key := proxyMonitorLeaderLockKey
+	if s.redisClient != nil {
+		ok, err := s.redisClient.SetNX(ctx, key, s.instanceID, proxyMonitorLeaderLockTTL).Result()
+		if err == nil {
+			if !ok {
+				s.maybeLogSkip()
+				return nil, false
+			}
+			return func() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				defer cancel()
+				_, _ = proxyMonitorReleaseScript.Run(releaseCtx, s.redisClient, []string{key}, s.instanceID).Result()
+			}, true
+		}
+		// Redis error: fall back to DB advisory lock.
+		s.warnNoRedisOnce.Do(func() {
+			log.Printf("[ProxyMonitor] leader lock SetNX failed; falling back to DB advisory lock: %v", err)
+		})
+	}
+
+	release, ok := tryAcquireDBAdvisoryLock(ctx, s.db, hashAdvisoryLockID(key))
+	if !ok {
+		s.maybeLogSkip()
+		return nil, false
+	}
+	return release, true
+}
+
+func (s *ProxyMonitorService) maybeLogSkip() {
+	s.skipLogMu.Lock()
+	defer s.skipLogMu.Unlock()
+
+	now := time.Now()
+	if !s.skipLogAt.IsZero() && now.Sub(s.skipLogAt) < time.Minute {
+		return
+	}
+	s.skipLogAt = now
+	log.Printf("[ProxyMonitor] leader lock held by another instance; skipping")
+}
+
+func (s *ProxyMonitorService) recordHeartbeatSuccess(runAt time.Time, duration time.Duration, result string) {
+	if s.opsRepo == nil {
+		return
+	}
+	now := time.Now().UTC()
+	durMs := duration.Milliseconds()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.opsRepo.UpsertJobHeartbeat(ctx, &OpsUpsertJobHeartbeatInput{
+		JobName:        proxyMonitorJobName,
+		LastRunAt:      &runAt,
+		LastSuccessAt:  &now,
+		LastDurationMs: &durMs,
+		LastResult:     &result,
+	})
+}
+
+func (s *ProxyMonitorService) recordHeartbeatError(runAt time.Time, duration time.Duration, err error) {
+	if s.opsRepo == nil || err == nil {
+		return
+	}
+	now := time.Now().UTC()
+	durMs := duration.Milliseconds()
+	msg := truncateString(err.Error(), 2048)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.opsRepo.UpsertJobHeartbeat(ctx, &OpsUpsertJobHeartbeatInput{
+		JobName:        proxyMonitorJobName,
+		LastRunAt:      &runAt,
+		LastErrorAt:    &now,
+		LastError:      &msg,
+		LastDurationMs: &durMs,
+	})
+}
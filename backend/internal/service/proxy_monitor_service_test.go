@@ -0,0 +1,208 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type proxyHealthRepoStub struct {
+	checks      []ProxyHealthCheck
+	states      map[int64]*ProxyHealthState
+	downCount   int64
+	exitChanges int64
+	affected    []ProxyAffectedAccount
+}
+
+func (r *proxyHealthRepoStub) InsertCheck(ctx context.Context, check *ProxyHealthCheck) error {
+	r.checks = append(r.checks, *check)
+	return nil
+}
+
+func (r *proxyHealthRepoStub) ListChecks(ctx context.Context, proxyID int64, limit int) ([]ProxyHealthCheck, error) {
+	return r.checks, nil
+}
+
+func (r *proxyHealthRepoStub) GetState(ctx context.Context, proxyID int64) (*ProxyHealthState, error) {
+	return r.states[proxyID], nil
+}
+
+func (r *proxyHealthRepoStub) ListStates(ctx context.Context) ([]ProxyHealthState, error) {
+	out := make([]ProxyHealthState, 0, len(r.states))
+	for _, st := range r.states {
+		out = append(out, *st)
+	}
+	return out, nil
+}
+
+func (r *proxyHealthRepoStub) UpsertState(ctx context.Context, state *ProxyHealthState) error {
+	cp := *state
+	r.states[state.ProxyID] = &cp
+	return nil
+}
+
+func (r *proxyHealthRepoStub) DeleteChecksBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *proxyHealthRepoStub) CountDown(ctx context.Context) (int64, error) {
+	return r.downCount, nil
+}
+
+func (r *proxyHealthRepoStub) CountExitChanges(ctx context.Context, start, end time.Time) (int64, error) {
+	return r.exitChanges, nil
+}
+
+func (r *proxyHealthRepoStub) ListAffectedAccounts(ctx context.Context, proxyID int64) ([]ProxyAffectedAccount, error) {
+	return r.affected, nil
+}
+
+type monitorProxyRepoStub struct {
+	proxyRepoStub
+	statusUpdates []string
+}
+
+func (s *monitorProxyRepoStub) Update(ctx context.Context, proxy *Proxy) error {
+	s.statusUpdates = append(s.statusUpdates, proxy.Status)
+	return nil
+}
+
+type proxyProberStub struct {
+	err  error
+	info *ProxyExitInfo
+}
+
+func (p *proxyProberStub) ProbeProxy(ctx context.Context, proxyURL string) (*ProxyExitInfo, int64, error) {
+	if p.err != nil {
+		return nil, 0, p.err
+	}
+	return p.info, 120, nil
+}
+
+func newProxyMonitorTestService() (*ProxyMonitorService, *proxyHealthRepoStub, *monitorProxyRepoStub, *proxyProberStub) {
+	healthRepo := &proxyHealthRepoStub{states: map[int64]*ProxyHealthState{}}
+	proxyRepo := &monitorProxyRepoStub{}
+	prober := &proxyProberStub{info: &ProxyExitInfo{IP: "1.1.1.1", Country: "Japan", CountryCode: "JP"}}
+	svc := NewProxyMonitorService(healthRepo, proxyRepo, nil, prober, nil, nil, nil, nil, nil)
+	return svc, healthRepo, proxyRepo, prober
+}
+
+func runProxyCheck(svc *ProxyMonitorService, repo *proxyHealthRepoStub, proxy *Proxy, settings *ProxyMonitorSettings) *ProxyHealthState {
+	ctx := context.Background()
+	check := svc.checkProxy(ctx, proxy)
+	svc.recordCheck(ctx, proxy, repo.states[proxy.ID], check, settings)
+	return repo.states[proxy.ID]
+}
+
+func TestProxyMonitor_DownAfterThresholdAndRecover(t *testing.T) {
+	svc, repo, proxyRepo, prober := newProxyMonitorTestService()
+	settings := DefaultProxyMonitorSettings()
+	settings.Enabled = true
+	proxy := &Proxy{ID: 1, Name: "jp-1", Protocol: "http", Host: "10.0.0.1", Port: 8080, Status: StatusActive}
+
+	prober.err = errors.New("proxyconnect tcp: i/o timeout")
+	state := runProxyCheck(svc, repo, proxy, settings)
+	state = runProxyCheck(svc, repo, proxy, settings)
+	require.False(t, state.Down)
+	require.Empty(t, proxyRepo.statusUpdates)
+
+	state = runProxyCheck(svc, repo, proxy, settings)
+	require.True(t, state.Down)
+	require.NotNil(t, state.DownSince)
+	require.True(t, state.AutoDisabled)
+	require.Equal(t, []string{proxyStatusInactive}, proxyRepo.statusUpdates)
+	require.Equal(t, proxyStatusInactive, proxy.Status)
+
+	prober.err = nil
+	state = runProxyCheck(svc, repo, proxy, settings)
+	require.True(t, state.Down, "恢复需要连续成功达到阈值")
+
+	state = runProxyCheck(svc, repo, proxy, settings)
+	require.False(t, state.Down)
+	require.False(t, state.AutoDisabled)
+	require.Equal(t, []string{proxyStatusInactive, StatusActive}, proxyRepo.statusUpdates)
+	require.Len(t, repo.checks, 5)
+}
+
+func TestProxyMonitor_AutoDisableOff(t *testing.T) {
+	svc, repo, proxyRepo, prober := newProxyMonitorTestService()
+	settings := DefaultProxyMonitorSettings()
+	settings.FailureThreshold = 1
+	settings.AutoDisable = false
+	proxy := &Proxy{ID: 1, Status: StatusActive}
+
+	prober.err = errors.New("connection refused")
+	state := runProxyCheck(svc, repo, proxy, settings)
+	require.True(t, state.Down)
+	require.False(t, state.AutoDisabled)
+	require.Empty(t, proxyRepo.statusUpdates)
+}
+
+func TestProxyMonitor_RecoverDoesNotReenableManuallyChangedProxy(t *testing.T) {
+	svc, repo, proxyRepo, _ := newProxyMonitorTestService()
+	settings := DefaultProxyMonitorSettings()
+	settings.RecoveryThreshold = 1
+	repo.states[1] = &ProxyHealthState{ProxyID: 1, Down: true, AutoDisabled: true}
+
+	// 管理员已手动重新启用代理：只清除标记，不再写入状态
+	proxy := &Proxy{ID: 1, Status: StatusActive}
+	state := runProxyCheck(svc, repo, proxy, settings)
+	require.False(t, state.Down)
+	require.False(t, state.AutoDisabled)
+	require.Empty(t, proxyRepo.statusUpdates)
+}
+
+func TestProxyMonitor_DetectsExitChange(t *testing.T) {
+	svc, repo, _, prober := newProxyMonitorTestService()
+	settings := DefaultProxyMonitorSettings()
+	proxy := &Proxy{ID: 1, Status: StatusActive}
+
+	// 首次巡检仅记录出口信息
+	state := runProxyCheck(svc, repo, proxy, settings)
+	require.Equal(t, "1.1.1.1", state.LastExitIP)
+	require.Nil(t, state.ExitChangedAt)
+	require.False(t, repo.checks[0].ExitIPChanged)
+
+	prober.info = &ProxyExitInfo{IP: "2.2.2.2", Country: "Japan", CountryCode: "JP"}
+	state = runProxyCheck(svc, repo, proxy, settings)
+	require.NotNil(t, state.ExitChangedAt)
+	require.True(t, repo.checks[1].ExitIPChanged)
+	require.False(t, repo.checks[1].CountryChanged)
+
+	prober.info = &ProxyExitInfo{IP: "3.3.3.3", Country: "United States", CountryCode: "US"}
+	state = runProxyCheck(svc, repo, proxy, settings)
+	require.True(t, repo.checks[2].ExitIPChanged)
+	require.True(t, repo.checks[2].CountryChanged)
+	require.Equal(t, "US", state.LastCountryCode)
+
+	// 失败的巡检不影响出口记录
+	prober.err = errors.New("timeout")
+	state = runProxyCheck(svc, repo, proxy, settings)
+	require.Equal(t, "3.3.3.3", state.LastExitIP)
+	require.False(t, repo.checks[3].ExitIPChanged)
+}
+
+func TestComputeRuleMetricProxyIndicators(t *testing.T) {
+	repo := &proxyHealthRepoStub{downCount: 2, exitChanges: 3}
+	svc := &OpsAlertEvaluatorService{opsRepo: &stubOpsRepo{overview: &OpsDashboardOverview{}}}
+	start := time.Now().UTC().Add(-5 * time.Minute)
+	end := time.Now().UTC()
+
+	// 未注入代理健康存储时指标不可用
+	_, ok := svc.computeRuleMetric(context.Background(), &OpsAlertRule{MetricType: "proxy_down_count"}, nil, start, end, "", nil)
+	require.False(t, ok)
+
+	svc.SetProxyHealthRepository(repo)
+	value, ok := svc.computeRuleMetric(context.Background(), &OpsAlertRule{MetricType: "proxy_down_count"}, nil, start, end, "", nil)
+	require.True(t, ok)
+	require.Equal(t, float64(2), value)
+
+	value, ok = svc.computeRuleMetric(context.Background(), &OpsAlertRule{MetricType: "proxy_exit_ip_changed"}, nil, start, end, "", nil)
+	require.True(t, ok)
+	require.Equal(t, float64(3), value)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// 代理池选择策略
+const (
+	// ProxyPoolStrategyFailover 固定使用第一个健康代理，失效时按顺序切换
+	ProxyPoolStrategyFailover = "failover"
+	// ProxyPoolStrategyRoundRobin 新绑定的账号轮询分配代理（账号保持粘性）
+	ProxyPoolStrategyRoundRobin = "round_robin"
+	// ProxyPoolStrategyLowestLatency 新绑定的账号分配延迟最低的代理（账号保持粘性）
+	ProxyPoolStrategyLowestLatency = "lowest_latency"
+)
+
+var (
+	ErrProxyPoolNotFound = infraerrors.NotFound("PROXY_POOL_NOT_FOUND", "proxy pool not found")
+	ErrProxyPoolExists   = infraerrors.Conflict("PROXY_POOL_EXISTS", "proxy pool name already exists")
+	ErrProxyPoolInUse    = infraerrors.Conflict("PROXY_POOL_IN_USE", "proxy pool is in use by accounts")
+)
+
+// ProxyPool 代理池：一组有序代理及其选择策略
+type ProxyPool struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Strategy    string    `json:"strategy"`
+	ProxyIDs    []int64   `json:"proxy_ids"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (p *ProxyPool) IsActive() bool {
+	return p.Status == StatusActive
+}
+
+// ProxyPoolMember 代理池成员的当前健康状态（用于管理端展示）
+type ProxyPoolMember struct {
+	ProxyID   int64  `json:"proxy_id"`
+	Name      string `json:"name"`
+	Healthy   bool   `json:"healthy"`
+	LatencyMs *int64 `json:"latency_ms,omitempty"`
+	// CooldownUntil 网关请求失败后的冷却截止时间（冷却期内不参与选择）
+	CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
+}
+
+// ProxyPoolWithStatus 代理池及其成员健康状态、账号引用数
+type ProxyPoolWithStatus struct {
+	ProxyPool
+	Members      []ProxyPoolMember `json:"members"`
+	AccountCount int64             `json:"account_count"`
+}
+
+type ProxyPoolRepository interface {
+	Create(ctx context.Context, pool *ProxyPool) error
+	GetByID(ctx context.Context, id int64) (*ProxyPool, error)
+	Update(ctx context.Context, pool *ProxyPool) error
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context) ([]ProxyPool, error)
+
+	// CountAccountsByPoolID 统计引用代理池的账号数量
+	CountAccountsByPoolID(ctx context.Context) (map[int64]int64, error)
+	// ListAccountPoolBindings 返回引用代理池的账号：accountID -> poolID
+	ListAccountPoolBindings(ctx context.Context) (map[int64]int64, error)
+}
+
+// CreateProxyPoolInput 创建代理池请求
+type CreateProxyPoolInput struct {
+	Name        string  `json:"name" binding:"required,max=100"`
+	Description string  `json:"description"`
+	Strategy    string  `json:"strategy" binding:"omitempty,oneof=failover round_robin lowest_latency"`
+	ProxyIDs    []int64 `json:"proxy_ids" binding:"required,min=1"`
+}
+
+// UpdateProxyPoolInput 更新代理池请求（nil 表示不修改）
+type UpdateProxyPoolInput struct {
+	Name        *string  `json:"name" binding:"omitempty,max=100"`
+	Description *string  `json:"description"`
+	Strategy    *string  `json:"strategy" binding:"omitempty,oneof=failover round_robin lowest_latency"`
+	ProxyIDs    *[]int64 `json:"proxy_ids"`
+	Status      *string  `json:"status" binding:"omitempty,oneof=active inactive"`
+}
+
+// normalizeProxyPoolID 0 或负数表示不使用代理池
+func normalizeProxyPoolID(id *int64) *int64 {
+	if id == nil || *id <= 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,555 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+const (
+	// proxyPoolSnapshotTTL 代理池 / 代理 / 账号绑定关系的本地缓存时间
+	proxyPoolSnapshotTTL = 30 * time.Second
+	// proxyPoolFailureCooldown 代理请求失败后的冷却时间，冷却期内不参与选择
+	proxyPoolFailureCooldown = 60 * time.Second
+)
+
+// proxyPoolSnapshot 代理池选择所需数据的本地快照
+type proxyPoolSnapshot struct {
+	pools        map[int64]*ProxyPool
+	proxies      map[int64]*Proxy
+	accountPools map[int64]int64
+	expiresAt    time.Time
+}
+
+// ProxyPoolService 代理池管理与代理选择
+//
+// 账号引用代理池时，上游请求由 ProxyPoolHTTPUpstream 按代理池策略选择代理：
+//   - 选中的代理对账号保持粘性，只有代理失效（请求网络错误、被停用、延迟探测失败）时才切换，避免出口 IP 抖动
+//   - 请求因代理网络错误失败时，代理进入冷却并立即切换到池内下一个健康代理重试
+type ProxyPoolService struct {
+	poolRepo     ProxyPoolRepository
+	proxyRepo    ProxyRepository
+	latencyCache ProxyLatencyCache
+
+	mu          sync.Mutex
+	snapshot    *proxyPoolSnapshot
+	sticky      map[int64]int64     // accountID -> proxyID
+	cooldown    map[int64]time.Time // proxyID -> 冷却截止时间
+	roundRobin  map[int64]int       // poolID -> 下一个分配位置
+	refreshLock sync.Mutex
+}
+
+// NewProxyPoolService 创建代理池服务
+func NewProxyPoolService(poolRepo ProxyPoolRepository, proxyRepo ProxyRepository, latencyCache ProxyLatencyCache) *ProxyPoolService {
+	return &ProxyPoolService{
+		poolRepo:     poolRepo,
+		proxyRepo:    proxyRepo,
+		latencyCache: latencyCache,
+		sticky:       make(map[int64]int64),
+		cooldown:     make(map[int64]time.Time),
+		roundRobin:   make(map[int64]int),
+	}
+}
+
+// List 返回所有代理池及其成员健康状态
+func (s *ProxyPoolService) List(ctx context.Context) ([]ProxyPoolWithStatus, error) {
+	pools, err := s.poolRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.poolRepo.CountAccountsByPoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ProxyPoolWithStatus, 0, len(pools))
+	for i := range pools {
+		item, err := s.withStatus(ctx, &pools[i])
+		if err != nil {
+			return nil, err
+		}
+		item.AccountCount = counts[pools[i].ID]
+		out = append(out, *item)
+	}
+	return out, nil
+}
+
+// GetByID 返回代理池及其成员健康状态
+func (s *ProxyPoolService) GetByID(ctx context.Context, id int64) (*ProxyPoolWithStatus, error) {
+	pool, err := s.poolRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.withStatus(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.poolRepo.CountAccountsByPoolID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	item.AccountCount = counts[pool.ID]
+	return item, nil
+}
+
+// Create 创建代理池
+func (s *ProxyPoolService) Create(ctx context.Context, input *CreateProxyPoolInput) (*ProxyPool, error) {
+	strategy, err := normalizeProxyPoolStrategy(input.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	proxyIDs, err := s.validateProxyIDs(ctx, input.ProxyIDs)
+	if err != nil {
+		return nil, err
+	}
+	pool := &ProxyPool{
+		Name:        strings.TrimSpace(input.Name),
+		Description: strings.TrimSpace(input.Description),
+		Strategy:    strategy,
+		ProxyIDs:    proxyIDs,
+		Status:      StatusActive,
+	}
+	if pool.Name == "" {
+		return nil, infraerrors.BadRequest("INVALID_PROXY_POOL", "name is required")
+	}
+	if err := s.poolRepo.Create(ctx, pool); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return pool, nil
+}
+
+// Update 更新代理池
+func (s *ProxyPoolService) Update(ctx context.Context, id int64, input *UpdateProxyPoolInput) (*ProxyPool, error) {
+	pool, err := s.poolRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			return nil, infraerrors.BadRequest("INVALID_PROXY_POOL", "name is required")
+		}
+		pool.Name = name
+	}
+	if input.Description != nil {
+		pool.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Strategy != nil {
+		strategy, err := normalizeProxyPoolStrategy(*input.Strategy)
+		if err != nil {
+			return nil, err
+		}
+		pool.Strategy = strategy
+	}
+	if input.ProxyIDs != nil {
+		proxyIDs, err := s.validateProxyIDs(ctx, *input.ProxyIDs)
+		if err != nil {
+			return nil, err
+		}
+		pool.ProxyIDs = proxyIDs
+	}
+	if input.Status != nil {
+		pool.Status = *input.Status
+	}
+	if err := s.poolRepo.Update(ctx, pool); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return pool, nil
+}
+
+// Delete 删除代理池（仍被账号引用时拒绝）
+func (s *ProxyPoolService) Delete(ctx context.Context, id int64) error {
+	if _, err := s.poolRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	counts, err := s.poolRepo.CountAccountsByPoolID(ctx)
+	if err != nil {
+		return err
+	}
+	if counts[id] > 0 {
+		return ErrProxyPoolInUse
+	}
+	if err := s.poolRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// ResolveAccountProxy 为引用代理池的账号选择代理。
+// ok=false 表示账号未引用（有效的）代理池，调用方应使用账号自身的代理配置。
+func (s *ProxyPoolService) ResolveAccountProxy(ctx context.Context, accountID int64) (*Proxy, bool) {
+	if s == nil || accountID <= 0 {
+		return nil, false
+	}
+	snap := s.loadSnapshot(ctx)
+	if snap == nil {
+		return nil, false
+	}
+	poolID, ok := snap.accountPools[accountID]
+	if !ok {
+		return nil, false
+	}
+	pool := snap.pools[poolID]
+	if pool == nil || !pool.IsActive() {
+		return nil, false
+	}
+
+	latencies := s.loadLatencies(ctx, pool.ProxyIDs)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	proxy := s.selectProxyLocked(snap, pool, accountID, latencies, time.Now())
+	if proxy == nil {
+		return nil, false
+	}
+	return proxy, true
+}
+
+// ReportProxyFailure 记录代理请求失败：代理进入冷却，并解除账号的粘性绑定
+func (s *ProxyPoolService) ReportProxyFailure(accountID, proxyID int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cooldown[proxyID] = time.Now().Add(proxyPoolFailureCooldown)
+	if s.sticky[accountID] == proxyID {
+		delete(s.sticky, accountID)
+	}
+}
+
+// selectProxyLocked 按代理池策略选择代理（调用方持有 s.mu）
+func (s *ProxyPoolService) selectProxyLocked(snap *proxyPoolSnapshot, pool *ProxyPool, accountID int64, latencies map[int64]*ProxyLatencyInfo, now time.Time) *Proxy {
+	healthy := make([]*Proxy, 0, len(pool.ProxyIDs))
+	active := make([]*Proxy, 0, len(pool.ProxyIDs))
+	for _, id := range pool.ProxyIDs {
+		proxy := snap.proxies[id]
+		if proxy == nil || !proxy.IsActive() {
+			continue
+		}
+		active = append(active, proxy)
+		if until, ok := s.cooldown[id]; ok && now.Before(until) {
+			continue
+		}
+		if info := latencies[id]; info != nil && !info.Success {
+			continue
+		}
+		healthy = append(healthy, proxy)
+	}
+	// 全部代理不可用时仍按顺序尝试（fail open），避免账号完全无法请求
+	if len(healthy) == 0 {
+		healthy = active
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	if pool.Strategy == ProxyPoolStrategyFailover {
+		s.sticky[accountID] = healthy[0].ID
+		return healthy[0]
+	}
+
+	// 粘性：账号当前代理仍健康时继续使用
+	if stickyID, ok := s.sticky[accountID]; ok {
+		for _, proxy := range healthy {
+			if proxy.ID == stickyID {
+				return proxy
+			}
+		}
+	}
+
+	var chosen *Proxy
+	switch pool.Strategy {
+	case ProxyPoolStrategyLowestLatency:
+		chosen = lowestLatencyProxy(healthy, latencies)
+	default:
+		idx := s.roundRobin[pool.ID] % len(healthy)
+		s.roundRobin[pool.ID] = idx + 1
+		chosen = healthy[idx]
+	}
+	s.sticky[accountID] = chosen.ID
+	return chosen
+}
+
+// lowestLatencyProxy 选择延迟最低的代理；没有延迟数据的代理排在最后，保持池内顺序
+func lowestLatencyProxy(candidates []*Proxy, latencies map[int64]*ProxyLatencyInfo) *Proxy {
+	ordered := make([]*Proxy, len(candidates))
+	copy(ordered, candidates)
+	latencyOf := func(p *Proxy) (int64, bool) {
+		info := latencies[p.ID]
+		if info == nil || info.LatencyMs == nil {
+			return 0, false
+		}
+		return *info.LatencyMs, true
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		li, okI := latencyOf(ordered[i])
+		lj, okJ := latencyOf(ordered[j])
+		if okI != okJ {
+			return okI
+		}
+		return okI && li < lj
+	})
+	return ordered[0]
+}
+
+func (s *ProxyPoolService) loadLatencies(ctx context.Context, proxyIDs []int64) map[int64]*ProxyLatencyInfo {
+	if s.latencyCache == nil || len(proxyIDs) == 0 {
+		return nil
+	}
+	latencies, err := s.latencyCache.GetProxyLatencies(ctx, proxyIDs)
+	if err != nil {
+		log.Printf("[ProxyPool] Warning: load proxy latencies failed: %v", err)
+		return nil
+	}
+	return latencies
+}
+
+// loadSnapshot 返回本地快照，过期时从数据库刷新；刷新失败时继续使用旧快照
+func (s *ProxyPoolService) loadSnapshot(ctx context.Context) *proxyPoolSnapshot {
+	s.mu.Lock()
+	snap := s.snapshot
+	s.mu.Unlock()
+	if snap != nil && time.Now().Before(snap.expiresAt) {
+		return snap
+	}
+
+	s.refreshLock.Lock()
+	defer s.refreshLock.Unlock()
+	s.mu.Lock()
+	snap = s.snapshot
+	s.mu.Unlock()
+	if snap != nil && time.Now().Before(snap.expiresAt) {
+		return snap
+	}
+
+	fresh, err := s.buildSnapshot(ctx)
+	if err != nil {
+		log.Printf("[ProxyPool] Warning: refresh proxy pool snapshot failed: %v", err)
+		return snap
+	}
+	s.mu.Lock()
+	s.snapshot = fresh
+	s.mu.Unlock()
+	return fresh
+}
+
+func (s *ProxyPoolService) buildSnapshot(ctx context.Context) (*proxyPoolSnapshot, error) {
+	bindings, err := s.poolRepo.ListAccountPoolBindings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snap := &proxyPoolSnapshot{
+		pools:        make(map[int64]*ProxyPool),
+		proxies:      make(map[int64]*Proxy),
+		accountPools: bindings,
+		expiresAt:    time.Now().Add(proxyPoolSnapshotTTL),
+	}
+	if len(bindings) == 0 {
+		return snap, nil
+	}
+
+	pools, err := s.poolRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proxyIDSet := make(map[int64]struct{})
+	for i := range pools {
+		pool := pools[i]
+		snap.pools[pool.ID] = &pool
+		for _, id := range pool.ProxyIDs {
+			proxyIDSet[id] = struct{}{}
+		}
+	}
+	if len(proxyIDSet) == 0 {
+		return snap, nil
+	}
+	proxyIDs := make([]int64, 0, len(proxyIDSet))
+	for id := range proxyIDSet {
+		proxyIDs = append(proxyIDs, id)
+	}
+	proxies, err := s.proxyRepo.ListByIDs(ctx, proxyIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range proxies {
+		proxy := proxies[i]
+		snap.proxies[proxy.ID] = &proxy
+	}
+	return snap, nil
+}
+
+// invalidate 配置变更后丢弃本地快照
+func (s *ProxyPoolService) invalidate() {
+	s.mu.Lock()
+	s.snapshot = nil
+	s.mu.Unlock()
+}
+
+func (s *ProxyPoolService) withStatus(ctx context.Context, pool *ProxyPool) (*ProxyPoolWithStatus, error) {
+	out := &ProxyPoolWithStatus{ProxyPool: *pool, Members: make([]ProxyPoolMember, 0, len(pool.ProxyIDs))}
+	if len(pool.ProxyIDs) == 0 {
+		return out, nil
+	}
+	proxies, err := s.proxyRepo.ListByIDs(ctx, pool.ProxyIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*Proxy, len(proxies))
+	for i := range proxies {
+		byID[proxies[i].ID] = &proxies[i]
+	}
+	latencies := s.loadLatencies(ctx, pool.ProxyIDs)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range pool.ProxyIDs {
+		member := ProxyPoolMember{ProxyID: id}
+		proxy := byID[id]
+		if proxy != nil {
+			member.Name = proxy.Name
+			member.Healthy = proxy.IsActive()
+		}
+		if info := latencies[id]; info != nil {
+			member.LatencyMs = info.LatencyMs
+			if !info.Success {
+				member.Healthy = false
+			}
+		}
+		if until, ok := s.cooldown[id]; ok && now.Before(until) {
+			cooldownUntil := until
+			member.CooldownUntil = &cooldownUntil
+			member.Healthy = false
+		}
+		out.Members = append(out.Members, member)
+	}
+	return out, nil
+}
+
+// validateProxyIDs 去重并校验代理存在
+func (s *ProxyPoolService) validateProxyIDs(ctx context.Context, raw []int64) ([]int64, error) {
+	seen := make(map[int64]struct{}, len(raw))
+	ids := make([]int64, 0, len(raw))
+	for _, id := range raw {
+		if id <= 0 {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, infraerrors.BadRequest("INVALID_PROXY_POOL", "proxy_ids must contain at least one proxy")
+	}
+	proxies, err := s.proxyRepo.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(proxies) != len(ids) {
+		return nil, infraerrors.BadRequest("INVALID_PROXY_POOL", "proxy_ids contains unknown proxies")
+	}
+	return ids, nil
+}
+
+func normalizeProxyPoolStrategy(raw string) (string, error) {
+	strategy := strings.ToLower(strings.TrimSpace(raw))
+	switch strategy {
+	case "":
+		return ProxyPoolStrategyFailover, nil
+	case ProxyPoolStrategyFailover, ProxyPoolStrategyRoundRobin, ProxyPoolStrategyLowestLatency:
+		return strategy, nil
+	default:
+		return "", infraerrors.BadRequest("INVALID_PROXY_POOL_STRATEGY", "strategy must be one of failover, round_robin, lowest_latency")
+	}
+}
+
+// ProxyPoolHTTPUpstream 代理池感知的上游客户端装饰器。
+// 账号引用代理池时使用代理池选择的代理（覆盖调用方传入的 proxyURL），
+// 连接池隔离（ConnectionPoolIsolation）随实际使用的代理生效；
+// 因代理网络错误失败且请求体可重放时，切换到池内下一个健康代理重试。
+type ProxyPoolHTTPUpstream struct {
+	inner HTTPUpstream
+	pools *ProxyPoolService
+}
+
+// NewProxyPoolHTTPUpstream 包装上游客户端以支持代理池
+func NewProxyPoolHTTPUpstream(inner HTTPUpstream, pools *ProxyPoolService) HTTPUpstream {
+	if pools == nil {
+		return inner
+	}
+	return &ProxyPoolHTTPUpstream{inner: inner, pools: pools}
+}
+
+func (u *ProxyPoolHTTPUpstream) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	return u.do(req, proxyURL, accountID, func(r *http.Request, url string) (*http.Response, error) {
+		return u.inner.Do(r, url, accountID, accountConcurrency)
+	})
+}
+
+func (u *ProxyPoolHTTPUpstream) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	return u.do(req, proxyURL, accountID, func(r *http.Request, url string) (*http.Response, error) {
+		return u.inner.DoWithTLS(r, url, accountID, accountConcurrency, enableTLSFingerprint)
+	})
+}
+
+func (u *ProxyPoolHTTPUpstream) do(req *http.Request, proxyURL string, accountID int64, send func(*http.Request, string) (*http.Response, error)) (*http.Response, error) {
+	ctx := req.Context()
+	proxy, ok := u.pools.ResolveAccountProxy(ctx, accountID)
+	if !ok {
+		return send(req, proxyURL)
+	}
+
+	tried := make(map[int64]struct{})
+	for {
+		tried[proxy.ID] = struct{}{}
+		resp, err := send(req, proxy.URL())
+		if err == nil || ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return resp, err
+		}
+
+		u.pools.ReportProxyFailure(accountID, proxy.ID)
+		next, ok := u.pools.ResolveAccountProxy(ctx, accountID)
+		if !ok {
+			return resp, err
+		}
+		if _, seen := tried[next.ID]; seen {
+			return resp, err
+		}
+		retryReq, rewindErr := rewindRequest(req)
+		if rewindErr != nil {
+			return resp, err
+		}
+		log.Printf("[ProxyPool] account=%d proxy=%d failed (%v), switching to proxy=%d", accountID, proxy.ID, err, next.ID)
+		req = retryReq
+		proxy = next
+	}
+}
+
+// rewindRequest 复制请求并重置请求体，用于切换代理后重试
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req.Clone(req.Context()), nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body is not replayable")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
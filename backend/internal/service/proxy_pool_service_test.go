@@ -0,0 +1,233 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type proxyPoolRepoStub struct {
+	pools    map[int64]*ProxyPool
+	bindings map[int64]int64
+}
+
+func (s *proxyPoolRepoStub) Create(ctx context.Context, pool *ProxyPool) error {
+	pool.ID = int64(len(s.pools) + 1)
+	s.pools[pool.ID] = pool
+	return nil
+}
+
+func (s *proxyPoolRepoStub) GetByID(ctx context.Context, id int64) (*ProxyPool, error) {
+	if pool, ok := s.pools[id]; ok {
+		return pool, nil
+	}
+	return nil, ErrProxyPoolNotFound
+}
+
+func (s *proxyPoolRepoStub) Update(ctx context.Context, pool *ProxyPool) error {
+	s.pools[pool.ID] = pool
+	return nil
+}
+
+func (s *proxyPoolRepoStub) Delete(ctx context.Context, id int64) error {
+	delete(s.pools, id)
+	return nil
+}
+
+func (s *proxyPoolRepoStub) List(ctx context.Context) ([]ProxyPool, error) {
+	out := make([]ProxyPool, 0, len(s.pools))
+	for _, pool := range s.pools {
+		out = append(out, *pool)
+	}
+	return out, nil
+}
+
+func (s *proxyPoolRepoStub) CountAccountsByPoolID(ctx context.Context) (map[int64]int64, error) {
+	out := make(map[int64]int64)
+	for _, poolID := range s.bindings {
+		out[poolID]++
+	}
+	return out, nil
+}
+
+func (s *proxyPoolRepoStub) ListAccountPoolBindings(ctx context.Context) (map[int64]int64, error) {
+	return s.bindings, nil
+}
+
+type proxyPoolProxyRepoStub struct {
+	proxyRepoStub
+	proxies map[int64]Proxy
+}
+
+func (s *proxyPoolProxyRepoStub) ListByIDs(ctx context.Context, ids []int64) ([]Proxy, error) {
+	out := make([]Proxy, 0, len(ids))
+	for _, id := range ids {
+		if proxy, ok := s.proxies[id]; ok {
+			out = append(out, proxy)
+		}
+	}
+	return out, nil
+}
+
+type proxyLatencyCacheStub struct {
+	latencies map[int64]*ProxyLatencyInfo
+}
+
+func (s *proxyLatencyCacheStub) GetProxyLatencies(ctx context.Context, proxyIDs []int64) (map[int64]*ProxyLatencyInfo, error) {
+	return s.latencies, nil
+}
+
+func (s *proxyLatencyCacheStub) SetProxyLatency(ctx context.Context, proxyID int64, info *ProxyLatencyInfo) error {
+	return nil
+}
+
+// recordingUpstream 记录每次请求使用的代理，对 failing 中的代理返回网络错误
+type recordingUpstream struct {
+	failing map[string]bool
+	used    []string
+	bodies  []string
+}
+
+func (u *recordingUpstream) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	u.used = append(u.used, proxyURL)
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		u.bodies = append(u.bodies, string(body))
+	}
+	if u.failing[proxyURL] {
+		return nil, errors.New("proxyconnect tcp: connection refused")
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+}
+
+func (u *recordingUpstream) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	return u.Do(req, proxyURL, accountID, accountConcurrency)
+}
+
+func newProxyPoolTestService(strategy string, latencies map[int64]*ProxyLatencyInfo) *ProxyPoolService {
+	proxies := map[int64]Proxy{}
+	for id := int64(1); id <= 3; id++ {
+		proxies[id] = Proxy{ID: id, Protocol: "http", Host: fmt.Sprintf("10.0.0.%d", id), Port: 8080, Status: StatusActive}
+	}
+	poolRepo := &proxyPoolRepoStub{
+		pools: map[int64]*ProxyPool{
+			1: {ID: 1, Name: "pool", Strategy: strategy, ProxyIDs: []int64{1, 2, 3}, Status: StatusActive},
+		},
+		bindings: map[int64]int64{100: 1, 101: 1, 102: 1},
+	}
+	return NewProxyPoolService(poolRepo, &proxyPoolProxyRepoStub{proxies: proxies}, &proxyLatencyCacheStub{latencies: latencies})
+}
+
+func resolveProxyID(t *testing.T, svc *ProxyPoolService, accountID int64) int64 {
+	t.Helper()
+	proxy, ok := svc.ResolveAccountProxy(context.Background(), accountID)
+	require.True(t, ok)
+	return proxy.ID
+}
+
+func TestProxyPoolService_Failover(t *testing.T) {
+	svc := newProxyPoolTestService(ProxyPoolStrategyFailover, map[int64]*ProxyLatencyInfo{
+		1: {Success: false},
+	})
+
+	// 延迟探测失败的代理被跳过，按顺序使用下一个
+	require.Equal(t, int64(2), resolveProxyID(t, svc, 100))
+	require.Equal(t, int64(2), resolveProxyID(t, svc, 101))
+
+	svc.ReportProxyFailure(100, 2)
+	require.Equal(t, int64(3), resolveProxyID(t, svc, 100))
+
+	// 未引用代理池的账号不处理
+	_, ok := svc.ResolveAccountProxy(context.Background(), 999)
+	require.False(t, ok)
+}
+
+func TestProxyPoolService_RoundRobinIsSticky(t *testing.T) {
+	svc := newProxyPoolTestService(ProxyPoolStrategyRoundRobin, nil)
+
+	first := resolveProxyID(t, svc, 100)
+	second := resolveProxyID(t, svc, 101)
+	third := resolveProxyID(t, svc, 102)
+	require.ElementsMatch(t, []int64{1, 2, 3}, []int64{first, second, third})
+
+	// 同一账号在代理健康时保持不变，避免出口 IP 抖动
+	for i := 0; i < 5; i++ {
+		require.Equal(t, first, resolveProxyID(t, svc, 100))
+	}
+
+	svc.ReportProxyFailure(100, first)
+	moved := resolveProxyID(t, svc, 100)
+	require.NotEqual(t, first, moved)
+	require.Equal(t, moved, resolveProxyID(t, svc, 100))
+}
+
+func TestProxyPoolService_LowestLatency(t *testing.T) {
+	fast, slow := int64(40), int64(300)
+	svc := newProxyPoolTestService(ProxyPoolStrategyLowestLatency, map[int64]*ProxyLatencyInfo{
+		1: {Success: true, LatencyMs: &slow},
+		3: {Success: true, LatencyMs: &fast},
+	})
+	require.Equal(t, int64(3), resolveProxyID(t, svc, 100))
+}
+
+func TestProxyPoolService_AllUnhealthyFailsOpen(t *testing.T) {
+	svc := newProxyPoolTestService(ProxyPoolStrategyFailover, nil)
+	for id := int64(1); id <= 3; id++ {
+		svc.ReportProxyFailure(100, id)
+	}
+	require.Equal(t, int64(1), resolveProxyID(t, svc, 100))
+}
+
+func TestProxyPoolHTTPUpstream_SwitchesProxyOnNetworkError(t *testing.T) {
+	svc := newProxyPoolTestService(ProxyPoolStrategyFailover, nil)
+	proxy1 := "http://10.0.0.1:8080"
+	proxy2 := "http://10.0.0.2:8080"
+	inner := &recordingUpstream{failing: map[string]bool{proxy1: true}}
+	upstream := NewProxyPoolHTTPUpstream(inner, svc)
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.anthropic.com/v1/messages", bytes.NewReader([]byte(`{"model":"x"}`)))
+	require.NoError(t, err)
+	resp, err := upstream.Do(req, "http://account-proxy:1", 100, 1)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	require.Equal(t, []string{proxy1, proxy2}, inner.used)
+	require.Equal(t, []string{`{"model":"x"}`, `{"model":"x"}`}, inner.bodies, "切换代理重试时请求体应被重放")
+
+	// 后续请求直接使用切换后的代理
+	req, err = http.NewRequest(http.MethodGet, "https://api.anthropic.com/v1/models", nil)
+	require.NoError(t, err)
+	resp, err = upstream.Do(req, "", 100, 1)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, proxy2, inner.used[len(inner.used)-1])
+
+	// 未引用代理池的账号使用调用方传入的代理
+	req, err = http.NewRequest(http.MethodGet, "https://api.anthropic.com/v1/models", nil)
+	require.NoError(t, err)
+	resp, err = upstream.Do(req, "http://account-proxy:1", 999, 1)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, "http://account-proxy:1", inner.used[len(inner.used)-1])
+}
+
+func TestNormalizeProxyPoolStrategy(t *testing.T) {
+	got, err := normalizeProxyPoolStrategy("")
+	require.NoError(t, err)
+	require.Equal(t, ProxyPoolStrategyFailover, got)
+
+	got, err = normalizeProxyPoolStrategy(" Round_Robin ")
+	require.NoError(t, err)
+	require.Equal(t, ProxyPoolStrategyRoundRobin, got)
+
+	_, err = normalizeProxyPoolStrategy("random")
+	require.Error(t, err)
+}
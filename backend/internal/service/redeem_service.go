@@ -80,6 +80,7 @@ type RedeemService struct {
 	billingCacheService  *BillingCacheService
 	entClient            *dbent.Client
 	authCacheInvalidator APIKeyAuthCacheInvalidator
+	referralService      *ReferralService
 }
 
 // NewRedeemService 创建兑换码服务实例
@@ -103,6 +104,11 @@ func NewRedeemService(
 	}
 }
 
+// SetReferralService 注入推荐计划服务（余额兑换视为充值，用于首充奖励）
+func (s *RedeemService) SetReferralService(referralService *ReferralService) {
+	s.referralService = referralService
+}
+
 // GenerateRandomCode 生成随机兑换码
 func (s *RedeemService) GenerateRandomCode() (string, error) {
 	// 生成16字节随机数据
@@ -328,6 +334,10 @@ func (s *RedeemService) Redeem(ctx context.Context, userID int64, code string) (
 	// 事务提交成功后失效缓存
 	s.invalidateRedeemCaches(ctx, userID, redeemCode)
 
+	if redeemCode.Type == RedeemTypeBalance && s.referralService != nil {
+		s.referralService.RecordTopUp(ctx, userID, redeemCode.Value)
+	}
+
 	// 重新获取更新后的兑换码
 	redeemCode, err = s.redeemRepo.GetByID(ctx, redeemCode.ID)
 	if err != nil {
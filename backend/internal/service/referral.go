@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 返佣类型
+const (
+	ReferralCommissionTypeFirstTopUp = "first_topup"
+	ReferralCommissionTypeSpend      = "spend"
+)
+
+// 返佣审核状态
+const (
+	ReferralCommissionStatusPending  = "pending"
+	ReferralCommissionStatusApproved = "approved"
+	ReferralCommissionStatusRejected = "rejected"
+)
+
+var (
+	ErrReferralDisabled              = infraerrors.Forbidden("REFERRAL_DISABLED", "referral program is disabled")
+	ErrReferralCodeInvalid           = infraerrors.BadRequest("REFERRAL_CODE_INVALID", "invalid referral code")
+	ErrReferralCodeNotFound          = infraerrors.NotFound("REFERRAL_CODE_NOT_FOUND", "referral code not found")
+	ErrReferralCodeConflict          = infraerrors.Conflict("REFERRAL_CODE_CONFLICT", "referral code already exists")
+	ErrReferralNotFound              = infraerrors.NotFound("REFERRAL_NOT_FOUND", "referral not found")
+	ErrReferralAlreadyBound          = infraerrors.Conflict("REFERRAL_ALREADY_BOUND", "user already has a referrer")
+	ErrReferralSelf                  = infraerrors.BadRequest("REFERRAL_SELF", "cannot refer yourself")
+	ErrReferralCommissionNotFound    = infraerrors.NotFound("REFERRAL_COMMISSION_NOT_FOUND", "referral commission not found")
+	ErrReferralCommissionNotPending  = infraerrors.Conflict("REFERRAL_COMMISSION_NOT_PENDING", "referral commission has already been reviewed")
+	ErrReferralSettingsInvalid       = infraerrors.BadRequest("REFERRAL_SETTINGS_INVALID", "invalid referral settings")
+	ErrReferralCommissionStatusParam = infraerrors.BadRequest("REFERRAL_COMMISSION_STATUS_INVALID", "status must be pending, approved or rejected")
+)
+
+// ReferralSettings 推荐计划配置（保存在 settings.referral_settings）
+type ReferralSettings struct {
+	Enabled bool `json:"enabled"`
+	// FirstTopUpBonusUSD 被推荐人首次充值时给推荐人的固定奖励
+	FirstTopUpBonusUSD float64 `json:"first_topup_bonus_usd"`
+	// FirstTopUpBonusPercent 首充奖励按首充金额的百分比计算，与固定奖励叠加
+	FirstTopUpBonusPercent float64 `json:"first_topup_bonus_percent"`
+	// CommissionPercent 被推荐人实际消费（余额扣费）的返佣百分比
+	CommissionPercent float64 `json:"commission_percent"`
+	// CommissionDays 注册后多少天内的消费参与返佣，0 表示不返佣
+	CommissionDays int `json:"commission_days"`
+}
+
+// DefaultReferralSettings 默认配置（推荐计划默认关闭）
+func DefaultReferralSettings() *ReferralSettings {
+	return &ReferralSettings{
+		CommissionPercent: 10,
+		CommissionDays:    90,
+	}
+}
+
+// Referral 推荐关系，返佣比例与截止时间在建立关系时快照
+type Referral struct {
+	RefereeUserID     int64      `json:"referee_user_id"`
+	ReferrerUserID    int64      `json:"referrer_user_id"`
+	Code              string     `json:"code"`
+	CommissionPercent float64    `json:"commission_percent"`
+	CommissionUntil   *time.Time `json:"commission_until"`
+	FirstTopUpAt      *time.Time `json:"first_topup_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// CommissionActive 当前是否仍在消费返佣期内
+func (r *Referral) CommissionActive(now time.Time) bool {
+	return r.CommissionPercent > 0 && r.CommissionUntil != nil && now.Before(*r.CommissionUntil)
+}
+
+// ReferralReferee 推荐人面板中的被推荐人（邮箱脱敏）
+type ReferralReferee struct {
+	UserID          int64      `json:"user_id"`
+	Email           string     `json:"email"`
+	CreatedAt       time.Time  `json:"created_at"`
+	FirstTopUpAt    *time.Time `json:"first_topup_at"`
+	CommissionUntil *time.Time `json:"commission_until"`
+	PendingAmount   float64    `json:"pending_amount"`
+	ApprovedAmount  float64    `json:"approved_amount"`
+}
+
+// ReferralStats 推荐人汇总
+type ReferralStats struct {
+	RefereeCount   int64   `json:"referee_count"`
+	ToppedUpCount  int64   `json:"topped_up_count"`
+	PendingAmount  float64 `json:"pending_amount"`
+	ApprovedAmount float64 `json:"approved_amount"`
+	RejectedAmount float64 `json:"rejected_amount"`
+}
+
+// ReferralDashboard 推荐人面板
+type ReferralDashboard struct {
+	Code string `json:"code"`
+	// LinkPath 推荐注册链接路径，前端拼接站点地址
+	LinkPath               string  `json:"link_path"`
+	Enabled                bool    `json:"enabled"`
+	FirstTopUpBonusUSD     float64 `json:"first_topup_bonus_usd"`
+	FirstTopUpBonusPercent float64 `json:"first_topup_bonus_percent"`
+	CommissionPercent      float64 `json:"commission_percent"`
+	CommissionDays         int     `json:"commission_days"`
+	ReferralStats
+}
+
+// ReferralCommission 返佣台账记录
+type ReferralCommission struct {
+	ID             int64      `json:"id"`
+	ReferrerUserID int64      `json:"referrer_user_id"`
+	RefereeUserID  int64      `json:"referee_user_id"`
+	ReferrerEmail  string     `json:"referrer_email,omitempty"`
+	RefereeEmail   string     `json:"referee_email,omitempty"`
+	Type           string     `json:"type"`
+	SourceAmount   float64    `json:"source_amount"`
+	Amount         float64    `json:"amount"`
+	PeriodDate     string     `json:"period_date"`
+	Status         string     `json:"status"`
+	ReviewedBy     *int64     `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	Notes          string     `json:"notes"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ReferralCommissionFilter 台账查询条件
+type ReferralCommissionFilter struct {
+	ReferrerUserID int64
+	RefereeUserID  int64
+	Type           string
+	Status         string
+}
+
+// ReferralRepository 推荐关系与返佣台账存储
+type ReferralRepository interface {
+	// GetCode 获取用户推荐码，不存在时返回 ErrReferralCodeNotFound
+	GetCode(ctx context.Context, userID int64) (string, error)
+	// CreateCode 保存推荐码；用户已有推荐码时保持不变，推荐码冲突时返回 ErrReferralCodeConflict
+	CreateCode(ctx context.Context, userID int64, code string) error
+	// GetUserIDByCode 按推荐码查找推荐人，不存在时返回 ErrReferralCodeNotFound
+	GetUserIDByCode(ctx context.Context, code string) (int64, error)
+
+	// Create 建立推荐关系，被推荐人已有推荐人时返回 ErrReferralAlreadyBound
+	Create(ctx context.Context, referral *Referral) error
+	// GetByReferee 获取被推荐人的推荐关系，不存在时返回 ErrReferralNotFound
+	GetByReferee(ctx context.Context, refereeUserID int64) (*Referral, error)
+	// MarkFirstTopUp 标记首充；仅第一次标记成功时返回推荐关系，否则返回 nil
+	MarkFirstTopUp(ctx context.Context, refereeUserID int64, at time.Time) (*Referral, error)
+	ListReferees(ctx context.Context, referrerUserID int64, params pagination.PaginationParams) ([]ReferralReferee, *pagination.PaginationResult, error)
+	GetStats(ctx context.Context, referrerUserID int64) (*ReferralStats, error)
+
+	CreateCommission(ctx context.Context, commission *ReferralCommission) error
+	// AccrueSpendCommission 累加消费返佣到 (推荐人, 被推荐人, periodDate) 的待审核记录
+	AccrueSpendCommission(ctx context.Context, referrerUserID, refereeUserID int64, spend, amount float64, periodDate string) error
+	ListCommissions(ctx context.Context, filter ReferralCommissionFilter, params pagination.PaginationParams) ([]ReferralCommission, *pagination.PaginationResult, error)
+	// ApproveCommission 审核通过并在同一语句内将返佣计入推荐人余额；
+	// rewardCode 非空时同时写入一条已使用的 referral_reward 余额变动记录
+	ApproveCommission(ctx context.Context, id, reviewerID int64, notes string, rewardCode string) (*ReferralCommission, error)
+	RejectCommission(ctx context.Context, id, reviewerID int64, notes string) (*ReferralCommission, error)
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	gocache "github.com/patrickmn/go-cache"
+)
+
+const (
+	referralCodeLength = 8
+	// 去掉易混淆字符（0/O、1/I/L）
+	referralCodeAlphabet    = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	referralCodeMaxAttempts = 5
+	// referralStateTTL 推荐关系本地缓存时间；关系只在注册时建立，缓存主要用于避免每次计费都查库
+	referralStateTTL = 10 * time.Minute
+	// referralCommissionMaxDays 返佣天数上限
+	referralCommissionMaxDays = 3650
+)
+
+// ReferralService 推荐计划：推荐码、推荐关系、首充奖励与消费返佣（需管理员审核入账）
+type ReferralService struct {
+	referralRepo         ReferralRepository
+	settingRepo          SettingRepository
+	billingCache         BillingCache
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+
+	// states 被推荐人 -> 推荐关系（nil 表示没有推荐人），过期条目由 go-cache 定期清理
+	states *gocache.Cache
+}
+
+// NewReferralService 创建推荐计划服务
+func NewReferralService(
+	referralRepo ReferralRepository,
+	settingRepo SettingRepository,
+	billingCache BillingCache,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+) *ReferralService {
+	return &ReferralService{
+		referralRepo:         referralRepo,
+		settingRepo:          settingRepo,
+		billingCache:         billingCache,
+		authCacheInvalidator: authCacheInvalidator,
+		states:               gocache.New(referralStateTTL, time.Minute),
+	}
+}
+
+// GetSettings 获取推荐计划配置
+func (s *ReferralService) GetSettings(ctx context.Context) (*ReferralSettings, error) {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyReferralSettings)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return DefaultReferralSettings(), nil
+		}
+		return nil, fmt.Errorf("get referral settings: %w", err)
+	}
+	settings := DefaultReferralSettings()
+	if value == "" {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(value), settings); err != nil {
+		return DefaultReferralSettings(), nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings 更新推荐计划配置（仅影响之后建立的推荐关系与之后的首充）
+func (s *ReferralService) UpdateSettings(ctx context.Context, settings *ReferralSettings) (*ReferralSettings, error) {
+	if err := validateReferralSettings(settings); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("marshal referral settings: %w", err)
+	}
+	if err := s.settingRepo.Set(ctx, SettingKeyReferralSettings, string(data)); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(ctx)
+}
+
+func validateReferralSettings(settings *ReferralSettings) error {
+	if settings == nil {
+		return ErrReferralSettingsInvalid
+	}
+	invalid := func(msg string) error {
+		return infraerrors.BadRequest("REFERRAL_SETTINGS_INVALID", msg)
+	}
+	if settings.FirstTopUpBonusUSD < 0 {
+		return invalid("first_topup_bonus_usd must be non-negative")
+	}
+	if settings.FirstTopUpBonusPercent < 0 || settings.FirstTopUpBonusPercent > 100 {
+		return invalid("first_topup_bonus_percent must be between 0 and 100")
+	}
+	if settings.CommissionPercent < 0 || settings.CommissionPercent > 100 {
+		return invalid("commission_percent must be between 0 and 100")
+	}
+	if settings.CommissionDays < 0 || settings.CommissionDays > referralCommissionMaxDays {
+		return invalid(fmt.Sprintf("commission_days must be between 0 and %d", referralCommissionMaxDays))
+	}
+	return nil
+}
+
+func generateReferralCode() (string, error) {
+	var sb strings.Builder
+	alphabetLen := big.NewInt(int64(len(referralCodeAlphabet)))
+	for i := 0; i < referralCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, alphabetLen)
+		if err != nil {
+			return "", fmt.Errorf("generate referral code: %w", err)
+		}
+		sb.WriteByte(referralCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+func normalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// GetOrCreateCode 获取用户推荐码，首次访问时生成
+func (s *ReferralService) GetOrCreateCode(ctx context.Context, userID int64) (string, error) {
+	code, err := s.referralRepo.GetCode(ctx, userID)
+	if err == nil {
+		return code, nil
+	}
+	if !errors.Is(err, ErrReferralCodeNotFound) {
+		return "", err
+	}
+	for attempt := 0; attempt < referralCodeMaxAttempts; attempt++ {
+		candidate, err := generateReferralCode()
+		if err != nil {
+			return "", err
+		}
+		if err := s.referralRepo.CreateCode(ctx, userID, candidate); err != nil {
+			if errors.Is(err, ErrReferralCodeConflict) {
+				continue
+			}
+			return "", err
+		}
+		// 并发请求可能已先生成，以库中记录为准
+		return s.referralRepo.GetCode(ctx, userID)
+	}
+	return "", ErrReferralCodeConflict
+}
+
+// BindReferral 注册时建立推荐关系，快照当前返佣比例与返佣期
+func (s *ReferralService) BindReferral(ctx context.Context, refereeUserID int64, code string) error {
+	code = normalizeReferralCode(code)
+	if code == "" {
+		return ErrReferralCodeInvalid
+	}
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled {
+		return ErrReferralDisabled
+	}
+	referrerID, err := s.referralRepo.GetUserIDByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, ErrReferralCodeNotFound) {
+			return ErrReferralCodeInvalid
+		}
+		return err
+	}
+	if referrerID == refereeUserID {
+		return ErrReferralSelf
+	}
+
+	now := time.Now()
+	referral := &Referral{
+		RefereeUserID:  refereeUserID,
+		ReferrerUserID: referrerID,
+		Code:           code,
+		CreatedAt:      now,
+	}
+	if settings.CommissionPercent > 0 && settings.CommissionDays > 0 {
+		until := now.AddDate(0, 0, settings.CommissionDays)
+		referral.CommissionPercent = settings.CommissionPercent
+		referral.CommissionUntil = &until
+	}
+	if err := s.referralRepo.Create(ctx, referral); err != nil {
+		return err
+	}
+	s.states.SetDefault(strconv.FormatInt(refereeUserID, 10), referral)
+	return nil
+}
+
+// getReferral 读取被推荐人的推荐关系（带本地缓存），没有推荐人时返回 nil
+func (s *ReferralService) getReferral(ctx context.Context, refereeUserID int64) (*Referral, error) {
+	key := strconv.FormatInt(refereeUserID, 10)
+	if v, ok := s.states.Get(key); ok {
+		return v.(*Referral), nil
+	}
+	referral, err := s.referralRepo.GetByReferee(ctx, refereeUserID)
+	if err != nil {
+		if !errors.Is(err, ErrReferralNotFound) {
+			return nil, err
+		}
+		referral = nil
+	}
+	s.states.SetDefault(key, referral)
+	return referral, nil
+}
+
+// RecordTopUp 余额充值后调用：被推荐人首次充值时为推荐人生成待审核的首充奖励
+func (s *ReferralService) RecordTopUp(ctx context.Context, userID int64, amount float64) {
+	if amount <= 0 {
+		return
+	}
+	referral, err := s.getReferral(ctx, userID)
+	if err != nil {
+		slog.Warn("referral_topup_lookup_failed", "user_id", userID, "error", err)
+		return
+	}
+	if referral == nil || referral.FirstTopUpAt != nil {
+		return
+	}
+	settings, err := s.GetSettings(ctx)
+	if err != nil || !settings.Enabled {
+		return
+	}
+
+	now := time.Now()
+	marked, err := s.referralRepo.MarkFirstTopUp(ctx, userID, now)
+	if err != nil {
+		slog.Warn("referral_first_topup_mark_failed", "user_id", userID, "error", err)
+		return
+	}
+	s.states.Delete(strconv.FormatInt(userID, 10))
+	if marked == nil {
+		return
+	}
+
+	bonus := settings.FirstTopUpBonusUSD + amount*settings.FirstTopUpBonusPercent/100
+	if bonus <= 0 {
+		return
+	}
+	commission := &ReferralCommission{
+		ReferrerUserID: marked.ReferrerUserID,
+		RefereeUserID:  userID,
+		Type:           ReferralCommissionTypeFirstTopUp,
+		SourceAmount:   amount,
+		Amount:         bonus,
+		PeriodDate:     timezone.StartOfDay(now).Format("2006-01-02"),
+		Status:         ReferralCommissionStatusPending,
+	}
+	if err := s.referralRepo.CreateCommission(ctx, commission); err != nil {
+		slog.Warn("referral_first_topup_commission_failed", "user_id", userID, "referrer_user_id", marked.ReferrerUserID, "error", err)
+	}
+}
+
+// RecordSpend 被推荐人余额扣费后调用：返佣期内按快照比例累加到当日待审核返佣
+func (s *ReferralService) RecordSpend(ctx context.Context, userID int64, cost float64) {
+	if cost <= 0 {
+		return
+	}
+	referral, err := s.getReferral(ctx, userID)
+	if err != nil {
+		slog.Warn("referral_spend_lookup_failed", "user_id", userID, "error", err)
+		return
+	}
+	now := time.Now()
+	if referral == nil || !referral.CommissionActive(now) {
+		return
+	}
+	settings, err := s.GetSettings(ctx)
+	if err != nil || !settings.Enabled {
+		return
+	}
+
+	amount := cost * referral.CommissionPercent / 100
+	periodDate := timezone.StartOfDay(now).Format("2006-01-02")
+	if err := s.referralRepo.AccrueSpendCommission(ctx, referral.ReferrerUserID, userID, cost, amount, periodDate); err != nil {
+		slog.Warn("referral_spend_commission_failed", "user_id", userID, "referrer_user_id", referral.ReferrerUserID, "error", err)
+	}
+}
+
+// GetDashboard 推荐人面板：推荐码、当前奖励规则与汇总
+func (s *ReferralService) GetDashboard(ctx context.Context, userID int64) (*ReferralDashboard, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	code, err := s.GetOrCreateCode(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.referralRepo.GetStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &ReferralDashboard{
+		Code:                   code,
+		LinkPath:               "/register?ref=" + code,
+		Enabled:                settings.Enabled,
+		FirstTopUpBonusUSD:     settings.FirstTopUpBonusUSD,
+		FirstTopUpBonusPercent: settings.FirstTopUpBonusPercent,
+		CommissionPercent:      settings.CommissionPercent,
+		CommissionDays:         settings.CommissionDays,
+		ReferralStats:          *stats,
+	}, nil
+}
+
+// ListReferees 推荐人查看自己推荐的用户（邮箱脱敏）
+func (s *ReferralService) ListReferees(ctx context.Context, userID int64, params pagination.PaginationParams) ([]ReferralReferee, *pagination.PaginationResult, error) {
+	referees, result, err := s.referralRepo.ListReferees(ctx, userID, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range referees {
+		referees[i].Email = MaskEmail(referees[i].Email)
+	}
+	return referees, result, nil
+}
+
+// ListUserCommissions 推荐人查看自己的返佣台账（被推荐人邮箱脱敏）
+func (s *ReferralService) ListUserCommissions(ctx context.Context, userID int64, status string, params pagination.PaginationParams) ([]ReferralCommission, *pagination.PaginationResult, error) {
+	if err := validateReferralCommissionStatus(status); err != nil {
+		return nil, nil, err
+	}
+	items, result, err := s.referralRepo.ListCommissions(ctx, ReferralCommissionFilter{ReferrerUserID: userID, Status: status}, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range items {
+		items[i].ReferrerEmail = ""
+		items[i].RefereeEmail = MaskEmail(items[i].RefereeEmail)
+		items[i].ReviewedBy = nil
+	}
+	return items, result, nil
+}
+
+func validateReferralCommissionStatus(status string) error {
+	switch status {
+	case "", ReferralCommissionStatusPending, ReferralCommissionStatusApproved, ReferralCommissionStatusRejected:
+		return nil
+	default:
+		return ErrReferralCommissionStatusParam
+	}
+}
+
+// ========== 管理员接口 ==========
+
+// AdminListCommissions 管理员查看返佣台账
+func (s *ReferralService) AdminListCommissions(ctx context.Context, filter ReferralCommissionFilter, params pagination.PaginationParams) ([]ReferralCommission, *pagination.PaginationResult, error) {
+	if err := validateReferralCommissionStatus(filter.Status); err != nil {
+		return nil, nil, err
+	}
+	return s.referralRepo.ListCommissions(ctx, filter, params)
+}
+
+// ApproveCommission 审核通过并入账到推荐人余额，同时写入余额变动记录（三者在同一语句内完成）
+func (s *ReferralService) ApproveCommission(ctx context.Context, id, reviewerID int64, notes string) (*ReferralCommission, error) {
+	code, err := GenerateRedeemCode()
+	if err != nil {
+		return nil, fmt.Errorf("generate referral reward code: %w", err)
+	}
+	commission, err := s.referralRepo.ApproveCommission(ctx, id, reviewerID, strings.TrimSpace(notes), code)
+	if err != nil {
+		return nil, err
+	}
+
+	referrerID := commission.ReferrerUserID
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, referrerID)
+	}
+	if s.billingCache != nil {
+		if err := s.billingCache.InvalidateUserBalance(ctx, referrerID); err != nil {
+			slog.Warn("referral_balance_cache_invalidate_failed", "user_id", referrerID, "error", err)
+		}
+	}
+	return commission, nil
+}
+
+// RejectCommission 驳回返佣
+func (s *ReferralService) RejectCommission(ctx context.Context, id, reviewerID int64, notes string) (*ReferralCommission, error) {
+	return s.referralRepo.RejectCommission(ctx, id, reviewerID, strings.TrimSpace(notes))
+}
@@ -0,0 +1,220 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// referralRepoStub 内存实现推荐关系与台账中测试用到的方法
+type referralRepoStub struct {
+	ReferralRepository
+
+	codes       map[string]int64
+	referrals   map[int64]*Referral
+	getCalls    int
+	commissions []*ReferralCommission
+	accrued     []float64
+	approved    *ReferralCommission
+	rewardCode  string
+}
+
+func newReferralRepoStub() *referralRepoStub {
+	return &referralRepoStub{
+		codes:     map[string]int64{},
+		referrals: map[int64]*Referral{},
+	}
+}
+
+func (s *referralRepoStub) GetUserIDByCode(ctx context.Context, code string) (int64, error) {
+	if id, ok := s.codes[code]; ok {
+		return id, nil
+	}
+	return 0, ErrReferralCodeNotFound
+}
+
+func (s *referralRepoStub) Create(ctx context.Context, referral *Referral) error {
+	if _, ok := s.referrals[referral.RefereeUserID]; ok {
+		return ErrReferralAlreadyBound
+	}
+	clone := *referral
+	s.referrals[referral.RefereeUserID] = &clone
+	return nil
+}
+
+func (s *referralRepoStub) GetByReferee(ctx context.Context, refereeUserID int64) (*Referral, error) {
+	s.getCalls++
+	if r, ok := s.referrals[refereeUserID]; ok {
+		clone := *r
+		return &clone, nil
+	}
+	return nil, ErrReferralNotFound
+}
+
+func (s *referralRepoStub) MarkFirstTopUp(ctx context.Context, refereeUserID int64, at time.Time) (*Referral, error) {
+	r, ok := s.referrals[refereeUserID]
+	if !ok || r.FirstTopUpAt != nil {
+		return nil, nil
+	}
+	r.FirstTopUpAt = &at
+	clone := *r
+	return &clone, nil
+}
+
+func (s *referralRepoStub) CreateCommission(ctx context.Context, commission *ReferralCommission) error {
+	clone := *commission
+	s.commissions = append(s.commissions, &clone)
+	return nil
+}
+
+func (s *referralRepoStub) AccrueSpendCommission(ctx context.Context, referrerUserID, refereeUserID int64, spend, amount float64, periodDate string) error {
+	s.accrued = append(s.accrued, amount)
+	return nil
+}
+
+func (s *referralRepoStub) ApproveCommission(ctx context.Context, id, reviewerID int64, notes string, rewardCode string) (*ReferralCommission, error) {
+	s.rewardCode = rewardCode
+	if s.approved == nil {
+		return nil, ErrReferralCommissionNotFound
+	}
+	s.approved.Status = ReferralCommissionStatusApproved
+	return s.approved, nil
+}
+
+func newTestReferralService(t *testing.T, repo *referralRepoStub, settings *ReferralSettings) *ReferralService {
+	t.Helper()
+	values := map[string]string{}
+	if settings != nil {
+		data, err := json.Marshal(settings)
+		require.NoError(t, err)
+		values[SettingKeyReferralSettings] = string(data)
+	}
+	return NewReferralService(repo, &settingRepoStub{values: values}, nil, nil)
+}
+
+func TestReferralService_GetSettings_DefaultsDisabled(t *testing.T) {
+	svc := newTestReferralService(t, newReferralRepoStub(), nil)
+	settings, err := svc.GetSettings(context.Background())
+	require.NoError(t, err)
+	require.False(t, settings.Enabled)
+}
+
+func TestValidateReferralSettings(t *testing.T) {
+	require.NoError(t, validateReferralSettings(&ReferralSettings{Enabled: true, CommissionPercent: 10, CommissionDays: 30}))
+	require.Error(t, validateReferralSettings(nil))
+	require.Error(t, validateReferralSettings(&ReferralSettings{FirstTopUpBonusUSD: -1}))
+	require.Error(t, validateReferralSettings(&ReferralSettings{CommissionPercent: 101}))
+	require.Error(t, validateReferralSettings(&ReferralSettings{FirstTopUpBonusPercent: -5}))
+	require.Error(t, validateReferralSettings(&ReferralSettings{CommissionDays: -1}))
+}
+
+func TestReferralService_BindReferral(t *testing.T) {
+	ctx := context.Background()
+	enabled := &ReferralSettings{Enabled: true, CommissionPercent: 10, CommissionDays: 30}
+
+	t.Run("disabled program rejects binding", func(t *testing.T) {
+		repo := newReferralRepoStub()
+		repo.codes["ABCD2345"] = 1
+		svc := newTestReferralService(t, repo, &ReferralSettings{})
+		require.ErrorIs(t, svc.BindReferral(ctx, 2, "ABCD2345"), ErrReferralDisabled)
+	})
+
+	t.Run("unknown code", func(t *testing.T) {
+		svc := newTestReferralService(t, newReferralRepoStub(), enabled)
+		require.ErrorIs(t, svc.BindReferral(ctx, 2, "NOPE"), ErrReferralCodeInvalid)
+	})
+
+	t.Run("self referral", func(t *testing.T) {
+		repo := newReferralRepoStub()
+		repo.codes["ABCD2345"] = 2
+		svc := newTestReferralService(t, repo, enabled)
+		require.ErrorIs(t, svc.BindReferral(ctx, 2, "ABCD2345"), ErrReferralSelf)
+	})
+
+	t.Run("snapshots commission terms and normalizes code", func(t *testing.T) {
+		repo := newReferralRepoStub()
+		repo.codes["ABCD2345"] = 1
+		svc := newTestReferralService(t, repo, enabled)
+		require.NoError(t, svc.BindReferral(ctx, 2, " abcd2345 "))
+
+		r := repo.referrals[2]
+		require.NotNil(t, r)
+		require.Equal(t, int64(1), r.ReferrerUserID)
+		require.Equal(t, 10.0, r.CommissionPercent)
+		require.NotNil(t, r.CommissionUntil)
+		require.WithinDuration(t, time.Now().AddDate(0, 0, 30), *r.CommissionUntil, time.Minute)
+	})
+}
+
+func TestReferralService_RecordTopUp_FirstOnly(t *testing.T) {
+	ctx := context.Background()
+	repo := newReferralRepoStub()
+	repo.referrals[2] = &Referral{RefereeUserID: 2, ReferrerUserID: 1}
+	svc := newTestReferralService(t, repo, &ReferralSettings{Enabled: true, FirstTopUpBonusUSD: 5, FirstTopUpBonusPercent: 10})
+
+	svc.RecordTopUp(ctx, 2, 100)
+	svc.RecordTopUp(ctx, 2, 100)
+
+	require.Len(t, repo.commissions, 1)
+	c := repo.commissions[0]
+	require.Equal(t, ReferralCommissionTypeFirstTopUp, c.Type)
+	require.Equal(t, ReferralCommissionStatusPending, c.Status)
+	require.Equal(t, int64(1), c.ReferrerUserID)
+	require.InDelta(t, 15.0, c.Amount, 1e-9)
+	require.InDelta(t, 100.0, c.SourceAmount, 1e-9)
+
+	// 没有推荐人的用户不产生奖励
+	svc.RecordTopUp(ctx, 3, 100)
+	require.Len(t, repo.commissions, 1)
+}
+
+func TestReferralService_RecordSpend(t *testing.T) {
+	ctx := context.Background()
+	future := time.Now().Add(24 * time.Hour)
+	past := time.Now().Add(-time.Hour)
+	repo := newReferralRepoStub()
+	repo.referrals[2] = &Referral{RefereeUserID: 2, ReferrerUserID: 1, CommissionPercent: 20, CommissionUntil: &future}
+	repo.referrals[3] = &Referral{RefereeUserID: 3, ReferrerUserID: 1, CommissionPercent: 20, CommissionUntil: &past}
+	svc := newTestReferralService(t, repo, &ReferralSettings{Enabled: true})
+
+	svc.RecordSpend(ctx, 2, 1.5)
+	svc.RecordSpend(ctx, 2, 0.5)
+	svc.RecordSpend(ctx, 3, 1)
+	svc.RecordSpend(ctx, 4, 1)
+	svc.RecordSpend(ctx, 4, 1)
+
+	require.Len(t, repo.accrued, 2)
+	require.InDelta(t, 0.3, repo.accrued[0], 1e-9)
+	require.InDelta(t, 0.1, repo.accrued[1], 1e-9)
+	// 推荐关系（包括"没有推荐人"）在本地缓存，不会每次计费都查库
+	require.Equal(t, 3, repo.getCalls)
+}
+
+func TestReferralService_RecordSpend_DisabledProgram(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+	repo := newReferralRepoStub()
+	repo.referrals[2] = &Referral{RefereeUserID: 2, ReferrerUserID: 1, CommissionPercent: 20, CommissionUntil: &future}
+	svc := newTestReferralService(t, repo, &ReferralSettings{Enabled: false})
+
+	svc.RecordSpend(context.Background(), 2, 1)
+	require.Empty(t, repo.accrued)
+}
+
+func TestReferralService_ApproveCommission_RecordsBalanceHistory(t *testing.T) {
+	repo := newReferralRepoStub()
+	repo.approved = &ReferralCommission{ID: 9, ReferrerUserID: 1, RefereeUserID: 2, Type: ReferralCommissionTypeSpend, Amount: 3.5, Status: ReferralCommissionStatusPending}
+	invalidator := &authCacheInvalidatorStub{}
+	svc := NewReferralService(repo, &settingRepoStub{}, nil, invalidator)
+
+	got, err := svc.ApproveCommission(context.Background(), 9, 100, "ok")
+	require.NoError(t, err)
+	require.Equal(t, ReferralCommissionStatusApproved, got.Status)
+	require.Equal(t, []int64{1}, invalidator.userIDs)
+	// 余额变动记录的兑换码随审核一起交给仓储，在同一语句内写入
+	require.Len(t, repo.rewardCode, 32)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 对账单主体类型
+const (
+	StatementSubjectUser         = "user"
+	StatementSubjectOrganization = "organization"
+)
+
+// 对账单导出格式
+const (
+	StatementFormatHTML = "html"
+	StatementFormatCSV  = "csv"
+	StatementFormatPDF  = "pdf"
+)
+
+var (
+	ErrStatementNotFound           = infraerrors.NotFound("STATEMENT_NOT_FOUND", "statement not found")
+	ErrStatementSubjectNotFound    = infraerrors.NotFound("STATEMENT_SUBJECT_NOT_FOUND", "statement subject not found")
+	ErrStatementSubjectTypeInvalid = infraerrors.BadRequest("STATEMENT_SUBJECT_TYPE_INVALID", "subject_type must be user or organization")
+	ErrStatementPeriodInvalid      = infraerrors.BadRequest("STATEMENT_PERIOD_INVALID", "period must be in YYYY-MM format")
+	ErrStatementPeriodOpen         = infraerrors.BadRequest("STATEMENT_PERIOD_OPEN", "statements are only available for closed months")
+	ErrStatementNotReady           = infraerrors.Conflict("STATEMENT_NOT_READY", "usage aggregation has not caught up with this period yet, please retry later")
+	ErrStatementFormatInvalid      = infraerrors.BadRequest("STATEMENT_FORMAT_INVALID", "format must be html, csv or pdf")
+	ErrStatementEmailUnavailable   = infraerrors.BadRequest("STATEMENT_EMAIL_UNAVAILABLE", "email queue is not configured")
+)
+
+// StatementLine 对账单用量明细（按 模型 + 分组 + 计费类型 汇总）
+type StatementLine struct {
+	Model               string  `json:"model"`
+	GroupID             int64   `json:"group_id"`
+	GroupName           string  `json:"group_name"`
+	BillingType         int8    `json:"billing_type"`
+	Requests            int64   `json:"requests"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	TotalCost           float64 `json:"total_cost"`
+	ActualCost          float64 `json:"actual_cost"`
+}
+
+// Statement 月度对账单快照
+//
+// 余额关系：ClosingBalance = OpeningBalance + TopUps + Adjustments - BalanceUsage。
+// 订阅模式的用量不影响余额，SubscriptionUsage 仅供参考。
+type Statement struct {
+	ID          int64     `json:"id"`
+	Number      string    `json:"number"`
+	SubjectType string    `json:"subject_type"`
+	SubjectID   int64     `json:"subject_id"`
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+
+	OpeningBalance    float64         `json:"opening_balance"`
+	TopUps            float64         `json:"top_ups"`
+	Adjustments       float64         `json:"adjustments"`
+	BalanceUsage      float64         `json:"balance_usage"`
+	SubscriptionUsage float64         `json:"subscription_usage"`
+	ClosingBalance    float64         `json:"closing_balance"`
+	Lines             []StatementLine `json:"lines"`
+
+	GeneratedAt time.Time  `json:"generated_at"`
+	EmailedAt   *time.Time `json:"emailed_at"`
+
+	// 以下字段不持久化，读取时由主体信息填充
+	SubjectName  string `json:"subject_name,omitempty"`
+	SubjectEmail string `json:"subject_email,omitempty"`
+}
+
+// StatementSubject 对账单主体的当前信息（组织的邮箱为所有者邮箱）
+type StatementSubject struct {
+	Name    string
+	Email   string
+	Balance float64
+}
+
+// StatementCredits 时间范围内的余额入账（正数）与扣减调整（负数）
+type StatementCredits struct {
+	TopUps      float64
+	Adjustments float64
+}
+
+// StatementFilter 管理员查询对账单的过滤条件
+type StatementFilter struct {
+	SubjectType string
+	SubjectID   int64
+	PeriodStart *time.Time
+}
+
+// StatementRepository 对账单快照及其数据源
+//
+// 日期参数均为配置时区下的 YYYY-MM-DD，与 usage_dashboard_user_daily.bucket_date 保持一致。
+type StatementRepository interface {
+	// Upsert 按 主体 + 账期 写入快照，已存在时覆盖金额与明细但保留编号，回填 ID / GeneratedAt
+	Upsert(ctx context.Context, statement *Statement) error
+	GetByID(ctx context.Context, id int64) (*Statement, error)
+	GetBySubjectPeriod(ctx context.Context, subjectType string, subjectID int64, periodStart time.Time) (*Statement, error)
+	List(ctx context.Context, filter StatementFilter, params pagination.PaginationParams) ([]Statement, *pagination.PaginationResult, error)
+	MarkEmailed(ctx context.Context, id int64, at time.Time) error
+
+	GetSubject(ctx context.Context, subjectType string, subjectID int64) (*StatementSubject, error)
+	// ListActiveSubjects 账期内有用量或余额变动的主体
+	ListActiveSubjects(ctx context.Context, subjectType string, start, end time.Time) ([]int64, error)
+	// GetUsageLines 按 [startDate, endDate) 从日聚合表汇总用量明细
+	GetUsageLines(ctx context.Context, subjectType string, subjectID int64, startDate, endDate string) ([]StatementLine, error)
+	// SumBalanceUsageSince 从 since（含）至今余额模式的实际扣费，直接读取 usage_logs，不受聚合延迟影响
+	SumBalanceUsageSince(ctx context.Context, subjectType string, subjectID int64, since time.Time) (float64, error)
+	// SumCredits 汇总 [start, end) 的余额入账与扣减调整，end 为 nil 表示至今
+	SumCredits(ctx context.Context, subjectType string, subjectID int64, start time.Time, end *time.Time) (*StatementCredits, error)
+}
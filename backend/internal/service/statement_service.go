@@ -0,0 +1,526 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pdf"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+const (
+	statementPeriodLayout = "2006-01"
+	statementDateLayout   = "2006-01-02"
+	// statementBulkEmailTimeout 批量发送（生成 + 入队）的整体超时
+	statementBulkEmailTimeout = time.Hour
+)
+
+// StatementService 月度对账单：基于日聚合表生成快照，支持 HTML / CSV / PDF 导出与批量邮件发送
+type StatementService struct {
+	repo                StatementRepository
+	aggRepo             DashboardAggregationRepository
+	organizationService *OrganizationService
+	emailQueue          *EmailQueueService
+	settingService      *SettingService
+}
+
+// NewStatementService 创建对账单服务
+func NewStatementService(
+	repo StatementRepository,
+	aggRepo DashboardAggregationRepository,
+	organizationService *OrganizationService,
+	emailQueue *EmailQueueService,
+	settingService *SettingService,
+) *StatementService {
+	return &StatementService{
+		repo:                repo,
+		aggRepo:             aggRepo,
+		organizationService: organizationService,
+		emailQueue:          emailQueue,
+		settingService:      settingService,
+	}
+}
+
+// ParseStatementPeriod 解析 YYYY-MM 账期（按配置时区），只允许已结束的月份
+func ParseStatementPeriod(period string, now time.Time) (time.Time, error) {
+	start, err := timezone.ParseInLocation(statementPeriodLayout, strings.TrimSpace(period))
+	if err != nil {
+		return time.Time{}, ErrStatementPeriodInvalid
+	}
+	if start.AddDate(0, 1, 0).After(now) {
+		return time.Time{}, ErrStatementPeriodOpen
+	}
+	return start, nil
+}
+
+func validateStatementSubjectType(subjectType string) error {
+	if subjectType != StatementSubjectUser && subjectType != StatementSubjectOrganization {
+		return ErrStatementSubjectTypeInvalid
+	}
+	return nil
+}
+
+// statementNumber 对账单编号由 账期 + 主体 决定，重复生成时保持不变
+func statementNumber(subjectType string, subjectID int64, periodStart time.Time) string {
+	prefix := "U"
+	if subjectType == StatementSubjectOrganization {
+		prefix = "O"
+	}
+	return fmt.Sprintf("ST-%s-%s%06d", periodStart.Format("200601"), prefix, subjectID)
+}
+
+func roundStatementAmount(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
+
+// ensureAggregated 日聚合水位未越过账期结束时间时拒绝生成，避免快照缺少账期末尾的用量
+func (s *StatementService) ensureAggregated(ctx context.Context, periodStart time.Time) error {
+	watermark, err := s.aggRepo.GetAggregationWatermark(ctx)
+	if err != nil {
+		return fmt.Errorf("get aggregation watermark: %w", err)
+	}
+	if watermark.Before(periodStart.AddDate(0, 1, 0)) {
+		return ErrStatementNotReady
+	}
+	return nil
+}
+
+// build 计算对账单
+//
+// 期末余额由当前余额倒推：减去账期结束后的入账/调整，加回账期结束后的余额扣费
+// （账期后扣费直接从 usage_logs 汇总，与实时扣减的余额保持一致）；
+// 期初余额 = 期末余额 - 账期内入账与调整 + 账期内余额扣费。
+func (s *StatementService) build(ctx context.Context, subjectType string, subjectID int64, periodStart time.Time) (*Statement, *StatementSubject, error) {
+	subject, err := s.repo.GetSubject(ctx, subjectType, subjectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	periodEnd := periodStart.AddDate(0, 1, 0)
+	startDate := periodStart.Format(statementDateLayout)
+	endDate := periodEnd.Format(statementDateLayout)
+
+	lines, err := s.repo.GetUsageLines(ctx, subjectType, subjectID, startDate, endDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load usage lines: %w", err)
+	}
+	credits, err := s.repo.SumCredits(ctx, subjectType, subjectID, periodStart, &periodEnd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sum period credits: %w", err)
+	}
+	creditsAfter, err := s.repo.SumCredits(ctx, subjectType, subjectID, periodEnd, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sum credits after period: %w", err)
+	}
+	usageAfter, err := s.repo.SumBalanceUsageSince(ctx, subjectType, subjectID, periodEnd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sum usage after period: %w", err)
+	}
+
+	st := &Statement{
+		Number:      statementNumber(subjectType, subjectID, periodStart),
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		Period:      periodStart.Format(statementPeriodLayout),
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		TopUps:      roundStatementAmount(credits.TopUps),
+		Adjustments: roundStatementAmount(credits.Adjustments),
+		Lines:       lines,
+	}
+	if st.Lines == nil {
+		st.Lines = []StatementLine{}
+	}
+	for _, line := range lines {
+		if line.BillingType == BillingTypeSubscription {
+			st.SubscriptionUsage += line.ActualCost
+		} else {
+			st.BalanceUsage += line.ActualCost
+		}
+	}
+	st.BalanceUsage = roundStatementAmount(st.BalanceUsage)
+	st.SubscriptionUsage = roundStatementAmount(st.SubscriptionUsage)
+	st.ClosingBalance = roundStatementAmount(subject.Balance - creditsAfter.TopUps - creditsAfter.Adjustments + usageAfter)
+	st.OpeningBalance = roundStatementAmount(st.ClosingBalance - st.TopUps - st.Adjustments + st.BalanceUsage)
+	return st, subject, nil
+}
+
+func (s *StatementService) generate(ctx context.Context, subjectType string, subjectID int64, periodStart time.Time) (*Statement, error) {
+	if err := s.ensureAggregated(ctx, periodStart); err != nil {
+		return nil, err
+	}
+	st, subject, err := s.build(ctx, subjectType, subjectID, periodStart)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Upsert(ctx, st); err != nil {
+		return nil, err
+	}
+	st.SubjectName = subject.Name
+	st.SubjectEmail = subject.Email
+	return st, nil
+}
+
+// getOrGenerate 已有快照直接返回（保证下载内容稳定），否则现场生成
+func (s *StatementService) getOrGenerate(ctx context.Context, subjectType string, subjectID int64, periodStart time.Time) (*Statement, error) {
+	st, err := s.repo.GetBySubjectPeriod(ctx, subjectType, subjectID, periodStart)
+	if err != nil {
+		if errors.Is(err, ErrStatementNotFound) {
+			return s.generate(ctx, subjectType, subjectID, periodStart)
+		}
+		return nil, err
+	}
+	return st, s.fillSubject(ctx, st)
+}
+
+func (s *StatementService) fillSubject(ctx context.Context, st *Statement) error {
+	subject, err := s.repo.GetSubject(ctx, st.SubjectType, st.SubjectID)
+	if err != nil {
+		return err
+	}
+	st.SubjectName = subject.Name
+	st.SubjectEmail = subject.Email
+	return nil
+}
+
+// ========== 用户接口 ==========
+
+// GetUserStatement 获取当前用户某个账期的对账单（不存在时生成）
+func (s *StatementService) GetUserStatement(ctx context.Context, userID int64, period string) (*Statement, error) {
+	periodStart, err := ParseStatementPeriod(period, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.getOrGenerate(ctx, StatementSubjectUser, userID, periodStart)
+}
+
+// ListUserStatements 列出当前用户已生成的对账单
+func (s *StatementService) ListUserStatements(ctx context.Context, userID int64, params pagination.PaginationParams) ([]Statement, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, StatementFilter{SubjectType: StatementSubjectUser, SubjectID: userID}, params)
+}
+
+// GetOrganizationStatement 获取组织对账单（owner / admin）
+func (s *StatementService) GetOrganizationStatement(ctx context.Context, orgID, userID int64, period string) (*Statement, error) {
+	if err := s.organizationService.CheckManager(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+	periodStart, err := ParseStatementPeriod(period, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.getOrGenerate(ctx, StatementSubjectOrganization, orgID, periodStart)
+}
+
+// ListOrganizationStatements 列出组织已生成的对账单（owner / admin）
+func (s *StatementService) ListOrganizationStatements(ctx context.Context, orgID, userID int64, params pagination.PaginationParams) ([]Statement, *pagination.PaginationResult, error) {
+	if err := s.organizationService.CheckManager(ctx, orgID, userID); err != nil {
+		return nil, nil, err
+	}
+	return s.repo.List(ctx, StatementFilter{SubjectType: StatementSubjectOrganization, SubjectID: orgID}, params)
+}
+
+// ========== 管理员接口 ==========
+
+// AdminList 管理员查询对账单
+func (s *StatementService) AdminList(ctx context.Context, filter StatementFilter, params pagination.PaginationParams) ([]Statement, *pagination.PaginationResult, error) {
+	if filter.SubjectType != "" {
+		if err := validateStatementSubjectType(filter.SubjectType); err != nil {
+			return nil, nil, err
+		}
+	}
+	return s.repo.List(ctx, filter, params)
+}
+
+// AdminGet 管理员获取对账单
+func (s *StatementService) AdminGet(ctx context.Context, id int64) (*Statement, error) {
+	st, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return st, s.fillSubject(ctx, st)
+}
+
+// AdminGenerate 管理员（重新）生成对账单，编号保持不变
+func (s *StatementService) AdminGenerate(ctx context.Context, subjectType string, subjectID int64, period string) (*Statement, error) {
+	if err := validateStatementSubjectType(subjectType); err != nil {
+		return nil, err
+	}
+	periodStart, err := ParseStatementPeriod(period, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.generate(ctx, subjectType, subjectID, periodStart)
+}
+
+// StartBulkEmail 为指定主体（为空时为账期内所有活跃主体）生成对账单并加入邮件队列，
+// 生成与入队在后台进行，返回待发送的主体数量
+func (s *StatementService) StartBulkEmail(ctx context.Context, subjectType, period string, subjectIDs []int64) (int, error) {
+	if s.emailQueue == nil {
+		return 0, ErrStatementEmailUnavailable
+	}
+	if err := validateStatementSubjectType(subjectType); err != nil {
+		return 0, err
+	}
+	periodStart, err := ParseStatementPeriod(period, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if err := s.ensureAggregated(ctx, periodStart); err != nil {
+		return 0, err
+	}
+	if len(subjectIDs) == 0 {
+		subjectIDs, err = s.repo.ListActiveSubjects(ctx, subjectType, periodStart, periodStart.AddDate(0, 1, 0))
+		if err != nil {
+			return 0, err
+		}
+	}
+	if len(subjectIDs) == 0 {
+		return 0, nil
+	}
+
+	go s.emailStatements(subjectType, periodStart, subjectIDs)
+	return len(subjectIDs), nil
+}
+
+func (s *StatementService) emailStatements(subjectType string, periodStart time.Time, subjectIDs []int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), statementBulkEmailTimeout)
+	defer cancel()
+
+	siteName := "Sub2API"
+	if s.settingService != nil {
+		siteName = s.settingService.GetSiteName(ctx)
+	}
+
+	var queued, skipped, failed int
+	for _, id := range subjectIDs {
+		st, err := s.getOrGenerate(ctx, subjectType, id, periodStart)
+		if err != nil {
+			failed++
+			log.Printf("[Statement] Generate %s %d for %s failed: %v", subjectType, id, periodStart.Format(statementPeriodLayout), err)
+			continue
+		}
+		if st.SubjectEmail == "" {
+			skipped++
+			continue
+		}
+		body, err := s.Render(st, StatementFormatHTML, siteName)
+		if err != nil {
+			failed++
+			log.Printf("[Statement] Render %s failed: %v", st.Number, err)
+			continue
+		}
+		subject := fmt.Sprintf("[%s] Statement %s (%s)", siteName, st.Number, st.Period)
+		if err := s.emailQueue.EnqueueStatement(ctx, st.SubjectEmail, subject, string(body)); err != nil {
+			log.Printf("[Statement] Enqueue %s failed, stopping bulk email: %v", st.Number, err)
+			failed += len(subjectIDs) - queued - skipped - failed
+			break
+		}
+		queued++
+		if err := s.repo.MarkEmailed(ctx, st.ID, time.Now()); err != nil {
+			log.Printf("[Statement] Mark %s emailed failed: %v", st.Number, err)
+		}
+	}
+	log.Printf("[Statement] Bulk email %s %s: queued=%d skipped=%d failed=%d",
+		subjectType, periodStart.Format(statementPeriodLayout), queued, skipped, failed)
+}
+
+// ========== 导出 ==========
+
+// StatementContentType 导出格式对应的 Content-Type
+func StatementContentType(format string) string {
+	switch format {
+	case StatementFormatCSV:
+		return "text/csv; charset=utf-8"
+	case StatementFormatPDF:
+		return "application/pdf"
+	default:
+		return "text/html; charset=utf-8"
+	}
+}
+
+// StatementFilename 下载文件名
+func StatementFilename(st *Statement, format string) string {
+	return st.Number + "." + format
+}
+
+// Render 按格式导出对账单
+func (s *StatementService) Render(st *Statement, format, siteName string) ([]byte, error) {
+	switch format {
+	case StatementFormatHTML:
+		return renderStatementHTML(st, siteName)
+	case StatementFormatCSV:
+		return renderStatementCSV(st)
+	case StatementFormatPDF:
+		return renderStatementPDF(st, siteName), nil
+	default:
+		return nil, ErrStatementFormatInvalid
+	}
+}
+
+// SiteName 导出时使用的站点名称
+func (s *StatementService) SiteName(ctx context.Context) string {
+	if s.settingService == nil {
+		return "Sub2API"
+	}
+	return s.settingService.GetSiteName(ctx)
+}
+
+func statementBillingLabel(billingType int8) string {
+	if billingType == BillingTypeSubscription {
+		return "subscription"
+	}
+	return "balance"
+}
+
+func statementGroupLabel(line StatementLine) string {
+	if line.GroupName != "" {
+		return line.GroupName
+	}
+	if line.GroupID == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("#%d", line.GroupID)
+}
+
+type statementSummaryRow struct {
+	Label string
+	Value string
+}
+
+func statementSummary(st *Statement) []statementSummaryRow {
+	return []statementSummaryRow{
+		{"Opening balance", fmt.Sprintf("%.2f", st.OpeningBalance)},
+		{"Top-ups", fmt.Sprintf("%.2f", st.TopUps)},
+		{"Adjustments", fmt.Sprintf("%.2f", st.Adjustments)},
+		{"Usage (balance)", fmt.Sprintf("%.4f", st.BalanceUsage)},
+		{"Closing balance", fmt.Sprintf("%.2f", st.ClosingBalance)},
+		{"Usage (subscription, informational)", fmt.Sprintf("%.4f", st.SubscriptionUsage)},
+	}
+}
+
+var statementHTMLTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"billing": statementBillingLabel,
+	"group":   statementGroupLabel,
+	"cost":    func(v float64) string { return fmt.Sprintf("%.4f", v) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>{{.St.Number}}</title></head>
+<body style="font-family: Arial, sans-serif; color: #333; max-width: 860px; margin: 0 auto; padding: 24px;">
+  <h2 style="margin-bottom: 4px;">{{.SiteName}} Statement</h2>
+  <p style="margin-top: 0; color: #666;">No. {{.St.Number}} &middot; Period {{.St.Period}} &middot; {{.St.SubjectName}}</p>
+  <table style="border-collapse: collapse; margin: 16px 0;">
+    {{range .Summary}}<tr><td style="padding: 4px 24px 4px 0;">{{.Label}}</td><td style="padding: 4px 0; text-align: right;">{{.Value}}</td></tr>
+    {{end}}
+  </table>
+  <table style="border-collapse: collapse; width: 100%; font-size: 13px;">
+    <tr style="background: #f5f5f5;">
+      <th style="text-align: left; padding: 6px;">Model</th>
+      <th style="text-align: left; padding: 6px;">Group</th>
+      <th style="text-align: left; padding: 6px;">Billing</th>
+      <th style="text-align: right; padding: 6px;">Requests</th>
+      <th style="text-align: right; padding: 6px;">Input</th>
+      <th style="text-align: right; padding: 6px;">Output</th>
+      <th style="text-align: right; padding: 6px;">Cache</th>
+      <th style="text-align: right; padding: 6px;">Cost</th>
+    </tr>
+    {{range .St.Lines}}<tr style="border-top: 1px solid #eee;">
+      <td style="padding: 6px;">{{.Model}}</td>
+      <td style="padding: 6px;">{{group .}}</td>
+      <td style="padding: 6px;">{{billing .BillingType}}</td>
+      <td style="text-align: right; padding: 6px;">{{.Requests}}</td>
+      <td style="text-align: right; padding: 6px;">{{.InputTokens}}</td>
+      <td style="text-align: right; padding: 6px;">{{.OutputTokens}}</td>
+      <td style="text-align: right; padding: 6px;">{{.CacheReadTokens}}</td>
+      <td style="text-align: right; padding: 6px;">{{cost .ActualCost}}</td>
+    </tr>
+    {{else}}<tr><td colspan="8" style="padding: 6px; color: #999;">No usage in this period.</td></tr>
+    {{end}}
+  </table>
+  <p style="color: #999; font-size: 12px; margin-top: 24px;">Amounts in USD. Generated at {{.St.GeneratedAt.Format "2006-01-02 15:04:05"}}.</p>
+</body>
+</html>
+`))
+
+func renderStatementHTML(st *Statement, siteName string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := statementHTMLTemplate.Execute(&buf, map[string]any{
+		"St":       st,
+		"SiteName": siteName,
+		"Summary":  statementSummary(st),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderStatementCSV(st *Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	rows := [][]string{
+		{"Statement", st.Number},
+		{"Period", st.Period},
+		{"Subject", st.SubjectType, fmt.Sprintf("%d", st.SubjectID), st.SubjectName},
+	}
+	for _, row := range statementSummary(st) {
+		rows = append(rows, []string{row.Label, row.Value})
+	}
+	rows = append(rows,
+		[]string{},
+		[]string{"model", "group_id", "group_name", "billing", "requests", "input_tokens", "output_tokens", "cache_creation_tokens", "cache_read_tokens", "total_cost", "actual_cost"},
+	)
+	for _, line := range st.Lines {
+		rows = append(rows, []string{
+			line.Model,
+			fmt.Sprintf("%d", line.GroupID),
+			line.GroupName,
+			statementBillingLabel(line.BillingType),
+			fmt.Sprintf("%d", line.Requests),
+			fmt.Sprintf("%d", line.InputTokens),
+			fmt.Sprintf("%d", line.OutputTokens),
+			fmt.Sprintf("%d", line.CacheCreationTokens),
+			fmt.Sprintf("%d", line.CacheReadTokens),
+			fmt.Sprintf("%.10f", line.TotalCost),
+			fmt.Sprintf("%.10f", line.ActualCost),
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderStatementPDF(st *Statement, siteName string) []byte {
+	doc := pdf.New()
+	doc.Line(siteName+" Statement", 16, true)
+	doc.Line(fmt.Sprintf("No. %s    Period %s", st.Number, st.Period), 10, false)
+	doc.Line(fmt.Sprintf("%s #%d  %s", st.SubjectType, st.SubjectID, st.SubjectName), 10, false)
+	doc.Blank(10)
+	for _, row := range statementSummary(st) {
+		doc.Line(fmt.Sprintf("%-38s %14s", row.Label, row.Value), 10, false)
+	}
+	doc.Blank(10)
+	doc.Line(fmt.Sprintf("%-28s %-14s %-5s %9s %12s %12s %10s", "Model", "Group", "Bill", "Requests", "Input", "Output", "Cost"), 8, true)
+	for _, line := range st.Lines {
+		billing := "bal"
+		if line.BillingType == BillingTypeSubscription {
+			billing = "sub"
+		}
+		doc.Line(fmt.Sprintf("%-28.28s %-14.14s %-5s %9d %12d %12d %10.4f",
+			line.Model, statementGroupLabel(line), billing, line.Requests, line.InputTokens, line.OutputTokens, line.ActualCost), 8, false)
+	}
+	if len(st.Lines) == 0 {
+		doc.Line("No usage in this period.", 8, false)
+	}
+	doc.Blank(8)
+	doc.Line("Amounts in USD. Generated at "+st.GeneratedAt.Format("2006-01-02 15:04:05")+".", 8, false)
+	return doc.Bytes()
+}
@@ -0,0 +1,228 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/stretchr/testify/require"
+)
+
+// statementRepoStub 内存实现对账单仓储，数据源按参数返回固定值
+type statementRepoStub struct {
+	StatementRepository
+
+	subject      *StatementSubject
+	lines        []StatementLine
+	periodCredit StatementCredits
+	afterCredit  StatementCredits
+	usageAfter   float64
+
+	saved   map[string]*Statement
+	upserts int
+	nextID  int64
+}
+
+func newStatementRepoStub() *statementRepoStub {
+	return &statementRepoStub{
+		subject: &StatementSubject{Name: "alice", Email: "alice@example.com"},
+		saved:   map[string]*Statement{},
+	}
+}
+
+func (s *statementRepoStub) key(subjectType string, subjectID int64, periodStart time.Time) string {
+	return statementNumber(subjectType, subjectID, periodStart)
+}
+
+func (s *statementRepoStub) Upsert(ctx context.Context, st *Statement) error {
+	s.upserts++
+	k := s.key(st.SubjectType, st.SubjectID, st.PeriodStart)
+	if existing, ok := s.saved[k]; ok {
+		st.ID = existing.ID
+	} else {
+		s.nextID++
+		st.ID = s.nextID
+	}
+	st.GeneratedAt = time.Now()
+	clone := *st
+	s.saved[k] = &clone
+	return nil
+}
+
+func (s *statementRepoStub) GetBySubjectPeriod(ctx context.Context, subjectType string, subjectID int64, periodStart time.Time) (*Statement, error) {
+	if st, ok := s.saved[s.key(subjectType, subjectID, periodStart)]; ok {
+		clone := *st
+		return &clone, nil
+	}
+	return nil, ErrStatementNotFound
+}
+
+func (s *statementRepoStub) GetSubject(ctx context.Context, subjectType string, subjectID int64) (*StatementSubject, error) {
+	if s.subject == nil {
+		return nil, ErrStatementSubjectNotFound
+	}
+	clone := *s.subject
+	return &clone, nil
+}
+
+func (s *statementRepoStub) GetUsageLines(ctx context.Context, subjectType string, subjectID int64, startDate, endDate string) ([]StatementLine, error) {
+	return s.lines, nil
+}
+
+func (s *statementRepoStub) SumBalanceUsageSince(ctx context.Context, subjectType string, subjectID int64, since time.Time) (float64, error) {
+	return s.usageAfter, nil
+}
+
+func (s *statementRepoStub) SumCredits(ctx context.Context, subjectType string, subjectID int64, start time.Time, end *time.Time) (*StatementCredits, error) {
+	if end == nil {
+		c := s.afterCredit
+		return &c, nil
+	}
+	c := s.periodCredit
+	return &c, nil
+}
+
+// statementAggregatedStub 日聚合水位已追上当前时间
+func statementAggregatedStub() *dashboardAggregationRepoStub {
+	return &dashboardAggregationRepoStub{watermark: time.Now()}
+}
+
+func TestParseStatementPeriod(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, timezone.Location())
+
+	start, err := ParseStatementPeriod("2026-09", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, timezone.Location()), start)
+
+	_, err = ParseStatementPeriod("2026-10", now)
+	require.ErrorIs(t, err, ErrStatementPeriodOpen)
+
+	_, err = ParseStatementPeriod("2026/09", now)
+	require.ErrorIs(t, err, ErrStatementPeriodInvalid)
+}
+
+func TestStatementNumber_Stable(t *testing.T) {
+	period := time.Date(2026, 9, 1, 0, 0, 0, 0, timezone.Location())
+	require.Equal(t, "ST-202609-U000042", statementNumber(StatementSubjectUser, 42, period))
+	require.Equal(t, "ST-202609-O000007", statementNumber(StatementSubjectOrganization, 7, period))
+}
+
+func TestStatementService_Build_ReconstructsBalances(t *testing.T) {
+	repo := newStatementRepoStub()
+	// 当前余额 50；账期后又充值 20、扣减 5，账期后余额扣费 3
+	repo.subject.Balance = 50
+	repo.afterCredit = StatementCredits{TopUps: 20, Adjustments: -5}
+	repo.usageAfter = 3
+	// 账期内充值 100、划转到组织 10；余额用量 12.5，订阅用量 7
+	repo.periodCredit = StatementCredits{TopUps: 100, Adjustments: -10}
+	repo.lines = []StatementLine{
+		{Model: "claude-sonnet-4", BillingType: BillingTypeBalance, ActualCost: 10},
+		{Model: "gpt-5", BillingType: BillingTypeBalance, ActualCost: 2.5},
+		{Model: "claude-opus-4", BillingType: BillingTypeSubscription, ActualCost: 7},
+	}
+	svc := NewStatementService(repo, statementAggregatedStub(), nil, nil, nil)
+
+	period := time.Date(2026, 9, 1, 0, 0, 0, 0, timezone.Location())
+	st, _, err := svc.build(context.Background(), StatementSubjectUser, 1, period)
+	require.NoError(t, err)
+
+	require.Equal(t, "2026-09", st.Period)
+	require.Equal(t, period.AddDate(0, 1, 0), st.PeriodEnd)
+	require.InDelta(t, 12.5, st.BalanceUsage, 1e-9)
+	require.InDelta(t, 7.0, st.SubscriptionUsage, 1e-9)
+	// 期末 = 50 - 20 + 5 + 3 = 38；期初 = 38 - 100 + 10 + 12.5 = -39.5
+	require.InDelta(t, 38.0, st.ClosingBalance, 1e-9)
+	require.InDelta(t, -39.5, st.OpeningBalance, 1e-9)
+	require.InDelta(t, st.ClosingBalance, st.OpeningBalance+st.TopUps+st.Adjustments-st.BalanceUsage, 1e-9)
+}
+
+func TestStatementService_GetUserStatement_ReusesSnapshot(t *testing.T) {
+	repo := newStatementRepoStub()
+	repo.subject.Balance = 10
+	svc := NewStatementService(repo, statementAggregatedStub(), nil, nil, nil)
+	ctx := context.Background()
+
+	first, err := svc.GetUserStatement(ctx, 1, "2020-01")
+	require.NoError(t, err)
+	require.Equal(t, "alice@example.com", first.SubjectEmail)
+
+	// 余额变化后再次下载仍返回首次生成的快照
+	repo.subject.Balance = 99
+	second, err := svc.GetUserStatement(ctx, 1, "2020-01")
+	require.NoError(t, err)
+	require.Equal(t, 1, repo.upserts)
+	require.Equal(t, first.Number, second.Number)
+	require.Equal(t, first.ClosingBalance, second.ClosingBalance)
+
+	// 管理员重新生成保持编号不变
+	regenerated, err := svc.AdminGenerate(ctx, StatementSubjectUser, 1, "2020-01")
+	require.NoError(t, err)
+	require.Equal(t, first.ID, regenerated.ID)
+	require.Equal(t, first.Number, regenerated.Number)
+	require.InDelta(t, 99.0, regenerated.ClosingBalance, 1e-9)
+}
+
+func TestStatementService_RefusesUntilAggregated(t *testing.T) {
+	repo := newStatementRepoStub()
+	period := time.Date(2026, 9, 1, 0, 0, 0, 0, timezone.Location())
+	// 水位停在账期最后一天，账期末尾的用量尚未聚合
+	agg := &dashboardAggregationRepoStub{watermark: period.AddDate(0, 1, 0).Add(-time.Hour)}
+	svc := NewStatementService(repo, agg, nil, nil, nil)
+	ctx := context.Background()
+
+	_, err := svc.getOrGenerate(ctx, StatementSubjectUser, 1, period)
+	require.ErrorIs(t, err, ErrStatementNotReady)
+	require.Zero(t, repo.upserts)
+
+	agg.watermark = period.AddDate(0, 1, 0)
+	st, err := svc.getOrGenerate(ctx, StatementSubjectUser, 1, period)
+	require.NoError(t, err)
+	require.Equal(t, "2026-09", st.Period)
+	require.Equal(t, 1, repo.upserts)
+}
+
+func TestStatementService_StartBulkEmail_RequiresQueue(t *testing.T) {
+	svc := NewStatementService(newStatementRepoStub(), statementAggregatedStub(), nil, nil, nil)
+	_, err := svc.StartBulkEmail(context.Background(), StatementSubjectUser, "2020-01", []int64{1})
+	require.ErrorIs(t, err, ErrStatementEmailUnavailable)
+}
+
+func TestStatementService_Render(t *testing.T) {
+	st := &Statement{
+		Number:         "ST-202609-U000001",
+		SubjectType:    StatementSubjectUser,
+		SubjectID:      1,
+		SubjectName:    "<alice>",
+		Period:         "2026-09",
+		ClosingBalance: 38,
+		Lines: []StatementLine{
+			{Model: "claude-sonnet-4", GroupID: 3, GroupName: "默认分组", Requests: 12, ActualCost: 1.25},
+		},
+		GeneratedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+	svc := NewStatementService(newStatementRepoStub(), statementAggregatedStub(), nil, nil, nil)
+
+	html, err := svc.Render(st, StatementFormatHTML, "Sub2API")
+	require.NoError(t, err)
+	require.Contains(t, string(html), "ST-202609-U000001")
+	require.Contains(t, string(html), "&lt;alice&gt;")
+	require.Contains(t, string(html), "默认分组")
+
+	csvData, err := svc.Render(st, StatementFormatCSV, "Sub2API")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(csvData), "Statement,ST-202609-U000001\n"))
+	require.Contains(t, string(csvData), "claude-sonnet-4,3,默认分组,balance,12,")
+
+	pdfData, err := svc.Render(st, StatementFormatPDF, "Sub2API")
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdfData, []byte("%PDF-")))
+
+	_, err = svc.Render(st, "xlsx", "Sub2API")
+	require.ErrorIs(t, err, ErrStatementFormatInvalid)
+	require.Equal(t, "ST-202609-U000001.pdf", StatementFilename(st, StatementFormatPDF))
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+const (
+	UsageExportStatusPending   = "pending"
+	UsageExportStatusRunning   = "running"
+	UsageExportStatusSucceeded = "succeeded"
+	UsageExportStatusFailed    = "failed"
+	UsageExportStatusCanceled  = "canceled"
+)
+
+// 导出范围：管理员导出任意用户的记录，用户只能导出自己的记录（不含账号、IP 等管理员字段）
+const (
+	UsageExportScopeAdmin = "admin"
+	UsageExportScopeUser  = "user"
+)
+
+// 导出格式
+const (
+	UsageExportFormatCSV     = "csv"
+	UsageExportFormatNDJSON  = "ndjson"
+	UsageExportFormatParquet = "parquet"
+)
+
+var (
+	ErrUsageExportDisabled       = infraerrors.New(http.StatusServiceUnavailable, "USAGE_EXPORT_DISABLED", "usage export is disabled")
+	ErrUsageExportTaskNotFound   = infraerrors.NotFound("USAGE_EXPORT_TASK_NOT_FOUND", "export task not found")
+	ErrUsageExportFormatInvalid  = infraerrors.BadRequest("USAGE_EXPORT_FORMAT_INVALID", "format must be csv, ndjson or parquet")
+	ErrUsageExportMissingRange   = infraerrors.BadRequest("USAGE_EXPORT_MISSING_RANGE", "start_date and end_date are required")
+	ErrUsageExportInvalidRange   = infraerrors.BadRequest("USAGE_EXPORT_INVALID_RANGE", "end_date must be after start_date")
+	ErrUsageExportRangeTooLarge  = infraerrors.BadRequest("USAGE_EXPORT_RANGE_TOO_LARGE", "date range is too large")
+	ErrUsageExportTooManyTasks   = infraerrors.TooManyRequests("USAGE_EXPORT_TOO_MANY_TASKS", "too many export tasks in progress")
+	ErrUsageExportCancelConflict = infraerrors.Conflict("USAGE_EXPORT_CANCEL_CONFLICT", "export task cannot be canceled in current status")
+	ErrUsageExportNotReady       = infraerrors.Conflict("USAGE_EXPORT_NOT_READY", "export file is not ready")
+	ErrUsageExportLinkExpired    = infraerrors.New(http.StatusGone, "USAGE_EXPORT_LINK_EXPIRED", "download link has expired")
+)
+
+// UsageExportTask 使用记录导出任务
+// 状态包含 pending/running/succeeded/failed/canceled；下载链接在 ExpiresAt 之后失效
+type UsageExportTask struct {
+	ID            int64
+	Scope         string
+	Format        string
+	Status        string
+	Filters       usagestats.UsageLogFilters
+	CreatedBy     int64
+	TotalRows     int64
+	ExportedRows  int64
+	FilePath      string
+	FileSize      int64
+	DownloadToken string
+	ErrorMsg      *string
+	CanceledBy    *int64
+	CanceledAt    *time.Time
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+	ExpiresAt     *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Downloadable 文件已生成且链接未过期
+func (t *UsageExportTask) Downloadable(now time.Time) bool {
+	return t.Status == UsageExportStatusSucceeded && t.FilePath != "" && t.ExpiresAt != nil && now.Before(*t.ExpiresAt)
+}
+
+// UsageExportTaskFilter 任务列表查询条件；CreatedBy 为 0 表示不限创建者
+type UsageExportTaskFilter struct {
+	Scope     string
+	CreatedBy int64
+}
+
+// UsageExportRepository 定义导出任务持久层接口
+type UsageExportRepository interface {
+	CreateTask(ctx context.Context, task *UsageExportTask) error
+	ListTasks(ctx context.Context, filter UsageExportTaskFilter, params pagination.PaginationParams) ([]UsageExportTask, *pagination.PaginationResult, error)
+	GetTask(ctx context.Context, taskID int64) (*UsageExportTask, error)
+	GetTaskByToken(ctx context.Context, token string) (*UsageExportTask, error)
+	// CountActiveTasks 统计用户 pending/running 状态的任务数
+	CountActiveTasks(ctx context.Context, createdBy int64) (int, error)
+	// ClaimNextPendingTask 抢占下一条可执行任务（同 UsageCleanupRepository），抢占后进度清零从头导出
+	ClaimNextPendingTask(ctx context.Context, staleRunningAfterSeconds int64) (*UsageExportTask, error)
+	// GetTaskStatus 查询任务状态；若不存在返回 sql.ErrNoRows
+	GetTaskStatus(ctx context.Context, taskID int64) (string, error)
+	UpdateTaskProgress(ctx context.Context, taskID int64, totalRows, exportedRows int64) error
+	// CancelTask 将任务标记为 canceled（仅允许 pending/running）
+	CancelTask(ctx context.Context, taskID int64, canceledBy int64) (bool, error)
+	MarkTaskSucceeded(ctx context.Context, taskID int64, exportedRows int64, filePath string, fileSize int64, expiresAt time.Time) error
+	MarkTaskFailed(ctx context.Context, taskID int64, exportedRows int64, errorMsg string) error
+	// PurgeExpiredFiles 清空已过期任务的 file_path 并返回原路径，由调用方删除文件
+	PurgeExpiredFiles(ctx context.Context, now time.Time) ([]string, error)
+
+	CountUsageLogs(ctx context.Context, filters usagestats.UsageLogFilters) (int64, error)
+	// StreamUsageLogs 通过只读事务内的服务端游标按 id 升序分批读取使用记录；fn 返回错误时中止
+	StreamUsageLogs(ctx context.Context, filters usagestats.UsageLogFilters, batchSize int, fn func([]UsageLog) error) error
+}
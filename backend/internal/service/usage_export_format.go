@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/parquet"
+)
+
+// usageExportColumn 导出列定义，三种格式共用同一列顺序
+type usageExportColumn struct {
+	name      string
+	typ       parquet.Type
+	optional  bool
+	adminOnly bool
+	value     func(*UsageLog) any
+}
+
+func optionalInt64(v *int64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func optionalInt(v *int) any {
+	if v == nil {
+		return nil
+	}
+	return int64(*v)
+}
+
+func optionalFloat(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func optionalString(v *string) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+var usageExportAllColumns = []usageExportColumn{
+	{name: "id", typ: parquet.Int64, value: func(l *UsageLog) any { return l.ID }},
+	{name: "created_at", typ: parquet.Timestamp, value: func(l *UsageLog) any { return l.CreatedAt.UTC() }},
+	{name: "request_id", typ: parquet.String, value: func(l *UsageLog) any { return l.RequestID }},
+	{name: "user_id", typ: parquet.Int64, value: func(l *UsageLog) any { return l.UserID }},
+	{name: "api_key_id", typ: parquet.Int64, value: func(l *UsageLog) any { return l.APIKeyID }},
+	{name: "account_id", typ: parquet.Int64, adminOnly: true, value: func(l *UsageLog) any { return l.AccountID }},
+	{name: "organization_id", typ: parquet.Int64, optional: true, value: func(l *UsageLog) any { return optionalInt64(l.OrganizationID) }},
+	{name: "group_id", typ: parquet.Int64, optional: true, value: func(l *UsageLog) any { return optionalInt64(l.GroupID) }},
+	{name: "subscription_id", typ: parquet.Int64, optional: true, value: func(l *UsageLog) any { return optionalInt64(l.SubscriptionID) }},
+	{name: "model", typ: parquet.String, value: func(l *UsageLog) any { return l.Model }},
+	{name: "requested_model", typ: parquet.String, optional: true, value: func(l *UsageLog) any { return optionalString(l.RequestedModel) }},
+	{name: "reasoning_effort", typ: parquet.String, optional: true, value: func(l *UsageLog) any { return optionalString(l.ReasoningEffort) }},
+	{name: "billing_type", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.BillingType) }},
+	{name: "stream", typ: parquet.Boolean, value: func(l *UsageLog) any { return l.Stream }},
+	{name: "input_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.InputTokens) }},
+	{name: "output_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.OutputTokens) }},
+	{name: "cache_creation_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.CacheCreationTokens) }},
+	{name: "cache_read_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.CacheReadTokens) }},
+	{name: "cache_creation_5m_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.CacheCreation5mTokens) }},
+	{name: "cache_creation_1h_tokens", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.CacheCreation1hTokens) }},
+	{name: "input_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.InputCost }},
+	{name: "output_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.OutputCost }},
+	{name: "cache_creation_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.CacheCreationCost }},
+	{name: "cache_read_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.CacheReadCost }},
+	{name: "total_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.TotalCost }},
+	{name: "actual_cost", typ: parquet.Double, value: func(l *UsageLog) any { return l.ActualCost }},
+	{name: "rate_multiplier", typ: parquet.Double, value: func(l *UsageLog) any { return l.RateMultiplier }},
+	{name: "account_rate_multiplier", typ: parquet.Double, optional: true, adminOnly: true, value: func(l *UsageLog) any { return optionalFloat(l.AccountRateMultiplier) }},
+	{name: "duration_ms", typ: parquet.Int64, optional: true, value: func(l *UsageLog) any { return optionalInt(l.DurationMs) }},
+	{name: "first_token_ms", typ: parquet.Int64, optional: true, value: func(l *UsageLog) any { return optionalInt(l.FirstTokenMs) }},
+	{name: "image_count", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.ImageCount) }},
+	{name: "image_size", typ: parquet.String, optional: true, value: func(l *UsageLog) any { return optionalString(l.ImageSize) }},
+	{name: "key_generation", typ: parquet.Int64, value: func(l *UsageLog) any { return int64(l.KeyGeneration) }},
+	{name: "tags", typ: parquet.String, optional: true, value: func(l *UsageLog) any {
+		if len(l.Tags) == 0 {
+			return nil
+		}
+		raw, _ := json.Marshal(l.Tags)
+		return string(raw)
+	}},
+	{name: "user_agent", typ: parquet.String, optional: true, value: func(l *UsageLog) any { return optionalString(l.UserAgent) }},
+	{name: "ip_address", typ: parquet.String, optional: true, adminOnly: true, value: func(l *UsageLog) any { return optionalString(l.IPAddress) }},
+}
+
+// usageExportColumns 返回导出范围可见的列
+func usageExportColumns(scope string) []usageExportColumn {
+	out := make([]usageExportColumn, 0, len(usageExportAllColumns))
+	for _, col := range usageExportAllColumns {
+		if col.adminOnly && scope != UsageExportScopeAdmin {
+			continue
+		}
+		out = append(out, col)
+	}
+	return out
+}
+
+func usageExportRow(columns []usageExportColumn, l *UsageLog) []any {
+	row := make([]any, len(columns))
+	for i, col := range columns {
+		row[i] = col.value(l)
+	}
+	return row
+}
+
+// usageExportEncoder 逐行写出导出文件
+type usageExportEncoder interface {
+	WriteRow(row []any) error
+	Close() error
+}
+
+// UsageExportContentType 导出文件的 Content-Type
+func UsageExportContentType(format string) string {
+	switch format {
+	case UsageExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case UsageExportFormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+func newUsageExportEncoder(format string, w io.Writer, columns []usageExportColumn) (usageExportEncoder, error) {
+	switch format {
+	case UsageExportFormatCSV:
+		return newUsageExportCSVEncoder(w, columns)
+	case UsageExportFormatNDJSON:
+		return &usageExportNDJSONEncoder{w: bufio.NewWriterSize(w, 64<<10), columns: columns}, nil
+	case UsageExportFormatParquet:
+		defs := make([]parquet.Column, len(columns))
+		for i, col := range columns {
+			defs[i] = parquet.Column{Name: col.name, Type: col.typ, Optional: col.optional}
+		}
+		pw, err := parquet.NewWriter(w, defs, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &usageExportParquetEncoder{w: pw}, nil
+	default:
+		return nil, ErrUsageExportFormatInvalid
+	}
+}
+
+type usageExportCSVEncoder struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newUsageExportCSVEncoder(w io.Writer, columns []usageExportColumn) (*usageExportCSVEncoder, error) {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.name
+	}
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &usageExportCSVEncoder{w: cw, record: make([]string, len(columns))}, nil
+}
+
+func (e *usageExportCSVEncoder) WriteRow(row []any) error {
+	for i, v := range row {
+		e.record[i] = formatUsageExportCSVValue(v)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *usageExportCSVEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatUsageExportCSVValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case string:
+		// User-Agent 等字段由客户端提供，避免被表格软件当作公式执行
+		if val != "" && strings.ContainsRune("=+-@\t\r", rune(val[0])) {
+			return "'" + val
+		}
+		return val
+	default:
+		return ""
+	}
+}
+
+// usageExportNDJSONEncoder 每行一个 JSON 对象，字段顺序与列定义一致
+type usageExportNDJSONEncoder struct {
+	w       *bufio.Writer
+	columns []usageExportColumn
+}
+
+func (e *usageExportNDJSONEncoder) WriteRow(row []any) error {
+	if err := e.w.WriteByte('{'); err != nil {
+		return err
+	}
+	for i, v := range row {
+		if i > 0 {
+			_ = e.w.WriteByte(',')
+		}
+		_, _ = e.w.WriteString(strconv.Quote(e.columns[i].name))
+		_ = e.w.WriteByte(':')
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := e.w.Write(raw); err != nil {
+			return err
+		}
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *usageExportNDJSONEncoder) Close() error {
+	return e.w.Flush()
+}
+
+type usageExportParquetEncoder struct {
+	w *parquet.Writer
+}
+
+func (e *usageExportParquetEncoder) WriteRow(row []any) error {
+	return e.w.Write(row)
+}
+
+func (e *usageExportParquetEncoder) Close() error {
+	return e.w.Close()
+}
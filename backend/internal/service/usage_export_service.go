@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+const (
+	usageExportWorkerName = "usage_export_worker"
+)
+
+// errUsageExportCanceled 执行过程中检测到任务被取消
+var errUsageExportCanceled = errors.New("usage export canceled")
+
+// UsageExportService 负责创建与执行使用记录导出任务
+// 任务执行方式与 UsageCleanupService 相同：定时轮询抢占 pending 任务，超时未完成的 running 任务允许重新抢占。
+type UsageExportService struct {
+	repo        UsageExportRepository
+	timingWheel *TimingWheelService
+	cfg         *config.Config
+
+	running   int32
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+func NewUsageExportService(repo UsageExportRepository, timingWheel *TimingWheelService, cfg *config.Config) *UsageExportService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	return &UsageExportService{
+		repo:         repo,
+		timingWheel:  timingWheel,
+		cfg:          cfg,
+		workerCtx:    workerCtx,
+		workerCancel: workerCancel,
+	}
+}
+
+func (s *UsageExportService) Start() {
+	if s == nil {
+		return
+	}
+	if s.cfg != nil && !s.cfg.UsageExport.Enabled {
+		log.Printf("[UsageExport] not started (disabled)")
+		return
+	}
+	if s.repo == nil || s.timingWheel == nil {
+		log.Printf("[UsageExport] not started (missing deps)")
+		return
+	}
+
+	interval := s.workerInterval()
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(usageExportWorkerName, interval, s.runOnce)
+		log.Printf("[UsageExport] started (interval=%s dir=%s batch_size=%d task_timeout=%s link_ttl=%s)", interval, s.dir(), s.batchSize(), s.taskTimeout(), s.linkTTL())
+	})
+}
+
+func (s *UsageExportService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(usageExportWorkerName)
+		}
+		log.Printf("[UsageExport] stopped")
+	})
+}
+
+// CreateTask 创建导出任务；用户范围的任务由调用方保证 filters.UserID 为当前用户
+func (s *UsageExportService) CreateTask(ctx context.Context, scope, format string, filters usagestats.UsageLogFilters, createdBy int64) (*UsageExportTask, error) {
+	if s == nil || s.repo == nil {
+		return nil, fmt.Errorf("export service not ready")
+	}
+	if s.cfg != nil && !s.cfg.UsageExport.Enabled {
+		return nil, ErrUsageExportDisabled
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format != UsageExportFormatCSV && format != UsageExportFormatNDJSON && format != UsageExportFormatParquet {
+		return nil, ErrUsageExportFormatInvalid
+	}
+	if err := s.validateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	active, err := s.repo.CountActiveTasks(ctx, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	if active >= s.maxActiveTasksPerUser() {
+		return nil, ErrUsageExportTooManyTasks
+	}
+
+	token, err := generateUsageExportToken()
+	if err != nil {
+		return nil, err
+	}
+	task := &UsageExportTask{
+		Scope:         scope,
+		Format:        format,
+		Status:        UsageExportStatusPending,
+		Filters:       filters,
+		CreatedBy:     createdBy,
+		DownloadToken: token,
+	}
+	if err := s.repo.CreateTask(ctx, task); err != nil {
+		return nil, fmt.Errorf("create export task: %w", err)
+	}
+	log.Printf("[UsageExport] task created: task=%d scope=%s format=%s operator=%d", task.ID, scope, format, createdBy)
+	go s.runOnce()
+	return task, nil
+}
+
+func generateUsageExportToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate download token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (s *UsageExportService) validateFilters(filters usagestats.UsageLogFilters) error {
+	if filters.StartTime == nil || filters.EndTime == nil {
+		return ErrUsageExportMissingRange
+	}
+	if filters.EndTime.Before(*filters.StartTime) {
+		return ErrUsageExportInvalidRange
+	}
+	maxDays := s.maxRangeDays()
+	if filters.EndTime.Sub(*filters.StartTime) > time.Duration(maxDays)*24*time.Hour {
+		return ErrUsageExportRangeTooLarge.WithMetadata(map[string]string{"max_range_days": fmt.Sprintf("%d", maxDays)})
+	}
+	return nil
+}
+
+// ListTasks 列出任务；createdBy 为 0 时不限创建者
+func (s *UsageExportService) ListTasks(ctx context.Context, scope string, createdBy int64, params pagination.PaginationParams) ([]UsageExportTask, *pagination.PaginationResult, error) {
+	return s.repo.ListTasks(ctx, UsageExportTaskFilter{Scope: scope, CreatedBy: createdBy}, params)
+}
+
+// GetTask 获取任务；范围或创建者不匹配时视为不存在
+func (s *UsageExportService) GetTask(ctx context.Context, scope string, createdBy int64, taskID int64) (*UsageExportTask, error) {
+	task, err := s.repo.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Scope != scope || (createdBy > 0 && task.CreatedBy != createdBy) {
+		return nil, ErrUsageExportTaskNotFound
+	}
+	return task, nil
+}
+
+// CancelTask 取消 pending/running 任务
+func (s *UsageExportService) CancelTask(ctx context.Context, scope string, createdBy int64, taskID int64, canceledBy int64) error {
+	task, err := s.GetTask(ctx, scope, createdBy, taskID)
+	if err != nil {
+		return err
+	}
+	if task.Status != UsageExportStatusPending && task.Status != UsageExportStatusRunning {
+		return ErrUsageExportCancelConflict
+	}
+	ok, err := s.repo.CancelTask(ctx, taskID, canceledBy)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// 状态可能并发改变
+		return ErrUsageExportCancelConflict
+	}
+	log.Printf("[UsageExport] task canceled: task=%d operator=%d", taskID, canceledBy)
+	return nil
+}
+
+// OpenDownload 通过下载令牌获取可下载的任务
+func (s *UsageExportService) OpenDownload(ctx context.Context, token string) (*UsageExportTask, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrUsageExportTaskNotFound
+	}
+	task, err := s.repo.GetTaskByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if task.Status != UsageExportStatusSucceeded {
+		return nil, ErrUsageExportNotReady
+	}
+	if !task.Downloadable(time.Now()) {
+		return nil, ErrUsageExportLinkExpired
+	}
+	if _, err := os.Stat(task.FilePath); err != nil {
+		return nil, ErrUsageExportLinkExpired
+	}
+	return task, nil
+}
+
+// UsageExportFilename 下载时使用的文件名
+func UsageExportFilename(task *UsageExportTask) string {
+	return fmt.Sprintf("usage-export-%d.%s", task.ID, task.Format)
+}
+
+func (s *UsageExportService) runOnce() {
+	if s == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	parent := context.Background()
+	if s.workerCtx != nil {
+		parent = s.workerCtx
+	}
+	s.purgeExpiredFiles(parent)
+
+	ctx, cancel := context.WithTimeout(parent, s.taskTimeout())
+	defer cancel()
+
+	task, err := s.repo.ClaimNextPendingTask(ctx, int64(s.taskTimeout().Seconds()))
+	if err != nil {
+		log.Printf("[UsageExport] claim pending task failed: %v", err)
+		return
+	}
+	if task == nil {
+		return
+	}
+	log.Printf("[UsageExport] task claimed: task=%d scope=%s format=%s created_by=%d", task.ID, task.Scope, task.Format, task.CreatedBy)
+	s.executeTask(ctx, task)
+}
+
+func (s *UsageExportService) purgeExpiredFiles(parent context.Context) {
+	ctx, cancel := context.WithTimeout(parent, 10*time.Second)
+	defer cancel()
+	paths, err := s.repo.PurgeExpiredFiles(ctx, time.Now())
+	if err != nil {
+		log.Printf("[UsageExport] purge expired files failed: %v", err)
+		return
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[UsageExport] remove expired file failed: path=%s err=%v", path, err)
+		}
+	}
+	if len(paths) > 0 {
+		log.Printf("[UsageExport] purged %d expired export files", len(paths))
+	}
+}
+
+func (s *UsageExportService) executeTask(ctx context.Context, task *UsageExportTask) {
+	start := time.Now()
+	total, err := s.repo.CountUsageLogs(ctx, task.Filters)
+	if err != nil {
+		s.handleTaskError(ctx, task.ID, 0, err)
+		return
+	}
+	s.updateProgress(task.ID, total, 0)
+
+	dir := s.dir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		s.markTaskFailed(task.ID, 0, err)
+		return
+	}
+	finalPath := filepath.Join(dir, fmt.Sprintf("usage-export-%d-%s.%s", task.ID, task.DownloadToken[:12], task.Format))
+	tmpPath := finalPath + ".tmp"
+
+	exported, err := s.writeFile(ctx, task, tmpPath, total)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		if errors.Is(err, errUsageExportCanceled) {
+			log.Printf("[UsageExport] task canceled: task=%d exported_rows=%d duration=%s", task.ID, exported, time.Since(start))
+			return
+		}
+		s.handleTaskError(ctx, task.ID, exported, err)
+		return
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		_ = os.Remove(tmpPath)
+		s.markTaskFailed(task.ID, exported, err)
+		return
+	}
+	var size int64
+	if info, err := os.Stat(finalPath); err == nil {
+		size = info.Size()
+	}
+
+	updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	expiresAt := time.Now().Add(s.linkTTL())
+	if err := s.repo.MarkTaskSucceeded(updateCtx, task.ID, exported, finalPath, size, expiresAt); err != nil {
+		log.Printf("[UsageExport] update task succeeded failed: task=%d err=%v", task.ID, err)
+		return
+	}
+	log.Printf("[UsageExport] task succeeded: task=%d rows=%d size=%d duration=%s", task.ID, exported, size, time.Since(start))
+}
+
+// writeFile 流式读取使用记录写入临时文件，每批写完后更新进度并检查是否已取消
+func (s *UsageExportService) writeFile(ctx context.Context, task *UsageExportTask, path string, total int64) (int64, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	columns := usageExportColumns(task.Scope)
+	encoder, err := newUsageExportEncoder(task.Format, file, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	var exported int64
+	err = s.repo.StreamUsageLogs(ctx, task.Filters, s.batchSize(), func(logs []UsageLog) error {
+		for i := range logs {
+			if err := encoder.WriteRow(usageExportRow(columns, &logs[i])); err != nil {
+				return err
+			}
+		}
+		exported += int64(len(logs))
+		canceled, err := s.isTaskCanceled(task.ID)
+		if err != nil {
+			return err
+		}
+		if canceled {
+			return errUsageExportCanceled
+		}
+		s.updateProgress(task.ID, total, exported)
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+	if err := encoder.Close(); err != nil {
+		return exported, err
+	}
+	return exported, file.Close()
+}
+
+func (s *UsageExportService) updateProgress(taskID, total, exported int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := s.repo.UpdateTaskProgress(ctx, taskID, total, exported); err != nil {
+		log.Printf("[UsageExport] task progress update failed: task=%d exported_rows=%d err=%v", taskID, exported, err)
+	}
+}
+
+func (s *UsageExportService) handleTaskError(ctx context.Context, taskID, exported int64, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+		// 任务被中断（例如服务停止/超时），保持 running 状态，后续通过 stale reclaim 重新导出。
+		log.Printf("[UsageExport] task interrupted: task=%d err=%v", taskID, err)
+		return
+	}
+	s.markTaskFailed(taskID, exported, err)
+}
+
+func (s *UsageExportService) markTaskFailed(taskID int64, exported int64, err error) {
+	msg := strings.TrimSpace(err.Error())
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	log.Printf("[UsageExport] task failed: task=%d exported_rows=%d err=%s", taskID, exported, msg)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if updateErr := s.repo.MarkTaskFailed(ctx, taskID, exported, msg); updateErr != nil {
+		log.Printf("[UsageExport] update task failed failed: task=%d err=%v", taskID, updateErr)
+	}
+}
+
+func (s *UsageExportService) isTaskCanceled(taskID int64) (bool, error) {
+	checkCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	status, err := s.repo.GetTaskStatus(checkCtx, taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return status == UsageExportStatusCanceled, nil
+}
+
+func (s *UsageExportService) dir() string {
+	if s == nil || s.cfg == nil || strings.TrimSpace(s.cfg.UsageExport.Dir) == "" {
+		return filepath.Join("data", "exports")
+	}
+	return s.cfg.UsageExport.Dir
+}
+
+func (s *UsageExportService) maxRangeDays() int {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.MaxRangeDays <= 0 {
+		return 92
+	}
+	return s.cfg.UsageExport.MaxRangeDays
+}
+
+func (s *UsageExportService) batchSize() int {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.BatchSize <= 0 {
+		return 2000
+	}
+	return s.cfg.UsageExport.BatchSize
+}
+
+func (s *UsageExportService) workerInterval() time.Duration {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.WorkerIntervalSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(s.cfg.UsageExport.WorkerIntervalSeconds) * time.Second
+}
+
+func (s *UsageExportService) taskTimeout() time.Duration {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.TaskTimeoutSeconds <= 0 {
+		return time.Hour
+	}
+	return time.Duration(s.cfg.UsageExport.TaskTimeoutSeconds) * time.Second
+}
+
+func (s *UsageExportService) linkTTL() time.Duration {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.LinkTTLHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(s.cfg.UsageExport.LinkTTLHours) * time.Hour
+}
+
+func (s *UsageExportService) maxActiveTasksPerUser() int {
+	if s == nil || s.cfg == nil || s.cfg.UsageExport.MaxActiveTasksPerUser <= 0 {
+		return 2
+	}
+	return s.cfg.UsageExport.MaxActiveTasksPerUser
+}
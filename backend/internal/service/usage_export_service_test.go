@@ -0,0 +1,329 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+	"github.com/stretchr/testify/require"
+)
+
+type usageExportRepoStub struct {
+	mu          sync.Mutex
+	created     []*UsageExportTask
+	tasks       map[int64]*UsageExportTask
+	active      int
+	logs        []UsageLog
+	cancelAfter int // 读取到第 N 批后将任务标记为取消
+	batches     int
+	progress    []int64
+	succeeded   map[int64]string
+	failed      map[int64]string
+}
+
+func newUsageExportRepoStub() *usageExportRepoStub {
+	return &usageExportRepoStub{
+		tasks:     map[int64]*UsageExportTask{},
+		succeeded: map[int64]string{},
+		failed:    map[int64]string{},
+	}
+}
+
+func (s *usageExportRepoStub) CreateTask(ctx context.Context, task *UsageExportTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task.ID = int64(len(s.created) + 1)
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = task.CreatedAt
+	s.created = append(s.created, task)
+	s.tasks[task.ID] = task
+	return nil
+}
+
+func (s *usageExportRepoStub) ListTasks(ctx context.Context, filter UsageExportTaskFilter, params pagination.PaginationParams) ([]UsageExportTask, *pagination.PaginationResult, error) {
+	return nil, &pagination.PaginationResult{}, nil
+}
+
+func (s *usageExportRepoStub) GetTask(ctx context.Context, taskID int64) (*UsageExportTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return nil, ErrUsageExportTaskNotFound
+	}
+	cp := *task
+	return &cp, nil
+}
+
+func (s *usageExportRepoStub) GetTaskByToken(ctx context.Context, token string) (*UsageExportTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, task := range s.tasks {
+		if task.DownloadToken == token {
+			cp := *task
+			return &cp, nil
+		}
+	}
+	return nil, ErrUsageExportTaskNotFound
+}
+
+func (s *usageExportRepoStub) CountActiveTasks(ctx context.Context, createdBy int64) (int, error) {
+	return s.active, nil
+}
+
+func (s *usageExportRepoStub) ClaimNextPendingTask(ctx context.Context, staleRunningAfterSeconds int64) (*UsageExportTask, error) {
+	return nil, nil
+}
+
+func (s *usageExportRepoStub) GetTaskStatus(ctx context.Context, taskID int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancelAfter > 0 && s.batches >= s.cancelAfter {
+		return UsageExportStatusCanceled, nil
+	}
+	return UsageExportStatusRunning, nil
+}
+
+func (s *usageExportRepoStub) UpdateTaskProgress(ctx context.Context, taskID int64, totalRows, exportedRows int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress = append(s.progress, exportedRows)
+	return nil
+}
+
+func (s *usageExportRepoStub) CancelTask(ctx context.Context, taskID int64, canceledBy int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task := s.tasks[taskID]
+	if task.Status != UsageExportStatusPending && task.Status != UsageExportStatusRunning {
+		return false, nil
+	}
+	task.Status = UsageExportStatusCanceled
+	return true, nil
+}
+
+func (s *usageExportRepoStub) MarkTaskSucceeded(ctx context.Context, taskID int64, exportedRows int64, filePath string, fileSize int64, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.succeeded[taskID] = filePath
+	return nil
+}
+
+func (s *usageExportRepoStub) MarkTaskFailed(ctx context.Context, taskID int64, exportedRows int64, errorMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed[taskID] = errorMsg
+	return nil
+}
+
+func (s *usageExportRepoStub) PurgeExpiredFiles(ctx context.Context, now time.Time) ([]string, error) {
+	return nil, nil
+}
+
+func (s *usageExportRepoStub) CountUsageLogs(ctx context.Context, filters usagestats.UsageLogFilters) (int64, error) {
+	return int64(len(s.logs)), nil
+}
+
+func (s *usageExportRepoStub) StreamUsageLogs(ctx context.Context, filters usagestats.UsageLogFilters, batchSize int, fn func([]UsageLog) error) error {
+	for start := 0; start < len(s.logs); start += batchSize {
+		end := start + batchSize
+		if end > len(s.logs) {
+			end = len(s.logs)
+		}
+		s.mu.Lock()
+		s.batches++
+		s.mu.Unlock()
+		if err := fn(s.logs[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ UsageExportRepository = (*usageExportRepoStub)(nil)
+
+func newUsageExportTestService(repo UsageExportRepository, dir string) *UsageExportService {
+	cfg := &config.Config{UsageExport: config.UsageExportConfig{
+		Enabled:               true,
+		Dir:                   dir,
+		MaxRangeDays:          31,
+		BatchSize:             2,
+		LinkTTLHours:          1,
+		MaxActiveTasksPerUser: 2,
+	}}
+	return NewUsageExportService(repo, nil, cfg)
+}
+
+func usageExportTestRange(days int) usagestats.UsageLogFilters {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, days)
+	return usagestats.UsageLogFilters{UserID: 7, StartTime: &start, EndTime: &end}
+}
+
+func usageExportTestLogs() []UsageLog {
+	ua := "=cmd|calc"
+	ip := "10.0.0.1"
+	group := int64(3)
+	at := time.Date(2026, 9, 2, 8, 0, 0, 0, time.UTC)
+	return []UsageLog{
+		{ID: 1, UserID: 7, APIKeyID: 11, AccountID: 5, RequestID: "req-1", Model: "claude-sonnet-4", InputTokens: 10, OutputTokens: 20, TotalCost: 0.5, ActualCost: 0.5, RateMultiplier: 1, CreatedAt: at, UserAgent: &ua, IPAddress: &ip},
+		{ID: 2, UserID: 7, APIKeyID: 11, AccountID: 5, RequestID: "req-2", Model: "gpt-5", GroupID: &group, Stream: true, ActualCost: 1.25, RateMultiplier: 1, CreatedAt: at, Tags: map[string]string{"team": "ml"}},
+		{ID: 3, UserID: 7, APIKeyID: 12, AccountID: 6, RequestID: "req-3", Model: "gemini", RateMultiplier: 1, CreatedAt: at},
+	}
+}
+
+func TestUsageExportService_CreateTaskValidation(t *testing.T) {
+	repo := newUsageExportRepoStub()
+	svc := newUsageExportTestService(repo, t.TempDir())
+	ctx := context.Background()
+
+	_, err := svc.CreateTask(ctx, UsageExportScopeUser, "xlsx", usageExportTestRange(1), 7)
+	require.ErrorIs(t, err, ErrUsageExportFormatInvalid)
+
+	_, err = svc.CreateTask(ctx, UsageExportScopeUser, "csv", usagestats.UsageLogFilters{UserID: 7}, 7)
+	require.ErrorIs(t, err, ErrUsageExportMissingRange)
+
+	_, err = svc.CreateTask(ctx, UsageExportScopeUser, "csv", usageExportTestRange(40), 7)
+	require.Equal(t, http.StatusBadRequest, infraerrors.Code(err))
+	require.Equal(t, "USAGE_EXPORT_RANGE_TOO_LARGE", infraerrors.Reason(err))
+
+	repo.active = 2
+	_, err = svc.CreateTask(ctx, UsageExportScopeUser, "csv", usageExportTestRange(1), 7)
+	require.ErrorIs(t, err, ErrUsageExportTooManyTasks)
+
+	repo.active = 0
+	task, err := svc.CreateTask(ctx, UsageExportScopeUser, " Parquet ", usageExportTestRange(30), 7)
+	require.NoError(t, err)
+	require.Equal(t, UsageExportFormatParquet, task.Format)
+	require.Equal(t, UsageExportStatusPending, task.Status)
+	require.Len(t, task.DownloadToken, 64)
+}
+
+func TestUsageExportService_CreateTaskDisabled(t *testing.T) {
+	svc := NewUsageExportService(newUsageExportRepoStub(), nil, &config.Config{})
+	_, err := svc.CreateTask(context.Background(), UsageExportScopeAdmin, "csv", usageExportTestRange(1), 1)
+	require.ErrorIs(t, err, ErrUsageExportDisabled)
+}
+
+func TestUsageExportService_ExecuteTaskWritesCSV(t *testing.T) {
+	repo := newUsageExportRepoStub()
+	repo.logs = usageExportTestLogs()
+	svc := newUsageExportTestService(repo, t.TempDir())
+	task := &UsageExportTask{ID: 1, Scope: UsageExportScopeUser, Format: UsageExportFormatCSV, DownloadToken: strings.Repeat("a", 64)}
+
+	svc.executeTask(context.Background(), task)
+
+	require.Empty(t, repo.failed)
+	path := repo.succeeded[1]
+	require.NotEmpty(t, path)
+	require.Equal(t, []int64{0, 2, 3}, repo.progress)
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	records, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	header := strings.Join(records[0], ",")
+	// 用户范围不导出账号与 IP
+	require.NotContains(t, header, "account_id")
+	require.NotContains(t, header, "ip_address")
+	require.Equal(t, "id", records[0][0])
+	require.Equal(t, "req-1", records[1][2])
+	require.Contains(t, records[1], "'=cmd|calc")
+	require.Contains(t, records[2], `{"team":"ml"}`)
+}
+
+func TestUsageExportService_ExecuteTaskWritesNDJSONForAdmin(t *testing.T) {
+	repo := newUsageExportRepoStub()
+	repo.logs = usageExportTestLogs()
+	svc := newUsageExportTestService(repo, t.TempDir())
+	task := &UsageExportTask{ID: 2, Scope: UsageExportScopeAdmin, Format: UsageExportFormatNDJSON, DownloadToken: strings.Repeat("b", 64)}
+
+	svc.executeTask(context.Background(), task)
+
+	raw, err := os.ReadFile(repo.succeeded[2])
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasPrefix(lines[0], `{"id":1,"created_at":`))
+
+	var row map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	require.Equal(t, float64(5), row["account_id"])
+	require.Equal(t, float64(3), row["group_id"])
+	require.Equal(t, true, row["stream"])
+	require.Nil(t, row["ip_address"])
+}
+
+func TestUsageExportService_ExecuteTaskStopsWhenCanceled(t *testing.T) {
+	repo := newUsageExportRepoStub()
+	repo.logs = usageExportTestLogs()
+	repo.cancelAfter = 1
+	dir := t.TempDir()
+	svc := newUsageExportTestService(repo, dir)
+	task := &UsageExportTask{ID: 3, Scope: UsageExportScopeUser, Format: UsageExportFormatParquet, DownloadToken: strings.Repeat("c", 64)}
+
+	svc.executeTask(context.Background(), task)
+
+	require.Empty(t, repo.succeeded)
+	require.Empty(t, repo.failed)
+	require.Equal(t, 1, repo.batches)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestUsageExportService_CancelAndScope(t *testing.T) {
+	repo := newUsageExportRepoStub()
+	svc := newUsageExportTestService(repo, t.TempDir())
+	ctx := context.Background()
+	repo.tasks[1] = &UsageExportTask{ID: 1, Scope: UsageExportScopeUser, CreatedBy: 7, Status: UsageExportStatusRunning}
+	repo.tasks[2] = &UsageExportTask{ID: 2, Scope: UsageExportScopeUser, CreatedBy: 7, Status: UsageExportStatusSucceeded}
+
+	_, err := svc.GetTask(ctx, UsageExportScopeUser, 8, 1)
+	require.ErrorIs(t, err, ErrUsageExportTaskNotFound)
+	_, err = svc.GetTask(ctx, UsageExportScopeAdmin, 0, 1)
+	require.ErrorIs(t, err, ErrUsageExportTaskNotFound)
+
+	require.ErrorIs(t, svc.CancelTask(ctx, UsageExportScopeUser, 7, 2, 7), ErrUsageExportCancelConflict)
+	require.NoError(t, svc.CancelTask(ctx, UsageExportScopeUser, 7, 1, 7))
+	require.Equal(t, UsageExportStatusCanceled, repo.tasks[1].Status)
+}
+
+func TestUsageExportService_OpenDownload(t *testing.T) {
+	repo := newUsageExportRepoStub()
+	svc := newUsageExportTestService(repo, t.TempDir())
+	ctx := context.Background()
+
+	path := t.TempDir() + "/export.csv"
+	require.NoError(t, os.WriteFile(path, []byte("id\n"), 0o600))
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+	repo.tasks[1] = &UsageExportTask{ID: 1, Status: UsageExportStatusSucceeded, FilePath: path, ExpiresAt: &future, DownloadToken: "ready"}
+	repo.tasks[2] = &UsageExportTask{ID: 2, Status: UsageExportStatusSucceeded, FilePath: path, ExpiresAt: &past, DownloadToken: "expired"}
+	repo.tasks[3] = &UsageExportTask{ID: 3, Status: UsageExportStatusRunning, DownloadToken: "running"}
+
+	task, err := svc.OpenDownload(ctx, "ready")
+	require.NoError(t, err)
+	require.Equal(t, path, task.FilePath)
+
+	_, err = svc.OpenDownload(ctx, "expired")
+	require.ErrorIs(t, err, ErrUsageExportLinkExpired)
+	_, err = svc.OpenDownload(ctx, "running")
+	require.ErrorIs(t, err, ErrUsageExportNotReady)
+	_, err = svc.OpenDownload(ctx, "missing")
+	require.ErrorIs(t, err, ErrUsageExportTaskNotFound)
+}
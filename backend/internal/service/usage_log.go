@@ -17,6 +17,8 @@ type UsageLog struct {
 	// ReasoningEffort is the request's reasoning effort level (OpenAI Responses API),
 	// e.g. "low" / "medium" / "high" / "xhigh". Nil means not provided / not applicable.
 	ReasoningEffort *string
+	// RequestedModel 客户端请求的原始模型，仅在分组模型降级链生效时记录（Model 为实际服务的模型）
+	RequestedModel *string
 
 	GroupID        *int64
 	SubscriptionID *int64
@@ -50,6 +52,15 @@ type UsageLog struct {
 	ImageCount int
 	ImageSize  *string
 
+	// KeyGeneration 请求所用 API Key 密钥的代数（轮换后用于观察客户端迁移进度）
+	KeyGeneration int
+
+	// Tags 成本归属标签（通过白名单校验），无标签时为 nil
+	Tags map[string]string
+
+	// OrganizationID 组织 Key 产生的用量所属组织（同时归属创建 Key 的成员 UserID）
+	OrganizationID *int64
+
 	CreatedAt time.Time
 
 	User         *User
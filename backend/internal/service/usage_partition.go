@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 分区维护任务类型
+const (
+	UsagePartitionTaskConvert = "convert"
+	UsagePartitionTaskArchive = "archive"
+	UsagePartitionTaskRestore = "restore"
+	// UsagePartitionTaskDedupeBackfill 将历史使用记录分批登记到 usage_log_request_ids
+	UsagePartitionTaskDedupeBackfill = "dedupe_backfill"
+)
+
+const (
+	UsagePartitionTaskStatusPending   = "pending"
+	UsagePartitionTaskStatusRunning   = "running"
+	UsagePartitionTaskStatusSucceeded = "succeeded"
+	UsagePartitionTaskStatusFailed    = "failed"
+)
+
+// 归档状态：archived 表示分区已分离，restored 表示已回灌且在 RestoredUntil 前保持在线
+const (
+	UsageLogArchiveStatusArchived = "archived"
+	UsageLogArchiveStatusRestored = "restored"
+)
+
+var (
+	ErrUsagePartitionDisabled       = infraerrors.ServiceUnavailable("USAGE_PARTITION_DISABLED", "usage partition management is disabled")
+	ErrUsageLogsAlreadyPartitioned  = infraerrors.Conflict("USAGE_LOGS_ALREADY_PARTITIONED", "usage_logs is already partitioned")
+	ErrUsageLogsNotPartitioned      = infraerrors.Conflict("USAGE_LOGS_NOT_PARTITIONED", "usage_logs is not partitioned")
+	ErrUsagePartitionTaskActive     = infraerrors.Conflict("USAGE_PARTITION_TASK_ACTIVE", "a partition task of the same kind is already pending or running")
+	ErrUsagePartitionCleanupRunning = infraerrors.Conflict("USAGE_PARTITION_CLEANUP_RUNNING", "usage cleanup tasks must finish before converting usage_logs")
+	ErrUsageLogArchiveNotFound      = infraerrors.NotFound("USAGE_LOG_ARCHIVE_NOT_FOUND", "usage log archive not found")
+	ErrUsageLogLegacyTableMissing   = infraerrors.NotFound("USAGE_LOGS_LEGACY_NOT_FOUND", "usage_logs_legacy does not exist")
+	ErrUsageLogArchiveStorage       = infraerrors.Conflict("USAGE_LOG_ARCHIVE_STORAGE_MISMATCH", "archive was stored on a different storage than the configured one")
+
+	// ErrUsagePartitionSwapBusy 切换时未能在 lock_timeout 内拿到 usage_logs 排他锁，任务让出后重试
+	ErrUsagePartitionSwapBusy = errors.New("usage_logs swap lock timeout")
+)
+
+const usageLogPartitionPrefix = "usage_logs_"
+
+// UsageLogPartitionName 返回 month 所在月的分区表名，如 usage_logs_202601
+func UsageLogPartitionName(month time.Time) string {
+	return usageLogPartitionPrefix + month.UTC().Format("200601")
+}
+
+// ParseUsageLogPartitionName 解析月分区表名，非月分区（如默认分区）返回 false
+func ParseUsageLogPartitionName(name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, usageLogPartitionPrefix)
+	if !ok || len(suffix) != 6 {
+		return time.Time{}, false
+	}
+	month, err := time.Parse("200601", suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return month.UTC(), true
+}
+
+// UsagePartitionTask 分区维护任务
+type UsagePartitionTask struct {
+	ID            int64
+	TaskType      string
+	Status        string
+	PartitionName string
+	ArchiveID     *int64
+	// CursorID 在线转换已复制到的 usage_logs.id；去重回填为已回填到的 created_at（Unix 秒）
+	CursorID      int64
+	TotalRows     int64
+	ProcessedRows int64
+	ErrorMsg      *string
+	CreatedBy     *int64
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// UsageLogArchive 已归档的月分区
+type UsageLogArchive struct {
+	ID            int64
+	PartitionName string
+	RangeStart    time.Time
+	RangeEnd      time.Time
+	RowCount      int64
+	Storage       string
+	Location      string
+	FileSize      int64
+	Checksum      string
+	Status        string
+	ArchivedAt    time.Time
+	RestoredAt    *time.Time
+	RestoredUntil *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// UsageLogPartition usage_logs 的子分区
+type UsageLogPartition struct {
+	Name string
+	// Month 分区起始月（UTC）；默认分区为零值
+	Month         time.Time
+	IsDefault     bool
+	EstimatedRows int64
+	SizeBytes     int64
+}
+
+// UsagePartitionStatus 分区概况
+type UsagePartitionStatus struct {
+	Partitioned    bool
+	LegacyExists   bool
+	Partitions     []UsageLogPartition
+	ArchiveEnabled bool
+	// RetentionCutoff 早于该月的分区会被归档（未启用归档时为 nil）
+	RetentionCutoff *time.Time
+}
+
+// UsagePartitionRepository 分区维护持久层接口
+type UsagePartitionRepository interface {
+	CreateTask(ctx context.Context, task *UsagePartitionTask) error
+	ListTasks(ctx context.Context, params pagination.PaginationParams) ([]UsagePartitionTask, *pagination.PaginationResult, error)
+	// HasActiveTask 是否存在同类型（及同分区，partitionName 为空时不限）的 pending/running 任务
+	HasActiveTask(ctx context.Context, taskType, partitionName string) (bool, error)
+	// ClaimNextPendingTask 抢占下一条任务（同 UsageCleanupRepository），保留 CursorID 以便断点续做
+	ClaimNextPendingTask(ctx context.Context, staleRunningAfterSeconds int64) (*UsagePartitionTask, error)
+	UpdateTaskProgress(ctx context.Context, taskID, cursorID, totalRows, processedRows int64) error
+	// YieldTask 将 running 任务放回 pending，下一轮从 CursorID 继续
+	YieldTask(ctx context.Context, taskID int64) error
+	MarkTaskSucceeded(ctx context.Context, taskID int64) error
+	MarkTaskFailed(ctx context.Context, taskID int64, errorMsg string) error
+	// HasSucceededTask 是否存在已成功完成的指定类型任务
+	HasSucceededTask(ctx context.Context, taskType string) (bool, error)
+	// HasActiveCleanupTasks 是否存在 pending/running 的使用记录清理任务
+	HasActiveCleanupTasks(ctx context.Context) (bool, error)
+
+	ListArchives(ctx context.Context, params pagination.PaginationParams) ([]UsageLogArchive, *pagination.PaginationResult, error)
+	GetArchive(ctx context.Context, archiveID int64) (*UsageLogArchive, error)
+	// GetArchiveByPartition 不存在时返回 ErrUsageLogArchiveNotFound
+	GetArchiveByPartition(ctx context.Context, partitionName string) (*UsageLogArchive, error)
+	// UpsertArchive 按 partition_name 写入归档记录，状态重置为 archived
+	UpsertArchive(ctx context.Context, archive *UsageLogArchive) error
+	MarkArchiveRestored(ctx context.Context, archiveID int64, restoredUntil time.Time) error
+	MarkArchiveDetached(ctx context.Context, archiveID int64) error
+
+	IsPartitioned(ctx context.Context) (bool, error)
+	ListPartitions(ctx context.Context) ([]UsageLogPartition, error)
+	// EnsurePartition 创建 month 所在月的分区（已存在时忽略）
+	EnsurePartition(ctx context.Context, month time.Time) error
+	DetachAndDropPartition(ctx context.Context, partitionName string) error
+	CountPartitionRows(ctx context.Context, partitionName string) (int64, error)
+	// StreamPartitionRows 通过只读事务内的游标按 id 升序读取分区行（row_to_json 文本）
+	StreamPartitionRows(ctx context.Context, partitionName string, batchSize int, fn func(rows [][]byte) error) error
+	// InsertArchivedRows 将 row_to_json 文本批量写回 usage_logs，已存在的行忽略，返回写入行数
+	InsertArchivedRows(ctx context.Context, rows [][]byte) (int64, error)
+
+	LegacyTableExists(ctx context.Context) (bool, error)
+	DropLegacyTable(ctx context.Context) error
+	// PrepareConversion 创建影子分区表（索引、外键、月分区与默认分区），可重复执行；返回估算总行数
+	PrepareConversion(ctx context.Context, now time.Time, aheadMonths int) (int64, error)
+	// CopyConversionBatch 复制 id > afterID 的下一批记录，返回本批最大 id 与行数
+	CopyConversionBatch(ctx context.Context, afterID int64, limit int) (int64, int64, error)
+	// SwapConversion 锁表复制剩余记录并原子切换：usage_logs → usage_logs_legacy，影子表 → usage_logs
+	SwapConversion(ctx context.Context, afterID int64) error
+
+	// OldestUsageLogTime usage_logs 中最早的 created_at，表为空时返回 nil
+	OldestUsageLogTime(ctx context.Context) (*time.Time, error)
+	// BackfillRequestIDs 将 created_at 位于 [from, to) 的使用记录登记到 usage_log_request_ids，已登记的忽略，返回登记数
+	BackfillRequestIDs(ctx context.Context, from, to time.Time) (int64, error)
+}
+
+// UsageLogArchiveStore 归档文件存储（本地目录或 S3 兼容对象存储）
+type UsageLogArchiveStore interface {
+	// Kind 存储类型：local / s3
+	Kind() string
+	// Put 保存本地文件并返回位置（本地路径或对象键），成功后 localPath 可能已被移动
+	Put(ctx context.Context, name, localPath string) (string, error)
+	Open(ctx context.Context, location string) (io.ReadCloser, error)
+}
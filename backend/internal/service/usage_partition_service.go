@@ -0,0 +1,770 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	usagePartitionWorkerName = "usage_partition_worker"
+	usageLogArchiveExt       = ".ndjson.gz"
+	// usageDedupeBackfillWindow 去重回填每批覆盖的 created_at 时间窗
+	usageDedupeBackfillWindow = time.Hour
+)
+
+// UsagePartitionService 负责 usage_logs 的月分区维护
+//   - 每轮预建当月及未来 AheadMonths 个月的分区
+//   - 启用归档时为超出保留期的分区创建归档任务：导出为 gzip JSON Lines 并保存到本地或 S3 后分离删除
+//   - 执行管理员发起的在线转换与归档回灌任务
+//   - 去重登记表（usage_log_request_ids）完成历史回填前创建回填任务，按 created_at 时间窗分批登记
+//
+// 任务执行方式与 UsageCleanupService 相同：定时轮询抢占 pending 任务，超时未完成的 running 任务允许重新抢占。
+type UsagePartitionService struct {
+	repo        UsagePartitionRepository
+	store       UsageLogArchiveStore
+	timingWheel *TimingWheelService
+	cfg         *config.Config
+
+	running   int32
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+func NewUsagePartitionService(repo UsagePartitionRepository, store UsageLogArchiveStore, timingWheel *TimingWheelService, cfg *config.Config) *UsagePartitionService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	return &UsagePartitionService{
+		repo:         repo,
+		store:        store,
+		timingWheel:  timingWheel,
+		cfg:          cfg,
+		workerCtx:    workerCtx,
+		workerCancel: workerCancel,
+	}
+}
+
+func (s *UsagePartitionService) Start() {
+	if s == nil {
+		return
+	}
+	if !s.enabled() {
+		log.Printf("[UsagePartition] not started (disabled)")
+		return
+	}
+	if s.repo == nil || s.timingWheel == nil {
+		log.Printf("[UsagePartition] not started (missing deps)")
+		return
+	}
+
+	interval := s.workerInterval()
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(usagePartitionWorkerName, interval, s.runOnce)
+		log.Printf("[UsagePartition] started (interval=%s ahead_months=%d batch_size=%d archive=%t)", interval, s.aheadMonths(), s.batchSize(), s.archiveEnabled())
+	})
+}
+
+func (s *UsagePartitionService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(usagePartitionWorkerName)
+		}
+		log.Printf("[UsagePartition] stopped")
+	})
+}
+
+// Status 返回分区概况
+func (s *UsagePartitionService) Status(ctx context.Context) (*UsagePartitionStatus, error) {
+	partitioned, err := s.repo.IsPartitioned(ctx)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := s.repo.LegacyTableExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &UsagePartitionStatus{
+		Partitioned:    partitioned,
+		LegacyExists:   legacy,
+		Partitions:     []UsageLogPartition{},
+		ArchiveEnabled: s.archiveEnabled(),
+	}
+	if partitioned {
+		if status.Partitions, err = s.repo.ListPartitions(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if status.ArchiveEnabled {
+		cutoff := s.retentionCutoff(time.Now())
+		status.RetentionCutoff = &cutoff
+	}
+	return status, nil
+}
+
+// ListTasks 列出分区维护任务
+func (s *UsagePartitionService) ListTasks(ctx context.Context, params pagination.PaginationParams) ([]UsagePartitionTask, *pagination.PaginationResult, error) {
+	return s.repo.ListTasks(ctx, params)
+}
+
+// ListArchives 列出已归档分区
+func (s *UsagePartitionService) ListArchives(ctx context.Context, params pagination.PaginationParams) ([]UsageLogArchive, *pagination.PaginationResult, error) {
+	return s.repo.ListArchives(ctx, params)
+}
+
+// StartConversion 创建在线转换任务
+// 转换按 id 分批复制，复制期间对旧记录的删除不会同步到新表，因此要求没有进行中的清理任务。
+func (s *UsagePartitionService) StartConversion(ctx context.Context, operator int64) (*UsagePartitionTask, error) {
+	if !s.enabled() {
+		return nil, ErrUsagePartitionDisabled
+	}
+	partitioned, err := s.repo.IsPartitioned(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if partitioned {
+		return nil, ErrUsageLogsAlreadyPartitioned
+	}
+	active, err := s.repo.HasActiveTask(ctx, UsagePartitionTaskConvert, "")
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, ErrUsagePartitionTaskActive
+	}
+	cleanupActive, err := s.repo.HasActiveCleanupTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cleanupActive {
+		return nil, ErrUsagePartitionCleanupRunning
+	}
+
+	task := &UsagePartitionTask{
+		TaskType:  UsagePartitionTaskConvert,
+		Status:    UsagePartitionTaskStatusPending,
+		CreatedBy: &operator,
+	}
+	if err := s.repo.CreateTask(ctx, task); err != nil {
+		return nil, fmt.Errorf("create partition task: %w", err)
+	}
+	log.Printf("[UsagePartition] conversion task created: task=%d operator=%d", task.ID, operator)
+	go s.runOnce()
+	return task, nil
+}
+
+// DropLegacyTable 删除转换后保留的 usage_logs_legacy
+func (s *UsagePartitionService) DropLegacyTable(ctx context.Context, operator int64) error {
+	exists, err := s.repo.LegacyTableExists(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUsageLogLegacyTableMissing
+	}
+	active, err := s.repo.HasActiveTask(ctx, UsagePartitionTaskConvert, "")
+	if err != nil {
+		return err
+	}
+	if active {
+		return ErrUsagePartitionTaskActive
+	}
+	if err := s.repo.DropLegacyTable(ctx); err != nil {
+		return err
+	}
+	log.Printf("[UsagePartition] usage_logs_legacy dropped: operator=%d", operator)
+	return nil
+}
+
+// RestoreArchive 创建回灌任务，将归档重新写入 usage_logs
+func (s *UsagePartitionService) RestoreArchive(ctx context.Context, archiveID int64, operator int64) (*UsagePartitionTask, error) {
+	if !s.enabled() || s.store == nil {
+		return nil, ErrUsagePartitionDisabled
+	}
+	partitioned, err := s.repo.IsPartitioned(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !partitioned {
+		return nil, ErrUsageLogsNotPartitioned
+	}
+	archive, err := s.repo.GetArchive(ctx, archiveID)
+	if err != nil {
+		return nil, err
+	}
+	if archive.Storage != s.store.Kind() {
+		return nil, ErrUsageLogArchiveStorage
+	}
+	active, err := s.repo.HasActiveTask(ctx, UsagePartitionTaskRestore, archive.PartitionName)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, ErrUsagePartitionTaskActive
+	}
+
+	task := &UsagePartitionTask{
+		TaskType:      UsagePartitionTaskRestore,
+		Status:        UsagePartitionTaskStatusPending,
+		PartitionName: archive.PartitionName,
+		ArchiveID:     &archive.ID,
+		TotalRows:     archive.RowCount,
+		CreatedBy:     &operator,
+	}
+	if err := s.repo.CreateTask(ctx, task); err != nil {
+		return nil, fmt.Errorf("create partition task: %w", err)
+	}
+	log.Printf("[UsagePartition] restore task created: task=%d archive=%d partition=%s operator=%d", task.ID, archive.ID, archive.PartitionName, operator)
+	go s.runOnce()
+	return task, nil
+}
+
+func (s *UsagePartitionService) runOnce() {
+	if s == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	parent := context.Background()
+	if s.workerCtx != nil {
+		parent = s.workerCtx
+	}
+	s.maintain(parent, time.Now())
+
+	claimCtx, cancel := context.WithTimeout(parent, 10*time.Second)
+	// 转换任务最多连续执行 taskTimeout 后让出，stale 阈值留出余量避免被其他实例提前抢占
+	task, err := s.repo.ClaimNextPendingTask(claimCtx, int64(2*s.taskTimeout().Seconds()))
+	cancel()
+	if err != nil {
+		log.Printf("[UsagePartition] claim pending task failed: %v", err)
+		return
+	}
+	if task == nil {
+		return
+	}
+	log.Printf("[UsagePartition] task claimed: task=%d type=%s partition=%s cursor=%d", task.ID, task.TaskType, task.PartitionName, task.CursorID)
+	s.executeTask(parent, task)
+}
+
+// maintain 预建未来分区，并为超出保留期的分区创建归档任务
+func (s *UsagePartitionService) maintain(parent context.Context, now time.Time) {
+	ctx, cancel := context.WithTimeout(parent, time.Minute)
+	defer cancel()
+
+	// 去重回填与是否已转换为分区表无关
+	if err := s.scheduleDedupeBackfill(ctx); err != nil {
+		log.Printf("[UsagePartition] schedule dedupe backfill failed: %v", err)
+	}
+
+	partitioned, err := s.repo.IsPartitioned(ctx)
+	if err != nil {
+		log.Printf("[UsagePartition] check partitioned failed: %v", err)
+		return
+	}
+	if !partitioned {
+		return
+	}
+
+	month := usagePartitionMonth(now)
+	for i := 0; i <= s.aheadMonths(); i++ {
+		if err := s.repo.EnsurePartition(ctx, month.AddDate(0, i, 0)); err != nil {
+			log.Printf("[UsagePartition] ensure partition failed: month=%s err=%v", month.AddDate(0, i, 0).Format("2006-01"), err)
+			return
+		}
+	}
+
+	if s.archiveEnabled() && s.store != nil {
+		if err := s.scheduleArchives(ctx, now); err != nil {
+			log.Printf("[UsagePartition] schedule archives failed: %v", err)
+		}
+	}
+}
+
+func (s *UsagePartitionService) scheduleArchives(ctx context.Context, now time.Time) error {
+	partitions, err := s.repo.ListPartitions(ctx)
+	if err != nil {
+		return err
+	}
+	cutoff := s.retentionCutoff(now)
+	for _, p := range partitions {
+		if p.IsDefault || p.Month.IsZero() || !p.Month.Before(cutoff) {
+			continue
+		}
+		archive, err := s.repo.GetArchiveByPartition(ctx, p.Name)
+		if err != nil && !errors.Is(err, ErrUsageLogArchiveNotFound) {
+			return err
+		}
+		if archive != nil && archive.Status == UsageLogArchiveStatusRestored &&
+			archive.RestoredUntil != nil && now.Before(*archive.RestoredUntil) {
+			continue
+		}
+		active, err := s.repo.HasActiveTask(ctx, UsagePartitionTaskArchive, p.Name)
+		if err != nil {
+			return err
+		}
+		if active {
+			continue
+		}
+		task := &UsagePartitionTask{
+			TaskType:      UsagePartitionTaskArchive,
+			Status:        UsagePartitionTaskStatusPending,
+			PartitionName: p.Name,
+			TotalRows:     p.EstimatedRows,
+		}
+		if err := s.repo.CreateTask(ctx, task); err != nil {
+			return err
+		}
+		log.Printf("[UsagePartition] archive task created: task=%d partition=%s", task.ID, p.Name)
+	}
+	return nil
+}
+
+// scheduleDedupeBackfill 历史回填尚未成功完成且没有进行中的任务时创建回填任务（失败后下一轮重新创建）
+func (s *UsagePartitionService) scheduleDedupeBackfill(ctx context.Context) error {
+	done, err := s.repo.HasSucceededTask(ctx, UsagePartitionTaskDedupeBackfill)
+	if err != nil || done {
+		return err
+	}
+	active, err := s.repo.HasActiveTask(ctx, UsagePartitionTaskDedupeBackfill, "")
+	if err != nil || active {
+		return err
+	}
+	task := &UsagePartitionTask{
+		TaskType: UsagePartitionTaskDedupeBackfill,
+		Status:   UsagePartitionTaskStatusPending,
+	}
+	if err := s.repo.CreateTask(ctx, task); err != nil {
+		return err
+	}
+	log.Printf("[UsagePartition] dedupe backfill task created: task=%d", task.ID)
+	return nil
+}
+
+func (s *UsagePartitionService) executeTask(parent context.Context, task *UsagePartitionTask) {
+	start := time.Now()
+	var err error
+	switch task.TaskType {
+	case UsagePartitionTaskConvert:
+		// 在线转换自行控制执行时长，超时后让出并保留游标
+		var yielded bool
+		yielded, err = s.executeConvert(parent, task)
+		if err == nil && yielded {
+			return
+		}
+	case UsagePartitionTaskDedupeBackfill:
+		var yielded bool
+		yielded, err = s.executeDedupeBackfill(parent, task)
+		if err == nil && yielded {
+			return
+		}
+	case UsagePartitionTaskArchive:
+		ctx, cancel := context.WithTimeout(parent, s.taskTimeout())
+		defer cancel()
+		err = s.executeArchive(ctx, task)
+	case UsagePartitionTaskRestore:
+		ctx, cancel := context.WithTimeout(parent, s.taskTimeout())
+		defer cancel()
+		err = s.executeRestore(ctx, task)
+	default:
+		err = fmt.Errorf("unknown task type %q", task.TaskType)
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			// 任务被中断（例如服务停止/超时），保持 running 状态，后续通过 stale reclaim 重新执行。
+			log.Printf("[UsagePartition] task interrupted: task=%d type=%s err=%v", task.ID, task.TaskType, err)
+			return
+		}
+		s.markTaskFailed(task, err)
+		return
+	}
+
+	updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.MarkTaskSucceeded(updateCtx, task.ID); err != nil {
+		log.Printf("[UsagePartition] update task succeeded failed: task=%d err=%v", task.ID, err)
+		return
+	}
+	log.Printf("[UsagePartition] task succeeded: task=%d type=%s partition=%s rows=%d duration=%s", task.ID, task.TaskType, task.PartitionName, task.ProcessedRows, time.Since(start))
+}
+
+// executeConvert 在线转换：准备影子分区表 → 按 id 分批复制 → 追平后锁表切换
+// 返回 yielded=true 表示本轮执行时长用尽或切换拿不到锁，任务已放回 pending。
+func (s *UsagePartitionService) executeConvert(ctx context.Context, task *UsagePartitionTask) (bool, error) {
+	partitioned, err := s.repo.IsPartitioned(ctx)
+	if err != nil {
+		return false, err
+	}
+	if partitioned {
+		// 上一轮已完成切换
+		return false, nil
+	}
+
+	total, err := s.repo.PrepareConversion(ctx, time.Now(), s.aheadMonths())
+	if err != nil {
+		return false, fmt.Errorf("prepare conversion: %w", err)
+	}
+	task.TotalRows = total
+
+	deadline := time.Now().Add(s.taskTimeout())
+	batchSize := s.batchSize()
+	for {
+		if time.Now().After(deadline) {
+			return true, s.yieldTask(task)
+		}
+		lastID, copied, err := s.repo.CopyConversionBatch(ctx, task.CursorID, batchSize)
+		if err != nil {
+			return false, fmt.Errorf("copy batch after id %d: %w", task.CursorID, err)
+		}
+		if copied > 0 {
+			task.CursorID = lastID
+			task.ProcessedRows += copied
+			s.updateProgress(task)
+		}
+		if copied < int64(batchSize) {
+			break
+		}
+	}
+
+	if err := s.repo.SwapConversion(ctx, task.CursorID); err != nil {
+		if errors.Is(err, ErrUsagePartitionSwapBusy) {
+			log.Printf("[UsagePartition] swap lock busy, retry next round: task=%d", task.ID)
+			return true, s.yieldTask(task)
+		}
+		return false, fmt.Errorf("swap tables: %w", err)
+	}
+	log.Printf("[UsagePartition] usage_logs converted to partitioned table; old table kept as usage_logs_legacy: task=%d", task.ID)
+	return false, nil
+}
+
+// executeDedupeBackfill 从最早的使用记录起按 created_at 时间窗分批登记到 usage_log_request_ids，
+// 追平到本轮开始时间即完成（之后写入的记录已由写入路径登记）；执行时长用尽时让出并保留游标。
+func (s *UsagePartitionService) executeDedupeBackfill(ctx context.Context, task *UsagePartitionTask) (bool, error) {
+	end := time.Now()
+	cursor := time.Unix(task.CursorID, 0).UTC()
+	if task.CursorID == 0 {
+		oldest, err := s.repo.OldestUsageLogTime(ctx)
+		if err != nil {
+			return false, fmt.Errorf("find oldest usage log: %w", err)
+		}
+		if oldest == nil {
+			return false, nil
+		}
+		cursor = oldest.UTC().Truncate(time.Second)
+	}
+
+	deadline := time.Now().Add(s.taskTimeout())
+	for cursor.Before(end) {
+		if time.Now().After(deadline) {
+			return true, s.yieldTask(task)
+		}
+		next := cursor.Add(usageDedupeBackfillWindow)
+		n, err := s.repo.BackfillRequestIDs(ctx, cursor, next)
+		if err != nil {
+			return false, fmt.Errorf("backfill request ids from %s: %w", cursor.Format(time.RFC3339), err)
+		}
+		task.CursorID = next.Unix()
+		task.ProcessedRows += n
+		s.updateProgress(task)
+		cursor = next
+	}
+	return false, nil
+}
+
+// executeArchive 导出分区为 gzip JSON Lines，保存后分离并删除分区
+func (s *UsagePartitionService) executeArchive(ctx context.Context, task *UsagePartitionTask) error {
+	name := task.PartitionName
+	month, ok := ParseUsageLogPartitionName(name)
+	if !ok {
+		return fmt.Errorf("invalid partition name %q", name)
+	}
+	if s.store == nil {
+		return ErrUsagePartitionDisabled
+	}
+
+	existing, err := s.repo.GetArchiveByPartition(ctx, name)
+	if err != nil && !errors.Is(err, ErrUsageLogArchiveNotFound) {
+		return err
+	}
+	if existing != nil && existing.Status == UsageLogArchiveStatusRestored {
+		// 回灌数据与归档文件一致，到期后直接分离，无需重新归档
+		if err := s.repo.DetachAndDropPartition(ctx, name); err != nil {
+			return err
+		}
+		return s.repo.MarkArchiveDetached(ctx, existing.ID)
+	}
+
+	total, err := s.repo.CountPartitionRows(ctx, name)
+	if err != nil {
+		return err
+	}
+	task.TotalRows = total
+	s.updateProgress(task)
+
+	dir := s.archiveDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmpPath := filepath.Join(dir, name+usageLogArchiveExt+".tmp")
+	size, checksum, err := s.writeArchive(ctx, task, tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if task.ProcessedRows != total {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("row count mismatch: counted %d, exported %d", total, task.ProcessedRows)
+	}
+
+	location, err := s.store.Put(ctx, name+usageLogArchiveExt, tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("store archive: %w", err)
+	}
+	archive := &UsageLogArchive{
+		PartitionName: name,
+		RangeStart:    month,
+		RangeEnd:      month.AddDate(0, 1, 0),
+		RowCount:      total,
+		Storage:       s.store.Kind(),
+		Location:      location,
+		FileSize:      size,
+		Checksum:      checksum,
+	}
+	if err := s.repo.UpsertArchive(ctx, archive); err != nil {
+		return err
+	}
+	return s.repo.DetachAndDropPartition(ctx, name)
+}
+
+func (s *UsagePartitionService) writeArchive(ctx context.Context, task *UsagePartitionTask, path string) (int64, string, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	hasher := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(file, hasher))
+	task.ProcessedRows = 0
+	err = s.repo.StreamPartitionRows(ctx, task.PartitionName, s.batchSize(), func(rows [][]byte) error {
+		for _, row := range rows {
+			if _, err := gz.Write(row); err != nil {
+				return err
+			}
+			if _, err := gz.Write([]byte{'\n'}); err != nil {
+				return err
+			}
+		}
+		task.ProcessedRows += int64(len(rows))
+		s.updateProgress(task)
+		return nil
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, "", err
+	}
+	if err := file.Sync(); err != nil {
+		return 0, "", err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+	return info.Size(), hex.EncodeToString(hasher.Sum(nil)), file.Close()
+}
+
+// executeRestore 读取归档并批量写回 usage_logs（已存在的行忽略），完成后校验文件摘要
+func (s *UsagePartitionService) executeRestore(ctx context.Context, task *UsagePartitionTask) error {
+	if task.ArchiveID == nil {
+		return errors.New("restore task has no archive")
+	}
+	if s.store == nil {
+		return ErrUsagePartitionDisabled
+	}
+	archive, err := s.repo.GetArchive(ctx, *task.ArchiveID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.EnsurePartition(ctx, archive.RangeStart); err != nil {
+		return err
+	}
+	task.TotalRows = archive.RowCount
+	task.ProcessedRows = 0
+	s.updateProgress(task)
+
+	rc, err := s.store.Open(ctx, archive.Location)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	hasher := sha256.New()
+	tee := io.TeeReader(rc, hasher)
+	gz, err := gzip.NewReader(tee)
+	if err != nil {
+		return fmt.Errorf("open gzip: %w", err)
+	}
+	reader := bufio.NewReaderSize(gz, 1<<20)
+
+	batchSize := s.batchSize()
+	batch := make([][]byte, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := s.repo.InsertArchivedRows(ctx, batch); err != nil {
+			return err
+		}
+		task.ProcessedRows += int64(len(batch))
+		s.updateProgress(task)
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			batch = append(batch, line)
+			if len(batch) >= batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("read archive: %w", readErr)
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(hasher.Sum(nil)); sum != archive.Checksum {
+		return fmt.Errorf("archive checksum mismatch: expected %s, got %s", archive.Checksum, sum)
+	}
+	return s.repo.MarkArchiveRestored(ctx, archive.ID, time.Now().Add(s.restoreKeep()))
+}
+
+func (s *UsagePartitionService) updateProgress(task *UsagePartitionTask) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := s.repo.UpdateTaskProgress(ctx, task.ID, task.CursorID, task.TotalRows, task.ProcessedRows); err != nil {
+		log.Printf("[UsagePartition] task progress update failed: task=%d processed=%d err=%v", task.ID, task.ProcessedRows, err)
+	}
+}
+
+func (s *UsagePartitionService) yieldTask(task *UsagePartitionTask) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.YieldTask(ctx, task.ID); err != nil {
+		return err
+	}
+	log.Printf("[UsagePartition] task yielded: task=%d cursor=%d processed=%d", task.ID, task.CursorID, task.ProcessedRows)
+	return nil
+}
+
+func (s *UsagePartitionService) markTaskFailed(task *UsagePartitionTask, err error) {
+	msg := strings.TrimSpace(err.Error())
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	log.Printf("[UsagePartition] task failed: task=%d type=%s partition=%s err=%s", task.ID, task.TaskType, task.PartitionName, msg)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if updateErr := s.repo.MarkTaskFailed(ctx, task.ID, msg); updateErr != nil {
+		log.Printf("[UsagePartition] update task failed failed: task=%d err=%v", task.ID, updateErr)
+	}
+}
+
+// retentionCutoff 早于该月（UTC）的分区超出保留期
+func (s *UsagePartitionService) retentionCutoff(now time.Time) time.Time {
+	return usagePartitionMonth(now).AddDate(0, -s.cfg.UsagePartition.Archive.RetentionMonths, 0)
+}
+
+func usagePartitionMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *UsagePartitionService) enabled() bool {
+	return s.cfg == nil || s.cfg.UsagePartition.Enabled
+}
+
+func (s *UsagePartitionService) archiveEnabled() bool {
+	return s.cfg != nil && s.cfg.UsageLogsRetentionManaged() && s.cfg.UsagePartition.Archive.RetentionMonths > 0
+}
+
+func (s *UsagePartitionService) workerInterval() time.Duration {
+	if s.cfg == nil || s.cfg.UsagePartition.WorkerIntervalSeconds <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(s.cfg.UsagePartition.WorkerIntervalSeconds) * time.Second
+}
+
+func (s *UsagePartitionService) taskTimeout() time.Duration {
+	if s.cfg == nil || s.cfg.UsagePartition.TaskTimeoutSeconds <= 0 {
+		return time.Hour
+	}
+	return time.Duration(s.cfg.UsagePartition.TaskTimeoutSeconds) * time.Second
+}
+
+func (s *UsagePartitionService) aheadMonths() int {
+	if s.cfg == nil || s.cfg.UsagePartition.AheadMonths <= 0 {
+		return 3
+	}
+	return s.cfg.UsagePartition.AheadMonths
+}
+
+func (s *UsagePartitionService) batchSize() int {
+	if s.cfg == nil || s.cfg.UsagePartition.BatchSize <= 0 {
+		return 20000
+	}
+	return s.cfg.UsagePartition.BatchSize
+}
+
+func (s *UsagePartitionService) archiveDir() string {
+	if s.cfg == nil || strings.TrimSpace(s.cfg.UsagePartition.Archive.Dir) == "" {
+		return "./data/usage-archives"
+	}
+	return s.cfg.UsagePartition.Archive.Dir
+}
+
+func (s *UsagePartitionService) restoreKeep() time.Duration {
+	if s.cfg == nil || s.cfg.UsagePartition.Archive.RestoreKeepDays <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(s.cfg.UsagePartition.Archive.RestoreKeepDays) * 24 * time.Hour
+}
@@ -0,0 +1,460 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type usagePartitionRepoStub struct {
+	mu            sync.Mutex
+	partitioned   bool
+	partitions    []UsageLogPartition
+	created       []*UsagePartitionTask
+	activeTasks   map[string]bool // taskType/partition
+	succeeded     map[string]bool // taskType
+	cleanupActive bool
+	archives      map[string]*UsageLogArchive
+	ensured       []time.Time
+	dropped       []string
+	yielded       []int64
+	progress      []int64
+
+	// 在线转换
+	sourceRows int64
+	swapErr    error
+	swapAfter  []int64
+
+	// 归档 / 回灌
+	partitionRows [][]byte
+	inserted      [][]byte
+	restoredUntil map[int64]time.Time
+	detached      []int64
+
+	// 去重回填
+	oldestLog  *time.Time
+	backfilled [][2]time.Time
+}
+
+func newUsagePartitionRepoStub() *usagePartitionRepoStub {
+	return &usagePartitionRepoStub{
+		activeTasks:   map[string]bool{},
+		succeeded:     map[string]bool{},
+		archives:      map[string]*UsageLogArchive{},
+		restoredUntil: map[int64]time.Time{},
+	}
+}
+
+func (s *usagePartitionRepoStub) CreateTask(ctx context.Context, task *UsagePartitionTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task.ID = int64(len(s.created) + 1)
+	s.created = append(s.created, task)
+	return nil
+}
+
+func (s *usagePartitionRepoStub) ListTasks(ctx context.Context, params pagination.PaginationParams) ([]UsagePartitionTask, *pagination.PaginationResult, error) {
+	return nil, &pagination.PaginationResult{}, nil
+}
+
+func (s *usagePartitionRepoStub) HasActiveTask(ctx context.Context, taskType, partitionName string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.activeTasks[taskType+"/"+partitionName], nil
+}
+
+func (s *usagePartitionRepoStub) ClaimNextPendingTask(ctx context.Context, staleRunningAfterSeconds int64) (*UsagePartitionTask, error) {
+	return nil, nil
+}
+
+func (s *usagePartitionRepoStub) UpdateTaskProgress(ctx context.Context, taskID, cursorID, totalRows, processedRows int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress = append(s.progress, processedRows)
+	return nil
+}
+
+func (s *usagePartitionRepoStub) YieldTask(ctx context.Context, taskID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.yielded = append(s.yielded, taskID)
+	return nil
+}
+
+func (s *usagePartitionRepoStub) MarkTaskSucceeded(ctx context.Context, taskID int64) error {
+	return nil
+}
+
+func (s *usagePartitionRepoStub) MarkTaskFailed(ctx context.Context, taskID int64, errorMsg string) error {
+	return nil
+}
+
+func (s *usagePartitionRepoStub) HasSucceededTask(ctx context.Context, taskType string) (bool, error) {
+	return s.succeeded[taskType], nil
+}
+
+func (s *usagePartitionRepoStub) HasActiveCleanupTasks(ctx context.Context) (bool, error) {
+	return s.cleanupActive, nil
+}
+
+func (s *usagePartitionRepoStub) ListArchives(ctx context.Context, params pagination.PaginationParams) ([]UsageLogArchive, *pagination.PaginationResult, error) {
+	return nil, &pagination.PaginationResult{}, nil
+}
+
+func (s *usagePartitionRepoStub) GetArchive(ctx context.Context, archiveID int64) (*UsageLogArchive, error) {
+	for _, a := range s.archives {
+		if a.ID == archiveID {
+			return a, nil
+		}
+	}
+	return nil, ErrUsageLogArchiveNotFound
+}
+
+func (s *usagePartitionRepoStub) GetArchiveByPartition(ctx context.Context, partitionName string) (*UsageLogArchive, error) {
+	if a, ok := s.archives[partitionName]; ok {
+		return a, nil
+	}
+	return nil, ErrUsageLogArchiveNotFound
+}
+
+func (s *usagePartitionRepoStub) UpsertArchive(ctx context.Context, archive *UsageLogArchive) error {
+	archive.ID = int64(len(s.archives) + 1)
+	archive.Status = UsageLogArchiveStatusArchived
+	s.archives[archive.PartitionName] = archive
+	return nil
+}
+
+func (s *usagePartitionRepoStub) MarkArchiveRestored(ctx context.Context, archiveID int64, restoredUntil time.Time) error {
+	s.restoredUntil[archiveID] = restoredUntil
+	return nil
+}
+
+func (s *usagePartitionRepoStub) MarkArchiveDetached(ctx context.Context, archiveID int64) error {
+	s.detached = append(s.detached, archiveID)
+	return nil
+}
+
+func (s *usagePartitionRepoStub) IsPartitioned(ctx context.Context) (bool, error) {
+	return s.partitioned, nil
+}
+
+func (s *usagePartitionRepoStub) ListPartitions(ctx context.Context) ([]UsageLogPartition, error) {
+	return s.partitions, nil
+}
+
+func (s *usagePartitionRepoStub) EnsurePartition(ctx context.Context, month time.Time) error {
+	s.ensured = append(s.ensured, month)
+	return nil
+}
+
+func (s *usagePartitionRepoStub) DetachAndDropPartition(ctx context.Context, partitionName string) error {
+	s.dropped = append(s.dropped, partitionName)
+	return nil
+}
+
+func (s *usagePartitionRepoStub) CountPartitionRows(ctx context.Context, partitionName string) (int64, error) {
+	return int64(len(s.partitionRows)), nil
+}
+
+func (s *usagePartitionRepoStub) StreamPartitionRows(ctx context.Context, partitionName string, batchSize int, fn func(rows [][]byte) error) error {
+	for start := 0; start < len(s.partitionRows); start += batchSize {
+		end := min(start+batchSize, len(s.partitionRows))
+		if err := fn(s.partitionRows[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *usagePartitionRepoStub) InsertArchivedRows(ctx context.Context, rows [][]byte) (int64, error) {
+	for _, row := range rows {
+		s.inserted = append(s.inserted, append([]byte(nil), row...))
+	}
+	return int64(len(rows)), nil
+}
+
+func (s *usagePartitionRepoStub) LegacyTableExists(ctx context.Context) (bool, error) {
+	return false, nil
+}
+
+func (s *usagePartitionRepoStub) DropLegacyTable(ctx context.Context) error {
+	return nil
+}
+
+func (s *usagePartitionRepoStub) PrepareConversion(ctx context.Context, now time.Time, aheadMonths int) (int64, error) {
+	return s.sourceRows, nil
+}
+
+// CopyConversionBatch 模拟 id 为 1..sourceRows 的源表
+func (s *usagePartitionRepoStub) CopyConversionBatch(ctx context.Context, afterID int64, limit int) (int64, int64, error) {
+	remaining := s.sourceRows - afterID
+	if remaining <= 0 {
+		return afterID, 0, nil
+	}
+	n := min(remaining, int64(limit))
+	return afterID + n, n, nil
+}
+
+func (s *usagePartitionRepoStub) SwapConversion(ctx context.Context, afterID int64) error {
+	s.swapAfter = append(s.swapAfter, afterID)
+	return s.swapErr
+}
+
+func (s *usagePartitionRepoStub) OldestUsageLogTime(ctx context.Context) (*time.Time, error) {
+	return s.oldestLog, nil
+}
+
+// BackfillRequestIDs 每个时间窗模拟登记 1 条
+func (s *usagePartitionRepoStub) BackfillRequestIDs(ctx context.Context, from, to time.Time) (int64, error) {
+	s.backfilled = append(s.backfilled, [2]time.Time{from, to})
+	return 1, nil
+}
+
+// usageLogArchiveStoreStub 本地目录存储
+type usageLogArchiveStoreStub struct {
+	dir string
+}
+
+func (s *usageLogArchiveStoreStub) Kind() string { return "local" }
+
+func (s *usageLogArchiveStoreStub) Put(ctx context.Context, name, localPath string) (string, error) {
+	target := filepath.Join(s.dir, name)
+	return target, os.Rename(localPath, target)
+}
+
+func (s *usageLogArchiveStoreStub) Open(ctx context.Context, location string) (io.ReadCloser, error) {
+	return os.Open(location)
+}
+
+func newUsagePartitionTestService(repo *usagePartitionRepoStub, dir string) *UsagePartitionService {
+	cfg := &config.Config{}
+	cfg.UsagePartition = config.UsagePartitionConfig{
+		Enabled:            true,
+		TaskTimeoutSeconds: 60,
+		AheadMonths:        2,
+		BatchSize:          2,
+		Archive: config.UsagePartitionArchiveConfig{
+			Enabled:         true,
+			RetentionMonths: 3,
+			Storage:         "local",
+			Dir:             dir,
+			RestoreKeepDays: 7,
+		},
+	}
+	return NewUsagePartitionService(repo, &usageLogArchiveStoreStub{dir: dir}, nil, cfg)
+}
+
+func TestParseUsageLogPartitionName(t *testing.T) {
+	month, ok := ParseUsageLogPartitionName("usage_logs_202602")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), month)
+	require.Equal(t, "usage_logs_202602", UsageLogPartitionName(month))
+
+	for _, name := range []string{"usage_logs_default", "usage_logs_2026", "usage_logs_legacy", "usage_logs", "other_202602"} {
+		_, ok := ParseUsageLogPartitionName(name)
+		require.False(t, ok, name)
+	}
+}
+
+func TestUsagePartitionService_MaintainEnsuresAndSchedulesArchives(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	repo := newUsagePartitionRepoStub()
+	repo.partitioned = true
+	month := func(m time.Month) time.Time { return time.Date(2026, m, 1, 0, 0, 0, 0, time.UTC) }
+	repo.partitions = []UsageLogPartition{
+		{Name: "usage_logs_default", IsDefault: true},
+		{Name: "usage_logs_202605", Month: month(5)},
+		{Name: "usage_logs_202606", Month: month(6)},
+		{Name: "usage_logs_202607", Month: month(7)}, // cutoff 之前，但已有任务
+		{Name: "usage_logs_202608", Month: month(8)}, // 保留期内
+	}
+	repo.activeTasks[UsagePartitionTaskArchive+"/usage_logs_202607"] = true
+	restoredUntil := now.Add(24 * time.Hour)
+	repo.archives["usage_logs_202606"] = &UsageLogArchive{ID: 9, PartitionName: "usage_logs_202606", Status: UsageLogArchiveStatusRestored, RestoredUntil: &restoredUntil}
+	repo.succeeded[UsagePartitionTaskDedupeBackfill] = true
+
+	svc := newUsagePartitionTestService(repo, t.TempDir())
+	svc.maintain(context.Background(), now)
+
+	require.Equal(t, []time.Time{month(10), month(11), month(12)}, repo.ensured)
+	require.Len(t, repo.created, 1)
+	require.Equal(t, UsagePartitionTaskArchive, repo.created[0].TaskType)
+	require.Equal(t, "usage_logs_202605", repo.created[0].PartitionName)
+}
+
+func TestUsagePartitionService_MaintainSkipsUnpartitionedTable(t *testing.T) {
+	repo := newUsagePartitionRepoStub()
+	repo.succeeded[UsagePartitionTaskDedupeBackfill] = true
+	svc := newUsagePartitionTestService(repo, t.TempDir())
+	svc.maintain(context.Background(), time.Now())
+	require.Empty(t, repo.ensured)
+	require.Empty(t, repo.created)
+}
+
+func TestUsagePartitionService_MaintainSchedulesDedupeBackfillOnce(t *testing.T) {
+	repo := newUsagePartitionRepoStub()
+	svc := newUsagePartitionTestService(repo, t.TempDir())
+
+	// 未转换为分区表时同样创建回填任务
+	svc.maintain(context.Background(), time.Now())
+	require.Len(t, repo.created, 1)
+	require.Equal(t, UsagePartitionTaskDedupeBackfill, repo.created[0].TaskType)
+	require.Empty(t, repo.ensured)
+
+	repo.activeTasks[UsagePartitionTaskDedupeBackfill+"/"] = true
+	svc.maintain(context.Background(), time.Now())
+	require.Len(t, repo.created, 1)
+
+	repo.activeTasks = map[string]bool{}
+	repo.succeeded[UsagePartitionTaskDedupeBackfill] = true
+	svc.maintain(context.Background(), time.Now())
+	require.Len(t, repo.created, 1)
+}
+
+func TestUsagePartitionService_DedupeBackfillWalksTimeWindows(t *testing.T) {
+	repo := newUsagePartitionRepoStub()
+	oldest := time.Now().Add(-150 * time.Minute)
+	repo.oldestLog = &oldest
+	svc := newUsagePartitionTestService(repo, t.TempDir())
+	task := &UsagePartitionTask{ID: 1, TaskType: UsagePartitionTaskDedupeBackfill}
+
+	yielded, err := svc.executeDedupeBackfill(context.Background(), task)
+	require.NoError(t, err)
+	require.False(t, yielded)
+	require.Len(t, repo.backfilled, 3)
+	start := oldest.UTC().Truncate(time.Second)
+	for i, window := range repo.backfilled {
+		require.Equal(t, start.Add(time.Duration(i)*time.Hour), window[0])
+		require.Equal(t, window[0].Add(usageDedupeBackfillWindow), window[1])
+	}
+	require.Equal(t, repo.backfilled[2][1].Unix(), task.CursorID)
+	require.Equal(t, int64(3), task.ProcessedRows)
+	require.Equal(t, []int64{1, 2, 3}, repo.progress)
+
+	// 从游标继续：已追平时不再回填
+	yielded, err = svc.executeDedupeBackfill(context.Background(), task)
+	require.NoError(t, err)
+	require.False(t, yielded)
+	require.Len(t, repo.backfilled, 3)
+
+	// 空表直接完成
+	empty := newUsagePartitionRepoStub()
+	yielded, err = newUsagePartitionTestService(empty, t.TempDir()).executeDedupeBackfill(context.Background(), &UsagePartitionTask{ID: 2})
+	require.NoError(t, err)
+	require.False(t, yielded)
+	require.Empty(t, empty.backfilled)
+}
+
+func TestUsagePartitionService_ConvertCopiesBatchesThenSwaps(t *testing.T) {
+	repo := newUsagePartitionRepoStub()
+	repo.sourceRows = 5
+	svc := newUsagePartitionTestService(repo, t.TempDir())
+	task := &UsagePartitionTask{ID: 1, TaskType: UsagePartitionTaskConvert}
+
+	yielded, err := svc.executeConvert(context.Background(), task)
+	require.NoError(t, err)
+	require.False(t, yielded)
+	require.Equal(t, int64(5), task.CursorID)
+	require.Equal(t, int64(5), task.ProcessedRows)
+	require.Equal(t, []int64{2, 4, 5}, repo.progress)
+	require.Equal(t, []int64{5}, repo.swapAfter)
+}
+
+func TestUsagePartitionService_ConvertYieldsWhenSwapBusy(t *testing.T) {
+	repo := newUsagePartitionRepoStub()
+	repo.sourceRows = 3
+	repo.swapErr = ErrUsagePartitionSwapBusy
+	svc := newUsagePartitionTestService(repo, t.TempDir())
+	task := &UsagePartitionTask{ID: 7, TaskType: UsagePartitionTaskConvert, CursorID: 2, ProcessedRows: 2}
+
+	yielded, err := svc.executeConvert(context.Background(), task)
+	require.NoError(t, err)
+	require.True(t, yielded)
+	require.Equal(t, []int64{7}, repo.yielded)
+	require.Equal(t, int64(3), task.CursorID)
+}
+
+func TestUsagePartitionService_StartConversionRejectsActiveCleanup(t *testing.T) {
+	repo := newUsagePartitionRepoStub()
+	repo.cleanupActive = true
+	svc := newUsagePartitionTestService(repo, t.TempDir())
+
+	_, err := svc.StartConversion(context.Background(), 1)
+	require.ErrorIs(t, err, ErrUsagePartitionCleanupRunning)
+
+	repo.cleanupActive = false
+	repo.partitioned = true
+	_, err = svc.StartConversion(context.Background(), 1)
+	require.ErrorIs(t, err, ErrUsageLogsAlreadyPartitioned)
+	require.Empty(t, repo.created)
+}
+
+func TestUsagePartitionService_ArchiveAndRestoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	repo := newUsagePartitionRepoStub()
+	repo.partitioned = true
+	for i := 1; i <= 5; i++ {
+		repo.partitionRows = append(repo.partitionRows, []byte(fmt.Sprintf(`{"id":%d,"model":"claude"}`, i)))
+	}
+	svc := newUsagePartitionTestService(repo, dir)
+
+	archiveTask := &UsagePartitionTask{ID: 1, TaskType: UsagePartitionTaskArchive, PartitionName: "usage_logs_202501"}
+	require.NoError(t, svc.executeArchive(context.Background(), archiveTask))
+	require.Equal(t, []string{"usage_logs_202501"}, repo.dropped)
+
+	archive := repo.archives["usage_logs_202501"]
+	require.NotNil(t, archive)
+	require.Equal(t, int64(5), archive.RowCount)
+	require.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), archive.RangeStart)
+	require.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), archive.RangeEnd)
+	require.Equal(t, filepath.Join(dir, "usage_logs_202501.ndjson.gz"), archive.Location)
+	info, err := os.Stat(archive.Location)
+	require.NoError(t, err)
+	require.Equal(t, info.Size(), archive.FileSize)
+	_, err = os.Stat(archive.Location + ".tmp")
+	require.True(t, os.IsNotExist(err))
+
+	restoreTask := &UsagePartitionTask{ID: 2, TaskType: UsagePartitionTaskRestore, ArchiveID: &archive.ID}
+	require.NoError(t, svc.executeRestore(context.Background(), restoreTask))
+	require.Equal(t, repo.partitionRows, repo.inserted)
+	require.Equal(t, []time.Time{archive.RangeStart}, repo.ensured)
+	require.Contains(t, repo.restoredUntil, archive.ID)
+	require.Equal(t, int64(5), restoreTask.ProcessedRows)
+}
+
+func TestUsagePartitionService_RestoreRejectsChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	repo := newUsagePartitionRepoStub()
+	repo.partitioned = true
+	repo.partitionRows = [][]byte{[]byte(`{"id":1}`)}
+	svc := newUsagePartitionTestService(repo, dir)
+
+	require.NoError(t, svc.executeArchive(context.Background(), &UsagePartitionTask{ID: 1, TaskType: UsagePartitionTaskArchive, PartitionName: "usage_logs_202501"}))
+	archive := repo.archives["usage_logs_202501"]
+	archive.Checksum = "deadbeef"
+
+	err := svc.executeRestore(context.Background(), &UsagePartitionTask{ID: 2, TaskType: UsagePartitionTaskRestore, ArchiveID: &archive.ID})
+	require.ErrorContains(t, err, "checksum mismatch")
+	require.Empty(t, repo.restoredUntil)
+}
+
+func TestUsagePartitionService_ArchiveDetachesExpiredRestore(t *testing.T) {
+	repo := newUsagePartitionRepoStub()
+	repo.archives["usage_logs_202501"] = &UsageLogArchive{ID: 3, PartitionName: "usage_logs_202501", Status: UsageLogArchiveStatusRestored}
+	svc := newUsagePartitionTestService(repo, t.TempDir())
+
+	require.NoError(t, svc.executeArchive(context.Background(), &UsagePartitionTask{ID: 1, TaskType: UsagePartitionTaskArchive, PartitionName: "usage_logs_202501"}))
+	require.Equal(t, []string{"usage_logs_202501"}, repo.dropped)
+	require.Equal(t, []int64{3}, repo.detached)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+// UsageTagsHeader 客户端携带成本归属标签的请求头，格式：key=value,key=value
+const UsageTagsHeader = "X-Sub2API-Tags"
+
+const (
+	maxUsageTagsPerRequest = 10
+	maxAllowedTagKeys      = 20
+	usageTagKeyMaxLen      = 32
+	usageTagValueMaxLen    = 64
+)
+
+var (
+	usageTagKeyPattern   = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+	usageTagValuePattern = regexp.MustCompile(`^[A-Za-z0-9_.:/@-]+$`)
+
+	ErrUsageTagKeyNotAllowed  = infraerrors.BadRequest("USAGE_TAG_KEY_NOT_ALLOWED", "usage tag key is not allowed for this API key")
+	ErrAllowedTagKeysInvalid  = infraerrors.BadRequest("ALLOWED_TAG_KEYS_INVALID", "invalid allowed tag keys")
+	errUsageTagsTooMany       = fmt.Errorf("at most %d tags per request", maxUsageTagsPerRequest)
+	errUsageTagMissingEqual   = errors.New("tag must be in key=value form")
+	errUsageTagDuplicateKey   = errors.New("duplicate tag key")
+	errUsageTagKeyMalformed   = fmt.Errorf("tag key must match %s and be at most %d characters", usageTagKeyPattern.String(), usageTagKeyMaxLen)
+	errUsageTagValueMalformed = fmt.Errorf("tag value must match %s and be at most %d characters", usageTagValuePattern.String(), usageTagValueMaxLen)
+)
+
+// parseUsageTags 解析 key=value,key=value 形式的标签（键统一小写）
+func parseUsageTags(raw string) (map[string]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	parts := strings.Split(raw, ",")
+	tags := make(map[string]string, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, errUsageTagMissingEqual
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if !validUsageTagKey(key) {
+			return nil, errUsageTagKeyMalformed
+		}
+		if len(value) > usageTagValueMaxLen || !usageTagValuePattern.MatchString(value) {
+			return nil, errUsageTagValueMalformed
+		}
+		if _, dup := tags[key]; dup {
+			return nil, errUsageTagDuplicateKey
+		}
+		tags[key] = value
+	}
+	if len(tags) > maxUsageTagsPerRequest {
+		return nil, errUsageTagsTooMany
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return tags, nil
+}
+
+func validUsageTagKey(key string) bool {
+	return len(key) <= usageTagKeyMaxLen && usageTagKeyPattern.MatchString(key)
+}
+
+// NormalizeAllowedTagKeys 校验并规范化标签键白名单（小写、去重、排序），空列表返回 nil
+func NormalizeAllowedTagKeys(keys []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(keys))
+	out := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		if !validUsageTagKey(key) {
+			return nil, ErrAllowedTagKeysInvalid.WithMetadata(map[string]string{"key": key})
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, key)
+	}
+	if len(out) > maxAllowedTagKeys {
+		return nil, infraerrors.BadRequest("ALLOWED_TAG_KEYS_INVALID", fmt.Sprintf("at most %d allowed tag keys", maxAllowedTagKeys))
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// EffectiveAllowedTagKeys API Key 单独配置的标签键优先，否则使用用户级配置
+func (k *APIKey) EffectiveAllowedTagKeys() []string {
+	if len(k.AllowedTagKeys) > 0 {
+		return k.AllowedTagKeys
+	}
+	if k.User != nil {
+		return k.User.AllowedTagKeys
+	}
+	return nil
+}
+
+// ResolveUsageTags 解析请求携带的成本归属标签。
+//
+// header 为 X-Sub2API-Tags 请求头，格式或标签键不合法时返回 400 错误；
+// fallback 为请求体中的 metadata.user_id（Anthropic）或 user（OpenAI），
+// 仅当其形如 key=value 列表时才解析，不合法或未授权的键静默忽略（这些字段通常另有用途）。
+// 同一个键同时出现时请求头优先。未配置标签键白名单时不记录任何标签，但显式请求头仍会被拒绝。
+func ResolveUsageTags(apiKey *APIKey, header, fallback string) (map[string]string, error) {
+	var allowed []string
+	if apiKey != nil {
+		allowed = apiKey.EffectiveAllowedTagKeys()
+	}
+	allowedSet := make(map[string]struct{}, len(allowed))
+	for _, key := range allowed {
+		allowedSet[key] = struct{}{}
+	}
+
+	tags, err := parseUsageTags(header)
+	if err != nil {
+		return nil, infraerrors.BadRequest("USAGE_TAGS_INVALID", fmt.Sprintf("invalid %s header: %v", UsageTagsHeader, err))
+	}
+	for key := range tags {
+		if _, ok := allowedSet[key]; !ok {
+			return nil, ErrUsageTagKeyNotAllowed.WithMetadata(map[string]string{"key": key})
+		}
+	}
+
+	if fallback = strings.TrimSpace(fallback); fallback != "" && len(allowedSet) > 0 && strings.Contains(fallback, "=") {
+		if bodyTags, err := parseUsageTags(fallback); err == nil {
+			for key, value := range bodyTags {
+				if _, ok := allowedSet[key]; !ok {
+					continue
+				}
+				if _, exists := tags[key]; exists {
+					continue
+				}
+				if tags == nil {
+					tags = make(map[string]string, len(bodyTags))
+				}
+				tags[key] = value
+			}
+		}
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return tags, nil
+}
+
+// NormalizeUsageTagFilter 校验用量查询中的 tag_key / tag_value 参数（键统一小写）
+func NormalizeUsageTagFilter(key, value string) (string, string, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	if key == "" {
+		if value != "" {
+			return "", "", infraerrors.BadRequest("USAGE_TAG_FILTER_INVALID", "tag_value requires tag_key")
+		}
+		return "", "", nil
+	}
+	if !validUsageTagKey(key) {
+		return "", "", infraerrors.BadRequest("USAGE_TAG_FILTER_INVALID", "invalid tag_key")
+	}
+	if value != "" && (len(value) > usageTagValueMaxLen || !usageTagValuePattern.MatchString(value)) {
+		return "", "", infraerrors.BadRequest("USAGE_TAG_FILTER_INVALID", "invalid tag_value")
+	}
+	return key, value, nil
+}
+
+func validateTagUsageFilters(filters *usagestats.TagUsageFilters) error {
+	key, value, err := NormalizeUsageTagFilter(filters.TagKey, filters.TagValue)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return infraerrors.BadRequest("USAGE_TAG_FILTER_INVALID", "tag_key is required")
+	}
+	if !filters.EndTime.After(filters.StartTime) {
+		return infraerrors.BadRequest("USAGE_TAG_FILTER_INVALID", "end time must be after start time")
+	}
+	filters.TagKey, filters.TagValue = key, value
+	return nil
+}
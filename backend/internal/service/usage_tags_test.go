@@ -0,0 +1,80 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveUsageTags_HeaderRequiresAllowedKeys(t *testing.T) {
+	key := &APIKey{AllowedTagKeys: []string{"env", "project"}}
+
+	tags, err := ResolveUsageTags(key, "Project=search, env=prod", "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"project": "search", "env": "prod"}, tags)
+
+	_, err = ResolveUsageTags(key, "team=core", "")
+	require.Equal(t, "USAGE_TAG_KEY_NOT_ALLOWED", infraerrors.Reason(err))
+
+	_, err = ResolveUsageTags(key, "project", "")
+	require.Equal(t, "USAGE_TAGS_INVALID", infraerrors.Reason(err))
+
+	_, err = ResolveUsageTags(key, "project=a,project=b", "")
+	require.Equal(t, "USAGE_TAGS_INVALID", infraerrors.Reason(err))
+
+	// 未配置白名单时显式请求头被拒绝
+	_, err = ResolveUsageTags(&APIKey{}, "env=prod", "")
+	require.Equal(t, "USAGE_TAG_KEY_NOT_ALLOWED", infraerrors.Reason(err))
+}
+
+func TestResolveUsageTags_FallbackIsLenient(t *testing.T) {
+	key := &APIKey{User: &User{AllowedTagKeys: []string{"env", "project"}}}
+
+	// 请求头优先，未授权的键与不合法的 fallback 被忽略
+	tags, err := ResolveUsageTags(key, "env=prod", "env=dev,project=search,team=core")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"env": "prod", "project": "search"}, tags)
+
+	tags, err = ResolveUsageTags(key, "", "user_0123456789abcdef_account__session_x")
+	require.NoError(t, err)
+	require.Nil(t, tags)
+
+	tags, err = ResolveUsageTags(key, "", "env=has space")
+	require.NoError(t, err)
+	require.Nil(t, tags)
+
+	// Key 级配置覆盖用户级配置
+	key.AllowedTagKeys = []string{"team"}
+	tags, err = ResolveUsageTags(key, "", "env=dev,team=core")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "core"}, tags)
+}
+
+func TestNormalizeAllowedTagKeys(t *testing.T) {
+	keys, err := NormalizeAllowedTagKeys([]string{" Project", "env", "project", ""})
+	require.NoError(t, err)
+	require.Equal(t, []string{"env", "project"}, keys)
+
+	keys, err = NormalizeAllowedTagKeys([]string{"  "})
+	require.NoError(t, err)
+	require.Nil(t, keys)
+
+	_, err = NormalizeAllowedTagKeys([]string{"bad key"})
+	require.Equal(t, "ALLOWED_TAG_KEYS_INVALID", infraerrors.Reason(err))
+}
+
+func TestNormalizeUsageTagFilter(t *testing.T) {
+	key, value, err := NormalizeUsageTagFilter(" Env ", "prod")
+	require.NoError(t, err)
+	require.Equal(t, "env", key)
+	require.Equal(t, "prod", value)
+
+	_, _, err = NormalizeUsageTagFilter("", "prod")
+	require.Error(t, err)
+
+	_, _, err = NormalizeUsageTagFilter("env", "bad value")
+	require.Error(t, err)
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// ErrUsageWriteDisabled 未启用异步写入
+var ErrUsageWriteDisabled = infraerrors.ServiceUnavailable("USAGE_WRITE_DISABLED", "async usage write is disabled")
+
+// UsageCharge 一条使用记录对应的扣费明细
+// 异步写入时随使用记录一起进入队列，与使用记录在同一事务内执行数据库侧扣费，
+// 依靠 usage_log_request_ids 的 (request_id, api_key_id) 登记保证重复投递不会重复扣费。
+type UsageCharge struct {
+	UserID   int64 `json:"user_id"`
+	APIKeyID int64 `json:"api_key_id"`
+
+	// 订阅模式：累加订阅用量（TotalCost，不考虑倍率）
+	SubscriptionID     *int64  `json:"subscription_id,omitempty"`
+	SubscriptionUserID int64   `json:"subscription_user_id,omitempty"`
+	GroupID            int64   `json:"group_id,omitempty"`
+	SubscriptionCost   float64 `json:"subscription_cost,omitempty"`
+
+	// 余额模式：扣除用户余额并累计推荐返佣（ActualCost）
+	BalanceCost float64 `json:"balance_cost,omitempty"`
+
+	// 组织 Key：累加成员月度用量，余额模式下同时扣除组织余额
+	OrganizationID            *int64  `json:"organization_id,omitempty"`
+	OrganizationCost          float64 `json:"organization_cost,omitempty"`
+	OrganizationDeductBalance bool    `json:"organization_deduct_balance,omitempty"`
+
+	// API Key 独立配额与日/周/月消费上限
+	QuotaCost float64 `json:"quota_cost,omitempty"`
+	SpendCost float64 `json:"spend_cost,omitempty"`
+}
+
+// UsageWriteEntry 写入队列的一条使用记录
+type UsageWriteEntry struct {
+	Log    *UsageLog   `json:"log"`
+	Charge UsageCharge `json:"charge"`
+}
+
+// UsageWriteMessage 从队列读取的消息
+type UsageWriteMessage struct {
+	ID string
+	// RequestKey 入队时登记的 (api_key_id, request_id) 索引键，确认时一并删除
+	RequestKey string
+	Payload    []byte
+	// Deliveries 已投递次数（首次读取为 1）
+	Deliveries int64
+}
+
+// UsageWriteQueueStats 队列积压情况
+type UsageWriteQueueStats struct {
+	// Length 队列中尚未确认删除的消息数（含未读取与处理中）
+	Length int64
+	// Pending 已投递但尚未确认的消息数
+	Pending int64
+	// DeadLetters 死信队列中的消息数
+	DeadLetters int64
+}
+
+// UsageWriteQueue 使用记录持久化队列（Redis Stream 消费组）
+type UsageWriteQueue interface {
+	// Enqueue 写入消息，并登记 requestKey 直至消息被确认
+	Enqueue(ctx context.Context, requestKey string, payload []byte) error
+	// HasPending requestKey 对应的消息是否仍在队列中（含死信）
+	HasPending(ctx context.Context, requestKey string) (bool, error)
+	// Read 读取新消息，block 为无消息时的最长等待时间
+	Read(ctx context.Context, consumer string, count int, block time.Duration) ([]UsageWriteMessage, error)
+	// ClaimStale 接管空闲超过 minIdle 的未确认消息（包括已崩溃实例遗留的消息）
+	ClaimStale(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]UsageWriteMessage, error)
+	// Ack 确认并删除消息及其 requestKey 登记
+	Ack(ctx context.Context, msgs ...UsageWriteMessage) error
+	// DeadLetter 将消息转入死信队列并从主队列确认删除
+	DeadLetter(ctx context.Context, msg UsageWriteMessage, reason string) error
+	// ReplayDeadLetters 将死信消息重新投递到主队列，返回重新投递的数量
+	ReplayDeadLetters(ctx context.Context, count int) (int, error)
+	Stats(ctx context.Context) (*UsageWriteQueueStats, error)
+}
+
+// UsageLogBatchWriter 批量写入使用记录
+type UsageLogBatchWriter interface {
+	// CreateBatch 多行插入，冲突（重复的 request_id + api_key_id）的记录跳过；
+	// 返回与 logs 等长的标记，表示对应记录是否实际写入
+	CreateBatch(ctx context.Context, logs []*UsageLog) ([]bool, error)
+}
+
+// UsageWriteStats 异步写入运行状态
+type UsageWriteStats struct {
+	Enabled     bool      `json:"enabled"`
+	Backlog     int64     `json:"backlog"`
+	Pending     int64     `json:"pending"`
+	DeadLetters int64     `json:"dead_letters"`
+	Submitted   int64     `json:"submitted"`
+	Fallbacks   int64     `json:"fallbacks"`
+	Inserted    int64     `json:"inserted"`
+	Duplicates  int64     `json:"duplicates"`
+	Failures    int64     `json:"failures"`
+	LastFlushAt time.Time `json:"last_flush_at"`
+}
+
+// usageWriteRequestKey 队列中登记使用记录的索引键
+func usageWriteRequestKey(apiKeyID int64, requestID string) string {
+	return strconv.FormatInt(apiKeyID, 10) + ":" + requestID
+}
+
+// newUsageCharge 按同步路径的规则生成扣费明细
+// quotaUpdater 为 nil 时不扣减 API Key 独立配额（与同步路径中 input.APIKeyService 为空一致）
+func newUsageCharge(user *User, apiKey *APIKey, subscription *UserSubscription, cost *CostBreakdown, isSubscriptionBilling bool, quotaUpdater APIKeyQuotaUpdater) UsageCharge {
+	charge := UsageCharge{UserID: user.ID, APIKeyID: apiKey.ID}
+	if isSubscriptionBilling {
+		if cost.TotalCost > 0 {
+			charge.SubscriptionID = &subscription.ID
+			charge.SubscriptionUserID = subscriptionUserID(user, apiKey)
+			charge.GroupID = *apiKey.GroupID
+			charge.SubscriptionCost = cost.TotalCost
+		}
+	} else if apiKey.OrganizationID == nil && cost.ActualCost > 0 {
+		charge.BalanceCost = cost.ActualCost
+	}
+	if apiKey.OrganizationID != nil {
+		charge.OrganizationID = apiKey.OrganizationID
+		charge.OrganizationCost = cost.ActualCost
+		charge.OrganizationDeductBalance = !isSubscriptionBilling
+	}
+	if cost.ActualCost > 0 && apiKey.Quota > 0 && quotaUpdater != nil {
+		charge.QuotaCost = cost.ActualCost
+	}
+	if cost.ActualCost > 0 && apiKey.HasSpendLimits() {
+		charge.SpendCost = cost.ActualCost
+	}
+	return charge
+}
+
+// applyUsageChargeCache 更新 Redis 扣费缓存（请求结束时立即执行，保证余额检查及时生效）
+func applyUsageChargeCache(billingCache *BillingCacheService, charge UsageCharge) {
+	if billingCache == nil {
+		return
+	}
+	if charge.SubscriptionCost > 0 {
+		billingCache.QueueUpdateSubscriptionUsage(charge.SubscriptionUserID, charge.GroupID, charge.SubscriptionCost)
+	}
+	if charge.BalanceCost > 0 {
+		billingCache.QueueDeductBalance(charge.UserID, charge.BalanceCost)
+	}
+	if charge.SpendCost > 0 {
+		billingCache.QueueUpdateAPIKeySpend(charge.APIKeyID, charge.SpendCost)
+	}
+}
+
+// revertUsageChargeCache 记录被判定为重复时，请求时已扣减的缓存需以数据库为准重建
+func revertUsageChargeCache(ctx context.Context, billingCache *BillingCacheService, charge UsageCharge) {
+	if billingCache == nil {
+		return
+	}
+	if charge.SubscriptionCost > 0 {
+		if err := billingCache.InvalidateSubscription(ctx, charge.SubscriptionUserID, charge.GroupID); err != nil {
+			log.Printf("[UsageWrite] invalidate subscription cache failed: user=%d group=%d err=%v", charge.SubscriptionUserID, charge.GroupID, err)
+		}
+	}
+	if charge.BalanceCost > 0 {
+		if err := billingCache.InvalidateUserBalance(ctx, charge.UserID); err != nil {
+			log.Printf("[UsageWrite] invalidate balance cache failed: user=%d err=%v", charge.UserID, err)
+		}
+	}
+	if charge.SpendCost > 0 {
+		if err := billingCache.InvalidateAPIKeySpend(ctx, charge.APIKeyID); err != nil {
+			log.Printf("[UsageWrite] invalidate api key spend cache failed: api_key=%d err=%v", charge.APIKeyID, err)
+		}
+	}
+}
+
+// chargeUsageDB 执行数据库侧扣费（仅对实际写入的记录调用）
+// 与使用记录写入处于同一事务，任一步失败返回错误由调用方整体回滚，消息保持未确认等待重试。
+func chargeUsageDB(ctx context.Context, userRepo UserRepository, userSubRepo UserSubscriptionRepository, billingCache *BillingCacheService, charge UsageCharge, monthStart time.Time) error {
+	if charge.SubscriptionCost > 0 && charge.SubscriptionID != nil && userSubRepo != nil {
+		if err := userSubRepo.IncrementUsage(ctx, *charge.SubscriptionID, charge.SubscriptionCost); err != nil {
+			return fmt.Errorf("increment subscription usage: %w", err)
+		}
+	}
+	if charge.BalanceCost > 0 && userRepo != nil {
+		if err := userRepo.DeductBalance(ctx, charge.UserID, charge.BalanceCost); err != nil {
+			return fmt.Errorf("deduct balance: %w", err)
+		}
+	}
+	if charge.OrganizationID != nil && billingCache != nil {
+		if err := billingCache.ChargeOrganizationUsage(ctx, *charge.OrganizationID, charge.UserID, charge.OrganizationCost, charge.OrganizationDeductBalance, monthStart); err != nil {
+			return fmt.Errorf("record organization usage: %w", err)
+		}
+	}
+	return nil
+}
+
+// finishUsageCharge 扣费事务提交后执行的附带更新：推荐返佣、组织缓存与 API Key 独立配额。
+// 配额累加会更新 api_keys 行并可能禁用 Key、失效认证缓存，放在事务外避免长时间持有行锁。
+func finishUsageCharge(ctx context.Context, billingCache *BillingCacheService, quotaUpdater APIKeyQuotaUpdater, charge UsageCharge, monthStart time.Time) {
+	if billingCache != nil {
+		if charge.BalanceCost > 0 {
+			billingCache.RecordReferralSpend(ctx, charge.UserID, charge.BalanceCost)
+		}
+		if charge.OrganizationID != nil {
+			billingCache.UpdateOrganizationUsageCache(ctx, *charge.OrganizationID, charge.UserID, charge.OrganizationCost, charge.OrganizationDeductBalance, monthStart)
+		}
+	}
+	if charge.QuotaCost > 0 && quotaUpdater != nil {
+		if err := quotaUpdater.UpdateQuotaUsed(ctx, charge.APIKeyID, charge.QuotaCost); err != nil {
+			log.Printf("Update API key quota failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/google/uuid"
+)
+
+const (
+	usageWriteMaxBackoff = 30 * time.Second
+	usageWriteOpTimeout  = 30 * time.Second
+	// usageWriteReplayMax 单次重放死信的最大数量
+	usageWriteReplayMax = 10000
+	// usageWriteRequestIDPrefix 异步写入为缺少 request_id 的记录分配的 ID 前缀
+	usageWriteRequestIDPrefix = "uw-"
+)
+
+// UsageWriteService 使用记录异步批量写入
+//   - 请求结束时将使用记录与扣费明细写入 Redis Stream，同时立即更新 Redis 扣费缓存
+//   - 后台 worker 以消费组方式读取，在同一事务内按批多行 INSERT 写入 usage_logs 并对实际写入的记录执行数据库侧扣费，
+//     扣费失败时整批回滚，消息保持未确认等待重试
+//   - 未确认的消息在空闲超过 claim_idle_seconds 后由任意 worker 接管，超过 max_retries 次投递转入死信队列
+//
+// 入队失败（Redis 不可用）时 Submit 返回 false，调用方回退为同步写入。
+type UsageWriteService struct {
+	entClient    *dbent.Client
+	queue        UsageWriteQueue
+	batchWriter  UsageLogBatchWriter
+	userRepo     UserRepository
+	userSubRepo  UserSubscriptionRepository
+	billingCache *BillingCacheService
+	quotaUpdater APIKeyQuotaUpdater
+	cfg          *config.Config
+
+	submitted   atomic.Int64
+	fallbacks   atomic.Int64
+	inserted    atomic.Int64
+	duplicates  atomic.Int64
+	failures    atomic.Int64
+	lastFlushAt atomic.Int64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+func NewUsageWriteService(
+	entClient *dbent.Client,
+	queue UsageWriteQueue,
+	batchWriter UsageLogBatchWriter,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	billingCache *BillingCacheService,
+	apiKeyService *APIKeyService,
+	cfg *config.Config,
+) *UsageWriteService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	s := &UsageWriteService{
+		entClient:    entClient,
+		queue:        queue,
+		batchWriter:  batchWriter,
+		userRepo:     userRepo,
+		userSubRepo:  userSubRepo,
+		billingCache: billingCache,
+		cfg:          cfg,
+		workerCtx:    workerCtx,
+		workerCancel: workerCancel,
+	}
+	if apiKeyService != nil {
+		s.quotaUpdater = apiKeyService
+	}
+	return s
+}
+
+func (s *UsageWriteService) Start() {
+	if s == nil {
+		return
+	}
+	if !s.enabled() {
+		log.Printf("[UsageWrite] async write not started (mode=sync)")
+		return
+	}
+	s.startOnce.Do(func() {
+		host, _ := os.Hostname()
+		if host == "" {
+			host = "sub2api"
+		}
+		workers := s.cfg.UsageWrite.Workers
+		for i := 0; i < workers; i++ {
+			consumer := fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i)
+			s.wg.Add(1)
+			go s.runWorker(consumer)
+		}
+		log.Printf("[UsageWrite] started (workers=%d batch_size=%d flush_interval=%s)", workers, s.batchSize(), s.flushInterval())
+	})
+}
+
+// Stop 停止 worker；未确认的消息保留在队列中，由其他实例或重启后接管
+func (s *UsageWriteService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		s.wg.Wait()
+		log.Printf("[UsageWrite] stopped")
+	})
+}
+
+// Enabled 是否启用异步写入
+func (s *UsageWriteService) Enabled() bool {
+	return s != nil && s.enabled()
+}
+
+func (s *UsageWriteService) enabled() bool {
+	return s.cfg != nil && s.cfg.UsageWrite.Async() && s.queue != nil && s.batchWriter != nil
+}
+
+// Submit 将使用记录写入队列，返回 false 表示未入队（未启用或 Redis 不可用），调用方需同步写入
+func (s *UsageWriteService) Submit(ctx context.Context, usageLog *UsageLog, charge UsageCharge) bool {
+	if !s.Enabled() || usageLog == nil {
+		return false
+	}
+	// 关联对象不入队，仅保留 usage_logs 列数据
+	record := *usageLog
+	record.User, record.APIKey, record.Account, record.Group, record.Subscription = nil, nil, nil, nil, nil
+	if record.RequestID == "" {
+		// 无上游请求 ID 时在入队前分配，重复投递与死信重放沿用同一 ID，保证去重生效
+		record.RequestID = usageWriteRequestIDPrefix + uuid.NewString()
+	}
+	entry := UsageWriteEntry{Log: &record, Charge: charge}
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("[UsageWrite] marshal usage log failed: %v", err)
+		s.fallbacks.Add(1)
+		return false
+	}
+	// 请求 context 可能已随客户端断开而取消，入队不应因此失败
+	enqueueCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 3*time.Second)
+	defer cancel()
+	if err := s.queue.Enqueue(enqueueCtx, usageWriteRequestKey(record.APIKeyID, record.RequestID), payload); err != nil {
+		log.Printf("[UsageWrite] enqueue failed, falling back to sync write: %v", err)
+		s.fallbacks.Add(1)
+		return false
+	}
+	s.submitted.Add(1)
+	return true
+}
+
+// Stats 返回队列积压与本实例的处理计数
+func (s *UsageWriteService) Stats(ctx context.Context) (*UsageWriteStats, error) {
+	stats := &UsageWriteStats{}
+	if s == nil {
+		return stats, nil
+	}
+	stats.Enabled = s.enabled()
+	stats.Submitted = s.submitted.Load()
+	stats.Fallbacks = s.fallbacks.Load()
+	stats.Inserted = s.inserted.Load()
+	stats.Duplicates = s.duplicates.Load()
+	stats.Failures = s.failures.Load()
+	if last := s.lastFlushAt.Load(); last > 0 {
+		stats.LastFlushAt = time.Unix(0, last).UTC()
+	}
+	if s.queue == nil {
+		return stats, nil
+	}
+	queueStats, err := s.queue.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.Backlog = queueStats.Length
+	stats.Pending = queueStats.Pending
+	stats.DeadLetters = queueStats.DeadLetters
+	return stats, nil
+}
+
+// HasPendingUsage 指定 request_id 的使用记录是否已入队但尚未写入 usage_logs（含死信）
+func (s *UsageWriteService) HasPendingUsage(ctx context.Context, apiKeyID int64, requestID string) (bool, error) {
+	if s == nil || s.queue == nil {
+		return false, nil
+	}
+	return s.queue.HasPending(ctx, usageWriteRequestKey(apiKeyID, requestID))
+}
+
+// ReplayDeadLetters 将死信重新投递到主队列（修复问题后由管理员触发）
+func (s *UsageWriteService) ReplayDeadLetters(ctx context.Context) (int, error) {
+	if !s.Enabled() {
+		return 0, ErrUsageWriteDisabled
+	}
+	return s.queue.ReplayDeadLetters(ctx, usageWriteReplayMax)
+}
+
+func (s *UsageWriteService) runWorker(consumer string) {
+	defer s.wg.Done()
+	var lastClaim time.Time
+	backoff := time.Duration(0)
+	for {
+		if backoff > 0 && !s.sleep(backoff) {
+			return
+		}
+		if s.workerCtx.Err() != nil {
+			return
+		}
+
+		claimStale := time.Since(lastClaim) >= s.claimIdle()/2
+		if claimStale {
+			lastClaim = time.Now()
+		}
+		msgs, err := s.collect(consumer, claimStale)
+		if err != nil {
+			if s.workerCtx.Err() != nil {
+				return
+			}
+			log.Printf("[UsageWrite] read queue failed: consumer=%s err=%v", consumer, err)
+			backoff = nextUsageWriteBackoff(backoff)
+			continue
+		}
+		if len(msgs) == 0 {
+			backoff = 0
+			continue
+		}
+
+		if err := s.flush(s.workerCtx, msgs); err != nil {
+			log.Printf("[UsageWrite] flush failed: consumer=%s batch=%d err=%v", consumer, len(msgs), err)
+			backoff = nextUsageWriteBackoff(backoff)
+			continue
+		}
+		backoff = 0
+	}
+}
+
+// collect 攒批：读满 batch_size 或等待 flush_interval 后返回
+func (s *UsageWriteService) collect(consumer string, claimStale bool) ([]UsageWriteMessage, error) {
+	batchSize := s.batchSize()
+	batch := make([]UsageWriteMessage, 0, batchSize)
+	if claimStale {
+		claimed, err := s.queue.ClaimStale(s.workerCtx, consumer, s.claimIdle(), batchSize)
+		if err != nil {
+			log.Printf("[UsageWrite] claim stale messages failed: consumer=%s err=%v", consumer, err)
+		}
+		batch = append(batch, claimed...)
+	}
+
+	deadline := time.Now().Add(s.flushInterval())
+	for len(batch) < batchSize {
+		wait := time.Until(deadline)
+		if wait < time.Millisecond {
+			break
+		}
+		msgs, err := s.queue.Read(s.workerCtx, consumer, batchSize-len(batch), wait)
+		if err != nil {
+			if len(batch) > 0 {
+				break
+			}
+			return nil, err
+		}
+		batch = append(batch, msgs...)
+	}
+	return batch, nil
+}
+
+type usageWriteItem struct {
+	msg   UsageWriteMessage
+	entry UsageWriteEntry
+}
+
+// flush 写入一批消息；返回错误时未写入的消息保持未确认状态，等待接管重试
+func (s *UsageWriteService) flush(ctx context.Context, msgs []UsageWriteMessage) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usageWriteOpTimeout)
+	defer cancel()
+
+	items := make([]usageWriteItem, 0, len(msgs))
+	for _, msg := range msgs {
+		var entry UsageWriteEntry
+		if err := json.Unmarshal(msg.Payload, &entry); err != nil || entry.Log == nil {
+			reason := "invalid payload"
+			if err != nil {
+				reason = err.Error()
+			}
+			s.deadLetter(ctx, msg, reason)
+			continue
+		}
+		if entry.Log.RequestID == "" {
+			// 兼容未分配 request_id 时入队的消息：以 Stream 消息 ID 派生，重复投递时保持不变
+			entry.Log.RequestID = usageWriteRequestIDPrefix + msg.ID
+		}
+		items = append(items, usageWriteItem{msg: msg, entry: entry})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	monthStart := timezone.StartOfMonth(time.Now())
+	inserted, err := s.writeBatch(ctx, items, monthStart)
+	if err == nil {
+		for i := range items {
+			s.settle(ctx, items[i].entry, inserted[i], monthStart)
+		}
+		s.ack(ctx, items)
+		return nil
+	}
+
+	// 整批失败时逐条写入，隔离无法写入或扣费失败的记录
+	log.Printf("[UsageWrite] batch write failed, retrying one by one: batch=%d err=%v", len(items), err)
+	done := make([]usageWriteItem, 0, len(items))
+	var lastErr error
+	for _, item := range items {
+		single, err := s.writeBatch(ctx, []usageWriteItem{item}, monthStart)
+		if err != nil {
+			lastErr = err
+			s.failures.Add(1)
+			if item.msg.Deliveries >= int64(s.maxRetries()) {
+				s.deadLetter(ctx, item.msg, err.Error())
+			}
+			continue
+		}
+		s.settle(ctx, item.entry, single[0], monthStart)
+		done = append(done, item)
+	}
+	s.ack(ctx, done)
+	return lastErr
+}
+
+// writeBatch 在同一事务内写入使用记录并对实际写入的记录执行数据库侧扣费，
+// 任一步失败整体回滚，返回与 items 等长的写入标记
+func (s *UsageWriteService) writeBatch(ctx context.Context, items []usageWriteItem, monthStart time.Time) ([]bool, error) {
+	txCtx := ctx
+	var tx *dbent.Tx
+	if s.entClient != nil {
+		var err error
+		tx, err = s.entClient.Tx(ctx)
+		if err != nil && !errors.Is(err, dbent.ErrTxStarted) {
+			return nil, fmt.Errorf("begin transaction: %w", err)
+		}
+		if err == nil {
+			defer func() { _ = tx.Rollback() }()
+			txCtx = dbent.NewTxContext(ctx, tx)
+		}
+	}
+
+	logs := make([]*UsageLog, len(items))
+	for i := range items {
+		logs[i] = items[i].entry.Log
+	}
+	inserted, err := s.batchWriter.CreateBatch(txCtx, logs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if !inserted[i] {
+			continue
+		}
+		if err := chargeUsageDB(txCtx, s.userRepo, s.userSubRepo, s.billingCache, items[i].entry.Charge, monthStart); err != nil {
+			return nil, fmt.Errorf("charge usage: request_id=%s: %w", items[i].entry.Log.RequestID, err)
+		}
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+	return inserted, nil
+}
+
+// settle 在写入事务提交后执行：实际写入的记录完成扣费附带更新；重复记录未扣费，重建请求时已扣减的缓存
+func (s *UsageWriteService) settle(ctx context.Context, entry UsageWriteEntry, inserted bool, monthStart time.Time) {
+	if inserted {
+		s.inserted.Add(1)
+		finishUsageCharge(ctx, s.billingCache, s.quotaUpdater, entry.Charge, monthStart)
+		return
+	}
+	s.duplicates.Add(1)
+	revertUsageChargeCache(ctx, s.billingCache, entry.Charge)
+}
+
+func (s *UsageWriteService) ack(ctx context.Context, items []usageWriteItem) {
+	if len(items) == 0 {
+		return
+	}
+	msgs := make([]UsageWriteMessage, len(items))
+	for i := range items {
+		msgs[i] = items[i].msg
+	}
+	// 确认失败时消息会被再次投递，届时按重复记录处理，不会重复扣费
+	if err := s.queue.Ack(ctx, msgs...); err != nil {
+		log.Printf("[UsageWrite] ack failed: count=%d err=%v", len(msgs), err)
+	}
+	s.lastFlushAt.Store(time.Now().UnixNano())
+}
+
+func (s *UsageWriteService) deadLetter(ctx context.Context, msg UsageWriteMessage, reason string) {
+	log.Printf("ALERT: [UsageWrite] usage record moved to dead letter: id=%s deliveries=%d reason=%s", msg.ID, msg.Deliveries, reason)
+	if err := s.queue.DeadLetter(ctx, msg, reason); err != nil {
+		log.Printf("[UsageWrite] dead letter failed: id=%s err=%v", msg.ID, err)
+	}
+}
+
+func (s *UsageWriteService) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-s.workerCtx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func nextUsageWriteBackoff(cur time.Duration) time.Duration {
+	if cur <= 0 {
+		return 500 * time.Millisecond
+	}
+	cur *= 2
+	if cur > usageWriteMaxBackoff {
+		return usageWriteMaxBackoff
+	}
+	return cur
+}
+
+func (s *UsageWriteService) batchSize() int {
+	if s.cfg == nil || s.cfg.UsageWrite.BatchSize <= 0 {
+		return 500
+	}
+	return s.cfg.UsageWrite.BatchSize
+}
+
+func (s *UsageWriteService) flushInterval() time.Duration {
+	if s.cfg == nil || s.cfg.UsageWrite.FlushIntervalMs <= 0 {
+		return 200 * time.Millisecond
+	}
+	return time.Duration(s.cfg.UsageWrite.FlushIntervalMs) * time.Millisecond
+}
+
+func (s *UsageWriteService) claimIdle() time.Duration {
+	if s.cfg == nil || s.cfg.UsageWrite.ClaimIdleSeconds <= 0 {
+		return time.Minute
+	}
+	return time.Duration(s.cfg.UsageWrite.ClaimIdleSeconds) * time.Second
+}
+
+func (s *UsageWriteService) maxRetries() int {
+	if s.cfg == nil || s.cfg.UsageWrite.MaxRetries <= 0 {
+		return 10
+	}
+	return s.cfg.UsageWrite.MaxRetries
+}
@@ -0,0 +1,418 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type usageWriteQueueStub struct {
+	mu          sync.Mutex
+	enqueueErr  error
+	enqueued    [][]byte
+	requestKeys []string
+	pending     map[string]bool
+	acked       []string
+	dead        []string
+}
+
+func (q *usageWriteQueueStub) Enqueue(ctx context.Context, requestKey string, payload []byte) error {
+	if q.enqueueErr != nil {
+		return q.enqueueErr
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.enqueued = append(q.enqueued, payload)
+	q.requestKeys = append(q.requestKeys, requestKey)
+	if q.pending == nil {
+		q.pending = map[string]bool{}
+	}
+	q.pending[requestKey] = true
+	return nil
+}
+
+func (q *usageWriteQueueStub) HasPending(ctx context.Context, requestKey string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending[requestKey], nil
+}
+
+// message 以第 i 条入队记录构造读取到的消息
+func (q *usageWriteQueueStub) message(i int, id string) UsageWriteMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return UsageWriteMessage{ID: id, RequestKey: q.requestKeys[i], Payload: q.enqueued[i], Deliveries: 1}
+}
+
+func (q *usageWriteQueueStub) Read(ctx context.Context, consumer string, count int, block time.Duration) ([]UsageWriteMessage, error) {
+	return nil, nil
+}
+
+func (q *usageWriteQueueStub) ClaimStale(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]UsageWriteMessage, error) {
+	return nil, nil
+}
+
+func (q *usageWriteQueueStub) Ack(ctx context.Context, msgs ...UsageWriteMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, msg := range msgs {
+		q.acked = append(q.acked, msg.ID)
+		delete(q.pending, msg.RequestKey)
+	}
+	return nil
+}
+
+func (q *usageWriteQueueStub) DeadLetter(ctx context.Context, msg UsageWriteMessage, reason string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dead = append(q.dead, msg.ID)
+	return nil
+}
+
+func (q *usageWriteQueueStub) ReplayDeadLetters(ctx context.Context, count int) (int, error) {
+	return 0, nil
+}
+
+func (q *usageWriteQueueStub) Stats(ctx context.Context) (*UsageWriteQueueStats, error) {
+	return &UsageWriteQueueStats{Length: int64(len(q.enqueued)), DeadLetters: int64(len(q.dead))}, nil
+}
+
+// usageLogBatchWriterStub 按 (request_id, api_key_id) 模拟唯一索引
+type usageLogBatchWriterStub struct {
+	existing map[string]bool
+	// failRequestIDs 包含这些 request_id 的写入返回错误
+	failRequestIDs map[string]bool
+	// discardWrites 模拟写入随事务回滚：不登记已写入的 request_id
+	discardWrites bool
+	calls         int
+}
+
+func (w *usageLogBatchWriterStub) CreateBatch(ctx context.Context, logs []*UsageLog) ([]bool, error) {
+	w.calls++
+	for _, l := range logs {
+		if w.failRequestIDs[l.RequestID] {
+			return nil, errors.New("insert failed")
+		}
+	}
+	if w.existing == nil {
+		w.existing = map[string]bool{}
+	}
+	out := make([]bool, len(logs))
+	for i, l := range logs {
+		if w.existing[l.RequestID] {
+			continue
+		}
+		if !w.discardWrites {
+			w.existing[l.RequestID] = true
+		}
+		out[i] = true
+	}
+	return out, nil
+}
+
+type usageWriteUserRepoStub struct {
+	UserRepository
+	deducted  map[int64]float64
+	deductErr error
+}
+
+func (r *usageWriteUserRepoStub) DeductBalance(ctx context.Context, id int64, amount float64) error {
+	if r.deductErr != nil {
+		return r.deductErr
+	}
+	if r.deducted == nil {
+		r.deducted = map[int64]float64{}
+	}
+	r.deducted[id] += amount
+	return nil
+}
+
+type usageWriteUserSubRepoStub struct {
+	UserSubscriptionRepository
+	incremented map[int64]float64
+}
+
+func (r *usageWriteUserSubRepoStub) IncrementUsage(ctx context.Context, id int64, costUSD float64) error {
+	if r.incremented == nil {
+		r.incremented = map[int64]float64{}
+	}
+	r.incremented[id] += costUSD
+	return nil
+}
+
+type usageWriteBillingCacheStub struct {
+	BillingCache
+	balanceInvalidations      []int64
+	subscriptionInvalidations []int64
+	spendInvalidations        []int64
+}
+
+func (c *usageWriteBillingCacheStub) InvalidateUserBalance(ctx context.Context, userID int64) error {
+	c.balanceInvalidations = append(c.balanceInvalidations, userID)
+	return nil
+}
+
+func (c *usageWriteBillingCacheStub) InvalidateSubscriptionCache(ctx context.Context, userID, groupID int64) error {
+	c.subscriptionInvalidations = append(c.subscriptionInvalidations, userID)
+	return nil
+}
+
+func (c *usageWriteBillingCacheStub) InvalidateAPIKeySpendCache(ctx context.Context, apiKeyID int64, window string) error {
+	c.spendInvalidations = append(c.spendInvalidations, apiKeyID)
+	return nil
+}
+
+type usageWriteQuotaStub struct {
+	used map[int64]float64
+}
+
+func (q *usageWriteQuotaStub) UpdateQuotaUsed(ctx context.Context, apiKeyID int64, cost float64) error {
+	if q.used == nil {
+		q.used = map[int64]float64{}
+	}
+	q.used[apiKeyID] += cost
+	return nil
+}
+
+type usageWriteTestEnv struct {
+	svc     *UsageWriteService
+	queue   *usageWriteQueueStub
+	writer  *usageLogBatchWriterStub
+	users   *usageWriteUserRepoStub
+	subs    *usageWriteUserSubRepoStub
+	cache   *usageWriteBillingCacheStub
+	quota   *usageWriteQuotaStub
+	billing *BillingCacheService
+}
+
+func newUsageWriteTestEnv(t *testing.T) *usageWriteTestEnv {
+	t.Helper()
+	cfg := &config.Config{UsageWrite: config.UsageWriteConfig{
+		Mode:       config.UsageWriteModeRedisStream,
+		Workers:    1,
+		BatchSize:  100,
+		MaxRetries: 3,
+	}}
+	env := &usageWriteTestEnv{
+		queue:  &usageWriteQueueStub{},
+		writer: &usageLogBatchWriterStub{},
+		users:  &usageWriteUserRepoStub{},
+		subs:   &usageWriteUserSubRepoStub{},
+		cache:  &usageWriteBillingCacheStub{},
+		quota:  &usageWriteQuotaStub{},
+	}
+	env.billing = NewBillingCacheService(env.cache, nil, nil, nil, cfg)
+	t.Cleanup(env.billing.Stop)
+	env.svc = NewUsageWriteService(nil, env.queue, env.writer, env.users, env.subs, env.billing, nil, cfg)
+	env.svc.quotaUpdater = env.quota
+	return env
+}
+
+func usageWriteTestMessage(t *testing.T, id string, deliveries int64, entry UsageWriteEntry) UsageWriteMessage {
+	t.Helper()
+	payload, err := json.Marshal(entry)
+	require.NoError(t, err)
+	return UsageWriteMessage{ID: id, Payload: payload, Deliveries: deliveries}
+}
+
+func TestUsageWriteServiceSubmit(t *testing.T) {
+	env := newUsageWriteTestEnv(t)
+
+	log := &UsageLog{UserID: 1, APIKeyID: 2, RequestID: "req-1", ActualCost: 1.5, APIKey: &APIKey{ID: 2, Key: "sk-secret"}}
+	require.True(t, env.svc.Submit(context.Background(), log, UsageCharge{UserID: 1, APIKeyID: 2, BalanceCost: 1.5}))
+	require.Len(t, env.queue.enqueued, 1)
+	require.NotNil(t, log.APIKey, "submit must not modify the caller's log")
+
+	var entry UsageWriteEntry
+	require.NoError(t, json.Unmarshal(env.queue.enqueued[0], &entry))
+	require.Equal(t, "req-1", entry.Log.RequestID)
+	require.Nil(t, entry.Log.APIKey)
+	require.Equal(t, 1.5, entry.Charge.BalanceCost)
+
+	env.queue.enqueueErr = errors.New("redis down")
+	require.False(t, env.svc.Submit(context.Background(), log, UsageCharge{}))
+
+	stats, err := env.svc.Stats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), stats.Submitted)
+	require.Equal(t, int64(1), stats.Fallbacks)
+
+	syncSvc := NewUsageWriteService(nil, env.queue, env.writer, nil, nil, nil, nil, &config.Config{UsageWrite: config.UsageWriteConfig{Mode: config.UsageWriteModeSync}})
+	require.False(t, syncSvc.Submit(context.Background(), log, UsageCharge{}))
+}
+
+func TestUsageWriteServiceAssignsStableRequestID(t *testing.T) {
+	env := newUsageWriteTestEnv(t)
+
+	// 入队前分配的 request_id 随消息持久化，重复投递按重复记录处理
+	log := &UsageLog{UserID: 1, APIKeyID: 10}
+	require.True(t, env.svc.Submit(context.Background(), log, UsageCharge{UserID: 1, APIKeyID: 10, BalanceCost: 2}))
+	require.Empty(t, log.RequestID, "submit must not modify the caller's log")
+	var entry UsageWriteEntry
+	require.NoError(t, json.Unmarshal(env.queue.enqueued[0], &entry))
+	require.True(t, strings.HasPrefix(entry.Log.RequestID, usageWriteRequestIDPrefix))
+
+	msg := env.queue.message(0, "1-0")
+	require.NoError(t, env.svc.flush(context.Background(), []UsageWriteMessage{msg}))
+	msg.Deliveries = 2
+	require.NoError(t, env.svc.flush(context.Background(), []UsageWriteMessage{msg}))
+	require.Equal(t, map[int64]float64{1: 2}, env.users.deducted)
+
+	// 未带 request_id 入队的消息以 Stream 消息 ID 派生，重复投递同样只扣费一次
+	legacy := usageWriteTestMessage(t, "2-0", 1, UsageWriteEntry{
+		Log:    &UsageLog{UserID: 2, APIKeyID: 20},
+		Charge: UsageCharge{UserID: 2, APIKeyID: 20, BalanceCost: 3},
+	})
+	require.NoError(t, env.svc.flush(context.Background(), []UsageWriteMessage{legacy}))
+	legacy.Deliveries = 2
+	require.NoError(t, env.svc.flush(context.Background(), []UsageWriteMessage{legacy}))
+	require.Equal(t, map[int64]float64{1: 2, 2: 3}, env.users.deducted)
+	require.True(t, env.writer.existing[usageWriteRequestIDPrefix+"2-0"])
+
+	stats, err := env.svc.Stats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stats.Inserted)
+	require.Equal(t, int64(2), stats.Duplicates)
+}
+
+func TestUsageWriteServiceFlushKeepsMessageWhenChargeFails(t *testing.T) {
+	env := newUsageWriteTestEnv(t)
+	env.users.deductErr = errors.New("deduct failed")
+	env.writer.discardWrites = true
+
+	msgs := []UsageWriteMessage{
+		usageWriteTestMessage(t, "1-0", 1, UsageWriteEntry{
+			Log:    &UsageLog{UserID: 1, APIKeyID: 10, RequestID: "req-charge"},
+			Charge: UsageCharge{UserID: 1, APIKeyID: 10, BalanceCost: 2, QuotaCost: 2},
+		}),
+	}
+
+	// 扣费失败时写入事务回滚，消息保持未确认，不执行事务后的附带更新
+	require.Error(t, env.svc.flush(context.Background(), msgs))
+	require.Empty(t, env.queue.acked)
+	require.Empty(t, env.queue.dead)
+	require.Empty(t, env.quota.used)
+
+	stats, err := env.svc.Stats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(0), stats.Inserted)
+	require.Equal(t, int64(1), stats.Failures)
+
+	// 达到重试上限后转入死信队列，等待修复后重放
+	msgs[0].Deliveries = 3
+	require.Error(t, env.svc.flush(context.Background(), msgs))
+	require.Equal(t, []string{"1-0"}, env.queue.dead)
+}
+
+func TestUsageWriteServiceFlushBillsOnlyInsertedRecords(t *testing.T) {
+	env := newUsageWriteTestEnv(t)
+	env.writer.existing = map[string]bool{"req-dup": true}
+
+	subID := int64(30)
+	msgs := []UsageWriteMessage{
+		usageWriteTestMessage(t, "1-0", 1, UsageWriteEntry{
+			Log:    &UsageLog{UserID: 1, APIKeyID: 10, RequestID: "req-new"},
+			Charge: UsageCharge{UserID: 1, APIKeyID: 10, BalanceCost: 2, QuotaCost: 2},
+		}),
+		usageWriteTestMessage(t, "2-0", 1, UsageWriteEntry{
+			Log:    &UsageLog{UserID: 2, APIKeyID: 20, RequestID: "req-dup"},
+			Charge: UsageCharge{UserID: 2, APIKeyID: 20, BalanceCost: 3, SpendCost: 3},
+		}),
+		usageWriteTestMessage(t, "3-0", 1, UsageWriteEntry{
+			Log:    &UsageLog{UserID: 3, APIKeyID: 30, RequestID: "req-sub"},
+			Charge: UsageCharge{UserID: 3, APIKeyID: 30, SubscriptionID: &subID, SubscriptionUserID: 3, GroupID: 7, SubscriptionCost: 4},
+		}),
+		// 同批重复投递：第二条按重复处理
+		usageWriteTestMessage(t, "4-0", 1, UsageWriteEntry{
+			Log:    &UsageLog{UserID: 3, APIKeyID: 30, RequestID: "req-sub"},
+			Charge: UsageCharge{UserID: 3, APIKeyID: 30, SubscriptionID: &subID, SubscriptionUserID: 3, GroupID: 7, SubscriptionCost: 4},
+		}),
+		{ID: "5-0", Payload: []byte("not json"), Deliveries: 1},
+	}
+
+	require.NoError(t, env.svc.flush(context.Background(), msgs))
+
+	require.Equal(t, map[int64]float64{1: 2}, env.users.deducted)
+	require.Equal(t, map[int64]float64{30: 4}, env.subs.incremented)
+	require.Equal(t, map[int64]float64{10: 2}, env.quota.used)
+	require.Equal(t, []int64{2}, env.cache.balanceInvalidations)
+	require.Equal(t, []int64{3}, env.cache.subscriptionInvalidations)
+	require.Equal(t, []int64{20}, env.cache.spendInvalidations)
+	require.ElementsMatch(t, []string{"1-0", "2-0", "3-0", "4-0"}, env.queue.acked)
+	require.Equal(t, []string{"5-0"}, env.queue.dead)
+	require.Equal(t, 1, env.writer.calls)
+}
+
+func TestUsageWriteServiceFlushRetriesAndDeadLetters(t *testing.T) {
+	env := newUsageWriteTestEnv(t)
+	env.writer.failRequestIDs = map[string]bool{"req-bad": true, "req-exhausted": true}
+
+	msgs := []UsageWriteMessage{
+		usageWriteTestMessage(t, "1-0", 1, UsageWriteEntry{
+			Log:    &UsageLog{UserID: 1, APIKeyID: 10, RequestID: "req-ok"},
+			Charge: UsageCharge{UserID: 1, APIKeyID: 10, BalanceCost: 1},
+		}),
+		usageWriteTestMessage(t, "2-0", 1, UsageWriteEntry{
+			Log:    &UsageLog{UserID: 2, APIKeyID: 20, RequestID: "req-bad"},
+			Charge: UsageCharge{UserID: 2, APIKeyID: 20, BalanceCost: 1},
+		}),
+		usageWriteTestMessage(t, "3-0", 3, UsageWriteEntry{
+			Log:    &UsageLog{UserID: 3, APIKeyID: 30, RequestID: "req-exhausted"},
+			Charge: UsageCharge{UserID: 3, APIKeyID: 30, BalanceCost: 1},
+		}),
+	}
+
+	require.Error(t, env.svc.flush(context.Background(), msgs))
+
+	// 可写入的记录逐条重试后正常扣费并确认；失败的记录未达重试上限时保持未确认
+	require.Equal(t, map[int64]float64{1: 1}, env.users.deducted)
+	require.Equal(t, []string{"1-0"}, env.queue.acked)
+	require.Equal(t, []string{"3-0"}, env.queue.dead)
+
+	stats, err := env.svc.Stats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), stats.Inserted)
+	require.Equal(t, int64(2), stats.Failures)
+	require.Equal(t, int64(1), stats.DeadLetters)
+}
+
+func TestNewUsageCharge(t *testing.T) {
+	groupID := int64(5)
+	orgID := int64(9)
+	limit := 10.0
+	user := &User{ID: 1}
+	cost := &CostBreakdown{TotalCost: 2, ActualCost: 3}
+	quota := &usageWriteQuotaStub{}
+
+	balance := newUsageCharge(user, &APIKey{ID: 2, UserID: 1, Quota: 100, DailyLimitUSD: &limit}, nil, cost, false, quota)
+	require.Equal(t, 3.0, balance.BalanceCost)
+	require.Equal(t, 3.0, balance.QuotaCost)
+	require.Equal(t, 3.0, balance.SpendCost)
+	require.Zero(t, balance.SubscriptionCost)
+	require.Nil(t, balance.OrganizationID)
+
+	noQuotaUpdater := newUsageCharge(user, &APIKey{ID: 2, UserID: 1, Quota: 100}, nil, cost, false, nil)
+	require.Zero(t, noQuotaUpdater.QuotaCost)
+
+	sub := &UserSubscription{ID: 7, UserID: 1}
+	subscription := newUsageCharge(user, &APIKey{ID: 2, UserID: 1, GroupID: &groupID}, sub, cost, true, nil)
+	require.Equal(t, 2.0, subscription.SubscriptionCost)
+	require.Equal(t, int64(7), *subscription.SubscriptionID)
+	require.Equal(t, groupID, subscription.GroupID)
+	require.Zero(t, subscription.BalanceCost)
+
+	org := newUsageCharge(user, &APIKey{ID: 2, UserID: 1, OrganizationID: &orgID}, nil, cost, false, nil)
+	require.Zero(t, org.BalanceCost)
+	require.Equal(t, orgID, *org.OrganizationID)
+	require.Equal(t, 3.0, org.OrganizationCost)
+	require.True(t, org.OrganizationDeductBalance)
+}
@@ -13,6 +13,7 @@ type User struct {
 	Notes         string
 	PasswordHash  string
 	Role          string
+	AdminRole     string // 管理员角色，仅 Role=admin 时生效，空表示超级管理员
 	Balance       float64
 	Concurrency   int
 	Status        string
@@ -30,6 +31,9 @@ type User struct {
 	TotpEnabled         bool       // 是否启用 TOTP
 	TotpEnabledAt       *time.Time // TOTP 启用时间
 
+	// AllowedTagKeys 请求可携带的成本归属标签键（API Key 未单独配置时使用）
+	AllowedTagKeys []string
+
 	APIKeys       []APIKey
 	Subscriptions []UserSubscription
 }
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 用户提醒类型
+const (
+	UserNotificationLowBalance           = "low_balance"
+	UserNotificationKeyQuota             = "key_quota"
+	UserNotificationKeyExpiring          = "key_expiring"
+	UserNotificationSubscriptionUsage    = "subscription_usage"
+	UserNotificationSubscriptionExpiring = "subscription_expiring"
+)
+
+// 提醒投递渠道
+const (
+	UserNotificationChannelEmail   = "email"
+	UserNotificationChannelWebhook = "webhook"
+)
+
+var (
+	ErrUserNotificationSettingsInvalid = infraerrors.BadRequest("USER_NOTIFICATION_SETTINGS_INVALID", "invalid notification settings")
+	ErrUserNotificationWebhookInvalid  = infraerrors.BadRequest("USER_NOTIFICATION_WEBHOOK_INVALID", "webhook_url must be a public https url")
+)
+
+// UserNotificationSettings 用户提醒阈值与渠道，阈值为 nil 表示不提醒该项
+type UserNotificationSettings struct {
+	UserID        int64  `json:"user_id"`
+	EmailEnabled  bool   `json:"email_enabled"`
+	WebhookURL    string `json:"webhook_url"`
+	WebhookSecret string `json:"webhook_secret"`
+
+	BalanceBelowUSD          *float64 `json:"balance_below_usd"`
+	KeyQuotaPercent          *int     `json:"key_quota_percent"`
+	SubscriptionUsagePercent *int     `json:"subscription_usage_percent"`
+	ExpiryDays               *int     `json:"expiry_days"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HasThresholds 是否配置了任一提醒阈值
+func (s *UserNotificationSettings) HasThresholds() bool {
+	return s.BalanceBelowUSD != nil || s.KeyQuotaPercent != nil || s.SubscriptionUsagePercent != nil || s.ExpiryDays != nil
+}
+
+// DefaultUserNotificationSettings 未配置时的默认值：邮件渠道开启，不设任何阈值
+func DefaultUserNotificationSettings(userID int64) *UserNotificationSettings {
+	return &UserNotificationSettings{UserID: userID, EmailEnabled: true}
+}
+
+// UpdateUserNotificationSettingsRequest 更新提醒设置
+type UpdateUserNotificationSettingsRequest struct {
+	EmailEnabled             bool     `json:"email_enabled"`
+	WebhookURL               string   `json:"webhook_url"`
+	RegenerateWebhookSecret  bool     `json:"regenerate_webhook_secret"`
+	BalanceBelowUSD          *float64 `json:"balance_below_usd"`
+	KeyQuotaPercent          *int     `json:"key_quota_percent"`
+	SubscriptionUsagePercent *int     `json:"subscription_usage_percent"`
+	ExpiryDays               *int     `json:"expiry_days"`
+}
+
+// UserNotification 已触发的提醒
+type UserNotification struct {
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"user_id"`
+	Kind          string     `json:"kind"`
+	TargetID      int64      `json:"target_id"`
+	DedupKey      string     `json:"-"`
+	Title         string     `json:"title"`
+	Message       string     `json:"message"`
+	Channels      string     `json:"channels"`
+	DeliveryError string     `json:"delivery_error,omitempty"`
+	ResolvedAt    *time.Time `json:"resolved_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// UserNotificationRepository 提醒设置与提醒记录
+type UserNotificationRepository interface {
+	// GetSettings 未配置时返回 nil, nil
+	GetSettings(ctx context.Context, userID int64) (*UserNotificationSettings, error)
+	UpsertSettings(ctx context.Context, settings *UserNotificationSettings) error
+	// ListUsersWithThresholds 按 user_id 升序分批返回配置了阈值的正常状态用户
+	ListUsersWithThresholds(ctx context.Context, afterUserID int64, limit int) ([]UserNotificationSettings, error)
+
+	// CreateIfAbsent 同一 dedup_key 没有未解除记录时写入并返回 true
+	CreateIfAbsent(ctx context.Context, notification *UserNotification) (bool, error)
+	UpdateDelivery(ctx context.Context, id int64, channels, deliveryError string) error
+	// ResolveExcept 将用户未解除、且 dedup_key 不在 activeKeys 中的提醒标记为已解除
+	ResolveExcept(ctx context.Context, userID int64, activeKeys []string, at time.Time) error
+	List(ctx context.Context, userID int64, params pagination.PaginationParams) ([]UserNotification, *pagination.PaginationResult, error)
+}
+
+// UserNotificationWebhookSender 投递用户 Webhook（body 为 JSON，signature 为 HMAC-SHA256 十六进制）
+type UserNotificationWebhookSender interface {
+	Send(ctx context.Context, url string, body []byte, signature string) error
+}
+
+// UserNotificationWebhookPayload Webhook 请求体
+type UserNotificationWebhookPayload struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	TargetID  int64     `json:"target_id"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	UserID    int64     `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
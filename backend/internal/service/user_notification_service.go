@@ -0,0 +1,539 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	userNotificationBatchSize      = 200
+	userNotificationRunTimeout     = 5 * time.Minute
+	userNotificationDeliverTimeout = 15 * time.Second
+	userNotificationMaxExpiryDays  = 365
+
+	userNotificationLeaderLockKey = "user:notification:leader"
+	userNotificationLeaderLockTTL = 10 * time.Minute
+)
+
+var userNotificationReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// userNotificationCandidate 一次评估中满足条件的提醒
+type userNotificationCandidate struct {
+	Kind     string
+	TargetID int64
+	DedupKey string
+	Title    string
+	Message  string
+}
+
+// UserNotificationService 用户提醒：后台定时评估余额 / Key 额度 / 订阅窗口 / 到期时间，
+// 通过邮件队列与用户 Webhook 投递；不在请求热路径上执行。
+type UserNotificationService struct {
+	repo          UserNotificationRepository
+	userRepo      UserRepository
+	apiKeyRepo    APIKeyRepository
+	userSubRepo   UserSubscriptionRepository
+	emailQueue    *EmailQueueService
+	settings      *SettingService
+	webhookSender UserNotificationWebhookSender
+	db            *sql.DB
+	redisClient   *redis.Client
+	cfg           *config.Config
+
+	// 多实例部署时只有持有 leader 锁的实例执行评估，避免重复提醒
+	instanceID      string
+	warnNoRedisOnce sync.Once
+	skipLogMu       sync.Mutex
+	skipLogAt       time.Time
+
+	interval time.Duration
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewUserNotificationService 创建用户提醒服务
+func NewUserNotificationService(
+	repo UserNotificationRepository,
+	userRepo UserRepository,
+	apiKeyRepo APIKeyRepository,
+	userSubRepo UserSubscriptionRepository,
+	emailQueue *EmailQueueService,
+	settings *SettingService,
+	webhookSender UserNotificationWebhookSender,
+	db *sql.DB,
+	redisClient *redis.Client,
+	cfg *config.Config,
+	interval time.Duration,
+) *UserNotificationService {
+	return &UserNotificationService{
+		repo:          repo,
+		userRepo:      userRepo,
+		apiKeyRepo:    apiKeyRepo,
+		userSubRepo:   userSubRepo,
+		emailQueue:    emailQueue,
+		settings:      settings,
+		webhookSender: webhookSender,
+		db:            db,
+		redisClient:   redisClient,
+		cfg:           cfg,
+		instanceID:    uuid.NewString(),
+		interval:      interval,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// Start 启动后台评估任务
+func (s *UserNotificationService) Start() {
+	if s == nil || s.repo == nil || s.interval <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.runOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台任务
+func (s *UserNotificationService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+// ========== 用户设置 ==========
+
+// GetSettings 获取提醒设置，未配置时返回默认值
+func (s *UserNotificationService) GetSettings(ctx context.Context, userID int64) (*UserNotificationSettings, error) {
+	settings, err := s.repo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return DefaultUserNotificationSettings(userID), nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings 更新提醒设置；配置 Webhook 时自动生成签名密钥
+func (s *UserNotificationService) UpdateSettings(ctx context.Context, userID int64, req UpdateUserNotificationSettingsRequest) (*UserNotificationSettings, error) {
+	if err := validateUserNotificationThresholds(req); err != nil {
+		return nil, err
+	}
+	current, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookURL := strings.TrimSpace(req.WebhookURL)
+	if webhookURL != "" {
+		allowPrivate := s.cfg != nil && s.cfg.Security.URLAllowlist.AllowPrivateHosts
+		normalized, err := urlvalidator.ValidateHTTPSURL(webhookURL, urlvalidator.ValidationOptions{AllowPrivate: allowPrivate})
+		if err != nil {
+			return nil, ErrUserNotificationWebhookInvalid
+		}
+		webhookURL = normalized
+	}
+
+	updated := &UserNotificationSettings{
+		UserID:                   userID,
+		EmailEnabled:             req.EmailEnabled,
+		WebhookURL:               webhookURL,
+		WebhookSecret:            current.WebhookSecret,
+		BalanceBelowUSD:          req.BalanceBelowUSD,
+		KeyQuotaPercent:          req.KeyQuotaPercent,
+		SubscriptionUsagePercent: req.SubscriptionUsagePercent,
+		ExpiryDays:               req.ExpiryDays,
+	}
+	if webhookURL != "" && (updated.WebhookSecret == "" || req.RegenerateWebhookSecret) {
+		secret, err := generateUserNotificationWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		updated.WebhookSecret = secret
+	}
+	if err := s.repo.UpsertSettings(ctx, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func validateUserNotificationThresholds(req UpdateUserNotificationSettingsRequest) error {
+	if req.BalanceBelowUSD != nil && *req.BalanceBelowUSD < 0 {
+		return ErrUserNotificationSettingsInvalid.WithMetadata(map[string]string{"field": "balance_below_usd"})
+	}
+	if req.KeyQuotaPercent != nil && (*req.KeyQuotaPercent < 1 || *req.KeyQuotaPercent > 100) {
+		return ErrUserNotificationSettingsInvalid.WithMetadata(map[string]string{"field": "key_quota_percent"})
+	}
+	if req.SubscriptionUsagePercent != nil && (*req.SubscriptionUsagePercent < 1 || *req.SubscriptionUsagePercent > 100) {
+		return ErrUserNotificationSettingsInvalid.WithMetadata(map[string]string{"field": "subscription_usage_percent"})
+	}
+	if req.ExpiryDays != nil && (*req.ExpiryDays < 1 || *req.ExpiryDays > userNotificationMaxExpiryDays) {
+		return ErrUserNotificationSettingsInvalid.WithMetadata(map[string]string{"field": "expiry_days"})
+	}
+	return nil
+}
+
+func generateUserNotificationWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// ListNotifications 用户查看已触发的提醒
+func (s *UserNotificationService) ListNotifications(ctx context.Context, userID int64, params pagination.PaginationParams) ([]UserNotification, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, userID, params)
+}
+
+// ========== 后台评估 ==========
+
+func (s *UserNotificationService) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), userNotificationRunTimeout)
+	defer cancel()
+
+	release, ok := s.tryAcquireLeaderLock(ctx)
+	if !ok {
+		return
+	}
+	if release != nil {
+		defer release()
+	}
+
+	var afterUserID int64
+	var evaluated, fired int
+	for {
+		batch, err := s.repo.ListUsersWithThresholds(ctx, afterUserID, userNotificationBatchSize)
+		if err != nil {
+			log.Printf("[UserNotification] List users failed: %v", err)
+			return
+		}
+		for i := range batch {
+			n, err := s.evaluateUser(ctx, &batch[i], time.Now())
+			if err != nil {
+				log.Printf("[UserNotification] Evaluate user %d failed: %v", batch[i].UserID, err)
+				continue
+			}
+			evaluated++
+			fired += n
+		}
+		if len(batch) < userNotificationBatchSize {
+			break
+		}
+		afterUserID = batch[len(batch)-1].UserID
+	}
+	if fired > 0 {
+		log.Printf("[UserNotification] Evaluated %d users, fired %d notifications", evaluated, fired)
+	}
+}
+
+func (s *UserNotificationService) tryAcquireLeaderLock(ctx context.Context) (func(), bool) {
+	// In simple run mode, assume single instance.
+	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
+		return nil, true
+	}
+
+	key := userNotificationLeaderLockKey
+	if s.redisClient != nil {
+		ok, err := s.redisClient.SetNX(ctx, key, s.instanceID, userNotificationLeaderLockTTL).Result()
+		if err == nil {
+			if !ok {
+				s.maybeLogSkip()
+				return nil, false
+			}
+			return func() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				defer cancel()
+				_, _ = userNotificationReleaseScript.Run(releaseCtx, s.redisClient, []string{key}, s.instanceID).Result()
+			}, true
+		}
+		// Redis error: fall back to DB advisory lock.
+		s.warnNoRedisOnce.Do(func() {
+			log.Printf("[UserNotification] leader lock SetNX failed; falling back to DB advisory lock: %v", err)
+		})
+	}
+
+	release, ok := tryAcquireDBAdvisoryLock(ctx, s.db, hashAdvisoryLockID(key))
+	if !ok {
+		s.maybeLogSkip()
+		return nil, false
+	}
+	return release, true
+}
+
+func (s *UserNotificationService) maybeLogSkip() {
+	s.skipLogMu.Lock()
+	defer s.skipLogMu.Unlock()
+
+	now := time.Now()
+	if !s.skipLogAt.IsZero() && now.Sub(s.skipLogAt) < time.Minute {
+		return
+	}
+	s.skipLogAt = now
+	log.Printf("[UserNotification] leader lock held by another instance; skipping")
+}
+
+// evaluateUser 评估单个用户：新满足的条件写入并投递，不再满足的条件标记解除
+func (s *UserNotificationService) evaluateUser(ctx context.Context, settings *UserNotificationSettings, now time.Time) (int, error) {
+	user, err := s.userRepo.GetByID(ctx, settings.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	var keys []APIKey
+	if settings.KeyQuotaPercent != nil || settings.ExpiryDays != nil {
+		if keys, err = s.listAPIKeys(ctx, settings.UserID); err != nil {
+			return 0, err
+		}
+	}
+	var subs []UserSubscription
+	if settings.SubscriptionUsagePercent != nil || settings.ExpiryDays != nil {
+		if subs, err = s.userSubRepo.ListActiveByUserID(ctx, settings.UserID); err != nil {
+			return 0, err
+		}
+	}
+
+	candidates := evaluateUserNotifications(settings, user, keys, subs, now)
+	activeKeys := make([]string, 0, len(candidates))
+	fired := 0
+	for _, c := range candidates {
+		activeKeys = append(activeKeys, c.DedupKey)
+		notification := &UserNotification{
+			UserID:   settings.UserID,
+			Kind:     c.Kind,
+			TargetID: c.TargetID,
+			DedupKey: c.DedupKey,
+			Title:    c.Title,
+			Message:  c.Message,
+		}
+		created, err := s.repo.CreateIfAbsent(ctx, notification)
+		if err != nil {
+			return fired, err
+		}
+		if !created {
+			continue
+		}
+		fired++
+		s.deliver(ctx, settings, user, notification)
+	}
+	if err := s.repo.ResolveExcept(ctx, settings.UserID, activeKeys, now); err != nil {
+		return fired, err
+	}
+	return fired, nil
+}
+
+func (s *UserNotificationService) listAPIKeys(ctx context.Context, userID int64) ([]APIKey, error) {
+	var out []APIKey
+	params := pagination.PaginationParams{Page: 1, PageSize: 100}
+	for {
+		keys, result, err := s.apiKeyRepo.ListByUserID(ctx, userID, params)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, keys...)
+		if result == nil || params.Page >= result.Pages || len(keys) == 0 {
+			return out, nil
+		}
+		params.Page++
+	}
+}
+
+// evaluateUserNotifications 计算当前满足条件的提醒；dedup_key 带上窗口标识，
+// 进入新窗口（订阅窗口重置、续期、调整额度）时会生成新的 key 从而再次提醒
+func evaluateUserNotifications(settings *UserNotificationSettings, user *User, keys []APIKey, subs []UserSubscription, now time.Time) []userNotificationCandidate {
+	var out []userNotificationCandidate
+
+	if th := settings.BalanceBelowUSD; th != nil && user.Balance < *th {
+		out = append(out, userNotificationCandidate{
+			Kind:     UserNotificationLowBalance,
+			DedupKey: UserNotificationLowBalance,
+			Title:    "Low balance",
+			Message:  fmt.Sprintf("Your balance is $%.2f, below your alert threshold of $%.2f.", user.Balance, *th),
+		})
+	}
+
+	for i := range keys {
+		key := &keys[i]
+		if key.Status != StatusActive && key.Status != StatusAPIKeyQuotaExhausted {
+			continue
+		}
+		if th := settings.KeyQuotaPercent; th != nil && key.Quota > 0 {
+			percent := key.QuotaUsed / key.Quota * 100
+			if percent >= float64(*th) {
+				out = append(out, userNotificationCandidate{
+					Kind:     UserNotificationKeyQuota,
+					TargetID: key.ID,
+					DedupKey: fmt.Sprintf("%s:%d:%.4f", UserNotificationKeyQuota, key.ID, key.Quota),
+					Title:    "API key quota almost used up",
+					Message:  fmt.Sprintf("API key \"%s\" has used %.0f%% of its $%.2f quota.", key.Name, percent, key.Quota),
+				})
+			}
+		}
+		if days := settings.ExpiryDays; days != nil && key.ExpiresAt != nil && withinExpiryWindow(*key.ExpiresAt, *days, now) {
+			out = append(out, userNotificationCandidate{
+				Kind:     UserNotificationKeyExpiring,
+				TargetID: key.ID,
+				DedupKey: fmt.Sprintf("%s:%d:%d", UserNotificationKeyExpiring, key.ID, key.ExpiresAt.Unix()),
+				Title:    "API key expiring soon",
+				Message:  fmt.Sprintf("API key \"%s\" expires at %s.", key.Name, key.ExpiresAt.Format(time.RFC3339)),
+			})
+		}
+	}
+
+	for i := range subs {
+		sub := &subs[i]
+		groupName := fmt.Sprintf("#%d", sub.GroupID)
+		if sub.Group != nil {
+			groupName = sub.Group.Name
+		}
+		if th := settings.SubscriptionUsagePercent; th != nil && sub.Group != nil {
+			windows := []struct {
+				name    string
+				limit   *float64
+				usage   float64
+				start   *time.Time
+				expired bool
+			}{
+				{"daily", sub.Group.DailyLimitUSD, sub.DailyUsageUSD, sub.DailyWindowStart, sub.NeedsDailyReset()},
+				{"weekly", sub.Group.WeeklyLimitUSD, sub.WeeklyUsageUSD, sub.WeeklyWindowStart, sub.NeedsWeeklyReset()},
+				{"monthly", sub.Group.MonthlyLimitUSD, sub.MonthlyUsageUSD, sub.MonthlyWindowStart, sub.NeedsMonthlyReset()},
+			}
+			for _, w := range windows {
+				// 窗口未激活或已过期（下次请求时重置）视为用量为 0
+				if w.limit == nil || *w.limit <= 0 || w.start == nil || w.expired {
+					continue
+				}
+				percent := w.usage / *w.limit * 100
+				if percent < float64(*th) {
+					continue
+				}
+				out = append(out, userNotificationCandidate{
+					Kind:     UserNotificationSubscriptionUsage,
+					TargetID: sub.ID,
+					DedupKey: fmt.Sprintf("%s:%d:%s:%d", UserNotificationSubscriptionUsage, sub.ID, w.name, w.start.Unix()),
+					Title:    "Subscription usage limit almost reached",
+					Message:  fmt.Sprintf("Your %s subscription has used %.0f%% of its %s limit ($%.2f of $%.2f).", groupName, percent, w.name, w.usage, *w.limit),
+				})
+			}
+		}
+		if days := settings.ExpiryDays; days != nil && withinExpiryWindow(sub.ExpiresAt, *days, now) {
+			out = append(out, userNotificationCandidate{
+				Kind:     UserNotificationSubscriptionExpiring,
+				TargetID: sub.ID,
+				DedupKey: fmt.Sprintf("%s:%d:%d", UserNotificationSubscriptionExpiring, sub.ID, sub.ExpiresAt.Unix()),
+				Title:    "Subscription expiring soon",
+				Message:  fmt.Sprintf("Your %s subscription expires at %s.", groupName, sub.ExpiresAt.Format(time.RFC3339)),
+			})
+		}
+	}
+	return out
+}
+
+func withinExpiryWindow(expiresAt time.Time, days int, now time.Time) bool {
+	return expiresAt.After(now) && !expiresAt.After(now.AddDate(0, 0, days))
+}
+
+// deliver 投递到邮件与 Webhook，结果记录在提醒上（投递失败不会重试，避免重复打扰）
+func (s *UserNotificationService) deliver(ctx context.Context, settings *UserNotificationSettings, user *User, n *UserNotification) {
+	ctx, cancel := context.WithTimeout(ctx, userNotificationDeliverTimeout)
+	defer cancel()
+
+	siteName := "Sub2API"
+	if s.settings != nil {
+		siteName = s.settings.GetSiteName(ctx)
+	}
+
+	var channels, errs []string
+	if settings.EmailEnabled && user.Email != "" && s.emailQueue != nil {
+		subject := fmt.Sprintf("[%s] %s", siteName, n.Title)
+		if err := s.emailQueue.EnqueueNotification(ctx, user.Email, subject, buildUserNotificationEmailBody(siteName, n)); err != nil {
+			errs = append(errs, "email: "+err.Error())
+		} else {
+			channels = append(channels, UserNotificationChannelEmail)
+		}
+	}
+	if settings.WebhookURL != "" && s.webhookSender != nil {
+		body, err := json.Marshal(UserNotificationWebhookPayload{
+			ID:        n.ID,
+			Kind:      n.Kind,
+			TargetID:  n.TargetID,
+			Title:     n.Title,
+			Message:   n.Message,
+			UserID:    n.UserID,
+			CreatedAt: n.CreatedAt,
+		})
+		if err == nil {
+			err = s.webhookSender.Send(ctx, settings.WebhookURL, body, signUserNotificationWebhook(settings.WebhookSecret, body))
+		}
+		if err != nil {
+			errs = append(errs, "webhook: "+err.Error())
+		} else {
+			channels = append(channels, UserNotificationChannelWebhook)
+		}
+	}
+
+	n.Channels = strings.Join(channels, ",")
+	n.DeliveryError = strings.Join(errs, "; ")
+	if err := s.repo.UpdateDelivery(ctx, n.ID, n.Channels, n.DeliveryError); err != nil {
+		log.Printf("[UserNotification] Update delivery of notification %d failed: %v", n.ID, err)
+	}
+}
+
+// signUserNotificationWebhook HMAC-SHA256(secret, body) 十六进制
+func signUserNotificationWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func buildUserNotificationEmailBody(siteName string, n *UserNotification) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family: Arial, sans-serif; color: #333; max-width: 600px; margin: 0 auto; padding: 24px;">
+  <h2 style="margin-bottom: 8px;">%s</h2>
+  <p style="font-size: 16px;">%s</p>
+  <p style="color: #999; font-size: 12px; margin-top: 24px;">You are receiving this because you enabled usage alerts on %s. You can change your alert thresholds in your account settings.</p>
+</body>
+</html>
+`, html.EscapeString(n.Title), html.EscapeString(n.Message), html.EscapeString(siteName))
+}
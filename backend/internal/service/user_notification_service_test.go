@@ -0,0 +1,266 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+// userNotificationRepoStub 内存实现提醒仓储，按 dedup_key 模拟未解除记录的唯一约束
+type userNotificationRepoStub struct {
+	UserNotificationRepository
+
+	settings map[int64]*UserNotificationSettings
+	items    []*UserNotification
+	nextID   int64
+	listRuns int
+}
+
+func newUserNotificationRepoStub() *userNotificationRepoStub {
+	return &userNotificationRepoStub{settings: map[int64]*UserNotificationSettings{}}
+}
+
+func (s *userNotificationRepoStub) GetSettings(ctx context.Context, userID int64) (*UserNotificationSettings, error) {
+	if st, ok := s.settings[userID]; ok {
+		clone := *st
+		return &clone, nil
+	}
+	return nil, nil
+}
+
+func (s *userNotificationRepoStub) ListUsersWithThresholds(ctx context.Context, afterUserID int64, limit int) ([]UserNotificationSettings, error) {
+	s.listRuns++
+	return nil, nil
+}
+
+func (s *userNotificationRepoStub) UpsertSettings(ctx context.Context, settings *UserNotificationSettings) error {
+	clone := *settings
+	s.settings[settings.UserID] = &clone
+	return nil
+}
+
+func (s *userNotificationRepoStub) CreateIfAbsent(ctx context.Context, n *UserNotification) (bool, error) {
+	for _, item := range s.items {
+		if item.UserID == n.UserID && item.DedupKey == n.DedupKey && item.ResolvedAt == nil {
+			return false, nil
+		}
+	}
+	s.nextID++
+	n.ID = s.nextID
+	n.CreatedAt = time.Now()
+	clone := *n
+	s.items = append(s.items, &clone)
+	return true, nil
+}
+
+func (s *userNotificationRepoStub) UpdateDelivery(ctx context.Context, id int64, channels, deliveryError string) error {
+	for _, item := range s.items {
+		if item.ID == id {
+			item.Channels = channels
+			item.DeliveryError = deliveryError
+		}
+	}
+	return nil
+}
+
+func (s *userNotificationRepoStub) ResolveExcept(ctx context.Context, userID int64, activeKeys []string, at time.Time) error {
+	active := map[string]bool{}
+	for _, k := range activeKeys {
+		active[k] = true
+	}
+	for _, item := range s.items {
+		if item.UserID == userID && item.ResolvedAt == nil && !active[item.DedupKey] {
+			resolvedAt := at
+			item.ResolvedAt = &resolvedAt
+		}
+	}
+	return nil
+}
+
+type notificationAPIKeyRepoStub struct {
+	APIKeyRepository
+	keys []APIKey
+}
+
+func (s *notificationAPIKeyRepoStub) ListByUserID(ctx context.Context, userID int64, params pagination.PaginationParams) ([]APIKey, *pagination.PaginationResult, error) {
+	return s.keys, &pagination.PaginationResult{Total: int64(len(s.keys)), Page: 1, PageSize: params.PageSize, Pages: 1}, nil
+}
+
+type notificationUserSubRepoStub struct {
+	UserSubscriptionRepository
+	subs []UserSubscription
+}
+
+func (s *notificationUserSubRepoStub) ListActiveByUserID(ctx context.Context, userID int64) ([]UserSubscription, error) {
+	return s.subs, nil
+}
+
+type webhookSenderStub struct {
+	urls       []string
+	bodies     [][]byte
+	signatures []string
+}
+
+func (s *webhookSenderStub) Send(ctx context.Context, url string, body []byte, signature string) error {
+	s.urls = append(s.urls, url)
+	s.bodies = append(s.bodies, body)
+	s.signatures = append(s.signatures, signature)
+	return nil
+}
+
+func TestEvaluateUserNotifications(t *testing.T) {
+	now := time.Now()
+	threshold := 5.0
+	settings := &UserNotificationSettings{
+		BalanceBelowUSD:          &threshold,
+		KeyQuotaPercent:          intPtr(80),
+		SubscriptionUsagePercent: intPtr(90),
+		ExpiryDays:               intPtr(3),
+	}
+	user := &User{ID: 1, Balance: 2}
+	keyExpiry := now.Add(48 * time.Hour)
+	keys := []APIKey{
+		{ID: 10, Name: "prod", Status: StatusActive, Quota: 100, QuotaUsed: 85},
+		{ID: 11, Name: "dev", Status: StatusActive, Quota: 100, QuotaUsed: 10, ExpiresAt: &keyExpiry},
+		{ID: 12, Name: "off", Status: StatusDisabled, Quota: 100, QuotaUsed: 100},
+	}
+	limit := 10.0
+	windowStart := now.Add(-2 * time.Hour)
+	staleStart := now.Add(-30 * time.Hour)
+	subs := []UserSubscription{
+		{
+			ID:                20,
+			GroupID:           3,
+			Group:             &Group{Name: "pro", DailyLimitUSD: &limit, WeeklyLimitUSD: &limit},
+			ExpiresAt:         now.AddDate(0, 0, 30),
+			DailyWindowStart:  &staleStart, // 已过期的日窗口，下次请求会重置，不提醒
+			DailyUsageUSD:     9.5,
+			WeeklyWindowStart: &windowStart,
+			WeeklyUsageUSD:    9.5,
+		},
+		{ID: 21, GroupID: 4, ExpiresAt: now.Add(24 * time.Hour)},
+	}
+
+	candidates := evaluateUserNotifications(settings, user, keys, subs, now)
+	keysByKind := map[string][]string{}
+	for _, c := range candidates {
+		keysByKind[c.Kind] = append(keysByKind[c.Kind], c.DedupKey)
+	}
+	require.Equal(t, []string{"low_balance"}, keysByKind[UserNotificationLowBalance])
+	require.Equal(t, []string{"key_quota:10:100.0000"}, keysByKind[UserNotificationKeyQuota])
+	require.Len(t, keysByKind[UserNotificationKeyExpiring], 1)
+	require.True(t, strings.HasPrefix(keysByKind[UserNotificationKeyExpiring][0], "key_expiring:11:"))
+	require.Len(t, keysByKind[UserNotificationSubscriptionUsage], 1)
+	require.True(t, strings.HasPrefix(keysByKind[UserNotificationSubscriptionUsage][0], "subscription_usage:20:weekly:"))
+	require.Len(t, keysByKind[UserNotificationSubscriptionExpiring], 1)
+	require.True(t, strings.HasPrefix(keysByKind[UserNotificationSubscriptionExpiring][0], "subscription_expiring:21:"))
+
+	// 未配置阈值时不产生提醒
+	require.Empty(t, evaluateUserNotifications(&UserNotificationSettings{}, user, keys, subs, now))
+}
+
+func TestUserNotificationService_EvaluateUser_DedupAndRearm(t *testing.T) {
+	repo := newUserNotificationRepoStub()
+	user := &User{ID: 1, Email: "alice@example.com", Balance: 2}
+	sender := &webhookSenderStub{}
+	svc := NewUserNotificationService(repo, &userRepoStub{user: user}, &notificationAPIKeyRepoStub{}, &notificationUserSubRepoStub{}, nil, nil, sender, nil, nil, nil, time.Minute)
+
+	threshold := 5.0
+	settings := &UserNotificationSettings{
+		UserID:          1,
+		EmailEnabled:    true,
+		WebhookURL:      "https://hooks.example.com/alerts",
+		WebhookSecret:   "whsec_test",
+		BalanceBelowUSD: &threshold,
+	}
+	ctx := context.Background()
+
+	fired, err := svc.evaluateUser(ctx, settings, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, fired)
+	require.Len(t, sender.bodies, 1)
+	require.Equal(t, UserNotificationChannelWebhook, repo.items[0].Channels)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write(sender.bodies[0])
+	require.Equal(t, hex.EncodeToString(mac.Sum(nil)), sender.signatures[0])
+
+	// 条件持续满足时不重复提醒
+	fired, err = svc.evaluateUser(ctx, settings, time.Now())
+	require.NoError(t, err)
+	require.Zero(t, fired)
+	require.Len(t, sender.bodies, 1)
+
+	// 余额回升后解除，再次低于阈值时重新提醒
+	user.Balance = 20
+	fired, err = svc.evaluateUser(ctx, settings, time.Now())
+	require.NoError(t, err)
+	require.Zero(t, fired)
+	require.NotNil(t, repo.items[0].ResolvedAt)
+
+	user.Balance = 1
+	fired, err = svc.evaluateUser(ctx, settings, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, fired)
+	require.Len(t, repo.items, 2)
+	require.Len(t, sender.bodies, 2)
+}
+
+func TestUserNotificationService_RunOnceRequiresLeaderLock(t *testing.T) {
+	repo := newUserNotificationRepoStub()
+
+	// 多实例模式下拿不到 Redis / DB 锁时跳过本轮评估
+	svc := NewUserNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, &config.Config{}, time.Minute)
+	svc.runOnce()
+	require.Zero(t, repo.listRuns)
+
+	// simple 模式视为单实例，直接执行
+	svc = NewUserNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, &config.Config{RunMode: config.RunModeSimple}, time.Minute)
+	svc.runOnce()
+	require.Equal(t, 1, repo.listRuns)
+}
+
+func TestUserNotificationService_UpdateSettings(t *testing.T) {
+	repo := newUserNotificationRepoStub()
+	svc := NewUserNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, time.Minute)
+	ctx := context.Background()
+
+	defaults, err := svc.GetSettings(ctx, 1)
+	require.NoError(t, err)
+	require.True(t, defaults.EmailEnabled)
+	require.False(t, defaults.HasThresholds())
+
+	_, err = svc.UpdateSettings(ctx, 1, UpdateUserNotificationSettingsRequest{KeyQuotaPercent: intPtr(150)})
+	require.ErrorIs(t, err, ErrUserNotificationSettingsInvalid)
+	_, err = svc.UpdateSettings(ctx, 1, UpdateUserNotificationSettingsRequest{ExpiryDays: intPtr(0)})
+	require.ErrorIs(t, err, ErrUserNotificationSettingsInvalid)
+	_, err = svc.UpdateSettings(ctx, 1, UpdateUserNotificationSettingsRequest{WebhookURL: "http://hooks.example.com"})
+	require.ErrorIs(t, err, ErrUserNotificationWebhookInvalid)
+
+	saved, err := svc.UpdateSettings(ctx, 1, UpdateUserNotificationSettingsRequest{
+		EmailEnabled:    true,
+		WebhookURL:      "https://hooks.example.com/alerts",
+		KeyQuotaPercent: intPtr(80),
+	})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(saved.WebhookSecret, "whsec_"))
+
+	// 不要求重新生成时保留原密钥
+	again, err := svc.UpdateSettings(ctx, 1, UpdateUserNotificationSettingsRequest{WebhookURL: "https://hooks.example.com/alerts"})
+	require.NoError(t, err)
+	require.Equal(t, saved.WebhookSecret, again.WebhookSecret)
+
+	rotated, err := svc.UpdateSettings(ctx, 1, UpdateUserNotificationSettingsRequest{WebhookURL: "https://hooks.example.com/alerts", RegenerateWebhookSecret: true})
+	require.NoError(t, err)
+	require.NotEqual(t, saved.WebhookSecret, rotated.WebhookSecret)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
@@ -50,6 +51,8 @@ type UpdateProfileRequest struct {
 	Email       *string `json:"email"`
 	Username    *string `json:"username"`
 	Concurrency *int    `json:"concurrency"`
+	// AllowedTagKeys 成本归属标签键白名单（nil 不修改，空数组清空）
+	AllowedTagKeys []string `json:"allowed_tag_keys"`
 }
 
 // ChangePasswordRequest 修改密码请求
@@ -97,6 +100,7 @@ func (s *UserService) UpdateProfile(ctx context.Context, userID int64, req Updat
 		return nil, fmt.Errorf("get user: %w", err)
 	}
 	oldConcurrency := user.Concurrency
+	tagKeysChanged := false
 
 	// 更新字段
 	if req.Email != nil {
@@ -119,10 +123,19 @@ func (s *UserService) UpdateProfile(ctx context.Context, userID int64, req Updat
 		user.Concurrency = *req.Concurrency
 	}
 
+	if req.AllowedTagKeys != nil {
+		keys, err := NormalizeAllowedTagKeys(req.AllowedTagKeys)
+		if err != nil {
+			return nil, err
+		}
+		tagKeysChanged = !slices.Equal(keys, user.AllowedTagKeys)
+		user.AllowedTagKeys = keys
+	}
+
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, fmt.Errorf("update user: %w", err)
 	}
-	if s.authCacheInvalidator != nil && user.Concurrency != oldConcurrency {
+	if s.authCacheInvalidator != nil && (user.Concurrency != oldConcurrency || tagKeysChanged) {
 		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
 	}
 
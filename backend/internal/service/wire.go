@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
@@ -66,6 +67,36 @@ func ProvideUsageCleanupService(repo UsageCleanupRepository, timingWheel *Timing
 	return svc
 }
 
+// ProvideUsageExportService 创建并启动使用记录导出任务服务
+func ProvideUsageExportService(repo UsageExportRepository, timingWheel *TimingWheelService, cfg *config.Config) *UsageExportService {
+	svc := NewUsageExportService(repo, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideUsagePartitionService 创建并启动 usage_logs 分区维护服务
+func ProvideUsagePartitionService(repo UsagePartitionRepository, store UsageLogArchiveStore, timingWheel *TimingWheelService, cfg *config.Config) *UsagePartitionService {
+	svc := NewUsagePartitionService(repo, store, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideUsageWriteService 创建并启动使用记录异步写入服务
+func ProvideUsageWriteService(
+	entClient *dbent.Client,
+	queue UsageWriteQueue,
+	batchWriter UsageLogBatchWriter,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	billingCacheService *BillingCacheService,
+	apiKeyService *APIKeyService,
+	cfg *config.Config,
+) *UsageWriteService {
+	svc := NewUsageWriteService(entClient, queue, batchWriter, userRepo, userSubRepo, billingCacheService, apiKeyService, cfg)
+	svc.Start()
+	return svc
+}
+
 // ProvideAccountExpiryService creates and starts AccountExpiryService.
 func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpiryService {
 	svc := NewAccountExpiryService(accountRepo, time.Minute)
@@ -73,6 +104,54 @@ func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpirySe
 	return svc
 }
 
+// ProvideAccountProbeService creates and starts AccountProbeService.
+func ProvideAccountProbeService(
+	probeRepo AccountProbeRepository,
+	accountRepo AccountRepository,
+	settingRepo SettingRepository,
+	accountTestService *AccountTestService,
+	tempUnschedCache TempUnschedCache,
+	opsRepo OpsRepository,
+	db *sql.DB,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *AccountProbeService {
+	svc := NewAccountProbeService(probeRepo, accountRepo, settingRepo, accountTestService, tempUnschedCache, opsRepo, db, redisClient, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideProxyMonitorService creates and starts ProxyMonitorService.
+func ProvideProxyMonitorService(
+	healthRepo ProxyHealthRepository,
+	proxyRepo ProxyRepository,
+	settingRepo SettingRepository,
+	prober ProxyExitInfoProber,
+	latencyCache ProxyLatencyCache,
+	opsRepo OpsRepository,
+	db *sql.DB,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *ProxyMonitorService {
+	svc := NewProxyMonitorService(healthRepo, proxyRepo, settingRepo, prober, latencyCache, opsRepo, db, redisClient, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideProxyImportService creates ProxyImportService and starts the subscription refresher.
+func ProvideProxyImportService(
+	proxyRepo ProxyRepository,
+	subRepo ProxySubscriptionRepository,
+	fetcher ProxySubscriptionFetcher,
+	db *sql.DB,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *ProxyImportService {
+	svc := NewProxyImportService(proxyRepo, subRepo, fetcher, db, redisClient, cfg)
+	svc.Start()
+	return svc
+}
+
 // ProvideSubscriptionExpiryService creates and starts SubscriptionExpiryService.
 func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository) *SubscriptionExpiryService {
 	svc := NewSubscriptionExpiryService(userSubRepo, time.Minute)
@@ -80,6 +159,24 @@ func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository) *S
 	return svc
 }
 
+// ProvideUserNotificationService creates and starts UserNotificationService.
+func ProvideUserNotificationService(
+	repo UserNotificationRepository,
+	userRepo UserRepository,
+	apiKeyRepo APIKeyRepository,
+	userSubRepo UserSubscriptionRepository,
+	emailQueue *EmailQueueService,
+	settingService *SettingService,
+	webhookSender UserNotificationWebhookSender,
+	db *sql.DB,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *UserNotificationService {
+	svc := NewUserNotificationService(repo, userRepo, apiKeyRepo, userSubRepo, emailQueue, settingService, webhookSender, db, redisClient, cfg, 5*time.Minute)
+	svc.Start()
+	return svc
+}
+
 // ProvideTimingWheelService creates and starts TimingWheelService
 func ProvideTimingWheelService() (*TimingWheelService, error) {
 	svc, err := NewTimingWheelService()
@@ -98,8 +195,9 @@ func ProvideDeferredService(accountRepo AccountRepository, timingWheel *TimingWh
 }
 
 // ProvideConcurrencyService creates ConcurrencyService and starts slot cleanup worker.
-func ProvideConcurrencyService(cache ConcurrencyCache, accountRepo AccountRepository, cfg *config.Config) *ConcurrencyService {
+func ProvideConcurrencyService(cache ConcurrencyCache, fairShareCache FairShareCache, accountRepo AccountRepository, cfg *config.Config) *ConcurrencyService {
 	svc := NewConcurrencyService(cache)
+	svc.SetFairShare(fairShareCache, accountRepo)
 	if cfg != nil {
 		svc.StartSlotCleanupWorker(accountRepo, cfg.Gateway.Scheduling.SlotCleanupInterval)
 	}
@@ -119,6 +217,165 @@ func ProvideSchedulerSnapshotService(
 	return svc
 }
 
+// ProvideGatewayService creates GatewayService with optional dependencies.
+func ProvideGatewayService(
+	accountRepo AccountRepository,
+	groupRepo GroupRepository,
+	usageLogRepo UsageLogRepository,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	userGroupRateRepo UserGroupRateRepository,
+	cache GatewayCache,
+	cfg *config.Config,
+	schedulerSnapshot *SchedulerSnapshotService,
+	concurrencyService *ConcurrencyService,
+	billingService *BillingService,
+	rateLimitService *RateLimitService,
+	billingCacheService *BillingCacheService,
+	identityService *IdentityService,
+	httpUpstream HTTPUpstream,
+	deferredService *DeferredService,
+	claudeTokenProvider *ClaudeTokenProvider,
+	sessionLimitCache SessionLimitCache,
+	digestStore *DigestSessionStore,
+	latencyCache AccountLatencyCache,
+	usageWriter *UsageWriteService,
+) *GatewayService {
+	svc := NewGatewayService(
+		accountRepo,
+		groupRepo,
+		usageLogRepo,
+		userRepo,
+		userSubRepo,
+		userGroupRateRepo,
+		cache,
+		cfg,
+		schedulerSnapshot,
+		concurrencyService,
+		billingService,
+		rateLimitService,
+		billingCacheService,
+		identityService,
+		httpUpstream,
+		deferredService,
+		claudeTokenProvider,
+		sessionLimitCache,
+		digestStore,
+	)
+	svc.SetAccountLatencyCache(latencyCache)
+	svc.SetUsageWriteService(usageWriter)
+	return svc
+}
+
+// ProvideOpenAIGatewayService creates OpenAIGatewayService with async usage write support.
+func ProvideOpenAIGatewayService(
+	accountRepo AccountRepository,
+	usageLogRepo UsageLogRepository,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	cache GatewayCache,
+	cfg *config.Config,
+	schedulerSnapshot *SchedulerSnapshotService,
+	concurrencyService *ConcurrencyService,
+	billingService *BillingService,
+	rateLimitService *RateLimitService,
+	billingCacheService *BillingCacheService,
+	httpUpstream HTTPUpstream,
+	deferredService *DeferredService,
+	openAITokenProvider *OpenAITokenProvider,
+	usageWriter *UsageWriteService,
+	latencyCache AccountLatencyCache,
+) *OpenAIGatewayService {
+	svc := NewOpenAIGatewayService(
+		accountRepo,
+		usageLogRepo,
+		userRepo,
+		userSubRepo,
+		cache,
+		cfg,
+		schedulerSnapshot,
+		concurrencyService,
+		billingService,
+		rateLimitService,
+		billingCacheService,
+		httpUpstream,
+		deferredService,
+		openAITokenProvider,
+	)
+	svc.SetUsageWriteService(usageWriter)
+	svc.SetAccountLatencyCache(latencyCache)
+	return svc
+}
+
+// ProvideAuthService creates AuthService with passkey and lockout enforcement for OAuth logins.
+func ProvideAuthService(
+	userRepo UserRepository,
+	redeemRepo RedeemCodeRepository,
+	refreshTokenCache RefreshTokenCache,
+	cfg *config.Config,
+	settingService *SettingService,
+	emailService *EmailService,
+	turnstileService *TurnstileService,
+	emailQueueService *EmailQueueService,
+	promoService *PromoService,
+	sessionRepo UserSessionRepository,
+	passkeyService *PasskeyService,
+	loginSecurity *LoginSecurityService,
+) *AuthService {
+	svc := NewAuthService(userRepo, redeemRepo, refreshTokenCache, cfg, settingService, emailService, turnstileService, emailQueueService, promoService, sessionRepo)
+	svc.SetPasskeyService(passkeyService)
+	svc.SetLoginSecurityService(loginSecurity)
+	return svc
+}
+
+// ProvideAPIKeyService creates APIKeyService with organization support.
+func ProvideAPIKeyService(
+	apiKeyRepo APIKeyRepository,
+	userRepo UserRepository,
+	groupRepo GroupRepository,
+	userSubRepo UserSubscriptionRepository,
+	userGroupRateRepo UserGroupRateRepository,
+	cache APIKeyCache,
+	orgRepo OrganizationRepository,
+	cfg *config.Config,
+) *APIKeyService {
+	svc := NewAPIKeyService(apiKeyRepo, userRepo, groupRepo, userSubRepo, userGroupRateRepo, cache, cfg)
+	svc.SetOrganizationRepository(orgRepo)
+	return svc
+}
+
+// ProvideBillingCacheService creates BillingCacheService with organization billing support.
+func ProvideBillingCacheService(
+	cache BillingCache,
+	userRepo UserRepository,
+	subRepo UserSubscriptionRepository,
+	usageLogRepo UsageLogRepository,
+	orgRepo OrganizationRepository,
+	referralService *ReferralService,
+	cfg *config.Config,
+) *BillingCacheService {
+	svc := NewBillingCacheService(cache, userRepo, subRepo, usageLogRepo, cfg)
+	svc.SetOrganizationRepository(orgRepo)
+	svc.SetReferralService(referralService)
+	return svc
+}
+
+// ProvideRedeemService creates RedeemService with referral top-up tracking.
+func ProvideRedeemService(
+	redeemRepo RedeemCodeRepository,
+	userRepo UserRepository,
+	subscriptionService *SubscriptionService,
+	cache RedeemCache,
+	billingCacheService *BillingCacheService,
+	entClient *dbent.Client,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	referralService *ReferralService,
+) *RedeemService {
+	svc := NewRedeemService(redeemRepo, userRepo, subscriptionService, cache, billingCacheService, entClient, authCacheInvalidator)
+	svc.SetReferralService(referralService)
+	return svc
+}
+
 // ProvideRateLimitService creates RateLimitService with optional dependencies.
 func ProvideRateLimitService(
 	accountRepo AccountRepository,
@@ -146,8 +403,10 @@ func ProvideOpsMetricsCollector(
 	db *sql.DB,
 	redisClient *redis.Client,
 	cfg *config.Config,
+	usageWriter *UsageWriteService,
 ) *OpsMetricsCollector {
 	collector := NewOpsMetricsCollector(opsRepo, settingRepo, accountRepo, concurrencyService, db, redisClient, cfg)
+	collector.SetUsageWriteService(usageWriter)
 	collector.Start()
 	return collector
 }
@@ -170,10 +429,12 @@ func ProvideOpsAlertEvaluatorService(
 	opsService *OpsService,
 	opsRepo OpsRepository,
 	emailService *EmailService,
+	proxyHealthRepo ProxyHealthRepository,
 	redisClient *redis.Client,
 	cfg *config.Config,
 ) *OpsAlertEvaluatorService {
 	svc := NewOpsAlertEvaluatorService(opsService, opsRepo, emailService, redisClient, cfg)
+	svc.SetProxyHealthRepository(proxyHealthRepo)
 	svc.Start()
 	return svc
 }
@@ -213,24 +474,34 @@ func ProvideAPIKeyAuthCacheInvalidator(apiKeyService *APIKeyService) APIKeyAuthC
 // ProviderSet is the Wire provider set for all services
 var ProviderSet = wire.NewSet(
 	// Core services
-	NewAuthService,
+	ProvideAuthService,
 	NewUserService,
-	NewAPIKeyService,
+	ProvideAPIKeyService,
 	ProvideAPIKeyAuthCacheInvalidator,
 	NewGroupService,
 	NewAccountService,
 	NewProxyService,
-	NewRedeemService,
+	NewProxyPoolService,
+	NewAdminRoleService,
+	NewAdminAPIKeyService,
+	NewAdminAuditService,
+	NewOAuthProviderService,
+	NewPasskeyService,
+	NewLoginSecurityService,
+	ProvideRedeemService,
 	NewPromoService,
 	NewUsageService,
 	NewDashboardService,
 	ProvidePricingService,
 	NewBillingService,
-	NewBillingCacheService,
+	ProvideBillingCacheService,
+	NewOrganizationService,
+	NewReferralService,
+	NewStatementService,
 	NewAnnouncementService,
 	NewAdminService,
-	NewGatewayService,
-	NewOpenAIGatewayService,
+	ProvideGatewayService,
+	ProvideOpenAIGatewayService,
 	NewOAuthService,
 	NewOpenAIOAuthService,
 	NewGeminiOAuthService,
@@ -265,10 +536,18 @@ var ProviderSet = wire.NewSet(
 	ProvideUpdateService,
 	ProvideTokenRefreshService,
 	ProvideAccountExpiryService,
+	ProvideAccountProbeService,
+	ProvideProxyMonitorService,
+	ProvideProxyImportService,
 	ProvideSubscriptionExpiryService,
+	ProvideUserNotificationService,
 	ProvideTimingWheelService,
 	ProvideDashboardAggregationService,
 	ProvideUsageCleanupService,
+	ProvideUsageExportService,
+	ProvideUsagePartitionService,
+	ProvideUsageWriteService,
+	NewIdempotencyService,
 	ProvideDeferredService,
 	NewAntigravityQuotaFetcher,
 	NewUserAttributeService,
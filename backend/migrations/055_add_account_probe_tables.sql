@@ -0,0 +1,41 @@
+-- 账号健康探测（Account health probes）
+-- account_probe_results: 每次探测的结果历史（延迟 / 成功与否 / 错误信息）
+-- account_probe_states: 每个账号的连续成功/失败计数，以及探测器自动执行的处置动作
+
+CREATE TABLE IF NOT EXISTS account_probe_results (
+    id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    platform VARCHAR(50) NOT NULL DEFAULT '',
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL DEFAULT false,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_probe_results_account_created
+    ON account_probe_results (account_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_account_probe_results_created_at
+    ON account_probe_results (created_at);
+
+COMMENT ON TABLE account_probe_results IS '账号健康探测结果历史';
+COMMENT ON COLUMN account_probe_results.latency_ms IS '探测耗时（毫秒）';
+
+CREATE TABLE IF NOT EXISTS account_probe_states (
+    account_id BIGINT PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    consecutive_successes INTEGER NOT NULL DEFAULT 0,
+    last_probe_at TIMESTAMPTZ,
+    last_success BOOLEAN NOT NULL DEFAULT false,
+    last_latency_ms BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    auto_action VARCHAR(20) NOT NULL DEFAULT '',
+    auto_action_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_probe_states_auto_action
+    ON account_probe_states (auto_action) WHERE auto_action <> '';
+
+COMMENT ON TABLE account_probe_states IS '账号健康探测状态（连续成功/失败计数）';
+COMMENT ON COLUMN account_probe_states.auto_action IS '探测器自动执行的处置：temp_unsched / error，空表示未处置';
@@ -0,0 +1,7 @@
+-- 分组账号选择策略
+-- default: 优先级 → 负载率 → LRU（原有行为）
+-- weighted: 同优先级内按账号权重（accounts.extra.selection_weight）加权随机
+-- latency: 同优先级内按 Redis 中的 EWMA 首 token 延迟与错误率评分选择
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS selection_strategy VARCHAR(20) NOT NULL DEFAULT 'default';
+
+COMMENT ON COLUMN groups.selection_strategy IS '账号选择策略：default/weighted/latency';
@@ -0,0 +1,6 @@
+-- 分组内用户公平分配开关
+-- 开启后，每个活跃用户在分组账号总并发中的占用按 max-min 公平算法动态限制，
+-- 防止单个高并发用户占满共享分组的全部账号槽位
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS fair_share_enabled BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN groups.fair_share_enabled IS '是否在分组内按用户 max-min 公平分配账号并发';
@@ -0,0 +1,14 @@
+-- 分组级别的模型降级链
+-- 当某模型在分组内所有账号均不可用（选择失败或 failover 耗尽）时，按顺序尝试降级模型
+-- 格式: {"model_pattern": ["fallback_model_1", "fallback_model_2"], ...}
+-- 例如: {"claude-opus-*": ["claude-sonnet-4-5-20250929", "claude-haiku-4-5-20251001"]}
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS model_fallback JSONB DEFAULT '{}';
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS model_fallback_enabled BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN groups.model_fallback IS '模型降级链：{"model_pattern": ["fallback_model", ...]}，支持通配符匹配，按顺序尝试';
+COMMENT ON COLUMN groups.model_fallback_enabled IS '是否启用模型降级链';
+
+-- 使用日志记录客户端请求的原始模型（仅在发生模型降级时写入，model 字段为实际服务的模型）
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS requested_model VARCHAR(100);
+
+COMMENT ON COLUMN usage_logs.requested_model IS '客户端请求的原始模型（发生模型降级时记录，NULL 表示与 model 相同）';
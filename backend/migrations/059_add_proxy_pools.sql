@@ -0,0 +1,28 @@
+-- 代理池（Proxy pools）
+-- 一组代理 + 选择策略；账号可以引用代理池代替单个代理，代理失效时自动切换到池内其他健康代理
+--   strategy: failover（按顺序使用第一个健康代理）、round_robin（新账号轮询分配）、lowest_latency（选择延迟最低的代理）
+--   proxy_ids: 有序的代理 ID 列表（failover 策略下顺序即优先级）
+
+CREATE TABLE IF NOT EXISTS proxy_pools (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    strategy VARCHAR(20) NOT NULL DEFAULT 'failover',
+    proxy_ids JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_proxy_pools_name ON proxy_pools (name);
+
+COMMENT ON TABLE proxy_pools IS '代理池：一组代理及其选择策略';
+COMMENT ON COLUMN proxy_pools.strategy IS '选择策略：failover, round_robin, lowest_latency';
+COMMENT ON COLUMN proxy_pools.proxy_ids IS '有序代理 ID 列表（JSON 数组）';
+
+-- 账号引用代理池（设置后优先于 proxy_id）
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS proxy_pool_id BIGINT REFERENCES proxy_pools(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_proxy_pool_id ON accounts (proxy_pool_id) WHERE proxy_pool_id IS NOT NULL;
+
+COMMENT ON COLUMN accounts.proxy_pool_id IS '账号使用的代理池 ID（设置后优先于 proxy_id）';
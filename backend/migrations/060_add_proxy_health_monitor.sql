@@ -0,0 +1,52 @@
+-- 代理健康监控（Proxy health monitor）
+-- proxy_health_checks: 每次巡检的结果历史（延迟 / 出口 IP / 国家 / 是否发生出口变化）
+-- proxy_health_states: 每个代理的连续成功/失败计数、最近一次出口信息，以及监控器自动执行的下线标记
+
+CREATE TABLE IF NOT EXISTS proxy_health_checks (
+    id BIGSERIAL PRIMARY KEY,
+    proxy_id BIGINT NOT NULL REFERENCES proxies(id) ON DELETE CASCADE,
+    success BOOLEAN NOT NULL DEFAULT false,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    country VARCHAR(100) NOT NULL DEFAULT '',
+    country_code VARCHAR(10) NOT NULL DEFAULT '',
+    exit_ip_changed BOOLEAN NOT NULL DEFAULT false,
+    country_changed BOOLEAN NOT NULL DEFAULT false,
+    error_message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_proxy_health_checks_proxy_created
+    ON proxy_health_checks (proxy_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_proxy_health_checks_created_at
+    ON proxy_health_checks (created_at);
+CREATE INDEX IF NOT EXISTS idx_proxy_health_checks_exit_changed
+    ON proxy_health_checks (created_at) WHERE exit_ip_changed OR country_changed;
+
+COMMENT ON TABLE proxy_health_checks IS '代理健康巡检结果历史';
+COMMENT ON COLUMN proxy_health_checks.exit_ip_changed IS '与上一次成功巡检相比出口 IP 是否变化';
+COMMENT ON COLUMN proxy_health_checks.country_changed IS '与上一次成功巡检相比出口国家是否变化';
+
+CREATE TABLE IF NOT EXISTS proxy_health_states (
+    proxy_id BIGINT PRIMARY KEY REFERENCES proxies(id) ON DELETE CASCADE,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    consecutive_successes INTEGER NOT NULL DEFAULT 0,
+    last_check_at TIMESTAMPTZ,
+    last_success BOOLEAN NOT NULL DEFAULT false,
+    last_latency_ms BIGINT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_exit_ip VARCHAR(64) NOT NULL DEFAULT '',
+    last_country_code VARCHAR(10) NOT NULL DEFAULT '',
+    exit_changed_at TIMESTAMPTZ,
+    down BOOLEAN NOT NULL DEFAULT false,
+    down_since TIMESTAMPTZ,
+    auto_disabled BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_proxy_health_states_down
+    ON proxy_health_states (proxy_id) WHERE down;
+
+COMMENT ON TABLE proxy_health_states IS '代理健康状态（连续成功/失败计数、最近出口信息）';
+COMMENT ON COLUMN proxy_health_states.down IS '连续失败达到阈值后判定为下线，连续成功达到阈值后恢复';
+COMMENT ON COLUMN proxy_health_states.auto_disabled IS '监控器是否将代理状态自动置为 inactive（恢复时仅撤销该标记对应的修改）';
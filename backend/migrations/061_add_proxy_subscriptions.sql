@@ -0,0 +1,27 @@
+-- 代理订阅（Proxy subscriptions）
+-- 定时拉取供应商提供的 Clash YAML / URI 列表 / CSV 订阅，按 host + port + username 与现有代理去重，
+-- 新增、更新代理，并停用从订阅中消失的代理（重新出现时自动启用）。
+
+CREATE TABLE IF NOT EXISTS proxy_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    format VARCHAR(20) NOT NULL DEFAULT 'auto',
+    default_protocol VARCHAR(20) NOT NULL DEFAULT 'http',
+    interval_minutes INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    proxy_ids JSONB NOT NULL DEFAULT '[]'::jsonb,
+    disabled_proxy_ids JSONB NOT NULL DEFAULT '[]'::jsonb,
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_proxy_subscriptions_name ON proxy_subscriptions (name);
+
+COMMENT ON TABLE proxy_subscriptions IS '代理订阅（定时同步供应商代理列表）';
+COMMENT ON COLUMN proxy_subscriptions.format IS '订阅格式：auto / clash / uri / csv';
+COMMENT ON COLUMN proxy_subscriptions.interval_minutes IS '自动刷新间隔（分钟），0 表示仅手动同步';
+COMMENT ON COLUMN proxy_subscriptions.proxy_ids IS '订阅管理的代理 ID（JSON 数组）';
+COMMENT ON COLUMN proxy_subscriptions.disabled_proxy_ids IS '因从订阅中消失而被停用的代理 ID，重新出现时自动启用';
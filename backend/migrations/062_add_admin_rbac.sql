@@ -0,0 +1,21 @@
+-- 管理后台基于角色的访问控制（RBAC）
+-- users.admin_role 指定管理员角色（内置角色名或自定义角色名），空字符串视为超级管理员以兼容已有管理员；
+-- admin_roles 保存自定义角色及其权限列表，内置角色（super_admin / operator / billing / support / read_only）由代码定义。
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS admin_role VARCHAR(50) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN users.admin_role IS '管理员角色（仅 role=admin 生效），空表示超级管理员';
+
+CREATE TABLE IF NOT EXISTS admin_roles (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    permissions JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_roles_name ON admin_roles (name);
+
+COMMENT ON TABLE admin_roles IS '自定义管理员角色';
+COMMENT ON COLUMN admin_roles.permissions IS '权限列表（JSON 字符串数组），如 ["users:read", "usage:read"]';
@@ -0,0 +1,33 @@
+-- 多个具名管理员 API Key
+-- 每个 Key 仅保存 SHA-256 哈希，按角色/权限点限定范围，可选 IP 白名单与过期时间，并记录最近使用情况。
+-- 原全局 Admin API Key（settings.admin_api_key）继续兼容。
+
+CREATE TABLE IF NOT EXISTS admin_api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    key_prefix VARCHAR(20) NOT NULL DEFAULT '',
+    role VARCHAR(50) NOT NULL DEFAULT '',
+    permissions JSONB NOT NULL DEFAULT '[]'::jsonb,
+    ip_whitelist JSONB NOT NULL DEFAULT '[]'::jsonb,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    last_used_ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_by BIGINT NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_api_keys_key_hash ON admin_api_keys (key_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_api_keys_name ON admin_api_keys (name);
+
+COMMENT ON TABLE admin_api_keys IS '具名管理员 API Key（按权限范围授权）';
+COMMENT ON COLUMN admin_api_keys.key_hash IS 'Key 的 SHA-256 十六进制哈希，明文仅在创建时返回一次';
+COMMENT ON COLUMN admin_api_keys.key_prefix IS 'Key 明文前缀，用于识别';
+COMMENT ON COLUMN admin_api_keys.role IS '管理员角色（内置或自定义），为空时仅使用 permissions';
+COMMENT ON COLUMN admin_api_keys.permissions IS '额外授予的权限点（JSON 字符串数组）';
+COMMENT ON COLUMN admin_api_keys.ip_whitelist IS 'IP/CIDR 白名单，为空表示不限制';
+COMMENT ON COLUMN admin_api_keys.status IS 'active / revoked';
+COMMENT ON COLUMN admin_api_keys.created_by IS '创建者用户 ID，请求以该管理员身份执行';
@@ -0,0 +1,52 @@
+-- 管理后台审计日志（仅追加）
+-- 记录每个变更类管理接口的操作者（JWT 管理员或 Admin API Key）、来源 IP、操作、目标对象，
+-- 以及变更前后快照与字段级差异（敏感字段已脱敏）。
+-- 过期数据由 OpsCleanupService 按 ops.cleanup.audit_log_retention_days 清理。
+
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor_user_id BIGINT,
+    actor_api_key_id BIGINT,
+    actor_api_key_name VARCHAR(100) NOT NULL DEFAULT '',
+    auth_method VARCHAR(20) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(512) NOT NULL,
+    action VARCHAR(128) NOT NULL,
+    resource VARCHAR(64) NOT NULL,
+    target_id VARCHAR(64) NOT NULL DEFAULT '',
+    status_code INT NOT NULL DEFAULT 0,
+    request JSONB,
+    before JSONB,
+    after JSONB,
+    diff JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_created_at ON admin_audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_actor_user ON admin_audit_logs (actor_user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_resource_target ON admin_audit_logs (resource, target_id, created_at);
+
+-- 仅追加：禁止修改已写入的审计记录（删除仅用于过期清理）
+CREATE OR REPLACE FUNCTION admin_audit_logs_reject_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'admin_audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_admin_audit_logs_no_update ON admin_audit_logs;
+CREATE TRIGGER trg_admin_audit_logs_no_update
+    BEFORE UPDATE ON admin_audit_logs
+    FOR EACH ROW EXECUTE FUNCTION admin_audit_logs_reject_update();
+
+COMMENT ON TABLE admin_audit_logs IS '管理后台审计日志（仅追加）';
+COMMENT ON COLUMN admin_audit_logs.actor_user_id IS '操作者用户 ID（Admin API Key 请求为 Key 的创建者）';
+COMMENT ON COLUMN admin_audit_logs.actor_api_key_id IS '使用的具名 Admin API Key ID，旧版全局 Key 为 0，JWT 请求为空';
+COMMENT ON COLUMN admin_audit_logs.auth_method IS 'jwt / admin_api_key';
+COMMENT ON COLUMN admin_audit_logs.path IS '实际请求路径';
+COMMENT ON COLUMN admin_audit_logs.action IS '操作名，如 users.update、accounts.refresh';
+COMMENT ON COLUMN admin_audit_logs.resource IS '资源类型，即 /admin/ 之后的第一段路径';
+COMMENT ON COLUMN admin_audit_logs.request IS '请求体（已脱敏）';
+COMMENT ON COLUMN admin_audit_logs.before IS '变更前快照（已脱敏）';
+COMMENT ON COLUMN admin_audit_logs.after IS '变更后快照（已脱敏）';
+COMMENT ON COLUMN admin_audit_logs.diff IS '字段级差异 {path: {before, after}}，敏感字段仅标记变更';
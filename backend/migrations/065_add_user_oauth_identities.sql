@@ -0,0 +1,20 @@
+-- 通用 OIDC / OAuth2 登录：第三方身份与本地用户的绑定关系
+-- 提供商配置保存在 settings.oauth_providers（JSON），此表记录 (provider, subject) -> user_id。
+
+CREATE TABLE IF NOT EXISTS user_oauth_identities (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_oauth_identities_provider_subject ON user_oauth_identities (provider, subject);
+CREATE INDEX IF NOT EXISTS idx_user_oauth_identities_user_id ON user_oauth_identities (user_id);
+
+COMMENT ON TABLE user_oauth_identities IS '第三方登录身份绑定';
+COMMENT ON COLUMN user_oauth_identities.provider IS '提供商标识（settings.oauth_providers 中的 name）';
+COMMENT ON COLUMN user_oauth_identities.subject IS '提供商侧的稳定用户标识（OIDC sub / GitHub id）';
+COMMENT ON COLUMN user_oauth_identities.email IS '最近一次登录时提供商返回的邮箱';
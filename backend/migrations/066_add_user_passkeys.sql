@@ -0,0 +1,38 @@
+-- WebAuthn 通行密钥：可作为免密登录方式，也可作为登录第二因素
+-- 配置保存在 settings.passkey_settings（JSON），恢复码用于丢失认证器时完成第二因素验证。
+
+CREATE TABLE IF NOT EXISTS user_passkeys (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    credential_id VARCHAR(1400) NOT NULL,
+    public_key BYTEA NOT NULL,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    aaguid VARCHAR(36) NOT NULL DEFAULT '',
+    transports JSONB NOT NULL DEFAULT '[]'::jsonb,
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    backed_up BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_passkeys_credential_id ON user_passkeys (credential_id);
+CREATE INDEX IF NOT EXISTS idx_user_passkeys_user_id ON user_passkeys (user_id);
+
+COMMENT ON TABLE user_passkeys IS '用户 WebAuthn 通行密钥';
+COMMENT ON COLUMN user_passkeys.credential_id IS '凭据 ID（base64url）';
+COMMENT ON COLUMN user_passkeys.public_key IS '凭据公钥（COSE_Key 编码）';
+COMMENT ON COLUMN user_passkeys.sign_count IS '认证器签名计数，用于检测克隆的认证器';
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
+
+COMMENT ON TABLE user_recovery_codes IS '第二因素恢复码（一次性）';
+COMMENT ON COLUMN user_recovery_codes.code_hash IS '恢复码 SHA-256 哈希，明文仅在生成时展示一次';
@@ -0,0 +1,49 @@
+-- 登录会话与登录事件
+-- user_sessions：每个 Refresh Token 家族（一次登录）对应一条会话，Token 轮转时更新最近刷新时间，
+-- 撤销会话即删除对应的 Token 家族。
+-- login_events：登录成功/失败记录，失败记录同时用于账号/IP 维度的登录锁定（计数保存在 Redis）。
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id VARCHAR(64) NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    login_method VARCHAR(32) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_refreshed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sessions_family_id ON user_sessions (family_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_active ON user_sessions (user_id, last_refreshed_at DESC) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_user_sessions_expires_at ON user_sessions (expires_at);
+
+COMMENT ON TABLE user_sessions IS '用户登录会话（对应 Refresh Token 家族）';
+COMMENT ON COLUMN user_sessions.family_id IS 'Refresh Token 家族 ID，不对外暴露';
+COMMENT ON COLUMN user_sessions.name IS '根据 User-Agent 推断的设备名称，如 Chrome on macOS';
+COMMENT ON COLUMN user_sessions.ip IS '最近一次登录/刷新时的客户端 IP';
+COMMENT ON COLUMN user_sessions.revoked_at IS '撤销时间（登出、撤销或检测到 Token 重放）';
+
+CREATE TABLE IF NOT EXISTS login_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    method VARCHAR(32) NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    failure_reason VARCHAR(64) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_events_created_at ON login_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_login_events_user_id ON login_events (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_events_email ON login_events (email, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_login_events_ip ON login_events (ip, created_at DESC);
+
+COMMENT ON TABLE login_events IS '登录事件（成功与失败），按 ops.cleanup.login_event_retention_days 清理';
+COMMENT ON COLUMN login_events.method IS '登录方式：password / 2fa / passkey / oauth';
+COMMENT ON COLUMN login_events.failure_reason IS '失败原因（错误码），成功时为空';
@@ -0,0 +1,20 @@
+-- API Key 轮换
+-- 轮换时为同一条 API Key 记录签发新密钥，旧密钥写入 previous_key，在宽限期内仍可认证，
+-- 到期后自动失效（认证查询按 previous_key_expires_at 过滤）。分组、额度与使用记录保持不变。
+-- usage_logs.key_generation 记录请求所使用的密钥代数，用于观察客户端迁移进度。
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS previous_key VARCHAR(128);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS previous_key_expires_at TIMESTAMPTZ;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS key_generation INT NOT NULL DEFAULT 1;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_previous_key ON api_keys (previous_key) WHERE previous_key IS NOT NULL;
+
+COMMENT ON COLUMN api_keys.previous_key IS '轮换前的旧密钥，宽限期内仍可使用';
+COMMENT ON COLUMN api_keys.previous_key_expires_at IS '旧密钥失效时间';
+COMMENT ON COLUMN api_keys.key_generation IS '密钥代数，每次轮换加 1';
+COMMENT ON COLUMN api_keys.rotated_at IS '最近一次轮换时间';
+
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS key_generation INT NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN usage_logs.key_generation IS '请求所使用的 API Key 密钥代数';
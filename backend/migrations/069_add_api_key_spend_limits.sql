@@ -0,0 +1,12 @@
+-- API Key 滚动消费限额
+-- 可选的日/周/月美元上限，按配置时区的自然日、自然周（周一起）、自然月重置。
+-- 窗口内消费以 usage_logs.actual_cost 为准，运行时缓存在 Redis 计费缓存中
+-- （聚合查询使用 010 中的 idx_usage_logs_api_key_created_at）。
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS daily_limit_usd DECIMAL(20,8);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS weekly_limit_usd DECIMAL(20,8);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS monthly_limit_usd DECIMAL(20,8);
+
+COMMENT ON COLUMN api_keys.daily_limit_usd IS '每日消费上限（USD），NULL 表示不限制';
+COMMENT ON COLUMN api_keys.weekly_limit_usd IS '每周消费上限（USD），NULL 表示不限制';
+COMMENT ON COLUMN api_keys.monthly_limit_usd IS '每月消费上限（USD），NULL 表示不限制';
@@ -0,0 +1,38 @@
+-- 成本归属标签（按项目/环境分摊账单）
+-- usage_logs.tags：请求携带的标签（X-Sub2API-Tags 请求头，或 metadata.user_id / user 字段），仅保存通过白名单校验的键。
+-- users / api_keys.allowed_tag_keys：允许的标签键列表，API Key 未配置时回退到用户配置，均为空时不记录标签。
+-- usage_dashboard_tag_daily：按 日期 + 用户 + 标签键值 预聚合，由 DashboardAggregationService 维护。
+
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS tags JSONB;
+CREATE INDEX IF NOT EXISTS idx_usage_logs_tags ON usage_logs USING GIN (tags) WHERE tags IS NOT NULL;
+COMMENT ON COLUMN usage_logs.tags IS '成本归属标签，如 {"project":"search","env":"prod"}，无标签时为 NULL';
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS allowed_tag_keys JSONB;
+COMMENT ON COLUMN users.allowed_tag_keys IS '允许请求携带的标签键（API Key 未单独配置时使用）';
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_tag_keys JSONB;
+COMMENT ON COLUMN api_keys.allowed_tag_keys IS '该 Key 允许请求携带的标签键，为空时回退到用户配置';
+
+CREATE TABLE IF NOT EXISTS usage_dashboard_tag_daily (
+    bucket_date DATE NOT NULL,
+    user_id BIGINT NOT NULL,
+    tag_key VARCHAR(32) NOT NULL,
+    tag_value VARCHAR(64) NOT NULL,
+    total_requests BIGINT NOT NULL DEFAULT 0,
+    input_tokens BIGINT NOT NULL DEFAULT 0,
+    output_tokens BIGINT NOT NULL DEFAULT 0,
+    cache_creation_tokens BIGINT NOT NULL DEFAULT 0,
+    cache_read_tokens BIGINT NOT NULL DEFAULT 0,
+    total_cost DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    actual_cost DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (bucket_date, user_id, tag_key, tag_value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_dashboard_tag_daily_key_date
+    ON usage_dashboard_tag_daily (tag_key, bucket_date);
+CREATE INDEX IF NOT EXISTS idx_usage_dashboard_tag_daily_user_key_date
+    ON usage_dashboard_tag_daily (user_id, tag_key, bucket_date);
+
+COMMENT ON TABLE usage_dashboard_tag_daily IS '按标签键值预聚合的每日用量（成本分摊）';
+COMMENT ON COLUMN usage_dashboard_tag_daily.bucket_date IS '按配置时区划分的日期';
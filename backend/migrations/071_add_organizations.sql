@@ -0,0 +1,51 @@
+-- 组织（团队）：共享余额与订阅，成员分为 owner / admin / member 三种角色
+-- organizations.balance：组织资金池，组织 API Key 的余额模式请求从这里扣费。
+-- 订阅类型分组下，组织 API Key 使用组织所有者（owner_user_id）名下的订阅。
+-- organization_members.spend_limit_usd：成员每自然月（按配置时区）可消耗的上限，为空表示不限制；
+-- spend_used_usd 记录 spend_period_start 所在月份内的已用金额，跨月后在下次扣费时归零。
+-- api_keys.organization_id / usage_logs.organization_id：组织 Key 仍归属创建它的成员（user_id），用量同时归属成员与组织。
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    owner_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    balance DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_organizations_owner_user_id ON organizations (owner_user_id);
+
+COMMENT ON TABLE organizations IS '组织（团队），成员共享余额与订阅';
+COMMENT ON COLUMN organizations.owner_user_id IS '组织所有者，订阅类型分组下组织 Key 使用其名下订阅';
+COMMENT ON COLUMN organizations.balance IS '组织共享余额（USD）';
+COMMENT ON COLUMN organizations.status IS 'active / disabled，禁用后组织 Key 无法发起请求';
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    spend_limit_usd DECIMAL(20, 8),
+    spend_used_usd DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    spend_period_start TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON organization_members (user_id);
+
+COMMENT ON TABLE organization_members IS '组织成员';
+COMMENT ON COLUMN organization_members.role IS 'owner / admin / member';
+COMMENT ON COLUMN organization_members.spend_limit_usd IS '成员每月可消耗组织资源的上限（USD），为空表示不限制';
+COMMENT ON COLUMN organization_members.spend_used_usd IS 'spend_period_start 所在月份内的已用金额';
+COMMENT ON COLUMN organization_members.spend_period_start IS '已用金额对应的月份起点（按配置时区）';
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_api_keys_organization_id ON api_keys (organization_id) WHERE organization_id IS NOT NULL;
+COMMENT ON COLUMN api_keys.organization_id IS '所属组织，非空时请求从组织资金池/所有者订阅扣费';
+
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS organization_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_usage_logs_organization_created_at ON usage_logs (organization_id, created_at) WHERE organization_id IS NOT NULL;
+COMMENT ON COLUMN usage_logs.organization_id IS '组织 Key 产生的用量所属组织';
@@ -0,0 +1,61 @@
+-- 推荐（邀请返利）计划
+-- referral_codes：每个用户一个推荐码（首次访问推荐面板时生成），注册时填写推荐码建立推荐关系。
+-- referrals：被推荐人 -> 推荐人的持久关系，注册时快照返佣比例与返佣截止时间，之后修改配置不影响已有关系。
+-- referral_commissions：返佣台账。首充奖励一条一笔；消费返佣按 (推荐人, 被推荐人, 自然日) 聚合到待审核记录，
+-- 管理员审核通过后才入账到推荐人余额。
+
+CREATE TABLE IF NOT EXISTS referral_codes (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    code VARCHAR(32) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referral_codes_code ON referral_codes (code);
+
+COMMENT ON TABLE referral_codes IS '用户推荐码';
+
+CREATE TABLE IF NOT EXISTS referrals (
+    referee_user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    referrer_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code VARCHAR(32) NOT NULL,
+    commission_percent DECIMAL(10,4) NOT NULL DEFAULT 0,
+    commission_until TIMESTAMPTZ,
+    first_topup_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals (referrer_user_id, created_at DESC);
+
+COMMENT ON TABLE referrals IS '推荐关系（每个被推荐人仅有一个推荐人）';
+COMMENT ON COLUMN referrals.commission_percent IS '建立关系时快照的消费返佣比例（百分比）';
+COMMENT ON COLUMN referrals.commission_until IS '消费返佣截止时间，NULL 表示不返佣';
+COMMENT ON COLUMN referrals.first_topup_at IS '被推荐人首次充值时间（首充奖励只发放一次）';
+
+CREATE TABLE IF NOT EXISTS referral_commissions (
+    id BIGSERIAL PRIMARY KEY,
+    referrer_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    referee_user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    commission_type VARCHAR(20) NOT NULL,
+    source_amount DECIMAL(20,8) NOT NULL DEFAULT 0,
+    amount DECIMAL(20,8) NOT NULL DEFAULT 0,
+    period_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewed_by BIGINT,
+    reviewed_at TIMESTAMPTZ,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 消费返佣按天聚合：同一天只保留一条待审核记录，审核后新的消费会开启新记录
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referral_commissions_pending_spend
+    ON referral_commissions (referrer_user_id, referee_user_id, period_date)
+    WHERE commission_type = 'spend' AND status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_referral_commissions_referrer ON referral_commissions (referrer_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_referral_commissions_status ON referral_commissions (status, created_at DESC);
+
+COMMENT ON TABLE referral_commissions IS '推荐返佣台账（需管理员审核后入账）';
+COMMENT ON COLUMN referral_commissions.commission_type IS 'first_topup: 首充奖励; spend: 消费返佣';
+COMMENT ON COLUMN referral_commissions.source_amount IS '首充金额或被推荐人当日实际消费金额';
+COMMENT ON COLUMN referral_commissions.amount IS '返佣金额（USD）';
+COMMENT ON COLUMN referral_commissions.status IS 'pending / approved / rejected';
@@ -0,0 +1,85 @@
+-- 月度对账单（用户 / 组织）
+-- usage_dashboard_user_daily：按 日期 + 用户 + 组织 + 模型 + 分组 + 计费类型 预聚合，由 DashboardAggregationService 维护，
+--   对账单的用量明细与期末余额推算均基于此表（历史数据可通过 /admin/dashboard/aggregation/backfill 回填）。
+-- organization_balance_logs：组织余额变动流水（成员划转、管理员调整），用于组织对账单的充值统计与余额推算。
+-- statements：已生成的对账单快照，编号按 主体 + 账期 唯一，重新生成时保持编号不变。
+
+CREATE TABLE IF NOT EXISTS usage_dashboard_user_daily (
+    bucket_date DATE NOT NULL,
+    user_id BIGINT NOT NULL,
+    organization_id BIGINT NOT NULL DEFAULT 0,
+    model VARCHAR(100) NOT NULL,
+    group_id BIGINT NOT NULL DEFAULT 0,
+    billing_type SMALLINT NOT NULL DEFAULT 0,
+    total_requests BIGINT NOT NULL DEFAULT 0,
+    input_tokens BIGINT NOT NULL DEFAULT 0,
+    output_tokens BIGINT NOT NULL DEFAULT 0,
+    cache_creation_tokens BIGINT NOT NULL DEFAULT 0,
+    cache_read_tokens BIGINT NOT NULL DEFAULT 0,
+    total_cost DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    actual_cost DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (bucket_date, user_id, organization_id, model, group_id, billing_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_dashboard_user_daily_user_date
+    ON usage_dashboard_user_daily (user_id, bucket_date);
+CREATE INDEX IF NOT EXISTS idx_usage_dashboard_user_daily_org_date
+    ON usage_dashboard_user_daily (organization_id, bucket_date) WHERE organization_id <> 0;
+
+COMMENT ON TABLE usage_dashboard_user_daily IS '按用户/组织、模型、分组预聚合的每日用量（对账单）';
+COMMENT ON COLUMN usage_dashboard_user_daily.bucket_date IS '按配置时区划分的日期';
+COMMENT ON COLUMN usage_dashboard_user_daily.organization_id IS '组织 Key 产生的用量所属组织，个人用量为 0';
+COMMENT ON COLUMN usage_dashboard_user_daily.group_id IS '分组 ID，无分组为 0';
+COMMENT ON COLUMN usage_dashboard_user_daily.billing_type IS '0 余额 / 1 订阅';
+
+CREATE TABLE IF NOT EXISTS organization_balance_logs (
+    id BIGSERIAL PRIMARY KEY,
+    organization_id BIGINT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id BIGINT,
+    type VARCHAR(20) NOT NULL,
+    amount DECIMAL(20, 8) NOT NULL,
+    balance_after DECIMAL(20, 8) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_balance_logs_org_created_at
+    ON organization_balance_logs (organization_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_organization_balance_logs_user_created_at
+    ON organization_balance_logs (user_id, created_at) WHERE user_id IS NOT NULL;
+
+COMMENT ON TABLE organization_balance_logs IS '组织余额变动流水（不含请求扣费）';
+COMMENT ON COLUMN organization_balance_logs.user_id IS '划转资金的成员，管理员调整时为空';
+COMMENT ON COLUMN organization_balance_logs.type IS 'deposit（成员划转）/ admin（管理员调整）';
+COMMENT ON COLUMN organization_balance_logs.amount IS '余额变动金额（USD），扣减为负数';
+
+CREATE TABLE IF NOT EXISTS statements (
+    id BIGSERIAL PRIMARY KEY,
+    number VARCHAR(32) NOT NULL UNIQUE,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id BIGINT NOT NULL,
+    period_start DATE NOT NULL,
+    opening_balance DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    top_ups DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    adjustments DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    balance_usage DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    subscription_usage DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    closing_balance DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    lines JSONB NOT NULL DEFAULT '[]',
+    generated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    emailed_at TIMESTAMPTZ,
+    UNIQUE (subject_type, subject_id, period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_statements_period_start ON statements (period_start);
+
+COMMENT ON TABLE statements IS '月度对账单快照';
+COMMENT ON COLUMN statements.number IS '对账单编号，如 ST-202609-U000042，按主体 + 账期固定';
+COMMENT ON COLUMN statements.subject_type IS 'user / organization';
+COMMENT ON COLUMN statements.period_start IS '账期所在月份第一天（按配置时区）';
+COMMENT ON COLUMN statements.top_ups IS '账期内的充值与入账（兑换码、管理员增加、推荐返佣、成员划转入组织）';
+COMMENT ON COLUMN statements.adjustments IS '账期内的扣减调整（管理员扣减、划转到组织），为负数';
+COMMENT ON COLUMN statements.balance_usage IS '账期内余额模式的实际扣费';
+COMMENT ON COLUMN statements.subscription_usage IS '账期内订阅模式的用量（不影响余额，仅供参考）';
+COMMENT ON COLUMN statements.lines IS '按模型 + 分组 + 计费类型汇总的用量明细';
+COMMENT ON COLUMN statements.emailed_at IS '最近一次加入邮件发送队列的时间';
@@ -0,0 +1,51 @@
+-- 用户提醒：余额不足、Key 额度、订阅窗口用量、订阅 / Key 即将到期
+-- user_notification_settings：用户自定义阈值与投递渠道（邮件 / Webhook），阈值为空表示不提醒该项。
+-- user_notifications：已触发的提醒记录，由后台任务评估写入；
+--   dedup_key = 类型:对象ID:窗口，未解除（resolved_at 为空）时同一 dedup_key 只会触发一次，
+--   条件不再满足（如余额回升、进入新窗口、续期）时标记 resolved_at，下次满足条件时重新提醒。
+
+CREATE TABLE IF NOT EXISTS user_notification_settings (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    webhook_url TEXT,
+    webhook_secret VARCHAR(64),
+    balance_below_usd DECIMAL(20, 8),
+    key_quota_percent INT,
+    subscription_usage_percent INT,
+    expiry_days INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE user_notification_settings IS '用户提醒阈值与投递渠道';
+COMMENT ON COLUMN user_notification_settings.webhook_url IS '用户提供的 Webhook 地址（仅 https），为空表示不推送';
+COMMENT ON COLUMN user_notification_settings.webhook_secret IS 'Webhook 签名密钥，请求头 X-Sub2API-Signature: sha256=HMAC(body)';
+COMMENT ON COLUMN user_notification_settings.balance_below_usd IS '余额低于该值时提醒';
+COMMENT ON COLUMN user_notification_settings.key_quota_percent IS 'API Key 额度使用达到该百分比时提醒';
+COMMENT ON COLUMN user_notification_settings.subscription_usage_percent IS '订阅日/周/月窗口用量达到该百分比时提醒';
+COMMENT ON COLUMN user_notification_settings.expiry_days IS '订阅或 API Key 在该天数内到期时提醒';
+
+CREATE TABLE IF NOT EXISTS user_notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    target_id BIGINT NOT NULL DEFAULT 0,
+    dedup_key VARCHAR(128) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    message TEXT NOT NULL,
+    channels VARCHAR(64) NOT NULL DEFAULT '',
+    delivery_error TEXT,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_notifications_open_dedup
+    ON user_notifications (user_id, dedup_key) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_user_notifications_user_created_at
+    ON user_notifications (user_id, created_at DESC);
+
+COMMENT ON TABLE user_notifications IS '已触发的用户提醒';
+COMMENT ON COLUMN user_notifications.kind IS 'low_balance / key_quota / key_expiring / subscription_usage / subscription_expiring';
+COMMENT ON COLUMN user_notifications.target_id IS '关联对象：API Key ID 或订阅 ID，余额提醒为 0';
+COMMENT ON COLUMN user_notifications.channels IS '已投递的渠道，逗号分隔（email / webhook）';
+COMMENT ON COLUMN user_notifications.resolved_at IS '条件解除时间，解除后同类提醒可再次触发';
@@ -0,0 +1,42 @@
+-- 使用记录批量导出任务（CSV / JSON Lines / Parquet）
+-- 与 usage_cleanup_tasks 相同的任务表模式：创建后由后台执行器抢占执行，
+-- 通过数据库游标流式读取 usage_logs 写入本地文件，完成后生成带有效期的下载令牌。
+
+CREATE TABLE IF NOT EXISTS usage_export_tasks (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(20) NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    filters JSONB NOT NULL,
+    created_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    total_rows BIGINT NOT NULL DEFAULT 0,
+    exported_rows BIGINT NOT NULL DEFAULT 0,
+    file_path TEXT,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    download_token VARCHAR(64) NOT NULL UNIQUE,
+    error_message TEXT,
+    canceled_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    canceled_at TIMESTAMPTZ,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_export_tasks_status_created_at
+    ON usage_export_tasks(status, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_usage_export_tasks_scope_created_by
+    ON usage_export_tasks(scope, created_by, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_usage_export_tasks_expires_at
+    ON usage_export_tasks(expires_at) WHERE file_path IS NOT NULL;
+
+COMMENT ON TABLE usage_export_tasks IS '使用记录批量导出任务';
+COMMENT ON COLUMN usage_export_tasks.scope IS 'admin（管理员导出）/ user（用户导出自己的记录）';
+COMMENT ON COLUMN usage_export_tasks.format IS 'csv / ndjson / parquet';
+COMMENT ON COLUMN usage_export_tasks.filters IS '与使用记录列表接口相同的过滤条件';
+COMMENT ON COLUMN usage_export_tasks.total_rows IS '开始执行时统计的匹配行数，用于展示进度';
+COMMENT ON COLUMN usage_export_tasks.file_path IS '导出文件的本地路径，过期清理后置空';
+COMMENT ON COLUMN usage_export_tasks.download_token IS '下载链接令牌，到达 expires_at 后失效';
@@ -0,0 +1,59 @@
+-- usage_logs 月分区管理与冷归档
+-- 035 只在 usage_logs 已是分区表时预建分区。本迁移新增任务表与归档表，
+-- 由后台维护作业执行在线转换（影子分区表分批复制后原子切换）、预建未来分区、
+-- 过期分区归档（gzip JSON Lines，本地或 S3 兼容存储）后分离删除，以及归档回灌。
+-- 仪表盘预聚合表（usage_dashboard_*）不受影响。
+
+CREATE TABLE IF NOT EXISTS usage_log_partition_tasks (
+    id BIGSERIAL PRIMARY KEY,
+    task_type VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    partition_name VARCHAR(64),
+    archive_id BIGINT,
+    cursor_id BIGINT NOT NULL DEFAULT 0,
+    total_rows BIGINT NOT NULL DEFAULT 0,
+    processed_rows BIGINT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_log_partition_tasks_status_created_at
+    ON usage_log_partition_tasks(status, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_usage_log_partition_tasks_created_at
+    ON usage_log_partition_tasks(created_at DESC);
+
+COMMENT ON TABLE usage_log_partition_tasks IS 'usage_logs 分区维护任务';
+COMMENT ON COLUMN usage_log_partition_tasks.task_type IS 'convert（在线转换为分区表）/ archive（归档并分离分区）/ restore（回灌归档）';
+COMMENT ON COLUMN usage_log_partition_tasks.cursor_id IS '在线转换已复制到的 usage_logs.id，任务让出或重新抢占后从此处继续';
+
+CREATE TABLE IF NOT EXISTS usage_log_archives (
+    id BIGSERIAL PRIMARY KEY,
+    partition_name VARCHAR(64) NOT NULL UNIQUE,
+    range_start TIMESTAMPTZ NOT NULL,
+    range_end TIMESTAMPTZ NOT NULL,
+    row_count BIGINT NOT NULL DEFAULT 0,
+    storage VARCHAR(20) NOT NULL,
+    location TEXT NOT NULL,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    checksum VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    restored_at TIMESTAMPTZ,
+    restored_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_log_archives_range_start
+    ON usage_log_archives(range_start DESC);
+
+COMMENT ON TABLE usage_log_archives IS '已归档的 usage_logs 月分区';
+COMMENT ON COLUMN usage_log_archives.storage IS 'local / s3';
+COMMENT ON COLUMN usage_log_archives.location IS '本地文件路径或对象键';
+COMMENT ON COLUMN usage_log_archives.checksum IS '压缩文件 SHA256，回灌时校验';
+COMMENT ON COLUMN usage_log_archives.status IS 'archived（已分离）/ restored（已回灌，restored_until 后再次分离）';
@@ -0,0 +1,28 @@
+-- ops_system_metrics 增加使用记录异步写入队列指标（usage_write.mode=redis_stream 时采集）
+-- usage_write_backlog: Redis Stream 中尚未落库的记录数（含处理中）
+-- usage_write_dead_letters: 超过最大重试次数后转入死信队列的记录数
+ALTER TABLE ops_system_metrics
+    ADD COLUMN IF NOT EXISTS usage_write_backlog INT,
+    ADD COLUMN IF NOT EXISTS usage_write_dead_letters INT;
+
+-- 使用记录写入积压 (P1)
+INSERT INTO ops_alert_rules (
+    name, description, enabled, metric_type, operator, threshold,
+    window_minutes, sustained_minutes, severity, notify_email, cooldown_minutes,
+    created_at, updated_at
+) VALUES (
+    '使用记录写入积压',
+    '当使用记录写入队列积压超过 50000 条且持续 5 分钟时触发告警（数据库写入能力不足或不可用）',
+    true, 'usage_write_backlog', '>', 50000.0, 5, 5, 'P1', true, 20, NOW(), NOW()
+) ON CONFLICT (name) DO NOTHING;
+
+-- 使用记录进入死信队列 (P1)
+INSERT INTO ops_alert_rules (
+    name, description, enabled, metric_type, operator, threshold,
+    window_minutes, sustained_minutes, severity, notify_email, cooldown_minutes,
+    created_at, updated_at
+) VALUES (
+    '使用记录写入失败',
+    '当存在无法写入的使用记录（死信队列非空）时触发告警，修复后需在管理后台重放',
+    true, 'usage_write_dead_letters', '>', 0.0, 1, 1, 'P1', true, 60, NOW(), NOW()
+) ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,20 @@
+-- usage_logs 计费去重表
+-- usage_logs 转换为分区表后唯一索引必须包含分区键，变为 (request_id, api_key_id, created_at)，
+-- 同一请求以不同 created_at 重复写入时不再冲突。写入使用记录前先在本表（非分区）
+-- 以 (request_id, api_key_id) 为冲突目标登记，登记成功才写入 usage_logs 并扣费。
+-- 记录随 usage_logs 保留期一同清理。
+-- 已有记录不在迁移中回填（大表全量 GROUP BY 会长时间阻塞启动），由分区维护作业创建
+-- dedupe_backfill 任务按 created_at 时间窗分批回填；回填完成前查询同时检查 usage_logs。
+
+CREATE TABLE IF NOT EXISTS usage_log_request_ids (
+    request_id VARCHAR(64) NOT NULL,
+    api_key_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (request_id, api_key_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_log_request_ids_created_at
+    ON usage_log_request_ids (created_at);
+
+COMMENT ON TABLE usage_log_request_ids IS 'usage_logs 的 (request_id, api_key_id) 去重登记，不随 usage_logs 分区';
+COMMENT ON COLUMN usage_log_request_ids.created_at IS '对应使用记录的 created_at，用于按保留期清理';
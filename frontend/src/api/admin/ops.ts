@@ -286,6 +286,9 @@ export interface OpsSystemMetricsSnapshot {
   goroutine_count?: number | null
   concurrency_queue_depth?: number | null
   account_switch_count?: number | null
+
+  usage_write_backlog?: number | null
+  usage_write_dead_letters?: number | null
 }
 
 export interface OpsJobHeartbeat {
@@ -694,6 +697,8 @@ export type MetricType =
   | 'cpu_usage_percent'
   | 'memory_usage_percent'
   | 'concurrency_queue_depth'
+  | 'usage_write_backlog'
+  | 'usage_write_dead_letters'
   | 'group_available_accounts'
   | 'group_available_ratio'
   | 'group_rate_limit_ratio'
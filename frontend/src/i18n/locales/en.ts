@@ -2423,6 +2423,7 @@ export default {
       waiting: 'waiting',
       conns: 'conns',
       queue: 'queue',
+      usageWriteBacklog: 'usage backlog',
       accountSwitches: 'Account switches',
       ok: 'ok',
       lastRun: 'last_run:',
@@ -2839,6 +2840,8 @@ export default {
           cpu: 'CPU Usage (%)',
           memory: 'Memory Usage (%)',
           queueDepth: 'Concurrency Queue Depth',
+          usageWriteBacklog: 'Usage Write Backlog',
+          usageWriteDeadLetters: 'Usage Write Dead Letters',
           groupAvailableAccounts: 'Group Available Accounts',
           groupAvailableRatio: 'Group Available Ratio (%)',
           groupRateLimitRatio: 'Group Rate Limit Ratio (%)',
@@ -2856,6 +2859,8 @@ export default {
           cpu: 'Current instance CPU usage (0-100).',
           memory: 'Current instance memory usage (0-100).',
           queueDepth: 'Concurrency queue depth within the window (queued requests).',
+          usageWriteBacklog: 'Usage records queued in the Redis stream but not yet written to the database (async write mode).',
+          usageWriteDeadLetters: 'Usage records moved to the dead-letter stream after exhausting retries.',
           groupAvailableAccounts: 'Number of available accounts in the selected group (requires group_id).',
           groupAvailableRatio: 'Available account ratio in the selected group (0-100, requires group_id).',
           groupRateLimitRatio: 'Rate-limited account ratio in the selected group (0-100, requires group_id).',
@@ -2590,6 +2590,7 @@ export default {
       waiting: '等待',
       conns: '连接',
       queue: '队列',
+      usageWriteBacklog: '用量积压',
       accountSwitches: '账号切换',
       ok: '正常',
       lastRun: '最近运行',
@@ -3012,6 +3013,8 @@ export default {
           cpu: 'CPU 使用率 (%)',
           memory: '内存使用率 (%)',
           queueDepth: '并发排队深度',
+          usageWriteBacklog: '使用记录写入积压',
+          usageWriteDeadLetters: '使用记录死信数',
           groupAvailableAccounts: '分组可用账号数',
           groupAvailableRatio: '分组可用比例 (%)',
           groupRateLimitRatio: '分组限流比例 (%)',
@@ -3029,6 +3032,8 @@ export default {
           cpu: '当前实例 CPU 使用率（0~100）。',
           memory: '当前实例内存使用率（0~100）。',
           queueDepth: '统计窗口内并发队列排队深度（等待中的请求数）。',
+          usageWriteBacklog: '异步写入模式下尚未落库的使用记录数（Redis Stream 积压）。',
+          usageWriteDeadLetters: '多次重试仍无法写入、已转入死信队列的使用记录数。',
           groupAvailableAccounts: '指定分组中当前可用账号数量（需要 group_id 过滤）。',
           groupAvailableRatio: '指定分组中可用账号占比（0~100，需要 group_id 过滤）。',
           groupRateLimitRatio: '指定分组中账号被限流的比例（0~100，需要 group_id 过滤）。',